@@ -28,15 +28,17 @@ import (
 // (see endpointmanager/pkg/workers) as well as the arguments for the capabilityquerier.QuerierArgs
 // struct that is used when calling capabilityquerier.GetAndSendCapabilityStatement
 type queryArgs struct {
-	workers     *workers.Workers
-	ctx         context.Context
-	client      *http.Client
-	jobDuration time.Duration
-	mq          *lanternmq.MessageQueue
-	ch          *lanternmq.ChannelID
-	qName       string
-	userAgent   string
-	store       *postgresql.Store
+	workers             *workers.Workers
+	ctx                 context.Context
+	client              *http.Client
+	jobDuration         time.Duration
+	mq                  *lanternmq.MessageQueue
+	ch                  *lanternmq.ChannelID
+	qName               string
+	userAgent           string
+	store               *postgresql.Store
+	proposeCanonicalURL bool
+	ipFamilyClients     map[string]*http.Client
 }
 
 // queryEndpointsCapabilityStatement gets an endpoint from the queue message and queries it to get the Capability Statement.
@@ -63,24 +65,29 @@ func queryEndpointsCapabilityStatement(message []byte, args *map[string]interfac
 	exportFileWait := viper.GetInt("exportfile_wait")
 
 	if urlString == "FINISHED" {
-		historypruning.PruneInfoHistory(qa.ctx, qa.store, true)
+		_, err := historypruning.PruneInfoHistory(qa.ctx, qa.store, true, false)
+		if err != nil {
+			log.Warnf("Error pruning the info history: %s", err.Error())
+		}
 		time.Sleep(time.Duration(exportFileWait) * time.Second)
-		err := jsonexport.CreateJSONExport(qa.ctx, qa.store, "/etc/lantern/exportfolder/fhir_endpoints_fields.json")
+		err = jsonexport.CreateJSONExport(qa.ctx, qa.store, "/etc/lantern/exportfolder/fhir_endpoints_fields.json")
 		return err
 	}
 
 	jobArgs := make(map[string]interface{})
 
 	jobArgs["querierArgs"] = capabilityquerier.QuerierArgs{
-		FhirURL:        urlString,
-		RequestVersion: requestVersion,
-		DefaultVersion: defaultVersion,
-		Client:         qa.client,
-		MessageQueue:   qa.mq,
-		ChannelID:      qa.ch,
-		QueueName:      qa.qName,
-		UserAgent:      qa.userAgent,
-		Store:          qa.store,
+		FhirURL:             urlString,
+		RequestVersion:      requestVersion,
+		DefaultVersion:      defaultVersion,
+		Client:              qa.client,
+		MessageQueue:        qa.mq,
+		ChannelID:           qa.ch,
+		QueueName:           qa.qName,
+		UserAgent:           qa.userAgent,
+		Store:               qa.store,
+		ProposeCanonicalURL: qa.proposeCanonicalURL,
+		IPFamilyClients:     qa.ipFamilyClients,
 	}
 
 	job := workers.Job{
@@ -139,7 +146,7 @@ func queryEndpointsVersionsOperation(message []byte, args *map[string]interface{
 	return nil
 }
 
-func setupQueue(store *postgresql.Store, userAgent string, client *http.Client, ctx context.Context, qName string, endptQName string, processFunc lanternmq.MessageHandler) {
+func setupQueue(store *postgresql.Store, userAgent string, client *http.Client, ipFamilyClients map[string]*http.Client, ctx context.Context, qName string, endptQName string, processFunc lanternmq.MessageHandler) {
 	// Set up the queue for sending messages
 	qUser := viper.GetString("quser")
 	qPassword := viper.GetString("qpassword")
@@ -164,15 +171,17 @@ func setupQueue(store *postgresql.Store, userAgent string, client *http.Client,
 
 	args := make(map[string]interface{})
 	args["queryArgs"] = queryArgs{
-		workers:     workers,
-		ctx:         ctx,
-		client:      client,
-		jobDuration: 30 * time.Second,
-		mq:          &mq,
-		ch:          &ch,
-		qName:       qName,
-		userAgent:   userAgent,
-		store:       store,
+		workers:             workers,
+		ctx:                 ctx,
+		client:              client,
+		jobDuration:         30 * time.Second,
+		mq:                  &mq,
+		ch:                  &ch,
+		qName:               qName,
+		userAgent:           userAgent,
+		store:               store,
+		proposeCanonicalURL: viper.GetBool("capquery_propose_canonical_url"),
+		ipFamilyClients:     ipFamilyClients,
 	}
 
 	messages, err := mq.ConsumeFromQueue(ch, endptQName)
@@ -211,13 +220,19 @@ func main() {
 		Timeout: time.Second * 35,
 	}
 
+	// The IP family clients are shared by every capability statement query so that their connections are reused
+	var ipFamilyClients map[string]*http.Client
+	if viper.GetBool("capquery_check_ip_families") {
+		ipFamilyClients = capabilityquerier.NewIPFamilyClients(client)
+	}
+
 	ctx := context.Background()
 
 	versionResponseQName := viper.GetString("versionsquery_response_qname")
 	versionEndptQName := viper.GetString("versionsquery_qname")
-	go setupQueue(store, userAgent, client, ctx, versionResponseQName, versionEndptQName, queryEndpointsVersionsOperation)
+	go setupQueue(store, userAgent, client, nil, ctx, versionResponseQName, versionEndptQName, queryEndpointsVersionsOperation)
 	capQName := viper.GetString("capquery_qname")
 	capQueryEndptQName := viper.GetString("endptinfo_capquery_qname")
-	setupQueue(store, userAgent, client, ctx, capQName, capQueryEndptQName, queryEndpointsCapabilityStatement)
+	setupQueue(store, userAgent, client, ipFamilyClients, ctx, capQName, capQueryEndptQName, queryEndpointsCapabilityStatement)
 
 }
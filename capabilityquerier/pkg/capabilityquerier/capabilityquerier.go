@@ -6,8 +6,10 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math/rand"
+	"net"
 	"net/http"
 	"net/http/httptrace"
 	"net/url"
@@ -15,7 +17,6 @@ import (
 	"time"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
-	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
 	"github.com/onc-healthit/lantern-back-end/lanternmq"
 	aq "github.com/onc-healthit/lantern-back-end/lanternmq/pkg/accessqueue"
 	"github.com/pkg/errors"
@@ -41,20 +42,30 @@ var tlsUnknown = "TLS version unknown"
 var tlsNone = "No TLS"
 
 // Message is the structure that gets sent on the queue with capability statement inforation. It includes the URL of
-// the FHIR API, any errors from making the FHIR API request, the MIME type, the TLS version, and the capability
-// statement itself.
+// the FHIR API, any errors from making the FHIR API request and the category of that error, the MIME type, the TLS version, the redirects followed
+// for the metadata and well-known requests, the time spent in each phase of the metadata request, the negotiated HTTP protocol, the reachability and response time of the endpoint over IPv4 and IPv6,
+// and the capability statement itself.
 type Message struct {
-	URL                  string      `json:"url"`
-	Err                  string      `json:"err"`
-	MIMETypes            []string    `json:"mimeTypes"`
-	TLSVersion           string      `json:"tlsVersion"`
-	HTTPResponse         int         `json:"httpResponse"`
-	CapabilityStatement  interface{} `json:"capabilityStatement"`
-	SMARTHTTPResponse    int         `json:"smarthttpResponse"`
-	SMARTResp            interface{} `json:"smartResp"`
-	ResponseTime         float64     `json:"responseTime"`
-	RequestedFhirVersion string      `json:"requestedFhirVersion"`
-	DefaultFhirVersion   string      `json:"defaultFhirVersion"`
+	URL                  string                           `json:"url"`
+	Err                  string                           `json:"err"`
+	ErrCategory          endpointmanager.ErrorCategory    `json:"errCategory"`
+	MIMETypes            []string                         `json:"mimeTypes"`
+	TLSVersion           string                           `json:"tlsVersion"`
+	HTTPResponse         int                              `json:"httpResponse"`
+	CapabilityStatement  interface{}                      `json:"capabilityStatement"`
+	SMARTHTTPResponse    int                              `json:"smarthttpResponse"`
+	SMARTResp            interface{}                      `json:"smartResp"`
+	ResponseTime         float64                          `json:"responseTime"`
+	RequestedFhirVersion string                           `json:"requestedFhirVersion"`
+	DefaultFhirVersion   string                           `json:"defaultFhirVersion"`
+	Redirects            []endpointmanager.Redirect       `json:"redirects"`
+	SMARTRedirects       []endpointmanager.Redirect       `json:"smartRedirects"`
+	CanonicalURL         string                           `json:"canonicalURL"`
+	LatencyBreakdown     endpointmanager.LatencyBreakdown `json:"latencyBreakdown"`
+	HTTPVersion          string                           `json:"httpVersion"`
+	ALPNProtocol         string                           `json:"alpnProtocol"`
+	IPReachability       map[string]bool                  `json:"ipReachability"`
+	IPResponseTimes      map[string]float64               `json:"ipResponseTimes"`
 }
 
 // VersionMessage is the structure that gets sent on the queue with $versions response inforation. It includes the URL of
@@ -66,17 +77,21 @@ type VersionsMessage struct {
 }
 
 // QuerierArgs is a struct of the queue connection information (MessageQueue, ChannelID, and QueueName) as well as
-// the Client and FhirURL for querying
+// the Client and FhirURL for querying. If ProposeCanonicalURL is set, the final URL of a chain of permanent
+// redirects is proposed as the endpoint's canonical URL. If IPFamilyClients is set, the metadata URL is also
+// requested with each of its clients to record whether the endpoint is reachable over each IP family.
 type QuerierArgs struct {
-	FhirURL        string
-	RequestVersion string
-	DefaultVersion string
-	Client         *http.Client
-	MessageQueue   *lanternmq.MessageQueue
-	ChannelID      *lanternmq.ChannelID
-	QueueName      string
-	UserAgent      string
-	Store          *postgresql.Store
+	FhirURL             string
+	RequestVersion      string
+	DefaultVersion      string
+	Client              *http.Client
+	MessageQueue        *lanternmq.MessageQueue
+	ChannelID           *lanternmq.ChannelID
+	QueueName           string
+	UserAgent           string
+	Store               endpointmanager.EndpointInfoStore
+	ProposeCanonicalURL bool
+	IPFamilyClients     map[string]*http.Client
 }
 
 // GetAndSendVersionsResponse gets a $versions response from a FHIR API endpoint and then puts the versions
@@ -108,16 +123,15 @@ func GetAndSendVersionsResponse(ctx context.Context, args *map[string]interface{
 			log.Errorf("unable to create new GET request from URL: " + versionsURL)
 		} else {
 			req.Header.Set("User-Agent", qa.UserAgent)
-			trace := &httptrace.ClientTrace{}
-			req = req.WithContext(httptrace.WithClientTrace(ctx, trace))
+			req = req.WithContext(ctx)
 
-			httpResponseCode, _, _, versionsResponse, _, err := requestWithMimeType(req, "application/json", qa.Client)
+			versionsResp, err := requestWithMimeType(req, "application/json", qa.Client)
 			// If an error occurs with the version request we still want to proceed with the capability request
 			if err != nil {
 				log.Infof("Error requesting versions response: %s", err.Error())
 			} else {
-				if httpResponseCode == 200 && versionsResponse != nil {
-					err = json.Unmarshal(versionsResponse, &(jsonResponse))
+				if versionsResp.HTTPResponseCode == 200 && versionsResp.Body != nil {
+					err = json.Unmarshal(versionsResp.Body, &(jsonResponse))
 					if err != nil {
 						log.Errorf("Error unmarshalling versions response: %s", err.Error())
 					}
@@ -192,10 +206,22 @@ func GetAndSendCapabilityStatement(ctx context.Context, args *map[string]interfa
 		case <-ctx.Done():
 			log.Warnf("Got error: server could not be reached from URL: %s", qa.FhirURL)
 			message.Err = "server could not be reached from URL: " + metadataURL
+			message.ErrCategory = endpointmanager.Timeout
 		default:
 			log.Warnf("Got error:\n%s\n\nfrom URL: %s", err.Error(), qa.FhirURL)
 			message.Err = err.Error()
+			message.ErrCategory = endpointmanager.ClassifyError(err)
 		}
+	} else {
+		message.ErrCategory = getResponseErrorCategory(&message)
+	}
+
+	if qa.ProposeCanonicalURL {
+		message.CanonicalURL = endpointmanager.CanonicalURL(message.Redirects)
+	}
+
+	if qa.IPFamilyClients != nil {
+		message.IPReachability, message.IPResponseTimes = checkIPFamilies(ctx, metadataURL, qa.IPFamilyClients, userAgent)
 	}
 
 	wellKnownURL := endpointmanager.NormalizeWellKnownURL(castURL.String())
@@ -223,13 +249,10 @@ func GetAndSendCapabilityStatement(ctx context.Context, args *map[string]interfa
 // fills out message with http response code, tls version, capability statement, and supported mime types
 func requestCapabilityStatementAndSmartOnFhir(ctx context.Context, fhirURL string, endptType EndpointType, client *http.Client, userAgent string, message *Message) error {
 	var err error
-	var httpResponseCode int
+	var resp mimeTypeResponse
 	var mimeTypeWorked bool
 	var otherMimeWorked bool
-	var tlsVersion string
-	var capResp []byte
 	var jsonResponse interface{}
-	var responseTime float64
 
 	// Add a short time buffer before sending HTTP request to reduce burden on servers hosting multiple endpoints
 	time.Sleep(time.Duration(500 * time.Millisecond))
@@ -238,8 +261,7 @@ func requestCapabilityStatementAndSmartOnFhir(ctx context.Context, fhirURL strin
 		return errors.Wrap(err, "unable to create new GET request from URL: "+fhirURL)
 	}
 	req.Header.Set("User-Agent", userAgent)
-	trace := &httptrace.ClientTrace{}
-	req = req.WithContext(httptrace.WithClientTrace(ctx, trace))
+	req = req.WithContext(ctx)
 
 	// If there is a requested fhir version, set the fhirVersion in the request header
 	if message.RequestedFhirVersion != "None" {
@@ -259,26 +281,27 @@ func requestCapabilityStatementAndSmartOnFhir(ctx context.Context, fhirURL strin
 		} else {
 			firstMIME = message.MIMETypes[randomMimeIdx]
 		}
-		httpResponseCode, tlsVersion, mimeTypeWorked, capResp, responseTime, err = requestWithMimeType(req, firstMIME, client)
+		resp, err = requestWithMimeType(req, firstMIME, client)
 		if err != nil {
 			return err
 		}
 	} else if endptType == wellknown && len(message.MIMETypes) > 0 {
 		firstMIME = message.MIMETypes[0]
-		httpResponseCode, _, _, capResp, _, err = requestWithMimeType(req, firstMIME, client)
+		resp, err = requestWithMimeType(req, firstMIME, client)
 		if err != nil {
 			return err
 		}
 	} else {
-		httpResponseCode, tlsVersion, mimeTypeWorked, capResp, responseTime, err = requestWithMimeType(req, fhir3PlusJSONMIMEType, client)
+		resp, err = requestWithMimeType(req, fhir3PlusJSONMIMEType, client)
 		if err != nil {
 			return err
 		}
 	}
+	mimeTypeWorked = resp.MIMETypeWorked
 
 	if endptType == metadata {
 		otherMime := fhir2LessJSONMIMEType
-		if httpResponseCode != http.StatusOK || !mimeTypeWorked {
+		if resp.HTTPResponseCode != http.StatusOK || !mimeTypeWorked {
 			// Try the other mime type and remove the mime type that was initially saved
 			// but no longer works
 			if len(message.MIMETypes) == 2 {
@@ -292,22 +315,24 @@ func requestCapabilityStatementAndSmartOnFhir(ctx context.Context, fhirURL strin
 				message.MIMETypes = []string{}
 			}
 			// replace all values based on the other mime type if there were any issues with the first mime type request
-			httpResponseCode, tlsVersion, otherMimeWorked, capResp, responseTime, err = requestWithMimeType(req, otherMime, client)
+			resp, err = requestWithMimeType(req, otherMime, client)
 			if err != nil {
 				return err
 			}
+			otherMimeWorked = resp.MIMETypeWorked
 		} else if len(message.MIMETypes) == 0 {
 			// only check fhir 2 mime type support if the first request worked and there were no
 			// mimeTypes saved in the database
-			_, _, otherMimeWorked, _, _, err = requestWithMimeType(req, otherMime, client)
+			otherResp, err := requestWithMimeType(req, otherMime, client)
 			if err != nil {
 				return err
 			}
+			otherMimeWorked = otherResp.MIMETypeWorked
 		}
 
 		finalMimeList := []string{}
 		// If there was a 2nd saved mime type and it also did not work, remove it from the MIMETypes array
-		if len(message.MIMETypes) == 1 && (httpResponseCode != http.StatusOK || !otherMimeWorked) {
+		if len(message.MIMETypes) == 1 && (resp.HTTPResponseCode != http.StatusOK || !otherMimeWorked) {
 			message.MIMETypes = []string{}
 		} else if otherMimeWorked {
 			// If the 2nd tried mime type did work, add it to the MIMETypes array
@@ -323,8 +348,8 @@ func requestCapabilityStatementAndSmartOnFhir(ctx context.Context, fhirURL strin
 		}
 	}
 
-	if capResp != nil {
-		err = json.Unmarshal(capResp, &(jsonResponse))
+	if resp.Body != nil {
+		err = json.Unmarshal(resp.Body, &(jsonResponse))
 		if err != nil {
 			return err
 		}
@@ -332,18 +357,102 @@ func requestCapabilityStatementAndSmartOnFhir(ctx context.Context, fhirURL strin
 
 	switch endptType {
 	case metadata:
-		message.TLSVersion = tlsVersion
-		message.HTTPResponse = httpResponseCode
+		message.TLSVersion = resp.TLSVersion
+		message.HTTPResponse = resp.HTTPResponseCode
 		message.CapabilityStatement = jsonResponse
-		message.ResponseTime = responseTime
+		message.ResponseTime = resp.ResponseTime
+		message.Redirects = resp.Redirects
+		message.LatencyBreakdown = resp.Latency
+		message.HTTPVersion = resp.Protocol.HTTPVersion
+		message.ALPNProtocol = resp.Protocol.ALPNProtocol
 	case wellknown:
-		message.SMARTHTTPResponse = httpResponseCode
+		message.SMARTHTTPResponse = resp.HTTPResponseCode
 		message.SMARTResp = jsonResponse
+		message.SMARTRedirects = resp.Redirects
 	}
 
 	return nil
 }
 
+// getResponseErrorCategory classifies a metadata request that completed without error but did not return
+// a capability statement.
+func getResponseErrorCategory(message *Message) endpointmanager.ErrorCategory {
+	if category := endpointmanager.ClassifyHTTPStatus(message.HTTPResponse); category != endpointmanager.NoError {
+		return category
+	}
+	if message.HTTPResponse != http.StatusOK {
+		return endpointmanager.NoError
+	}
+	// the response body is only read when the response has a JSON mime type
+	if message.CapabilityStatement == nil {
+		return endpointmanager.NonJSONBody
+	}
+	return endpointmanager.ClassifyFHIRResource(message.CapabilityStatement, "CapabilityStatement", "Conformance")
+}
+
+// NewIPFamilyClients returns a copy of the given client for IPv4 and for IPv6, keyed by IP family, for checking
+// which IP families endpoints are reachable over. The clients are meant to be made once and shared by every
+// query so that their idle connections are reused rather than left open by a new transport per request.
+func NewIPFamilyClients(client *http.Client) map[string]*http.Client {
+	return map[string]*http.Client{
+		endpointmanager.IPv4Family: ipFamilyClient(client, "tcp4"),
+		endpointmanager.IPv6Family: ipFamilyClient(client, "tcp6"),
+	}
+}
+
+// checkIPFamilies requests the given metadata URL with each of the IP family clients made by NewIPFamilyClients
+// and returns whether the endpoint was reachable over each IP family along with the response time over each
+// family that was reachable.
+func checkIPFamilies(ctx context.Context, metadataURL string, clients map[string]*http.Client, userAgent string) (map[string]bool, map[string]float64) {
+	reachability := make(map[string]bool)
+	responseTimes := make(map[string]float64)
+
+	for family, client := range clients {
+		req, err := http.NewRequest("GET", metadataURL, nil)
+		if err != nil {
+			log.Warnf("unable to create new GET request from URL: %s", metadataURL)
+			return nil, nil
+		}
+		req.Header.Set("User-Agent", userAgent)
+		req = req.WithContext(ctx)
+
+		resp, err := requestWithMimeType(req, fhir3PlusJSONMIMEType, client)
+		if err != nil {
+			log.Infof("%s is not reachable over %s: %s", metadataURL, family, err.Error())
+			reachability[family] = false
+			continue
+		}
+		reachability[family] = true
+		responseTimes[family] = resp.ResponseTime
+	}
+
+	return reachability, responseTimes
+}
+
+// ipFamilyClient returns a copy of the given client that only dials connections using the given network,
+// which is either "tcp4" or "tcp6". Connections are not shared with the original client so that a
+// connection made over the other IP family is not reused.
+func ipFamilyClient(client *http.Client, network string) *http.Client {
+	var transport *http.Transport
+	if t, ok := client.Transport.(*http.Transport); ok && t != nil {
+		transport = t.Clone()
+	} else {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+
+	dialContext := transport.DialContext
+	if dialContext == nil {
+		dialContext = (&net.Dialer{}).DialContext
+	}
+	transport.DialContext = func(ctx context.Context, _ string, addr string) (net.Conn, error) {
+		return dialContext(ctx, network, addr)
+	}
+
+	familyClient := *client
+	familyClient.Transport = transport
+	return &familyClient
+}
+
 func getTLSVersion(resp *http.Response) string {
 	if resp.TLS != nil {
 		switch resp.TLS.Version {
@@ -364,6 +473,46 @@ func getTLSVersion(resp *http.Response) string {
 	return tlsNone
 }
 
+// protocolInfo is the HTTP protocol negotiated with a server.
+type protocolInfo struct {
+	HTTPVersion  string
+	ALPNProtocol string
+}
+
+// getProtocolInfo returns the HTTP version of the response and the application protocol negotiated
+// using ALPN during the TLS handshake, which is empty for plain HTTP connections or servers that do
+// not support ALPN.
+func getProtocolInfo(resp *http.Response) protocolInfo {
+	protocol := protocolInfo{
+		HTTPVersion: resp.Proto,
+	}
+	if resp.TLS != nil {
+		protocol.ALPNProtocol = resp.TLS.NegotiatedProtocol
+	}
+	return protocol
+}
+
+// getRedirects walks back from the final response through the responses that caused each redirect
+// and returns the redirects in the order they were followed.
+func getRedirects(resp *http.Response) []endpointmanager.Redirect {
+	var redirects []endpointmanager.Redirect
+
+	for prev := resp.Request.Response; prev != nil; prev = prev.Request.Response {
+		redirect := endpointmanager.Redirect{
+			StatusCode: prev.StatusCode,
+		}
+		location, err := prev.Location()
+		if err == nil {
+			redirect.Location = location.String()
+		} else {
+			redirect.Location = prev.Header.Get("Location")
+		}
+		redirects = append([]endpointmanager.Redirect{redirect}, redirects...)
+	}
+
+	return redirects
+}
+
 func isJSONMIMEType(mimeType string) bool {
 	return strings.Contains(mimeType, "json")
 }
@@ -378,49 +527,66 @@ func mimeTypesMatch(reqMimeType string, respMimeType string) bool {
 	return false
 }
 
-// responds with
-//   http status code
-//   tls version
-//   mime type match
-//   capability statement
-//   error
-func requestWithMimeType(req *http.Request, mimeType string, client *http.Client) (int, string, bool, []byte, float64, error) {
-	var httpResponseCode int
-	var tlsVersion string
-	var capStat []byte
+// mimeTypeResponse is the result of requesting a URL with a given mime type.
+type mimeTypeResponse struct {
+	HTTPResponseCode int
+	TLSVersion       string
+	// MIMETypeWorked is whether the response had a JSON mime type
+	MIMETypeWorked bool
+	// Body is only read when MIMETypeWorked is true
+	Body         []byte
+	ResponseTime float64
+	Redirects    []endpointmanager.Redirect
+	Latency      endpointmanager.LatencyBreakdown
+	Protocol     protocolInfo
+}
 
-	mimeMatches := false
+// requestWithMimeType makes the given request accepting the given mime type and returns the response's
+// status code, TLS version, body, timing, redirects and negotiated protocol.
+func requestWithMimeType(req *http.Request, mimeType string, client *http.Client) (mimeTypeResponse, error) {
+	var result mimeTypeResponse
 
 	req.Header.Set("Accept", mimeType)
 
+	tracer := &latencyTracer{}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), tracer.clientTrace()))
+
 	start := time.Now()
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return -1, "", false, nil, -1, errors.Wrapf(err, "making the GET request to %s failed", req.URL.String())
+		return mimeTypeResponse{}, errors.Wrapf(err, "making the GET request to %s failed", req.URL.String())
 	}
+	defer resp.Body.Close()
 
-	var responseTime = float64(time.Since(start).Seconds())
+	result.ResponseTime = float64(time.Since(start).Seconds())
 
-	httpResponseCode = resp.StatusCode
-	if httpResponseCode == http.StatusOK {
+	result.HTTPResponseCode = resp.StatusCode
+	if result.HTTPResponseCode == http.StatusOK {
 		respMimeType := resp.Header.Get("Content-Type")
 		// endpoints generally return an xml mime type by default.
 		// checking that it's a json mime type confirms that it processes the JSON type request.
 		// however, it doesn't necessarily match the request type exactly and seems to cache the
 		// first JSON request type it receives and continues to respond with that.
 		if isJSONMIMEType(respMimeType) {
-			defer resp.Body.Close()
-			mimeMatches = true
+			result.MIMETypeWorked = true
 
-			capStat, err = ioutil.ReadAll(resp.Body)
+			result.Body, err = ioutil.ReadAll(resp.Body)
+			tracer.bodyRead()
 			if err != nil {
-				return -1, "", false, nil, -1, errors.Wrapf(err, "reading the response from %s failed", req.URL.String())
+				return mimeTypeResponse{}, errors.Wrapf(err, "reading the response from %s failed", req.URL.String())
 			}
 		}
 	}
+	if !result.MIMETypeWorked {
+		// drain the unread body so the connection can be reused by the transport
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+	}
 
-	tlsVersion = getTLSVersion(resp)
+	result.TLSVersion = getTLSVersion(resp)
+	result.Redirects = getRedirects(resp)
+	result.Latency = tracer.breakdown()
+	result.Protocol = getProtocolInfo(resp)
 
-	return httpResponseCode, tlsVersion, mimeMatches, capStat, responseTime, nil
+	return result, nil
 }
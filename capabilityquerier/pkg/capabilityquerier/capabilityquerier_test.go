@@ -319,12 +319,12 @@ func Test_requestWithMimeType(t *testing.T) {
 	th.Assert(t, err == nil, err)
 	defer tc.Close()
 
-	httpCode, tlsVersion, mimeMatch, capStat, _, err := requestWithMimeType(req, fhir2LessJSONMIMEType, &(tc.Client))
+	resp, err := requestWithMimeType(req, fhir2LessJSONMIMEType, &(tc.Client))
 	th.Assert(t, err == nil, err)
-	th.Assert(t, httpCode == 200, "expected 200 response")
-	th.Assert(t, tlsVersion == "TLS 1.0", fmt.Sprintf("expected TLS 1.0. got %s", tlsVersion))
-	th.Assert(t, mimeMatch, "expected the mime types to match")
-	th.Assert(t, capStat != nil, "expected to receive a capability statement")
+	th.Assert(t, resp.HTTPResponseCode == 200, "expected 200 response")
+	th.Assert(t, resp.TLSVersion == "TLS 1.0", fmt.Sprintf("expected TLS 1.0. got %s", resp.TLSVersion))
+	th.Assert(t, resp.MIMETypeWorked, "expected the mime types to match")
+	th.Assert(t, resp.Body != nil, "expected to receive a capability statement")
 
 	// test http request error
 
@@ -332,7 +332,7 @@ func Test_requestWithMimeType(t *testing.T) {
 	th.Assert(t, err == nil, err)
 	tc.Close() // makes request fail
 
-	_, _, _, _, _, err = requestWithMimeType(req, fhir2LessJSONMIMEType, &(tc.Client))
+	_, err = requestWithMimeType(req, fhir2LessJSONMIMEType, &(tc.Client))
 	switch errors.Cause(err).(type) {
 	case *url.Error:
 		// expect url.Error because we closed the connection that we're querying.
@@ -344,9 +344,141 @@ func Test_requestWithMimeType(t *testing.T) {
 	tc = th.NewTestClientWith404()
 	defer tc.Close()
 
-	httpCode, _, _, _, _, err = requestWithMimeType(req, fhir2LessJSONMIMEType, &(tc.Client))
+	resp, err = requestWithMimeType(req, fhir2LessJSONMIMEType, &(tc.Client))
 	th.Assert(t, err == nil, err)
-	th.Assert(t, httpCode == 404, fmt.Sprintf("expected 404 response code. Got %d", httpCode))
+	th.Assert(t, resp.HTTPResponseCode == 404, fmt.Sprintf("expected 404 response code. Got %d", resp.HTTPResponseCode))
+}
+
+func Test_getRedirects(t *testing.T) {
+	path := filepath.Join("testdata", "metadata.json")
+	okResponse, err := ioutil.ReadFile(path)
+	th.Assert(t, err == nil, err)
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old/metadata":
+			http.Redirect(w, r, "/temp/metadata", http.StatusFound)
+		case "/temp/metadata":
+			http.Redirect(w, r, "http://example.com/new/metadata", http.StatusMovedPermanently)
+		default:
+			w.Header().Set("Content-Type", fhir3PlusJSONMIMEType+"; charset=utf-8")
+			_, _ = w.Write(okResponse)
+		}
+	})
+	tc := th.NewTestClientNoTLS(h)
+	defer tc.Close()
+
+	// redirect chain is recorded in the order it was followed
+
+	req, err := http.NewRequest("GET", "http://example.com/old/metadata", nil)
+	th.Assert(t, err == nil, err)
+
+	resp, err := requestWithMimeType(req, fhir3PlusJSONMIMEType, &(tc.Client))
+	th.Assert(t, err == nil, err)
+	th.Assert(t, resp.HTTPResponseCode == 200, fmt.Sprintf("expected 200 response. Got %d", resp.HTTPResponseCode))
+	redirects := resp.Redirects
+	th.Assert(t, len(redirects) == 2, fmt.Sprintf("expected two redirects. Got %d", len(redirects)))
+	th.Assert(t, redirects[0].StatusCode == http.StatusFound, fmt.Sprintf("expected first redirect to be a 302. Got %d", redirects[0].StatusCode))
+	th.Assert(t, redirects[0].Location == "http://example.com/temp/metadata", fmt.Sprintf("expected relative location to be resolved. Got %s", redirects[0].Location))
+	th.Assert(t, redirects[1].StatusCode == http.StatusMovedPermanently, fmt.Sprintf("expected second redirect to be a 301. Got %d", redirects[1].StatusCode))
+	th.Assert(t, redirects[1].Location == "http://example.com/new/metadata", fmt.Sprintf("expected location http://example.com/new/metadata. Got %s", redirects[1].Location))
+
+	// no redirects
+
+	req, err = http.NewRequest("GET", "http://example.com/new/metadata", nil)
+	th.Assert(t, err == nil, err)
+
+	resp, err = requestWithMimeType(req, fhir3PlusJSONMIMEType, &(tc.Client))
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(resp.Redirects) == 0, fmt.Sprintf("expected no redirects. Got %d", len(resp.Redirects)))
+}
+
+func Test_getProtocolInfo(t *testing.T) {
+	tc, err := testClientWithNoTLS()
+	th.Assert(t, err == nil, err)
+	defer tc.Close()
+
+	req, err := http.NewRequest("GET", sampleURLNoTLS, nil)
+	th.Assert(t, err == nil, err)
+
+	mimeResp, err := requestWithMimeType(req, fhir2LessJSONMIMEType, &(tc.Client))
+	th.Assert(t, err == nil, err)
+	protocol := mimeResp.Protocol
+	th.Assert(t, protocol.HTTPVersion == "HTTP/1.1", fmt.Sprintf("expected HTTP/1.1. Got %s", protocol.HTTPVersion))
+
+	// the negotiated application protocol is taken from the TLS connection state
+
+	resp := &http.Response{
+		Proto: "HTTP/2.0",
+		TLS:   &tls.ConnectionState{NegotiatedProtocol: "h2"},
+	}
+	protocol = getProtocolInfo(resp)
+	th.Assert(t, protocol.HTTPVersion == "HTTP/2.0", fmt.Sprintf("expected HTTP/2.0. Got %s", protocol.HTTPVersion))
+	th.Assert(t, protocol.ALPNProtocol == "h2", fmt.Sprintf("expected h2. Got %s", protocol.ALPNProtocol))
+
+	resp = &http.Response{Proto: "HTTP/1.0"}
+	protocol = getProtocolInfo(resp)
+	th.Assert(t, protocol.HTTPVersion == "HTTP/1.0", fmt.Sprintf("expected HTTP/1.0. Got %s", protocol.HTTPVersion))
+	th.Assert(t, protocol.ALPNProtocol == "", fmt.Sprintf("expected no ALPN protocol without TLS. Got %s", protocol.ALPNProtocol))
+}
+
+func Test_checkIPFamilies(t *testing.T) {
+	path := filepath.Join("testdata", "metadata.json")
+	okResponse, err := ioutil.ReadFile(path)
+	th.Assert(t, err == nil, err)
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", fhir3PlusJSONMIMEType+"; charset=utf-8")
+		_, _ = w.Write(okResponse)
+	})
+	// the test server only listens on an IPv4 loopback address
+	tc := th.NewTestClientNoTLS(h)
+	defer tc.Close()
+
+	clients := NewIPFamilyClients(&(tc.Client))
+	th.Assert(t, len(clients) == 2, fmt.Sprintf("expected a client for each IP family. Got %d", len(clients)))
+	reachability, responseTimes := checkIPFamilies(context.Background(), "http://example.com/metadata", clients, "")
+	th.Assert(t, reachability[endpointmanager.IPv4Family], "expected the endpoint to be reachable over IPv4")
+	th.Assert(t, !reachability[endpointmanager.IPv6Family], "did not expect the endpoint to be reachable over IPv6")
+	_, ok := reachability[endpointmanager.IPv6Family]
+	th.Assert(t, ok, "expected IPv6 reachability to be recorded")
+	th.Assert(t, responseTimes[endpointmanager.IPv4Family] >= 0, "expected an IPv4 response time")
+	_, ok = responseTimes[endpointmanager.IPv6Family]
+	th.Assert(t, !ok, "did not expect an IPv6 response time")
+
+	// the original client is not modified
+	_, isTransport := tc.Client.Transport.(*http.Transport)
+	th.Assert(t, isTransport, "expected the original client's transport to be unchanged")
+}
+
+func Test_getResponseErrorCategory(t *testing.T) {
+	message := Message{HTTPResponse: 200, CapabilityStatement: map[string]interface{}{"resourceType": "CapabilityStatement"}}
+	category := getResponseErrorCategory(&message)
+	th.Assert(t, category == endpointmanager.NoError, fmt.Sprintf("expected no error category. Got %s", category))
+
+	message.CapabilityStatement = map[string]interface{}{"resourceType": "Conformance"}
+	category = getResponseErrorCategory(&message)
+	th.Assert(t, category == endpointmanager.NoError, fmt.Sprintf("expected no error category for a DSTU2 conformance statement. Got %s", category))
+
+	message.CapabilityStatement = map[string]interface{}{"resourceType": "OperationOutcome"}
+	category = getResponseErrorCategory(&message)
+	th.Assert(t, category == endpointmanager.WrongResourceType, fmt.Sprintf("expected %s. Got %s", endpointmanager.WrongResourceType, category))
+
+	message.CapabilityStatement = []interface{}{}
+	category = getResponseErrorCategory(&message)
+	th.Assert(t, category == endpointmanager.InvalidFHIRJSON, fmt.Sprintf("expected %s. Got %s", endpointmanager.InvalidFHIRJSON, category))
+
+	message.CapabilityStatement = nil
+	category = getResponseErrorCategory(&message)
+	th.Assert(t, category == endpointmanager.NonJSONBody, fmt.Sprintf("expected %s. Got %s", endpointmanager.NonJSONBody, category))
+
+	message.HTTPResponse = 404
+	category = getResponseErrorCategory(&message)
+	th.Assert(t, category == endpointmanager.HTTPClientError, fmt.Sprintf("expected %s. Got %s", endpointmanager.HTTPClientError, category))
+
+	message.HTTPResponse = 503
+	category = getResponseErrorCategory(&message)
+	th.Assert(t, category == endpointmanager.HTTPServerError, fmt.Sprintf("expected %s. Got %s", endpointmanager.HTTPServerError, category))
 }
 
 func basicTestClient() (*th.TestClient, error) {
@@ -0,0 +1,103 @@
+package capabilityquerier
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+)
+
+// latencyTracer records when each phase of a request starts and ends using the hooks of an
+// httptrace.ClientTrace. When redirects are followed, only the phases of the last request in the
+// redirect chain are kept.
+type latencyTracer struct {
+	mu     sync.Mutex
+	phases requestPhases
+}
+
+type requestPhases struct {
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	bodyDone     time.Time
+}
+
+func (lt *latencyTracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			lt.mu.Lock()
+			defer lt.mu.Unlock()
+			lt.phases = requestPhases{}
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			lt.record(&lt.phases.dnsStart)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			lt.record(&lt.phases.dnsDone)
+		},
+		ConnectStart: func(string, string) {
+			lt.mu.Lock()
+			defer lt.mu.Unlock()
+			// several addresses may be dialed for the same connection, so keep the first start
+			if lt.phases.connectStart.IsZero() {
+				lt.phases.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(_ string, _ string, err error) {
+			if err == nil {
+				lt.record(&lt.phases.connectDone)
+			}
+		},
+		TLSHandshakeStart: func() {
+			lt.record(&lt.phases.tlsStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			lt.record(&lt.phases.tlsDone)
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			lt.record(&lt.phases.wroteRequest)
+		},
+		GotFirstResponseByte: func() {
+			lt.record(&lt.phases.firstByte)
+		},
+	}
+}
+
+func (lt *latencyTracer) record(t *time.Time) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	*t = time.Now()
+}
+
+// bodyRead records that the response body has been read.
+func (lt *latencyTracer) bodyRead() {
+	lt.record(&lt.phases.bodyDone)
+}
+
+// breakdown returns the time spent in each phase of the request in seconds. Phases that did not
+// happen, such as the DNS lookup when a connection was reused, are 0.
+func (lt *latencyTracer) breakdown() endpointmanager.LatencyBreakdown {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	return endpointmanager.LatencyBreakdown{
+		DNSLookup:       phaseSeconds(lt.phases.dnsStart, lt.phases.dnsDone),
+		TCPConnect:      phaseSeconds(lt.phases.connectStart, lt.phases.connectDone),
+		TLSHandshake:    phaseSeconds(lt.phases.tlsStart, lt.phases.tlsDone),
+		TimeToFirstByte: phaseSeconds(lt.phases.wroteRequest, lt.phases.firstByte),
+		BodyTransfer:    phaseSeconds(lt.phases.firstByte, lt.phases.bodyDone),
+	}
+}
+
+func phaseSeconds(start time.Time, end time.Time) float64 {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start).Seconds()
+}
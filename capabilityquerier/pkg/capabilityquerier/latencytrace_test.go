@@ -0,0 +1,52 @@
+package capabilityquerier
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+)
+
+func Test_latencyTracer(t *testing.T) {
+	path := filepath.Join("testdata", "metadata.json")
+	okResponse, err := ioutil.ReadFile(path)
+	th.Assert(t, err == nil, err)
+
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old/metadata" {
+			http.Redirect(w, r, "/new/metadata", http.StatusMovedPermanently)
+			return
+		}
+		// simulate a slow server
+		time.Sleep(50 * time.Millisecond)
+		w.Header().Set("Content-Type", fhir3PlusJSONMIMEType+"; charset=utf-8")
+		_, _ = w.Write(okResponse)
+	})
+	tc := th.NewTestClientNoTLS(h)
+	defer tc.Close()
+
+	req, err := http.NewRequest("GET", "http://example.com/old/metadata", nil)
+	th.Assert(t, err == nil, err)
+
+	resp, err := requestWithMimeType(req, fhir3PlusJSONMIMEType, &(tc.Client))
+	th.Assert(t, err == nil, err)
+	latency := resp.Latency
+	responseTime := resp.ResponseTime
+	th.Assert(t, latency.TimeToFirstByte >= 0.05, fmt.Sprintf("expected the time to first byte to include the server's processing time. Got %f", latency.TimeToFirstByte))
+	th.Assert(t, latency.TimeToFirstByte <= responseTime, fmt.Sprintf("expected the time to first byte %f to be less than the response time %f", latency.TimeToFirstByte, responseTime))
+	th.Assert(t, latency.BodyTransfer >= 0, fmt.Sprintf("expected a body transfer time. Got %f", latency.BodyTransfer))
+	// plain HTTP requests do not perform a TLS handshake
+	th.Assert(t, latency.TLSHandshake == 0, fmt.Sprintf("expected no TLS handshake time. Got %f", latency.TLSHandshake))
+
+	// phases that did not happen are 0
+
+	start := time.Now()
+	th.Assert(t, phaseSeconds(time.Time{}, start) == 0, "expected a phase with no start to be 0")
+	th.Assert(t, phaseSeconds(start, time.Time{}) == 0, "expected a phase with no end to be 0")
+	th.Assert(t, phaseSeconds(start.Add(time.Second), start) == 0, "expected a phase that ended before it started to be 0")
+	th.Assert(t, phaseSeconds(start, start.Add(time.Second)) == 1, "expected a one second phase")
+}
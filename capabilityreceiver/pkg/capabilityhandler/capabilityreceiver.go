@@ -131,6 +131,89 @@ func formatMessage(message []byte) (*endpointmanager.FHIREndpointInfo, *endpoint
 		return nil, nil, fmt.Errorf("response time is not a float")
 	}
 
+	redirects, err := parseRedirects(msgJSON["redirects"])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", url, err)
+	}
+
+	smartRedirects, err := parseRedirects(msgJSON["smartRedirects"])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", url, err)
+	}
+
+	latencyBreakdown, err := parseLatencyBreakdown(msgJSON["latencyBreakdown"])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", url, err)
+	}
+
+	// canonicalURL is only included in the message when the querier is proposing canonical URLs
+	var canonicalURL string
+	if msgJSON["canonicalURL"] != nil {
+		canonicalURL, ok = msgJSON["canonicalURL"].(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s: unable to cast canonical URL to string", url)
+		}
+	}
+
+	// errCategory is not included in messages from queriers that predate error classification
+	var errCategory string
+	if msgJSON["errCategory"] != nil {
+		errCategory, ok = msgJSON["errCategory"].(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s: unable to cast error category to string", url)
+		}
+	}
+
+	// the negotiated protocol is not included in messages from queriers that predate protocol detection
+	var httpVersion string
+	if msgJSON["httpVersion"] != nil {
+		httpVersion, ok = msgJSON["httpVersion"].(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s: unable to cast HTTP version to string", url)
+		}
+	}
+
+	var alpnProtocol string
+	if msgJSON["alpnProtocol"] != nil {
+		alpnProtocol, ok = msgJSON["alpnProtocol"].(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s: unable to cast ALPN protocol to string", url)
+		}
+	}
+
+	// ipReachability and ipResponseTimes are only included in the message when the querier is checking IP families
+	var ipReachability map[string]bool
+	if msgJSON["ipReachability"] != nil {
+		reachabilityInt, ok := msgJSON["ipReachability"].(map[string]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("%s: unable to cast IP reachability to map[string]interface{}", url)
+		}
+		ipReachability = make(map[string]bool)
+		for family, reachableInt := range reachabilityInt {
+			reachable, ok := reachableInt.(bool)
+			if !ok {
+				return nil, nil, fmt.Errorf("%s: unable to cast %s reachability to bool", url, family)
+			}
+			ipReachability[family] = reachable
+		}
+	}
+
+	var ipResponseTimes map[string]float64
+	if msgJSON["ipResponseTimes"] != nil {
+		responseTimesInt, ok := msgJSON["ipResponseTimes"].(map[string]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("%s: unable to cast IP response times to map[string]interface{}", url)
+		}
+		ipResponseTimes = make(map[string]float64)
+		for family, responseTimeInt := range responseTimesInt {
+			ipResponseTime, ok := responseTimeInt.(float64)
+			if !ok {
+				return nil, nil, fmt.Errorf("%s: unable to cast %s response time to float", url, family)
+			}
+			ipResponseTimes[family] = ipResponseTime
+		}
+	}
+
 	fhirVersion := ""
 	if capStat != nil {
 		fhirVersion, _ = capStat.GetFHIRVersion()
@@ -138,7 +221,7 @@ func formatMessage(message []byte) (*endpointmanager.FHIREndpointInfo, *endpoint
 
 	validator := validation.ValidatorForFHIRVersion(fhirVersion)
 
-	validationObj := validator.RunValidation(capStat, mimeTypes, fhirVersion, tlsVersion, smartResponse, requestedFhirVersion, defaultFhirVersion)
+	validationObj := validator.RunValidation(capStat, mimeTypes, fhirVersion, tlsVersion, smartResponse, requestedFhirVersion, defaultFhirVersion, httpVersion)
 	includedFields := RunIncludedFieldsAndExtensionsChecks(capInt, fhirVersion)
 	operationResource := RunSupportedResourcesChecks(capInt)
 
@@ -146,9 +229,16 @@ func formatMessage(message []byte) (*endpointmanager.FHIREndpointInfo, *endpoint
 		URL:                  url,
 		HTTPResponse:         httpResponse,
 		Errors:               errs,
+		ErrorCategory:        endpointmanager.ErrorCategory(errCategory),
 		SMARTHTTPResponse:    smarthttpResponse,
 		ResponseTime:         responseTime,
 		RequestedFhirVersion: requestedFhirVersion,
+		Redirects:            redirects,
+		SMARTRedirects:       smartRedirects,
+		PermanentRedirect:    endpointmanager.HasPermanentRedirect(redirects) || endpointmanager.HasPermanentRedirect(smartRedirects),
+		CanonicalURL:         canonicalURL,
+		IPResponseTimes:      ipResponseTimes,
+		LatencyBreakdown:     latencyBreakdown,
 	}
 
 	fhirEndpoint := endpointmanager.FHIREndpointInfo{
@@ -162,11 +252,83 @@ func formatMessage(message []byte) (*endpointmanager.FHIREndpointInfo, *endpoint
 		Metadata:              FHIREndpointMetadata,
 		RequestedFhirVersion:  requestedFhirVersion,
 		CapabilityFhirVersion: fhirVersion,
+		HTTPVersion:           httpVersion,
+		ALPNProtocol:          alpnProtocol,
+		IPReachability:        ipReachability,
 	}
 
 	return &fhirEndpoint, &validationObj, nil
 }
 
+// parseRedirects converts the redirect chain in the queue message into a list of Redirects. A missing
+// redirect chain is treated as no redirects having been followed.
+func parseRedirects(redirectsInt interface{}) ([]endpointmanager.Redirect, error) {
+	var redirects []endpointmanager.Redirect
+	if redirectsInt == nil {
+		return redirects, nil
+	}
+
+	redirectList, ok := redirectsInt.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unable to cast redirects to []interface{}")
+	}
+	for _, redirectInt := range redirectList {
+		redirectMap, ok := redirectInt.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unable to cast redirect to map[string]interface{}")
+		}
+		// JSON numbers are golang float64s
+		statusCode, ok := redirectMap["statusCode"].(float64)
+		if !ok {
+			return nil, fmt.Errorf("unable to cast redirect status code to int")
+		}
+		location, ok := redirectMap["location"].(string)
+		if !ok {
+			return nil, fmt.Errorf("unable to cast redirect location to string")
+		}
+		redirects = append(redirects, endpointmanager.Redirect{
+			StatusCode: int(statusCode),
+			Location:   location,
+		})
+	}
+
+	return redirects, nil
+}
+
+// parseLatencyBreakdown converts the time spent in each phase of the metadata request in the queue message
+// into a LatencyBreakdown. A missing breakdown is treated as no phases having been timed.
+func parseLatencyBreakdown(latencyInt interface{}) (endpointmanager.LatencyBreakdown, error) {
+	var latency endpointmanager.LatencyBreakdown
+	if latencyInt == nil {
+		return latency, nil
+	}
+
+	latencyMap, ok := latencyInt.(map[string]interface{})
+	if !ok {
+		return latency, fmt.Errorf("unable to cast latency breakdown to map[string]interface{}")
+	}
+	phases := map[string]*float64{
+		"dnsLookup":       &latency.DNSLookup,
+		"tcpConnect":      &latency.TCPConnect,
+		"tlsHandshake":    &latency.TLSHandshake,
+		"timeToFirstByte": &latency.TimeToFirstByte,
+		"bodyTransfer":    &latency.BodyTransfer,
+	}
+	for phase, duration := range phases {
+		if latencyMap[phase] == nil {
+			continue
+		}
+		// JSON numbers are golang float64s
+		seconds, ok := latencyMap[phase].(float64)
+		if !ok {
+			return latency, fmt.Errorf("unable to cast latency breakdown %s to float", phase)
+		}
+		*duration = seconds
+	}
+
+	return latency, nil
+}
+
 // saveMsgInDB formats the message data for the database and either adds a new entry to the database or
 // updates a current one
 func saveMsgInDB(message []byte, args *map[string]interface{}) error {
@@ -191,117 +353,130 @@ func saveMsgInDB(message []byte, args *map[string]interface{}) error {
 		fhirEndpoint.RequestedFhirVersion = "None"
 	}
 
-	store := qa.store
 	ctx := qa.ctx
 
-	existingEndpt, err = store.GetFHIREndpointInfoUsingURLAndRequestedVersion(ctx, fhirEndpoint.URL, fhirEndpoint.RequestedFhirVersion)
-
-	if err == sql.ErrNoRows {
-
-		// If the endpoint info entry doesn't exist, add it to the DB
-		err = chplmapper.MatchEndpointToVendor(ctx, fhirEndpoint, store)
-		if err != nil {
-			return fmt.Errorf("doesn't exist, match endpoint to vendor failed, %s", err)
-		}
-		err = chplmapper.MatchEndpointToProduct(ctx, fhirEndpoint, store, fmt.Sprintf("%v", qa.chplMatchFile))
-		if err != nil {
-			return fmt.Errorf("doesn't exist, match endpoint to product failed, %s", err)
-		}
-
-		metadataID, err := store.AddFHIREndpointMetadata(ctx, fhirEndpoint.Metadata)
-		if err != nil {
-			return fmt.Errorf("doesn't exist, add endpoint metadata failed, %s", err)
-		}
+	// the metadata, validation and info rows for the message are stored in a single transaction so that a failure
+	// part way through does not leave orphaned metadata or validation results
+	return qa.store.WithTx(ctx, func(store *postgresql.Store) error {
+		existingEndpt, err = store.GetFHIREndpointInfoUsingURLAndRequestedVersion(ctx, fhirEndpoint.URL, fhirEndpoint.RequestedFhirVersion)
 
-		valResID, err := store.AddValidationResult(ctx)
-		if err != nil {
-			return fmt.Errorf("adding new validation result ID failed, %s", err)
-		}
-		fhirEndpoint.ValidationID = valResID
-
-		err = store.AddValidation(ctx, validation, valResID)
-		if err != nil {
-			return fmt.Errorf("error adding validation rows to table, %s", err)
-		}
+		if err == sql.ErrNoRows {
 
-		err = store.AddFHIREndpointInfo(ctx, fhirEndpoint, metadataID)
-		if err != nil {
-			return fmt.Errorf("doesn't exist, add to fhir_endpoints_info failed, %s", err)
-		}
-	} else if err != nil {
-		return err
-	} else {
-		fhirEndpoint.VendorID = existingEndpt.VendorID
-		fhirEndpoint.HealthITProductID = existingEndpt.HealthITProductID
-
-		existingEndpt.Metadata.URL = fhirEndpoint.Metadata.URL
-		existingEndpt.Metadata.HTTPResponse = fhirEndpoint.Metadata.HTTPResponse
-		existingEndpt.Metadata.Errors = fhirEndpoint.Metadata.Errors
-		existingEndpt.Metadata.ResponseTime = fhirEndpoint.Metadata.ResponseTime
-		existingEndpt.Metadata.SMARTHTTPResponse = fhirEndpoint.Metadata.SMARTHTTPResponse
-		existingEndpt.Metadata.RequestedFhirVersion = fhirEndpoint.Metadata.RequestedFhirVersion
-
-		// Set fhirEndpoint.ValidationID to existingEndpt value because they should have the same ValidationID
-		// until there's a reason to update it
-		fhirEndpoint.ValidationID = existingEndpt.ValidationID
-
-		// If the existing endpoint info does not equal the stored endpoint info, update it with the new information, otherwise only update metadata.
-		if !existingEndpt.EqualExcludeMetadata(fhirEndpoint) {
-			existingEndpt.CapabilityStatement = fhirEndpoint.CapabilityStatement
-			existingEndpt.TLSVersion = fhirEndpoint.TLSVersion
-			existingEndpt.MIMETypes = fhirEndpoint.MIMETypes
-			existingEndpt.SMARTResponse = fhirEndpoint.SMARTResponse
-			existingEndpt.IncludedFields = fhirEndpoint.IncludedFields
-			existingEndpt.OperationResource = fhirEndpoint.OperationResource
-			existingEndpt.CapabilityFhirVersion = fhirEndpoint.CapabilityFhirVersion
-
-			err = chplmapper.MatchEndpointToVendor(ctx, existingEndpt, store)
+			// If the endpoint info entry doesn't exist, add it to the DB
+			err = chplmapper.MatchEndpointToVendor(ctx, fhirEndpoint, store)
 			if err != nil {
-				return fmt.Errorf("does exist, match endpoint to vendor failed, %s", err)
+				return fmt.Errorf("doesn't exist, match endpoint to vendor failed, %s", err)
 			}
-
-			err = chplmapper.MatchEndpointToProduct(ctx, existingEndpt, store, fmt.Sprintf("%v", qa.chplMatchFile))
+			err = chplmapper.MatchEndpointToProduct(ctx, fhirEndpoint, store, fmt.Sprintf("%v", qa.chplMatchFile))
 			if err != nil {
-				return fmt.Errorf("does exist, match endpoint to product failed, %s", err)
+				return fmt.Errorf("doesn't exist, match endpoint to product failed, %s", err)
 			}
 
-			metadataID, err := store.AddFHIREndpointMetadata(ctx, existingEndpt.Metadata)
+			metadataID, err := store.AddFHIREndpointMetadata(ctx, fhirEndpoint.Metadata)
 			if err != nil {
-				return fmt.Errorf("does exist, add endpoint metadata failed, %s", err)
+				return fmt.Errorf("doesn't exist, add endpoint metadata failed, %s", err)
 			}
 
 			valResID, err := store.AddValidationResult(ctx)
 			if err != nil {
 				return fmt.Errorf("adding new validation result ID failed, %s", err)
 			}
-			existingEndpt.ValidationID = valResID
+			fhirEndpoint.ValidationID = valResID
 
 			err = store.AddValidation(ctx, validation, valResID)
 			if err != nil {
 				return fmt.Errorf("error adding validation rows to table, %s", err)
 			}
 
-			err = store.UpdateFHIREndpointInfo(ctx, existingEndpt, metadataID)
+			err = store.AddFHIREndpointInfo(ctx, fhirEndpoint, metadataID)
 			if err != nil {
-				return fmt.Errorf("does exist, add to fhir_endpoints_info failed, %s", err)
+				return fmt.Errorf("doesn't exist, add to fhir_endpoints_info failed, %s", err)
 			}
+		} else if err != nil {
+			return err
 		} else {
-			metadataID, err := store.AddFHIREndpointMetadata(ctx, existingEndpt.Metadata)
-			if err != nil {
-				return fmt.Errorf("just adding endpoint metadata failed, %s", err)
-			}
-
-			err = store.UpdateMetadataIDInfo(ctx, metadataID, existingEndpt.ID)
-			if err != nil {
-				return fmt.Errorf("just adding the Metadata ID failed, %s", err)
+			fhirEndpoint.VendorID = existingEndpt.VendorID
+			fhirEndpoint.HealthITProductID = existingEndpt.HealthITProductID
+
+			existingEndpt.Metadata.URL = fhirEndpoint.Metadata.URL
+			existingEndpt.Metadata.HTTPResponse = fhirEndpoint.Metadata.HTTPResponse
+			existingEndpt.Metadata.Errors = fhirEndpoint.Metadata.Errors
+			existingEndpt.Metadata.ErrorCategory = fhirEndpoint.Metadata.ErrorCategory
+			existingEndpt.Metadata.ResponseTime = fhirEndpoint.Metadata.ResponseTime
+			existingEndpt.Metadata.SMARTHTTPResponse = fhirEndpoint.Metadata.SMARTHTTPResponse
+			existingEndpt.Metadata.RequestedFhirVersion = fhirEndpoint.Metadata.RequestedFhirVersion
+			existingEndpt.Metadata.Redirects = fhirEndpoint.Metadata.Redirects
+			existingEndpt.Metadata.SMARTRedirects = fhirEndpoint.Metadata.SMARTRedirects
+			existingEndpt.Metadata.PermanentRedirect = fhirEndpoint.Metadata.PermanentRedirect
+			existingEndpt.Metadata.CanonicalURL = fhirEndpoint.Metadata.CanonicalURL
+			existingEndpt.Metadata.IPResponseTimes = fhirEndpoint.Metadata.IPResponseTimes
+			existingEndpt.Metadata.LatencyBreakdown = fhirEndpoint.Metadata.LatencyBreakdown
+
+			// Set fhirEndpoint.ValidationID to existingEndpt value because they should have the same ValidationID
+			// until there's a reason to update it
+			fhirEndpoint.ValidationID = existingEndpt.ValidationID
+
+			// If the existing endpoint info does not equal the stored endpoint info, update it with the new information, otherwise only update metadata.
+			if !existingEndpt.EqualExcludeMetadata(fhirEndpoint) {
+				existingEndpt.CapabilityStatement = fhirEndpoint.CapabilityStatement
+				existingEndpt.TLSVersion = fhirEndpoint.TLSVersion
+				existingEndpt.MIMETypes = fhirEndpoint.MIMETypes
+				existingEndpt.SMARTResponse = fhirEndpoint.SMARTResponse
+				existingEndpt.IncludedFields = fhirEndpoint.IncludedFields
+				existingEndpt.OperationResource = fhirEndpoint.OperationResource
+				existingEndpt.CapabilityFhirVersion = fhirEndpoint.CapabilityFhirVersion
+				existingEndpt.HTTPVersion = fhirEndpoint.HTTPVersion
+				existingEndpt.ALPNProtocol = fhirEndpoint.ALPNProtocol
+				existingEndpt.IPReachability = fhirEndpoint.IPReachability
+
+				err = chplmapper.MatchEndpointToVendor(ctx, existingEndpt, store)
+				if err != nil {
+					return fmt.Errorf("does exist, match endpoint to vendor failed, %s", err)
+				}
+
+				err = chplmapper.MatchEndpointToProduct(ctx, existingEndpt, store, fmt.Sprintf("%v", qa.chplMatchFile))
+				if err != nil {
+					return fmt.Errorf("does exist, match endpoint to product failed, %s", err)
+				}
+
+				metadataID, err := store.AddFHIREndpointMetadata(ctx, existingEndpt.Metadata)
+				if err != nil {
+					return fmt.Errorf("does exist, add endpoint metadata failed, %s", err)
+				}
+
+				valResID, err := store.AddValidationResult(ctx)
+				if err != nil {
+					return fmt.Errorf("adding new validation result ID failed, %s", err)
+				}
+				existingEndpt.ValidationID = valResID
+
+				err = store.AddValidation(ctx, validation, valResID)
+				if err != nil {
+					return fmt.Errorf("error adding validation rows to table, %s", err)
+				}
+
+				err = store.UpdateFHIREndpointInfo(ctx, existingEndpt, metadataID)
+				if err != nil {
+					return fmt.Errorf("does exist, add to fhir_endpoints_info failed, %s", err)
+				}
+			} else {
+				metadataID, err := store.AddFHIREndpointMetadata(ctx, existingEndpt.Metadata)
+				if err != nil {
+					return fmt.Errorf("just adding endpoint metadata failed, %s", err)
+				}
+
+				err = store.UpdateMetadataIDInfo(ctx, metadataID, existingEndpt.ID)
+				if err != nil {
+					return fmt.Errorf("just adding the Metadata ID failed, %s", err)
+				}
 			}
 		}
-	}
 
-	return nil
+		return nil
+	})
 }
 
-func removeNoLongerExistingVersionsInfos(ctx context.Context, store *postgresql.Store, url string, supportedVersions []string) error {
+func removeNoLongerExistingVersionsInfos(ctx context.Context, store endpointmanager.EndpointInfoStore, url string, supportedVersions []string) error {
 	// If there is a requestedVersion for a URL in fhir_endpoints_info that is no longer in supportedVersions
 	// then we need to remove those fhir_endpoint_info entries
 	endptInfos, err := store.GetFHIREndpointInfosByURLWithDifferentRequestedVersion(ctx, url, supportedVersions)
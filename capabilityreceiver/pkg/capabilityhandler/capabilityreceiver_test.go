@@ -349,6 +349,108 @@ func Test_formatMessage(t *testing.T) {
 	th.Assert(t, returnErr != nil, "Expected an error to be thrown due to an incorrect responseTime")
 	tmpMessage["responseTime"] = 0.1234
 
+	// test redirect chain
+	tmpMessage["redirects"] = []map[string]interface{}{
+		{"statusCode": 302, "location": "https://example.com/temp/metadata"},
+		{"statusCode": 301, "location": "https://example.com/DSTU2/metadata"},
+	}
+	tmpMessage["canonicalURL"] = "https://example.com/DSTU2/"
+	message, err = convertInterfaceToBytes(tmpMessage)
+	th.Assert(t, err == nil, err)
+	endpt, _, returnErr = formatMessage(message)
+	th.Assert(t, returnErr == nil, returnErr)
+	th.Assert(t, len(endpt.Metadata.Redirects) == 2, fmt.Sprintf("Expected two redirects, got %d", len(endpt.Metadata.Redirects)))
+	th.Assert(t, endpt.Metadata.Redirects[1].StatusCode == 301, fmt.Sprintf("Expected second redirect to be a 301, got %d", endpt.Metadata.Redirects[1].StatusCode))
+	th.Assert(t, endpt.Metadata.Redirects[1].Location == "https://example.com/DSTU2/metadata", fmt.Sprintf("Unexpected redirect location %s", endpt.Metadata.Redirects[1].Location))
+	th.Assert(t, len(endpt.Metadata.SMARTRedirects) == 0, "Expected no SMART redirects")
+	th.Assert(t, endpt.Metadata.PermanentRedirect, "Expected permanent redirect to be flagged")
+	th.Assert(t, endpt.Metadata.CanonicalURL == "https://example.com/DSTU2/", fmt.Sprintf("Unexpected canonical URL %s", endpt.Metadata.CanonicalURL))
+
+	// test incorrect redirects
+	tmpMessage["redirects"] = []map[string]interface{}{{"statusCode": "301", "location": "https://example.com/DSTU2/metadata"}}
+	message, err = convertInterfaceToBytes(tmpMessage)
+	th.Assert(t, err == nil, err)
+	_, _, returnErr = formatMessage(message)
+	th.Assert(t, returnErr != nil, "Expected an error to be thrown due to an incorrect redirect status code")
+	tmpMessage["redirects"] = 1
+	message, err = convertInterfaceToBytes(tmpMessage)
+	th.Assert(t, err == nil, err)
+	_, _, returnErr = formatMessage(message)
+	th.Assert(t, returnErr != nil, "Expected an error to be thrown due to incorrect redirects")
+	delete(tmpMessage, "redirects")
+	delete(tmpMessage, "canonicalURL")
+
+	// test latency breakdown
+	tmpMessage["latencyBreakdown"] = map[string]interface{}{"dnsLookup": 0.01, "tcpConnect": 0.02, "tlsHandshake": 0.05, "timeToFirstByte": 0.3, "bodyTransfer": 0.04}
+	message, err = convertInterfaceToBytes(tmpMessage)
+	th.Assert(t, err == nil, err)
+	endpt, _, returnErr = formatMessage(message)
+	th.Assert(t, returnErr == nil, returnErr)
+	expectedLatency := endpointmanager.LatencyBreakdown{DNSLookup: 0.01, TCPConnect: 0.02, TLSHandshake: 0.05, TimeToFirstByte: 0.3, BodyTransfer: 0.04}
+	th.Assert(t, endpt.Metadata.LatencyBreakdown.Equal(expectedLatency), fmt.Sprintf("Unexpected latency breakdown %+v", endpt.Metadata.LatencyBreakdown))
+	tmpMessage["latencyBreakdown"] = map[string]interface{}{"tlsHandshake": "0.05"}
+	message, err = convertInterfaceToBytes(tmpMessage)
+	th.Assert(t, err == nil, err)
+	_, _, returnErr = formatMessage(message)
+	th.Assert(t, returnErr != nil, "Expected an error to be thrown due to an incorrect latency breakdown")
+	delete(tmpMessage, "latencyBreakdown")
+
+	// test error category
+	tmpMessage["errCategory"] = "http_5xx"
+	message, err = convertInterfaceToBytes(tmpMessage)
+	th.Assert(t, err == nil, err)
+	endpt, _, returnErr = formatMessage(message)
+	th.Assert(t, returnErr == nil, returnErr)
+	th.Assert(t, endpt.Metadata.ErrorCategory == endpointmanager.HTTPServerError, fmt.Sprintf("Unexpected error category %s", endpt.Metadata.ErrorCategory))
+	tmpMessage["errCategory"] = 5
+	message, err = convertInterfaceToBytes(tmpMessage)
+	th.Assert(t, err == nil, err)
+	_, _, returnErr = formatMessage(message)
+	th.Assert(t, returnErr != nil, "Expected an error to be thrown due to an incorrect errCategory")
+	delete(tmpMessage, "errCategory")
+
+	// test negotiated protocol and IP family reachability
+	tmpMessage["httpVersion"] = "HTTP/1.0"
+	tmpMessage["alpnProtocol"] = "http/1.1"
+	tmpMessage["ipReachability"] = map[string]interface{}{"IPv4": true, "IPv6": false}
+	tmpMessage["ipResponseTimes"] = map[string]interface{}{"IPv4": 0.25}
+	message, err = convertInterfaceToBytes(tmpMessage)
+	th.Assert(t, err == nil, err)
+	endpt, validationObj, returnErr := formatMessage(message)
+	th.Assert(t, returnErr == nil, returnErr)
+	th.Assert(t, endpt.HTTPVersion == "HTTP/1.0", fmt.Sprintf("Unexpected HTTP version %s", endpt.HTTPVersion))
+	th.Assert(t, endpt.ALPNProtocol == "http/1.1", fmt.Sprintf("Unexpected ALPN protocol %s", endpt.ALPNProtocol))
+	th.Assert(t, endpt.IPReachability["IPv4"] && !endpt.IPReachability["IPv6"], fmt.Sprintf("Unexpected IP reachability %v", endpt.IPReachability))
+	th.Assert(t, endpt.Metadata.IPResponseTimes["IPv4"] == 0.25, fmt.Sprintf("Unexpected IP response times %v", endpt.Metadata.IPResponseTimes))
+	httpVersionRuleFound := false
+	for _, rule := range validationObj.Results {
+		if rule.RuleName == endpointmanager.HTTPVersionRule {
+			httpVersionRuleFound = true
+			th.Assert(t, !rule.Valid, "Expected an HTTP/1.0 server to fail the HTTP version check")
+		}
+	}
+	th.Assert(t, httpVersionRuleFound, "Expected the HTTP version check to be run")
+	tmpMessage["ipReachability"] = map[string]interface{}{"IPv4": "true"}
+	message, err = convertInterfaceToBytes(tmpMessage)
+	th.Assert(t, err == nil, err)
+	_, _, returnErr = formatMessage(message)
+	th.Assert(t, returnErr != nil, "Expected an error to be thrown due to incorrect ipReachability")
+	tmpMessage["ipReachability"] = map[string]interface{}{"IPv4": true}
+	tmpMessage["ipResponseTimes"] = 0.25
+	message, err = convertInterfaceToBytes(tmpMessage)
+	th.Assert(t, err == nil, err)
+	_, _, returnErr = formatMessage(message)
+	th.Assert(t, returnErr != nil, "Expected an error to be thrown due to incorrect ipResponseTimes")
+	tmpMessage["httpVersion"] = 1
+	delete(tmpMessage, "ipResponseTimes")
+	message, err = convertInterfaceToBytes(tmpMessage)
+	th.Assert(t, err == nil, err)
+	_, _, returnErr = formatMessage(message)
+	th.Assert(t, returnErr != nil, "Expected an error to be thrown due to an incorrect httpVersion")
+	delete(tmpMessage, "httpVersion")
+	delete(tmpMessage, "alpnProtocol")
+	delete(tmpMessage, "ipReachability")
+
 	// test incorrect requested version
 	tmpMessage["requestedFhirVersion"] = 1
 	message, err = convertInterfaceToBytes(tmpMessage)
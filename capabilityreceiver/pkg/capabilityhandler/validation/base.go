@@ -15,6 +15,7 @@ import (
 var version3plus = []string{"3.0.0", "3.0.1", "3.0.2", "3.2.0", "3.3.0", "3.5.0", "3.5a.0", "4.0.0", "4.0.1"}
 var fhir3PlusJSONMIMEType = "application/fhir+json"
 var fhir2LessJSONMIMEType = "application/json+fhir"
+var http10 = "HTTP/1.0"
 
 type baseVal struct {
 }
@@ -26,7 +27,8 @@ func (bv *baseVal) RunValidation(capStat capabilityparser.CapabilityStatement,
 	tlsVersion string,
 	smartRsp smartparser.SMARTResponse,
 	requestedFhirVersion string,
-	defaultFhirVersion string) endpointmanager.Validation {
+	defaultFhirVersion string,
+	httpVersion string) endpointmanager.Validation {
 	var validationResults []endpointmanager.Rule
 
 	returnedRule := bv.CapStatExists(capStat)
@@ -35,6 +37,12 @@ func (bv *baseVal) RunValidation(capStat capabilityparser.CapabilityStatement,
 	returnedRule = bv.MimeTypeValid(mimeTypes, fhirVersion)
 	validationResults = append(validationResults, returnedRule)
 
+	// the HTTP version is not known when the endpoint could not be reached
+	if httpVersion != "" {
+		returnedRule = bv.HTTPVersionValid(httpVersion)
+		validationResults = append(validationResults, returnedRule)
+	}
+
 	returnedRules := bv.KindValid(capStat)
 	validationResults = append(validationResults, returnedRules[0])
 
@@ -119,6 +127,26 @@ func (bv *baseVal) MimeTypeValid(mimeTypes []string, fhirVersion string) endpoin
 	return ruleError
 }
 
+// HTTPVersionValid checks that the server does not only support HTTP/1.0, which lacks persistent
+// connections and the Host header that FHIR servers commonly rely on
+func (bv *baseVal) HTTPVersionValid(httpVersion string) endpointmanager.Rule {
+	ruleError := endpointmanager.Rule{
+		RuleName:  endpointmanager.HTTPVersionRule,
+		Valid:     true,
+		Expected:  "HTTP/1.1, HTTP/2.0",
+		Actual:    httpVersion,
+		Reference: "https://www.hl7.org/fhir/http.html",
+		Comment:   "The server supports HTTP/1.1 or later.",
+	}
+
+	if httpVersion == http10 {
+		ruleError.Valid = false
+		ruleError.Comment = "The server only supports HTTP/1.0. Servers should support HTTP/1.1 or later."
+	}
+
+	return ruleError
+}
+
 func (bv *baseVal) TLSVersion(tlsVersion string) endpointmanager.Rule {
 	var ruleError endpointmanager.Rule
 	return ruleError
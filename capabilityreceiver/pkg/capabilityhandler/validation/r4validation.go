@@ -35,7 +35,8 @@ func (v *r4Validation) RunValidation(capStat capabilityparser.CapabilityStatemen
 	tlsVersion string,
 	smartRsp smartparser.SMARTResponse,
 	requestedFhirVersion string,
-	defaultFhirVersion string) endpointmanager.Validation {
+	defaultFhirVersion string,
+	httpVersion string) endpointmanager.Validation {
 	var validationResults []endpointmanager.Rule
 
 	returnedRule := v.CapStatExists(capStat)
@@ -44,6 +45,12 @@ func (v *r4Validation) RunValidation(capStat capabilityparser.CapabilityStatemen
 	returnedRule = v.MimeTypeValid(mimeTypes, fhirVersion)
 	validationResults = append(validationResults, returnedRule)
 
+	// the HTTP version is not known when the endpoint could not be reached
+	if httpVersion != "" {
+		returnedRule = v.HTTPVersionValid(httpVersion)
+		validationResults = append(validationResults, returnedRule)
+	}
+
 	if requestedFhirVersion == "None" && defaultFhirVersion != "" {
 		returnedRule = v.VersionResponseValid(fhirVersion, defaultFhirVersion)
 		validationResults = append(validationResults, returnedRule)
@@ -23,7 +23,8 @@ func (v *stu3Validation) RunValidation(capStat capabilityparser.CapabilityStatem
 	tlsVersion string,
 	smartRsp smartparser.SMARTResponse,
 	requestedFhirVersion string,
-	defaultFhirVersion string) endpointmanager.Validation {
+	defaultFhirVersion string,
+	httpVersion string) endpointmanager.Validation {
 	var validationResults []endpointmanager.Rule
 
 	returnedRule := v.CapStatExists(capStat)
@@ -32,6 +33,12 @@ func (v *stu3Validation) RunValidation(capStat capabilityparser.CapabilityStatem
 	returnedRule = v.MimeTypeValid(mimeTypes, fhirVersion)
 	validationResults = append(validationResults, returnedRule)
 
+	// the HTTP version is not known when the endpoint could not be reached
+	if httpVersion != "" {
+		returnedRule = v.HTTPVersionValid(httpVersion)
+		validationResults = append(validationResults, returnedRule)
+	}
+
 	returnedRules := v.KindValid(capStat)
 	validationResults = append(validationResults, returnedRules[0])
 
@@ -49,7 +49,7 @@ func Test_RunValidation(t *testing.T) {
 	requestedFhirVersion := "None"
 	defaultFhirVersion := "1.0.2"
 
-	actualVal := validator.RunValidation(cs, []string{fhir2LessJSONMIMEType}, "1.0.2", "TLS 1.2", sr, requestedFhirVersion, defaultFhirVersion)
+	actualVal := validator.RunValidation(cs, []string{fhir2LessJSONMIMEType}, "1.0.2", "TLS 1.2", sr, requestedFhirVersion, defaultFhirVersion, "")
 	th.Assert(t, len(actualVal.Results) == 3, fmt.Sprintf("RunValidation should have returned 3 validation checks, instead it returned %d", len(actualVal.Results)))
 	eq := reflect.DeepEqual(actualVal.Results[0], expectedFirstVal)
 	th.Assert(t, eq == true, fmt.Sprintf("RunValidation's first returned validation is not correct, is instead %+v", actualVal.Results[0]))
@@ -85,12 +85,20 @@ func Test_RunValidation(t *testing.T) {
 		Reference: "http://hl7.org/fhir/capabilitystatement.html",
 	}
 
-	actualVal = validator2.RunValidation(cs2, []string{fhir3PlusJSONMIMEType}, "4.0.1", "TLS 1.2", sr, requestedFhirVersion, defaultFhirVersion)
+	actualVal = validator2.RunValidation(cs2, []string{fhir3PlusJSONMIMEType}, "4.0.1", "TLS 1.2", sr, requestedFhirVersion, defaultFhirVersion, "")
 	th.Assert(t, len(actualVal.Results) == 15, fmt.Sprintf("RunValidation should have returned 15 validation checks, instead it returned %d", len(actualVal.Results)))
 	eq = reflect.DeepEqual(actualVal.Results[3], expectedFourthVal)
 	th.Assert(t, eq == true, "RunValidation's fourth returned validation is not correct")
 	eq = reflect.DeepEqual(actualVal.Results[14], expectedLastVal)
 	th.Assert(t, eq == true, "RunValidation's last returned validation is not correct")
+
+	// the HTTP version check is only included when the HTTP version is known
+
+	actualVal = validator2.RunValidation(cs2, []string{fhir3PlusJSONMIMEType}, "4.0.1", "TLS 1.2", sr, requestedFhirVersion, defaultFhirVersion, "HTTP/1.1")
+	th.Assert(t, len(actualVal.Results) == 16, fmt.Sprintf("RunValidation should have returned 16 validation checks, instead it returned %d", len(actualVal.Results)))
+	th.Assert(t, actualVal.Results[2].RuleName == endpointmanager.HTTPVersionRule, fmt.Sprintf("RunValidation's third returned validation should be the HTTP version check, is instead %s", actualVal.Results[2].RuleName))
+	eq = reflect.DeepEqual(actualVal.Results[15], expectedLastVal)
+	th.Assert(t, eq == true, "RunValidation's last returned validation is not correct")
 }
 
 func Test_CapStatExists(t *testing.T) {
@@ -818,6 +826,37 @@ func Test_TLSVersion(t *testing.T) {
 	th.Assert(t, eq == true, fmt.Sprintf("TLSVersion check should be invalid, returned value is instead %+v", actualVal))
 }
 
+func Test_HTTPVersionValid(t *testing.T) {
+	cs, err := getR4CapStat()
+	th.Assert(t, err == nil, err)
+
+	validator, err := getValidator(cs, r4)
+	th.Assert(t, err == nil, err)
+
+	// base test
+
+	expectedVal := endpointmanager.Rule{
+		RuleName:  endpointmanager.HTTPVersionRule,
+		Valid:     true,
+		Expected:  "HTTP/1.1, HTTP/2.0",
+		Actual:    "HTTP/2.0",
+		Reference: "https://www.hl7.org/fhir/http.html",
+		Comment:   "The server supports HTTP/1.1 or later.",
+	}
+	actualVal := validator.HTTPVersionValid("HTTP/2.0")
+	eq := reflect.DeepEqual(actualVal, expectedVal)
+	th.Assert(t, eq == true, fmt.Sprintf("HTTPVersionValid check should be valid, returned value is instead %+v", actualVal))
+
+	// HTTP/1.0 only servers are not valid
+
+	expectedVal.Valid = false
+	expectedVal.Actual = "HTTP/1.0"
+	expectedVal.Comment = "The server only supports HTTP/1.0. Servers should support HTTP/1.1 or later."
+	actualVal = validator.HTTPVersionValid("HTTP/1.0")
+	eq = reflect.DeepEqual(actualVal, expectedVal)
+	th.Assert(t, eq == true, fmt.Sprintf("HTTPVersionValid check should be invalid, returned value is instead %+v", actualVal))
+}
+
 func Test_UniqueResources(t *testing.T) {
 	cs, err := getR4CapStat()
 	th.Assert(t, err == nil, err)
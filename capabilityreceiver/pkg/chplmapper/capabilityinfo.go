@@ -2,14 +2,16 @@ package chplmapper
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"io/ioutil"
 	"os"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/capabilityparser"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
-	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
 	"github.com/pkg/errors"
 )
 
@@ -28,7 +30,7 @@ var fluffWords = []string{
 
 // MatchEndpointToVendor creates the database association between the endpoint and the vendor,
 // and the endpoint and the healht IT product.
-func MatchEndpointToVendor(ctx context.Context, ep *endpointmanager.FHIREndpointInfo, store *postgresql.Store) error {
+func MatchEndpointToVendor(ctx context.Context, ep *endpointmanager.FHIREndpointInfo, store endpointmanager.VendorStore) error {
 	if ep.CapabilityStatement == nil {
 		return nil
 	}
@@ -43,37 +45,87 @@ func MatchEndpointToVendor(ctx context.Context, ep *endpointmanager.FHIREndpoint
 	return nil
 }
 
-// MatchEndpointToProduct creates the database association between the endpoint and the HealthITProduct,
-func MatchEndpointToProduct(ctx context.Context, ep *endpointmanager.FHIREndpointInfo, store *postgresql.Store, matchFile string) error {
-	if ep.CapabilityStatement == nil {
-		return nil
-	}
+// ProductMatchStore is the store used by MatchEndpointToProduct. The endpoints are used to find the list sources of
+// the endpoint being matched.
+type ProductMatchStore interface {
+	endpointmanager.ProductStore
+	GetFHIREndpointUsingURL(ctx context.Context, url string) ([]*endpointmanager.FHIREndpoint, error)
+}
 
-	chplProductNameVersion, err := openProductLinksFile(matchFile)
-	if err != nil {
-		return errors.Wrap(err, "error matching the capability statement to a CHPL product")
-	}
+// chplProductNumberRegex matches the CHPL product numbers of the 2014 edition, such as CHP-006401, and of the 2015
+// edition, such as 15.04.04.2657.Care.01.00.0.160701
+var chplProductNumberRegex = regexp.MustCompile(`^(CHP-\d{6}|\d{2}\.\d{2}\.\d{2}\.\d{4}\.\w{4}\.\d{2}\.\d{2}\.\d\.\d{6})$`)
+
+// MatchEndpointToProduct creates the database association between the endpoint and the HealthITProduct. The product
+// is matched using the software name and version in the endpoint's capability statement and the match file. If that
+// does not find a stored product, the endpoint is matched to the product whose service base URL list it was
+// harvested from, which is the endpoint's list source.
+func MatchEndpointToProduct(ctx context.Context, ep *endpointmanager.FHIREndpointInfo, store ProductMatchStore, matchFile string) error {
+	if ep.CapabilityStatement != nil {
+		chplProductNameVersion, err := openProductLinksFile(matchFile)
+		if err != nil {
+			return errors.Wrap(err, "error matching the capability statement to a CHPL product")
+		}
 
-	softwareName, err := ep.CapabilityStatement.GetSoftwareName()
-	if err != nil {
-		return errors.Wrap(err, "error matching the capability statement to a CHPL product")
+		softwareName, err := ep.CapabilityStatement.GetSoftwareName()
+		if err != nil {
+			return errors.Wrap(err, "error matching the capability statement to a CHPL product")
+		}
+		softwareVersion, err := ep.CapabilityStatement.GetSoftwareVersion()
+		if err != nil {
+			return errors.Wrap(err, "error matching the capability statement to a CHPL product")
+		}
+		chplID := chplProductNameVersion[softwareName][softwareVersion]
+
+		healthITProductID, err := store.GetHealthITProductIDByCHPLID(ctx, chplID)
+		// No errors thrown means a healthit product with CHPLID was found and can be set on ep
+		if err == nil {
+			ep.HealthITProductID = healthITProductID
+			return nil
+		}
 	}
-	softwareVersion, err := ep.CapabilityStatement.GetSoftwareVersion()
+
+	healthITProductID, err := listSourceProductMatch(ctx, ep.URL, store)
 	if err != nil {
-		return errors.Wrap(err, "error matching the capability statement to a CHPL product")
+		return errors.Wrap(err, "error matching the endpoint's list source to a CHPL product")
 	}
-	chplID := chplProductNameVersion[softwareName][softwareVersion]
-
-	healthITProductID, err := store.GetHealthITProductIDByCHPLID(ctx, chplID)
-	// No errors thrown means a healthit product with CHPLID was found and can be set on ep
-	if err == nil {
+	if healthITProductID != 0 {
 		ep.HealthITProductID = healthITProductID
 	}
 
 	return nil
 }
 
-func getVendorMatch(ctx context.Context, capStat capabilityparser.CapabilityStatement, store *postgresql.Store) (int, error) {
+// listSourceProductMatch returns the ID of the stored product whose CHPL product number is a list source of the
+// endpoint with the given URL, or 0 if there is none. If the endpoint has more than one such list source, the
+// first in order of CHPL product number is used.
+func listSourceProductMatch(ctx context.Context, url string, store ProductMatchStore) (int, error) {
+	endpoints, err := store.GetFHIREndpointUsingURL(ctx, url)
+	if err != nil {
+		return 0, err
+	}
+
+	var chplIDs []string
+	for _, endpoint := range endpoints {
+		if chplProductNumberRegex.MatchString(endpoint.ListSource) {
+			chplIDs = append(chplIDs, endpoint.ListSource)
+		}
+	}
+	sort.Strings(chplIDs)
+
+	for _, chplID := range chplIDs {
+		healthITProductID, err := store.GetHealthITProductIDByCHPLID(ctx, chplID)
+		if err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+		return healthITProductID, nil
+	}
+	return 0, nil
+}
+
+func getVendorMatch(ctx context.Context, capStat capabilityparser.CapabilityStatement, store endpointmanager.VendorStore) (int, error) {
 	var vendorID int
 	vendorsRaw, err := store.GetVendorNames(ctx)
 	if err != nil {
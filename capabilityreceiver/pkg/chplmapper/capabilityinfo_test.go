@@ -1,9 +1,15 @@
 package chplmapper
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/capabilityparser"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/memorystore"
 	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
 )
 
@@ -39,3 +45,89 @@ func Test_matchName(t *testing.T) {
 	actual = matchName(dev, devListNorm, devList)
 	th.Assert(t, expected == actual, fmt.Sprintf("Expected %s. Got %s.", expected, actual))
 }
+
+func Test_MatchEndpointToVendorWithMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := memorystore.NewStore()
+
+	epic := &endpointmanager.Vendor{Name: "Epic Systems Corporation"}
+	cerner := &endpointmanager.Vendor{Name: "Cerner Corporation"}
+	for _, vendor := range []*endpointmanager.Vendor{epic, cerner} {
+		err := store.AddVendor(ctx, vendor)
+		th.Assert(t, err == nil, err)
+	}
+
+	csJSON, err := ioutil.ReadFile(filepath.Join("../../testdata", "cerner_capability_dstu2.json"))
+	th.Assert(t, err == nil, err)
+	cs, err := capabilityparser.NewCapabilityStatement(csJSON)
+	th.Assert(t, err == nil, err)
+
+	epInfo := &endpointmanager.FHIREndpointInfo{
+		URL:                 "example.com/FHIR/DSTU2",
+		CapabilityStatement: cs}
+	err = MatchEndpointToVendor(ctx, epInfo, store)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, epInfo.VendorID == cerner.ID, fmt.Sprintf("expected vendor value to be %d. Instead got %d", cerner.ID, epInfo.VendorID))
+}
+
+func Test_MatchEndpointToProductUsingListSource(t *testing.T) {
+	ctx := context.Background()
+	store := memorystore.NewStore()
+	matchFile := filepath.Join("../../testdata", "test_chpl_product_mapping.json")
+
+	harvested := &endpointmanager.HealthITProduct{Name: "Harvested Product", Version: "1.0", CHPLID: "15.04.04.2657.Care.01.00.0.160701"}
+	matched := &endpointmanager.HealthITProduct{Name: "Allscripts FHIR", Version: "19.4.121.0", CHPLID: "CorrectVersionAndName"}
+	for _, product := range []*endpointmanager.HealthITProduct{harvested, matched} {
+		err := store.AddHealthITProduct(ctx, product)
+		th.Assert(t, err == nil, err)
+	}
+
+	// the endpoint was harvested from the product's service base URL list, and is also in a vendor's list
+	for _, listSource := range []string{"https://open.epic.com/MyApps/EndpointsJson", harvested.CHPLID} {
+		err := store.AddFHIREndpoint(ctx, &endpointmanager.FHIREndpoint{URL: "example.com/FHIR/R4", ListSource: listSource})
+		th.Assert(t, err == nil, err)
+	}
+
+	// without a capability statement, the product is matched using the list source
+	epInfo := &endpointmanager.FHIREndpointInfo{URL: "example.com/FHIR/R4"}
+	err := MatchEndpointToProduct(ctx, epInfo, store, matchFile)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, epInfo.HealthITProductID == harvested.ID, fmt.Sprintf("expected the harvested product %d to be matched, got %d", harvested.ID, epInfo.HealthITProductID))
+
+	// a capability statement whose software is not in the match file also falls back to the list source
+	csJSON, err := ioutil.ReadFile(filepath.Join("../../testdata", "cerner_capability_dstu2.json"))
+	th.Assert(t, err == nil, err)
+	cs, err := capabilityparser.NewCapabilityStatement(csJSON)
+	th.Assert(t, err == nil, err)
+	epInfo = &endpointmanager.FHIREndpointInfo{URL: "example.com/FHIR/R4", CapabilityStatement: cs}
+	err = MatchEndpointToProduct(ctx, epInfo, store, matchFile)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, epInfo.HealthITProductID == harvested.ID, fmt.Sprintf("expected the harvested product %d to be matched, got %d", harvested.ID, epInfo.HealthITProductID))
+
+	// the match file takes precedence over the list source
+	csJSON, err = ioutil.ReadFile(filepath.Join("../../testdata", "allscripts_capability_dstu2.json"))
+	th.Assert(t, err == nil, err)
+	cs, err = capabilityparser.NewCapabilityStatement(csJSON)
+	th.Assert(t, err == nil, err)
+	epInfo = &endpointmanager.FHIREndpointInfo{URL: "example.com/FHIR/R4", CapabilityStatement: cs}
+	err = MatchEndpointToProduct(ctx, epInfo, store, matchFile)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, epInfo.HealthITProductID == matched.ID, fmt.Sprintf("expected the matched product %d, got %d", matched.ID, epInfo.HealthITProductID))
+
+	// list sources that are not CHPL product numbers are not matched
+	epInfo = &endpointmanager.FHIREndpointInfo{URL: "example.com/FHIR/DSTU2"}
+	err = store.AddFHIREndpoint(ctx, &endpointmanager.FHIREndpoint{URL: epInfo.URL, ListSource: "CorrectVersionAndName"})
+	th.Assert(t, err == nil, err)
+	err = MatchEndpointToProduct(ctx, epInfo, store, matchFile)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, epInfo.HealthITProductID == 0, fmt.Sprintf("expected no product to be matched, got %d", epInfo.HealthITProductID))
+}
+
+func Test_chplProductNumberRegex(t *testing.T) {
+	for _, number := range []string{"CHP-006401", "15.04.04.2657.Care.01.00.0.160701", "15.07.07.1447.BE02.02.00.1.180226"} {
+		th.Assert(t, chplProductNumberRegex.MatchString(number), fmt.Sprintf("expected %s to be a CHPL product number", number))
+	}
+	for _, listSource := range []string{"", "https://open.epic.com/MyApps/EndpointsJson", "CHP-0064", "15.04.04.2657.Care.01.00.0"} {
+		th.Assert(t, !chplProductNumberRegex.MatchString(listSource), fmt.Sprintf("expected %s not to be a CHPL product number", listSource))
+	}
+}
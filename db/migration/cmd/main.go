@@ -1,18 +1,49 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
 	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/onc-healthit/lantern-back-end/db/migration/pkg/datamigrations"
+	"github.com/onc-healthit/lantern-back-end/db/migration/pkg/migrator"
 
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/lib/pq"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
 
+const usage = `Usage: main [-dry-run] [command]
+
+Commands:
+  up [N]     apply the next N migrations, or all of the pending migrations if N is not given
+  down N     roll back the last N migrations
+  goto V     apply or roll back migrations until the database is at version V
+  status     print the database's version and the pending migrations
+  force V    set the database's version to V and clear its dirty flag without running any migrations,
+             use -1 for no version
+
+Without a command, the next migration is applied.
+`
+
 func main() {
+	dryRun := flag.Bool("dry-run", false, "print the migrations that would be run without running them")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	command := "up"
+	args := []string{"1"}
+	if flag.NArg() > 0 {
+		command = flag.Arg(0)
+		args = flag.Args()[1:]
+	}
 
 	viper.SetEnvPrefix("lantern")
 	viper.AutomaticEnv()
@@ -29,21 +60,138 @@ func main() {
 
 	db, err := sql.Open("postgres", psqlInfo)
 	if err != nil {
-		log.Error("endpoint URL parsing error (open postgres): ", err.Error())
+		log.Fatal("opening the postgres database failed: ", err)
+	}
+
+	// the Go data migrations run along with the SQL migration of the same version
+	dataMigrations := []migrator.DataMigration{
+		datamigrations.OperationResource(db),
+	}
+
+	m, err := migrator.New(db, "file://./migrations", dataMigrations)
+	if err != nil {
+		log.Fatal(err)
 	}
+	defer m.Close()
+
+	ctx := context.Background()
 
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	var steps []migrator.Step
+	switch command {
+	case "up":
+		n := -1
+		if len(args) > 0 {
+			n = parseArg(args, "N")
+		}
+		steps, err = m.PlanUp(n)
+	case "down":
+		steps, err = m.PlanDown(parseArg(args, "N"))
+	case "goto":
+		target := parseArg(args, "V")
+		if target < 0 {
+			log.Fatalf("the version must not be negative")
+		}
+		steps, err = m.PlanGoto(uint(target))
+	case "status":
+		printStatus(m)
+		return
+	case "force":
+		version := parseArg(args, "V")
+		if *dryRun {
+			fmt.Printf("Would force the version to %d\n", version)
+			return
+		}
+		err = m.Force(version)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Infof("Forced the version to %d", version)
+		return
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
 	if err != nil {
-		log.Error("endpoint URL parsing error (with instance postgres): ", err.Error())
+		log.Fatal(err)
 	}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://./migrations",
-		"postgres", driver)
+	if *dryRun {
+		printPlan(steps)
+		return
+	}
 
-	if err := m.Steps(1); err != nil {
+	err = m.Apply(ctx, steps)
+	if err != nil {
 		version, dirty, retError := m.Version()
 		fmt.Printf("Version %+v with Dirty Flag %+v threw Error \n %+v", version, dirty, retError)
 		log.Fatal(err)
 	}
+
+	// once the history tables are partitioned, make sure the partitions for the upcoming months exist
+	var partitioned bool
+	err = db.QueryRow("SELECT to_regprocedure('create_history_partitions(date,date)') IS NOT NULL").Scan(&partitioned)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if partitioned {
+		_, err = db.Exec("SELECT create_history_partitions(CURRENT_DATE, (CURRENT_DATE + INTERVAL '3 months')::DATE)")
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// parseArg parses the command's single integer argument.
+func parseArg(args []string, name string) int {
+	if len(args) != 1 {
+		log.Fatalf("expected the argument %s", name)
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		log.Fatalf("the argument %s must be a number: %s", name, err)
+	}
+	return n
+}
+
+func printPlan(steps []migrator.Step) {
+	if len(steps) == 0 {
+		fmt.Println("No migrations to run")
+		return
+	}
+	fmt.Println("Migrations to run:")
+	printSteps(steps)
+}
+
+func printStatus(m *migrator.Migrator) {
+	version, dirty, err := m.Version()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if version == migrator.NilVersion {
+		fmt.Println("Version: none")
+	} else {
+		fmt.Printf("Version: %d\n", version)
+	}
+	fmt.Printf("Dirty: %t\n", dirty)
+
+	pending, err := m.Pending()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Pending migrations: %d\n", len(pending))
+	printSteps(pending)
+}
+
+func printSteps(steps []migrator.Step) {
+	for _, step := range steps {
+		fmt.Printf("  %s\n", step)
+		// data migrations are run after a migration is applied and before it is rolled back
+		order := "then"
+		if !step.Up {
+			order = "first"
+		}
+		for _, dataMigration := range step.DataMigrations {
+			fmt.Printf("    %s run data migration %s\n", order, dataMigration.Name)
+		}
+	}
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/onc-healthit/lantern-back-end/db/migration/pkg/schemadrift"
+
+	_ "github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Builds the schema from dbsetup.sql and the schema from the migrations in temporary schemas of the database and
+// prints the differences between them. Exits with a non-zero status if there are any.
+func main() {
+	setupFile := flag.String("setup", "../../../sql/dbsetup.sql", "the database setup file")
+	migrationsDir := flag.String("migrations", "../../migrations", "the migrations directory")
+	baseFile := flag.String("base", "", "a file creating the schema as it was before the first migration. If not given, the base is made by rolling back every migration from the setup file's schema")
+	flag.Parse()
+
+	viper.SetEnvPrefix("lantern")
+	viper.AutomaticEnv()
+	host := viper.GetString("dbhost")
+	port := viper.GetInt("dbport")
+	user := viper.GetString("dbuser")
+	password := viper.GetString("dbpassword")
+	dbname := viper.GetString("dbname")
+	sslmode := viper.GetString("dbsslmode")
+
+	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s "+
+		"password=%s dbname=%s sslmode=%s",
+		host, port, user, password, dbname, sslmode)
+
+	db, err := sql.Open("postgres", psqlInfo)
+	if err != nil {
+		log.Fatal("opening the postgres database failed: ", err)
+	}
+	defer db.Close()
+
+	diffs, err := schemadrift.Check(context.Background(), db, *setupFile, *migrationsDir, *baseFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(diffs) == 0 {
+		fmt.Println("The schema from dbsetup.sql matches the schema from the migrations")
+		return
+	}
+	fmt.Printf("Found %d differences between the schema from dbsetup.sql and the schema from the migrations:\n", len(diffs))
+	for _, diff := range diffs {
+		fmt.Println(diff)
+	}
+	os.Exit(1)
+}
@@ -5,8 +5,8 @@ DROP VIEW IF EXISTS endpoint_export;
 ALTER TABLE fhir_endpoints_info DROP COLUMN IF EXISTS operation_resource CASCADE;
 ALTER TABLE fhir_endpoints_info_history DROP COLUMN IF EXISTS operation_resource CASCADE;
 
-ALTER TABLE fhir_endpoints_info ADD COLUMN supported_resources JSONB;
-ALTER TABLE fhir_endpoints_info_history ADD COLUMN supported_resources JSONB;
+ALTER TABLE fhir_endpoints_info ADD COLUMN IF NOT EXISTS supported_resources JSONB;
+ALTER TABLE fhir_endpoints_info_history ADD COLUMN IF NOT EXISTS supported_resources JSONB;
 
 CREATE or REPLACE VIEW endpoint_export AS
 SELECT endpts.url, endpts.list_source, endpts.organization_names AS endpoint_names,
@@ -1,11 +1,37 @@
 BEGIN;
 
-ALTER TABLE fhir_endpoints_info DROP COLUMN IF EXISTS validation_result_id CASCADE;
-ALTER TABLE fhir_endpoints_info_history DROP COLUMN IF EXISTS validation_result_id CASCADE;
-
 ALTER TABLE fhir_endpoints_info ADD COLUMN validation JSONB;
 ALTER TABLE fhir_endpoints_info_history ADD COLUMN validation JSONB;
 
+-- Move each row's validation back into the validation field before the validation tables are dropped. The triggers
+-- are disabled so that moving the info rows' validations does not add history entries or change their update times.
+ALTER TABLE fhir_endpoints_info DISABLE TRIGGER add_fhir_endpoint_info_history_trigger;
+ALTER TABLE fhir_endpoints_info DISABLE TRIGGER set_timestamp_fhir_endpoints_info;
+
+CREATE TEMPORARY TABLE validation_fields ON COMMIT DROP AS
+SELECT validation_result_id, jsonb_build_object('Results', jsonb_agg(jsonb_build_object(
+    'RuleName', rule_name,
+    'Valid', valid,
+    'Expected', expected,
+    'Actual', actual,
+    'Comment', comment,
+    'Reference', reference,
+    'ImplGuide', implementation_guide))) AS validation
+FROM validations
+WHERE validation_result_id IS NOT NULL
+GROUP BY validation_result_id;
+
+UPDATE fhir_endpoints_info AS info SET validation = fields.validation
+FROM validation_fields AS fields WHERE info.validation_result_id = fields.validation_result_id;
+UPDATE fhir_endpoints_info_history AS history SET validation = fields.validation
+FROM validation_fields AS fields WHERE history.validation_result_id = fields.validation_result_id;
+
+ALTER TABLE fhir_endpoints_info ENABLE TRIGGER add_fhir_endpoint_info_history_trigger;
+ALTER TABLE fhir_endpoints_info ENABLE TRIGGER set_timestamp_fhir_endpoints_info;
+
+ALTER TABLE fhir_endpoints_info DROP COLUMN IF EXISTS validation_result_id CASCADE;
+ALTER TABLE fhir_endpoints_info_history DROP COLUMN IF EXISTS validation_result_id CASCADE;
+
 DROP TABLE IF EXISTS validations;
 DROP TABLE IF EXISTS validation_results;
 
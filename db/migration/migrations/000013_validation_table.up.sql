@@ -4,14 +4,11 @@ CREATE TABLE IF NOT EXISTS validation_results (
     id                      SERIAL PRIMARY KEY
 );
 
-ALTER TABLE fhir_endpoints_info 
+ALTER TABLE fhir_endpoints_info
 ADD COLUMN validation_result_id INT REFERENCES validation_results(id) ON DELETE SET NULL;
 ALTER TABLE fhir_endpoints_info_history
 ADD COLUMN validation_result_id INT REFERENCES validation_results(id) ON DELETE SET NULL;
 
-ALTER TABLE fhir_endpoints_info DROP COLUMN IF EXISTS validation CASCADE;
-ALTER TABLE fhir_endpoints_info_history DROP COLUMN IF EXISTS validation CASCADE;
-
 CREATE TABLE IF NOT EXISTS validations (
     rule_name               VARCHAR(500),
     valid                   BOOLEAN,
@@ -23,4 +20,41 @@ CREATE TABLE IF NOT EXISTS validations (
     validation_result_id    INT REFERENCES validation_results(id) ON DELETE SET NULL
 );
 
+-- Move each row's validation into the validation tables before the validation field is dropped. The triggers are
+-- disabled so that moving the info rows' validations does not add history entries or change their update times.
+ALTER TABLE fhir_endpoints_info DISABLE TRIGGER add_fhir_endpoint_info_history_trigger;
+ALTER TABLE fhir_endpoints_info DISABLE TRIGGER set_timestamp_fhir_endpoints_info;
+
+DO $$
+DECLARE
+    entry RECORD;
+    result_id INT;
+BEGIN
+    FOR entry IN
+        SELECT 'fhir_endpoints_info' AS table_name, ctid AS row_id, validation FROM fhir_endpoints_info
+        WHERE validation IS NOT NULL
+        UNION ALL
+        SELECT 'fhir_endpoints_info_history', ctid, validation FROM fhir_endpoints_info_history
+        WHERE validation IS NOT NULL
+    LOOP
+        INSERT INTO validation_results (id) VALUES (DEFAULT) RETURNING id INTO result_id;
+
+        INSERT INTO validations (rule_name, valid, expected, actual, comment, reference, implementation_guide, validation_result_id)
+        SELECT LEFT(rule->>'RuleName', 500), (rule->>'Valid')::BOOLEAN, LEFT(rule->>'Expected', 500),
+            LEFT(rule->>'Actual', 500), LEFT(rule->>'Comment', 500), LEFT(rule->>'Reference', 500),
+            LEFT(rule->>'ImplGuide', 500), result_id
+        FROM jsonb_array_elements(CASE jsonb_typeof(entry.validation->'Results')
+            WHEN 'array' THEN entry.validation->'Results' ELSE '[]'::JSONB END) AS rule;
+
+        EXECUTE format('UPDATE %I SET validation_result_id = $1 WHERE ctid = $2', entry.table_name)
+        USING result_id, entry.row_id;
+    END LOOP;
+END $$;
+
+ALTER TABLE fhir_endpoints_info ENABLE TRIGGER add_fhir_endpoint_info_history_trigger;
+ALTER TABLE fhir_endpoints_info ENABLE TRIGGER set_timestamp_fhir_endpoints_info;
+
+ALTER TABLE fhir_endpoints_info DROP COLUMN IF EXISTS validation CASCADE;
+ALTER TABLE fhir_endpoints_info_history DROP COLUMN IF EXISTS validation CASCADE;
+
 COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP INDEX IF EXISTS metadata_permanent_redirect_idx;
+
+ALTER TABLE fhir_endpoints_metadata DROP COLUMN IF EXISTS redirects;
+ALTER TABLE fhir_endpoints_metadata DROP COLUMN IF EXISTS smart_redirects;
+ALTER TABLE fhir_endpoints_metadata DROP COLUMN IF EXISTS permanent_redirect;
+ALTER TABLE fhir_endpoints_metadata DROP COLUMN IF EXISTS canonical_url;
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+ALTER TABLE fhir_endpoints_metadata ADD COLUMN IF NOT EXISTS redirects JSONB;
+ALTER TABLE fhir_endpoints_metadata ADD COLUMN IF NOT EXISTS smart_redirects JSONB;
+ALTER TABLE fhir_endpoints_metadata ADD COLUMN IF NOT EXISTS permanent_redirect BOOLEAN DEFAULT FALSE;
+ALTER TABLE fhir_endpoints_metadata ADD COLUMN IF NOT EXISTS canonical_url VARCHAR(500) DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS metadata_permanent_redirect_idx ON fhir_endpoints_metadata(permanent_redirect);
+
+COMMIT;
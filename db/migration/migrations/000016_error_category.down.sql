@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS metadata_error_category_idx;
+
+ALTER TABLE fhir_endpoints_metadata DROP COLUMN IF EXISTS error_category;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE fhir_endpoints_metadata ADD COLUMN IF NOT EXISTS error_category VARCHAR(500) DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS metadata_error_category_idx ON fhir_endpoints_metadata(error_category);
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+DROP VIEW IF EXISTS endpoint_hosting;
+
+DROP TRIGGER IF EXISTS add_fhir_endpoint_hosting_history_trigger ON fhir_endpoints_hosting;
+DROP TRIGGER IF EXISTS set_timestamp_fhir_endpoints_hosting ON fhir_endpoints_hosting;
+DROP FUNCTION IF EXISTS add_fhir_endpoint_hosting_history();
+
+DROP INDEX IF EXISTS fhir_endpoints_hosting_history_url_idx;
+
+DROP TABLE IF EXISTS fhir_endpoints_hosting_history;
+DROP TABLE IF EXISTS fhir_endpoints_hosting;
+
+COMMIT;
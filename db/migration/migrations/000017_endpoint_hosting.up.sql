@@ -0,0 +1,70 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS fhir_endpoints_hosting (
+    id                      SERIAL PRIMARY KEY,
+    url                     VARCHAR(500) UNIQUE,
+    hostname                VARCHAR(500),
+    ip_addresses            JSONB,
+    errors                  VARCHAR(500),
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS fhir_endpoints_hosting_history (
+    operation               CHAR(1) NOT NULL,
+    entered_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    user_id                 VARCHAR(500),
+    id                      INT, -- should link to fhir_endpoints_hosting(id). not using 'reference' because if the original is deleted, we still want the historical copies to remain.
+    url                     VARCHAR(500),
+    hostname                VARCHAR(500),
+    ip_addresses            JSONB,
+    errors                  VARCHAR(500),
+    created_at              TIMESTAMPTZ,
+    updated_at              TIMESTAMPTZ
+);
+
+CREATE OR REPLACE FUNCTION add_fhir_endpoint_hosting_history() RETURNS TRIGGER AS $fhir_endpoints_hosting_history$
+    BEGIN
+        --
+        -- Create a row in fhir_endpoints_hosting_history to reflect the operation performed on fhir_endpoints_hosting,
+        -- make use of the special variable TG_OP to work out the operation.
+        --
+        IF (TG_OP = 'DELETE') THEN
+            INSERT INTO fhir_endpoints_hosting_history SELECT 'D', now(), user, OLD.*;
+            RETURN OLD;
+        ELSIF (TG_OP = 'UPDATE') THEN
+            INSERT INTO fhir_endpoints_hosting_history SELECT 'U', now(), user, NEW.*;
+            RETURN NEW;
+        ELSIF (TG_OP = 'INSERT') THEN
+            INSERT INTO fhir_endpoints_hosting_history SELECT 'I', now(), user, NEW.*;
+            RETURN NEW;
+        END IF;
+        RETURN NULL; -- result is ignored since this is an AFTER trigger
+    END;
+$fhir_endpoints_hosting_history$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS set_timestamp_fhir_endpoints_hosting ON fhir_endpoints_hosting;
+CREATE TRIGGER set_timestamp_fhir_endpoints_hosting
+BEFORE UPDATE ON fhir_endpoints_hosting
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+DROP TRIGGER IF EXISTS add_fhir_endpoint_hosting_history_trigger ON fhir_endpoints_hosting;
+CREATE TRIGGER add_fhir_endpoint_hosting_history_trigger
+AFTER INSERT OR UPDATE OR DELETE on fhir_endpoints_hosting
+FOR EACH ROW
+EXECUTE PROCEDURE add_fhir_endpoint_hosting_history();
+
+CREATE or REPLACE VIEW endpoint_hosting AS
+SELECT hosting.url, hosting.hostname, ips->>'ip' AS ip_address, ips->>'family' AS ip_family,
+    (ips->>'asn')::INTEGER AS asn, ips->>'asOrganization' AS as_organization, ips->>'countryCode' AS country_code,
+    vendors.name AS vendor_name
+FROM fhir_endpoints_hosting AS hosting
+-- hosts that could not be resolved have null ip_addresses and are left out of the view
+CROSS JOIN LATERAL jsonb_array_elements(CASE WHEN jsonb_typeof(hosting.ip_addresses) = 'array' THEN hosting.ip_addresses ELSE '[]'::jsonb END) AS ips
+LEFT JOIN fhir_endpoints_info AS endpts_info ON hosting.url = endpts_info.url AND endpts_info.requested_fhir_version = 'None'
+LEFT JOIN vendors ON endpts_info.vendor_id = vendors.id;
+
+CREATE INDEX IF NOT EXISTS fhir_endpoints_hosting_history_url_idx ON fhir_endpoints_hosting_history (url);
+
+COMMIT;
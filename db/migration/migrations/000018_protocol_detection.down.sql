@@ -0,0 +1,13 @@
+BEGIN;
+
+ALTER TABLE fhir_endpoints_info DROP COLUMN IF EXISTS http_version;
+ALTER TABLE fhir_endpoints_info DROP COLUMN IF EXISTS alpn_protocol;
+ALTER TABLE fhir_endpoints_info DROP COLUMN IF EXISTS ip_reachability;
+
+ALTER TABLE fhir_endpoints_info_history DROP COLUMN IF EXISTS http_version;
+ALTER TABLE fhir_endpoints_info_history DROP COLUMN IF EXISTS alpn_protocol;
+ALTER TABLE fhir_endpoints_info_history DROP COLUMN IF EXISTS ip_reachability;
+
+ALTER TABLE fhir_endpoints_metadata DROP COLUMN IF EXISTS ip_response_times;
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+-- columns are added to fhir_endpoints_info and fhir_endpoints_info_history in the same order so that
+-- the history trigger can continue to copy rows with NEW.*
+ALTER TABLE fhir_endpoints_info ADD COLUMN IF NOT EXISTS http_version VARCHAR(500) DEFAULT '';
+ALTER TABLE fhir_endpoints_info ADD COLUMN IF NOT EXISTS alpn_protocol VARCHAR(500) DEFAULT '';
+ALTER TABLE fhir_endpoints_info ADD COLUMN IF NOT EXISTS ip_reachability JSONB;
+
+ALTER TABLE fhir_endpoints_info_history ADD COLUMN IF NOT EXISTS http_version VARCHAR(500) DEFAULT '';
+ALTER TABLE fhir_endpoints_info_history ADD COLUMN IF NOT EXISTS alpn_protocol VARCHAR(500) DEFAULT '';
+ALTER TABLE fhir_endpoints_info_history ADD COLUMN IF NOT EXISTS ip_reachability JSONB;
+
+ALTER TABLE fhir_endpoints_metadata ADD COLUMN IF NOT EXISTS ip_response_times JSONB;
+
+COMMIT;
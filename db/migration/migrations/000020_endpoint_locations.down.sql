@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE fhir_endpoints DROP COLUMN IF EXISTS locations;
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE fhir_endpoints ADD COLUMN IF NOT EXISTS locations JSONB;
+
+COMMIT;
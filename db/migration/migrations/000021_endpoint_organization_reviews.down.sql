@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS endpoint_organization_reviews;
+
+COMMIT;
@@ -0,0 +1,25 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS endpoint_organization_reviews (
+    url                     VARCHAR(500),
+    organization_npi_id     VARCHAR(500),
+    confidence              NUMERIC (5, 3),
+    score_breakdown         JSONB,
+    status                  VARCHAR(500) NOT NULL DEFAULT 'pending',
+    decided_by              VARCHAR(500) DEFAULT '',
+    decided_at              TIMESTAMPTZ,
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT endpoint_org_review PRIMARY KEY (url, organization_npi_id)
+);
+
+DROP TRIGGER IF EXISTS set_timestamp_endpoint_organization_reviews ON endpoint_organization_reviews;
+
+CREATE TRIGGER set_timestamp_endpoint_organization_reviews
+BEFORE UPDATE ON endpoint_organization_reviews
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE INDEX IF NOT EXISTS endpoint_organization_reviews_status_idx ON endpoint_organization_reviews (status);
+
+COMMIT;
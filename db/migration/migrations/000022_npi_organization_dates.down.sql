@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE npi_organizations DROP COLUMN IF EXISTS last_update_date;
+ALTER TABLE npi_organizations DROP COLUMN IF EXISTS deactivation_date;
+ALTER TABLE npi_organizations DROP COLUMN IF EXISTS reactivation_date;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE npi_organizations ADD COLUMN IF NOT EXISTS last_update_date DATE;
+ALTER TABLE npi_organizations ADD COLUMN IF NOT EXISTS deactivation_date DATE;
+ALTER TABLE npi_organizations ADD COLUMN IF NOT EXISTS reactivation_date DATE;
+
+COMMIT;
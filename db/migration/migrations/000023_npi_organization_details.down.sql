@@ -0,0 +1,12 @@
+BEGIN;
+
+DROP VIEW IF EXISTS npi_organization_provider_types;
+
+DROP TABLE IF EXISTS npi_organization_taxonomies;
+DROP TABLE IF EXISTS npi_organization_identifiers;
+DROP TABLE IF EXISTS nucc_taxonomies;
+
+ALTER TABLE npi_organizations DROP COLUMN IF EXISTS mailing_location;
+ALTER TABLE npi_organizations DROP COLUMN IF EXISTS authorized_official;
+
+COMMIT;
@@ -0,0 +1,52 @@
+BEGIN;
+
+ALTER TABLE npi_organizations ADD COLUMN IF NOT EXISTS mailing_location JSONB;
+ALTER TABLE npi_organizations ADD COLUMN IF NOT EXISTS authorized_official JSONB;
+
+CREATE TABLE IF NOT EXISTS npi_organization_taxonomies (
+    npi_id                      VARCHAR(500) REFERENCES npi_organizations(npi_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    position                    INTEGER,
+    code                        VARCHAR(500),
+    license_number              VARCHAR(500),
+    license_state               VARCHAR(500),
+    is_primary                  BOOLEAN NOT NULL DEFAULT FALSE,
+    CONSTRAINT npi_organization_taxonomy PRIMARY KEY (npi_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS npi_organization_identifiers (
+    npi_id                      VARCHAR(500) REFERENCES npi_organizations(npi_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    position                    INTEGER,
+    identifier                  VARCHAR(500),
+    type_code                   VARCHAR(500),
+    state                       VARCHAR(500),
+    issuer                      VARCHAR(500),
+    CONSTRAINT npi_organization_identifier PRIMARY KEY (npi_id, position)
+);
+
+CREATE TABLE IF NOT EXISTS nucc_taxonomies (
+    code                        VARCHAR(500) PRIMARY KEY,
+    grouping_name               VARCHAR(500),
+    classification              VARCHAR(500),
+    specialization              VARCHAR(500),
+    display_name                VARCHAR(500),
+    created_at                  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at                  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+DROP TRIGGER IF EXISTS set_timestamp_nucc_taxonomies ON nucc_taxonomies;
+
+CREATE TRIGGER set_timestamp_nucc_taxonomies
+BEFORE UPDATE ON nucc_taxonomies
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE INDEX IF NOT EXISTS npi_organization_taxonomies_code_idx ON npi_organization_taxonomies (code);
+
+CREATE or REPLACE VIEW npi_organization_provider_types AS
+SELECT orgs.npi_id, orgs.name, taxonomies.code AS taxonomy, taxonomies.is_primary,
+    nucc.grouping_name, nucc.classification, nucc.specialization, nucc.display_name
+FROM npi_organizations AS orgs
+JOIN npi_organization_taxonomies AS taxonomies ON orgs.npi_id = taxonomies.npi_id
+LEFT JOIN nucc_taxonomies AS nucc ON taxonomies.code = nucc.code;
+
+COMMIT;
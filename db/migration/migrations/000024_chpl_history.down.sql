@@ -0,0 +1,19 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS add_vendor_history_trigger ON vendors;
+DROP FUNCTION IF EXISTS add_vendor_history();
+DROP TRIGGER IF EXISTS add_healthit_product_history_trigger ON healthit_products;
+DROP FUNCTION IF EXISTS add_healthit_product_history();
+DROP TRIGGER IF EXISTS add_certification_criteria_history_trigger ON certification_criteria;
+DROP FUNCTION IF EXISTS add_certification_criteria_history();
+
+DROP INDEX IF EXISTS vendors_history_id_idx;
+DROP INDEX IF EXISTS healthit_products_history_id_idx;
+DROP INDEX IF EXISTS certification_criteria_history_id_idx;
+
+DROP TABLE IF EXISTS vendors_history;
+DROP TABLE IF EXISTS healthit_products_history;
+DROP TABLE IF EXISTS certification_criteria_history;
+DROP TABLE IF EXISTS chpl_syncs;
+
+COMMIT;
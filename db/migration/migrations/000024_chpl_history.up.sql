@@ -0,0 +1,163 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS vendors_history (
+    operation               CHAR(1) NOT NULL,
+    entered_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    user_id                 VARCHAR(500),
+    id                      INT, -- should link to vendors(id). not using 'reference' because if the original is deleted, we still want the historical copies to remain.
+    name                    VARCHAR(500),
+    developer_code          VARCHAR(500),
+    url                     VARCHAR(500),
+    location                JSONB,
+    status                  VARCHAR(500),
+    last_modified_in_chpl   TIMESTAMPTZ,
+    chpl_id                 INTEGER,
+    created_at              TIMESTAMPTZ,
+    updated_at              TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS healthit_products_history (
+    operation               CHAR(1) NOT NULL,
+    entered_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    user_id                 VARCHAR(500),
+    id                      INT, -- should link to healthit_products(id). not using 'reference' because if the original is deleted, we still want the historical copies to remain.
+    name                    VARCHAR(500),
+    version                 VARCHAR(500),
+    vendor_id               INT,
+    location                JSONB,
+    authorization_standard  VARCHAR(500),
+    api_syntax              VARCHAR(500),
+    api_url                 VARCHAR(500),
+    certification_criteria  JSONB,
+    certification_status    VARCHAR(500),
+    certification_date      DATE,
+    certification_edition   VARCHAR(500),
+    last_modified_in_chpl   DATE,
+    chpl_id                 VARCHAR(500),
+    created_at              TIMESTAMPTZ,
+    updated_at              TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS certification_criteria_history (
+    operation               CHAR(1) NOT NULL,
+    entered_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    user_id                 VARCHAR(500),
+    id                      INT, -- should link to certification_criteria(id). not using 'reference' because if the original is deleted, we still want the historical copies to remain.
+    certification_id        INTEGER,
+    cerification_number     VARCHAR(500),
+    title                   VARCHAR(500),
+    certification_edition_id INTEGER,
+    certification_edition   VARCHAR(500),
+    description             VARCHAR(500),
+    removed                 BOOLEAN,
+    created_at              TIMESTAMPTZ,
+    updated_at              TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS chpl_syncs (
+    id                      SERIAL PRIMARY KEY,
+    mode                    VARCHAR(500),
+    started_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at            TIMESTAMPTZ
+);
+
+CREATE OR REPLACE FUNCTION add_vendor_history() RETURNS TRIGGER AS $vendors_history$
+    BEGIN
+        --
+        -- Create a row in vendors_history to reflect the operation performed on vendors,
+        -- make use of the special variable TG_OP to work out the operation.
+        -- Updates that only change updated_at are not recorded so that each CHPL sync only adds the rows that changed.
+        --
+        IF (TG_OP = 'DELETE') THEN
+            INSERT INTO vendors_history SELECT 'D', now(), user, OLD.*;
+            RETURN OLD;
+        ELSIF (TG_OP = 'UPDATE') THEN
+            IF (to_jsonb(OLD) - 'updated_at') IS DISTINCT FROM (to_jsonb(NEW) - 'updated_at') THEN
+                INSERT INTO vendors_history SELECT 'U', now(), user, NEW.*;
+            END IF;
+            RETURN NEW;
+        ELSIF (TG_OP = 'INSERT') THEN
+            INSERT INTO vendors_history SELECT 'I', now(), user, NEW.*;
+            RETURN NEW;
+        END IF;
+        RETURN NULL; -- result is ignored since this is an AFTER trigger
+    END;
+$vendors_history$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION add_healthit_product_history() RETURNS TRIGGER AS $healthit_products_history$
+    BEGIN
+        --
+        -- Create a row in healthit_products_history to reflect the operation performed on healthit_products,
+        -- make use of the special variable TG_OP to work out the operation.
+        -- Updates that only change updated_at are not recorded so that each CHPL sync only adds the rows that changed.
+        --
+        IF (TG_OP = 'DELETE') THEN
+            INSERT INTO healthit_products_history SELECT 'D', now(), user, OLD.*;
+            RETURN OLD;
+        ELSIF (TG_OP = 'UPDATE') THEN
+            IF (to_jsonb(OLD) - 'updated_at') IS DISTINCT FROM (to_jsonb(NEW) - 'updated_at') THEN
+                INSERT INTO healthit_products_history SELECT 'U', now(), user, NEW.*;
+            END IF;
+            RETURN NEW;
+        ELSIF (TG_OP = 'INSERT') THEN
+            INSERT INTO healthit_products_history SELECT 'I', now(), user, NEW.*;
+            RETURN NEW;
+        END IF;
+        RETURN NULL; -- result is ignored since this is an AFTER trigger
+    END;
+$healthit_products_history$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION add_certification_criteria_history() RETURNS TRIGGER AS $certification_criteria_history$
+    BEGIN
+        --
+        -- Create a row in certification_criteria_history to reflect the operation performed on certification_criteria,
+        -- make use of the special variable TG_OP to work out the operation.
+        -- Updates that only change updated_at are not recorded so that each CHPL sync only adds the rows that changed.
+        --
+        IF (TG_OP = 'DELETE') THEN
+            INSERT INTO certification_criteria_history SELECT 'D', now(), user, OLD.*;
+            RETURN OLD;
+        ELSIF (TG_OP = 'UPDATE') THEN
+            IF (to_jsonb(OLD) - 'updated_at') IS DISTINCT FROM (to_jsonb(NEW) - 'updated_at') THEN
+                INSERT INTO certification_criteria_history SELECT 'U', now(), user, NEW.*;
+            END IF;
+            RETURN NEW;
+        ELSIF (TG_OP = 'INSERT') THEN
+            INSERT INTO certification_criteria_history SELECT 'I', now(), user, NEW.*;
+            RETURN NEW;
+        END IF;
+        RETURN NULL; -- result is ignored since this is an AFTER trigger
+    END;
+$certification_criteria_history$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS add_vendor_history_trigger ON vendors;
+CREATE TRIGGER add_vendor_history_trigger
+AFTER INSERT OR UPDATE OR DELETE on vendors
+FOR EACH ROW
+EXECUTE PROCEDURE add_vendor_history();
+
+DROP TRIGGER IF EXISTS add_healthit_product_history_trigger ON healthit_products;
+CREATE TRIGGER add_healthit_product_history_trigger
+AFTER INSERT OR UPDATE OR DELETE on healthit_products
+FOR EACH ROW
+EXECUTE PROCEDURE add_healthit_product_history();
+
+DROP TRIGGER IF EXISTS add_certification_criteria_history_trigger ON certification_criteria;
+CREATE TRIGGER add_certification_criteria_history_trigger
+AFTER INSERT OR UPDATE OR DELETE on certification_criteria
+FOR EACH ROW
+EXECUTE PROCEDURE add_certification_criteria_history();
+
+CREATE INDEX IF NOT EXISTS vendors_history_id_idx ON vendors_history (id, entered_at);
+CREATE INDEX IF NOT EXISTS healthit_products_history_id_idx ON healthit_products_history (id, entered_at);
+CREATE INDEX IF NOT EXISTS certification_criteria_history_id_idx ON certification_criteria_history (id, entered_at);
+
+-- record the current rows so that the first changes after the migration can be compared with them
+INSERT INTO vendors_history SELECT 'I', vendors.updated_at, user, vendors.* FROM vendors
+WHERE NOT EXISTS (SELECT 1 FROM vendors_history WHERE vendors_history.id = vendors.id);
+INSERT INTO healthit_products_history SELECT 'I', healthit_products.updated_at, user, healthit_products.* FROM healthit_products
+WHERE NOT EXISTS (SELECT 1 FROM healthit_products_history WHERE healthit_products_history.id = healthit_products.id);
+INSERT INTO certification_criteria_history SELECT 'I', certification_criteria.updated_at, user, certification_criteria.* FROM certification_criteria
+WHERE NOT EXISTS (SELECT 1 FROM certification_criteria_history WHERE certification_criteria_history.id = certification_criteria.id);
+
+COMMIT;
@@ -0,0 +1,106 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS add_fhir_endpoint_info_history_trigger ON fhir_endpoints_info;
+DROP VIEW IF EXISTS endpoint_export;
+DROP VIEW IF EXISTS fhir_endpoints_info_with_documents;
+DROP VIEW IF EXISTS fhir_endpoints_info_history_with_documents;
+
+DROP INDEX IF EXISTS implementation_guide_idx;
+DROP INDEX IF EXISTS resource_type_idx;
+DROP INDEX IF EXISTS capstat_url_idx;
+DROP INDEX IF EXISTS capstat_version_idx;
+DROP INDEX IF EXISTS capstat_name_idx;
+DROP INDEX IF EXISTS capstat_title_idx;
+DROP INDEX IF EXISTS capstat_date_idx;
+DROP INDEX IF EXISTS capstat_publisher_idx;
+DROP INDEX IF EXISTS capstat_description_idx;
+DROP INDEX IF EXISTS capstat_purpose_idx;
+DROP INDEX IF EXISTS capstat_copyright_idx;
+DROP INDEX IF EXISTS capstat_software_name_idx;
+DROP INDEX IF EXISTS capstat_software_version_idx;
+DROP INDEX IF EXISTS capstat_software_releaseDate_idx;
+DROP INDEX IF EXISTS capstat_implementation_description_idx;
+DROP INDEX IF EXISTS capstat_implementation_url_idx;
+DROP INDEX IF EXISTS capstat_implementation_custodian_idx;
+DROP INDEX IF EXISTS security_code_idx;
+DROP INDEX IF EXISTS security_service_idx;
+DROP INDEX IF EXISTS smart_capabilities_idx;
+DROP INDEX IF EXISTS fhir_endpoints_info_capability_statement_hash_idx;
+DROP INDEX IF EXISTS fhir_endpoints_info_history_capability_statement_hash_idx;
+
+ALTER TABLE fhir_endpoints_info ADD COLUMN IF NOT EXISTS capability_statement JSONB;
+ALTER TABLE fhir_endpoints_info ADD COLUMN IF NOT EXISTS smart_response JSONB;
+ALTER TABLE fhir_endpoints_info_history ADD COLUMN IF NOT EXISTS capability_statement JSONB;
+ALTER TABLE fhir_endpoints_info_history ADD COLUMN IF NOT EXISTS smart_response JSONB;
+
+ALTER TABLE fhir_endpoints_info DISABLE TRIGGER set_timestamp_fhir_endpoints_info;
+
+UPDATE fhir_endpoints_info SET
+    capability_statement = (SELECT document FROM fhir_endpoints_documents WHERE hash = capability_statement_hash),
+    smart_response = (SELECT document FROM fhir_endpoints_documents WHERE hash = smart_response_hash);
+
+UPDATE fhir_endpoints_info_history SET
+    capability_statement = (SELECT document FROM fhir_endpoints_documents WHERE hash = capability_statement_hash),
+    smart_response = (SELECT document FROM fhir_endpoints_documents WHERE hash = smart_response_hash);
+
+ALTER TABLE fhir_endpoints_info ENABLE TRIGGER set_timestamp_fhir_endpoints_info;
+
+ALTER TABLE fhir_endpoints_info DROP COLUMN IF EXISTS capability_statement_hash;
+ALTER TABLE fhir_endpoints_info DROP COLUMN IF EXISTS smart_response_hash;
+ALTER TABLE fhir_endpoints_info_history DROP COLUMN IF EXISTS capability_statement_hash;
+ALTER TABLE fhir_endpoints_info_history DROP COLUMN IF EXISTS smart_response_hash;
+
+DROP FUNCTION IF EXISTS add_fhir_endpoints_document(JSONB);
+DROP FUNCTION IF EXISTS fhir_endpoints_document_hash(JSONB);
+DROP TABLE IF EXISTS fhir_endpoints_documents;
+
+CREATE TRIGGER add_fhir_endpoint_info_history_trigger
+AFTER INSERT OR UPDATE OR DELETE on fhir_endpoints_info
+FOR EACH ROW
+WHEN (current_setting('metadata.setting', 't') IS NULL OR current_setting('metadata.setting', 't') = 'FALSE')
+EXECUTE PROCEDURE add_fhir_endpoint_info_history();
+
+CREATE or REPLACE VIEW endpoint_export AS
+SELECT endpts.url, endpts.list_source, endpts.organization_names AS endpoint_names,
+    vendors.name as vendor_name,
+    endpts_info.tls_version, endpts_info.mime_types, endpts_metadata.http_response,
+    endpts_metadata.response_time_seconds, endpts_metadata.smart_http_response, endpts_metadata.errors,
+    endpts_info.capability_fhir_version AS FHIR_VERSION,
+    endpts_info.capability_statement->>'publisher' AS PUBLISHER,
+    endpts_info.capability_statement->'software'->'name' AS SOFTWARE_NAME,
+    endpts_info.capability_statement->'software'->'version' AS SOFTWARE_VERSION,
+    endpts_info.capability_statement->'software'->'releaseDate' AS SOFTWARE_RELEASEDATE,
+    endpts_info.updated_at AS INFO_UPDATED, endpts_info.created_at AS INFO_CREATED,
+    endpts_info.requested_fhir_version,
+    orgs.name AS ORGANIZATION_NAME, orgs.secondary_name AS ORGANIZATION_SECONDARY_NAME,
+    orgs.taxonomy, orgs.Location->>'state' AS STATE, orgs.Location->>'zipcode' AS ZIPCODE,
+    links.confidence AS MATCH_SCORE, endpts_metadata.availability
+FROM endpoint_organization AS links
+RIGHT JOIN fhir_endpoints AS endpts ON links.url = endpts.url
+LEFT JOIN fhir_endpoints_info AS endpts_info ON endpts.url = endpts_info.url
+LEFT JOIN fhir_endpoints_metadata AS endpts_metadata ON endpts_info.metadata_id = endpts_metadata.id
+LEFT JOIN vendors ON endpts_info.vendor_id = vendors.id
+LEFT JOIN npi_organizations AS orgs ON links.organization_npi_id = orgs.npi_id;
+
+CREATE INDEX implementation_guide_idx ON fhir_endpoints_info ((capability_statement->>'implementationGuide'));
+CREATE INDEX resource_type_idx ON fhir_endpoints_info (((capability_statement::json#>'{rest,0,resource}') ->> 'type'));
+CREATE INDEX capstat_url_idx ON fhir_endpoints_info ((capability_statement->>'url'));
+CREATE INDEX capstat_version_idx ON fhir_endpoints_info ((capability_statement->>'version'));
+CREATE INDEX capstat_name_idx ON fhir_endpoints_info ((capability_statement->>'name'));
+CREATE INDEX capstat_title_idx ON fhir_endpoints_info ((capability_statement->>'title'));
+CREATE INDEX capstat_date_idx ON fhir_endpoints_info ((capability_statement->>'date'));
+CREATE INDEX capstat_publisher_idx ON fhir_endpoints_info ((capability_statement->>'publisher'));
+CREATE INDEX capstat_description_idx ON fhir_endpoints_info ((capability_statement->>'description'));
+CREATE INDEX capstat_purpose_idx ON fhir_endpoints_info ((capability_statement->>'purpose'));
+CREATE INDEX capstat_copyright_idx ON fhir_endpoints_info ((capability_statement->>'copyright'));
+CREATE INDEX capstat_software_name_idx ON fhir_endpoints_info ((capability_statement->'software'->>'name'));
+CREATE INDEX capstat_software_version_idx ON fhir_endpoints_info ((capability_statement->'software'->>'version'));
+CREATE INDEX capstat_software_releaseDate_idx ON fhir_endpoints_info ((capability_statement->'software'->>'releaseDate'));
+CREATE INDEX capstat_implementation_description_idx ON fhir_endpoints_info ((capability_statement->'implementation'->>'description'));
+CREATE INDEX capstat_implementation_url_idx ON fhir_endpoints_info ((capability_statement->'implementation'->>'url'));
+CREATE INDEX capstat_implementation_custodian_idx ON fhir_endpoints_info ((capability_statement->'implementation'->>'custodian'));
+CREATE INDEX security_code_idx ON fhir_endpoints_info ((capability_statement::json#>'{rest,0,security,service}'->'coding'->>'code'));
+CREATE INDEX security_service_idx ON fhir_endpoints_info ((capability_statement::json#>'{rest,0,security}' -> 'service' ->> 'text'));
+CREATE INDEX smart_capabilities_idx ON fhir_endpoints_info ((smart_response->'capabilities'));
+
+COMMIT;
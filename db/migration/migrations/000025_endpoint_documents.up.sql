@@ -0,0 +1,144 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS add_fhir_endpoint_info_history_trigger ON fhir_endpoints_info;
+DROP VIEW IF EXISTS endpoint_export;
+
+DROP INDEX IF EXISTS implementation_guide_idx;
+DROP INDEX IF EXISTS resource_type_idx;
+DROP INDEX IF EXISTS capstat_url_idx;
+DROP INDEX IF EXISTS capstat_version_idx;
+DROP INDEX IF EXISTS capstat_name_idx;
+DROP INDEX IF EXISTS capstat_title_idx;
+DROP INDEX IF EXISTS capstat_date_idx;
+DROP INDEX IF EXISTS capstat_publisher_idx;
+DROP INDEX IF EXISTS capstat_description_idx;
+DROP INDEX IF EXISTS capstat_purpose_idx;
+DROP INDEX IF EXISTS capstat_copyright_idx;
+DROP INDEX IF EXISTS capstat_software_name_idx;
+DROP INDEX IF EXISTS capstat_software_version_idx;
+DROP INDEX IF EXISTS capstat_software_releaseDate_idx;
+DROP INDEX IF EXISTS capstat_implementation_description_idx;
+DROP INDEX IF EXISTS capstat_implementation_url_idx;
+DROP INDEX IF EXISTS capstat_implementation_custodian_idx;
+DROP INDEX IF EXISTS security_code_idx;
+DROP INDEX IF EXISTS security_service_idx;
+DROP INDEX IF EXISTS smart_capabilities_idx;
+
+CREATE TABLE IF NOT EXISTS fhir_endpoints_documents (
+    hash                    CHAR(64) PRIMARY KEY, -- see fhir_endpoints_document_hash
+    document                JSONB NOT NULL,
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE OR REPLACE FUNCTION fhir_endpoints_document_hash(document JSONB) RETURNS CHAR(64) AS $$
+    -- the text form of a JSONB value is canonical: object keys are sorted, duplicate keys removed and
+    -- whitespace normalized, so documents that only differ in formatting have the same hash.
+    SELECT encode(sha256(convert_to(document::text, 'UTF8')), 'hex');
+$$ LANGUAGE sql IMMUTABLE STRICT;
+
+CREATE OR REPLACE FUNCTION add_fhir_endpoints_document(document JSONB) RETURNS CHAR(64) AS $$
+    DECLARE
+        document_hash CHAR(64);
+    BEGIN
+        --
+        -- Store the document in fhir_endpoints_documents if it is not already there and return its hash.
+        --
+        IF document IS NULL THEN
+            RETURN NULL;
+        END IF;
+        document_hash := fhir_endpoints_document_hash(document);
+        INSERT INTO fhir_endpoints_documents (hash, document) VALUES (document_hash, document)
+            ON CONFLICT (hash) DO NOTHING;
+        RETURN document_hash;
+    END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE fhir_endpoints_info ADD COLUMN IF NOT EXISTS capability_statement_hash CHAR(64) REFERENCES fhir_endpoints_documents(hash) ON DELETE SET NULL;
+ALTER TABLE fhir_endpoints_info ADD COLUMN IF NOT EXISTS smart_response_hash CHAR(64) REFERENCES fhir_endpoints_documents(hash) ON DELETE SET NULL;
+ALTER TABLE fhir_endpoints_info_history ADD COLUMN IF NOT EXISTS capability_statement_hash CHAR(64);
+ALTER TABLE fhir_endpoints_info_history ADD COLUMN IF NOT EXISTS smart_response_hash CHAR(64);
+
+-- the history trigger is dropped above and the timestamp trigger is disabled so that moving the
+-- documents does not add history rows or change updated_at
+ALTER TABLE fhir_endpoints_info DISABLE TRIGGER set_timestamp_fhir_endpoints_info;
+
+UPDATE fhir_endpoints_info SET
+    capability_statement_hash = add_fhir_endpoints_document(capability_statement),
+    smart_response_hash = add_fhir_endpoints_document(smart_response);
+
+UPDATE fhir_endpoints_info_history SET
+    capability_statement_hash = add_fhir_endpoints_document(capability_statement),
+    smart_response_hash = add_fhir_endpoints_document(smart_response);
+
+ALTER TABLE fhir_endpoints_info ENABLE TRIGGER set_timestamp_fhir_endpoints_info;
+
+ALTER TABLE fhir_endpoints_info DROP COLUMN IF EXISTS capability_statement;
+ALTER TABLE fhir_endpoints_info DROP COLUMN IF EXISTS smart_response;
+ALTER TABLE fhir_endpoints_info_history DROP COLUMN IF EXISTS capability_statement;
+ALTER TABLE fhir_endpoints_info_history DROP COLUMN IF EXISTS smart_response;
+
+CREATE TRIGGER add_fhir_endpoint_info_history_trigger
+AFTER INSERT OR UPDATE OR DELETE on fhir_endpoints_info
+FOR EACH ROW
+WHEN (current_setting('metadata.setting', 't') IS NULL OR current_setting('metadata.setting', 't') = 'FALSE')
+EXECUTE PROCEDURE add_fhir_endpoint_info_history();
+
+CREATE or REPLACE VIEW fhir_endpoints_info_with_documents AS
+SELECT info.*, capstat.document AS capability_statement, smart.document AS smart_response
+FROM fhir_endpoints_info AS info
+LEFT JOIN fhir_endpoints_documents AS capstat ON info.capability_statement_hash = capstat.hash
+LEFT JOIN fhir_endpoints_documents AS smart ON info.smart_response_hash = smart.hash;
+
+CREATE or REPLACE VIEW fhir_endpoints_info_history_with_documents AS
+SELECT history.*, capstat.document AS capability_statement, smart.document AS smart_response
+FROM fhir_endpoints_info_history AS history
+LEFT JOIN fhir_endpoints_documents AS capstat ON history.capability_statement_hash = capstat.hash
+LEFT JOIN fhir_endpoints_documents AS smart ON history.smart_response_hash = smart.hash;
+
+CREATE or REPLACE VIEW endpoint_export AS
+SELECT endpts.url, endpts.list_source, endpts.organization_names AS endpoint_names,
+    vendors.name as vendor_name,
+    endpts_info.tls_version, endpts_info.mime_types, endpts_metadata.http_response,
+    endpts_metadata.response_time_seconds, endpts_metadata.smart_http_response, endpts_metadata.errors,
+    endpts_info.capability_fhir_version AS FHIR_VERSION,
+    endpts_info.capability_statement->>'publisher' AS PUBLISHER,
+    endpts_info.capability_statement->'software'->'name' AS SOFTWARE_NAME,
+    endpts_info.capability_statement->'software'->'version' AS SOFTWARE_VERSION,
+    endpts_info.capability_statement->'software'->'releaseDate' AS SOFTWARE_RELEASEDATE,
+    endpts_info.updated_at AS INFO_UPDATED, endpts_info.created_at AS INFO_CREATED,
+    endpts_info.requested_fhir_version,
+    orgs.name AS ORGANIZATION_NAME, orgs.secondary_name AS ORGANIZATION_SECONDARY_NAME,
+    orgs.taxonomy, orgs.Location->>'state' AS STATE, orgs.Location->>'zipcode' AS ZIPCODE,
+    links.confidence AS MATCH_SCORE, endpts_metadata.availability
+FROM endpoint_organization AS links
+RIGHT JOIN fhir_endpoints AS endpts ON links.url = endpts.url
+LEFT JOIN fhir_endpoints_info_with_documents AS endpts_info ON endpts.url = endpts_info.url
+LEFT JOIN fhir_endpoints_metadata AS endpts_metadata ON endpts_info.metadata_id = endpts_metadata.id
+LEFT JOIN vendors ON endpts_info.vendor_id = vendors.id
+LEFT JOIN npi_organizations AS orgs ON links.organization_npi_id = orgs.npi_id;
+
+CREATE INDEX implementation_guide_idx ON fhir_endpoints_documents ((document->>'implementationGuide'));
+CREATE INDEX resource_type_idx ON fhir_endpoints_documents (((document::json#>'{rest,0,resource}') ->> 'type'));
+CREATE INDEX capstat_url_idx ON fhir_endpoints_documents ((document->>'url'));
+CREATE INDEX capstat_version_idx ON fhir_endpoints_documents ((document->>'version'));
+CREATE INDEX capstat_name_idx ON fhir_endpoints_documents ((document->>'name'));
+CREATE INDEX capstat_title_idx ON fhir_endpoints_documents ((document->>'title'));
+CREATE INDEX capstat_date_idx ON fhir_endpoints_documents ((document->>'date'));
+CREATE INDEX capstat_publisher_idx ON fhir_endpoints_documents ((document->>'publisher'));
+CREATE INDEX capstat_description_idx ON fhir_endpoints_documents ((document->>'description'));
+CREATE INDEX capstat_purpose_idx ON fhir_endpoints_documents ((document->>'purpose'));
+CREATE INDEX capstat_copyright_idx ON fhir_endpoints_documents ((document->>'copyright'));
+CREATE INDEX capstat_software_name_idx ON fhir_endpoints_documents ((document->'software'->>'name'));
+CREATE INDEX capstat_software_version_idx ON fhir_endpoints_documents ((document->'software'->>'version'));
+CREATE INDEX capstat_software_releaseDate_idx ON fhir_endpoints_documents ((document->'software'->>'releaseDate'));
+CREATE INDEX capstat_implementation_description_idx ON fhir_endpoints_documents ((document->'implementation'->>'description'));
+CREATE INDEX capstat_implementation_url_idx ON fhir_endpoints_documents ((document->'implementation'->>'url'));
+CREATE INDEX capstat_implementation_custodian_idx ON fhir_endpoints_documents ((document->'implementation'->>'custodian'));
+CREATE INDEX security_code_idx ON fhir_endpoints_documents ((document::json#>'{rest,0,security,service}'->'coding'->>'code'));
+CREATE INDEX security_service_idx ON fhir_endpoints_documents ((document::json#>'{rest,0,security}' -> 'service' ->> 'text'));
+CREATE INDEX smart_capabilities_idx ON fhir_endpoints_documents ((document->'capabilities'));
+
+CREATE INDEX fhir_endpoints_info_capability_statement_hash_idx ON fhir_endpoints_info (capability_statement_hash);
+CREATE INDEX fhir_endpoints_info_history_capability_statement_hash_idx ON fhir_endpoints_info_history (capability_statement_hash);
+
+COMMIT;
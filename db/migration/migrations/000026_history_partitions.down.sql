@@ -0,0 +1,95 @@
+BEGIN;
+
+DROP VIEW IF EXISTS endpoint_export;
+DROP VIEW IF EXISTS fhir_endpoints_info_history_with_documents;
+
+DROP INDEX IF EXISTS fhir_endpoints_info_history_url_idx;
+DROP INDEX IF EXISTS fhir_endpoints_info_history_vendor_id_idx;
+DROP INDEX IF EXISTS fhir_endpoints_info_history_capability_statement_hash_idx;
+DROP INDEX IF EXISTS info_history_metadata_id_idx;
+DROP INDEX IF EXISTS metadata_id_idx;
+DROP INDEX IF EXISTS metadata_response_time_idx;
+DROP INDEX IF EXISTS metadata_permanent_redirect_idx;
+DROP INDEX IF EXISTS metadata_error_category_idx;
+
+ALTER TABLE fhir_endpoints_metadata RENAME TO fhir_endpoints_metadata_partitioned;
+ALTER TABLE fhir_endpoints_metadata_partitioned RENAME CONSTRAINT fhir_endpoints_metadata_pkey TO fhir_endpoints_metadata_partitioned_pkey;
+ALTER TABLE fhir_endpoints_info_history RENAME TO fhir_endpoints_info_history_partitioned;
+ALTER SEQUENCE IF EXISTS fhir_endpoints_metadata_id_seq OWNED BY NONE;
+
+CREATE TABLE fhir_endpoints_metadata (LIKE fhir_endpoints_metadata_partitioned INCLUDING DEFAULTS);
+ALTER TABLE fhir_endpoints_metadata ADD PRIMARY KEY (id);
+ALTER SEQUENCE IF EXISTS fhir_endpoints_metadata_id_seq OWNED BY fhir_endpoints_metadata.id;
+
+CREATE TABLE fhir_endpoints_info_history (LIKE fhir_endpoints_info_history_partitioned INCLUDING DEFAULTS);
+
+-- the rows are copied before the metadata triggers are created so that the endpoint availability is not counted again
+INSERT INTO fhir_endpoints_metadata SELECT * FROM fhir_endpoints_metadata_partitioned;
+INSERT INTO fhir_endpoints_info_history SELECT * FROM fhir_endpoints_info_history_partitioned;
+
+DROP TABLE fhir_endpoints_metadata_partitioned;
+DROP TABLE fhir_endpoints_info_history_partitioned;
+
+DROP FUNCTION IF EXISTS create_history_partitions(DATE, DATE);
+DROP FUNCTION IF EXISTS add_fhir_endpoints_metadata_triggers(TEXT);
+DROP TABLE IF EXISTS history_partitions;
+DROP TABLE IF EXISTS fhir_endpoints_metadata_daily;
+
+CREATE TRIGGER set_timestamp_fhir_endpoints_metadata
+BEFORE UPDATE ON fhir_endpoints_metadata
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TRIGGER update_fhir_endpoint_availability_trigger
+BEFORE INSERT OR UPDATE on fhir_endpoints_metadata
+FOR EACH ROW
+EXECUTE PROCEDURE update_fhir_endpoint_availability_info();
+
+-- history rows whose metadata was removed by the retention policy can not reference it again
+UPDATE fhir_endpoints_info_history SET metadata_id = NULL
+WHERE metadata_id IS NOT NULL AND metadata_id NOT IN (SELECT id FROM fhir_endpoints_metadata);
+UPDATE fhir_endpoints_info SET metadata_id = NULL
+WHERE metadata_id IS NOT NULL AND metadata_id NOT IN (SELECT id FROM fhir_endpoints_metadata);
+
+ALTER TABLE fhir_endpoints_info_history ADD FOREIGN KEY (validation_result_id) REFERENCES validation_results(id) ON DELETE SET NULL;
+ALTER TABLE fhir_endpoints_info_history ADD FOREIGN KEY (metadata_id) REFERENCES fhir_endpoints_metadata(id) ON DELETE SET NULL;
+ALTER TABLE fhir_endpoints_info ADD FOREIGN KEY (metadata_id) REFERENCES fhir_endpoints_metadata(id) ON DELETE SET NULL;
+
+CREATE INDEX fhir_endpoints_info_history_url_idx ON fhir_endpoints_info_history (url);
+CREATE INDEX fhir_endpoints_info_history_vendor_id_idx ON fhir_endpoints_info_history (vendor_id);
+CREATE INDEX fhir_endpoints_info_history_capability_statement_hash_idx ON fhir_endpoints_info_history (capability_statement_hash);
+CREATE INDEX info_history_metadata_id_idx ON fhir_endpoints_info_history (metadata_id);
+CREATE INDEX metadata_id_idx ON fhir_endpoints_metadata (id);
+CREATE INDEX metadata_response_time_idx ON fhir_endpoints_metadata(response_time_seconds);
+CREATE INDEX metadata_permanent_redirect_idx ON fhir_endpoints_metadata(permanent_redirect);
+CREATE INDEX metadata_error_category_idx ON fhir_endpoints_metadata(error_category);
+
+CREATE or REPLACE VIEW fhir_endpoints_info_history_with_documents AS
+SELECT history.*, capstat.document AS capability_statement, smart.document AS smart_response
+FROM fhir_endpoints_info_history AS history
+LEFT JOIN fhir_endpoints_documents AS capstat ON history.capability_statement_hash = capstat.hash
+LEFT JOIN fhir_endpoints_documents AS smart ON history.smart_response_hash = smart.hash;
+
+CREATE or REPLACE VIEW endpoint_export AS
+SELECT endpts.url, endpts.list_source, endpts.organization_names AS endpoint_names,
+    vendors.name as vendor_name,
+    endpts_info.tls_version, endpts_info.mime_types, endpts_metadata.http_response,
+    endpts_metadata.response_time_seconds, endpts_metadata.smart_http_response, endpts_metadata.errors,
+    endpts_info.capability_fhir_version AS FHIR_VERSION,
+    endpts_info.capability_statement->>'publisher' AS PUBLISHER,
+    endpts_info.capability_statement->'software'->'name' AS SOFTWARE_NAME,
+    endpts_info.capability_statement->'software'->'version' AS SOFTWARE_VERSION,
+    endpts_info.capability_statement->'software'->'releaseDate' AS SOFTWARE_RELEASEDATE,
+    endpts_info.updated_at AS INFO_UPDATED, endpts_info.created_at AS INFO_CREATED,
+    endpts_info.requested_fhir_version,
+    orgs.name AS ORGANIZATION_NAME, orgs.secondary_name AS ORGANIZATION_SECONDARY_NAME,
+    orgs.taxonomy, orgs.Location->>'state' AS STATE, orgs.Location->>'zipcode' AS ZIPCODE,
+    links.confidence AS MATCH_SCORE, endpts_metadata.availability
+FROM endpoint_organization AS links
+RIGHT JOIN fhir_endpoints AS endpts ON links.url = endpts.url
+LEFT JOIN fhir_endpoints_info_with_documents AS endpts_info ON endpts.url = endpts_info.url
+LEFT JOIN fhir_endpoints_metadata AS endpts_metadata ON endpts_info.metadata_id = endpts_metadata.id
+LEFT JOIN vendors ON endpts_info.vendor_id = vendors.id
+LEFT JOIN npi_organizations AS orgs ON links.organization_npi_id = orgs.npi_id;
+
+COMMIT;
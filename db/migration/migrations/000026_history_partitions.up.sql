@@ -0,0 +1,179 @@
+BEGIN;
+
+DROP VIEW IF EXISTS endpoint_export;
+DROP VIEW IF EXISTS fhir_endpoints_info_history_with_documents;
+
+-- partitioned tables can not be referenced by foreign keys
+ALTER TABLE fhir_endpoints_info DROP CONSTRAINT IF EXISTS fhir_endpoints_info_metadata_id_fkey;
+ALTER TABLE fhir_endpoints_info_history DROP CONSTRAINT IF EXISTS fhir_endpoints_info_history_metadata_id_fkey;
+
+DROP INDEX IF EXISTS fhir_endpoints_info_history_url_idx;
+DROP INDEX IF EXISTS fhir_endpoints_info_history_vendor_id_idx;
+DROP INDEX IF EXISTS fhir_endpoints_info_history_capability_statement_hash_idx;
+DROP INDEX IF EXISTS info_history_metadata_id_idx;
+DROP INDEX IF EXISTS metadata_id_idx;
+DROP INDEX IF EXISTS metadata_response_time_idx;
+DROP INDEX IF EXISTS metadata_permanent_redirect_idx;
+DROP INDEX IF EXISTS metadata_error_category_idx;
+
+ALTER TABLE fhir_endpoints_metadata RENAME TO fhir_endpoints_metadata_unpartitioned;
+ALTER TABLE fhir_endpoints_metadata_unpartitioned RENAME CONSTRAINT fhir_endpoints_metadata_pkey TO fhir_endpoints_metadata_unpartitioned_pkey;
+ALTER TABLE fhir_endpoints_info_history RENAME TO fhir_endpoints_info_history_unpartitioned;
+ALTER SEQUENCE IF EXISTS fhir_endpoints_metadata_id_seq OWNED BY NONE;
+
+-- the partitioned tables keep the column order of the original tables, which the fhir_endpoints_info history
+-- trigger relies on
+CREATE TABLE fhir_endpoints_metadata (LIKE fhir_endpoints_metadata_unpartitioned INCLUDING DEFAULTS)
+PARTITION BY RANGE (created_at);
+ALTER TABLE fhir_endpoints_metadata ADD PRIMARY KEY (id, created_at);
+ALTER SEQUENCE IF EXISTS fhir_endpoints_metadata_id_seq OWNED BY fhir_endpoints_metadata.id;
+CREATE TABLE IF NOT EXISTS fhir_endpoints_metadata_default PARTITION OF fhir_endpoints_metadata DEFAULT;
+
+CREATE TABLE fhir_endpoints_info_history (LIKE fhir_endpoints_info_history_unpartitioned INCLUDING DEFAULTS)
+PARTITION BY RANGE (entered_at);
+ALTER TABLE fhir_endpoints_info_history ADD FOREIGN KEY (validation_result_id) REFERENCES validation_results(id) ON DELETE SET NULL;
+CREATE TABLE IF NOT EXISTS fhir_endpoints_info_history_default PARTITION OF fhir_endpoints_info_history DEFAULT;
+
+CREATE TABLE IF NOT EXISTS history_partitions (
+    name                    VARCHAR(500) PRIMARY KEY,
+    parent_table            VARCHAR(500),
+    month                   DATE,
+    tier                    VARCHAR(500) NOT NULL DEFAULT 'full',
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS fhir_endpoints_metadata_daily (
+    url                     VARCHAR(500),
+    requested_fhir_version  VARCHAR(500),
+    day                     DATE,
+    http_200_count          BIGINT,
+    http_all_count          BIGINT,
+    avg_response_time_seconds DECIMAL(7,4),
+    max_response_time_seconds DECIMAL(7,4),
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fhir_endpoints_metadata_daily_pkey PRIMARY KEY (url, requested_fhir_version, day)
+);
+
+DROP TRIGGER IF EXISTS set_timestamp_history_partitions ON history_partitions;
+DROP TRIGGER IF EXISTS set_timestamp_fhir_endpoints_metadata_daily ON fhir_endpoints_metadata_daily;
+
+CREATE TRIGGER set_timestamp_history_partitions
+BEFORE UPDATE ON history_partitions
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TRIGGER set_timestamp_fhir_endpoints_metadata_daily
+BEFORE UPDATE ON fhir_endpoints_metadata_daily
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE OR REPLACE FUNCTION add_fhir_endpoints_metadata_triggers(partition_name TEXT) RETURNS VOID AS $$
+    BEGIN
+        --
+        -- Partitioned tables can not have BEFORE row triggers, so the fhir_endpoints_metadata triggers are
+        -- created on each of its partitions.
+        --
+        EXECUTE format('CREATE TRIGGER set_timestamp_fhir_endpoints_metadata BEFORE UPDATE ON %I
+            FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp()', partition_name);
+        -- increments total number of times http status returned for endpoint
+        EXECUTE format('CREATE TRIGGER update_fhir_endpoint_availability_trigger BEFORE INSERT OR UPDATE ON %I
+            FOR EACH ROW EXECUTE PROCEDURE update_fhir_endpoint_availability_info()', partition_name);
+    END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION create_history_partitions(start_month DATE, end_month DATE) RETURNS VOID AS $$
+    DECLARE
+        partition_month DATE;
+        next_month      DATE;
+        parent_table    TEXT;
+        partition_key   TEXT;
+        partition_name  TEXT;
+    BEGIN
+        --
+        -- Create the monthly partitions of fhir_endpoints_info_history and fhir_endpoints_metadata from start_month
+        -- through end_month and record them in history_partitions. Partitions that were already created, including
+        -- the ones since dropped by the retention policy, are skipped. Rows for a new partition's month that were
+        -- stored in the default partition are moved into the new partition.
+        --
+        partition_month := date_trunc('month', start_month);
+        WHILE partition_month <= end_month LOOP
+            next_month := partition_month + INTERVAL '1 month';
+            FOREACH parent_table IN ARRAY ARRAY['fhir_endpoints_info_history', 'fhir_endpoints_metadata'] LOOP
+                partition_name := parent_table || to_char(partition_month, '"_y"YYYY"m"MM');
+                CONTINUE WHEN EXISTS (SELECT 1 FROM history_partitions WHERE name = partition_name);
+
+                IF parent_table = 'fhir_endpoints_metadata' THEN
+                    partition_key := 'created_at';
+                ELSE
+                    partition_key := 'entered_at';
+                END IF;
+                EXECUTE format('CREATE TABLE %I (LIKE %I INCLUDING DEFAULTS)', partition_name, parent_table);
+                EXECUTE format('WITH moved AS (DELETE FROM %I WHERE %I >= %L AND %I < %L RETURNING *) INSERT INTO %I SELECT * FROM moved',
+                    parent_table || '_default', partition_key, partition_month, partition_key, next_month, partition_name);
+                EXECUTE format('ALTER TABLE %I ATTACH PARTITION %I FOR VALUES FROM (%L) TO (%L)',
+                    parent_table, partition_name, partition_month, next_month);
+                IF parent_table = 'fhir_endpoints_metadata' THEN
+                    PERFORM add_fhir_endpoints_metadata_triggers(partition_name);
+                END IF;
+                INSERT INTO history_partitions (name, parent_table, month) VALUES (partition_name, parent_table, partition_month);
+            END LOOP;
+            partition_month := next_month;
+        END LOOP;
+    END;
+$$ LANGUAGE plpgsql;
+
+-- the rows are copied into the default partitions before the metadata triggers are created so that the
+-- endpoint availability is not counted again. create_history_partitions then moves them into the monthly partitions.
+INSERT INTO fhir_endpoints_metadata SELECT * FROM fhir_endpoints_metadata_unpartitioned;
+INSERT INTO fhir_endpoints_info_history SELECT * FROM fhir_endpoints_info_history_unpartitioned;
+
+SELECT create_history_partitions(
+    LEAST(CURRENT_DATE,
+        (SELECT MIN(created_at)::DATE FROM fhir_endpoints_metadata_unpartitioned),
+        (SELECT MIN(entered_at)::DATE FROM fhir_endpoints_info_history_unpartitioned)),
+    (CURRENT_DATE + INTERVAL '3 months')::DATE);
+SELECT add_fhir_endpoints_metadata_triggers('fhir_endpoints_metadata_default');
+
+DROP TABLE fhir_endpoints_metadata_unpartitioned;
+DROP TABLE fhir_endpoints_info_history_unpartitioned;
+
+CREATE INDEX fhir_endpoints_info_history_url_idx ON fhir_endpoints_info_history (url);
+CREATE INDEX fhir_endpoints_info_history_vendor_id_idx ON fhir_endpoints_info_history (vendor_id);
+CREATE INDEX fhir_endpoints_info_history_capability_statement_hash_idx ON fhir_endpoints_info_history (capability_statement_hash);
+CREATE INDEX info_history_metadata_id_idx ON fhir_endpoints_info_history (metadata_id);
+CREATE INDEX metadata_id_idx ON fhir_endpoints_metadata (id);
+CREATE INDEX metadata_response_time_idx ON fhir_endpoints_metadata(response_time_seconds);
+CREATE INDEX metadata_permanent_redirect_idx ON fhir_endpoints_metadata(permanent_redirect);
+CREATE INDEX metadata_error_category_idx ON fhir_endpoints_metadata(error_category);
+
+CREATE or REPLACE VIEW fhir_endpoints_info_history_with_documents AS
+SELECT history.*, capstat.document AS capability_statement, smart.document AS smart_response
+FROM fhir_endpoints_info_history AS history
+LEFT JOIN fhir_endpoints_documents AS capstat ON history.capability_statement_hash = capstat.hash
+LEFT JOIN fhir_endpoints_documents AS smart ON history.smart_response_hash = smart.hash;
+
+CREATE or REPLACE VIEW endpoint_export AS
+SELECT endpts.url, endpts.list_source, endpts.organization_names AS endpoint_names,
+    vendors.name as vendor_name,
+    endpts_info.tls_version, endpts_info.mime_types, endpts_metadata.http_response,
+    endpts_metadata.response_time_seconds, endpts_metadata.smart_http_response, endpts_metadata.errors,
+    endpts_info.capability_fhir_version AS FHIR_VERSION,
+    endpts_info.capability_statement->>'publisher' AS PUBLISHER,
+    endpts_info.capability_statement->'software'->'name' AS SOFTWARE_NAME,
+    endpts_info.capability_statement->'software'->'version' AS SOFTWARE_VERSION,
+    endpts_info.capability_statement->'software'->'releaseDate' AS SOFTWARE_RELEASEDATE,
+    endpts_info.updated_at AS INFO_UPDATED, endpts_info.created_at AS INFO_CREATED,
+    endpts_info.requested_fhir_version,
+    orgs.name AS ORGANIZATION_NAME, orgs.secondary_name AS ORGANIZATION_SECONDARY_NAME,
+    orgs.taxonomy, orgs.Location->>'state' AS STATE, orgs.Location->>'zipcode' AS ZIPCODE,
+    links.confidence AS MATCH_SCORE, endpts_metadata.availability
+FROM endpoint_organization AS links
+RIGHT JOIN fhir_endpoints AS endpts ON links.url = endpts.url
+LEFT JOIN fhir_endpoints_info_with_documents AS endpts_info ON endpts.url = endpts_info.url
+LEFT JOIN fhir_endpoints_metadata AS endpts_metadata ON endpts_info.metadata_id = endpts_metadata.id
+LEFT JOIN vendors ON endpts_info.vendor_id = vendors.id
+LEFT JOIN npi_organizations AS orgs ON links.organization_npi_id = orgs.npi_id;
+
+COMMIT;
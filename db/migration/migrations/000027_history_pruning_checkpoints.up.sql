@@ -0,0 +1,17 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS history_pruning_checkpoints (
+    mode                    VARCHAR(500) PRIMARY KEY,
+    last_url                VARCHAR(500),
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+DROP TRIGGER IF EXISTS set_timestamp_history_pruning_checkpoints ON history_pruning_checkpoints;
+
+CREATE TRIGGER set_timestamp_history_pruning_checkpoints
+BEFORE UPDATE ON history_pruning_checkpoints
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+COMMIT;
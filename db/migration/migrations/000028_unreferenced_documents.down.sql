@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS fhir_endpoints_info_smart_response_hash_idx;
+DROP INDEX IF EXISTS fhir_endpoints_info_history_smart_response_hash_idx;
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+-- the history pruning and retention look up whether the documents of the entries they remove are still referenced
+CREATE INDEX IF NOT EXISTS fhir_endpoints_info_smart_response_hash_idx ON fhir_endpoints_info (smart_response_hash);
+CREATE INDEX IF NOT EXISTS fhir_endpoints_info_history_smart_response_hash_idx ON fhir_endpoints_info_history (smart_response_hash);
+
+-- remove the documents left behind by history entries that were removed before the pruning and retention removed
+-- their documents
+DELETE FROM fhir_endpoints_documents AS documents
+WHERE NOT EXISTS (SELECT 1 FROM fhir_endpoints_info WHERE capability_statement_hash = documents.hash)
+    AND NOT EXISTS (SELECT 1 FROM fhir_endpoints_info WHERE smart_response_hash = documents.hash)
+    AND NOT EXISTS (SELECT 1 FROM fhir_endpoints_info_history WHERE capability_statement_hash = documents.hash)
+    AND NOT EXISTS (SELECT 1 FROM fhir_endpoints_info_history WHERE smart_response_hash = documents.hash);
+
+COMMIT;
@@ -0,0 +1,176 @@
+package datamigrations
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/onc-healthit/lantern-back-end/db/migration/pkg/migrator"
+)
+
+// infoTables are the tables that hold a capability statement for each endpoint as of version 12
+var infoTables = []string{"fhir_endpoints_info", "fhir_endpoints_info_history"}
+
+// capabilityStatement is the part of a capability statement that the resources are read from
+type capabilityStatement struct {
+	Rest []struct {
+		Resource []struct {
+			Type        interface{} `json:"type"`
+			Interaction []struct {
+				Code interface{} `json:"code"`
+			} `json:"interaction"`
+		} `json:"resource"`
+	} `json:"rest"`
+}
+
+// OperationResource returns the data migration of version 12, which replaces the supported_resources field of the
+// info tables with the operation_resource field. Going up, operation_resource is filled in from each row's capability
+// statement. Going down, supported_resources is added and filled in before the SQL migration drops
+// operation_resource.
+func OperationResource(db *sql.DB) migrator.DataMigration {
+	return migrator.DataMigration{
+		Version: 12,
+		Name:    "operation_resource",
+		Up: func(ctx context.Context) error {
+			return updateInfoTables(ctx, db, "operation_resource", func(capStat capabilityStatement) interface{} {
+				return operationResources(capStat)
+			})
+		},
+		Down: func(ctx context.Context) error {
+			return updateInfoTables(ctx, db, "supported_resources", func(capStat capabilityStatement) interface{} {
+				return supportedResources(capStat)
+			})
+		},
+	}
+}
+
+// updateInfoTables sets the given JSONB field of every row of the info tables to the value made from the row's
+// capability statement, adding the field if it does not exist yet. The info table's triggers are disabled so that
+// the update does not add history entries or change the rows' update times.
+func updateInfoTables(ctx context.Context, db *sql.DB, field string, value func(capabilityStatement) interface{}) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		ALTER TABLE fhir_endpoints_info DISABLE TRIGGER add_fhir_endpoint_info_history_trigger;
+		ALTER TABLE fhir_endpoints_info DISABLE TRIGGER set_timestamp_fhir_endpoints_info;`)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range infoTables {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s JSONB;", table, field))
+		if err != nil {
+			return err
+		}
+
+		values, err := infoTableValues(ctx, tx, table, value)
+		if err != nil {
+			return err
+		}
+
+		update, err := tx.PrepareContext(ctx, fmt.Sprintf("UPDATE %s SET %s = $1 WHERE ctid = $2::tid;", table, field))
+		if err != nil {
+			return err
+		}
+		for rowID, fieldJSON := range values {
+			_, err = update.ExecContext(ctx, fieldJSON, rowID)
+			if err != nil {
+				update.Close()
+				return fmt.Errorf("updating %s of a row of %s failed: %w", field, table, err)
+			}
+		}
+		update.Close()
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		ALTER TABLE fhir_endpoints_info ENABLE TRIGGER add_fhir_endpoint_info_history_trigger;
+		ALTER TABLE fhir_endpoints_info ENABLE TRIGGER set_timestamp_fhir_endpoints_info;`)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// infoTableValues returns the JSON of the value made from each row's capability statement, keyed by the row's ctid.
+// The rows are read in full before any of them are updated because a transaction's queries cannot be interleaved.
+func infoTableValues(ctx context.Context, tx *sql.Tx, table string, value func(capabilityStatement) interface{}) (map[string][]byte, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT ctid::text, capability_statement FROM %s;", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make(map[string][]byte)
+	for rows.Next() {
+		var rowID string
+		var capStatJSON []byte
+		err = rows.Scan(&rowID, &capStatJSON)
+		if err != nil {
+			return nil, err
+		}
+
+		// a capability statement that is missing, or is not an object, has no resources
+		var capStat capabilityStatement
+		if len(capStatJSON) > 0 {
+			_ = json.Unmarshal(capStatJSON, &capStat)
+		}
+		fieldJSON, err := json.Marshal(value(capStat))
+		if err != nil {
+			return nil, err
+		}
+		values[rowID] = fieldJSON
+	}
+	return values, rows.Err()
+}
+
+// operationResources maps each interaction code in the capability statement's first rest entry to the resource
+// types that support it. Resources that do not list any interaction codes are under "not specified".
+func operationResources(capStat capabilityStatement) map[string][]string {
+	opToResources := make(map[string][]string)
+	if len(capStat.Rest) == 0 {
+		return opToResources
+	}
+
+	for _, resource := range capStat.Rest[0].Resource {
+		resourceType, ok := resource.Type.(string)
+		if !ok {
+			continue
+		}
+
+		hasCodes := false
+		for _, interaction := range resource.Interaction {
+			code, ok := interaction.Code.(string)
+			if !ok {
+				continue
+			}
+			hasCodes = true
+			opToResources[code] = append(opToResources[code], resourceType)
+		}
+		if !hasCodes {
+			opToResources["not specified"] = append(opToResources["not specified"], resourceType)
+		}
+	}
+	return opToResources
+}
+
+// supportedResources returns the resource types in the capability statement's first rest entry, or nil if there
+// are none.
+func supportedResources(capStat capabilityStatement) []string {
+	if len(capStat.Rest) == 0 {
+		return nil
+	}
+
+	var resources []string
+	for _, resource := range capStat.Rest[0].Resource {
+		resourceType, ok := resource.Type.(string)
+		if ok {
+			resources = append(resources, resourceType)
+		}
+	}
+	return resources
+}
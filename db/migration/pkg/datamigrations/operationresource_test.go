@@ -0,0 +1,70 @@
+package datamigrations
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const testCapabilityStatement = `{
+	"resourceType": "CapabilityStatement",
+	"rest": [{
+		"resource": [
+			{"type": "Patient", "interaction": [{"code": "read"}, {"code": "search-type"}]},
+			{"type": "Observation", "interaction": [{"code": "read"}, {"code": 5}]},
+			{"type": "Encounter", "interaction": []},
+			{"type": "Condition", "interaction": [{"code": 1}]},
+			{"interaction": [{"code": "read"}]}
+		]
+	}, {
+		"resource": [{"type": "Device", "interaction": [{"code": "read"}]}]
+	}]
+}`
+
+func testCapStat(t *testing.T, capStatJSON string) capabilityStatement {
+	var capStat capabilityStatement
+	err := json.Unmarshal([]byte(capStatJSON), &capStat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return capStat
+}
+
+func Test_operationResources(t *testing.T) {
+	capStat := testCapStat(t, testCapabilityStatement)
+
+	expected := map[string][]string{
+		"read":          {"Patient", "Observation"},
+		"search-type":   {"Patient"},
+		"not specified": {"Encounter", "Condition"},
+	}
+	actual := operationResources(capStat)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	// a capability statement without any rest entries has no operations
+	actual = operationResources(testCapStat(t, `{"resourceType": "CapabilityStatement"}`))
+	if len(actual) != 0 {
+		t.Errorf("expected no operations, got %v", actual)
+	}
+	actual = operationResources(testCapStat(t, `null`))
+	if len(actual) != 0 {
+		t.Errorf("expected no operations for a missing capability statement, got %v", actual)
+	}
+}
+
+func Test_supportedResources(t *testing.T) {
+	capStat := testCapStat(t, testCapabilityStatement)
+
+	expected := []string{"Patient", "Observation", "Encounter", "Condition"}
+	actual := supportedResources(capStat)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	actual = supportedResources(testCapStat(t, `{"rest": []}`))
+	if actual != nil {
+		t.Errorf("expected no resources, got %v", actual)
+	}
+}
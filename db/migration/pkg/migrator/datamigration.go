@@ -0,0 +1,17 @@
+package migrator
+
+import (
+	"context"
+)
+
+// DataMigration is a Go migration of the stored data that accompanies the SQL migration with the same version. Its
+// Up function is run after the SQL migration is applied and its Down function is run before the SQL migration is
+// rolled back, so both see the schema of that version. Either function may be nil if there is no data to migrate in
+// that direction. Data migrations are frozen along with their SQL migration, so they should use SQL against that
+// version's schema rather than the current stores.
+type DataMigration struct {
+	Version uint
+	Name    string
+	Up      func(ctx context.Context) error
+	Down    func(ctx context.Context) error
+}
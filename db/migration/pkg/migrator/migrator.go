@@ -0,0 +1,332 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	log "github.com/sirupsen/logrus"
+)
+
+// NilVersion is the version of a database that has no migrations applied.
+const NilVersion = database.NilVersion
+
+// Step is the application or rollback of a single SQL migration, along with the data migrations that accompany it.
+type Step struct {
+	Version        uint   // the version of the SQL migration
+	Identifier     string // the name of the SQL migration, such as "validation_table"
+	Up             bool   // whether the migration is applied or rolled back
+	Target         int    // the version of the database after the step, NilVersion if none
+	DataMigrations []DataMigration
+}
+
+func (s Step) String() string {
+	if s.Up {
+		return fmt.Sprintf("up to %d (%s)", s.Version, s.Identifier)
+	}
+	if s.Target == NilVersion {
+		return fmt.Sprintf("down from %d (%s) to no version", s.Version, s.Identifier)
+	}
+	return fmt.Sprintf("down from %d (%s) to %d", s.Version, s.Identifier, s.Target)
+}
+
+// Migrator plans and runs the SQL migrations in the migrations directory one step at a time, running the registered
+// data migrations along with the step with the same version.
+// Usage:
+//
+// m, err := migrator.New(db, "file://./migrations", dataMigrations)
+// steps, err := m.PlanUp(-1)
+// err = m.Apply(ctx, steps)
+type Migrator struct {
+	migrate        *migrate.Migrate
+	source         source.Driver
+	database       database.Driver
+	dataMigrations []DataMigration
+}
+
+// New creates a Migrator for the postgres database using the migrations at the source URL.
+func New(db *sql.DB, sourceURL string, dataMigrations []DataMigration) (*Migrator, error) {
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("creating the postgres migration driver failed: %w", err)
+	}
+	src, err := source.Open(sourceURL)
+	if err != nil {
+		return nil, fmt.Errorf("opening the migrations at %s failed: %w", sourceURL, err)
+	}
+	m, err := migrate.NewWithInstance("migrations", src, "postgres", driver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		migrate:        m,
+		source:         src,
+		database:       driver,
+		dataMigrations: dataMigrations,
+	}, nil
+}
+
+// Close closes the migration source and the database driver.
+func (m *Migrator) Close() {
+	_, _ = m.migrate.Close()
+}
+
+// Version returns the database's current version, NilVersion if no migrations have been applied, and whether the
+// last migration failed and left the database dirty.
+func (m *Migrator) Version() (int, bool, error) {
+	return m.database.Version()
+}
+
+// Pending returns the steps that have not been applied yet.
+func (m *Migrator) Pending() ([]Step, error) {
+	version, _, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+	return planUp(m.source, m.dataMigrations, version, -1)
+}
+
+// PlanUp plans applying the next n migrations, or all of the pending migrations if n is negative.
+func (m *Migrator) PlanUp(n int) ([]Step, error) {
+	version, err := m.cleanVersion()
+	if err != nil {
+		return nil, err
+	}
+	return planUp(m.source, m.dataMigrations, version, n)
+}
+
+// PlanDown plans rolling back the last n migrations.
+func (m *Migrator) PlanDown(n int) ([]Step, error) {
+	version, err := m.cleanVersion()
+	if err != nil {
+		return nil, err
+	}
+	return planDown(m.source, m.dataMigrations, version, n)
+}
+
+// PlanGoto plans applying or rolling back migrations until the database is at the target version.
+func (m *Migrator) PlanGoto(target uint) ([]Step, error) {
+	version, err := m.cleanVersion()
+	if err != nil {
+		return nil, err
+	}
+	return planGoto(m.source, m.dataMigrations, version, target)
+}
+
+// Apply runs the planned steps in order. A step's data migrations are run after the SQL migration when it is
+// applied, and before the SQL migration when it is rolled back, so that they always see the schema of the step's
+// version. If a data migration fails after its SQL migration was applied, the database is marked dirty at that
+// version in the same way as a failed SQL migration. If one fails before a rollback, the SQL migration is not run
+// and the database is left at the step's version.
+func (m *Migrator) Apply(ctx context.Context, steps []Step) error {
+	for _, step := range steps {
+		log.Infof("Migrating %s", step)
+
+		if !step.Up {
+			err := runDataMigrations(ctx, step)
+			if err != nil {
+				return fmt.Errorf("%w, the database was not migrated %s", err, step)
+			}
+			err = m.migrate.Steps(-1)
+			if err != nil {
+				return fmt.Errorf("migrating %s failed: %w", step, err)
+			}
+			continue
+		}
+
+		err := m.migrate.Steps(1)
+		if err != nil {
+			return fmt.Errorf("migrating %s failed: %w", step, err)
+		}
+		err = runDataMigrations(ctx, step)
+		if err != nil {
+			dirtyErr := m.database.SetVersion(step.Target, true)
+			if dirtyErr != nil {
+				log.Warnf("marking the database dirty at version %d failed: %s", step.Target, dirtyErr)
+			}
+			return fmt.Errorf("%w after migrating %s, fix the data and then run 'force %d'", err, step, step.Target)
+		}
+	}
+	return nil
+}
+
+// runDataMigrations runs the Up or Down function of each of the step's data migrations.
+func runDataMigrations(ctx context.Context, step Step) error {
+	for _, dataMigration := range step.DataMigrations {
+		run := dataMigration.Down
+		if step.Up {
+			run = dataMigration.Up
+		}
+		if run == nil {
+			continue
+		}
+		log.Infof("Running data migration %s", dataMigration.Name)
+		err := run(ctx)
+		if err != nil {
+			return fmt.Errorf("data migration %s failed: %w", dataMigration.Name, err)
+		}
+	}
+	return nil
+}
+
+// Force sets the database's version without running any migrations and clears its dirty flag. Use NilVersion to
+// mark the database as having no migrations applied.
+func (m *Migrator) Force(version int) error {
+	if version != NilVersion {
+		_, _, err := m.source.ReadUp(uint(version))
+		if err != nil {
+			return fmt.Errorf("there is no migration with version %d: %w", version, err)
+		}
+	}
+	return m.migrate.Force(version)
+}
+
+// cleanVersion returns the database's current version, or an error if the database is dirty.
+func (m *Migrator) cleanVersion() (int, error) {
+	version, dirty, err := m.Version()
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("the database is dirty at version %d, fix it and then run 'force %d'", version, version)
+	}
+	return version, nil
+}
+
+// planUp plans applying the next n migrations after the current version, or all of them if n is negative.
+func planUp(src source.Driver, dataMigrations []DataMigration, current int, n int) ([]Step, error) {
+	var steps []Step
+	version := current
+	for n < 0 || len(steps) < n {
+		var next uint
+		var err error
+		if version == NilVersion {
+			next, err = src.First()
+		} else {
+			next, err = src.Next(uint(version))
+		}
+		if errors.Is(err, os.ErrNotExist) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		identifier, err := readIdentifier(src, next, true)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, Step{
+			Version:        next,
+			Identifier:     identifier,
+			Up:             true,
+			Target:         int(next),
+			DataMigrations: dataMigrationsForVersion(dataMigrations, next),
+		})
+		version = int(next)
+	}
+
+	if n > 0 && len(steps) < n {
+		return nil, fmt.Errorf("cannot apply %d migrations, only %d are pending", n, len(steps))
+	}
+	return steps, nil
+}
+
+// planDown plans rolling back the last n migrations from the current version.
+func planDown(src source.Driver, dataMigrations []DataMigration, current int, n int) ([]Step, error) {
+	var steps []Step
+	version := current
+	for len(steps) < n {
+		if version == NilVersion {
+			return nil, fmt.Errorf("cannot roll back %d migrations, only %d are applied", n, len(steps))
+		}
+
+		target := NilVersion
+		prev, err := src.Prev(uint(version))
+		if err == nil {
+			target = int(prev)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		identifier, err := readIdentifier(src, uint(version), false)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, Step{
+			Version:        uint(version),
+			Identifier:     identifier,
+			Up:             false,
+			Target:         target,
+			DataMigrations: dataMigrationsForVersion(dataMigrations, uint(version)),
+		})
+		version = target
+	}
+	return steps, nil
+}
+
+// planGoto plans applying or rolling back migrations from the current version until the target version is reached.
+func planGoto(src source.Driver, dataMigrations []DataMigration, current int, target uint) ([]Step, error) {
+	_, err := readIdentifier(src, target, true)
+	if err != nil {
+		return nil, fmt.Errorf("there is no migration with version %d: %w", target, err)
+	}
+
+	if current == int(target) {
+		return nil, nil
+	}
+	if current == NilVersion || current < int(target) {
+		steps, err := planUp(src, dataMigrations, current, -1)
+		if err != nil {
+			return nil, err
+		}
+		for i, step := range steps {
+			if step.Version == target {
+				return steps[:i+1], nil
+			}
+		}
+		return nil, fmt.Errorf("version %d is not after the current version %d", target, current)
+	}
+
+	var steps []Step
+	version := current
+	for version != int(target) {
+		step, err := planDown(src, dataMigrations, version, 1)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step...)
+		version = step[0].Target
+	}
+	return steps, nil
+}
+
+// readIdentifier returns the name of the migration with the given version.
+func readIdentifier(src source.Driver, version uint, up bool) (string, error) {
+	read := src.ReadDown
+	if up {
+		read = src.ReadUp
+	}
+	r, identifier, err := read(version)
+	if err != nil {
+		return "", err
+	}
+	r.Close()
+	return identifier, nil
+}
+
+func dataMigrationsForVersion(dataMigrations []DataMigration, version uint) []DataMigration {
+	var forVersion []DataMigration
+	for _, dataMigration := range dataMigrations {
+		if dataMigration.Version == version {
+			forVersion = append(forVersion, dataMigration)
+		}
+	}
+	return forVersion
+}
@@ -0,0 +1,152 @@
+package migrator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+func testSource(t *testing.T) source.Driver {
+	dir, err := ioutil.TempDir("", "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for _, name := range []string{
+		"000002_add_chpl_criteria",
+		"000003_add_endpoint_availability",
+		"000005_add_indexes",
+	} {
+		for _, direction := range []string{"up", "down"} {
+			err = ioutil.WriteFile(filepath.Join(dir, name+"."+direction+".sql"), []byte("SELECT 1;"), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	src, err := source.Open("file://" + dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { src.Close() })
+	return src
+}
+
+var testDataMigrations = []DataMigration{
+	{Version: 3, Name: "migrateavailability"},
+}
+
+func Test_planUp(t *testing.T) {
+	src := testSource(t)
+
+	// all of the migrations from no version
+	steps, err := planUp(src, testDataMigrations, NilVersion, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 3 {
+		t.Fatalf("expected 3 steps, got %d", len(steps))
+	}
+	if steps[0].Version != 2 || steps[0].Identifier != "add_chpl_criteria" || !steps[0].Up || steps[0].Target != 2 {
+		t.Errorf("expected the first step to apply version 2, got %s", steps[0])
+	}
+	if len(steps[1].DataMigrations) != 1 || steps[1].DataMigrations[0].Name != "migrateavailability" {
+		t.Errorf("expected the data migration to run after version 3, got %v", steps[1].DataMigrations)
+	}
+	if len(steps[0].DataMigrations) != 0 || len(steps[2].DataMigrations) != 0 {
+		t.Errorf("expected the data migration to only run after version 3")
+	}
+
+	// the next migration
+	steps, err = planUp(src, testDataMigrations, 3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 1 || steps[0].Version != 5 {
+		t.Errorf("expected a single step to version 5, got %v", steps)
+	}
+
+	// nothing is pending
+	steps, err = planUp(src, testDataMigrations, 5, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 0 {
+		t.Errorf("expected no steps, got %v", steps)
+	}
+
+	_, err = planUp(src, testDataMigrations, 3, 2)
+	if err == nil {
+		t.Errorf("expected an error applying more migrations than are pending")
+	}
+}
+
+func Test_planDown(t *testing.T) {
+	src := testSource(t)
+
+	steps, err := planDown(src, testDataMigrations, 5, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 2 {
+		t.Fatalf("expected 2 steps, got %d", len(steps))
+	}
+	if steps[0].Version != 5 || steps[0].Up || steps[0].Target != 3 {
+		t.Errorf("expected the first step to roll back version 5 to 3, got %s", steps[0])
+	}
+	if steps[1].Version != 3 || steps[1].Target != 2 || len(steps[1].DataMigrations) != 1 {
+		t.Errorf("expected the second step to roll back version 3 to 2 and run its data migration, got %s", steps[1])
+	}
+
+	steps, err = planDown(src, testDataMigrations, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 1 || steps[0].Target != NilVersion {
+		t.Errorf("expected rolling back the first migration to leave no version, got %v", steps)
+	}
+
+	_, err = planDown(src, testDataMigrations, 3, 3)
+	if err == nil {
+		t.Errorf("expected an error rolling back more migrations than are applied")
+	}
+}
+
+func Test_planGoto(t *testing.T) {
+	src := testSource(t)
+
+	steps, err := planGoto(src, testDataMigrations, NilVersion, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 2 || steps[1].Version != 3 || !steps[1].Up {
+		t.Errorf("expected 2 steps up to version 3, got %v", steps)
+	}
+
+	steps, err = planGoto(src, testDataMigrations, 5, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 2 || steps[1].Target != 2 || steps[1].Up {
+		t.Errorf("expected 2 steps down to version 2, got %v", steps)
+	}
+
+	steps, err = planGoto(src, testDataMigrations, 3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 0 {
+		t.Errorf("expected no steps to the current version, got %v", steps)
+	}
+
+	_, err = planGoto(src, testDataMigrations, 3, 4)
+	if err == nil {
+		t.Errorf("expected an error going to a version that does not exist")
+	}
+}
@@ -0,0 +1,386 @@
+// Package schemadrift checks that the schema created by dbsetup.sql is the same as the schema created by running the
+// migrations. Both schemas are built in temporary postgres schemas of the same database, loaded from the catalog and
+// compared.
+package schemadrift
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/lib/pq"
+)
+
+// The temporary schemas that the two schemas are built in.
+const (
+	setupSchema    = "schemadrift_setup"
+	migratedSchema = "schemadrift_migrated"
+)
+
+// Schema is the part of a postgres schema that is compared. Everything is keyed by name, triggers are keyed by
+// "<table>.<trigger>" and functions are keyed by "<function>(<argument types>)". Partitions of partitioned tables
+// are left out, since which partitions exist depends on the date the schema was created.
+type Schema struct {
+	Tables    map[string]*Table
+	Indexes   map[string]string // the index definitions
+	Views     map[string]string // the view queries
+	Triggers  map[string]string // the trigger definitions
+	Functions map[string]string // the function definitions, with runs of whitespace collapsed
+}
+
+// Table is a table's columns and constraints. ColumnOrder lists the columns in the order they are stored in, which
+// is the order that "SELECT *" and COPY without a column list use.
+type Table struct {
+	Columns     map[string]string // the column type, nullability and default
+	ColumnOrder []string
+	Constraints map[string]string // the constraint definitions
+}
+
+// Check builds the schema from the setup file and the schema from the migrations in the migrations directory, and
+// returns the differences between them. The migrations are run on top of the schema created by the base file, which
+// should create the schema as it was before the first migration. If the base file is empty, the base schema is made
+// by running the setup file and then rolling back every migration, in which case the check also verifies that the
+// down migrations undo the up migrations.
+// The temporary schemas are dropped once they are loaded.
+func Check(ctx context.Context, db *sql.DB, setupFile string, migrationsDir string, baseFile string) ([]string, error) {
+	setupSQL, err := ioutil.ReadFile(setupFile)
+	if err != nil {
+		return nil, err
+	}
+	upMigrations, err := readMigrations(migrationsDir, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var baseScripts []string
+	if baseFile != "" {
+		baseSQL, err := ioutil.ReadFile(baseFile)
+		if err != nil {
+			return nil, err
+		}
+		baseScripts = []string{string(baseSQL)}
+	} else {
+		downMigrations, err := readMigrations(migrationsDir, false)
+		if err != nil {
+			return nil, err
+		}
+		baseScripts = append([]string{string(setupSQL)}, downMigrations...)
+	}
+
+	defer dropSchema(ctx, db, setupSchema)
+	err = build(ctx, db, setupSchema, []string{string(setupSQL)})
+	if err != nil {
+		return nil, fmt.Errorf("building the schema from %s failed: %w", setupFile, err)
+	}
+	defer dropSchema(ctx, db, migratedSchema)
+	err = build(ctx, db, migratedSchema, append(baseScripts, upMigrations...))
+	if err != nil {
+		return nil, fmt.Errorf("building the schema from the migrations failed: %w", err)
+	}
+
+	setup, err := Load(ctx, db, setupSchema)
+	if err != nil {
+		return nil, err
+	}
+	migrated, err := Load(ctx, db, migratedSchema)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(setup, migrated, "dbsetup.sql", "migrations"), nil
+}
+
+// build creates the schema and runs the scripts in it. The scripts' objects are created in the schema because it is
+// the only schema in the search path while they run.
+func build(ctx context.Context, db *sql.DB, schema string, scripts []string) error {
+	err := dropSchema(ctx, db, schema)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, "CREATE SCHEMA "+pq.QuoteIdentifier(schema))
+	if err != nil {
+		return err
+	}
+
+	// the search path is set on a single connection, which is reset before it is returned to the pool
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	defer conn.ExecContext(ctx, "RESET search_path")
+
+	_, err = conn.ExecContext(ctx, "SET search_path TO "+pq.QuoteIdentifier(schema))
+	if err != nil {
+		return err
+	}
+	for i, script := range scripts {
+		_, err = conn.ExecContext(ctx, script)
+		if err != nil {
+			return fmt.Errorf("script %d of %d: %w", i+1, len(scripts), err)
+		}
+	}
+	return nil
+}
+
+func dropSchema(ctx context.Context, db *sql.DB, schema string) error {
+	_, err := db.ExecContext(ctx, "DROP SCHEMA IF EXISTS "+pq.QuoteIdentifier(schema)+" CASCADE")
+	return err
+}
+
+// readMigrations returns the up migrations in the directory in the order they are applied, or the down migrations
+// in the order they are rolled back.
+func readMigrations(dir string, up bool) ([]string, error) {
+	src, err := source.Open("file://" + dir)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	var migrations []string
+	version, err := src.First()
+	for err == nil {
+		read := src.ReadDown
+		if up {
+			read = src.ReadUp
+		}
+		r, _, readErr := read(version)
+		if readErr != nil {
+			return nil, readErr
+		}
+		migration, readErr := ioutil.ReadAll(r)
+		r.Close()
+		if readErr != nil {
+			return nil, readErr
+		}
+		migrations = append(migrations, string(migration))
+
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if !up {
+		for i, j := 0, len(migrations)-1; i < j; i, j = i+1, j-1 {
+			migrations[i], migrations[j] = migrations[j], migrations[i]
+		}
+	}
+	return migrations, nil
+}
+
+// Load loads the tables, indexes, views, triggers and functions of the postgres schema. The schema's name is removed from the
+// definitions, so that the same objects in different schemas have the same definitions.
+func Load(ctx context.Context, db *sql.DB, schema string) (*Schema, error) {
+	s := &Schema{
+		Tables:    make(map[string]*Table),
+		Indexes:   make(map[string]string),
+		Views:     make(map[string]string),
+		Triggers:  make(map[string]string),
+		Functions: make(map[string]string),
+	}
+	unqualify := func(definition string) string {
+		definition = strings.ReplaceAll(definition, pq.QuoteIdentifier(schema)+".", "")
+		return strings.ReplaceAll(definition, schema+".", "")
+	}
+
+	columns := `
+		SELECT c.relname, a.attname,
+			format_type(a.atttypid, a.atttypmod)
+				|| CASE WHEN a.attnotnull THEN ' NOT NULL' ELSE '' END
+				|| COALESCE(' DEFAULT ' || pg_get_expr(d.adbin, d.adrelid), '')
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+		LEFT JOIN pg_attrdef d ON d.adrelid = c.oid AND d.adnum = a.attnum
+		WHERE n.nspname = $1 AND c.relkind IN ('r', 'p') AND NOT c.relispartition
+		ORDER BY c.relname, a.attnum`
+	err := scanDefinitions(ctx, db, columns, schema, func(table string, column string, definition string) {
+		t := s.table(table)
+		t.Columns[column] = unqualify(definition)
+		t.ColumnOrder = append(t.ColumnOrder, column)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading the columns of schema %s failed: %w", schema, err)
+	}
+
+	constraints := `
+		SELECT c.relname, con.conname, pg_get_constraintdef(con.oid)
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND NOT c.relispartition`
+	err = scanDefinitions(ctx, db, constraints, schema, func(table string, constraint string, definition string) {
+		s.table(table).Constraints[constraint] = unqualify(definition)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading the constraints of schema %s failed: %w", schema, err)
+	}
+
+	indexes := `
+		SELECT t.relname, i.relname, pg_get_indexdef(i.oid)
+		FROM pg_index x
+		JOIN pg_class i ON i.oid = x.indexrelid
+		JOIN pg_class t ON t.oid = x.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		WHERE n.nspname = $1 AND NOT t.relispartition`
+	err = scanDefinitions(ctx, db, indexes, schema, func(table string, index string, definition string) {
+		s.Indexes[index] = unqualify(definition)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading the indexes of schema %s failed: %w", schema, err)
+	}
+
+	views := `
+		SELECT c.relname, c.relname, pg_get_viewdef(c.oid, true)
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relkind IN ('v', 'm')`
+	err = scanDefinitions(ctx, db, views, schema, func(view string, _ string, definition string) {
+		s.Views[view] = unqualify(definition)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading the views of schema %s failed: %w", schema, err)
+	}
+
+	triggers := `
+		SELECT c.relname, t.tgname, pg_get_triggerdef(t.oid, true)
+		FROM pg_trigger t
+		JOIN pg_class c ON c.oid = t.tgrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND NOT t.tgisinternal AND NOT c.relispartition`
+	err = scanDefinitions(ctx, db, triggers, schema, func(table string, trigger string, definition string) {
+		s.Triggers[table+"."+trigger] = unqualify(definition)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading the triggers of schema %s failed: %w", schema, err)
+	}
+
+	// aggregates and window functions have no definition to compare
+	functions := `
+		SELECT p.proname, pg_get_function_identity_arguments(p.oid), pg_get_functiondef(p.oid)
+		FROM pg_proc p
+		JOIN pg_namespace n ON n.oid = p.pronamespace
+		WHERE n.nspname = $1 AND p.prokind IN ('f', 'p')`
+	err = scanDefinitions(ctx, db, functions, schema, func(function string, arguments string, definition string) {
+		s.Functions[function+"("+unqualify(arguments)+")"] = strings.Join(strings.Fields(unqualify(definition)), " ")
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading the functions of schema %s failed: %w", schema, err)
+	}
+
+	return s, nil
+}
+
+// scanDefinitions runs the query for the schema and passes each of the rows' three columns to 'add'.
+func scanDefinitions(ctx context.Context, db *sql.DB, query string, schema string, add func(string, string, string)) error {
+	rows, err := db.QueryContext(ctx, query, schema)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var parent, name, definition string
+		err = rows.Scan(&parent, &name, &definition)
+		if err != nil {
+			return err
+		}
+		add(parent, name, definition)
+	}
+	return rows.Err()
+}
+
+func (s *Schema) table(name string) *Table {
+	table, ok := s.Tables[name]
+	if !ok {
+		table = &Table{
+			Columns:     make(map[string]string),
+			Constraints: make(map[string]string),
+		}
+		s.Tables[name] = table
+	}
+	return table
+}
+
+// Diff returns a sorted description of each difference between the two schemas. The names are used to say which
+// schema an object is missing from or what each schema's definition is.
+func Diff(a *Schema, b *Schema, aName string, bName string) []string {
+	var diffs []string
+	diffs = append(diffs, diffDefinitions("table", tableNames(a), tableNames(b), aName, bName)...)
+	for name, aTable := range a.Tables {
+		bTable, ok := b.Tables[name]
+		if !ok {
+			continue
+		}
+		diffs = append(diffs, diffDefinitions("column "+name+".", aTable.Columns, bTable.Columns, aName, bName)...)
+		diffs = append(diffs, diffColumnOrder(name, aTable, bTable, aName, bName)...)
+		diffs = append(diffs, diffDefinitions("constraint "+name+".", aTable.Constraints, bTable.Constraints, aName, bName)...)
+	}
+	diffs = append(diffs, diffDefinitions("index", a.Indexes, b.Indexes, aName, bName)...)
+	diffs = append(diffs, diffDefinitions("view", a.Views, b.Views, aName, bName)...)
+	diffs = append(diffs, diffDefinitions("trigger", a.Triggers, b.Triggers, aName, bName)...)
+	diffs = append(diffs, diffDefinitions("function", a.Functions, b.Functions, aName, bName)...)
+	sort.Strings(diffs)
+	return diffs
+}
+
+func tableNames(s *Schema) map[string]string {
+	names := make(map[string]string)
+	for name := range s.Tables {
+		names[name] = ""
+	}
+	return names
+}
+
+// diffColumnOrder describes a difference in the order of the columns that are in both tables. Columns that are only
+// in one of the tables are described by diffDefinitions.
+func diffColumnOrder(table string, a *Table, b *Table, aName string, bName string) []string {
+	shared := func(columns []string, other *Table) []string {
+		var order []string
+		for _, column := range columns {
+			if _, ok := other.Columns[column]; ok {
+				order = append(order, column)
+			}
+		}
+		return order
+	}
+	aOrder := strings.Join(shared(a.ColumnOrder, b), ", ")
+	bOrder := strings.Join(shared(b.ColumnOrder, a), ", ")
+	if aOrder == bOrder {
+		return nil
+	}
+	return []string{fmt.Sprintf("column order of %s differs:\n  %s: %s\n  %s: %s", table, aName, aOrder, bName, bOrder)}
+}
+
+// diffDefinitions describes the names that are only in one of the maps and the names whose definitions differ. A
+// kind ending with "." is joined to the name without a space.
+func diffDefinitions(kind string, a map[string]string, b map[string]string, aName string, bName string) []string {
+	label := func(name string) string {
+		if strings.HasSuffix(kind, ".") {
+			return kind + name
+		}
+		return kind + " " + name
+	}
+
+	var diffs []string
+	for name, aDefinition := range a {
+		bDefinition, ok := b[name]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("%s is only in %s", label(name), aName))
+		} else if aDefinition != bDefinition {
+			diffs = append(diffs, fmt.Sprintf("%s differs:\n  %s: %s\n  %s: %s", label(name), aName, aDefinition, bName, bDefinition))
+		}
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			diffs = append(diffs, fmt.Sprintf("%s is only in %s", label(name), bName))
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,55 @@
+// +build integration
+
+package schemadrift
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// Test_Check checks that the schema from dbsetup.sql matches the schema from the migrations, using the test database.
+func Test_Check(t *testing.T) {
+	// the host is shared with the lantern database and the user and database name are specific to the tests
+	viper.SetEnvPrefix("lantern")
+	for _, key := range []string{"dbhost", "dbport", "dbsslmode"} {
+		err := viper.BindEnv(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	viper.SetEnvPrefix("lantern_test")
+	for _, key := range []string{"dbuser", "dbpassword", "dbname"} {
+		err := viper.BindEnv(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	viper.SetDefault("dbhost", "localhost")
+	viper.SetDefault("dbport", 5432)
+	viper.SetDefault("dbsslmode", "disable")
+	viper.SetDefault("dbuser", "lantern")
+	viper.SetDefault("dbpassword", "postgrespassword")
+	viper.SetDefault("dbname", "lantern_test")
+
+	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		viper.GetString("dbhost"), viper.GetInt("dbport"), viper.GetString("dbuser"),
+		viper.GetString("dbpassword"), viper.GetString("dbname"), viper.GetString("dbsslmode"))
+	db, err := sql.Open("postgres", psqlInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	diffs, err := Check(context.Background(), db, "../../../sql/dbsetup.sql", "../../migrations", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("the schema from dbsetup.sql does not match the schema from the migrations:\n%s", strings.Join(diffs, "\n"))
+	}
+}
@@ -0,0 +1,131 @@
+package schemadrift
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func testSchema() *Schema {
+	return &Schema{
+		Tables: map[string]*Table{
+			"fhir_endpoints": {
+				Columns: map[string]string{
+					"id":  "integer NOT NULL DEFAULT nextval('fhir_endpoints_id_seq'::regclass)",
+					"url": "character varying(500)",
+				},
+				ColumnOrder: []string{"id", "url"},
+				Constraints: map[string]string{
+					"fhir_endpoints_pkey": "PRIMARY KEY (id)",
+				},
+			},
+		},
+		Indexes: map[string]string{
+			"fhir_endpoints_url_idx": "CREATE INDEX fhir_endpoints_url_idx ON fhir_endpoints USING btree (url)",
+		},
+		Views: map[string]string{
+			"endpoint_export": " SELECT fhir_endpoints.url FROM fhir_endpoints;",
+		},
+		Triggers: map[string]string{
+			"fhir_endpoints.set_timestamp_fhir_endpoints": "CREATE TRIGGER set_timestamp_fhir_endpoints BEFORE UPDATE ON fhir_endpoints FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp()",
+		},
+		Functions: map[string]string{
+			"trigger_set_timestamp()": "CREATE OR REPLACE FUNCTION trigger_set_timestamp() RETURNS trigger LANGUAGE plpgsql AS $function$ BEGIN NEW.updated_at = NOW(); RETURN NEW; END; $function$",
+		},
+	}
+}
+
+func Test_Diff(t *testing.T) {
+	a := testSchema()
+	b := testSchema()
+
+	diffs := Diff(a, b, "dbsetup.sql", "migrations")
+	if len(diffs) != 0 {
+		t.Errorf("expected no differences between the same schemas, got %v", diffs)
+	}
+
+	b.Tables["fhir_endpoints"].Columns["url"] = "character varying(200)"
+	b.Tables["fhir_endpoints"].Columns["list_source"] = "character varying(500)"
+	delete(b.Indexes, "fhir_endpoints_url_idx")
+	b.Views["endpoint_export"] = " SELECT fhir_endpoints.url, fhir_endpoints.id FROM fhir_endpoints;"
+	b.Tables["vendors"] = &Table{Columns: map[string]string{"id": "integer"}, ColumnOrder: []string{"id"}}
+	b.Functions["trigger_set_timestamp()"] = "CREATE OR REPLACE FUNCTION trigger_set_timestamp() RETURNS trigger LANGUAGE plpgsql AS $function$ BEGIN RETURN NEW; END; $function$"
+
+	diffs = Diff(a, b, "dbsetup.sql", "migrations")
+	expected := []string{
+		"column fhir_endpoints.list_source is only in migrations",
+		"column fhir_endpoints.url differs:\n  dbsetup.sql: character varying(500)\n  migrations: character varying(200)",
+		"function trigger_set_timestamp() differs:\n  dbsetup.sql: CREATE OR REPLACE FUNCTION trigger_set_timestamp() RETURNS trigger LANGUAGE plpgsql AS $function$ BEGIN NEW.updated_at = NOW(); RETURN NEW; END; $function$\n  migrations: CREATE OR REPLACE FUNCTION trigger_set_timestamp() RETURNS trigger LANGUAGE plpgsql AS $function$ BEGIN RETURN NEW; END; $function$",
+		"index fhir_endpoints_url_idx is only in dbsetup.sql",
+		"table vendors is only in migrations",
+		"view endpoint_export differs:\n  dbsetup.sql:  SELECT fhir_endpoints.url FROM fhir_endpoints;\n  migrations:  SELECT fhir_endpoints.url, fhir_endpoints.id FROM fhir_endpoints;",
+	}
+	if !reflect.DeepEqual(diffs, expected) {
+		t.Errorf("expected the differences %q, got %q", expected, diffs)
+	}
+}
+
+func Test_DiffColumnOrder(t *testing.T) {
+	a := testSchema()
+	b := testSchema()
+
+	// a column that is only in one of the tables does not change the order of the others
+	b.Tables["fhir_endpoints"].Columns["list_source"] = "character varying(500)"
+	b.Tables["fhir_endpoints"].ColumnOrder = []string{"id", "list_source", "url"}
+	diffs := Diff(a, b, "dbsetup.sql", "migrations")
+	expected := []string{"column fhir_endpoints.list_source is only in migrations"}
+	if !reflect.DeepEqual(diffs, expected) {
+		t.Errorf("expected the differences %q, got %q", expected, diffs)
+	}
+
+	b.Tables["fhir_endpoints"].ColumnOrder = []string{"url", "list_source", "id"}
+	diffs = Diff(a, b, "dbsetup.sql", "migrations")
+	expected = []string{
+		"column fhir_endpoints.list_source is only in migrations",
+		"column order of fhir_endpoints differs:\n  dbsetup.sql: id, url\n  migrations: url, id",
+	}
+	if !reflect.DeepEqual(diffs, expected) {
+		t.Errorf("expected the differences %q, got %q", expected, diffs)
+	}
+}
+
+func Test_readMigrations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"000002_add_chpl_criteria.up.sql":           "up 2",
+		"000002_add_chpl_criteria.down.sql":         "down 2",
+		"000010_add_endpoint_availability.up.sql":   "up 10",
+		"000010_add_endpoint_availability.down.sql": "down 10",
+		"000003_add_indexes.up.sql":                 "up 3",
+		"000003_add_indexes.down.sql":               "down 3",
+	}
+	for name, contents := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	up, err := readMigrations(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(up, []string{"up 2", "up 3", "up 10"}) {
+		t.Errorf("expected the up migrations in version order, got %v", up)
+	}
+
+	down, err := readMigrations(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(down, []string{"down 10", "down 3", "down 2"}) {
+		t.Errorf("expected the down migrations in reverse version order, got %v", down)
+	}
+}
@@ -6,6 +6,84 @@ BEGIN
 END;
 $$ LANGUAGE plpgsql;
 
+CREATE OR REPLACE FUNCTION fhir_endpoints_document_hash(document JSONB) RETURNS CHAR(64) AS $$
+    -- the text form of a JSONB value is canonical: object keys are sorted, duplicate keys removed and
+    -- whitespace normalized, so documents that only differ in formatting have the same hash.
+    SELECT encode(sha256(convert_to(document::text, 'UTF8')), 'hex');
+$$ LANGUAGE sql IMMUTABLE STRICT;
+
+CREATE OR REPLACE FUNCTION add_fhir_endpoints_document(document JSONB) RETURNS CHAR(64) AS $$
+    DECLARE
+        document_hash CHAR(64);
+    BEGIN
+        --
+        -- Store the document in fhir_endpoints_documents if it is not already there and return its hash.
+        --
+        IF document IS NULL THEN
+            RETURN NULL;
+        END IF;
+        document_hash := fhir_endpoints_document_hash(document);
+        INSERT INTO fhir_endpoints_documents (hash, document) VALUES (document_hash, document)
+            ON CONFLICT (hash) DO NOTHING;
+        RETURN document_hash;
+    END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION add_fhir_endpoints_metadata_triggers(partition_name TEXT) RETURNS VOID AS $$
+    BEGIN
+        --
+        -- Partitioned tables can not have BEFORE row triggers, so the fhir_endpoints_metadata triggers are
+        -- created on each of its partitions.
+        --
+        EXECUTE format('CREATE TRIGGER set_timestamp_fhir_endpoints_metadata BEFORE UPDATE ON %I
+            FOR EACH ROW EXECUTE PROCEDURE trigger_set_timestamp()', partition_name);
+        -- increments total number of times http status returned for endpoint
+        EXECUTE format('CREATE TRIGGER update_fhir_endpoint_availability_trigger BEFORE INSERT OR UPDATE ON %I
+            FOR EACH ROW EXECUTE PROCEDURE update_fhir_endpoint_availability_info()', partition_name);
+    END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION create_history_partitions(start_month DATE, end_month DATE) RETURNS VOID AS $$
+    DECLARE
+        partition_month DATE;
+        next_month      DATE;
+        parent_table    TEXT;
+        partition_key   TEXT;
+        partition_name  TEXT;
+    BEGIN
+        --
+        -- Create the monthly partitions of fhir_endpoints_info_history and fhir_endpoints_metadata from start_month
+        -- through end_month and record them in history_partitions. Partitions that were already created, including
+        -- the ones since dropped by the retention policy, are skipped. Rows for a new partition's month that were
+        -- stored in the default partition are moved into the new partition.
+        --
+        partition_month := date_trunc('month', start_month);
+        WHILE partition_month <= end_month LOOP
+            next_month := partition_month + INTERVAL '1 month';
+            FOREACH parent_table IN ARRAY ARRAY['fhir_endpoints_info_history', 'fhir_endpoints_metadata'] LOOP
+                partition_name := parent_table || to_char(partition_month, '"_y"YYYY"m"MM');
+                CONTINUE WHEN EXISTS (SELECT 1 FROM history_partitions WHERE name = partition_name);
+
+                IF parent_table = 'fhir_endpoints_metadata' THEN
+                    partition_key := 'created_at';
+                ELSE
+                    partition_key := 'entered_at';
+                END IF;
+                EXECUTE format('CREATE TABLE %I (LIKE %I INCLUDING DEFAULTS)', partition_name, parent_table);
+                EXECUTE format('WITH moved AS (DELETE FROM %I WHERE %I >= %L AND %I < %L RETURNING *) INSERT INTO %I SELECT * FROM moved',
+                    parent_table || '_default', partition_key, partition_month, partition_key, next_month, partition_name);
+                EXECUTE format('ALTER TABLE %I ATTACH PARTITION %I FOR VALUES FROM (%L) TO (%L)',
+                    parent_table, partition_name, partition_month, next_month);
+                IF parent_table = 'fhir_endpoints_metadata' THEN
+                    PERFORM add_fhir_endpoints_metadata_triggers(partition_name);
+                END IF;
+                INSERT INTO history_partitions (name, parent_table, month) VALUES (partition_name, parent_table, partition_month);
+            END LOOP;
+            partition_month := next_month;
+        END LOOP;
+    END;
+$$ LANGUAGE plpgsql;
+
 CREATE OR REPLACE FUNCTION add_fhir_endpoint_info_history() RETURNS TRIGGER AS $fhir_endpoints_info_history$
     BEGIN
         --
@@ -26,6 +104,95 @@ CREATE OR REPLACE FUNCTION add_fhir_endpoint_info_history() RETURNS TRIGGER AS $
     END;
 $fhir_endpoints_info_history$ LANGUAGE plpgsql;
 
+CREATE OR REPLACE FUNCTION add_fhir_endpoint_hosting_history() RETURNS TRIGGER AS $fhir_endpoints_hosting_history$
+    BEGIN
+        --
+        -- Create a row in fhir_endpoints_hosting_history to reflect the operation performed on fhir_endpoints_hosting,
+        -- make use of the special variable TG_OP to work out the operation.
+        --
+        IF (TG_OP = 'DELETE') THEN
+            INSERT INTO fhir_endpoints_hosting_history SELECT 'D', now(), user, OLD.*;
+            RETURN OLD;
+        ELSIF (TG_OP = 'UPDATE') THEN
+            INSERT INTO fhir_endpoints_hosting_history SELECT 'U', now(), user, NEW.*;
+            RETURN NEW;
+        ELSIF (TG_OP = 'INSERT') THEN
+            INSERT INTO fhir_endpoints_hosting_history SELECT 'I', now(), user, NEW.*;
+            RETURN NEW;
+        END IF;
+        RETURN NULL; -- result is ignored since this is an AFTER trigger
+    END;
+$fhir_endpoints_hosting_history$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION add_vendor_history() RETURNS TRIGGER AS $vendors_history$
+    BEGIN
+        --
+        -- Create a row in vendors_history to reflect the operation performed on vendors,
+        -- make use of the special variable TG_OP to work out the operation.
+        -- Updates that only change updated_at are not recorded so that each CHPL sync only adds the rows that changed.
+        --
+        IF (TG_OP = 'DELETE') THEN
+            INSERT INTO vendors_history SELECT 'D', now(), user, OLD.*;
+            RETURN OLD;
+        ELSIF (TG_OP = 'UPDATE') THEN
+            IF (to_jsonb(OLD) - 'updated_at') IS DISTINCT FROM (to_jsonb(NEW) - 'updated_at') THEN
+                INSERT INTO vendors_history SELECT 'U', now(), user, NEW.*;
+            END IF;
+            RETURN NEW;
+        ELSIF (TG_OP = 'INSERT') THEN
+            INSERT INTO vendors_history SELECT 'I', now(), user, NEW.*;
+            RETURN NEW;
+        END IF;
+        RETURN NULL; -- result is ignored since this is an AFTER trigger
+    END;
+$vendors_history$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION add_healthit_product_history() RETURNS TRIGGER AS $healthit_products_history$
+    BEGIN
+        --
+        -- Create a row in healthit_products_history to reflect the operation performed on healthit_products,
+        -- make use of the special variable TG_OP to work out the operation.
+        -- Updates that only change updated_at are not recorded so that each CHPL sync only adds the rows that changed.
+        --
+        IF (TG_OP = 'DELETE') THEN
+            INSERT INTO healthit_products_history SELECT 'D', now(), user, OLD.*;
+            RETURN OLD;
+        ELSIF (TG_OP = 'UPDATE') THEN
+            IF (to_jsonb(OLD) - 'updated_at') IS DISTINCT FROM (to_jsonb(NEW) - 'updated_at') THEN
+                INSERT INTO healthit_products_history SELECT 'U', now(), user, NEW.*;
+            END IF;
+            RETURN NEW;
+        ELSIF (TG_OP = 'INSERT') THEN
+            INSERT INTO healthit_products_history SELECT 'I', now(), user, NEW.*;
+            RETURN NEW;
+        END IF;
+        RETURN NULL; -- result is ignored since this is an AFTER trigger
+    END;
+$healthit_products_history$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION add_certification_criteria_history() RETURNS TRIGGER AS $certification_criteria_history$
+    BEGIN
+        --
+        -- Create a row in certification_criteria_history to reflect the operation performed on certification_criteria,
+        -- make use of the special variable TG_OP to work out the operation.
+        -- Updates that only change updated_at are not recorded so that each CHPL sync only adds the rows that changed.
+        --
+        IF (TG_OP = 'DELETE') THEN
+            INSERT INTO certification_criteria_history SELECT 'D', now(), user, OLD.*;
+            RETURN OLD;
+        ELSIF (TG_OP = 'UPDATE') THEN
+            IF (to_jsonb(OLD) - 'updated_at') IS DISTINCT FROM (to_jsonb(NEW) - 'updated_at') THEN
+                INSERT INTO certification_criteria_history SELECT 'U', now(), user, NEW.*;
+            END IF;
+            RETURN NEW;
+        ELSIF (TG_OP = 'INSERT') THEN
+            INSERT INTO certification_criteria_history SELECT 'I', now(), user, NEW.*;
+            RETURN NEW;
+        END IF;
+        RETURN NULL; -- result is ignored since this is an AFTER trigger
+    END;
+$certification_criteria_history$ LANGUAGE plpgsql;
+
 CREATE OR REPLACE FUNCTION update_fhir_endpoint_availability_info() RETURNS TRIGGER AS $fhir_endpoints_availability$
     DECLARE
         okay_count       bigint;
@@ -66,9 +233,45 @@ CREATE TABLE npi_organizations (
     name                        VARCHAR(500),
     secondary_name              VARCHAR(500),
     location                    JSONB,
+    mailing_location            JSONB,
     taxonomy                    VARCHAR(500), -- Taxonomy code mapping: http://www.wpc-edi.com/reference/codelists/healthcare/health-care-provider-taxonomy-code-set/
+    authorized_official         JSONB,
     normalized_name             VARCHAR(500),
     normalized_secondary_name   VARCHAR(500),
+    last_update_date            DATE,
+    deactivation_date           DATE,
+    reactivation_date           DATE,
+    created_at                  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at                  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE npi_organization_taxonomies (
+    npi_id                      VARCHAR(500) REFERENCES npi_organizations(npi_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    position                    INTEGER,
+    code                        VARCHAR(500),
+    license_number              VARCHAR(500),
+    license_state               VARCHAR(500),
+    is_primary                  BOOLEAN NOT NULL DEFAULT FALSE,
+    CONSTRAINT npi_organization_taxonomy PRIMARY KEY (npi_id, position)
+);
+
+CREATE TABLE npi_organization_identifiers (
+    npi_id                      VARCHAR(500) REFERENCES npi_organizations(npi_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    position                    INTEGER,
+    identifier                  VARCHAR(500),
+    type_code                   VARCHAR(500),
+    state                       VARCHAR(500),
+    issuer                      VARCHAR(500),
+    CONSTRAINT npi_organization_identifier PRIMARY KEY (npi_id, position)
+);
+
+-- NUCC health care provider taxonomy code set: https://www.nucc.org/index.php/code-sets-mainmenu-41/provider-taxonomy-mainmenu-40/csv-mainmenu-57
+CREATE TABLE nucc_taxonomies (
+    code                        VARCHAR(500) PRIMARY KEY,
+    grouping_name               VARCHAR(500),
+    classification              VARCHAR(500),
+    specialization              VARCHAR(500),
+    display_name                VARCHAR(500),
     created_at                  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     updated_at                  TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
@@ -147,28 +350,46 @@ CREATE TABLE fhir_endpoints (
     npi_ids                 VARCHAR(500)[],
     list_source             VARCHAR(500),
     versions_response       JSONB,
+    locations               JSONB,
     created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     CONSTRAINT fhir_endpoints_unique UNIQUE(url, list_source)
 );
 
+-- fhir_endpoints_metadata is partitioned by month, see create_history_partitions
 CREATE TABLE fhir_endpoints_metadata (
-    id                      SERIAL PRIMARY KEY,
+    id                      SERIAL,
     url                     VARCHAR(500),
     http_response           INTEGER,
     availability            DECIMAL(5,4),
     errors                  VARCHAR(500),
+    error_category          VARCHAR(500) DEFAULT '',
     response_time_seconds   DECIMAL(7,4),
     smart_http_response     INTEGER,
     requested_fhir_version VARCHAR(500) DEFAULT 'None',
+    redirects               JSONB,
+    smart_redirects         JSONB,
+    permanent_redirect      BOOLEAN DEFAULT FALSE,
+    canonical_url           VARCHAR(500) DEFAULT '',
+    ip_response_times       JSONB,
+    latency_breakdown       JSONB,
     created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+CREATE TABLE fhir_endpoints_metadata_default PARTITION OF fhir_endpoints_metadata DEFAULT;
 
 CREATE TABLE validation_results (
     id                      SERIAL PRIMARY KEY
 );
 
+CREATE TABLE fhir_endpoints_documents (
+    hash                    CHAR(64) PRIMARY KEY, -- see fhir_endpoints_document_hash
+    document                JSONB NOT NULL,
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
 CREATE TABLE fhir_endpoints_info (
     id                      SERIAL PRIMARY KEY,
     healthit_product_id     INT REFERENCES healthit_products(id) ON DELETE SET NULL,
@@ -176,19 +397,23 @@ CREATE TABLE fhir_endpoints_info (
     url                     VARCHAR(500),
     tls_version             VARCHAR(500),
     mime_types              VARCHAR(500)[],
-    capability_statement    JSONB,
+    capability_statement_hash CHAR(64) REFERENCES fhir_endpoints_documents(hash) ON DELETE SET NULL,
     validation_result_id    INT REFERENCES validation_results(id) ON DELETE SET NULL,
     included_fields         JSONB,
     operation_resource      JSONB,
     created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    smart_response          JSONB,
-    metadata_id             INT REFERENCES fhir_endpoints_metadata(id) ON DELETE SET NULL,
+    smart_response_hash     CHAR(64) REFERENCES fhir_endpoints_documents(hash) ON DELETE SET NULL,
+    metadata_id             INT, -- should link to fhir_endpoints_metadata(id). not using 'reference' because partitioned tables can not be referenced.
     requested_fhir_version  VARCHAR(500),
     capability_fhir_version VARCHAR(500),
+    http_version            VARCHAR(500) DEFAULT '',
+    alpn_protocol           VARCHAR(500) DEFAULT '',
+    ip_reachability         JSONB,
     CONSTRAINT fhir_endpoints_info_unique UNIQUE(url, requested_fhir_version)
 );
 
+-- fhir_endpoints_info_history is partitioned by month, see create_history_partitions
 CREATE TABLE fhir_endpoints_info_history (
     operation               CHAR(1) NOT NULL,
     entered_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
@@ -199,17 +424,22 @@ CREATE TABLE fhir_endpoints_info_history (
     url                     VARCHAR(500),
     tls_version             VARCHAR(500),
     mime_types              VARCHAR(500)[],
-    capability_statement    JSONB,
+    capability_statement_hash CHAR(64), -- should link to fhir_endpoints_documents(hash). not using 'reference' because we want the historical copies to be kept even if their document is removed.
     validation_result_id    INT REFERENCES validation_results(id) ON DELETE SET NULL,
     included_fields         JSONB,
     operation_resource      JSONB,
     created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-    smart_response          JSONB, 
-    metadata_id             INT REFERENCES fhir_endpoints_metadata(id) ON DELETE SET NULL,
+    smart_response_hash     CHAR(64), -- should link to fhir_endpoints_documents(hash).
+    metadata_id             INT, -- should link to fhir_endpoints_metadata(id). not using 'reference' because partitioned tables can not be referenced.
     requested_fhir_version  VARCHAR(500),
-    capability_fhir_version VARCHAR(500)
-);
+    capability_fhir_version VARCHAR(500),
+    http_version            VARCHAR(500) DEFAULT '',
+    alpn_protocol           VARCHAR(500) DEFAULT '',
+    ip_reachability         JSONB
+) PARTITION BY RANGE (entered_at);
+
+CREATE TABLE fhir_endpoints_info_history_default PARTITION OF fhir_endpoints_info_history DEFAULT;
 
 CREATE TABLE endpoint_organization (
     url                     VARCHAR(500),
@@ -220,6 +450,19 @@ CREATE TABLE endpoint_organization (
     CONSTRAINT endpoint_org PRIMARY KEY (url, organization_npi_id)
 );
 
+CREATE TABLE endpoint_organization_reviews (
+    url                     VARCHAR(500),
+    organization_npi_id     VARCHAR(500),
+    confidence              NUMERIC (5, 3),
+    score_breakdown         JSONB,
+    status                  VARCHAR(500) NOT NULL DEFAULT 'pending',
+    decided_by              VARCHAR(500) DEFAULT '',
+    decided_at              TIMESTAMPTZ,
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT endpoint_org_review PRIMARY KEY (url, organization_npi_id)
+);
+
 CREATE TABLE product_criteria (
     healthit_product_id      INT REFERENCES healthit_products(id) ON DELETE CASCADE,
     certification_id         INTEGER,
@@ -238,6 +481,90 @@ CREATE TABLE fhir_endpoints_availability (
     updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
 );
 
+CREATE TABLE fhir_endpoints_hosting (
+    id                      SERIAL PRIMARY KEY,
+    url                     VARCHAR(500) UNIQUE,
+    hostname                VARCHAR(500),
+    ip_addresses            JSONB,
+    errors                  VARCHAR(500),
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE fhir_endpoints_hosting_history (
+    operation               CHAR(1) NOT NULL,
+    entered_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    user_id                 VARCHAR(500),
+    id                      INT, -- should link to fhir_endpoints_hosting(id). not using 'reference' because if the original is deleted, we still want the historical copies to remain.
+    url                     VARCHAR(500),
+    hostname                VARCHAR(500),
+    ip_addresses            JSONB,
+    errors                  VARCHAR(500),
+    created_at              TIMESTAMPTZ,
+    updated_at              TIMESTAMPTZ
+);
+
+CREATE TABLE vendors_history (
+    operation               CHAR(1) NOT NULL,
+    entered_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    user_id                 VARCHAR(500),
+    id                      INT, -- should link to vendors(id). not using 'reference' because if the original is deleted, we still want the historical copies to remain.
+    name                    VARCHAR(500),
+    developer_code          VARCHAR(500),
+    url                     VARCHAR(500),
+    location                JSONB,
+    status                  VARCHAR(500),
+    last_modified_in_chpl   TIMESTAMPTZ,
+    chpl_id                 INTEGER,
+    created_at              TIMESTAMPTZ,
+    updated_at              TIMESTAMPTZ
+);
+
+CREATE TABLE healthit_products_history (
+    operation               CHAR(1) NOT NULL,
+    entered_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    user_id                 VARCHAR(500),
+    id                      INT, -- should link to healthit_products(id). not using 'reference' because if the original is deleted, we still want the historical copies to remain.
+    name                    VARCHAR(500),
+    version                 VARCHAR(500),
+    vendor_id               INT,
+    location                JSONB,
+    authorization_standard  VARCHAR(500),
+    api_syntax              VARCHAR(500),
+    api_url                 VARCHAR(500),
+    certification_criteria  JSONB,
+    certification_status    VARCHAR(500),
+    certification_date      DATE,
+    certification_edition   VARCHAR(500),
+    last_modified_in_chpl   DATE,
+    chpl_id                 VARCHAR(500),
+    created_at              TIMESTAMPTZ,
+    updated_at              TIMESTAMPTZ
+);
+
+CREATE TABLE certification_criteria_history (
+    operation               CHAR(1) NOT NULL,
+    entered_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    user_id                 VARCHAR(500),
+    id                      INT, -- should link to certification_criteria(id). not using 'reference' because if the original is deleted, we still want the historical copies to remain.
+    certification_id        INTEGER,
+    cerification_number     VARCHAR(500),
+    title                   VARCHAR(500),
+    certification_edition_id INTEGER,
+    certification_edition   VARCHAR(500),
+    description             VARCHAR(500),
+    removed                 BOOLEAN,
+    created_at              TIMESTAMPTZ,
+    updated_at              TIMESTAMPTZ
+);
+
+CREATE TABLE chpl_syncs (
+    id                      SERIAL PRIMARY KEY,
+    mode                    VARCHAR(500),
+    started_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at            TIMESTAMPTZ
+);
+
 CREATE TABLE validations (
     rule_name               VARCHAR(500),
     valid                   BOOLEAN,
@@ -249,6 +576,35 @@ CREATE TABLE validations (
     validation_result_id    INT REFERENCES validation_results(id) ON DELETE SET NULL
 );
 
+CREATE TABLE history_partitions (
+    name                    VARCHAR(500) PRIMARY KEY,
+    parent_table            VARCHAR(500),
+    month                   DATE,
+    tier                    VARCHAR(500) NOT NULL DEFAULT 'full',
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE fhir_endpoints_metadata_daily (
+    url                     VARCHAR(500),
+    requested_fhir_version  VARCHAR(500),
+    day                     DATE,
+    http_200_count          BIGINT,
+    http_all_count          BIGINT,
+    avg_response_time_seconds DECIMAL(7,4),
+    max_response_time_seconds DECIMAL(7,4),
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fhir_endpoints_metadata_daily_pkey PRIMARY KEY (url, requested_fhir_version, day)
+);
+
+CREATE TABLE history_pruning_checkpoints (
+    mode                    VARCHAR(500) PRIMARY KEY,
+    last_url                VARCHAR(500),
+    created_at              TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at              TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
 
 CREATE TRIGGER set_timestamp_fhir_endpoints
 BEFORE UPDATE ON fhir_endpoints
@@ -280,8 +636,18 @@ BEFORE UPDATE ON fhir_endpoints_info
 FOR EACH ROW
 EXECUTE PROCEDURE trigger_set_timestamp();
 
-CREATE TRIGGER set_timestamp_fhir_endpoints_metadata
-BEFORE UPDATE ON fhir_endpoints_metadata
+CREATE TRIGGER set_timestamp_history_partitions
+BEFORE UPDATE ON history_partitions
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TRIGGER set_timestamp_fhir_endpoints_metadata_daily
+BEFORE UPDATE ON fhir_endpoints_metadata_daily
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TRIGGER set_timestamp_history_pruning_checkpoints
+BEFORE UPDATE ON history_pruning_checkpoints
 FOR EACH ROW
 EXECUTE PROCEDURE trigger_set_timestamp();
 
@@ -290,6 +656,16 @@ BEFORE UPDATE ON endpoint_organization
 FOR EACH ROW
 EXECUTE PROCEDURE trigger_set_timestamp();
 
+CREATE TRIGGER set_timestamp_endpoint_organization_reviews
+BEFORE UPDATE ON endpoint_organization_reviews
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TRIGGER set_timestamp_nucc_taxonomies
+BEFORE UPDATE ON nucc_taxonomies
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
 CREATE TRIGGER set_timestamp_product_criteria
 BEFORE UPDATE ON product_criteria
 FOR EACH ROW
@@ -300,6 +676,11 @@ BEFORE UPDATE ON fhir_endpoints_availability
 FOR EACH ROW
 EXECUTE PROCEDURE trigger_set_timestamp();
 
+CREATE TRIGGER set_timestamp_fhir_endpoints_hosting
+BEFORE UPDATE ON fhir_endpoints_hosting
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
 -- captures history for the fhir_endpoint_info table
 CREATE TRIGGER add_fhir_endpoint_info_history_trigger
 AFTER INSERT OR UPDATE OR DELETE on fhir_endpoints_info
@@ -307,11 +688,44 @@ FOR EACH ROW
 WHEN (current_setting('metadata.setting', 't') IS NULL OR current_setting('metadata.setting', 't') = 'FALSE')
 EXECUTE PROCEDURE add_fhir_endpoint_info_history();
 
--- increments total number of times http status returned for endpoint 
-CREATE TRIGGER update_fhir_endpoint_availability_trigger
-BEFORE INSERT OR UPDATE on fhir_endpoints_metadata
+-- captures history for the fhir_endpoints_hosting table
+CREATE TRIGGER add_fhir_endpoint_hosting_history_trigger
+AFTER INSERT OR UPDATE OR DELETE on fhir_endpoints_hosting
+FOR EACH ROW
+EXECUTE PROCEDURE add_fhir_endpoint_hosting_history();
+
+-- captures history for the vendors table
+CREATE TRIGGER add_vendor_history_trigger
+AFTER INSERT OR UPDATE OR DELETE on vendors
+FOR EACH ROW
+EXECUTE PROCEDURE add_vendor_history();
+
+-- captures history for the healthit_products table
+CREATE TRIGGER add_healthit_product_history_trigger
+AFTER INSERT OR UPDATE OR DELETE on healthit_products
+FOR EACH ROW
+EXECUTE PROCEDURE add_healthit_product_history();
+
+-- captures history for the certification_criteria table
+CREATE TRIGGER add_certification_criteria_history_trigger
+AFTER INSERT OR UPDATE OR DELETE on certification_criteria
 FOR EACH ROW
-EXECUTE PROCEDURE update_fhir_endpoint_availability_info();
+EXECUTE PROCEDURE add_certification_criteria_history();
+
+-- the fhir_endpoints_metadata triggers are created on each of its partitions
+SELECT add_fhir_endpoints_metadata_triggers('fhir_endpoints_metadata_default');
+
+CREATE or REPLACE VIEW fhir_endpoints_info_with_documents AS
+SELECT info.*, capstat.document AS capability_statement, smart.document AS smart_response
+FROM fhir_endpoints_info AS info
+LEFT JOIN fhir_endpoints_documents AS capstat ON info.capability_statement_hash = capstat.hash
+LEFT JOIN fhir_endpoints_documents AS smart ON info.smart_response_hash = smart.hash;
+
+CREATE or REPLACE VIEW fhir_endpoints_info_history_with_documents AS
+SELECT history.*, capstat.document AS capability_statement, smart.document AS smart_response
+FROM fhir_endpoints_info_history AS history
+LEFT JOIN fhir_endpoints_documents AS capstat ON history.capability_statement_hash = capstat.hash
+LEFT JOIN fhir_endpoints_documents AS smart ON history.smart_response_hash = smart.hash;
 
 CREATE or REPLACE VIEW endpoint_export AS
 SELECT endpts.url, endpts.list_source, endpts.organization_names AS endpoint_names,
@@ -330,11 +744,28 @@ SELECT endpts.url, endpts.list_source, endpts.organization_names AS endpoint_nam
     links.confidence AS MATCH_SCORE, endpts_metadata.availability
 FROM endpoint_organization AS links
 RIGHT JOIN fhir_endpoints AS endpts ON links.url = endpts.url
-LEFT JOIN fhir_endpoints_info AS endpts_info ON endpts.url = endpts_info.url
+LEFT JOIN fhir_endpoints_info_with_documents AS endpts_info ON endpts.url = endpts_info.url
 LEFT JOIN fhir_endpoints_metadata AS endpts_metadata ON endpts_info.metadata_id = endpts_metadata.id
 LEFT JOIN vendors ON endpts_info.vendor_id = vendors.id
 LEFT JOIN npi_organizations AS orgs ON links.organization_npi_id = orgs.npi_id;
 
+CREATE or REPLACE VIEW endpoint_hosting AS
+SELECT hosting.url, hosting.hostname, ips->>'ip' AS ip_address, ips->>'family' AS ip_family,
+    (ips->>'asn')::INTEGER AS asn, ips->>'asOrganization' AS as_organization, ips->>'countryCode' AS country_code,
+    vendors.name AS vendor_name
+FROM fhir_endpoints_hosting AS hosting
+-- hosts that could not be resolved have null ip_addresses and are left out of the view
+CROSS JOIN LATERAL jsonb_array_elements(CASE WHEN jsonb_typeof(hosting.ip_addresses) = 'array' THEN hosting.ip_addresses ELSE '[]'::jsonb END) AS ips
+LEFT JOIN fhir_endpoints_info AS endpts_info ON hosting.url = endpts_info.url AND endpts_info.requested_fhir_version = 'None'
+LEFT JOIN vendors ON endpts_info.vendor_id = vendors.id;
+
+CREATE or REPLACE VIEW npi_organization_provider_types AS
+SELECT orgs.npi_id, orgs.name, taxonomies.code AS taxonomy, taxonomies.is_primary,
+    nucc.grouping_name, nucc.classification, nucc.specialization, nucc.display_name
+FROM npi_organizations AS orgs
+JOIN npi_organization_taxonomies AS taxonomies ON orgs.npi_id = taxonomies.npi_id
+LEFT JOIN nucc_taxonomies AS nucc ON taxonomies.code = nucc.code;
+
 CREATE INDEX fhir_endpoints_url_idx ON fhir_endpoints (url);
 CREATE INDEX fhir_endpoints_info_url_idx ON fhir_endpoints_info (url);
 CREATE INDEX fhir_endpoints_info_history_url_idx ON fhir_endpoints_info_history (url);
@@ -346,45 +777,61 @@ CREATE INDEX fhir_endpoints_info_history_vendor_id_idx ON fhir_endpoints_info_hi
 
 CREATE INDEX npi_organizations_npi_id_idx ON npi_organizations (npi_id);
 CREATE INDEX endpoint_organization_npi_id_idx ON endpoint_organization (organization_npi_id);
+CREATE INDEX endpoint_organization_reviews_status_idx ON endpoint_organization_reviews (status);
 
 CREATE INDEX vendor_name_idx ON vendors (name);
-CREATE INDEX implementation_guide_idx ON fhir_endpoints_info ((capability_statement->>'implementationGuide'));
+CREATE INDEX implementation_guide_idx ON fhir_endpoints_documents ((document->>'implementationGuide'));
 CREATE INDEX field_idx ON fhir_endpoints_info ((included_fields->> 'Field'));
 CREATE INDEX exists_idx ON fhir_endpoints_info ((included_fields->> 'Exists'));
 CREATE INDEX extension_idx ON fhir_endpoints_info ((included_fields->> 'Extension'));
 
-CREATE INDEX resource_type_idx ON fhir_endpoints_info (((capability_statement::json#>'{rest,0,resource}') ->> 'type'));
-
-CREATE INDEX capstat_url_idx ON fhir_endpoints_info ((capability_statement->>'url'));
-CREATE INDEX capstat_version_idx ON fhir_endpoints_info ((capability_statement->>'version'));
-CREATE INDEX capstat_name_idx ON fhir_endpoints_info ((capability_statement->>'name'));
-CREATE INDEX capstat_title_idx ON fhir_endpoints_info ((capability_statement->>'title'));
-CREATE INDEX capstat_date_idx ON fhir_endpoints_info ((capability_statement->>'date'));
-CREATE INDEX capstat_publisher_idx ON fhir_endpoints_info ((capability_statement->>'publisher'));
-CREATE INDEX capstat_description_idx ON fhir_endpoints_info ((capability_statement->>'description'));
-CREATE INDEX capstat_purpose_idx ON fhir_endpoints_info ((capability_statement->>'purpose'));
-CREATE INDEX capstat_copyright_idx ON fhir_endpoints_info ((capability_statement->>'copyright'));
-
-CREATE INDEX capstat_software_name_idx ON fhir_endpoints_info ((capability_statement->'software'->>'name'));
-CREATE INDEX capstat_software_version_idx ON fhir_endpoints_info ((capability_statement->'software'->>'version'));
-CREATE INDEX capstat_software_releaseDate_idx ON fhir_endpoints_info ((capability_statement->'software'->>'releaseDate'));
-CREATE INDEX capstat_implementation_description_idx ON fhir_endpoints_info ((capability_statement->'implementation'->>'description'));
-CREATE INDEX capstat_implementation_url_idx ON fhir_endpoints_info ((capability_statement->'implementation'->>'url'));
-CREATE INDEX capstat_implementation_custodian_idx ON fhir_endpoints_info ((capability_statement->'implementation'->>'custodian'));
+CREATE INDEX resource_type_idx ON fhir_endpoints_documents (((document::json#>'{rest,0,resource}') ->> 'type'));
+
+CREATE INDEX capstat_url_idx ON fhir_endpoints_documents ((document->>'url'));
+CREATE INDEX capstat_version_idx ON fhir_endpoints_documents ((document->>'version'));
+CREATE INDEX capstat_name_idx ON fhir_endpoints_documents ((document->>'name'));
+CREATE INDEX capstat_title_idx ON fhir_endpoints_documents ((document->>'title'));
+CREATE INDEX capstat_date_idx ON fhir_endpoints_documents ((document->>'date'));
+CREATE INDEX capstat_publisher_idx ON fhir_endpoints_documents ((document->>'publisher'));
+CREATE INDEX capstat_description_idx ON fhir_endpoints_documents ((document->>'description'));
+CREATE INDEX capstat_purpose_idx ON fhir_endpoints_documents ((document->>'purpose'));
+CREATE INDEX capstat_copyright_idx ON fhir_endpoints_documents ((document->>'copyright'));
+
+CREATE INDEX capstat_software_name_idx ON fhir_endpoints_documents ((document->'software'->>'name'));
+CREATE INDEX capstat_software_version_idx ON fhir_endpoints_documents ((document->'software'->>'version'));
+CREATE INDEX capstat_software_releaseDate_idx ON fhir_endpoints_documents ((document->'software'->>'releaseDate'));
+CREATE INDEX capstat_implementation_description_idx ON fhir_endpoints_documents ((document->'implementation'->>'description'));
+CREATE INDEX capstat_implementation_url_idx ON fhir_endpoints_documents ((document->'implementation'->>'url'));
+CREATE INDEX capstat_implementation_custodian_idx ON fhir_endpoints_documents ((document->'implementation'->>'custodian'));
 
 CREATE INDEX capability_fhir_version_idx ON fhir_endpoints_info (capability_fhir_version);
 CREATE INDEX requested_fhir_version_idx ON fhir_endpoints_info (requested_fhir_version);
 
-CREATE INDEX security_code_idx ON fhir_endpoints_info ((capability_statement::json#>'{rest,0,security,service}'->'coding'->>'code'));
-CREATE INDEX security_service_idx ON fhir_endpoints_info ((capability_statement::json#>'{rest,0,security}' -> 'service' ->> 'text'));
+CREATE INDEX security_code_idx ON fhir_endpoints_documents ((document::json#>'{rest,0,security,service}'->'coding'->>'code'));
+CREATE INDEX security_service_idx ON fhir_endpoints_documents ((document::json#>'{rest,0,security}' -> 'service' ->> 'text'));
 
-CREATE INDEX smart_capabilities_idx ON fhir_endpoints_info ((smart_response->'capabilities'));
+CREATE INDEX smart_capabilities_idx ON fhir_endpoints_documents ((document->'capabilities'));
 
 CREATE INDEX location_zipcode_idx ON npi_organizations ((location->>'zipcode'));
+CREATE INDEX npi_organization_taxonomies_code_idx ON npi_organization_taxonomies (code);
+
+CREATE INDEX fhir_endpoints_info_capability_statement_hash_idx ON fhir_endpoints_info (capability_statement_hash);
+CREATE INDEX fhir_endpoints_info_history_capability_statement_hash_idx ON fhir_endpoints_info_history (capability_statement_hash);
+CREATE INDEX fhir_endpoints_info_smart_response_hash_idx ON fhir_endpoints_info (smart_response_hash);
+CREATE INDEX fhir_endpoints_info_history_smart_response_hash_idx ON fhir_endpoints_info_history (smart_response_hash);
 
 CREATE INDEX info_metadata_id_idx ON fhir_endpoints_info (metadata_id);
 CREATE INDEX info_history_metadata_id_idx ON fhir_endpoints_info_history (metadata_id);
 CREATE INDEX metadata_id_idx ON fhir_endpoints_metadata (id);
 
 CREATE INDEX healthit_product_name_version_idx ON healthit_products (name, version);
-CREATE INDEX metadata_response_time_idx ON fhir_endpoints_metadata(response_time_seconds);
\ No newline at end of file
+CREATE INDEX metadata_response_time_idx ON fhir_endpoints_metadata(response_time_seconds);
+CREATE INDEX metadata_permanent_redirect_idx ON fhir_endpoints_metadata(permanent_redirect);
+CREATE INDEX metadata_error_category_idx ON fhir_endpoints_metadata(error_category);
+CREATE INDEX fhir_endpoints_hosting_history_url_idx ON fhir_endpoints_hosting_history (url);
+CREATE INDEX vendors_history_id_idx ON vendors_history (id, entered_at);
+CREATE INDEX healthit_products_history_id_idx ON healthit_products_history (id, entered_at);
+CREATE INDEX certification_criteria_history_id_idx ON certification_criteria_history (id, entered_at);
+
+-- the partitions for the current month and the next three months. the retention command creates later partitions.
+SELECT create_history_partitions(CURRENT_DATE, (CURRENT_DATE + INTERVAL '3 months')::DATE);
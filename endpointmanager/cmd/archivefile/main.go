@@ -1,11 +1,9 @@
 package main
 
 import (
+	"bufio"
 	"context"
-	"encoding/json"
-	"io/ioutil"
 	"os"
-	"time"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/archivefile"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/config"
@@ -19,24 +17,28 @@ func main() {
 	var dateStart string
 	var dateEnd string
 	var writeFile string
+	var resume bool
 
-	if len(os.Args) >= 3 {
+	if len(os.Args) >= 4 {
 		dateStart = os.Args[1]
 		dateEnd = os.Args[2]
 		writeFile = os.Args[3]
 	} else {
 		log.Fatalf("ERROR: Missing command-line arguments")
 	}
+	if len(os.Args) >= 5 {
+		if os.Args[4] != "resume" {
+			log.Fatalf("ERROR: Unknown argument %s, expected resume", os.Args[4])
+		}
+		resume = true
+	}
 
 	err := config.SetupConfig()
 	helpers.FailOnError("", err)
 
-	// Verify that given dates are in the correct format
-	layout := "2006-01-02"
-	_, err = time.Parse(layout, dateStart)
-	helpers.FailOnError("ERROR: Start date not in correct format", err)
-	_, err = time.Parse(layout, dateEnd)
-	helpers.FailOnError("ERROR: End date not in correct format", err)
+	// Verify that given dates are in the correct format and in order
+	dateRange, err := archivefile.ParseDateRange(dateStart, dateEnd)
+	helpers.FailOnError("ERROR: Invalid date range", err)
 
 	store, err := postgresql.NewStore(viper.GetString("dbhost"), viper.GetInt("dbport"), viper.GetString("dbuser"), viper.GetString("dbpassword"), viper.GetString("dbname"), viper.GetString("dbsslmode"))
 	helpers.FailOnError("", err)
@@ -47,14 +49,24 @@ func main() {
 	// Get worker environment variables
 	numWorkers := viper.GetInt("export_numworkers")
 	workerDur := viper.GetInt("export_duration")
-	entries, err := archivefile.CreateArchive(ctx, store, dateStart, dateEnd, numWorkers, workerDur)
-	helpers.FailOnError("", err)
 
-	// Format as JSON
-	finalFormatJSON, err := json.MarshalIndent(entries, "", "\t")
-	helpers.FailOnError("", err)
+	if resume {
+		err = archivefile.ResumeArchive(ctx, store, writeFile, dateRange, numWorkers, workerDur)
+		helpers.FailOnError("ERROR: Resuming the archive failed", err)
+		return
+	}
+
+	file, err := os.Create(writeFile)
+	helpers.FailOnError("ERROR: Creating the given file failed", err)
+	defer file.Close()
 
-	// Write to the given file
-	err = ioutil.WriteFile(writeFile, finalFormatJSON, 0644)
+	// Summaries are streamed to the file as they are made. If the archive fails part way, whatever was written
+	// is kept so that it can be resumed.
+	w := bufio.NewWriter(file)
+	err = archivefile.CreateArchive(ctx, store, w, dateRange, numWorkers, workerDur)
+	flushErr := w.Flush()
+	helpers.FailOnError("ERROR: Creating the archive failed", err)
+	helpers.FailOnError("ERROR: Writing to given file failed", flushErr)
+	err = file.Close()
 	helpers.FailOnError("ERROR: Writing to given file failed", err)
 }
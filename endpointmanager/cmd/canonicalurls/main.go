@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"sort"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/config"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
+	"github.com/spf13/viper"
+)
+
+func main() {
+	err := config.SetupConfig()
+	helpers.FailOnError("", err)
+
+	store, err := postgresql.NewStore(viper.GetString("dbhost"), viper.GetInt("dbport"), viper.GetString("dbuser"), viper.GetString("dbpassword"), viper.GetString("dbname"), viper.GetString("dbsslmode"))
+	helpers.FailOnError("", err)
+	ctx := context.Background()
+
+	proposals, err := store.GetCanonicalURLProposals(ctx)
+	helpers.FailOnError("", err)
+
+	var urls []string
+	for url := range proposals {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+
+	// Write each endpoint URL and its proposed canonical URL to stdout as CSV
+	w := csv.NewWriter(os.Stdout)
+	err = w.Write([]string{"url", "canonical_url"})
+	helpers.FailOnError("", err)
+	for _, url := range urls {
+		err = w.Write([]string{url, proposals[url]})
+		helpers.FailOnError("", err)
+	}
+	w.Flush()
+	helpers.FailOnError("", w.Error())
+}
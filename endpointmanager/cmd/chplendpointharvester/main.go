@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/chplquerier"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/config"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
+	"github.com/onc-healthit/lantern-back-end/lanternmq/pkg/accessqueue"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/streadway/amqp"
+)
+
+func main() {
+	err := config.SetupConfig()
+	helpers.FailOnError("Error setting up config", err)
+
+	capQName := viper.GetString("endptinfo_capquery_qname")
+	qUser := viper.GetString("quser")
+	qPassword := viper.GetString("qpassword")
+	qHost := viper.GetString("qhost")
+	qPort := viper.GetString("qport")
+
+	// setup specific queue info so we can test what's in the queue
+	s := fmt.Sprintf("amqp://%s:%s@%s:%s/", qUser, qPassword, qHost, qPort)
+	conn, err := amqp.Dial(s)
+	helpers.FailOnError("", err)
+
+	channel, err := conn.Channel()
+	helpers.FailOnError("", err)
+
+	count, err := accessqueue.QueueCount(capQName, channel)
+	helpers.FailOnError("", err)
+
+	if count != 0 {
+		log.Fatalf("There are %d messages in the queue. Queue must be empty to run the CHPL endpoint harvester.", count)
+	}
+
+	store, err := postgresql.NewStore(viper.GetString("dbhost"), viper.GetInt("dbport"), viper.GetString("dbuser"), viper.GetString("dbpassword"), viper.GetString("dbname"), viper.GetString("dbsslmode"))
+	helpers.FailOnError("", err)
+	defer store.Close()
+	log.Info("Successfully connected to DB!")
+
+	client := &http.Client{
+		Timeout: time.Second * 35,
+	}
+
+	// Read version file that is mounted to make user agent
+	version, err := ioutil.ReadFile("/etc/lantern/VERSION")
+	if err != nil {
+		log.Warnf("Cannot read VERSION file")
+	}
+	versionString := string(version)
+	versionNum := strings.Split(versionString, "=")
+	userAgent := "LANTERN/" + versionNum[0]
+
+	source, err := chplquerier.NewSourceFromConfig()
+	helpers.FailOnError("", err)
+	chplquerier.SetSource(source)
+
+	ctx := context.Background()
+	err = chplquerier.HarvestServiceBaseURLLists(ctx, store, client, userAgent)
+	helpers.FailOnError("", err)
+}
@@ -2,13 +2,17 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"encoding/csv"
 	"io/ioutil"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/chplquerier"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/config"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
 	log "github.com/sirupsen/logrus"
@@ -16,9 +20,24 @@ import (
 	"github.com/spf13/viper"
 )
 
+const usage = `usage:
+  main.go [full]    store all of the CHPL criteria, vendors and products
+  main.go modified  store only the vendors and products that CHPL modified since the previous sync
+  main.go report    write the changes made by the most recent sync to stdout`
+
 func main() {
 	var err error
 
+	mode := "full"
+	if len(os.Args) == 2 {
+		mode = os.Args[1]
+	} else if len(os.Args) > 2 {
+		log.Fatal(usage)
+	}
+	if mode != "full" && mode != "modified" && mode != "report" {
+		log.Fatal(usage)
+	}
+
 	err = config.SetupConfig()
 	helpers.FailOnError("", err)
 
@@ -28,6 +47,19 @@ func main() {
 	log.Info("Successfully connected!")
 
 	ctx := context.Background()
+
+	if mode == "report" {
+		sync, err := store.GetLastCompletedCHPLSync(ctx)
+		if err == sql.ErrNoRows {
+			log.Fatal("No CHPL sync has completed")
+		}
+		helpers.FailOnError("", err)
+		changes, err := store.GetCHPLChangesSince(ctx, sync.StartedAt)
+		helpers.FailOnError("", err)
+		writeChanges(changes)
+		return
+	}
+
 	client := &http.Client{
 		Timeout: time.Second * 35,
 	}
@@ -42,11 +74,26 @@ func main() {
 	userAgent := "LANTERN/" + versionNum[0]
 	log.Infof("user agent is %s", userAgent)
 
-	err = chplquerier.GetCHPLCriteria(ctx, store, client, userAgent)
-
+	source, err := chplquerier.NewSourceFromConfig()
 	helpers.FailOnError("", err)
-	err = chplquerier.GetCHPLVendors(ctx, store, client, userAgent)
+	chplquerier.SetSource(source)
+
+	changes, err := chplquerier.SyncCHPL(ctx, store, client, userAgent, mode == "modified")
 	helpers.FailOnError("", err)
-	err = chplquerier.GetCHPLProducts(ctx, store, client, userAgent)
+	for _, change := range changes {
+		log.Infof("CHPL %s %s (%s) %s: %q -> %q", change.Kind, change.Name, change.CHPLID, change.Change, change.Previous, change.Current)
+	}
+}
+
+// writeChanges writes each change to stdout as CSV
+func writeChanges(changes []endpointmanager.CHPLChange) {
+	w := csv.NewWriter(os.Stdout)
+	err := w.Write([]string{"changed_at", "kind", "chpl_id", "name", "change", "previous", "current"})
 	helpers.FailOnError("", err)
+	for _, change := range changes {
+		err = w.Write([]string{change.ChangedAt.Format(time.RFC3339), change.Kind, change.CHPLID, change.Name, change.Change, change.Previous, change.Current})
+		helpers.FailOnError("", err)
+	}
+	w.Flush()
+	helpers.FailOnError("", w.Error())
 }
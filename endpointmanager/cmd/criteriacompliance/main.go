@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"strconv"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/config"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const usage = `usage:
+  main.go endpoints [criteria]  write each endpoint's product and whether it is certified to the criteria
+  main.go products [criteria]   write the products certified to the criteria that have no live endpoints
+The criteria defaults to "` + endpointmanager.StandardizedAPICriteria + `".`
+
+func main() {
+	if len(os.Args) != 2 && len(os.Args) != 3 {
+		log.Fatal(usage)
+	}
+	report := os.Args[1]
+	if report != "endpoints" && report != "products" {
+		log.Fatal(usage)
+	}
+	criteria := endpointmanager.StandardizedAPICriteria
+	if len(os.Args) == 3 {
+		criteria = os.Args[2]
+	}
+
+	err := config.SetupConfig()
+	helpers.FailOnError("", err)
+
+	store, err := postgresql.NewStore(viper.GetString("dbhost"), viper.GetInt("dbport"), viper.GetString("dbuser"), viper.GetString("dbpassword"), viper.GetString("dbname"), viper.GetString("dbsslmode"))
+	helpers.FailOnError("", err)
+	defer store.Close()
+
+	ctx := context.Background()
+	w := csv.NewWriter(os.Stdout)
+
+	if report == "endpoints" {
+		compliances, err := store.GetEndpointCriteriaCompliance(ctx, criteria)
+		helpers.FailOnError("", err)
+		err = w.Write([]string{"url", "http_response", "product", "version", "chpl_id", "vendor", "criteria", "certified"})
+		helpers.FailOnError("", err)
+		uncertified := 0
+		for _, c := range compliances {
+			if !c.Certified {
+				uncertified++
+			}
+			err = w.Write([]string{c.URL, strconv.Itoa(c.HTTPResponse), c.ProductName, c.ProductVersion, c.CHPLID, c.VendorName, criteria, strconv.FormatBool(c.Certified)})
+			helpers.FailOnError("", err)
+		}
+		log.Infof("%d of %d endpoints have a product that is not certified to %s", uncertified, len(compliances), criteria)
+	} else {
+		hitps, err := store.GetCertifiedProductsWithoutLiveEndpoints(ctx, criteria)
+		helpers.FailOnError("", err)
+		err = w.Write([]string{"product", "version", "chpl_id", "certification_status", "certification_edition", "api_url", "criteria"})
+		helpers.FailOnError("", err)
+		for _, hitp := range hitps {
+			err = w.Write([]string{hitp.Name, hitp.Version, hitp.CHPLID, hitp.CertificationStatus, hitp.CertificationEdition, hitp.APIURL, criteria})
+			helpers.FailOnError("", err)
+		}
+		log.Infof("%d products certified to %s have no live endpoints", len(hitps), criteria)
+	}
+
+	w.Flush()
+	helpers.FailOnError("", w.Error())
+}
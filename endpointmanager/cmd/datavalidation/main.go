@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"math"
 
@@ -26,9 +27,7 @@ func main() {
 	// Divide query interval (in seconds) by an average of 1.5 seconds per request to get the maximum number of endpoints that can be queried within query interval
 	maxEndpoints := int(math.Floor(float64(queryInterval*60) / float64(1.5)))
 
-	var endpointTotal int
-	endpointCountQuery := "SELECT COUNT(*) from fhir_endpoints;"
-	err = store.DB.QueryRow(endpointCountQuery).Scan(&endpointTotal)
+	endpointTotal, err := store.GetFHIREndpointCount(context.Background())
 	helpers.FailOnError("", err)
 
 	if endpointTotal >= maxEndpoints {
@@ -20,8 +20,7 @@ func main() {
 	store, err := postgresql.NewStore(viper.GetString("dbhost"), viper.GetInt("dbport"), viper.GetString("dbuser"), viper.GetString("dbpassword"), viper.GetString("dbname"), viper.GetString("dbsslmode"))
 	helpers.FailOnError("Error creating store", err)
 	// Copy entire contents of endpoint_export view into a csv which will be written to /tmp
-	sql_query := "COPY (SELECT * FROM endpoint_export) TO '/tmp/export.csv' DELIMITER ',' CSV HEADER;"
-	_, err = store.DB.ExecContext(ctx, sql_query)
+	err = store.ExportEndpointsCSV(ctx, "/tmp/export.csv")
 	helpers.FailOnError("Error exporting csv", err)
 
 }
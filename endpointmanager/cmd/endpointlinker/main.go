@@ -26,7 +26,24 @@ func main() {
 	store, err := postgresql.NewStore(viper.GetString("dbhost"), viper.GetInt("dbport"), viper.GetString("dbuser"), viper.GetString("dbpassword"), viper.GetString("dbname"), viper.GetString("dbsslmode"))
 	helpers.FailOnError("Error creating store", err)
 
-	err = endpointlinker.LinkAllOrgsAndEndpoints(ctx, store, "/etc/lantern/resources/linkerMatchesAllowlist.json", "/etc/lantern/resources/linkerMatchesBlocklist.json", verbose)
+	matchConfig := endpointlinker.DefaultMatchConfig()
+	setFloat := func(key string, value *float64) {
+		if viper.IsSet(key) {
+			*value = viper.GetFloat64(key)
+		}
+	}
+	setFloat("linker_match_threshold", &matchConfig.Threshold)
+	setFloat("linker_name_weight", &matchConfig.NameWeight)
+	setFloat("linker_address_weight", &matchConfig.AddressWeight)
+	setFloat("linker_city_weight", &matchConfig.CityWeight)
+	setFloat("linker_state_weight", &matchConfig.StateWeight)
+	setFloat("linker_zipcode_weight", &matchConfig.ZipCodeWeight)
+	setFloat("linker_review_threshold", &matchConfig.ReviewThreshold)
+	if viper.IsSet("linker_provider_types") {
+		matchConfig.ProviderTypes = endpointlinker.ParseProviderTypes(viper.GetString("linker_provider_types"))
+	}
+
+	err = endpointlinker.LinkAllOrgsAndEndpoints(ctx, store, "/etc/lantern/resources/linkerMatchesAllowlist.json", "/etc/lantern/resources/linkerMatchesBlocklist.json", matchConfig, verbose)
 	helpers.FailOnError("Error linking all orgs and enpoints", err)
 
 }
@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"os"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/historypruning"
+	log "github.com/sirupsen/logrus"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/config"
 
@@ -13,7 +15,17 @@ import (
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
 )
 
+const usage = "usage: go run main.go [dry-run]"
+
 func main() {
+	dryRun := false
+	if len(os.Args) >= 2 {
+		if os.Args[1] != "dry-run" {
+			log.Fatal(usage)
+		}
+		dryRun = true
+	}
+
 	err := config.SetupConfig()
 	helpers.FailOnError("", err)
 
@@ -21,5 +33,12 @@ func main() {
 	store, err := postgresql.NewStore(viper.GetString("dbhost"), viper.GetInt("dbport"), viper.GetString("dbuser"), viper.GetString("dbpassword"), viper.GetString("dbname"), viper.GetString("dbsslmode"))
 	helpers.FailOnError("", err)
 
-	historypruning.PruneInfoHistory(ctx, store, false)
+	result, err := historypruning.PruneInfoHistory(ctx, store, false, dryRun)
+	helpers.FailOnError("", err)
+
+	if dryRun {
+		log.Infof("pruning the history of %d URLs would remove %d entries and %d validation results", result.URLs, result.RemovedEntries, result.RemovedValidationResults)
+	} else {
+		log.Infof("pruned the history of %d URLs, removing %d entries and %d validation results", result.URLs, result.RemovedEntries, result.RemovedValidationResults)
+	}
 }
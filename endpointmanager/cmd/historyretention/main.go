@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/config"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/historyretention"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const usage = "usage: go run main.go [dry-run]"
+
+func main() {
+	dryRun := false
+	if len(os.Args) >= 2 {
+		if os.Args[1] != "dry-run" {
+			log.Fatal(usage)
+		}
+		dryRun = true
+	}
+
+	err := config.SetupConfig()
+	helpers.FailOnError("", err)
+
+	policy, err := historyretention.PolicyFromConfig()
+	helpers.FailOnError("", err)
+
+	store, err := postgresql.NewStore(viper.GetString("dbhost"), viper.GetInt("dbport"), viper.GetString("dbuser"), viper.GetString("dbpassword"), viper.GetString("dbname"), viper.GetString("dbsslmode"))
+	helpers.FailOnError("", err)
+	ctx := context.Background()
+
+	err = historyretention.ApplyRetention(ctx, store, policy, time.Now(), dryRun)
+	helpers.FailOnError("", err)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"net"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/config"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/hostingenricher"
+	"github.com/spf13/viper"
+)
+
+func main() {
+	err := config.SetupConfig()
+	helpers.FailOnError("", err)
+
+	asnDB, err := hostingenricher.LoadASNDatabaseFromConfig()
+	helpers.FailOnError("", err)
+
+	ctx := context.Background()
+	store, err := postgresql.NewStore(viper.GetString("dbhost"), viper.GetInt("dbport"), viper.GetString("dbuser"), viper.GetString("dbpassword"), viper.GetString("dbname"), viper.GetString("dbsslmode"))
+	helpers.FailOnError("", err)
+	defer store.Close()
+
+	err = hostingenricher.EnrichEndpoints(ctx, store, net.DefaultResolver, asnDB)
+	helpers.FailOnError("", err)
+}
@@ -14,12 +14,19 @@ import (
 
 func main() {
 	var exportFile string
+	exportType := "endpoints"
 
-	if len(os.Args) >= 1 {
+	if len(os.Args) >= 2 {
 		exportFile = os.Args[1]
 	} else {
 		log.Fatalf("ERROR: Missing export file name command-line argument")
 	}
+	if len(os.Args) >= 3 {
+		exportType = os.Args[2]
+	}
+	if exportType != "endpoints" && exportType != "organizations" {
+		log.Fatalf("ERROR: The export type must be 'endpoints' or 'organizations'")
+	}
 
 	err := config.SetupConfig()
 	helpers.FailOnError("", err)
@@ -29,6 +36,10 @@ func main() {
 	ctx := context.Background()
 	log.Info("Successfully connected to DB!")
 
-	err = jsonexport.CreateJSONExport(ctx, store, exportFile)
+	if exportType == "organizations" {
+		err = jsonexport.CreateOrganizationJSONExport(ctx, store, exportFile)
+	} else {
+		err = jsonexport.CreateJSONExport(ctx, store, exportFile)
+	}
 	helpers.FailOnError("", err)
 }
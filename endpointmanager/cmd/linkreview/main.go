@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/config"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointlinker"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const usage = `usage:
+  main.go list [pending|accepted|rejected]
+  main.go accept <endpoint url> <organization npi id> <reviewer>
+  main.go reject <endpoint url> <organization npi id> <reviewer>`
+
+func main() {
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	err := config.SetupConfig()
+	helpers.FailOnError("Error setting up config", err)
+	ctx := context.Background()
+
+	store, err := postgresql.NewStore(viper.GetString("dbhost"), viper.GetInt("dbport"), viper.GetString("dbuser"), viper.GetString("dbpassword"), viper.GetString("dbname"), viper.GetString("dbsslmode"))
+	helpers.FailOnError("Error creating store", err)
+
+	switch os.Args[1] {
+	case "list":
+		status := endpointmanager.ReviewPending
+		if len(os.Args) > 2 {
+			status = os.Args[2]
+		}
+		err = listReviews(ctx, store, status)
+		helpers.FailOnError("Error listing link reviews", err)
+	case "accept", "reject":
+		if len(os.Args) != 5 {
+			log.Fatal(usage)
+		}
+		status := endpointmanager.ReviewAccepted
+		if os.Args[1] == "reject" {
+			status = endpointmanager.ReviewRejected
+		}
+		err = endpointlinker.DecideLinkReview(ctx, store, os.Args[2], os.Args[3], status, os.Args[4])
+		helpers.FailOnError("Error deciding link review", err)
+		log.Infof("Link between %s and %s %s by %s", os.Args[2], os.Args[3], status, os.Args[4])
+	default:
+		log.Fatal(usage)
+	}
+}
+
+// listReviews writes the reviews with the given status and their score breakdowns to stdout as CSV.
+func listReviews(ctx context.Context, store *postgresql.Store, status string) error {
+	reviews, err := store.GetEndpointOrganizationReviews(ctx, status)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(os.Stdout)
+	err = w.Write([]string{"url", "organization_npi_id", "confidence", "status", "id_match", "name_score", "endpoint_name",
+		"organization_name", "shared_tokens", "location_scores", "decided_by", "decided_at"})
+	if err != nil {
+		return err
+	}
+	for _, review := range reviews {
+		locationScores, err := json.Marshal(review.ScoreBreakdown.LocationScores)
+		if err != nil {
+			return err
+		}
+		decidedAt := ""
+		if !review.DecidedAt.IsZero() {
+			decidedAt = review.DecidedAt.Format(time.RFC3339)
+		}
+		err = w.Write([]string{
+			review.URL,
+			review.OrganizationNPIID,
+			fmt.Sprintf("%.3f", review.Confidence),
+			review.Status,
+			fmt.Sprintf("%t", review.ScoreBreakdown.IDMatch),
+			fmt.Sprintf("%.3f", review.ScoreBreakdown.NameScore),
+			review.ScoreBreakdown.EndpointName,
+			review.ScoreBreakdown.OrganizationName,
+			strings.Join(review.ScoreBreakdown.SharedTokens, " "),
+			string(locationScores),
+			review.DecidedBy,
+			decidedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -15,6 +15,11 @@ import (
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/nppesquerier"
 )
 
+const usage = `usage:
+  main.go <nppes org csv file>
+  main.go incremental <weekly nppes org csv file>
+  main.go deactivations <nppes deactivation csv file>`
+
 func main() {
 	err := config.SetupConfig()
 	helpers.FailOnError("", err)
@@ -22,12 +27,30 @@ func main() {
 	ctx := context.Background()
 	store, err := postgresql.NewStore(viper.GetString("dbhost"), viper.GetInt("dbport"), viper.GetString("dbuser"), viper.GetString("dbpassword"), viper.GetString("dbname"), viper.GetString("dbsslmode"))
 	helpers.FailOnError("", err)
-	if len(os.Args) != 2 {
-		log.Fatal("NPPES csv file not provided as argument.")
+
+	var report nppesquerier.NPILoadReport
+	switch len(os.Args) {
+	case 2:
+		fname := os.Args[1]
+		err = store.DeleteAllNPIOrganizations(ctx)
+		helpers.FailOnError("", err)
+		report, err = nppesquerier.LoadNPIFile(ctx, fname, store)
+		helpers.FailOnError("", err)
+	case 3:
+		fname := os.Args[2]
+		switch os.Args[1] {
+		case "incremental":
+			report, err = nppesquerier.LoadNPIFile(ctx, fname, store)
+		case "deactivations":
+			report, err = nppesquerier.LoadNPIDeactivationFile(ctx, fname, store)
+		default:
+			log.Fatal(usage)
+		}
+		helpers.FailOnError("", err)
+	default:
+		log.Fatal("NPPES csv file not provided as argument.\n" + usage)
 	}
-	fname := os.Args[1]
-	err = store.DeleteAllNPIOrganizations(ctx)
-	helpers.FailOnError("", err)
-	_, err = nppesquerier.ParseAndStoreNPIFile(ctx, fname, store)
-	helpers.FailOnError("", err)
+
+	log.Infof("NPI organizations added: %d, updated: %d, deactivated: %d, reactivated: %d, rejected: %d",
+		report.Added, report.Updated, report.Deactivated, report.Reactivated, report.Rejected)
 }
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/config"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/nppesquerier"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const usage = `usage:
+  main.go          write the reconciliation report to stdout
+  main.go import   also add the NPPES endpoints that are missing from the endpoint lists`
+
+func main() {
+	importMissing := false
+	if len(os.Args) == 2 && os.Args[1] == "import" {
+		importMissing = true
+	} else if len(os.Args) != 1 {
+		log.Fatal(usage)
+	}
+
+	err := config.SetupConfig()
+	helpers.FailOnError("", err)
+
+	store, err := postgresql.NewStore(viper.GetString("dbhost"), viper.GetInt("dbport"), viper.GetString("dbuser"), viper.GetString("dbpassword"), viper.GetString("dbname"), viper.GetString("dbsslmode"))
+	helpers.FailOnError("", err)
+	ctx := context.Background()
+
+	reconciliation, imported, err := nppesquerier.ReconcileNPIContactsWithStore(ctx, store, importMissing)
+	helpers.FailOnError("Error reconciling NPPES contacts", err)
+	if importMissing {
+		log.Infof("Imported %d NPPES endpoints", imported)
+	}
+
+	err = reconciliation.WriteCSV(os.Stdout)
+	helpers.FailOnError("Error writing reconciliation report", err)
+}
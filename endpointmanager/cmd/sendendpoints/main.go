@@ -2,11 +2,13 @@ package main
 
 import (
 	"context"
+	"net"
 	"sync"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/config"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/hostingenricher"
 	se "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/sendendpoints"
 	"github.com/onc-healthit/lantern-back-end/lanternmq/pkg/accessqueue"
 	log "github.com/sirupsen/logrus"
@@ -36,6 +38,15 @@ func main() {
 	capInterval := viper.GetInt("capquery_qryintvl")
 	go se.GetEnptsAndSend(ctx, &wg, capQName, capInterval, store, &mq, &channelID, errs)
 
+	// Hosting enrichment loop, so that changes in where the endpoints are hosted are recorded
+	hostingInterval := viper.GetInt("hosting_intvl")
+	if hostingInterval > 0 {
+		asnDB, err := hostingenricher.LoadASNDatabaseFromConfig()
+		helpers.FailOnError("", err)
+		wg.Add(1)
+		go hostingenricher.EnrichEndpointsPeriodically(ctx, &wg, store, net.DefaultResolver, asnDB, hostingInterval, errs)
+	}
+
 	for elem := range errs {
 		log.Warn(elem)
 	}
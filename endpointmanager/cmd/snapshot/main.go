@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/config"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/jsonexport"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const usage = "usage: go run main.go <date (YYYY-MM-DD) or time (RFC 3339)> [export JSON file name]"
+
+// parseAsOf parses the time to take the snapshot at. A date is taken as the end of that day in UTC.
+func parseAsOf(value string) (time.Time, error) {
+	day, err := time.Parse("2006-01-02", value)
+	if err == nil {
+		return day.Add(24*time.Hour - time.Nanosecond), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func main() {
+	var exportFile string
+
+	if len(os.Args) < 2 || len(os.Args) > 3 {
+		log.Fatal(usage)
+	}
+	asOf, err := parseAsOf(os.Args[1])
+	helpers.FailOnError("ERROR: The time is not a date or an RFC 3339 time", err)
+	if len(os.Args) == 3 {
+		exportFile = os.Args[2]
+	}
+
+	err = config.SetupConfig()
+	helpers.FailOnError("", err)
+
+	store, err := postgresql.NewStore(viper.GetString("dbhost"), viper.GetInt("dbport"), viper.GetString("dbuser"), viper.GetString("dbpassword"), viper.GetString("dbname"), viper.GetString("dbsslmode"))
+	helpers.FailOnError("", err)
+	ctx := context.Background()
+
+	if exportFile != "" {
+		err = jsonexport.CreateJSONExportAsOf(ctx, store, asOf, exportFile)
+		helpers.FailOnError("", err)
+		return
+	}
+
+	snapshots, err := store.GetEndpointSnapshots(ctx, asOf)
+	helpers.FailOnError("", err)
+
+	// Write the state of each endpoint and requested FHIR version to stdout as CSV
+	w := csv.NewWriter(os.Stdout)
+	err = w.Write([]string{"url", "requested_fhir_version", "capability_fhir_version", "info_updated", "tls_version",
+		"http_response", "response_time_seconds", "smart_http_response", "errors", "vendor_name"})
+	helpers.FailOnError("", err)
+	for _, snapshot := range snapshots {
+		info := snapshot.Info
+		record := []string{info.URL, info.RequestedFhirVersion, info.CapabilityFhirVersion,
+			info.UpdatedAt.Format(time.RFC3339), info.TLSVersion, "", "", "", "", ""}
+		if info.Metadata != nil {
+			record[5] = strconv.Itoa(info.Metadata.HTTPResponse)
+			record[6] = strconv.FormatFloat(info.Metadata.ResponseTime, 'f', -1, 64)
+			record[7] = strconv.Itoa(info.Metadata.SMARTHTTPResponse)
+			record[8] = info.Metadata.Errors
+		}
+		if snapshot.Vendor != nil {
+			record[9] = snapshot.Vendor.Name
+		}
+		err = w.Write(record)
+		helpers.FailOnError("", err)
+	}
+	w.Flush()
+	helpers.FailOnError("", w.Error())
+}
@@ -2,13 +2,15 @@ package archivefile
 
 import (
 	"context"
-	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"math"
+	"os"
 	"sort"
 	"time"
 
-	"github.com/lib/pq"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/workers"
@@ -33,6 +35,7 @@ type totalSummary struct {
 	HTTPResponse         []httpResponse         `json:"http_response"`
 	SmartHTTPResponse    []smartHTTPResponse    `json:"smart_http_response"`
 	Errors               []responseErrors       `json:"errors"`
+	ErrorCategories      []errorCategories      `json:"error_categories"`
 }
 
 // formats for specific fields in the above totalSummary struct
@@ -52,6 +55,56 @@ type responseErrors struct {
 	Error      string `json:"error"`
 	ErrorCount int    `json:"error_count"`
 }
+type errorCategories struct {
+	ErrorCategory string `json:"error_category"`
+	ErrorCount    int    `json:"error_category_count"`
+}
+
+// Store is the part of the database that the archive is summarized from.
+type Store interface {
+	GetArchiveEndpoints(ctx context.Context) ([]*postgresql.ArchiveEndpoint, error)
+	GetArchiveInfoHistory(ctx context.Context, url string, requestedFhirVersion string, start time.Time, end time.Time) ([]*postgresql.ArchiveInfoHistoryEntry, error)
+	GetArchiveMetadata(ctx context.Context, url string, requestedFhirVersion string, start time.Time, end time.Time) ([]*postgresql.ArchiveMetadataEntry, error)
+}
+
+// dateLayout is the format of the dates of a DateRange, such as '2021-01-31'
+const dateLayout = "2006-01-02"
+
+// progressInterval is the number of summaries written between each progress report
+const progressInterval = 100
+
+// jobsPerWorker is the number of summaries per worker that can be in progress or waiting to be written at once. It
+// bounds the summaries held back while the summaries ahead of them in the archive are still being made.
+const jobsPerWorker = 4
+
+// DateRange is the range of days that an archive summarizes, from the start of the Start day to the end of the
+// End day in UTC.
+type DateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// ParseDateRange parses the start and end dates of a DateRange, which are formatted as '2021-01-31'. The start
+// date must not be after the end date.
+func ParseDateRange(start string, end string) (DateRange, error) {
+	startDate, err := time.Parse(dateLayout, start)
+	if err != nil {
+		return DateRange{}, fmt.Errorf("the start date %s is not in the format YYYY-MM-DD: %s", start, err)
+	}
+	endDate, err := time.Parse(dateLayout, end)
+	if err != nil {
+		return DateRange{}, fmt.Errorf("the end date %s is not in the format YYYY-MM-DD: %s", end, err)
+	}
+	if startDate.After(endDate) {
+		return DateRange{}, fmt.Errorf("the start date %s is after the end date %s", start, end)
+	}
+	return DateRange{Start: startDate, End: endDate}, nil
+}
+
+// until returns the start of the day after the range, the first time that is not in it
+func (r DateRange) until() time.Time {
+	return r.End.AddDate(0, 0, 1)
+}
 
 // Result is the value that is returned from getting the history data from the
 // given URL
@@ -61,14 +114,14 @@ type Result struct {
 	Summary              totalSummary
 }
 
-// historyArgs is the format for the data passed to getHistory from a worker
+// historyArgs is the format for the data passed to getSummary from a worker
 type historyArgs struct {
 	fhirURL              string
 	requestedFhirVersion string
-	dateStart            string
-	dateEnd              string
-	store                *postgresql.Store
+	dateRange            DateRange
+	store                Store
 	result               chan Result
+	done                 <-chan struct{} // closed when the archive stops receiving results
 }
 
 // historyEntry is the format of the data received from the history table for the given URL
@@ -81,12 +134,7 @@ type historyEntry struct {
 	TLSVersion           string
 	MIMETypes            []string
 	RequestedFhirVersion string
-}
-
-// vendorEntry is the format of the data received from the vendor table for the given URL
-type vendorEntry struct {
-	URL        string
-	VendorName string
+	VendorName           string
 }
 
 // metadataEntry is the format of the data received from the fhir_endpoints_metadata for the
@@ -97,176 +145,328 @@ type metadataEntry struct {
 	HTTPResponse         int
 	SMARTHTTPResponse    int
 	Errors               string
+	ErrorCategory        string
 	RequestedFhirVersion string
 }
 
-// CreateArchive gets all data from fhir_endpoints, fhir_endpoints_info and vendors between
-// the given start and end date and summarizes the data
+// archiveKey identifies the summary of a URL and requested version. The archive is ordered by it.
+type archiveKey struct {
+	url                  string
+	requestedFhirVersion string
+}
+
+func summaryKey(summary totalSummary) archiveKey {
+	return archiveKey{url: summary.URL, requestedFhirVersion: summary.RequestedFhirVersion}
+}
+
+func (k archiveKey) less(k2 archiveKey) bool {
+	if k.url != k2.url {
+		return k.url < k2.url
+	}
+	return k.requestedFhirVersion < k2.requestedFhirVersion
+}
+
+// archiveWriter writes summaries to w one at a time as the elements of a JSON array, formatted in the same way as
+// json.MarshalIndent(summaries, "", "\t")
+type archiveWriter struct {
+	w       io.Writer
+	written int // the number of summaries in the array, including any written before the archive was resumed
+}
+
+func (a *archiveWriter) open() error {
+	_, err := io.WriteString(a.w, "[")
+	return err
+}
+
+func (a *archiveWriter) write(summary totalSummary) error {
+	summaryJSON, err := json.MarshalIndent(summary, "\t", "\t")
+	if err != nil {
+		return err
+	}
+	separator := ",\n\t"
+	if a.written == 0 {
+		separator = "\n\t"
+	}
+	_, err = io.WriteString(a.w, separator)
+	if err != nil {
+		return err
+	}
+	_, err = a.w.Write(summaryJSON)
+	if err != nil {
+		return err
+	}
+	a.written++
+	return nil
+}
+
+func (a *archiveWriter) close() error {
+	end := "\n]"
+	if a.written == 0 {
+		end = "]"
+	}
+	_, err := io.WriteString(a.w, end)
+	return err
+}
+
+// partialArchive is the part of an archive that was written before it was interrupted
+type partialArchive struct {
+	written  int         // the number of complete summaries
+	last     *archiveKey // the key of the last complete summary, nil if there are none
+	length   int64       // the length of the archive up to the end of the last complete summary
+	complete bool        // whether the archive was finished
+}
+
+// CreateArchive summarizes the data from fhir_endpoints, fhir_endpoints_info_history, fhir_endpoints_metadata and
+// vendors in the date range for each endpoint and requested FHIR version, and writes the summaries to w as a JSON
+// array ordered by URL and requested FHIR version. Each summary is written as soon as the ones before it have been,
+// so the archive is never held in memory.
 func CreateArchive(ctx context.Context,
-	store *postgresql.Store,
-	dateStart string,
-	dateEnd string,
+	store Store,
+	w io.Writer,
+	dateRange DateRange,
 	numWorkers int,
-	workerDur int) ([]totalSummary, error) {
-	// Get the fhir_endpoints specific information
-	sqlQuery := "SELECT DISTINCT e.url, h.requested_fhir_version, e.organization_names, e.created_at, e.list_source FROM fhir_endpoints e LEFT JOIN fhir_endpoints_info_history h ON e.url = h.url;"
-	rows, err := store.DB.QueryContext(ctx, sqlQuery)
+	workerDur int) error {
+	archive := &archiveWriter{w: w}
+	err := archive.open()
 	if err != nil {
-		return nil, fmt.Errorf("ERROR getting data from fhir_endpoints: %s", err)
-	}
-
-	totalEntries := 0
-	urls_fhir_version := make(map[string][]string)
-	allData := make(map[string]map[string]totalSummary)
-	defer rows.Close()
-	for rows.Next() {
-		var entry totalSummary
-		var listSource string
-		var requestedFhirVersion sql.NullString
-		err = rows.Scan(
-			&entry.URL,
-			&requestedFhirVersion,
-			pq.Array(&entry.OrganizationNames),
-			&entry.CreatedAt,
-			&listSource)
+		return err
+	}
+	err = writeSummaries(ctx, store, archive, dateRange, numWorkers, workerDur, nil)
+	if err != nil {
+		return err
+	}
+	return archive.close()
+}
+
+// ResumeArchive continues the archive in the file at the given path that CreateArchive or ResumeArchive was
+// interrupted while writing, using the same date range that the archive was started with. Anything after the last
+// complete summary in the file is removed and the archive continues with the URL and requested version after it.
+// A missing or empty file is started from the beginning, and an archive that was finished is left as it is.
+func ResumeArchive(ctx context.Context,
+	store Store,
+	path string,
+	dateRange DateRange,
+	numWorkers int,
+	workerDur int) error {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	partial, err := readPartialArchive(file, info.Size())
+	if err != nil {
+		return fmt.Errorf("the archive %s cannot be resumed: %s", path, err)
+	}
+	if partial.complete {
+		log.Infof("The archive %s is already complete with %d summaries", path, partial.written)
+		return nil
+	}
+
+	err = file.Truncate(partial.length)
+	if err != nil {
+		return err
+	}
+	_, err = file.Seek(partial.length, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	archive := &archiveWriter{w: file, written: partial.written}
+	if partial.length == 0 {
+		err = archive.open()
 		if err != nil {
-			return nil, fmt.Errorf("ERROR getting row from fhir_endpoints: %s", err)
+			return err
 		}
+	}
+	if partial.last != nil {
+		log.Infof("Resuming the archive %s after %d summaries, the last of which is for %s with requested version %s",
+			path, partial.written, partial.last.url, partial.last.requestedFhirVersion)
+	}
+	err = writeSummaries(ctx, store, archive, dateRange, numWorkers, workerDur, partial.last)
+	if err != nil {
+		return err
+	}
+	err = archive.close()
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
 
-		if requestedFhirVersion.Valid {
-			entry.RequestedFhirVersion = requestedFhirVersion.String
-		} else {
-			entry.RequestedFhirVersion = "None"
-		}
+// readPartialArchive reads the complete summaries at the start of an archive of the given size
+func readPartialArchive(r io.Reader, size int64) (partialArchive, error) {
+	var partial partialArchive
+	dec := json.NewDecoder(r)
 
-		if _, ok := allData[entry.URL]; !ok {
-			allData[entry.URL] = make(map[string]totalSummary)
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return partial, nil
+	} else if err != nil {
+		return partial, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return partial, errors.New("it is not a JSON array")
+	}
+	partial.length = dec.InputOffset()
+
+	for dec.More() {
+		var summary totalSummary
+		err = dec.Decode(&summary)
+		var syntaxErr *json.SyntaxError
+		if err == io.ErrUnexpectedEOF || (errors.As(err, &syntaxErr) && syntaxErr.Offset >= size) {
+			// the archive ends before the next summary was completely written
+			return partial, nil
+		} else if err != nil {
+			return partial, err
 		}
+		key := summaryKey(summary)
+		partial.written++
+		partial.last = &key
+		partial.length = dec.InputOffset()
+	}
+
+	tok, err = dec.Token()
+	partial.complete = err == nil && tok == json.Delim(']')
+	return partial, nil
+}
+
+// archiveSummaries creates the summary of each URL and requested version from the endpoints, ordered by URL and
+// requested version. The summaries only include the fhir_endpoints data.
+func archiveSummaries(endpoints []*postgresql.ArchiveEndpoint) []totalSummary {
+	var summaries []totalSummary
+	summaryIndex := make(map[archiveKey]int)
+	for _, endpoint := range endpoints {
+		key := archiveKey{url: endpoint.URL, requestedFhirVersion: endpoint.RequestedFhirVersion}
 
 		// If the URL already exists, include the new list source and organization names
-		if val, ok := allData[entry.URL][entry.RequestedFhirVersion]; ok {
-			val.ListSource = append(val.ListSource, listSource)
-			val.OrganizationNames = append(val.OrganizationNames, entry.OrganizationNames...)
-			allData[entry.URL][entry.RequestedFhirVersion] = val
-			totalEntries++
-		} else {
-			entry.ListSource = []string{listSource}
-			allData[entry.URL][entry.RequestedFhirVersion] = entry
-			urls_fhir_version[entry.URL] = append(urls_fhir_version[entry.URL], entry.RequestedFhirVersion)
-			totalEntries++
+		if i, ok := summaryIndex[key]; ok {
+			summaries[i].ListSource = append(summaries[i].ListSource, endpoint.ListSource)
+			summaries[i].OrganizationNames = append(summaries[i].OrganizationNames, endpoint.OrganizationNames...)
+			continue
 		}
+		summaryIndex[key] = len(summaries)
+		summaries = append(summaries, totalSummary{
+			URL:                  endpoint.URL,
+			RequestedFhirVersion: endpoint.RequestedFhirVersion,
+			OrganizationNames:    endpoint.OrganizationNames,
+			CreatedAt:            endpoint.CreatedAt,
+			ListSource:           []string{endpoint.ListSource},
+		})
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaryKey(summaries[i]).less(summaryKey(summaries[j]))
+	})
+	return summaries
+}
+
+// writeSummaries summarizes each URL and requested version after the given key, or all of them if it is nil,
+// using workers and writes the summaries in order as they are completed
+func writeSummaries(ctx context.Context,
+	store Store,
+	archive *archiveWriter,
+	dateRange DateRange,
+	numWorkers int,
+	workerDur int,
+	after *archiveKey) error {
+	// Get the fhir_endpoints specific information
+	endpoints, err := store.GetArchiveEndpoints(ctx)
+	if err != nil {
+		return fmt.Errorf("ERROR getting data from fhir_endpoints: %s", err)
+	}
+	summaries := archiveSummaries(endpoints)
+	total := len(summaries)
+	if after != nil {
+		first := sort.Search(len(summaries), func(i int) bool {
+			return after.less(summaryKey(summaries[i]))
+		})
+		summaries = summaries[first:]
+	}
+	done := total - len(summaries)
+	if len(summaries) == 0 {
+		log.Infof("Archived %d of %d endpoints", done, total)
+		return nil
 	}
 
+	// stop the workers and any jobs waiting to send their results if the archive is not completed
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	// Start workers
 	errs := make(chan error)
 	allWorkers := workers.NewWorkers()
 	err = allWorkers.Start(ctx, numWorkers, errs)
 	if err != nil {
-		return nil, fmt.Errorf("Error from starting workers. Error: %s", err)
+		return fmt.Errorf("Error from starting workers. Error: %s", err)
 	}
 
-	// Get history data using workers
+	// Get history data using workers. A job is only added once there is a slot for it, and a slot is freed when a
+	// summary is written, so that only a window of summaries past the next one to write is held in memory.
 	resultCh := make(chan Result)
-	go createJobs(ctx, resultCh, urls_fhir_version, dateStart, dateEnd, "history", workerDur, store, allWorkers)
-
-	// Add the results from createJobs to allData
-	count := 0
-	for res := range resultCh {
-		u, ok := allData[res.URL][res.RequestedFhirVersion]
-		if !ok {
-			return nil, fmt.Errorf("The URL %s does not exist in the fhir_endpoints tables", res.URL)
-		}
-		u.NumberOfUpdates = res.Summary.NumberOfUpdates
-		u.Updated = res.Summary.Updated
-		u.Operation = res.Summary.Operation
-		u.FHIRVersion = res.Summary.FHIRVersion
-		u.TLSVersion = res.Summary.TLSVersion
-		u.MIMETypes = res.Summary.MIMETypes
-		allData[res.URL][res.RequestedFhirVersion] = u
-		if count >= totalEntries-1 {
-			close(resultCh)
-		}
-		count++
-	}
+	slots := make(chan struct{}, numWorkers*jobsPerWorker)
+	go createJobs(ctx, resultCh, slots, summaries, dateRange, workerDur, store, allWorkers)
 
-	// Get vendor information separately so the endpoints that don't have vendor information aren't
-	// removed from the other history request
-	vendorQuery := `SELECT f.url, v.name FROM fhir_endpoints_info_history f, vendors v
-		WHERE f.updated_at between '` + dateStart + `' AND '` + dateEnd + `' AND f.vendor_id = v.id ORDER BY f.updated_at`
-	vendorRows, err := store.DB.QueryContext(ctx, vendorQuery)
-	if err != nil {
-		return nil, fmt.Errorf("ERROR getting data from fhir_endpoints_info_history and vendors: %s", err)
+	summaryIndex := make(map[archiveKey]int, len(summaries))
+	for i, summary := range summaries {
+		summaryIndex[summaryKey(summary)] = i
 	}
 
-	vendorResults := make(map[string][]vendorEntry)
-	defer vendorRows.Close()
-	for vendorRows.Next() {
-		var v vendorEntry
-		err = vendorRows.Scan(
-			&v.URL,
-			&v.VendorName)
-		if err != nil {
-			return nil, fmt.Errorf("Error while scanning the rows of the history and vendor table. Error: %s", err)
+	// The results arrive in the order the workers finish them, so the ones that arrive before the results ahead of
+	// them in the archive are held until those have been written
+	completed := make(map[int]totalSummary)
+	next := 0
+	for count := 0; count < len(summaries); count++ {
+		var res Result
+		select {
+		case res = <-resultCh:
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 
-		if val, ok := vendorResults[v.URL]; ok {
-			vendorResults[v.URL] = append(val, v)
-		} else {
-			vendorResults[v.URL] = []vendorEntry{v}
+		i, ok := summaryIndex[archiveKey{url: res.URL, requestedFhirVersion: res.RequestedFhirVersion}]
+		if !ok {
+			return fmt.Errorf("The URL %s does not exist in the fhir_endpoints tables", res.URL)
 		}
-	}
+		completed[i] = addResult(summaries[i], res.Summary)
 
-	for url, requested_versions := range urls_fhir_version {
-		for index := range requested_versions {
-			req_version := requested_versions[index]
-			u, ok := allData[url][req_version]
-			if !ok {
-				return nil, fmt.Errorf("The URL %s does not exist in the fhir_endpoints tables", url)
+		for summary, ok := completed[next]; ok; summary, ok = completed[next] {
+			err = archive.write(summary)
+			if err != nil {
+				return fmt.Errorf("ERROR writing the summary of %s to the archive: %s", summary.URL, err)
 			}
-			u.Vendor = makeDefaultMap()
-			if vResult, ok := vendorResults[url]; ok {
-				startElem := vResult[0]
-				endElem := vResult[len(vResult)-1]
-
-				u.Vendor["first"] = startElem.VendorName
-				if startElem.VendorName != endElem.VendorName {
-					u.Vendor["last"] = endElem.VendorName
-				}
+			delete(completed, next)
+			<-slots
+			next++
+			done++
+			if done%progressInterval == 0 || done == total {
+				log.Infof("Archived %d of %d endpoints", done, total)
 			}
-			allData[url][req_version] = u
-		}
-	}
-
-	// Get history data using workers
-	metaResultCh := make(chan Result)
-	go createJobs(ctx, metaResultCh, urls_fhir_version, dateStart, dateEnd, "metadata", workerDur, store, allWorkers)
-
-	// Add the results from metadata to allData
-	count = 0
-	for res := range metaResultCh {
-		u, ok := allData[res.URL][res.RequestedFhirVersion]
-		if !ok {
-			return nil, fmt.Errorf("The URL %s does not exist in the fhir_endpoints tables", res.URL)
-		}
-		u.ResponseTimeSecond = res.Summary.ResponseTimeSecond
-		u.HTTPResponse = res.Summary.HTTPResponse
-		u.SmartHTTPResponse = res.Summary.SmartHTTPResponse
-		u.Errors = res.Summary.Errors
-		allData[res.URL][res.RequestedFhirVersion] = u
-		if count == totalEntries-1 {
-			close(metaResultCh)
 		}
-		count++
 	}
 
-	var entries []totalSummary
-	for _, req_version_map := range allData {
-		for _, e := range req_version_map {
-			entries = append(entries, e)
-		}
-	}
+	return nil
+}
 
-	return entries, nil
+// addResult adds the history, vendor and metadata summarized by a worker to the summary of the fhir_endpoints data
+func addResult(summary totalSummary, result totalSummary) totalSummary {
+	summary.NumberOfUpdates = result.NumberOfUpdates
+	summary.Updated = result.Updated
+	summary.Operation = result.Operation
+	summary.FHIRVersion = result.FHIRVersion
+	summary.TLSVersion = result.TLSVersion
+	summary.MIMETypes = result.MIMETypes
+	summary.Vendor = result.Vendor
+	summary.ResponseTimeSecond = result.ResponseTimeSecond
+	summary.HTTPResponse = result.HTTPResponse
+	summary.SmartHTTPResponse = result.SmartHTTPResponse
+	summary.Errors = result.Errors
+	summary.ErrorCategories = result.ErrorCategories
+	return summary
 }
 
 // Creates a default first & last JSON object, using map[string]interface{} so that an
@@ -280,105 +480,107 @@ func makeDefaultMap() map[string]interface{} {
 	return defaultMap
 }
 
-// creates jobs for the workers so that each worker gets the history data
-// for a specified url
+// creates jobs for the workers so that each worker summarizes the data
+// for a specified url and requested version. Each job takes a slot, waiting until one is free.
 func createJobs(ctx context.Context,
 	ch chan Result,
-	urls_fhir_version map[string][]string,
-	dateStart string,
-	dateEnd string,
-	jobType string,
+	slots chan struct{},
+	summaries []totalSummary,
+	dateRange DateRange,
 	workerDur int,
-	store *postgresql.Store,
+	store Store,
 	allWorkers *workers.Workers) {
-	for url, requested_versions := range urls_fhir_version {
-		for index := range requested_versions {
-			jobArgs := make(map[string]interface{})
-			jobArgs["historyArgs"] = historyArgs{
-				fhirURL:              url,
-				requestedFhirVersion: requested_versions[index],
-				dateStart:            dateStart,
-				dateEnd:              dateEnd,
-				store:                store,
-				result:               ch,
-			}
+	for _, summary := range summaries {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
 
-			job := workers.Job{
-				Context:     ctx,
-				Duration:    time.Duration(workerDur) * time.Second,
-				HandlerArgs: &jobArgs,
-			}
+		jobArgs := make(map[string]interface{})
+		jobArgs["historyArgs"] = historyArgs{
+			fhirURL:              summary.URL,
+			requestedFhirVersion: summary.RequestedFhirVersion,
+			dateRange:            dateRange,
+			store:                store,
+			result:               ch,
+			done:                 ctx.Done(),
+		}
 
-			if jobType == "history" {
-				job.Handler = getHistory
-			} else {
-				job.Handler = getMetadata
-			}
+		job := workers.Job{
+			Context:     ctx,
+			Duration:    time.Duration(workerDur) * time.Second,
+			Handler:     getSummary,
+			HandlerArgs: &jobArgs,
+		}
 
-			err := allWorkers.Add(&job)
-			if err != nil {
-				log.Warnf("Error while adding job for getting history for URL %s, %s", url, err)
-			}
+		err := allWorkers.Add(&job)
+		if err != nil {
+			log.Warnf("Error while adding job for getting history for URL %s, %s", summary.URL, err)
 		}
 	}
 }
 
-// getHistory retrieves the data from the history table for a specific URL and formats it
-// as a totalSummary object before sending it back over the channel
-func getHistory(ctx context.Context, args *map[string]interface{}) error {
+// getSummary summarizes the data from the history and metadata tables for a specific URL and
+// requested version and sends the summary back over the channel
+func getSummary(ctx context.Context, args *map[string]interface{}) error {
+	ha, ok := (*args)["historyArgs"].(historyArgs)
+	if !ok {
+		return fmt.Errorf("unable to cast arguments to type historyArgs")
+	}
+
+	summary := getHistory(ctx, ha)
+	metadataSummary := getMetadata(ctx, ha)
+	summary.ResponseTimeSecond = metadataSummary.ResponseTimeSecond
+	summary.HTTPResponse = metadataSummary.HTTPResponse
+	summary.SmartHTTPResponse = metadataSummary.SmartHTTPResponse
+	summary.Errors = metadataSummary.Errors
+	summary.ErrorCategories = metadataSummary.ErrorCategories
+
+	result := Result{
+		URL:                  ha.fhirURL,
+		RequestedFhirVersion: ha.requestedFhirVersion,
+		Summary:              summary,
+	}
+	select {
+	case ha.result <- result:
+	case <-ha.done:
+	}
+	return nil
+}
+
+// getHistory retrieves the data from the history table for a specific URL and requested version
+// and summarizes it, including the first and last vendor
+func getHistory(ctx context.Context, ha historyArgs) totalSummary {
 	returnResult := totalSummary{
 		NumberOfUpdates: 0,
 		Updated:         makeDefaultMap(),
 		Operation:       makeDefaultMap(),
 		FHIRVersion:     makeDefaultMap(),
 		TLSVersion:      makeDefaultMap(),
+		Vendor:          makeDefaultMap(),
 	}
 	var history []historyEntry
+	var vendorNames []string
 
-	ha, ok := (*args)["historyArgs"].(historyArgs)
-	if !ok {
-		return fmt.Errorf("unable to cast arguments to type historyArgs")
-	}
-
-	// Get all rows in the history table between given dates
-	historyQuery := `SELECT updated_at, operation, capability_fhir_version, tls_version, mime_types FROM fhir_endpoints_info_history
-		WHERE updated_at between '` + ha.dateStart + `' AND '` + ha.dateEnd + `' AND url=$1 AND requested_fhir_version=$2 ORDER BY updated_at`
-	historyRows, err := ha.store.DB.QueryContext(ctx, historyQuery, ha.fhirURL, ha.requestedFhirVersion)
+	// Get all rows in the history table in the date range
+	historyRows, err := ha.store.GetArchiveInfoHistory(ctx, ha.fhirURL, ha.requestedFhirVersion, ha.dateRange.Start, ha.dateRange.until())
 	if err != nil {
 		log.Warnf("Failed getting the history rows for URL %s with requested version %s. Error: %s", ha.fhirURL, ha.requestedFhirVersion, err)
-		result := Result{
-			URL:                  ha.fhirURL,
-			RequestedFhirVersion: ha.requestedFhirVersion,
-			Summary:              returnResult,
-		}
-		ha.result <- result
-		return nil
+		return returnResult
 	}
 
-	defer historyRows.Close()
-	for historyRows.Next() {
-		var e historyEntry
-		var fhirVersion string
-
-		e.URL = ha.fhirURL
-		e.RequestedFhirVersion = ha.requestedFhirVersion
-
-		var err = historyRows.Scan(
-			&e.UpdatedAt,
-			&e.Operation,
-			&fhirVersion,
-			&e.TLSVersion,
-			pq.Array(&e.MIMETypes))
-		if err != nil {
-			log.Warnf("Error while scanning the rows of the history table for URL %s with requested version %s. Error: %s", ha.fhirURL, ha.requestedFhirVersion, err)
-			result := Result{
-				URL:                  ha.fhirURL,
-				RequestedFhirVersion: ha.requestedFhirVersion,
-				Summary:              returnResult,
-			}
-			ha.result <- result
-			return nil
+	for _, row := range historyRows {
+		e := historyEntry{
+			URL:                  ha.fhirURL,
+			UpdatedAt:            row.UpdatedAt,
+			Operation:            row.Operation,
+			TLSVersion:           row.TLSVersion,
+			MIMETypes:            row.MIMETypes,
+			RequestedFhirVersion: ha.requestedFhirVersion,
+			VendorName:           row.VendorName,
 		}
+		fhirVersion := row.CapabilityFhirVersion
 
 		if fhirVersion == "" {
 			e.FHIRVersion = fhirVersion
@@ -389,6 +591,10 @@ func getHistory(ctx context.Context, args *map[string]interface{}) error {
 		}
 
 		history = append(history, e)
+		// entries without a vendor are not included in the vendor summary
+		if e.VendorName != "" {
+			vendorNames = append(vendorNames, e.VendorName)
+		}
 	}
 
 	if len(history) > 0 {
@@ -420,62 +626,38 @@ func getHistory(ctx context.Context, args *map[string]interface{}) error {
 		}
 	}
 
-	result := Result{
-		URL:                  ha.fhirURL,
-		RequestedFhirVersion: ha.requestedFhirVersion,
-		Summary:              returnResult,
+	if len(vendorNames) > 0 {
+		returnResult.Vendor["first"] = vendorNames[0]
+		if vendorNames[0] != vendorNames[len(vendorNames)-1] {
+			returnResult.Vendor["last"] = vendorNames[len(vendorNames)-1]
+		}
 	}
-	ha.result <- result
-	return nil
+
+	return returnResult
 }
 
-// getMetadata retrieves the data from the metadata table for a specific URL and formats it
-// as a totalSummary object before sending it back over the channel
-func getMetadata(ctx context.Context, args *map[string]interface{}) error {
+// getMetadata retrieves the data from the metadata table for a specific URL and requested version
+// and summarizes it
+func getMetadata(ctx context.Context, ha historyArgs) totalSummary {
 	var returnResult totalSummary
 	var history []metadataEntry
 
-	ha, ok := (*args)["historyArgs"].(historyArgs)
-	if !ok {
-		return fmt.Errorf("unable to cast arguments to type historyArgs")
-	}
-
-	// Get all rows in the history table between given dates
-	metadataQuery := `SELECT response_time_seconds, http_response, smart_http_response, errors FROM fhir_endpoints_metadata
-		WHERE updated_at between '` + ha.dateStart + `' AND '` + ha.dateEnd + `' AND url=$1 AND requested_fhir_version=$2 ORDER BY updated_at`
-	metadataRows, err := ha.store.DB.QueryContext(ctx, metadataQuery, ha.fhirURL, ha.requestedFhirVersion)
+	// Get all rows in the metadata table in the date range
+	metadataRows, err := ha.store.GetArchiveMetadata(ctx, ha.fhirURL, ha.requestedFhirVersion, ha.dateRange.Start, ha.dateRange.until())
 	if err != nil {
 		log.Warnf("Failed getting the metadata rows for URL %s with requested version %s. Error: %s", ha.fhirURL, ha.requestedFhirVersion, err)
-		result := Result{
-			URL:                  ha.fhirURL,
-			RequestedFhirVersion: ha.requestedFhirVersion,
-			Summary:              returnResult,
-		}
-		ha.result <- result
-		return nil
+		return returnResult
 	}
 
-	defer metadataRows.Close()
-	for metadataRows.Next() {
-		var e metadataEntry
-
-		e.URL = ha.fhirURL
-		e.RequestedFhirVersion = ha.requestedFhirVersion
-
-		err = metadataRows.Scan(
-			&e.ResponseTimeSeconds,
-			&e.HTTPResponse,
-			&e.SMARTHTTPResponse,
-			&e.Errors)
-		if err != nil {
-			log.Warnf("Error while scanning the rows of the metadata table for URL %s with requested version %s. Error: %s", ha.fhirURL, ha.requestedFhirVersion, err)
-			result := Result{
-				URL:                  ha.fhirURL,
-				RequestedFhirVersion: ha.requestedFhirVersion,
-				Summary:              returnResult,
-			}
-			ha.result <- result
-			return nil
+	for _, row := range metadataRows {
+		e := metadataEntry{
+			URL:                  ha.fhirURL,
+			ResponseTimeSeconds:  row.ResponseTimeSeconds,
+			HTTPResponse:         row.HTTPResponse,
+			SMARTHTTPResponse:    row.SMARTHTTPResponse,
+			Errors:               row.Errors,
+			ErrorCategory:        row.ErrorCategory,
+			RequestedFhirVersion: ha.requestedFhirVersion,
 		}
 
 		history = append(history, e)
@@ -486,7 +668,8 @@ func getMetadata(ctx context.Context, args *map[string]interface{}) error {
 		httpResponseMap := make(map[int]int)
 		smartHTTPRespMap := make(map[int]int)
 		errorsMap := make(map[string]int)
-		// Keep track of each unique http response, smart http response, and error value
+		errorCategoriesMap := make(map[string]int)
+		// Keep track of each unique http response, smart http response, error value and error category
 		// and how many of each unique value there is
 		for _, elem := range history {
 			respTime = append(respTime, elem.ResponseTimeSeconds)
@@ -505,6 +688,10 @@ func getMetadata(ctx context.Context, args *map[string]interface{}) error {
 			} else {
 				errorsMap[elem.Errors] = 1
 			}
+			// requests that succeeded have no error category and are not counted
+			if elem.ErrorCategory != "" {
+				errorCategoriesMap[elem.ErrorCategory]++
+			}
 		}
 		// Calculate median of given response times
 		sort.Slice(respTime, func(i, j int) bool {
@@ -547,17 +734,20 @@ func getMetadata(ctx context.Context, args *map[string]interface{}) error {
 			}
 			errorArray = append(errorArray, errorResp)
 		}
+		var errorCategoryArray []errorCategories
+		for category, total := range errorCategoriesMap {
+			categoryResp := errorCategories{
+				ErrorCategory: category,
+				ErrorCount:    total,
+			}
+			errorCategoryArray = append(errorCategoryArray, categoryResp)
+		}
 		returnResult.ResponseTimeSecond = median
 		returnResult.HTTPResponse = httpRespArr
 		returnResult.SmartHTTPResponse = smartHTTPRespArr
 		returnResult.Errors = errorArray
+		returnResult.ErrorCategories = errorCategoryArray
 	}
 
-	result := Result{
-		URL:                  ha.fhirURL,
-		RequestedFhirVersion: ha.requestedFhirVersion,
-		Summary:              returnResult,
-	}
-	ha.result <- result
-	return nil
+	return returnResult
 }
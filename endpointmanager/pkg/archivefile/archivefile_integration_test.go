@@ -3,8 +3,10 @@
 package archivefile
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -33,16 +35,16 @@ var workerDur int
 var numWorkers int
 
 var testFhirEndpointInfo = endpointmanager.FHIREndpointInfo{
-	URL:        "http://example.com/DTSU2/",
-	MIMETypes:  []string{"application/json+fhir"},
-	TLSVersion: "TLS 1.2",
+	URL:                  "http://example.com/DTSU2/",
+	MIMETypes:            []string{"application/json+fhir"},
+	TLSVersion:           "TLS 1.2",
 	RequestedFhirVersion: "None",
 }
 
 var testFhirEndpointInfo2 = endpointmanager.FHIREndpointInfo{
-	URL:        "http://example.com/DTSU2/",
-	MIMETypes:  []string{"application/fhir+json"},
-	TLSVersion: "TLS 1.3",
+	URL:                  "http://example.com/DTSU2/",
+	MIMETypes:            []string{"application/fhir+json"},
+	TLSVersion:           "TLS 1.3",
 	RequestedFhirVersion: "None",
 }
 
@@ -53,20 +55,21 @@ var testFhirEndpoint = endpointmanager.FHIREndpoint{
 }
 
 var testMetadata = endpointmanager.FHIREndpointMetadata{
-	URL:               "http://example.com/DTSU2/",
-	HTTPResponse:      200,
-	Errors:            "Smart Response Failed",
-	ResponseTime:      0.8,
-	SMARTHTTPResponse: 400,
+	URL:                  "http://example.com/DTSU2/",
+	HTTPResponse:         200,
+	Errors:               "Smart Response Failed",
+	ErrorCategory:        endpointmanager.Timeout,
+	ResponseTime:         0.8,
+	SMARTHTTPResponse:    400,
 	RequestedFhirVersion: "None",
 }
 
 var testMetadata2 = endpointmanager.FHIREndpointMetadata{
-	URL:               "http://example.com/DTSU2/",
-	HTTPResponse:      200,
-	Errors:            "Smart Response Failed",
-	ResponseTime:      1.0,
-	SMARTHTTPResponse: 0,
+	URL:                  "http://example.com/DTSU2/",
+	HTTPResponse:         200,
+	Errors:               "Smart Response Failed",
+	ResponseTime:         1.0,
+	SMARTHTTPResponse:    0,
 	RequestedFhirVersion: "None",
 }
 
@@ -119,10 +122,10 @@ func TestMain(m *testing.M) {
 		tls_version,
 		mime_types,
 		vendor_id,
-		capability_statement,
+		capability_statement_hash,
 		capability_fhir_version,
 		requested_fhir_version)			
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);`)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, add_fhir_endpoints_document($8), $9, $10);`)
 	if err != nil {
 		panic(err)
 	}
@@ -178,7 +181,7 @@ func Test_CreateArchive(t *testing.T) {
 
 	// Empty test, come back to this
 
-	entries, err := CreateArchive(ctx, store, formatToday, formatTomorrow, numWorkers, workerDur)
+	entries, err := createArchive(ctx, formatToday, formatTomorrow)
 	th.Assert(t, err == nil, err)
 	th.Assert(t, len(entries) == 1, fmt.Sprintf("length of entries should have been 1, is instead %d", len(entries)))
 	th.Assert(t, entries[0].NumberOfUpdates == 0, fmt.Sprintf("There should have been no updates, instead there were %d updates", entries[0].NumberOfUpdates))
@@ -193,7 +196,7 @@ func Test_CreateArchive(t *testing.T) {
 
 	// Metadata should exist without impacting the history fields
 
-	entries, err = CreateArchive(ctx, store, formatToday, formatTomorrow, numWorkers, workerDur)
+	entries, err = createArchive(ctx, formatToday, formatTomorrow)
 	th.Assert(t, err == nil, err)
 	th.Assert(t, len(entries) == 1, fmt.Sprintf("length of entries should have been 1, is instead %d", len(entries)))
 	th.Assert(t, entries[0].NumberOfUpdates == 0, fmt.Sprintf("There should have been no updates, instead there were %d updates", entries[0].NumberOfUpdates))
@@ -207,7 +210,7 @@ func Test_CreateArchive(t *testing.T) {
 	th.Assert(t, err == nil, err)
 	th.Assert(t, count == 1, fmt.Sprintf("Should have got 1, intead got %d", count))
 
-	entries, err = CreateArchive(ctx, store, formatToday, formatTomorrow, numWorkers, workerDur)
+	entries, err = createArchive(ctx, formatToday, formatTomorrow)
 	th.Assert(t, err == nil, err)
 	th.Assert(t, len(entries) == 1, fmt.Sprintf("length of entries should have been 1, is instead %d", len(entries)))
 	th.Assert(t, entries[0].NumberOfUpdates == 1, fmt.Sprintf("only 1 update should have been registered, instead there were %d updates", entries[0].NumberOfUpdates))
@@ -226,7 +229,7 @@ func Test_CreateArchive(t *testing.T) {
 	th.Assert(t, err == nil, err)
 	th.Assert(t, count == 2, fmt.Sprintf("Should have got 2, intead got %d", count))
 
-	entries, err = CreateArchive(ctx, store, formatToday, formatTomorrow, numWorkers, workerDur)
+	entries, err = createArchive(ctx, formatToday, formatTomorrow)
 	th.Assert(t, err == nil, err)
 	th.Assert(t, len(entries) == 1, fmt.Sprintf("length of entries should have been 1, is instead %d", len(entries)))
 	th.Assert(t, entries[0].NumberOfUpdates == 2, fmt.Sprintf("2 updates should have been registered, instead there were %d updates", entries[0].NumberOfUpdates))
@@ -247,7 +250,7 @@ func Test_CreateArchive(t *testing.T) {
 	th.Assert(t, err == nil, err)
 	th.Assert(t, count == 3, fmt.Sprintf("Should have got 3, intead got %d", count))
 
-	entries, err = CreateArchive(ctx, store, formatToday, formatTomorrow, numWorkers, workerDur)
+	entries, err = createArchive(ctx, formatToday, formatTomorrow)
 	th.Assert(t, err == nil, err)
 	th.Assert(t, len(entries) == 1, fmt.Sprintf("length of entries should have been 1, is instead %d", len(entries)))
 	th.Assert(t, entries[0].NumberOfUpdates == 3, fmt.Sprintf("3 updates should have been registered, instead there were %d updates", entries[0].NumberOfUpdates))
@@ -262,7 +265,7 @@ func Test_CreateArchive(t *testing.T) {
 
 	twoDays := today.Add(time.Hour * 48).Format("2006-01-02")
 
-	entries, err = CreateArchive(ctx, store, formatTomorrow, twoDays, numWorkers, workerDur)
+	entries, err = createArchive(ctx, formatTomorrow, twoDays)
 	th.Assert(t, err == nil, err)
 	th.Assert(t, len(entries) == 1, fmt.Sprintf("length of entries should have been 1, is instead %d", len(entries)))
 	th.Assert(t, entries[0].NumberOfUpdates == 0, fmt.Sprintf("There should have been no updates, instead there were %d updates", entries[0].NumberOfUpdates))
@@ -278,7 +281,7 @@ func Test_CreateArchive(t *testing.T) {
 	err = addFHIREndpointInfoHistory(ctx, store, testFhirEndpointInfo, time.Now().UTC().Format("2006-01-02 15:04:05.000000000"), idCount, "U", 1)
 	th.Assert(t, err == nil, err)
 
-	entries, err = CreateArchive(ctx, store, formatToday, formatTomorrow, numWorkers, workerDur)
+	entries, err = createArchive(ctx, formatToday, formatTomorrow)
 	th.Assert(t, err == nil, err)
 	// Test to make sure there are 3 different entries for each requested fhir version for the one endpoint in the DB
 	th.Assert(t, len(entries) == 3, fmt.Sprintf("length of entries should have been 3, is instead %d", len(entries)))
@@ -308,8 +311,8 @@ func Test_getHistory(t *testing.T) {
 
 	// Get today and tomorrow's date
 	today := time.Now().UTC()
-	formatTomorrow := today.Add(time.Hour * 24).Format("2006-01-02")
-	formatToday := today.Format("2006-01-02")
+	dateRange, err := ParseDateRange(today.Format("2006-01-02"), today.Add(time.Hour*24).Format("2006-01-02"))
+	th.Assert(t, err == nil, err)
 
 	// If there is no capability statement, FHIRVersion should be null instead of empty string
 
@@ -325,25 +328,14 @@ func Test_getHistory(t *testing.T) {
 	th.Assert(t, err == nil, err)
 	th.Assert(t, count == 1, fmt.Sprintf("Should have got 1, intead got %d", count))
 
-	resultCh2 := make(chan Result)
-	jobArgs2 := make(map[string]interface{})
-	jobArgs2["historyArgs"] = historyArgs{
-		fhirURL:   "http://example.com/DTSU2/",
+	summary := getHistory(ctx, historyArgs{
+		fhirURL:              "http://example.com/DTSU2/",
 		requestedFhirVersion: "None",
-		dateStart: formatToday,
-		dateEnd:   formatTomorrow,
-		store:     store,
-		result:    resultCh2,
-	}
-
-	go getHistory(ctx, &jobArgs2)
-
-	for res := range resultCh2 {
-		th.Assert(t, res.URL == "http://example.com/DTSU2/", fmt.Sprintf("Expected URL to equal 'http://example.com/DTSU2/'. Is actually '%s'.", res.URL))
-		th.Assert(t, res.Summary.NumberOfUpdates == 1, fmt.Sprintf("1 update should have been registered, instead there were %d updates", res.Summary.NumberOfUpdates))
-		th.Assert(t, res.Summary.FHIRVersion["first"] == nil, fmt.Sprintf("FHIR Version first should have been nil, is instead %s", res.Summary.FHIRVersion["first"]))
-		close(resultCh2)
-	}
+		dateRange:            dateRange,
+		store:                store,
+	})
+	th.Assert(t, summary.NumberOfUpdates == 1, fmt.Sprintf("1 update should have been registered, instead there were %d updates", summary.NumberOfUpdates))
+	th.Assert(t, summary.FHIRVersion["first"] == nil, fmt.Sprintf("FHIR Version first should have been nil, is instead %s", summary.FHIRVersion["first"]))
 
 	// Base Case
 
@@ -354,27 +346,16 @@ func Test_getHistory(t *testing.T) {
 	th.Assert(t, err == nil, err)
 	th.Assert(t, count == 2, fmt.Sprintf("Should have got 2, intead got %d", count))
 
-	resultCh := make(chan Result)
-	jobArgs := make(map[string]interface{})
-	jobArgs["historyArgs"] = historyArgs{
-		fhirURL:   "http://example.com/DTSU2/",
+	summary = getHistory(ctx, historyArgs{
+		fhirURL:              "http://example.com/DTSU2/",
 		requestedFhirVersion: "None",
-		dateStart: formatToday,
-		dateEnd:   formatTomorrow,
-		store:     store,
-		result:    resultCh,
-	}
-
-	go getHistory(ctx, &jobArgs)
-
-	for res := range resultCh {
-		th.Assert(t, res.URL == "http://example.com/DTSU2/", fmt.Sprintf("Expected URL to equal 'http://example.com/DTSU2/'. Is actually '%s'.", res.URL))
-		th.Assert(t, res.Summary.NumberOfUpdates == 2, fmt.Sprintf("2 updates should have been registered, instead there were %d updates", res.Summary.NumberOfUpdates))
-		th.Assert(t, res.Summary.TLSVersion["first"] == "TLS 1.2", fmt.Sprintf("TLS first should have been TLS 1.2, is instead %s", res.Summary.TLSVersion["first"]))
-		th.Assert(t, res.Summary.TLSVersion["last"] == nil, fmt.Sprintf("TLS last should have been nil, it is instead %s", res.Summary.TLSVersion["last"]))
-		th.Assert(t, res.Summary.FHIRVersion["last"] == "1.0.2", fmt.Sprintf("FHIR Version last should have been 1.0.2, is instead %+v", res.Summary.FHIRVersion["last"]))
-		close(resultCh)
-	}
+		dateRange:            dateRange,
+		store:                store,
+	})
+	th.Assert(t, summary.NumberOfUpdates == 2, fmt.Sprintf("2 updates should have been registered, instead there were %d updates", summary.NumberOfUpdates))
+	th.Assert(t, summary.TLSVersion["first"] == "TLS 1.2", fmt.Sprintf("TLS first should have been TLS 1.2, is instead %s", summary.TLSVersion["first"]))
+	th.Assert(t, summary.TLSVersion["last"] == nil, fmt.Sprintf("TLS last should have been nil, it is instead %s", summary.TLSVersion["last"]))
+	th.Assert(t, summary.FHIRVersion["last"] == "1.0.2", fmt.Sprintf("FHIR Version last should have been 1.0.2, is instead %+v", summary.FHIRVersion["last"]))
 
 	// If the args are not properly formatted
 
@@ -383,30 +364,20 @@ func Test_getHistory(t *testing.T) {
 		"nonsense": 1,
 	}
 
-	err = getHistory(ctx, &jobArgs3)
+	err = getSummary(ctx, &jobArgs3)
 	th.Assert(t, err != nil, fmt.Sprint("Malformed arguments should have thrown error."))
 
 	// If the URL does not exist, return default data
 
-	resultCh4 := make(chan Result)
-	jobArgs4 := make(map[string]interface{})
-	jobArgs4["historyArgs"] = historyArgs{
-		fhirURL:   "thisurldoesntexist.com",
+	summary = getHistory(ctx, historyArgs{
+		fhirURL:              "thisurldoesntexist.com",
 		requestedFhirVersion: "None",
-		dateStart: formatToday,
-		dateEnd:   formatTomorrow,
-		store:     store,
-		result:    resultCh4,
-	}
-
-	go getHistory(ctx, &jobArgs4)
-	for res := range resultCh4 {
-		th.Assert(t, res.Summary.NumberOfUpdates == 0, fmt.Sprintf("Expected 0 entries in history table. Actually had %d entries.", res.Summary.NumberOfUpdates))
-		th.Assert(t, res.URL == "thisurldoesntexist.com", fmt.Sprintf("Expected URL to equal 'thisurldoesntexist.com'. Is actually '%s'.", res.URL))
-		th.Assert(t, res.Summary.TLSVersion["first"] == nil, fmt.Sprint("TLS first should have been nil"))
-		th.Assert(t, res.Summary.TLSVersion["last"] == nil, fmt.Sprint("TLS last should have been nil"))
-		close(resultCh4)
-	}
+		dateRange:            dateRange,
+		store:                store,
+	})
+	th.Assert(t, summary.NumberOfUpdates == 0, fmt.Sprintf("Expected 0 entries in history table. Actually had %d entries.", summary.NumberOfUpdates))
+	th.Assert(t, summary.TLSVersion["first"] == nil, fmt.Sprint("TLS first should have been nil"))
+	th.Assert(t, summary.TLSVersion["last"] == nil, fmt.Sprint("TLS last should have been nil"))
 }
 
 func Test_getMetadata(t *testing.T) {
@@ -419,8 +390,8 @@ func Test_getMetadata(t *testing.T) {
 
 	// Get today and tomorrow's date
 	today := time.Now().UTC()
-	formatTomorrow := today.Add(time.Hour * 24).Format("2006-01-02")
-	formatToday := today.Format("2006-01-02")
+	dateRange, err := ParseDateRange(today.Format("2006-01-02"), today.Add(time.Hour*24).Format("2006-01-02"))
+	th.Assert(t, err == nil, err)
 
 	// Add Metadata for Endpoint
 	_, err = store.AddFHIREndpointMetadata(ctx, &testMetadata)
@@ -428,82 +399,52 @@ func Test_getMetadata(t *testing.T) {
 
 	// Base Case
 
-	resultCh := make(chan Result)
-	jobArgs := make(map[string]interface{})
-	jobArgs["historyArgs"] = historyArgs{
-		fhirURL:   "http://example.com/DTSU2/",
+	summary := getMetadata(ctx, historyArgs{
+		fhirURL:              "http://example.com/DTSU2/",
 		requestedFhirVersion: "None",
-		dateStart: formatToday,
-		dateEnd:   formatTomorrow,
-		store:     store,
-		result:    resultCh,
-	}
-
-	go getMetadata(ctx, &jobArgs)
-
-	for res := range resultCh {
-		th.Assert(t, res.URL == "http://example.com/DTSU2/", fmt.Sprintf("Expected URL to equal 'http://example.com/DTSU2/'. Is actually '%s'.", res.URL))
-		th.Assert(t, len(res.Summary.SmartHTTPResponse) == 1, fmt.Sprintf("There should be 1 entry for the SMART HTTP Response, is instead %d", len(res.Summary.SmartHTTPResponse)))
-		th.Assert(t, res.Summary.SmartHTTPResponse[0].ResponseCode == 400, fmt.Sprintf("SMART HTTP Response Code should be 400, is instead %d", res.Summary.SmartHTTPResponse[0].ResponseCode))
-		th.Assert(t, res.Summary.SmartHTTPResponse[0].ResponseCount == 1, fmt.Sprintf("SMART HTTP Response Count should be 1, is instead %d", res.Summary.SmartHTTPResponse[0].ResponseCount))
-		close(resultCh)
-	}
+		dateRange:            dateRange,
+		store:                store,
+	})
+	th.Assert(t, len(summary.SmartHTTPResponse) == 1, fmt.Sprintf("There should be 1 entry for the SMART HTTP Response, is instead %d", len(summary.SmartHTTPResponse)))
+	th.Assert(t, summary.SmartHTTPResponse[0].ResponseCode == 400, fmt.Sprintf("SMART HTTP Response Code should be 400, is instead %d", summary.SmartHTTPResponse[0].ResponseCode))
+	th.Assert(t, summary.SmartHTTPResponse[0].ResponseCount == 1, fmt.Sprintf("SMART HTTP Response Count should be 1, is instead %d", summary.SmartHTTPResponse[0].ResponseCount))
 
 	// Add 2nd Metadata for Endpoint
 	_, err = store.AddFHIREndpointMetadata(ctx, &testMetadata2)
 	th.Assert(t, err == nil, err)
 
-	resultCh2 := make(chan Result)
-	jobArgs2 := make(map[string]interface{})
-	jobArgs2["historyArgs"] = historyArgs{
-		fhirURL:   "http://example.com/DTSU2/",
+	summary = getMetadata(ctx, historyArgs{
+		fhirURL:              "http://example.com/DTSU2/",
 		requestedFhirVersion: "None",
-		dateStart: formatToday,
-		dateEnd:   formatTomorrow,
-		store:     store,
-		result:    resultCh2,
-	}
-
-	go getMetadata(ctx, &jobArgs2)
-
-	for res := range resultCh2 {
-		th.Assert(t, res.URL == "http://example.com/DTSU2/", fmt.Sprintf("Expected URL to equal 'http://example.com/DTSU2/'. Is actually '%s'.", res.URL))
-		th.Assert(t, len(res.Summary.SmartHTTPResponse) == 2, fmt.Sprintf("SMART HTTP Response should have 2 entries, instead has %d", len(res.Summary.SmartHTTPResponse)))
-		th.Assert(t, len(res.Summary.HTTPResponse) == 1, fmt.Sprintf("HTTP Response should have 1 entry, instead has %d", len(res.Summary.HTTPResponse)))
-		th.Assert(t, res.Summary.HTTPResponse[0].ResponseCode == 200, fmt.Sprintf("HTTP Response Code should be 200, is instead %d", res.Summary.HTTPResponse[0].ResponseCode))
-		th.Assert(t, res.Summary.HTTPResponse[0].ResponseCount == 2, fmt.Sprintf("HTTP Response Count should be 2, is instead %d", res.Summary.HTTPResponse[0].ResponseCount))
-		th.Assert(t, len(res.Summary.Errors) == 1, fmt.Sprintf("Errors should have 1 entry, instead has %d", len(res.Summary.Errors)))
-		th.Assert(t, res.Summary.ResponseTimeSecond == 0.9, fmt.Sprintf("HTTP Response Code should be 0.9, the median of [0.8, 1.0], is instead %f", res.Summary.ResponseTimeSecond))
-		close(resultCh2)
-	}
+		dateRange:            dateRange,
+		store:                store,
+	})
+	th.Assert(t, len(summary.SmartHTTPResponse) == 2, fmt.Sprintf("SMART HTTP Response should have 2 entries, instead has %d", len(summary.SmartHTTPResponse)))
+	th.Assert(t, len(summary.HTTPResponse) == 1, fmt.Sprintf("HTTP Response should have 1 entry, instead has %d", len(summary.HTTPResponse)))
+	th.Assert(t, summary.HTTPResponse[0].ResponseCode == 200, fmt.Sprintf("HTTP Response Code should be 200, is instead %d", summary.HTTPResponse[0].ResponseCode))
+	th.Assert(t, summary.HTTPResponse[0].ResponseCount == 2, fmt.Sprintf("HTTP Response Count should be 2, is instead %d", summary.HTTPResponse[0].ResponseCount))
+	th.Assert(t, len(summary.Errors) == 1, fmt.Sprintf("Errors should have 1 entry, instead has %d", len(summary.Errors)))
+	th.Assert(t, summary.ResponseTimeSecond == 0.9, fmt.Sprintf("HTTP Response Code should be 0.9, the median of [0.8, 1.0], is instead %f", summary.ResponseTimeSecond))
 
 	// Add 3nd Metadata for Endpoint
 	_, err = store.AddFHIREndpointMetadata(ctx, &testMetadata)
 	th.Assert(t, err == nil, err)
 
-	resultCh3 := make(chan Result)
-	jobArgs3 := make(map[string]interface{})
-	jobArgs3["historyArgs"] = historyArgs{
-		fhirURL:   "http://example.com/DTSU2/",
+	summary = getMetadata(ctx, historyArgs{
+		fhirURL:              "http://example.com/DTSU2/",
 		requestedFhirVersion: "None",
-		dateStart: formatToday,
-		dateEnd:   formatTomorrow,
-		store:     store,
-		result:    resultCh3,
-	}
-
-	go getMetadata(ctx, &jobArgs3)
-
-	for res := range resultCh3 {
-		th.Assert(t, res.URL == "http://example.com/DTSU2/", fmt.Sprintf("Expected URL to equal 'http://example.com/DTSU2/'. Is actually '%s'.", res.URL))
-		th.Assert(t, len(res.Summary.SmartHTTPResponse) == 2, fmt.Sprintf("SMART HTTP Response should have 2 entries, instead has %d", len(res.Summary.SmartHTTPResponse)))
-		th.Assert(t, len(res.Summary.HTTPResponse) == 1, fmt.Sprintf("HTTP Response should have 1 entry, instead has %d", len(res.Summary.HTTPResponse)))
-		th.Assert(t, res.Summary.HTTPResponse[0].ResponseCode == 200, fmt.Sprintf("HTTP Response Code should be 200, is instead %d", res.Summary.HTTPResponse[0].ResponseCode))
-		th.Assert(t, res.Summary.HTTPResponse[0].ResponseCount == 3, fmt.Sprintf("HTTP Response Count should be 2, is instead %d", res.Summary.HTTPResponse[0].ResponseCount))
-		th.Assert(t, len(res.Summary.Errors) == 1, fmt.Sprintf("Errors should have 1 entry, instead has %d", len(res.Summary.Errors)))
-		th.Assert(t, res.Summary.ResponseTimeSecond == 0.8, fmt.Sprintf("HTTP Response Code should be 0.8, the median of [0.8, 0.8, 1.0], is instead %f", res.Summary.ResponseTimeSecond))
-		close(resultCh3)
-	}
+		dateRange:            dateRange,
+		store:                store,
+	})
+	th.Assert(t, len(summary.SmartHTTPResponse) == 2, fmt.Sprintf("SMART HTTP Response should have 2 entries, instead has %d", len(summary.SmartHTTPResponse)))
+	th.Assert(t, len(summary.HTTPResponse) == 1, fmt.Sprintf("HTTP Response should have 1 entry, instead has %d", len(summary.HTTPResponse)))
+	th.Assert(t, summary.HTTPResponse[0].ResponseCode == 200, fmt.Sprintf("HTTP Response Code should be 200, is instead %d", summary.HTTPResponse[0].ResponseCode))
+	th.Assert(t, summary.HTTPResponse[0].ResponseCount == 3, fmt.Sprintf("HTTP Response Count should be 2, is instead %d", summary.HTTPResponse[0].ResponseCount))
+	th.Assert(t, len(summary.Errors) == 1, fmt.Sprintf("Errors should have 1 entry, instead has %d", len(summary.Errors)))
+	th.Assert(t, len(summary.ErrorCategories) == 1, fmt.Sprintf("Error categories should have 1 entry, instead has %d", len(summary.ErrorCategories)))
+	th.Assert(t, summary.ErrorCategories[0].ErrorCategory == "timeout", fmt.Sprintf("Error category should be 'timeout', is instead %s", summary.ErrorCategories[0].ErrorCategory))
+	th.Assert(t, summary.ErrorCategories[0].ErrorCount == 2, fmt.Sprintf("Error category count should be 2, is instead %d", summary.ErrorCategories[0].ErrorCount))
+	th.Assert(t, summary.ResponseTimeSecond == 0.8, fmt.Sprintf("HTTP Response Code should be 0.8, the median of [0.8, 0.8, 1.0], is instead %f", summary.ResponseTimeSecond))
 
 	// If the args are not properly formatted
 
@@ -512,30 +453,21 @@ func Test_getMetadata(t *testing.T) {
 		"nonsense": 1,
 	}
 
-	err = getMetadata(ctx, &jobArgs4)
+	err = getSummary(ctx, &jobArgs4)
 	th.Assert(t, err != nil, fmt.Sprint("Malformed arguments should have thrown error."))
 
 	// If the URL does not exist, return default data
 
-	resultCh5 := make(chan Result)
-	jobArgs5 := make(map[string]interface{})
-	jobArgs5["historyArgs"] = historyArgs{
-		fhirURL:   "thisurldoesntexist.com",
+	summary = getMetadata(ctx, historyArgs{
+		fhirURL:              "thisurldoesntexist.com",
 		requestedFhirVersion: "None",
-		dateStart: formatToday,
-		dateEnd:   formatTomorrow,
-		store:     store,
-		result:    resultCh5,
-	}
-
-	go getMetadata(ctx, &jobArgs5)
-	for res := range resultCh5 {
-		th.Assert(t, len(res.Summary.HTTPResponse) == 0, fmt.Sprintf("HTTP Response should have 0 entries, instead has %d", len(res.Summary.HTTPResponse)))
-		th.Assert(t, len(res.Summary.SmartHTTPResponse) == 0, fmt.Sprintf("SMART HTTP Response should have 0 entries, instead has %d", len(res.Summary.SmartHTTPResponse)))
-		th.Assert(t, len(res.Summary.Errors) == 0, fmt.Sprintf("Errors should have 0 entries, instead has %d", len(res.Summary.Errors)))
-		th.Assert(t, res.Summary.ResponseTimeSecond == nil, fmt.Sprintf("ResponseTimeSecond should be 0, instead is %f", res.Summary.ResponseTimeSecond))
-		close(resultCh5)
-	}
+		dateRange:            dateRange,
+		store:                store,
+	})
+	th.Assert(t, len(summary.HTTPResponse) == 0, fmt.Sprintf("HTTP Response should have 0 entries, instead has %d", len(summary.HTTPResponse)))
+	th.Assert(t, len(summary.SmartHTTPResponse) == 0, fmt.Sprintf("SMART HTTP Response should have 0 entries, instead has %d", len(summary.SmartHTTPResponse)))
+	th.Assert(t, len(summary.Errors) == 0, fmt.Sprintf("Errors should have 0 entries, instead has %d", len(summary.Errors)))
+	th.Assert(t, summary.ResponseTimeSecond == nil, fmt.Sprintf("ResponseTimeSecond should be 0, instead is %f", summary.ResponseTimeSecond))
 }
 
 func setupCapabilityStatement(t *testing.T, path string) {
@@ -549,6 +481,22 @@ func setupCapabilityStatement(t *testing.T, path string) {
 	testFhirEndpointInfo.CapabilityFhirVersion = fhirVersion
 }
 
+// createArchive writes the archive of the given dates to a buffer and reads its entries back.
+func createArchive(ctx context.Context, start string, end string) ([]totalSummary, error) {
+	dateRange, err := ParseDateRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = CreateArchive(ctx, store, &buf, dateRange, numWorkers, workerDur)
+	if err != nil {
+		return nil, err
+	}
+	var entries []totalSummary
+	err = json.Unmarshal(buf.Bytes(), &entries)
+	return entries, err
+}
+
 // addFHIREndpointInfoHistory adds the FHIREndpointInfoHistory to the database.
 func addFHIREndpointInfoHistory(ctx context.Context,
 	store *postgresql.Store,
@@ -579,7 +527,7 @@ func addFHIREndpointInfoHistory(ctx context.Context,
 		pq.Array(e.MIMETypes),
 		vendorID,
 		capabilityStatementJSON,
-		e.CapabilityFhirVersion, 
+		e.CapabilityFhirVersion,
 		e.RequestedFhirVersion)
 	if err != nil {
 		return err
@@ -0,0 +1,242 @@
+package archivefile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+)
+
+// testArchiveStore returns the same archive data regardless of the date range.
+type testArchiveStore struct {
+	endpoints []*postgresql.ArchiveEndpoint
+	history   map[string][]*postgresql.ArchiveInfoHistoryEntry
+	metadata  map[string][]*postgresql.ArchiveMetadataEntry
+}
+
+func (s *testArchiveStore) GetArchiveEndpoints(ctx context.Context) ([]*postgresql.ArchiveEndpoint, error) {
+	return s.endpoints, nil
+}
+
+func (s *testArchiveStore) GetArchiveInfoHistory(ctx context.Context, url string, requestedFhirVersion string, start time.Time, end time.Time) ([]*postgresql.ArchiveInfoHistoryEntry, error) {
+	return s.history[url+requestedFhirVersion], nil
+}
+
+func (s *testArchiveStore) GetArchiveMetadata(ctx context.Context, url string, requestedFhirVersion string, start time.Time, end time.Time) ([]*postgresql.ArchiveMetadataEntry, error) {
+	return s.metadata[url+requestedFhirVersion], nil
+}
+
+var testDateRange = DateRange{
+	Start: time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC),
+	End:   time.Date(2021, time.March, 2, 0, 0, 0, 0, time.UTC),
+}
+
+func Test_CreateArchiveSummary(t *testing.T) {
+	url := "http://example.com/DTSU2/"
+	first := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	last := first.Add(time.Hour)
+
+	store := &testArchiveStore{
+		endpoints: []*postgresql.ArchiveEndpoint{{
+			URL:                  url,
+			RequestedFhirVersion: "None",
+			OrganizationNames:    []string{"Example Inc."},
+			CreatedAt:            first,
+			ListSource:           "Lantern",
+		}},
+		history: map[string][]*postgresql.ArchiveInfoHistoryEntry{
+			url + "None": {
+				{UpdatedAt: first, Operation: "I", CapabilityFhirVersion: "1.0.2", TLSVersion: "TLS 1.2", MIMETypes: []string{"application/json+fhir"}, VendorName: "Cerner Corporation"},
+				{UpdatedAt: last, Operation: "U", CapabilityFhirVersion: "1.0.2", TLSVersion: "TLS 1.3", MIMETypes: []string{"application/json+fhir"}, VendorName: "Epic Systems Corporation"},
+			},
+		},
+		metadata: map[string][]*postgresql.ArchiveMetadataEntry{
+			url + "None": {
+				{ResponseTimeSeconds: .1, HTTPResponse: 200, SMARTHTTPResponse: 200},
+				{ResponseTimeSeconds: .3, HTTPResponse: 404, SMARTHTTPResponse: 0, Errors: "not found", ErrorCategory: "http"},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := CreateArchive(context.Background(), store, &buf, testDateRange, 2, 10)
+	th.Assert(t, err == nil, err)
+
+	var entries []totalSummary
+	err = json.Unmarshal(buf.Bytes(), &entries)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(entries) == 1, fmt.Sprintf("expected 1 entry, got %d", len(entries)))
+
+	entry := entries[0]
+	th.Assert(t, entry.URL == url, fmt.Sprintf("expected the entry for %s, got %s", url, entry.URL))
+	th.Assert(t, entry.NumberOfUpdates == 2, fmt.Sprintf("expected 2 updates, got %d", entry.NumberOfUpdates))
+	th.Assert(t, entry.Operation["first"] == "I" && entry.Operation["last"] == "U", fmt.Sprintf("expected the first and last operations, got %v", entry.Operation))
+	th.Assert(t, entry.TLSVersion["first"] == "TLS 1.2" && entry.TLSVersion["last"] == "TLS 1.3", fmt.Sprintf("expected the first and last TLS versions, got %v", entry.TLSVersion))
+	th.Assert(t, entry.FHIRVersion["first"] == "1.0.2" && entry.FHIRVersion["last"] == nil, fmt.Sprintf("expected an unchanged FHIR version, got %v", entry.FHIRVersion))
+	th.Assert(t, entry.Vendor["first"] == "Cerner Corporation" && entry.Vendor["last"] == "Epic Systems Corporation", fmt.Sprintf("expected the first and last vendors, got %v", entry.Vendor))
+	th.Assert(t, entry.ResponseTimeSecond == .2, fmt.Sprintf("expected a median response time of .2, got %v", entry.ResponseTimeSecond))
+	th.Assert(t, len(entry.HTTPResponse) == 2, fmt.Sprintf("expected 2 http responses, got %d", len(entry.HTTPResponse)))
+	th.Assert(t, len(entry.ErrorCategories) == 1 && entry.ErrorCategories[0].ErrorCount == 1, fmt.Sprintf("expected 1 error category, got %v", entry.ErrorCategories))
+
+	// the archive is formatted in the same way as the whole array would be
+
+	expected, err := json.MarshalIndent([]totalSummary{addResult(archiveSummaries(store.endpoints)[0], entry)}, "", "\t")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, buf.String() == string(expected), fmt.Sprintf("expected the archive to be formatted as an indented array, got %s", buf.String()))
+}
+
+// testManyEndpointsStore returns a store with n endpoints, listed in the reverse of the archive's order
+func testManyEndpointsStore(n int) *testArchiveStore {
+	store := &testArchiveStore{history: make(map[string][]*postgresql.ArchiveInfoHistoryEntry)}
+	for i := n - 1; i >= 0; i-- {
+		url := fmt.Sprintf("http://example.com/%03d/", i)
+		store.endpoints = append(store.endpoints, &postgresql.ArchiveEndpoint{URL: url, RequestedFhirVersion: "None", ListSource: "Lantern"})
+		store.history[url+"None"] = []*postgresql.ArchiveInfoHistoryEntry{{Operation: "I", TLSVersion: "TLS 1.2"}}
+	}
+	return store
+}
+
+func Test_CreateArchiveOrder(t *testing.T) {
+	store := testManyEndpointsStore(50)
+	// a list source that the first endpoint is also in
+	store.endpoints = append(store.endpoints, &postgresql.ArchiveEndpoint{URL: "http://example.com/000/", RequestedFhirVersion: "None", ListSource: "Other"})
+
+	var buf bytes.Buffer
+	err := CreateArchive(context.Background(), store, &buf, testDateRange, 5, 10)
+	th.Assert(t, err == nil, err)
+
+	var entries []totalSummary
+	err = json.Unmarshal(buf.Bytes(), &entries)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(entries) == 50, fmt.Sprintf("expected 50 entries, got %d", len(entries)))
+	for i, entry := range entries {
+		url := fmt.Sprintf("http://example.com/%03d/", i)
+		th.Assert(t, entry.URL == url, fmt.Sprintf("expected entry %d to be %s, got %s", i, url, entry.URL))
+		th.Assert(t, entry.NumberOfUpdates == 1, fmt.Sprintf("expected the history of %s to be summarized", entry.URL))
+	}
+	th.Assert(t, len(entries[0].ListSource) == 2, fmt.Sprintf("expected both list sources of the first endpoint, got %v", entries[0].ListSource))
+
+	// an archive without any endpoints is an empty array
+
+	buf.Reset()
+	err = CreateArchive(context.Background(), &testArchiveStore{}, &buf, testDateRange, 5, 10)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, buf.String() == "[]", fmt.Sprintf("expected an empty array, got %s", buf.String()))
+}
+
+// blockingArchiveStore holds back the history of the first endpoint in the archive until release is closed, and
+// counts the endpoints whose history was requested.
+type blockingArchiveStore struct {
+	*testArchiveStore
+	release   chan struct{}
+	requested int32
+}
+
+func (s *blockingArchiveStore) GetArchiveInfoHistory(ctx context.Context, url string, requestedFhirVersion string, start time.Time, end time.Time) ([]*postgresql.ArchiveInfoHistoryEntry, error) {
+	atomic.AddInt32(&s.requested, 1)
+	if url == "http://example.com/000/" {
+		<-s.release
+	}
+	return s.testArchiveStore.GetArchiveInfoHistory(ctx, url, requestedFhirVersion, start, end)
+}
+
+func Test_CreateArchiveJobsInFlight(t *testing.T) {
+	numWorkers := 2
+	store := &blockingArchiveStore{testArchiveStore: testManyEndpointsStore(50), release: make(chan struct{})}
+
+	var buf bytes.Buffer
+	errs := make(chan error)
+	go func() {
+		errs <- CreateArchive(context.Background(), store, &buf, testDateRange, numWorkers, 10)
+	}()
+
+	// while the first summary is held back, only a window of the summaries after it are made
+	limit := int32(numWorkers * jobsPerWorker)
+	for wait := 0; atomic.LoadInt32(&store.requested) < limit && wait < 100; wait++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	requested := atomic.LoadInt32(&store.requested)
+	th.Assert(t, requested == limit, fmt.Sprintf("expected %d summaries to be in progress, got %d", limit, requested))
+
+	close(store.release)
+	err := <-errs
+	th.Assert(t, err == nil, err)
+	var entries []totalSummary
+	err = json.Unmarshal(buf.Bytes(), &entries)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(entries) == 50, fmt.Sprintf("expected 50 entries, got %d", len(entries)))
+}
+
+func Test_ResumeArchive(t *testing.T) {
+	store := testManyEndpointsStore(20)
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	err := CreateArchive(ctx, store, &buf, testDateRange, 3, 10)
+	th.Assert(t, err == nil, err)
+	complete := buf.String()
+
+	dir, err := ioutil.TempDir("", "archive")
+	th.Assert(t, err == nil, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "archive.json")
+
+	// the archive was interrupted part way through a summary, a separator and the array's start
+
+	cut := bytes.Index(buf.Bytes(), []byte("http://example.com/012/"))
+	for _, length := range []int{cut, cut - 40, 1, 0} {
+		err = ioutil.WriteFile(path, buf.Bytes()[:length], 0644)
+		th.Assert(t, err == nil, err)
+
+		err = ResumeArchive(ctx, store, path, testDateRange, 3, 10)
+		th.Assert(t, err == nil, err)
+		resumed, err := ioutil.ReadFile(path)
+		th.Assert(t, err == nil, err)
+		th.Assert(t, string(resumed) == complete, fmt.Sprintf("expected the archive resumed after %d bytes to be the complete archive, got %s", length, resumed))
+	}
+
+	// a complete archive is left as it is
+
+	err = ResumeArchive(ctx, store, path, testDateRange, 3, 10)
+	th.Assert(t, err == nil, err)
+	resumed, err := ioutil.ReadFile(path)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, string(resumed) == complete, "expected the complete archive to be unchanged")
+
+	// a file that is not an archive is not changed
+
+	err = ioutil.WriteFile(path, []byte(`{"url": "http://example.com/"}`), 0644)
+	th.Assert(t, err == nil, err)
+	err = ResumeArchive(ctx, store, path, testDateRange, 3, 10)
+	th.Assert(t, err != nil, "expected an error resuming a file that is not an archive")
+}
+
+func Test_ParseDateRange(t *testing.T) {
+	dateRange, err := ParseDateRange("2021-01-31", "2021-02-01")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, dateRange.Start.Equal(time.Date(2021, time.January, 31, 0, 0, 0, 0, time.UTC)), fmt.Sprintf("unexpected start %s", dateRange.Start))
+	th.Assert(t, dateRange.until().Equal(time.Date(2021, time.February, 2, 0, 0, 0, 0, time.UTC)), fmt.Sprintf("expected the range to include all of the end date, ends at %s", dateRange.until()))
+
+	// a single day
+	_, err = ParseDateRange("2021-01-31", "2021-01-31")
+	th.Assert(t, err == nil, err)
+
+	_, err = ParseDateRange("2021-02-01", "2021-01-31")
+	th.Assert(t, err != nil, "expected an error for a start date after the end date")
+	_, err = ParseDateRange("01/31/2021", "2021-02-01")
+	th.Assert(t, err != nil, "expected an error for a start date in the wrong format")
+	_, err = ParseDateRange("2021-01-31", "2021-02-31")
+	th.Assert(t, err != nil, "expected an error for an end date that does not exist")
+	_, err = ParseDateRange("2021-01-31'; DROP TABLE vendors; --", "2021-02-01")
+	th.Assert(t, err != nil, "expected an error for a start date that is not only a date")
+}
@@ -8,10 +8,29 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 )
 
+// CHPLStore stores the certification criteria, vendors and products gathered from CHPL.
+type CHPLStore interface {
+	endpointmanager.VendorStore
+	endpointmanager.ProductStore
+}
+
+// SyncStore stores the data gathered from CHPL along with a record of each sync.
+type SyncStore interface {
+	CHPLStore
+	endpointmanager.CHPLHistoryStore
+}
+
+// HarvestStore stores the endpoints harvested from the service base URL lists of the CHPL products.
+type HarvestStore interface {
+	endpointmanager.EndpointListStore
+	GetHealthITProductIDByCHPLID(ctx context.Context, CHPLID string) (int, error)
+}
+
 var chplDomain string = "https://chpl.healthit.gov"
 var chplAPIPath string = "/rest"
 
@@ -0,0 +1,135 @@
+package chplquerier
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// The CHPL collections that a Source provides.
+const (
+	ProductCollection  = "certified_products"
+	VendorCollection   = "vendors"
+	CriteriaCollection = "criteria"
+)
+
+// The modes of the CHPL fixture directory set by the chpl_fixture_mode configuration.
+const (
+	FixtureReplay = "replay"
+	FixtureRecord = "record"
+)
+
+// Source provides the JSON that CHPL returns for one of its collections: ProductCollection,
+// VendorCollection or CriteriaCollection.
+type Source interface {
+	GetJSON(ctx context.Context, client *http.Client, collection string, userAgent string) ([]byte, error)
+}
+
+// APISource requests the collections from the CHPL API. It requires the CHPL API key and network access.
+type APISource struct{}
+
+// FixtureSource reads the collections from a directory of recorded CHPL responses, one
+// chpl_<collection>.json file per collection, so that the CHPL querier can run without network access.
+type FixtureSource struct {
+	Dir string
+}
+
+// RecordingSource gets the collections from Source and saves each response to Dir in the format that
+// FixtureSource reads.
+type RecordingSource struct {
+	Source Source
+	Dir    string
+}
+
+// chplSource is the source that the querier functions use for CHPL responses.
+var chplSource Source = APISource{}
+
+// SetSource sets the source that the CHPL querier functions get their CHPL responses from.
+func SetSource(source Source) {
+	chplSource = source
+}
+
+// NewSourceFromConfig returns the source described by the chpl_fixture_dir and chpl_fixture_mode
+// configuration. If no fixture directory is configured, the CHPL API is used. In replay mode the
+// responses are read from the directory and in record mode the CHPL API responses are saved to it.
+func NewSourceFromConfig() (Source, error) {
+	dir := viper.GetString("chpl_fixture_dir")
+	if dir == "" {
+		return APISource{}, nil
+	}
+
+	mode := viper.GetString("chpl_fixture_mode")
+	switch mode {
+	case FixtureReplay:
+		log.Infof("reading CHPL responses from %s", dir)
+		return FixtureSource{Dir: dir}, nil
+	case FixtureRecord:
+		log.Infof("recording CHPL responses to %s", dir)
+		return RecordingSource{Source: APISource{}, Dir: dir}, nil
+	}
+	return nil, fmt.Errorf("unknown CHPL fixture mode %q, expected %q or %q", mode, FixtureReplay, FixtureRecord)
+}
+
+// GetJSON requests the collection from the CHPL API. As with the rest of the CHPL querier, request errors
+// are logged rather than returned.
+func (s APISource) GetJSON(ctx context.Context, client *http.Client, collection string, userAgent string) ([]byte, error) {
+	switch collection {
+	case ProductCollection:
+		return getProductJSON(ctx, client, userAgent)
+	case VendorCollection:
+		return getVendorJSON(ctx, client, userAgent)
+	case CriteriaCollection:
+		return getCriteriaJSON(ctx, client, userAgent)
+	}
+	return nil, fmt.Errorf("unknown CHPL collection %s", collection)
+}
+
+// GetJSON reads the recorded collection from the fixture directory.
+func (s FixtureSource) GetJSON(ctx context.Context, client *http.Client, collection string, userAgent string) ([]byte, error) {
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "unable to read the CHPL fixture - context ended")
+	default:
+		// ok
+	}
+
+	body, err := ioutil.ReadFile(fixturePath(s.Dir, collection))
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading the CHPL fixture for %s failed", collection)
+	}
+	return body, nil
+}
+
+// GetJSON gets the collection from the wrapped source and saves it to the fixture directory. Empty
+// responses, which the CHPL API source returns when a request fails, are not saved.
+func (s RecordingSource) GetJSON(ctx context.Context, client *http.Client, collection string, userAgent string) ([]byte, error) {
+	body, err := s.Source.GetJSON(ctx, client, collection, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) == 0 {
+		log.Warnf("not recording the empty CHPL response for %s", collection)
+		return body, nil
+	}
+
+	err = os.MkdirAll(s.Dir, 0755)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating the CHPL fixture directory failed")
+	}
+	err = ioutil.WriteFile(fixturePath(s.Dir, collection), body, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "recording the CHPL fixture for %s failed", collection)
+	}
+	return body, nil
+}
+
+func fixturePath(dir string, collection string) string {
+	return filepath.Join(dir, "chpl_"+collection+".json")
+}
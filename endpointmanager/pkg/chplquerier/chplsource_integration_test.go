@@ -0,0 +1,46 @@
+// +build integration
+
+package chplquerier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+	"github.com/spf13/viper"
+)
+
+func Test_GetCHPLFromFixtures(t *testing.T) {
+	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
+	defer teardown(t, store.DB)
+
+	SetSource(FixtureSource{Dir: "testdata"})
+	defer SetSource(APISource{})
+
+	// no API key or network access is needed
+	apiKey := viper.GetString("chplapikey")
+	viper.Set("chplapikey", "")
+	defer viper.Set("chplapikey", apiKey)
+
+	ctx := context.Background()
+	cli := &http.Client{}
+
+	err := GetCHPLCriteria(ctx, store, cli, "")
+	th.Assert(t, err == nil, err)
+	err = GetCHPLVendors(ctx, store, cli, "")
+	th.Assert(t, err == nil, err)
+	err = GetCHPLProducts(ctx, store, cli, "")
+	th.Assert(t, err == nil, err)
+
+	var ct int
+	err = store.DB.QueryRow("SELECT COUNT(*) FROM certification_criteria;").Scan(&ct)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, ct == 182, fmt.Sprintf("Expected 182 criteria stored. Actually had %d criteria stored.", ct))
+
+	// the fixture includes 201 product entries, but w duplicates, the number stored is 168.
+	err = store.DB.QueryRow("SELECT COUNT(*) FROM healthit_products;").Scan(&ct)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, ct == 168, fmt.Sprintf("Expected 168 products stored. Actually had %d products stored.", ct))
+}
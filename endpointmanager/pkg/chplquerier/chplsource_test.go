@@ -0,0 +1,116 @@
+package chplquerier
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+	"github.com/spf13/viper"
+)
+
+type mockSource struct {
+	body []byte
+}
+
+func (s mockSource) GetJSON(ctx context.Context, client *http.Client, collection string, userAgent string) ([]byte, error) {
+	return s.body, nil
+}
+
+func Test_FixtureSource(t *testing.T) {
+	source := FixtureSource{Dir: "testdata"}
+	ctx := context.Background()
+
+	// basic test
+
+	prodJSON, err := source.GetJSON(ctx, nil, ProductCollection, "")
+	th.Assert(t, err == nil, err)
+	prods, err := convertProductJSONToObj(ctx, prodJSON)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(prods.Results) == 201, fmt.Sprintf("Expected to read 201 products. Read %d products.", len(prods.Results)))
+
+	vendorJSON, err := source.GetJSON(ctx, nil, VendorCollection, "")
+	th.Assert(t, err == nil, err)
+	vendors, err := convertVendorJSONToObj(ctx, vendorJSON)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(vendors.Developers) > 0, "Expected to read vendors")
+
+	critJSON, err := source.GetJSON(ctx, nil, CriteriaCollection, "")
+	th.Assert(t, err == nil, err)
+	crits, err := convertCriteriaJSONToObj(ctx, critJSON)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(crits.Results) == 182, fmt.Sprintf("Expected to read 182 criteria. Read %d criteria.", len(crits.Results)))
+
+	// test missing fixture
+
+	_, err = FixtureSource{Dir: "nonexistent"}.GetJSON(ctx, nil, ProductCollection, "")
+	th.Assert(t, err != nil, "Expected an error due to the missing fixture")
+
+	// test context ended
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = source.GetJSON(ctx, nil, ProductCollection, "")
+	th.Assert(t, err != nil, "Expected an error due to the canceled context")
+}
+
+func Test_RecordingSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chplfixtures")
+	th.Assert(t, err == nil, err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	body := []byte(`{"criteria": []}`)
+
+	// basic test, including a fixture directory that does not exist yet
+
+	fixtureDir := filepath.Join(dir, "fixtures")
+	actual, err := RecordingSource{Source: mockSource{body: body}, Dir: fixtureDir}.GetJSON(ctx, nil, CriteriaCollection, "")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, string(actual) == string(body), fmt.Sprintf("Expected the body %s. Got %s", body, actual))
+
+	replayed, err := FixtureSource{Dir: fixtureDir}.GetJSON(ctx, nil, CriteriaCollection, "")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, string(replayed) == string(body), fmt.Sprintf("Expected the recorded body %s. Got %s", body, replayed))
+
+	// test empty responses are not recorded
+
+	_, err = RecordingSource{Source: mockSource{}, Dir: fixtureDir}.GetJSON(ctx, nil, VendorCollection, "")
+	th.Assert(t, err == nil, err)
+	_, err = os.Stat(filepath.Join(fixtureDir, "chpl_vendors.json"))
+	th.Assert(t, os.IsNotExist(err), "Expected the empty response not to be recorded")
+}
+
+func Test_NewSourceFromConfig(t *testing.T) {
+	fixtureDir := viper.GetString("chpl_fixture_dir")
+	fixtureMode := viper.GetString("chpl_fixture_mode")
+	defer viper.Set("chpl_fixture_dir", fixtureDir)
+	defer viper.Set("chpl_fixture_mode", fixtureMode)
+
+	viper.Set("chpl_fixture_dir", "")
+	source, err := NewSourceFromConfig()
+	th.Assert(t, err == nil, err)
+	_, ok := source.(APISource)
+	th.Assert(t, ok, "Expected the CHPL API source when there is no fixture directory")
+
+	viper.Set("chpl_fixture_dir", "testdata")
+	viper.Set("chpl_fixture_mode", FixtureReplay)
+	source, err = NewSourceFromConfig()
+	th.Assert(t, err == nil, err)
+	fixtureSource, ok := source.(FixtureSource)
+	th.Assert(t, ok && fixtureSource.Dir == "testdata", "Expected the fixture source")
+
+	viper.Set("chpl_fixture_mode", FixtureRecord)
+	source, err = NewSourceFromConfig()
+	th.Assert(t, err == nil, err)
+	recordingSource, ok := source.(RecordingSource)
+	th.Assert(t, ok && recordingSource.Dir == "testdata", "Expected the recording source")
+
+	viper.Set("chpl_fixture_mode", "other")
+	_, err = NewSourceFromConfig()
+	th.Assert(t, err != nil, "Expected an error due to the unknown mode")
+}
@@ -0,0 +1,66 @@
+package chplquerier
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+)
+
+// SyncCHPL queries CHPL for its certification criteria, vendors and products, stores them in 'store' and returns
+// the changes the sync made to the stored data. When 'modifiedOnly' is true, only the vendors and products that
+// CHPL modified since the previous completed sync started are stored; the certification criteria do not have a
+// last modified date and are always stored. CHPL cannot filter its collections by last modified date, so the full
+// collections are still downloaded and filtered here. If there is no previous completed sync, a full sync is
+// performed.
+func SyncCHPL(ctx context.Context, store SyncStore, cli *http.Client, userAgent string, modifiedOnly bool) ([]endpointmanager.CHPLChange, error) {
+	mode := endpointmanager.CHPLSyncFull
+	var modifiedSince time.Time
+	if modifiedOnly {
+		previous, err := store.GetLastCompletedCHPLSync(ctx)
+		if err == sql.ErrNoRows {
+			log.Info("no previous CHPL sync found; performing a full sync")
+		} else if err != nil {
+			return nil, errors.Wrap(err, "getting the previous CHPL sync failed")
+		} else {
+			mode = endpointmanager.CHPLSyncModified
+			modifiedSince = previous.StartedAt
+		}
+	}
+
+	sync, err := store.AddCHPLSync(ctx, mode)
+	if err != nil {
+		return nil, errors.Wrap(err, "recording the start of the CHPL sync failed")
+	}
+	log.Infof("starting %s CHPL sync", mode)
+
+	err = GetCHPLCriteria(ctx, store, cli, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	err = getCHPLVendorsModifiedSince(ctx, store, cli, userAgent, modifiedSince)
+	if err != nil {
+		return nil, err
+	}
+	err = getCHPLProductsModifiedSince(ctx, store, cli, userAgent, modifiedSince)
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.CompleteCHPLSync(ctx, sync)
+	if err != nil {
+		return nil, errors.Wrap(err, "recording the end of the CHPL sync failed")
+	}
+
+	changes, err := store.GetCHPLChangesSince(ctx, sync.StartedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the changes made by the CHPL sync failed")
+	}
+	log.Infof("%s CHPL sync made %d changes", mode, len(changes))
+	return changes, nil
+}
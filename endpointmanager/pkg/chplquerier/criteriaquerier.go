@@ -11,7 +11,6 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
-	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
 )
 
 var chplAPICertCriteriaPath string = "/data/certification-criteria"
@@ -33,9 +32,9 @@ type chplCertCriteria struct {
 
 // GetCHPLCriteria queries CHPL for its certification criteria using 'cli' and stores the criteria in 'store'
 // within the given context 'ctx'.
-func GetCHPLCriteria(ctx context.Context, store *postgresql.Store, cli *http.Client, userAgent string) error {
+func GetCHPLCriteria(ctx context.Context, store CHPLStore, cli *http.Client, userAgent string) error {
 	log.Debug("requesting certification criteria from CHPL")
-	critJSON, err := getCriteriaJSON(ctx, cli, userAgent)
+	critJSON, err := chplSource.GetJSON(ctx, cli, CriteriaCollection, userAgent)
 	if err != nil {
 		return err
 	}
@@ -102,7 +101,7 @@ func convertCriteriaJSONToObj(ctx context.Context, critJSON []byte) (*chplCertif
 }
 
 // takes the JSON model and converts it into an endpointmanager.CertificationCriteria
-func parseHITCriteria(criteria *chplCertCriteria, store *postgresql.Store) (*endpointmanager.CertificationCriteria, error) {
+func parseHITCriteria(criteria *chplCertCriteria, store CHPLStore) (*endpointmanager.CertificationCriteria, error) {
 
 	dbCrit := endpointmanager.CertificationCriteria{
 		CertificationID:        criteria.ID,
@@ -118,7 +117,7 @@ func parseHITCriteria(criteria *chplCertCriteria, store *postgresql.Store) (*end
 }
 
 // persists the criteria parsed from CHPL
-func persistCriterias(ctx context.Context, store *postgresql.Store, critList *chplCertifiedCriteriaList) error {
+func persistCriterias(ctx context.Context, store CHPLStore, critList *chplCertifiedCriteriaList) error {
 	for i, criteria := range critList.Results {
 
 		select {
@@ -144,7 +143,7 @@ func persistCriterias(ctx context.Context, store *postgresql.Store, critList *ch
 // adds a certification criteria to the store if that criteria's ID does not already exist.
 // if it does, update the entry
 func persistCriteria(ctx context.Context,
-	store *postgresql.Store,
+	store CHPLStore,
 	criteria *chplCertCriteria) error {
 
 	newDbCrit, err := parseHITCriteria(criteria, store)
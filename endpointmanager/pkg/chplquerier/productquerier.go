@@ -15,14 +15,13 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
-	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
 )
 
 var chplAPICertProdListPath string = "/collections/certified_products"
 var delimiter1 string = "☺"
 var delimiter2 string = "☹"
 
-var fields [11]string = [11]string{
+var fields [13]string = [13]string{
 	"id",
 	"edition",
 	"developer",
@@ -33,7 +32,9 @@ var fields [11]string = [11]string{
 	"criteriaMet",
 	"apiDocumentation",
 	"certificationDate",
-	"practiceType"}
+	"practiceType",
+	"lastModifiedDate",
+	"serviceBaseUrlList"}
 
 type chplCertifiedProductList struct {
 	Results []chplCertifiedProduct `json:"results"`
@@ -51,14 +52,23 @@ type chplCertifiedProduct struct {
 	CertificationStatus string `json:"certificationStatus"`
 	CriteriaMet         string `json:"criteriaMet"`
 	APIDocumentation    string `json:"apiDocumentation"`
+	LastModifiedDate    int64  `json:"lastModifiedDate"`
+	ServiceBaseURLList  string `json:"serviceBaseUrlList"`
 }
 
 // GetCHPLProducts queries CHPL for its HealthIT products using 'cli' and stores the products in 'store'
 // within the given context 'ctx'.
-func GetCHPLProducts(ctx context.Context, store *postgresql.Store, cli *http.Client, userAgent string) error {
+func GetCHPLProducts(ctx context.Context, store CHPLStore, cli *http.Client, userAgent string) error {
+	return getCHPLProductsModifiedSince(ctx, store, cli, userAgent, time.Time{})
+}
+
+// getCHPLProductsModifiedSince queries CHPL for its HealthIT products and stores the products that CHPL modified
+// at or after 'modifiedSince'. All of the products are stored if 'modifiedSince' is the zero time.
+func getCHPLProductsModifiedSince(ctx context.Context, store CHPLStore, cli *http.Client, userAgent string, modifiedSince time.Time) error {
 	log.Debug("requesting products from CHPL")
-	prodJSON, err := getProductJSON(ctx, cli, userAgent)
+	prodJSON, err := chplSource.GetJSON(ctx, cli, ProductCollection, userAgent)
 	if err != nil {
+		log.Warn(err)
 		return nil
 	}
 	log.Debug("done requesting products from CHPL")
@@ -70,6 +80,12 @@ func GetCHPLProducts(ctx context.Context, store *postgresql.Store, cli *http.Cli
 	}
 	log.Debug("done converting chpl json into product objects")
 
+	if !modifiedSince.IsZero() {
+		total := len(prodList.Results)
+		prodList = filterProductsModifiedSince(prodList, modifiedSince)
+		log.Infof("%d of %d chpl products were modified since %s", len(prodList.Results), total, modifiedSince.Format(time.RFC3339))
+	}
+
 	log.Debug("persisting chpl products")
 	err = persistProducts(ctx, store, prodList)
 	log.Debug("done persisting chpl products")
@@ -124,8 +140,20 @@ func convertProductJSONToObj(ctx context.Context, prodJSON []byte) (*chplCertifi
 	return &prodList, nil
 }
 
+// filterProductsModifiedSince returns the products that CHPL modified at or after 'modifiedSince'. Products
+// without a last modified date are kept because it is not known whether they changed.
+func filterProductsModifiedSince(prodList *chplCertifiedProductList, modifiedSince time.Time) *chplCertifiedProductList {
+	var modified chplCertifiedProductList
+	for _, prod := range prodList.Results {
+		if prod.LastModifiedDate == 0 || !time.Unix(prod.LastModifiedDate/1000, 0).Before(modifiedSince) {
+			modified.Results = append(modified.Results, prod)
+		}
+	}
+	return &modified
+}
+
 // takes the JSON model and converts it into an endpointmanager.HealthITProduct
-func parseHITProd(ctx context.Context, prod *chplCertifiedProduct, store *postgresql.Store) (*endpointmanager.HealthITProduct, error) {
+func parseHITProd(ctx context.Context, prod *chplCertifiedProduct, store CHPLStore) (*endpointmanager.HealthITProduct, error) {
 	id, err := getProductVendorID(ctx, prod, store)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting the product's vendor id failed")
@@ -153,6 +181,10 @@ func parseHITProd(ctx context.Context, prod *chplCertifiedProduct, store *postgr
 		CHPLID:                prod.ChplProductNumber,
 		CertificationCriteria: criteriaIDs,
 	}
+	// last_modified_in_chpl is a date so the time of day is dropped to compare it with the stored product
+	if prod.LastModifiedDate != 0 {
+		dbProd.LastModifiedInCHPL = time.Unix(prod.LastModifiedDate/1000, 0).UTC().Truncate(24 * time.Hour)
+	}
 
 	apiURL, err := getAPIURL(prod.APIDocumentation)
 	if err != nil {
@@ -164,7 +196,7 @@ func parseHITProd(ctx context.Context, prod *chplCertifiedProduct, store *postgr
 }
 
 // returns 0 if no match found.
-func getProductVendorID(ctx context.Context, prod *chplCertifiedProduct, store *postgresql.Store) (int, error) {
+func getProductVendorID(ctx context.Context, prod *chplCertifiedProduct, store CHPLStore) (int, error) {
 	vendor, err := store.GetVendorUsingName(ctx, prod.Developer)
 	if err == sql.ErrNoRows {
 		log.Warnf("no vendor match for product %s with vendor %s", prod.Product, prod.Developer)
@@ -203,7 +235,7 @@ func getAPIURL(apiDocStr string) (string, error) {
 // persists the products parsed from CHPL. Of note, CHPL includes many entries for a single product. The entry
 // associated with the most recent certifition edition, most recent certification date, or most criteria is the
 // one that is stored.
-func persistProducts(ctx context.Context, store *postgresql.Store, prodList *chplCertifiedProductList) error {
+func persistProducts(ctx context.Context, store CHPLStore, prodList *chplCertifiedProductList) error {
 	for i, prod := range prodList.Results {
 
 		select {
@@ -230,7 +262,7 @@ func persistProducts(ctx context.Context, store *postgresql.Store, prodList *chp
 // exist, determine if it makes sense to update the product (certified to more recent edition, certified at a
 // later date, has more certification criteria), or not.
 func persistProduct(ctx context.Context,
-	store *postgresql.Store,
+	store CHPLStore,
 	prod *chplCertifiedProduct) error {
 
 	newDbProd, err := parseHITProd(ctx, prod, store)
@@ -292,13 +324,14 @@ func persistProduct(ctx context.Context,
 // determines if a product needs to be udpated.
 //
 // if the two products are equal, do not update.
+// else if the certification edition and date are the same and the products are the same CHPL listing, update.
 // else if the new product has a more recent certification edition than the exisitng product, update.
 // else if the new product has a more recent certification date than the exisitng product, update.
 // else if the new product has more certification criteria than the existing product, update.
 //
 // throws errors if
 // - the certification edition is not a year
-// - the certification criteria list is the same length but not equal
+// - the two products are different CHPL listings with the same certification edition and date
 // - the two products are not equal but their differences don't fall into the categories noted above.
 func prodNeedsUpdate(existingDbProd *endpointmanager.HealthITProduct, newDbProd *endpointmanager.HealthITProduct) (bool, error) {
 	// check if the two are equal.
@@ -318,6 +351,10 @@ func prodNeedsUpdate(existingDbProd *endpointmanager.HealthITProduct, newDbProd
 	}
 
 	if newCertEdition == existingCertEdition && existingDbProd.CertificationDate == newDbProd.CertificationDate {
+		// the same listing changed in CHPL, for example its certification status or API URL.
+		if existingDbProd.CHPLID == newDbProd.CHPLID {
+			return true, nil
+		}
 		// cert dates are the same. unknown update precedence. throw error and don't perform update.
 		return false, fmt.Errorf("HealthITProducts certification edition and date are equal; unknown precendence for updates; not performing update: %s:%s to %s:%s", existingDbProd.Name, existingDbProd.CHPLID, newDbProd.Name, newDbProd.CHPLID)
 	}
@@ -336,6 +373,11 @@ func prodNeedsUpdate(existingDbProd *endpointmanager.HealthITProduct, newDbProd
 		return false, nil
 	}
 
+	// the same listing changed in CHPL, for example its certification status or API URL.
+	if existingDbProd.CHPLID == newDbProd.CHPLID {
+		return true, nil
+	}
+
 	// cert dates are the same. unknown update precedence. throw error and don't perform update.
 	return false, fmt.Errorf("HealthITProducts certification edition and date are equal; unknown precendence for updates; not performing update: %s:%s to %s:%s", existingDbProd.Name, existingDbProd.CHPLID, newDbProd.Name, newDbProd.CHPLID)
 }
@@ -343,7 +385,7 @@ func prodNeedsUpdate(existingDbProd *endpointmanager.HealthITProduct, newDbProd
 // linkProductToCriteria checks whether the product and certification have been linked before, and if not
 // links them
 func linkProductToCriteria(ctx context.Context,
-	store *postgresql.Store,
+	store CHPLStore,
 	critID int,
 	prodID int) error {
 	_, _, _, err := store.GetProductCriteriaLink(ctx, critID, prodID)
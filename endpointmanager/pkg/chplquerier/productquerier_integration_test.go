@@ -156,10 +156,22 @@ func Test_persistProduct(t *testing.T) {
 	// check that ambiguous update throws error
 	prod = testCHPLProd
 	prod.Edition = "2015" // same date as what is in store
+	// a different listing
+	prod.ChplProductNumber = "15.04.04.2657.Care.01.00.0.160733"
 	prod.CertificationStatus = "Retired"
 	err = persistProduct(ctx, store, &prod)
 	th.Assert(t, err != nil, "expected error updating product")
 
+	// check that a change to the same listing replaces item
+	prod = testCHPLProd
+	prod.Edition = "2015" // same date as what is in store
+	prod.CertificationStatus = "Retired"
+	err = persistProduct(ctx, store, &prod)
+	th.Assert(t, err == nil, err)
+	storedHitp, err = store.GetHealthITProductUsingNameAndVersion(ctx, "Carefluence Open API", "1")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, storedHitp.CertificationStatus == "Retired", "expected the certification status of the listing to be updated")
+
 	// check that error adding to store throws error
 	prod = testCHPLProd
 	prod.Product = "A new product"
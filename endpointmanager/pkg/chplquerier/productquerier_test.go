@@ -50,7 +50,7 @@ func Test_makeCHPLProductURL(t *testing.T) {
 	viper.Set("chplapikey", "tmp_api_key")
 	defer viper.Set("chplapikey", apiKey)
 
-	expected := "https://chpl.healthit.gov/rest/collections/certified_products?api_key=tmp_api_key&fields=id%2Cedition%2Cdeveloper%2Cproduct%2Cversion%2CchplProductNumber%2CcertificationStatus%2CcriteriaMet%2CapiDocumentation%2CcertificationDate%2CpracticeType"
+	expected := "https://chpl.healthit.gov/rest/collections/certified_products?api_key=tmp_api_key&fields=id%2Cedition%2Cdeveloper%2Cproduct%2Cversion%2CchplProductNumber%2CcertificationStatus%2CcriteriaMet%2CapiDocumentation%2CcertificationDate%2CpracticeType%2ClastModifiedDate%2CserviceBaseUrlList"
 
 	actualURL, err := makeCHPLProductURL()
 	th.Assert(t, err == nil, err)
@@ -233,7 +233,7 @@ func Test_prodNeedsUpdate(t *testing.T) {
 
 	critListShorter := testHITP
 	critListShorter.CertificationCriteria = []int{30, 31, 32, 33, 34, 35, 36, 37}
-	expectedResults = append(expectedResults, expectedResult{name: "critListShorter", hitProd: critListShorter, needsUpdate: false, err: fmt.Errorf("HealthITProducts certification edition and date are equal; unknown precendence for updates; not performing update: %s:%s to %s:%s", testHITP.Name, testHITP.CHPLID, testHITP.Name, testHITP.CHPLID)})
+	expectedResults = append(expectedResults, expectedResult{name: "critListShorter", hitProd: critListShorter, needsUpdate: true, err: nil})
 
 	chplID := testHITP
 	chplID.CHPLID = "15.04.04.2657.Care.01.00.0.160733"
@@ -241,7 +241,16 @@ func Test_prodNeedsUpdate(t *testing.T) {
 
 	certStatus := testHITP
 	certStatus.CertificationStatus = "Retired"
-	expectedResults = append(expectedResults, expectedResult{name: "certStatus", hitProd: certStatus, needsUpdate: false, err: fmt.Errorf("HealthITProducts certification edition and date are equal; unknown precendence for updates; not performing update: %s:%s to %s:%s", testHITP.Name, testHITP.CHPLID, testHITP.Name, testHITP.CHPLID)})
+	expectedResults = append(expectedResults, expectedResult{name: "certStatus", hitProd: certStatus, needsUpdate: true, err: nil})
+
+	apiURL := testHITP
+	apiURL.APIURL = "http://carefluence.com/Carefluence-OpenAPI-Documentation-v2.html"
+	expectedResults = append(expectedResults, expectedResult{name: "apiURL", hitProd: apiURL, needsUpdate: true, err: nil})
+
+	otherListing := testHITP
+	otherListing.CHPLID = "15.04.04.2657.Care.01.00.0.160733"
+	otherListing.CertificationStatus = "Retired"
+	expectedResults = append(expectedResults, expectedResult{name: "otherListing", hitProd: otherListing, needsUpdate: false, err: fmt.Errorf("HealthITProducts certification edition and date are equal; unknown precendence for updates; not performing update: %s:%s to %s:%s", testHITP.Name, testHITP.CHPLID, testHITP.Name, otherListing.CHPLID)})
 
 	for _, expRes := range expectedResults {
 		needsUpdate, err := prodNeedsUpdate(&base, &(expRes.hitProd))
@@ -273,6 +282,23 @@ func Test_prodNeedsUpdate(t *testing.T) {
 	th.Assert(t, origErr.Error() == expectedErrorStr, fmt.Sprintf("For 'prodNeedsUpdate' using %s, expected error\n%v\nAnd got error\n%v", name, expectedErrorStr, origErr))
 }
 
+func Test_filterProductsModifiedSince(t *testing.T) {
+	modifiedProd := testCHPLProd
+	modifiedProd.ID = 1
+	modifiedProd.LastModifiedDate = time.Date(2020, time.March, 2, 0, 0, 0, 0, time.UTC).Unix() * 1000
+	oldProd := testCHPLProd
+	oldProd.ID = 2
+	oldProd.LastModifiedDate = time.Date(2020, time.February, 2, 0, 0, 0, 0, time.UTC).Unix() * 1000
+	noDateProd := testCHPLProd
+	noDateProd.ID = 3
+	prodList := chplCertifiedProductList{Results: []chplCertifiedProduct{modifiedProd, oldProd, noDateProd}}
+
+	modified := filterProductsModifiedSince(&prodList, time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC))
+	th.Assert(t, len(modified.Results) == 2, fmt.Sprintf("expected 2 products to be modified. Got %d", len(modified.Results)))
+	th.Assert(t, modified.Results[0].ID == modifiedProd.ID, "expected the product modified in March to be kept")
+	th.Assert(t, modified.Results[1].ID == noDateProd.ID, "expected the product without a last modified date to be kept")
+}
+
 func Test_getProductJSON(t *testing.T) {
 	var err error
 	var tc *th.TestClient
@@ -0,0 +1,180 @@
+package chplquerier
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/fetcher"
+	endptQuerier "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/fhirendpointquerier"
+)
+
+// serviceBaseURLList is the service base URL list published for a CHPL listing
+type serviceBaseURLList struct {
+	CHPLID string
+	URL    string
+}
+
+// HarvestServiceBaseURLLists queries CHPL for the service base URL list of each stored product certified to
+// 170.315 (g)(10), requests each list and adds its endpoints to 'store' with the product's CHPL ID as the list
+// source. The lists may be FHIR Bundles, vendor JSON or CSV. A list that can not be requested or parsed is
+// skipped and the endpoints previously added from it are kept.
+func HarvestServiceBaseURLLists(ctx context.Context, store HarvestStore, cli *http.Client, userAgent string) error {
+	log.Debug("requesting products from CHPL")
+	prodJSON, err := chplSource.GetJSON(ctx, cli, ProductCollection, userAgent)
+	if err != nil {
+		return err
+	}
+	prodList, err := convertProductJSONToObj(ctx, prodJSON)
+	if err != nil {
+		return errors.Wrap(err, "converting health IT product JSON into a 'chplCertifiedProductList' object failed")
+	}
+
+	lists := getServiceBaseURLLists(prodList)
+	log.Infof("%d chpl products have a service base URL list", len(lists))
+
+	// vendors often publish one list for all of their products. Requesting the lists in URL order lets each
+	// list be requested once while only the current list's response is kept.
+	sort.SliceStable(lists, func(i, j int) bool {
+		return lists[i].URL < lists[j].URL
+	})
+
+	var currentURL string
+	var currentBody []byte
+	var currentErr error
+	harvested := 0
+	for i, list := range lists {
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(ctx.Err(), "harvested %d out of %d service base URL lists before context ended", i, len(lists))
+		default:
+			// ok
+		}
+
+		_, err = store.GetHealthITProductIDByCHPLID(ctx, list.CHPLID)
+		if err == sql.ErrNoRows {
+			log.Debugf("skipping the service base URL list for %s, which is not a stored product", list.CHPLID)
+			continue
+		} else if err != nil {
+			return errors.Wrapf(err, "getting the product with CHPL ID %s failed", list.CHPLID)
+		}
+
+		if list.URL != currentURL {
+			currentURL = list.URL
+			currentBody, currentErr = getServiceBaseURLListJSON(ctx, cli, list.URL, userAgent)
+		}
+		if currentErr != nil {
+			log.Warnf("requesting the service base URL list %s for %s failed: %s", list.URL, list.CHPLID, currentErr)
+			continue
+		}
+
+		err = persistServiceBaseURLList(ctx, store, list, currentBody)
+		if err != nil {
+			log.Warnf("storing the service base URL list %s for %s failed: %s", list.URL, list.CHPLID, err)
+			continue
+		}
+		harvested++
+	}
+
+	log.Infof("harvested %d out of %d service base URL lists", harvested, len(lists))
+	return nil
+}
+
+// persistServiceBaseURLList parses the list and adds its endpoints to the store. If the list is empty, the
+// endpoints previously added from it are removed.
+func persistServiceBaseURLList(ctx context.Context, store HarvestStore, list serviceBaseURLList, body []byte) error {
+	listOfEndpoints, err := fetcher.GetListOfEndpointsDetectFormat(body, list.CHPLID)
+	if err != nil {
+		return errors.Wrap(err, "parsing the service base URL list failed")
+	}
+
+	if len(listOfEndpoints.Entries) == 0 {
+		return endptQuerier.RemoveOldEndpoints(ctx, store, time.Now().Add(time.Hour*24), list.CHPLID)
+	}
+	return endptQuerier.AddEndpointData(ctx, store, &listOfEndpoints)
+}
+
+// getServiceBaseURLLists returns the service base URL list of each listing that has one, ordered by CHPL ID.
+func getServiceBaseURLLists(prodList *chplCertifiedProductList) []serviceBaseURLList {
+	var lists []serviceBaseURLList
+	for _, prod := range prodList.Results {
+		listURL, err := getServiceBaseURL(prod.ServiceBaseURLList)
+		if err != nil {
+			log.Warnf("error in CHPL data for %s: %s", prod.ChplProductNumber, err)
+			continue
+		}
+		if listURL == "" || prod.ChplProductNumber == "" {
+			continue
+		}
+		lists = append(lists, serviceBaseURLList{CHPLID: prod.ChplProductNumber, URL: listURL})
+	}
+	sort.SliceStable(lists, func(i, j int) bool {
+		return lists[i].CHPLID < lists[j].CHPLID
+	})
+	return lists
+}
+
+// parses 'serviceBaseURLStr' to extract the URL of the service base URL list published for the (g)(10)
+// criteria. Returns an empty string if there is none.
+// uses the same format as the API documentation string, see getAPIURL.
+func getServiceBaseURL(serviceBaseURLStr string) (string, error) {
+	if len(serviceBaseURLStr) == 0 {
+		return "", nil
+	}
+
+	for _, chunk := range strings.Split(serviceBaseURLStr, delimiter1) {
+		critAndURL := strings.Split(chunk, delimiter2)
+		if len(critAndURL) != 2 {
+			return "", errors.New("unexpected format for service base URL list string")
+		}
+		if strings.TrimSpace(critAndURL[0]) != endpointmanager.StandardizedAPICriteria {
+			continue
+		}
+		listURL := strings.TrimSpace(critAndURL[1])
+		// check that it's a valid URL
+		_, err := url.ParseRequestURI(listURL)
+		if err != nil {
+			return "", errors.Wrap(err, "the URL in the service base URL list string is not valid")
+		}
+		return listURL, nil
+	}
+
+	return "", nil
+}
+
+// requests the service base URL list and returns the response body
+func getServiceBaseURLListJSON(ctx context.Context, client *http.Client, listURL string, userAgent string) ([]byte, error) {
+	req, err := http.NewRequest("GET", listURL, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating http request failed")
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/fhir+json, application/json, text/csv;q=0.9, */*;q=0.8")
+	req = req.WithContext(ctx)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "making the GET request to the service base URL list failed")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("service base URL list request responded with status: " + resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading the service base URL list response body failed")
+	}
+
+	return body, nil
+}
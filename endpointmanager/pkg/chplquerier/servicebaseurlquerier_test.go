@@ -0,0 +1,157 @@
+package chplquerier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/memorystore"
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+)
+
+func Test_getServiceBaseURL(t *testing.T) {
+	// basic test
+
+	serviceBaseURLStr := "170.315 (g)(10)☹https://example.com/fhir/endpoints.json"
+	expected := "https://example.com/fhir/endpoints.json"
+	actual, err := getServiceBaseURL(serviceBaseURLStr)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, actual == expected, fmt.Sprintf("Expected '%s' to equal '%s'", actual, expected))
+
+	// test the (g)(10) URL is chosen over other criteria
+
+	serviceBaseURLStr = "170.315 (g)(9)☹https://example.com/docs☺170.315 (g)(10)☹https://example.com/fhir/endpoints.csv"
+	expected = "https://example.com/fhir/endpoints.csv"
+	actual, err = getServiceBaseURL(serviceBaseURLStr)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, actual == expected, fmt.Sprintf("Expected '%s' to equal '%s'", actual, expected))
+
+	// test no (g)(10) URL
+
+	actual, err = getServiceBaseURL("170.315 (g)(9)☹https://example.com/docs")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, actual == "", fmt.Sprintf("Expected an empty URL. Got '%s'", actual))
+
+	// test empty string
+
+	actual, err = getServiceBaseURL("")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, actual == "", fmt.Sprintf("Expected an empty URL. Got '%s'", actual))
+
+	// test bad url
+
+	_, err = getServiceBaseURL("170.315 (g)(10)☹example.com/fhir/endpoints.json")
+	th.Assert(t, err != nil, "Expected an error due to the invalid URL")
+
+	// test bad format
+
+	_, err = getServiceBaseURL("https://example.com/fhir/endpoints.json")
+	th.Assert(t, err != nil, "Expected an error due to the missing criteria")
+}
+
+func Test_getServiceBaseURLLists(t *testing.T) {
+	prodList := chplCertifiedProductList{Results: []chplCertifiedProduct{
+		{ChplProductNumber: "15.04.04.2657.Care.01.00.0.200101", ServiceBaseURLList: "170.315 (g)(10)☹https://example.com/b.json"},
+		{ChplProductNumber: "15.04.04.2657.Care.01.00.0.160701"},
+		{ChplProductNumber: "15.04.04.1111.Care.01.00.0.200101", ServiceBaseURLList: "170.315 (g)(10)☹https://example.com/a.json"},
+		{ChplProductNumber: "15.04.04.2222.Care.01.00.0.200101", ServiceBaseURLList: "170.315 (g)(10)☹not a url"},
+	}}
+
+	lists := getServiceBaseURLLists(&prodList)
+	th.Assert(t, len(lists) == 2, fmt.Sprintf("Expected 2 service base URL lists. Got %d", len(lists)))
+	th.Assert(t, lists[0].CHPLID == "15.04.04.1111.Care.01.00.0.200101", "Expected the lists to be ordered by CHPL ID")
+	th.Assert(t, lists[0].URL == "https://example.com/a.json", fmt.Sprintf("Expected the list URL https://example.com/a.json. Got %s", lists[0].URL))
+	th.Assert(t, lists[1].CHPLID == "15.04.04.2657.Care.01.00.0.200101", "Expected the lists to be ordered by CHPL ID")
+}
+
+func Test_getServiceBaseURLListJSON(t *testing.T) {
+	body := []byte(`{"resourceType": "Bundle", "entry": []}`)
+
+	// basic test
+
+	var userAgent string
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		_, _ = w.Write(body)
+	})
+	tc := th.NewTestClientNoTLS(h)
+	defer tc.Close()
+
+	actual, err := getServiceBaseURLListJSON(context.Background(), &(tc.Client), "http://example.com/endpoints.json", "LANTERN")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, string(actual) == string(body), fmt.Sprintf("Expected the body %s. Got %s", body, actual))
+	th.Assert(t, userAgent == "LANTERN", fmt.Sprintf("Expected the user agent LANTERN. Got %s", userAgent))
+
+	// test http status != 200
+
+	h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "sample 404 error", http.StatusNotFound)
+	})
+	tc404 := th.NewTestClientNoTLS(h)
+	defer tc404.Close()
+
+	_, err = getServiceBaseURLListJSON(context.Background(), &(tc404.Client), "http://example.com/endpoints.json", "LANTERN")
+	th.Assert(t, err != nil, "Expected an error due to the 404 response")
+
+	// test context ended
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = getServiceBaseURLListJSON(ctx, &(tc.Client), "http://example.com/endpoints.json", "LANTERN")
+	th.Assert(t, err != nil, "Expected an error due to the canceled context")
+}
+
+// productSource is a Source that returns 'products' for the product collection
+type productSource struct {
+	products []byte
+}
+
+func (ps productSource) GetJSON(ctx context.Context, client *http.Client, collection string, userAgent string) ([]byte, error) {
+	return ps.products, nil
+}
+
+func Test_HarvestServiceBaseURLLists(t *testing.T) {
+	defer SetSource(chplSource)
+	SetSource(productSource{products: []byte(`{"results": [
+		{"chplProductNumber": "15.04.04.1111.Care.01.00.0.200101", "serviceBaseUrlList": "170.315 (g)(10)☹http://example.com/missing.json"},
+		{"chplProductNumber": "15.04.04.2222.Care.01.00.0.200101", "serviceBaseUrlList": "170.315 (g)(10)☹http://example.com/endpoints.json"},
+		{"chplProductNumber": "15.04.04.3333.Care.01.00.0.200101", "serviceBaseUrlList": "170.315 (g)(10)☹http://example.com/missing.json"},
+		{"chplProductNumber": "15.04.04.4444.Care.01.00.0.200101", "serviceBaseUrlList": "170.315 (g)(10)☹http://example.com/endpoints.json"}
+	]}`)})
+
+	requests := make(map[string]int)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		if r.URL.Path != "/endpoints.json" {
+			http.Error(w, "sample 404 error", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"resourceType": "Bundle", "entry": [{"resource": {"resourceType": "Endpoint", "address": "http://example.com/fhir/"}}]}`))
+	})
+	tc := th.NewTestClientNoTLS(h)
+	defer tc.Close()
+
+	ctx := context.Background()
+	store := memorystore.NewStore()
+	for _, chplID := range []string{"15.04.04.1111.Care.01.00.0.200101", "15.04.04.2222.Care.01.00.0.200101", "15.04.04.3333.Care.01.00.0.200101", "15.04.04.4444.Care.01.00.0.200101"} {
+		err := store.AddHealthITProduct(ctx, &endpointmanager.HealthITProduct{CHPLID: chplID})
+		th.Assert(t, err == nil, err)
+	}
+
+	err := HarvestServiceBaseURLLists(ctx, store, &(tc.Client), "LANTERN")
+	th.Assert(t, err == nil, err)
+
+	// each list is requested once, including the list whose request failed
+	th.Assert(t, requests["/endpoints.json"] == 1, fmt.Sprintf("Expected the list to be requested once. Got %d", requests["/endpoints.json"]))
+	th.Assert(t, requests["/missing.json"] == 1, fmt.Sprintf("Expected the failed list to be requested once. Got %d", requests["/missing.json"]))
+
+	for _, chplID := range []string{"15.04.04.2222.Care.01.00.0.200101", "15.04.04.4444.Care.01.00.0.200101"} {
+		_, err = store.GetFHIREndpointUsingURLAndListSource(ctx, "http://example.com/fhir/", chplID)
+		th.Assert(t, err == nil, fmt.Sprintf("Expected the endpoint from the list of %s to be stored: %v", chplID, err))
+	}
+	count, err := store.GetFHIREndpointCount(ctx)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, count == 2, fmt.Sprintf("Expected 2 stored endpoints. Got %d", count))
+}
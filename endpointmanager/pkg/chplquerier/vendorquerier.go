@@ -13,7 +13,6 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
-	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
 )
 
 var chplAPIVendorListPath string = "/developers"
@@ -49,12 +48,19 @@ type chplVendor struct {
 
 // GetCHPLVendors queries CHPL for its vendor list using 'cli' and stores the vendors in 'store'
 // within the given context 'ctx'.
-func GetCHPLVendors(ctx context.Context, store *postgresql.Store, cli *http.Client, userAgent string) error {
+func GetCHPLVendors(ctx context.Context, store CHPLStore, cli *http.Client, userAgent string) error {
+	return getCHPLVendorsModifiedSince(ctx, store, cli, userAgent, time.Time{})
+}
+
+// getCHPLVendorsModifiedSince queries CHPL for its vendor list and stores the vendors that CHPL modified at or
+// after 'modifiedSince'. All of the vendors are stored if 'modifiedSince' is the zero time.
+func getCHPLVendorsModifiedSince(ctx context.Context, store CHPLStore, cli *http.Client, userAgent string, modifiedSince time.Time) error {
 	log.Debug("requesting vendors from CHPL")
-	vendorJSON, err := getVendorJSON(ctx, cli, userAgent)
+	vendorJSON, err := chplSource.GetJSON(ctx, cli, VendorCollection, userAgent)
 
 	// None of the returned errors should break the system, so just return nil
 	if err != nil {
+		log.Warn(err)
 		return nil
 	}
 	log.Debug("done requesting vendors from CHPL")
@@ -66,6 +72,12 @@ func GetCHPLVendors(ctx context.Context, store *postgresql.Store, cli *http.Clie
 	}
 	log.Debug("done converting chpl json into vendor objects")
 
+	if !modifiedSince.IsZero() {
+		total := len(vendorList.Developers)
+		vendorList = filterVendorsModifiedSince(vendorList, modifiedSince)
+		log.Infof("%d of %d vendors were modified since %s", len(vendorList.Developers), total, modifiedSince.Format(time.RFC3339))
+	}
+
 	log.Debug("persisting vendors")
 	err = persistVendors(ctx, store, vendorList)
 	log.Debug("done persisting vendors")
@@ -116,6 +128,19 @@ func convertVendorJSONToObj(ctx context.Context, vendorJSON []byte) (*chplVendor
 	return &vendorList, nil
 }
 
+// filterVendorsModifiedSince returns the vendors that CHPL modified at or after 'modifiedSince'. Vendors without
+// a last modified date are kept because it is not known whether they changed.
+func filterVendorsModifiedSince(vendorList *chplVendorList, modifiedSince time.Time) *chplVendorList {
+	var modified chplVendorList
+	for _, vendor := range vendorList.Developers {
+		lastModified := stringToDate(vendor.LastModifiedDate)
+		if lastModified.Equal(time.Unix(0, 0)) || !lastModified.Before(modifiedSince) {
+			modified.Developers = append(modified.Developers, vendor)
+		}
+	}
+	return &modified
+}
+
 // takes the JSON model and converts it into an endpointmanager.Vendor
 func parseVendor(vendor *chplVendor) (*endpointmanager.Vendor, error) {
 	var loc endpointmanager.Location
@@ -140,7 +165,7 @@ func parseVendor(vendor *chplVendor) (*endpointmanager.Vendor, error) {
 }
 
 // persists the vendors parsed from CHPL.
-func persistVendors(ctx context.Context, store *postgresql.Store, vendorList *chplVendorList) error {
+func persistVendors(ctx context.Context, store CHPLStore, vendorList *chplVendorList) error {
 	for i, vendor := range vendorList.Developers {
 
 		select {
@@ -164,7 +189,7 @@ func persistVendors(ctx context.Context, store *postgresql.Store, vendorList *ch
 }
 
 func persistVendor(ctx context.Context,
-	store *postgresql.Store,
+	store CHPLStore,
 	vendor *chplVendor) error {
 
 	newDbVendor, err := parseVendor(vendor)
@@ -15,6 +15,7 @@ import (
 	logtest "github.com/sirupsen/logrus/hooks/test"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/memorystore"
 	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
 	"github.com/spf13/viper"
 )
@@ -425,6 +426,47 @@ func Test_parseVendor(t *testing.T) {
 	th.Assert(t, vend.Equal(&expectedVend), "CHPL Vendor did not parse into Vendor as expected.")
 }
 
+func Test_filterVendorsModifiedSince(t *testing.T) {
+	noDate := testCHPLVendor1
+	noDate.DeveloperID = 333
+	noDate.LastModifiedDate = ""
+	vendList := chplVendorList{Developers: []chplVendor{testCHPLVendor1, testCHPLVendor2, noDate}}
+
+	// testCHPLVendor1 was modified in February 2020 and testCHPLVendor2 in March 2020
+	modified := filterVendorsModifiedSince(&vendList, time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC))
+	th.Assert(t, len(modified.Developers) == 2, fmt.Sprintf("expected 2 vendors to be modified. Got %d", len(modified.Developers)))
+	th.Assert(t, modified.Developers[0].DeveloperID == testCHPLVendor2.DeveloperID, "expected the vendor modified in March to be kept")
+	th.Assert(t, modified.Developers[1].DeveloperID == noDate.DeveloperID, "expected the vendor without a last modified date to be kept")
+
+	modified = filterVendorsModifiedSince(&vendList, time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC))
+	th.Assert(t, len(modified.Developers) == 3, fmt.Sprintf("expected all vendors to be modified. Got %d", len(modified.Developers)))
+}
+
+func Test_persistVendorsMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := memorystore.NewStore()
+
+	vendList := chplVendorList{Developers: []chplVendor{testCHPLVendor1, testCHPLVendor2}}
+	err := persistVendors(ctx, store, &vendList)
+	th.Assert(t, err == nil, err)
+
+	names, err := store.GetVendorNames(ctx)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(names) == 2, fmt.Sprintf("expected 2 stored vendors. Got %d", len(names)))
+
+	// a vendor that is already stored is updated
+	updated := testCHPLVendor1
+	updated.Website = "http://www.epic.com/updated"
+	err = persistVendors(ctx, store, &chplVendorList{Developers: []chplVendor{updated}})
+	th.Assert(t, err == nil, err)
+	vendor, err := store.GetVendorUsingName(ctx, testCHPLVendor1.Name)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, vendor.URL == updated.Website, fmt.Sprintf("expected the vendor's URL to be updated. Got %s", vendor.URL))
+	names, err = store.GetVendorNames(ctx)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(names) == 2, fmt.Sprintf("expected 2 stored vendors after the update. Got %d", len(names)))
+}
+
 func basicVendorTestClient() (*th.TestClient, error) {
 
 	path := filepath.Join("testdata", "chpl_vendors.json")
@@ -40,6 +40,14 @@ func SetupConfig() error {
 	if err != nil {
 		return err
 	}
+	err = viper.BindEnv("chpl_fixture_dir")
+	if err != nil {
+		return err
+	}
+	err = viper.BindEnv("chpl_fixture_mode")
+	if err != nil {
+		return err
+	}
 
 	// Capability Queue Setup
 
@@ -63,6 +71,14 @@ func SetupConfig() error {
 	if err != nil {
 		return err
 	}
+	err = viper.BindEnv("capquery_propose_canonical_url")
+	if err != nil {
+		return err
+	}
+	err = viper.BindEnv("capquery_check_ip_families")
+	if err != nil {
+		return err
+	}
 
 	// Version Response Queue Setup
 	err = viper.BindEnv("versionsquery_qname")
@@ -89,6 +105,30 @@ func SetupConfig() error {
 	if err != nil {
 		return err
 	}
+	err = viper.BindEnv("pruning_batch_size")
+	if err != nil {
+		return err
+	}
+
+	// History Retention
+	err = viper.BindEnv("retention_full_months")
+	if err != nil {
+		return err
+	}
+	err = viper.BindEnv("retention_summary_months")
+	if err != nil {
+		return err
+	}
+
+	// Hosting Enrichment
+	err = viper.BindEnv("hosting_asndb")
+	if err != nil {
+		return err
+	}
+	err = viper.BindEnv("hosting_intvl") // in minutes
+	if err != nil {
+		return err
+	}
 
 	err = viper.BindEnv("export_numworkers")
 	if err != nil {
@@ -99,12 +139,47 @@ func SetupConfig() error {
 		return err
 	}
 
+	// Endpoint Linker
+	err = viper.BindEnv("linker_match_threshold")
+	if err != nil {
+		return err
+	}
+	err = viper.BindEnv("linker_name_weight")
+	if err != nil {
+		return err
+	}
+	err = viper.BindEnv("linker_address_weight")
+	if err != nil {
+		return err
+	}
+	err = viper.BindEnv("linker_city_weight")
+	if err != nil {
+		return err
+	}
+	err = viper.BindEnv("linker_state_weight")
+	if err != nil {
+		return err
+	}
+	err = viper.BindEnv("linker_zipcode_weight")
+	if err != nil {
+		return err
+	}
+	err = viper.BindEnv("linker_review_threshold")
+	if err != nil {
+		return err
+	}
+	err = viper.BindEnv("linker_provider_types")
+	if err != nil {
+		return err
+	}
+
 	viper.SetDefault("dbhost", "localhost")
 	viper.SetDefault("dbport", 5432)
 	viper.SetDefault("dbuser", "lantern")
 	viper.SetDefault("dbpassword", "postgrespassword")
 	viper.SetDefault("dbname", "lantern")
 	viper.SetDefault("dbsslmode", "disable")
+	viper.SetDefault("chpl_fixture_mode", "replay")
 
 	viper.SetDefault("quser", "capabilityquerier")
 	viper.SetDefault("qpassword", "capabilityquerier")
@@ -115,12 +190,23 @@ func SetupConfig() error {
 	viper.SetDefault("versionsquery_qname", "version-responses")
 	viper.SetDefault("versionsquery_response_qname", "endpoints-to-version-responses")
 	viper.SetDefault("capquery_qryintvl", 1380) // 1380 minutes -> 23 hours.
+	viper.SetDefault("capquery_propose_canonical_url", false)
+	viper.SetDefault("capquery_check_ip_families", false)
 
 	viper.SetDefault("pruning_threshold", 43800) // 43800 minutes -> 1 month.
+	viper.SetDefault("pruning_batch_size", 100)
+
+	viper.SetDefault("retention_full_months", 6)
+	viper.SetDefault("retention_summary_months", 18)
+
+	viper.SetDefault("hosting_asndb", "")
+	viper.SetDefault("hosting_intvl", 1440) // 1440 minutes -> 24 hours. 0 turns off the hosting enrichment loop.
 
 	viper.SetDefault("export_numworkers", 25)
 	viper.SetDefault("export_duration", 240)
 
+	// the linker_* defaults come from endpointlinker.DefaultMatchConfig
+
 	return nil
 }
 
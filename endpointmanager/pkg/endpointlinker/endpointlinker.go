@@ -16,10 +16,16 @@ import (
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
-	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
 	"github.com/pkg/errors"
 )
 
+// Store is the part of the database that the endpoint linker reads the endpoints and NPI organizations from and
+// saves the links between them to.
+type Store interface {
+	endpointmanager.EndpointStore
+	endpointmanager.NPIStore
+}
+
 func NormalizeOrgName(orgName string) (string, error) {
 	orgName = strings.ReplaceAll(orgName, "-", " ")
 	orgName = strings.ReplaceAll(orgName, "/", " ")
@@ -74,36 +80,33 @@ func verbosePrint(message string, verbose bool) {
 	}
 }
 
-func getIdsOfMatchingNPIOrgs(npiOrgNames []*endpointmanager.NPIOrganization, normalizedEndpointName string, verbose bool, tokenVal map[string]float64, jaccardThreshold float64) ([]string, map[string]float64, error) {
-	JACCARD_THRESHOLD := jaccardThreshold
-
+func getIdsOfMatchingNPIOrgs(npiOrgNames []*endpointmanager.NPIOrganization, normalizedEndpointName string, locations []*endpointmanager.Location, verbose bool, tokenVal map[string]float64, matchConfig MatchConfig) ([]string, map[string]float64, error) {
 	matches := []string{}
 	confidenceMap := make(map[string]float64)
 
 	verbosePrint(normalizedEndpointName+" Matched To:", verbose)
 	for _, npiOrg := range npiOrgNames {
-		consideredMatch := false
-		confidence := 0.0
 		jaccard1 := calculateWeightedJaccardIndex(normalizedEndpointName, npiOrg.NormalizedName, tokenVal)
 		jaccard2 := calculateWeightedJaccardIndex(normalizedEndpointName, npiOrg.NormalizedSecondaryName, tokenVal)
-		if jaccard1 == 1.0 {
-			confidence = jaccard1
-			consideredMatch = true
-			verbosePrint("Exact Match Primary Name: "+normalizedEndpointName, verbose)
-		} else if jaccard2 == 1.0 {
-			confidence = jaccard2
-			consideredMatch = true
-			verbosePrint("Exact Match Secondary Name: "+normalizedEndpointName, verbose)
-		} else if jaccard1 >= JACCARD_THRESHOLD && jaccard1 > jaccard2 {
-			confidence = jaccard1
-			consideredMatch = true
-			verbosePrint(normalizedEndpointName+"=>"+npiOrg.NormalizedName+" Match Score: "+fmt.Sprintf("%f", jaccard1), verbose)
-		} else if jaccard2 >= JACCARD_THRESHOLD {
-			consideredMatch = true
-			confidence = jaccard2
-			verbosePrint(normalizedEndpointName+"=>"+npiOrg.NormalizedSecondaryName+" Match Score: "+fmt.Sprintf("%f", jaccard2), verbose)
+		nameScore := jaccard1
+		matchedName := npiOrg.NormalizedName
+		if jaccard2 > jaccard1 {
+			nameScore = jaccard2
+			matchedName = npiOrg.NormalizedSecondaryName
+		}
+		// skip organizations whose names could not reach the threshold even if every geographic
+		// component agreed
+		if nameScore < matchConfig.minimumNameScore() {
+			continue
 		}
-		if consideredMatch {
+
+		confidence := matchConfig.combinedScore(nameScore, locations, npiOrg.Location)
+		if confidence >= matchConfig.Threshold {
+			if nameScore == 1.0 {
+				verbosePrint("Exact Match Name: "+normalizedEndpointName+" Match Score: "+fmt.Sprintf("%f", confidence), verbose)
+			} else {
+				verbosePrint(normalizedEndpointName+"=>"+matchedName+" Match Score: "+fmt.Sprintf("%f", confidence), verbose)
+			}
 			// multiply confidence by .99 for all name matches to demonstrate that these matches are not as good as the id matches
 			confidence = confidence * .99
 			confidenceMap[npiOrg.NPI_ID] = confidence
@@ -131,7 +134,7 @@ func mergeMatches(allMatches []string, allConfidences map[string]float64, matche
 	return allMatches, allConfidences
 }
 
-func matchByID(ctx context.Context, endpoint *endpointmanager.FHIREndpoint, store *postgresql.Store, verbose bool) ([]string, map[string]float64, error) {
+func matchByID(ctx context.Context, endpoint *endpointmanager.FHIREndpoint, store endpointmanager.NPIStore, verbose bool) ([]string, map[string]float64, error) {
 	matches := make([]string, 0)
 	confidences := make(map[string]float64)
 	for _, npiID := range endpoint.NPIIDs {
@@ -148,7 +151,7 @@ func matchByID(ctx context.Context, endpoint *endpointmanager.FHIREndpoint, stor
 	return matches, confidences, nil
 }
 
-func matchByName(endpoint *endpointmanager.FHIREndpoint, npiOrgNames []*endpointmanager.NPIOrganization, verbose bool, tokenVal map[string]float64, jaccardThreshold float64) ([]string, map[string]float64, error) {
+func matchByName(endpoint *endpointmanager.FHIREndpoint, npiOrgNames []*endpointmanager.NPIOrganization, verbose bool, tokenVal map[string]float64, matchConfig MatchConfig) ([]string, map[string]float64, error) {
 	allMatches := make([]string, 0)
 	allConfidences := make(map[string]float64)
 	for _, name := range endpoint.OrganizationNames {
@@ -156,7 +159,7 @@ func matchByName(endpoint *endpointmanager.FHIREndpoint, npiOrgNames []*endpoint
 		if err != nil {
 			return allMatches, allConfidences, errors.Wrap(err, "Error getting normalizing endpoint organizaton name")
 		}
-		matches, confidences, err := getIdsOfMatchingNPIOrgs(npiOrgNames, normalizedEndpointName, verbose, tokenVal, jaccardThreshold)
+		matches, confidences, err := getIdsOfMatchingNPIOrgs(npiOrgNames, normalizedEndpointName, endpoint.Locations, verbose, tokenVal, matchConfig)
 		if err != nil {
 			return allMatches, allConfidences, errors.Wrap(err, "Error getting matching NPI org IDs")
 		}
@@ -166,7 +169,7 @@ func matchByName(endpoint *endpointmanager.FHIREndpoint, npiOrgNames []*endpoint
 	return allMatches, allConfidences, nil
 }
 
-func addMatch(ctx context.Context, store *postgresql.Store, orgID string, endpoint *endpointmanager.FHIREndpoint, confidence float64) error {
+func addMatch(ctx context.Context, store endpointmanager.NPIStore, orgID string, endpoint *endpointmanager.FHIREndpoint, confidence float64) error {
 	_, _, storedConfidence, err := store.GetNPIOrganizationFHIREndpointLink(ctx, orgID, endpoint.URL)
 	if err == sql.ErrNoRows {
 		err = store.LinkNPIOrganizationToFHIREndpoint(ctx, orgID, endpoint.URL, confidence)
@@ -310,8 +313,18 @@ func getTokenVals(npiOrg []*endpointmanager.NPIOrganization, FHIREndpoints []*en
 	return tokenVal
 }
 
-func LinkAllOrgsAndEndpoints(ctx context.Context, store *postgresql.Store, allowlistFile string, blocklistFile string, verbose bool) error {
-	jaccardThreshold := .85
+// filterNPIOrganizations returns the NPI organizations whose NPI ID is in npiIDs.
+func filterNPIOrganizations(npiOrgs []*endpointmanager.NPIOrganization, npiIDs map[string]bool) []*endpointmanager.NPIOrganization {
+	var filtered []*endpointmanager.NPIOrganization
+	for _, npiOrg := range npiOrgs {
+		if npiIDs[npiOrg.NPI_ID] {
+			filtered = append(filtered, npiOrg)
+		}
+	}
+	return filtered
+}
+
+func LinkAllOrgsAndEndpoints(ctx context.Context, store Store, allowlistFile string, blocklistFile string, matchConfig MatchConfig, verbose bool) error {
 	fhirEndpoints, err := store.GetAllFHIREndpoints(ctx)
 	if err != nil {
 		return errors.Wrap(err, "Error getting endpoint org names")
@@ -324,7 +337,28 @@ func LinkAllOrgsAndEndpoints(ctx context.Context, store *postgresql.Store, allow
 
 	tokenVal := getTokenVals(npiOrgNames, fhirEndpoints)
 
+	if len(matchConfig.ProviderTypes) > 0 {
+		npiIDs, err := store.GetNPIOrganizationNPIIDsByProviderType(ctx, matchConfig.ProviderTypes)
+		if err != nil {
+			return errors.Wrap(err, "Error getting NPI organizations by provider type")
+		}
+		npiOrgNames = filterNPIOrganizations(npiOrgNames, npiIDs)
+		verbosePrint(fmt.Sprintf("Matching by name to %d NPI organizations with provider types %v", len(npiOrgNames), matchConfig.ProviderTypes), verbose)
+	}
+
+	decisions, decidedReviews, err := getReviewDecisions(ctx, store)
+	if err != nil {
+		return errors.Wrap(err, "Error getting link review decisions")
+	}
+	npiOrgsByID := make(map[string]*endpointmanager.NPIOrganization)
+	if matchConfig.ReviewThreshold > 0 {
+		for _, npiOrg := range npiOrgNames {
+			npiOrgsByID[npiOrg.NPI_ID] = npiOrg
+		}
+	}
+
 	matchCount := 0
+	pendingCount := 0
 	unmatchable := []string{}
 	// Iterate through fhir endpoints
 	for _, endpoint := range fhirEndpoints {
@@ -335,7 +369,7 @@ func LinkAllOrgsAndEndpoints(ctx context.Context, store *postgresql.Store, allow
 		if err != nil {
 			return errors.Wrap(err, "error matching endpoint to NPI organization by ID")
 		}
-		nameMatches, nameConfidences, err := matchByName(endpoint, npiOrgNames, verbose, tokenVal, jaccardThreshold)
+		nameMatches, nameConfidences, err := matchByName(endpoint, npiOrgNames, verbose, tokenVal, matchConfig)
 		if err != nil {
 			return errors.Wrap(err, "error matching endpoint to NPI organization by name")
 		}
@@ -346,6 +380,28 @@ func LinkAllOrgsAndEndpoints(ctx context.Context, store *postgresql.Store, allow
 			matchCount++
 			// Iterate over matches and add to linking table
 			for _, match := range allMatches {
+				status := decisions[reviewKey(endpoint.URL, match)]
+				if status == endpointmanager.ReviewRejected {
+					continue
+				}
+				// hold matches below the review threshold for review unless they have already been accepted
+				if status != endpointmanager.ReviewAccepted && allConfidences[match] < matchConfig.ReviewThreshold {
+					review := &endpointmanager.EndpointOrganizationReview{
+						URL:               endpoint.URL,
+						OrganizationNPIID: match,
+						Confidence:        allConfidences[match],
+						Status:            endpointmanager.ReviewPending,
+					}
+					if npiOrg, ok := npiOrgsByID[match]; ok {
+						review.ScoreBreakdown = scoreBreakdown(endpoint, npiOrg, tokenVal, matchConfig)
+					}
+					err = store.SavePendingEndpointOrganizationReview(ctx, review)
+					if err != nil {
+						return errors.Wrap(err, "Error saving link for review")
+					}
+					pendingCount++
+					continue
+				}
 				err = addMatch(ctx, store, match, endpoint, allConfidences[match])
 				if err != nil {
 					return errors.Wrap(err, "Error linking org to FHIR endpoint")
@@ -374,7 +430,16 @@ func LinkAllOrgsAndEndpoints(ctx context.Context, store *postgresql.Store, allow
 		}
 	}
 
+	// reviewed links are applied last so that they take precedence over the algorithm and the correction files
+	for _, review := range decidedReviews {
+		err = applyReviewDecision(ctx, store, review.URL, review.OrganizationNPIID, review.Status)
+		if err != nil {
+			return errors.Wrap(err, "Error applying link review decision")
+		}
+	}
+
 	verbosePrint("Match Total: "+strconv.Itoa(matchCount)+"/"+strconv.Itoa(len(fhirEndpoints)), verbose)
+	verbosePrint("Matches Pending Review: "+strconv.Itoa(pendingCount), verbose)
 
 	verbosePrint("UNMATCHABLE ENDPOINT ORG NAMES", verbose)
 	if verbose {
@@ -410,7 +475,7 @@ func openLinkerCorrectionFiles(filepath string) ([]map[string]string, error) {
 }
 
 // Add/update endpoint to npi organization links found in allowlist file from database, and remove endpoint to npi organization links found in blocklist file from database
-func linkerFix(ctx context.Context, store *postgresql.Store, matchEndpointOrganization []map[string]string, unmatchEndpointOrganization []map[string]string) error {
+func linkerFix(ctx context.Context, store endpointmanager.NPIStore, matchEndpointOrganization []map[string]string, unmatchEndpointOrganization []map[string]string) error {
 	if len(matchEndpointOrganization) != 0 {
 		for _, matchesMap := range matchEndpointOrganization {
 			orgID := matchesMap["organizationID"]
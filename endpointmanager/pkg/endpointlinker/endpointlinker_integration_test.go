@@ -226,6 +226,75 @@ func Test_manualLinkerCorrections(t *testing.T) {
 	th.Assert(t, err == sql.ErrNoRows, "Expected sql no rows error due to being in blocklist file")
 }
 
+func Test_linkReviews(t *testing.T) {
+	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
+	defer teardown(t, store.DB)
+
+	ctx := context.Background()
+
+	npiOrg := &endpointmanager.NPIOrganization{
+		NPI_ID:         "1",
+		Name:           "Memorial Hospital Inc",
+		NormalizedName: "MEMORIAL HOSPITAL INC",
+		Location:       &endpointmanager.Location{City: "Boston", State: "MA", ZipCode: "02114"}}
+	err := store.AddNPIOrganization(ctx, npiOrg)
+	th.Assert(t, err == nil, err)
+	// a second organization so that the name tokens do not all have the same frequency
+	err = store.AddNPIOrganization(ctx, &endpointmanager.NPIOrganization{
+		NPI_ID:         "2",
+		Name:           "Memorial Health",
+		NormalizedName: "MEMORIAL HEALTH"})
+	th.Assert(t, err == nil, err)
+	ep := &endpointmanager.FHIREndpoint{
+		URL:               "example.com/FHIR/DSTU2",
+		OrganizationNames: []string{"Memorial Hospital Inc"},
+		ListSource:        "https://open.epic.com/MyApps/EndpointsJson"}
+	err = store.AddFHIREndpoint(ctx, ep)
+	th.Assert(t, err == nil, err)
+
+	// matches below the review threshold are held for review instead of being linked
+
+	matchConfig := DefaultMatchConfig()
+	matchConfig.ReviewThreshold = 1.0
+	err = LinkAllOrgsAndEndpoints(ctx, store, "", "", matchConfig, false)
+	th.Assert(t, err == nil, err)
+
+	_, _, _, err = store.GetNPIOrganizationFHIREndpointLink(ctx, npiOrg.NPI_ID, ep.URL)
+	th.Assert(t, err == sql.ErrNoRows, "Expected the match to not be linked while it is pending review")
+	review, err := store.GetEndpointOrganizationReview(ctx, ep.URL, npiOrg.NPI_ID)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, review.Status == endpointmanager.ReviewPending, fmt.Sprintf("expected a pending review, got %s", review.Status))
+	th.Assert(t, review.ScoreBreakdown.NameScore == 1, fmt.Sprintf("expected a name score of 1, got %f", review.ScoreBreakdown.NameScore))
+
+	// rejected reviews are not linked on later runs
+
+	err = DecideLinkReview(ctx, store, ep.URL, npiOrg.NPI_ID, endpointmanager.ReviewRejected, "reviewer")
+	th.Assert(t, err == nil, err)
+	err = LinkAllOrgsAndEndpoints(ctx, store, "", "", DefaultMatchConfig(), false)
+	th.Assert(t, err == nil, err)
+	_, _, _, err = store.GetNPIOrganizationFHIREndpointLink(ctx, npiOrg.NPI_ID, ep.URL)
+	th.Assert(t, err == sql.ErrNoRows, "Expected the rejected match to not be linked")
+
+	// accepted reviews are linked immediately and on later runs
+
+	err = DecideLinkReview(ctx, store, ep.URL, npiOrg.NPI_ID, endpointmanager.ReviewAccepted, "reviewer")
+	th.Assert(t, err == nil, err)
+	_, _, confidence, err := store.GetNPIOrganizationFHIREndpointLink(ctx, npiOrg.NPI_ID, ep.URL)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, confidence == 1.0, fmt.Sprintf("expected accepted link confidence 1.000, got '%f'.", confidence))
+
+	err = LinkAllOrgsAndEndpoints(ctx, store, "", "", matchConfig, false)
+	th.Assert(t, err == nil, err)
+	_, _, confidence, err = store.GetNPIOrganizationFHIREndpointLink(ctx, npiOrg.NPI_ID, ep.URL)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, confidence == 1.0, fmt.Sprintf("expected accepted link confidence 1.000, got '%f'.", confidence))
+
+	// a reviewer must be given
+
+	err = DecideLinkReview(ctx, store, ep.URL, npiOrg.NPI_ID, endpointmanager.ReviewAccepted, "")
+	th.Assert(t, err != nil, "expected an error when no reviewer is given")
+}
+
 func setup() error {
 	var err error
 	store, err = postgresql.NewStore(viper.GetString("dbhost"), viper.GetInt("dbport"), viper.GetString("dbuser"), viper.GetString("dbpassword"), viper.GetString("dbname"), viper.GetString("dbsslmode"))
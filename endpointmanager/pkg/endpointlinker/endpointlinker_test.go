@@ -152,13 +152,13 @@ func Test_calculateWeightedJaccardIndex(t *testing.T) {
 func Test_getIdsOfMatchingNPIOrgs(t *testing.T) {
 	var orgs []*endpointmanager.NPIOrganization
 
-	matches, confidences, err := getIdsOfMatchingNPIOrgs(orgs, "FOO BAR", false, tokenValues, .85)
+	matches, confidences, err := getIdsOfMatchingNPIOrgs(orgs, "FOO BAR", nil, false, tokenValues, DefaultMatchConfig())
 	th.Assert(t, (err == nil), "Error getting matches from empty list")
 	th.Assert(t, (len(matches) == 0), "There should not have been any matches returned got: "+strconv.Itoa(len(matches)))
 	th.Assert(t, (len(confidences) == 0), "There should not have been any confidences returned"+strconv.Itoa(len(matches)))
 
 	orgs = append(orgs, nonMatchingOrg)
-	matches, confidences, err = getIdsOfMatchingNPIOrgs(orgs, "FOO BAR", false, tokenValues, .85)
+	matches, confidences, err = getIdsOfMatchingNPIOrgs(orgs, "FOO BAR", nil, false, tokenValues, DefaultMatchConfig())
 	th.Assert(t, (err == nil), "Error getting matches from list")
 	th.Assert(t, (len(matches) == 0), "There should not have been any matches returned got: "+strconv.Itoa(len(matches)))
 	th.Assert(t, (len(confidences) == 0), "There should not have been any confidences returned"+strconv.Itoa(len(matches)))
@@ -170,7 +170,7 @@ func Test_getIdsOfMatchingNPIOrgs(t *testing.T) {
 	orgs = append(orgs, nonExactPrimaryNameOrgName)
 	orgs = append(orgs, nonExactPrimaryAndSecondaryOrgName)
 
-	matches, confidences, err = getIdsOfMatchingNPIOrgs(orgs, "FOO FOO BAR BAR BAZ BAZ BAM", false, tokenValues, .85)
+	matches, confidences, err = getIdsOfMatchingNPIOrgs(orgs, "FOO FOO BAR BAR BAZ BAZ BAM", nil, false, tokenValues, DefaultMatchConfig())
 	th.Assert(t, (err == nil), "Error getting matches from list")
 	th.Assert(t, (len(matches) == 5), "There should have been 5 matches returned got: "+strconv.Itoa(len(matches)))
 	th.Assert(t, (len(confidences) == 5), "There should have been 5 confidences returned "+strconv.Itoa(len(confidences)))
@@ -191,7 +191,7 @@ func Test_getIdsOfMatchingNPIOrgs(t *testing.T) {
 	th.Assert(t, (confidence == "0.866250"), "Exact match confidence should have been 0.866250 confidence got "+confidence)
 
 	// Test the case where the primary name and secondary name both pass threshold but one is greater than the other
-	matches, confidences, err = getIdsOfMatchingNPIOrgs(orgs, "ONE TWO THREE FOUR FIVE SIX SEVEN EIGHT", false, tokenValues, .85)
+	matches, confidences, err = getIdsOfMatchingNPIOrgs(orgs, "ONE TWO THREE FOUR FIVE SIX SEVEN EIGHT", nil, false, tokenValues, DefaultMatchConfig())
 	th.Assert(t, (err == nil), "Error getting matches from list")
 	th.Assert(t, (len(matches) == 1), "There should have been 1 matchs returned got: "+strconv.Itoa(len(matches)))
 	th.Assert(t, (len(confidences) == 1), "There should have been 1 confidences returned "+strconv.Itoa(len(confidences)))
@@ -199,6 +199,44 @@ func Test_getIdsOfMatchingNPIOrgs(t *testing.T) {
 	// ONE TWO THREE FOUR FIVE SIX SEVEN EIGHT and secondary name ONE TWO THREE FOUR FIVE SIX SEVEN should have confidence of .875000 * .99
 	// .875 *.99 > than primary name ONE TWO THREE FOUR FIVE SIX match of .75 * .99
 	th.Assert(t, (confidence == "0.866250"), "Exact match confidence should have been 0.866250 confidence got "+confidence)
+
+	// Test that an exact name match in a different state is not considered a match
+	orgs = []*endpointmanager.NPIOrganization{exactPrimaryNameOrg, nonExactSecondaryNameOrg}
+	locations := []*endpointmanager.Location{{Address1: "123 Main St", City: "Boston", State: "MA", ZipCode: "02114"}}
+	matches, confidences, err = getIdsOfMatchingNPIOrgs(orgs, "FOO FOO BAR BAR BAZ BAZ BAM", locations, false, tokenValues, DefaultMatchConfig())
+	th.Assert(t, (err == nil), "Error getting matches from list")
+	th.Assert(t, (len(matches) == 0), "There should not have been any matches returned got: "+strconv.Itoa(len(matches)))
+	th.Assert(t, (len(confidences) == 0), "There should not have been any confidences returned"+strconv.Itoa(len(confidences)))
+
+	// Test that geographic agreement is included in the confidence
+	locations = []*endpointmanager.Location{{Address1: "123 Gov Way", City: "A City", State: "AK", ZipCode: "00000"}}
+	matches, confidences, err = getIdsOfMatchingNPIOrgs(orgs, "FOO FOO BAR BAR BAZ BAZ BAM", locations, false, tokenValues, DefaultMatchConfig())
+	th.Assert(t, (err == nil), "Error getting matches from list")
+	th.Assert(t, (len(matches) == 2), "There should have been 2 matches returned got: "+strconv.Itoa(len(matches)))
+	confidence = fmt.Sprintf("%f", confidences[exactPrimaryNameOrg.NPI_ID])
+	// every component agrees so the confidence is 1 * .99
+	th.Assert(t, (confidence == "0.990000"), "Exact match confidence should have been 0.990000 confidence got "+confidence)
+	confidence = fmt.Sprintf("%f", confidences[nonExactSecondaryNameOrg.NPI_ID])
+	// the name score of .875 and the agreeing city, state and zip code, but not address, give (.875 + .55) / 1.65 * .99
+	th.Assert(t, (confidence == "0.855000"), "Match confidence should have been 0.855000 confidence got "+confidence)
+
+	// Test that the threshold is configurable
+	matchConfig := DefaultMatchConfig()
+	matchConfig.Threshold = .9
+	matches, _, err = getIdsOfMatchingNPIOrgs(orgs, "FOO FOO BAR BAR BAZ BAZ BAM", locations, false, tokenValues, matchConfig)
+	th.Assert(t, (err == nil), "Error getting matches from list")
+	th.Assert(t, (len(matches) == 1), "There should have been 1 match returned got: "+strconv.Itoa(len(matches)))
+}
+
+func Test_filterNPIOrganizations(t *testing.T) {
+	orgs := []*endpointmanager.NPIOrganization{exactPrimaryNameOrg, {NPI_ID: "2"}, {NPI_ID: "3"}}
+
+	filtered := filterNPIOrganizations(orgs, map[string]bool{"1": true, "3": true})
+	th.Assert(t, len(filtered) == 2, fmt.Sprintf("expected 2 organizations. got %d", len(filtered)))
+	th.Assert(t, filtered[0].NPI_ID == "1" && filtered[1].NPI_ID == "3", "expected the organizations with the given NPI IDs in their original order")
+
+	filtered = filterNPIOrganizations(orgs, map[string]bool{})
+	th.Assert(t, len(filtered) == 0, fmt.Sprintf("expected no organizations. got %d", len(filtered)))
 }
 
 func Test_mergeMatches(t *testing.T) {
@@ -303,7 +341,7 @@ func Test_matchByName(t *testing.T) {
 		ListSource:        "https://open.epic.com/MyApps/EndpointsJson"}
 
 	// test with no orgs
-	matches, confidences, err := matchByName(ep, orgs, false, tokenValues, .85)
+	matches, confidences, err := matchByName(ep, orgs, false, tokenValues, DefaultMatchConfig())
 	expected := 0
 	th.Assert(t, err == nil, err)
 	th.Assert(t, len(matches) == expected, "expected no matches")
@@ -312,7 +350,7 @@ func Test_matchByName(t *testing.T) {
 	orgs = append(orgs, nonMatchingOrg)
 
 	// test with non matching org
-	matches, confidences, err = matchByName(ep, orgs, false, tokenValues, .85)
+	matches, confidences, err = matchByName(ep, orgs, false, tokenValues, DefaultMatchConfig())
 	expected = 0
 	th.Assert(t, err == nil, err)
 	th.Assert(t, len(matches) == expected, "expected no matches")
@@ -326,7 +364,7 @@ func Test_matchByName(t *testing.T) {
 	orgs = append(orgs, nonExactPrimaryAndSecondaryOrgName)
 
 	// expect some matches with varying confidences to "FOO FOO BAR BAR BAZ BAZ BAM"
-	matches, confidences, err = matchByName(ep, orgs, false, tokenValues, .85)
+	matches, confidences, err = matchByName(ep, orgs, false, tokenValues, DefaultMatchConfig())
 	expected = 5
 	th.Assert(t, err == nil, err)
 	th.Assert(t, len(matches) == expected, fmt.Sprintf("expected %d matches. got %d.", expected, len(matches)))
@@ -349,7 +387,7 @@ func Test_matchByName(t *testing.T) {
 
 	// expect some matches with varying confidences to "FOO FOO BAR BAR BAZ BAZ BAM BAM"
 	ep.OrganizationNames = []string{"FOO FOO BAR BAR BAZ BAZ BAM BAM"}
-	matches, confidences, err = matchByName(ep, orgs, false, tokenValues, .85)
+	matches, confidences, err = matchByName(ep, orgs, false, tokenValues, DefaultMatchConfig())
 	expected = 5
 	th.Assert(t, err == nil, err)
 	th.Assert(t, len(matches) == expected, fmt.Sprintf("expected %d matches. got %d.", expected, len(matches)))
@@ -373,7 +411,7 @@ func Test_matchByName(t *testing.T) {
 	// check that highest confidence value is used
 	// expect some matches with varying confidences to "FOO FOO BAR BAR BAZ BAZ BAM BAM" and "FOO FOO BAR BAR BAZ BAZ BAM"
 	ep.OrganizationNames = []string{"FOO FOO BAR BAR BAZ BAZ BAM BAM", "FOO FOO BAR BAR BAZ BAZ BAM"}
-	matches, confidences, err = matchByName(ep, orgs, false, tokenValues, .85)
+	matches, confidences, err = matchByName(ep, orgs, false, tokenValues, DefaultMatchConfig())
 	expected = 5
 	th.Assert(t, err == nil, err)
 	th.Assert(t, len(matches) == expected, fmt.Sprintf("expected %d matches. got %d.", expected, len(matches)))
@@ -397,7 +435,7 @@ func Test_matchByName(t *testing.T) {
 	// checking non-existent org name causes no issues
 	// expect some matches with varying confidences to "FOO FOO BAR BAR BAZ BAZ BAM BAM" and "FOO FOO BAR BAR BAZ BAZ BAM" and "BLAH"
 	ep.OrganizationNames = []string{"FOO FOO BAR BAR BAZ BAZ BAM BAM", "FOO FOO BAR BAR BAZ BAZ BAM", "BLAH"}
-	matches, confidences, err = matchByName(ep, orgs, false, tokenValues, .85)
+	matches, confidences, err = matchByName(ep, orgs, false, tokenValues, DefaultMatchConfig())
 	expected = 5
 	th.Assert(t, err == nil, err)
 	th.Assert(t, len(matches) == expected, fmt.Sprintf("expected %d matches. got %d.", expected, len(matches)))
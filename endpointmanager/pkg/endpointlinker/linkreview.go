@@ -0,0 +1,123 @@
+package endpointlinker
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
+	"github.com/pkg/errors"
+)
+
+func reviewKey(url string, orgID string) string {
+	return url + "|" + orgID
+}
+
+// getReviewDecisions returns the status of every accepted or rejected review keyed by the endpoint URL and NPI ID.
+func getReviewDecisions(ctx context.Context, store endpointmanager.NPIStore) (map[string]string, []*endpointmanager.EndpointOrganizationReview, error) {
+	decisions := make(map[string]string)
+	var decided []*endpointmanager.EndpointOrganizationReview
+	for _, status := range []string{endpointmanager.ReviewAccepted, endpointmanager.ReviewRejected} {
+		reviews, err := store.GetEndpointOrganizationReviews(ctx, status)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, review := range reviews {
+			decisions[reviewKey(review.URL, review.OrganizationNPIID)] = review.Status
+		}
+		decided = append(decided, reviews...)
+	}
+	return decisions, decided, nil
+}
+
+// applyReviewDecision links the endpoint to the NPI organization with a confidence of 1 if the review was
+// accepted, and removes the link if the review was rejected.
+func applyReviewDecision(ctx context.Context, store endpointmanager.NPIStore, url string, orgID string, status string) error {
+	_, _, _, err := store.GetNPIOrganizationFHIREndpointLink(ctx, orgID, url)
+	if err != nil && err != sql.ErrNoRows {
+		return errors.Wrap(err, "Error checking if org to FHIR endpoint link exists")
+	}
+	linkExists := err == nil
+
+	if status == endpointmanager.ReviewAccepted {
+		if linkExists {
+			err = store.UpdateNPIOrganizationFHIREndpointLink(ctx, orgID, url, 1.0)
+		} else {
+			err = store.LinkNPIOrganizationToFHIREndpoint(ctx, orgID, url, 1.0)
+		}
+		if err != nil {
+			return errors.Wrap(err, "Error linking org to FHIR endpoint for accepted review")
+		}
+	} else if status == endpointmanager.ReviewRejected && linkExists {
+		err = store.DeleteNPIOrganizationFHIREndpointLink(ctx, orgID, url)
+		if err != nil {
+			return errors.Wrap(err, "Error unlinking org to FHIR endpoint for rejected review")
+		}
+	}
+	return nil
+}
+
+// DecideLinkReview records that the reviewer accepted or rejected the link between the endpoint URL and the NPI
+// organization, and applies the decision to the endpoint_organization table. The decision is applied again on
+// every later run of the endpoint linker.
+func DecideLinkReview(ctx context.Context, store endpointmanager.NPIStore, url string, orgID string, status string, decidedBy string) error {
+	if decidedBy == "" {
+		return errors.New("the reviewer deciding a link review must be given")
+	}
+	err := store.DecideEndpointOrganizationReview(ctx, url, orgID, status, decidedBy)
+	if err != nil {
+		return errors.Wrap(err, "Error saving link review decision")
+	}
+	return applyReviewDecision(ctx, store, url, orgID, status)
+}
+
+// scoreBreakdown returns how the endpoint was scored against the NPI organization, using the endpoint organization
+// name that best matches either of the NPI organization's names.
+func scoreBreakdown(endpoint *endpointmanager.FHIREndpoint, npiOrg *endpointmanager.NPIOrganization, tokenVal map[string]float64, matchConfig MatchConfig) endpointmanager.LinkScoreBreakdown {
+	breakdown := endpointmanager.LinkScoreBreakdown{
+		IDMatch: helpers.StringArrayContains(endpoint.NPIIDs, npiOrg.NPI_ID),
+	}
+
+	for _, name := range endpoint.OrganizationNames {
+		normalizedEndpointName, err := NormalizeOrgName(name)
+		if err != nil {
+			continue
+		}
+		for _, orgName := range []string{npiOrg.NormalizedName, npiOrg.NormalizedSecondaryName} {
+			if orgName == "" {
+				continue
+			}
+			score := calculateWeightedJaccardIndex(normalizedEndpointName, orgName, tokenVal)
+			if breakdown.EndpointName == "" || score > breakdown.NameScore {
+				breakdown.NameScore = score
+				breakdown.EndpointName = normalizedEndpointName
+				breakdown.OrganizationName = orgName
+			}
+		}
+	}
+
+	breakdown.SharedTokens = sharedTokens(breakdown.EndpointName, breakdown.OrganizationName)
+	breakdown.LocationScores = matchConfig.bestLocationComponentScores(breakdown.NameScore, endpoint.Locations, npiOrg.Location)
+	breakdown.CombinedScore = matchConfig.combinedScore(breakdown.NameScore, endpoint.Locations, npiOrg.Location)
+
+	return breakdown
+}
+
+// sharedTokens returns the sorted, distinct tokens that appear in both strings.
+func sharedTokens(string1 string, string2 string) []string {
+	tokens1 := make(map[string]bool)
+	for _, token := range strings.Fields(string1) {
+		tokens1[token] = true
+	}
+	shared := []string{}
+	for _, token := range strings.Fields(string2) {
+		if tokens1[token] {
+			shared = append(shared, token)
+			tokens1[token] = false
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
@@ -0,0 +1,82 @@
+package endpointlinker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/memorystore"
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+)
+
+func Test_scoreBreakdown(t *testing.T) {
+	ep := &endpointmanager.FHIREndpoint{
+		URL:               "example.com/FHIR/DSTU2",
+		OrganizationNames: []string{"Not A Match", "Foo Foo Bar Bar Baz Baz Bam"},
+		NPIIDs:            []string{"2"},
+		Locations:         []*endpointmanager.Location{{City: "Other City", State: "AK"}, {City: "A City", State: "AK"}}}
+
+	breakdown := scoreBreakdown(ep, nonExactSecondaryNameOrg, tokenValues, DefaultMatchConfig())
+	th.Assert(t, breakdown.IDMatch, "expected an ID match")
+	th.Assert(t, breakdown.NameScore == .875, fmt.Sprintf("expected a name score of .875, got %f", breakdown.NameScore))
+	th.Assert(t, breakdown.EndpointName == "FOO FOO BAR BAR BAZ BAZ BAM", fmt.Sprintf("expected the best matching endpoint name, got %s", breakdown.EndpointName))
+	th.Assert(t, breakdown.OrganizationName == nonExactSecondaryNameOrg.NormalizedSecondaryName, fmt.Sprintf("expected the best matching organization name, got %s", breakdown.OrganizationName))
+	expectedTokens := []string{"BAM", "BAR", "BAZ", "FOO"}
+	th.Assert(t, len(breakdown.SharedTokens) == len(expectedTokens), fmt.Sprintf("expected shared tokens %v, got %v", expectedTokens, breakdown.SharedTokens))
+	for i := range expectedTokens {
+		th.Assert(t, breakdown.SharedTokens[i] == expectedTokens[i], fmt.Sprintf("expected shared tokens %v, got %v", expectedTokens, breakdown.SharedTokens))
+	}
+	// the second location agrees on both the city and state
+	th.Assert(t, len(breakdown.LocationScores) == 2, fmt.Sprintf("expected 2 location scores, got %v", breakdown.LocationScores))
+	th.Assert(t, breakdown.LocationScores["city"] == 1 && breakdown.LocationScores["state"] == 1, fmt.Sprintf("expected the city and state to agree, got %v", breakdown.LocationScores))
+	expected := (.875 + .15 + .25) / 1.4
+	th.Assert(t, floatsEqual(breakdown.CombinedScore, expected), fmt.Sprintf("expected a combined score of %f, got %f", expected, breakdown.CombinedScore))
+
+	// no matching names or locations
+
+	ep = &endpointmanager.FHIREndpoint{
+		URL:               "example.com/FHIR/DSTU2",
+		OrganizationNames: []string{"Unrelated"}}
+	breakdown = scoreBreakdown(ep, nonExactSecondaryNameOrg, tokenValues, DefaultMatchConfig())
+	th.Assert(t, !breakdown.IDMatch, "expected no ID match")
+	th.Assert(t, breakdown.NameScore == 0, fmt.Sprintf("expected a name score of 0, got %f", breakdown.NameScore))
+	th.Assert(t, len(breakdown.SharedTokens) == 0, fmt.Sprintf("expected no shared tokens, got %v", breakdown.SharedTokens))
+	th.Assert(t, len(breakdown.LocationScores) == 0, fmt.Sprintf("expected no location scores, got %v", breakdown.LocationScores))
+}
+
+func Test_sharedTokens(t *testing.T) {
+	shared := sharedTokens("FOO BAR BAR", "BAR FOO BAZ BAR BAR")
+	th.Assert(t, len(shared) == 2 && shared[0] == "BAR" && shared[1] == "FOO", fmt.Sprintf("expected [BAR FOO], got %v", shared))
+
+	shared = sharedTokens("", "FOO")
+	th.Assert(t, len(shared) == 0, fmt.Sprintf("expected no shared tokens, got %v", shared))
+}
+
+func Test_DecideLinkReview(t *testing.T) {
+	ctx := context.Background()
+	store := memorystore.NewStore()
+	url := "example.com/FHIR/DSTU2"
+
+	err := DecideLinkReview(ctx, store, url, "1", endpointmanager.ReviewAccepted, "")
+	th.Assert(t, err != nil, "expected an error when the reviewer is not given")
+
+	// accepting a link that was never proposed creates it with full confidence
+	err = DecideLinkReview(ctx, store, url, "1", endpointmanager.ReviewAccepted, "reviewer")
+	th.Assert(t, err == nil, err)
+	_, _, confidence, err := store.GetNPIOrganizationFHIREndpointLink(ctx, "1", url)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, confidence == 1, fmt.Sprintf("expected a confidence of 1 for an accepted link, got %f", confidence))
+
+	// rejecting the link removes it
+	err = DecideLinkReview(ctx, store, url, "1", endpointmanager.ReviewRejected, "reviewer")
+	th.Assert(t, err == nil, err)
+	_, _, _, err = store.GetNPIOrganizationFHIREndpointLink(ctx, "1", url)
+	th.Assert(t, err == sql.ErrNoRows, fmt.Sprintf("expected the rejected link to be removed, got %v", err))
+
+	decisions, decided, err := getReviewDecisions(ctx, store)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(decided) == 1, fmt.Sprintf("expected 1 decided review, got %d", len(decided)))
+	th.Assert(t, decisions[reviewKey(url, "1")] == endpointmanager.ReviewRejected, "expected the review to be rejected")
+}
@@ -0,0 +1,211 @@
+package endpointlinker
+
+import (
+	"strings"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+)
+
+// locationComponents are the geographic components compared by the endpoint linker.
+var locationComponents = []string{"address", "city", "state", "zipcode"}
+
+// MatchConfig holds the threshold and the component weights used to score how well an endpoint's
+// organization matches an NPI organization when they are not linked by NPI ID.
+//
+// The combined score is the weighted average of the name score and the geographic components, where each
+// geographic component is 1 if the endpoint and NPI organization agree on it and 0 if they do not.
+// Geographic components that are missing from either the endpoint or the NPI organization are left out of
+// the average, so when no location information is available the combined score is the name score.
+type MatchConfig struct {
+	Threshold     float64
+	NameWeight    float64
+	AddressWeight float64
+	CityWeight    float64
+	StateWeight   float64
+	ZipCodeWeight float64
+	// ReviewThreshold is the confidence below which name matches are saved for review instead of being linked.
+	// Matches are never held for review when it is 0.
+	ReviewThreshold float64
+	// ProviderTypes limits matching by name to NPI organizations with a taxonomy whose NUCC grouping,
+	// classification, specialization or display name is one of the provider types, such as "Hospitals".
+	// All NPI organizations are matched when it is empty.
+	ProviderTypes []string
+}
+
+// DefaultMatchConfig returns the MatchConfig used by the endpoint linker when none is configured. The
+// LANTERN_LINKER_* settings override its fields.
+func DefaultMatchConfig() MatchConfig {
+	return MatchConfig{
+		Threshold:     .85,
+		NameWeight:    1.0,
+		AddressWeight: .1,
+		CityWeight:    .15,
+		StateWeight:   .25,
+		ZipCodeWeight: .15,
+	}
+}
+
+// combinedScore returns the best combined score of the name score with each of the endpoint's locations.
+func (mc MatchConfig) combinedScore(nameScore float64, locations []*endpointmanager.Location, orgLocation *endpointmanager.Location) float64 {
+	best := nameScore
+	for i, location := range locations {
+		score := mc.locationScore(nameScore, location, orgLocation)
+		if i == 0 || score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+func (mc MatchConfig) locationScore(nameScore float64, location *endpointmanager.Location, orgLocation *endpointmanager.Location) float64 {
+	total := mc.NameWeight * nameScore
+	weights := mc.NameWeight
+
+	componentScores := mc.locationComponentScores(location, orgLocation)
+	// sum the components in a fixed order so that the score is the same on every run
+	for _, component := range locationComponents {
+		score, ok := componentScores[component]
+		if !ok {
+			continue
+		}
+		weight := mc.componentWeight(component)
+		total += weight * score
+		weights += weight
+	}
+
+	if len(componentScores) == 0 || weights == 0 {
+		return nameScore
+	}
+	return total / weights
+}
+
+// bestLocationComponentScores returns the score of each geographic component compared for the endpoint location
+// that gives the best combined score.
+func (mc MatchConfig) bestLocationComponentScores(nameScore float64, locations []*endpointmanager.Location, orgLocation *endpointmanager.Location) map[string]float64 {
+	var best map[string]float64
+	bestScore := 0.0
+	for i, location := range locations {
+		score := mc.locationScore(nameScore, location, orgLocation)
+		if i == 0 || score > bestScore {
+			bestScore = score
+			best = mc.locationComponentScores(location, orgLocation)
+		}
+	}
+	return best
+}
+
+// locationComponentScores returns the score of each geographic component that has a weight and is present in both
+// locations, keyed by "address", "city", "state" and "zipcode".
+func (mc MatchConfig) locationComponentScores(location *endpointmanager.Location, orgLocation *endpointmanager.Location) map[string]float64 {
+	scores := make(map[string]float64)
+	if location == nil || orgLocation == nil {
+		return scores
+	}
+
+	addComponent := func(component string, value1 string, value2 string, similarity func(string, string) float64) {
+		if mc.componentWeight(component) <= 0 || value1 == "" || value2 == "" {
+			return
+		}
+		scores[component] = similarity(value1, value2)
+	}
+
+	addComponent("address", normalizeLocationField(location.Address1), normalizeLocationField(orgLocation.Address1), tokenJaccardIndex)
+	addComponent("city", normalizeLocationField(location.City), normalizeLocationField(orgLocation.City), exactMatch)
+	addComponent("state", normalizeLocationField(location.State), normalizeLocationField(orgLocation.State), exactMatch)
+	addComponent("zipcode", normalizeZipCode(location.ZipCode), normalizeZipCode(orgLocation.ZipCode), exactMatch)
+
+	return scores
+}
+
+func (mc MatchConfig) componentWeight(component string) float64 {
+	switch component {
+	case "address":
+		return mc.AddressWeight
+	case "city":
+		return mc.CityWeight
+	case "state":
+		return mc.StateWeight
+	case "zipcode":
+		return mc.ZipCodeWeight
+	}
+	return 0
+}
+
+// minimumNameScore returns the lowest name score that could reach the threshold if every geographic
+// component agreed.
+func (mc MatchConfig) minimumNameScore() float64 {
+	if mc.NameWeight <= 0 {
+		return 0
+	}
+	geoWeights := 0.0
+	for _, weight := range []float64{mc.AddressWeight, mc.CityWeight, mc.StateWeight, mc.ZipCodeWeight} {
+		if weight > 0 {
+			geoWeights += weight
+		}
+	}
+	return (mc.Threshold*(mc.NameWeight+geoWeights) - geoWeights) / mc.NameWeight
+}
+
+func normalizeLocationField(value string) string {
+	normalized, err := NormalizeOrgName(value)
+	if err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(normalized), " ")
+}
+
+// normalizeZipCode returns the five-digit zip code so that ZIP+4 codes match their five-digit zip code.
+func normalizeZipCode(zipCode string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, zipCode)
+	if len(digits) < 5 {
+		return ""
+	}
+	return digits[:5]
+}
+
+func exactMatch(value1 string, value2 string) float64 {
+	if value1 == value2 {
+		return 1
+	}
+	return 0
+}
+
+// tokenJaccardIndex returns the unweighted Jaccard index of the tokens of the two strings.
+func tokenJaccardIndex(value1 string, value2 string) float64 {
+	tokens1 := make(map[string]bool)
+	for _, token := range strings.Fields(value1) {
+		tokens1[token] = true
+	}
+	tokens2 := make(map[string]bool)
+	for _, token := range strings.Fields(value2) {
+		tokens2[token] = true
+	}
+	intersect := 0
+	for token := range tokens2 {
+		if tokens1[token] {
+			intersect++
+		}
+	}
+	union := len(tokens1) + len(tokens2) - intersect
+	if union == 0 {
+		return 0
+	}
+	return float64(intersect) / float64(union)
+}
+
+// ParseProviderTypes splits a comma separated list of provider types, dropping empty entries.
+func ParseProviderTypes(providerTypes string) []string {
+	var types []string
+	for _, providerType := range strings.Split(providerTypes, ",") {
+		providerType = strings.TrimSpace(providerType)
+		if providerType != "" {
+			types = append(types, providerType)
+		}
+	}
+	return types
+}
@@ -0,0 +1,95 @@
+package endpointlinker
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+)
+
+func floatsEqual(f1 float64, f2 float64) bool {
+	return math.Abs(f1-f2) < 1e-9
+}
+
+func Test_combinedScore(t *testing.T) {
+	mc := DefaultMatchConfig()
+	orgLocation := &endpointmanager.Location{
+		Address1: "123 Gov Way",
+		City:     "A City",
+		State:    "AK",
+		ZipCode:  "00000-1234"}
+
+	// no locations leaves the name score as is
+
+	score := mc.combinedScore(.9, nil, orgLocation)
+	th.Assert(t, score == .9, fmt.Sprintf("expected the name score .9 when the endpoint has no locations. got %f", score))
+	score = mc.combinedScore(.9, []*endpointmanager.Location{{State: "AK"}}, nil)
+	th.Assert(t, score == .9, fmt.Sprintf("expected the name score .9 when the organization has no location. got %f", score))
+
+	// every component agrees, ignoring case, punctuation and ZIP+4
+
+	location := &endpointmanager.Location{
+		Address1: "123 GOV. WAY",
+		City:     "a city",
+		State:    "ak",
+		ZipCode:  "00000"}
+	score = mc.combinedScore(.8, []*endpointmanager.Location{location}, orgLocation)
+	expected := (.8 + .1 + .15 + .25 + .15) / 1.65
+	th.Assert(t, floatsEqual(score, expected), fmt.Sprintf("expected %f. got %f", expected, score))
+
+	// only components present on both sides count
+
+	location = &endpointmanager.Location{State: "MA"}
+	score = mc.combinedScore(1, []*endpointmanager.Location{location}, orgLocation)
+	expected = 1 / 1.25
+	th.Assert(t, floatsEqual(score, expected), fmt.Sprintf("expected %f. got %f", expected, score))
+
+	// partial address agreement
+
+	location = &endpointmanager.Location{Address1: "123 Main Way"}
+	score = mc.combinedScore(1, []*endpointmanager.Location{location}, orgLocation)
+	expected = (1 + .1*.5) / 1.1
+	th.Assert(t, floatsEqual(score, expected), fmt.Sprintf("expected %f. got %f", expected, score))
+
+	// the best location is used
+
+	locations := []*endpointmanager.Location{{State: "MA"}, {State: "AK"}}
+	score = mc.combinedScore(.9, locations, orgLocation)
+	expected = (.9 + .25) / 1.25
+	th.Assert(t, floatsEqual(score, expected), fmt.Sprintf("expected %f. got %f", expected, score))
+
+	// zero weights are ignored
+
+	mc.StateWeight = 0
+	score = mc.combinedScore(.9, []*endpointmanager.Location{{State: "MA"}}, orgLocation)
+	th.Assert(t, score == .9, fmt.Sprintf("expected the name score .9 when the state weight is 0. got %f", score))
+}
+
+func Test_minimumNameScore(t *testing.T) {
+	mc := DefaultMatchConfig()
+	minimum := mc.minimumNameScore()
+	// a name score at the minimum reaches the threshold when every geographic component agrees
+	expected := (.85*1.65 - .65) / 1
+	th.Assert(t, floatsEqual(minimum, expected), fmt.Sprintf("expected %f. got %f", expected, minimum))
+
+	mc = MatchConfig{Threshold: .85, NameWeight: 1}
+	minimum = mc.minimumNameScore()
+	th.Assert(t, floatsEqual(minimum, .85), fmt.Sprintf("expected the threshold when there are no geographic weights. got %f", minimum))
+}
+
+func Test_normalizeZipCode(t *testing.T) {
+	th.Assert(t, normalizeZipCode("02114-1234") == "02114", "expected ZIP+4 to be normalized to the five-digit zip code")
+	th.Assert(t, normalizeZipCode("021141234") == "02114", "expected a nine-digit zip code to be normalized to the five-digit zip code")
+	th.Assert(t, normalizeZipCode("0211") == "", "expected an incomplete zip code to be ignored")
+	th.Assert(t, normalizeZipCode("") == "", "expected an empty zip code to be ignored")
+}
+
+func Test_ParseProviderTypes(t *testing.T) {
+	types := ParseProviderTypes(" Hospitals, Pharmacy ,,")
+	th.Assert(t, len(types) == 2, fmt.Sprintf("expected 2 provider types. got %v", types))
+	th.Assert(t, types[0] == "Hospitals" && types[1] == "Pharmacy", fmt.Sprintf("expected the provider types to be trimmed. got %v", types))
+
+	th.Assert(t, len(ParseProviderTypes("")) == 0, "expected no provider types from an empty string")
+}
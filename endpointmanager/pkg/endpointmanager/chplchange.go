@@ -0,0 +1,181 @@
+package endpointmanager
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// The modes of a CHPLSync.
+const (
+	CHPLSyncFull     = "full"
+	CHPLSyncModified = "modified"
+)
+
+// The kinds of CHPL records that a CHPLChange describes.
+const (
+	CHPLProduct  = "product"
+	CHPLVendor   = "vendor"
+	CHPLCriteria = "criteria"
+)
+
+// The changes that a CHPLChange describes besides changes to a single field, which use the field's name.
+const (
+	CHPLChangeAdded      = "added"
+	CHPLChangeRemoved    = "removed"
+	CHPLChangeNewVersion = "new_version"
+	CHPLChangeWithdrawn  = "withdrawn"
+)
+
+// CHPLSync records a run of the CHPL querier. CompletedAt is the zero time while the sync is running or if it
+// failed. Modified syncs only store the vendors and products that CHPL modified after the previous sync started.
+type CHPLSync struct {
+	ID          int
+	Mode        string
+	StartedAt   time.Time
+	CompletedAt time.Time
+}
+
+// CHPLChange describes one change to a product, vendor or certification criteria stored from CHPL. Change is
+// CHPLChangeAdded, CHPLChangeRemoved, CHPLChangeNewVersion, CHPLChangeWithdrawn or the name of the column that
+// changed, such as "certification_status" or "api_url". Previous and Current are the values of that column
+// before and after the change.
+type CHPLChange struct {
+	Kind      string
+	CHPLID    string
+	Name      string
+	Change    string
+	Previous  string
+	Current   string
+	ChangedAt time.Time
+}
+
+// HealthITProductChanges returns the changes between two versions of a product. previous is nil when the product
+// was added and current is nil when it was removed. A product whose certification status becomes one of the
+// CHPL "Withdrawn" statuses is reported as withdrawn.
+func HealthITProductChanges(previous *HealthITProduct, current *HealthITProduct, changedAt time.Time) []CHPLChange {
+	if previous == nil && current == nil {
+		return nil
+	}
+	product := current
+	if product == nil {
+		product = previous
+	}
+	change := CHPLChange{Kind: CHPLProduct, CHPLID: product.CHPLID, Name: product.Name + " " + product.Version, ChangedAt: changedAt}
+	if previous == nil {
+		return []CHPLChange{change.with(CHPLChangeAdded, "", current.Version)}
+	}
+	if current == nil {
+		return []CHPLChange{change.with(CHPLChangeRemoved, previous.Version, "")}
+	}
+
+	var changes []CHPLChange
+	if previous.CertificationStatus != current.CertificationStatus {
+		kind := "certification_status"
+		if strings.HasPrefix(current.CertificationStatus, "Withdrawn") {
+			kind = CHPLChangeWithdrawn
+		}
+		changes = append(changes, change.with(kind, previous.CertificationStatus, current.CertificationStatus))
+	}
+	if previous.APIURL != current.APIURL {
+		changes = append(changes, change.with("api_url", previous.APIURL, current.APIURL))
+	}
+	if previous.CHPLID != current.CHPLID {
+		changes = append(changes, change.with("chpl_id", previous.CHPLID, current.CHPLID))
+	}
+	if previous.CertificationEdition != current.CertificationEdition {
+		changes = append(changes, change.with("certification_edition", previous.CertificationEdition, current.CertificationEdition))
+	}
+	if !previous.CertificationDate.Equal(current.CertificationDate) {
+		changes = append(changes, change.with("certification_date", previous.CertificationDate.Format("2006-01-02"), current.CertificationDate.Format("2006-01-02")))
+	}
+	if !cmp.Equal(previous.CertificationCriteria, current.CertificationCriteria) {
+		changes = append(changes, change.with("certification_criteria", joinInts(previous.CertificationCriteria), joinInts(current.CertificationCriteria)))
+	}
+	return changes
+}
+
+// VendorChanges returns the changes between two versions of a vendor. previous is nil when the vendor was added
+// and current is nil when it was removed.
+func VendorChanges(previous *Vendor, current *Vendor, changedAt time.Time) []CHPLChange {
+	if previous == nil && current == nil {
+		return nil
+	}
+	vendor := current
+	if vendor == nil {
+		vendor = previous
+	}
+	change := CHPLChange{Kind: CHPLVendor, CHPLID: strconv.Itoa(vendor.CHPLID), Name: vendor.Name, ChangedAt: changedAt}
+	if previous == nil {
+		return []CHPLChange{change.with(CHPLChangeAdded, "", current.Name)}
+	}
+	if current == nil {
+		return []CHPLChange{change.with(CHPLChangeRemoved, previous.Name, "")}
+	}
+
+	var changes []CHPLChange
+	if previous.Name != current.Name {
+		changes = append(changes, change.with("name", previous.Name, current.Name))
+	}
+	if previous.Status != current.Status {
+		changes = append(changes, change.with("status", previous.Status, current.Status))
+	}
+	if previous.URL != current.URL {
+		changes = append(changes, change.with("url", previous.URL, current.URL))
+	}
+	if previous.DeveloperCode != current.DeveloperCode {
+		changes = append(changes, change.with("developer_code", previous.DeveloperCode, current.DeveloperCode))
+	}
+	return changes
+}
+
+// CertificationCriteriaChanges returns the changes between two versions of a certification criteria. previous is
+// nil when the criteria was added and current is nil when it was removed from the store.
+func CertificationCriteriaChanges(previous *CertificationCriteria, current *CertificationCriteria, changedAt time.Time) []CHPLChange {
+	if previous == nil && current == nil {
+		return nil
+	}
+	criteria := current
+	if criteria == nil {
+		criteria = previous
+	}
+	change := CHPLChange{Kind: CHPLCriteria, CHPLID: strconv.Itoa(criteria.CertificationID), Name: criteria.CertificationNumber, ChangedAt: changedAt}
+	if previous == nil {
+		return []CHPLChange{change.with(CHPLChangeAdded, "", current.Title)}
+	}
+	if current == nil {
+		return []CHPLChange{change.with(CHPLChangeRemoved, previous.Title, "")}
+	}
+
+	var changes []CHPLChange
+	if previous.CertificationNumber != current.CertificationNumber {
+		changes = append(changes, change.with("certification_number", previous.CertificationNumber, current.CertificationNumber))
+	}
+	if previous.Title != current.Title {
+		changes = append(changes, change.with("title", previous.Title, current.Title))
+	}
+	if previous.Description != current.Description {
+		changes = append(changes, change.with("description", previous.Description, current.Description))
+	}
+	if previous.Removed != current.Removed {
+		changes = append(changes, change.with("removed", strconv.FormatBool(previous.Removed), strconv.FormatBool(current.Removed)))
+	}
+	return changes
+}
+
+func (c CHPLChange) with(change string, previous string, current string) CHPLChange {
+	c.Change = change
+	c.Previous = previous
+	c.Current = current
+	return c
+}
+
+func joinInts(ints []int) string {
+	strs := make([]string, len(ints))
+	for i, val := range ints {
+		strs[i] = strconv.Itoa(val)
+	}
+	return strings.Join(strs, ",")
+}
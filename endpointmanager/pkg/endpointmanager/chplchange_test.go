@@ -0,0 +1,100 @@
+package endpointmanager
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_HealthITProductChanges(t *testing.T) {
+	changedAt := time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC)
+	previous := &HealthITProduct{
+		Name:                  "Carefluence Open API",
+		Version:               "1",
+		APIURL:                "http://carefluence.com/docs.html",
+		CertificationCriteria: []int{30, 31},
+		CertificationStatus:   "Active",
+		CertificationDate:     time.Date(2016, 7, 1, 0, 0, 0, 0, time.UTC),
+		CertificationEdition:  "2014",
+		CHPLID:                "15.04.04.2657.Care.01.00.0.160701",
+	}
+
+	added := HealthITProductChanges(nil, previous, changedAt)
+	if len(added) != 1 || added[0].Change != CHPLChangeAdded || added[0].Current != "1" {
+		t.Errorf("expected the product to be added. Got %+v", added)
+	}
+	if added[0].Kind != CHPLProduct || added[0].Name != "Carefluence Open API 1" || !added[0].ChangedAt.Equal(changedAt) {
+		t.Errorf("expected the change to describe the product. Got %+v", added[0])
+	}
+
+	removed := HealthITProductChanges(previous, nil, changedAt)
+	if len(removed) != 1 || removed[0].Change != CHPLChangeRemoved {
+		t.Errorf("expected the product to be removed. Got %+v", removed)
+	}
+
+	same := *previous
+	if changes := HealthITProductChanges(previous, &same, changedAt); len(changes) != 0 {
+		t.Errorf("expected no changes for the same product. Got %+v", changes)
+	}
+
+	current := *previous
+	current.CertificationStatus = "Retired"
+	current.APIURL = "http://carefluence.com/docs-v2.html"
+	current.CertificationCriteria = []int{30, 31, 32}
+	changes := HealthITProductChanges(previous, &current, changedAt)
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes. Got %+v", changes)
+	}
+	if changes[0].Change != "certification_status" || changes[0].Previous != "Active" || changes[0].Current != "Retired" {
+		t.Errorf("unexpected certification status change %+v", changes[0])
+	}
+	if changes[1].Change != "api_url" || changes[1].Current != "http://carefluence.com/docs-v2.html" {
+		t.Errorf("unexpected api url change %+v", changes[1])
+	}
+	if changes[2].Change != "certification_criteria" || changes[2].Previous != "30,31" || changes[2].Current != "30,31,32" {
+		t.Errorf("unexpected certification criteria change %+v", changes[2])
+	}
+
+	withdrawn := *previous
+	withdrawn.CertificationStatus = "Withdrawn by ONC-ACB"
+	changes = HealthITProductChanges(previous, &withdrawn, changedAt)
+	if len(changes) != 1 || changes[0].Change != CHPLChangeWithdrawn {
+		t.Errorf("expected the product to be withdrawn. Got %+v", changes)
+	}
+}
+
+func Test_VendorChanges(t *testing.T) {
+	changedAt := time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC)
+	previous := &Vendor{Name: "Epic Systems Corporation", DeveloperCode: "1447", URL: "http://www.epic.com", Status: "Active", CHPLID: 448}
+
+	added := VendorChanges(nil, previous, changedAt)
+	if len(added) != 1 || added[0].Change != CHPLChangeAdded || added[0].CHPLID != "448" {
+		t.Errorf("expected the vendor to be added. Got %+v", added)
+	}
+
+	current := *previous
+	current.Status = "Suspended by ONC"
+	current.LastModifiedInCHPL = changedAt
+	changes := VendorChanges(previous, &current, changedAt)
+	if len(changes) != 1 || changes[0].Change != "status" || changes[0].Current != "Suspended by ONC" {
+		t.Errorf("expected the vendor status to change. Got %+v", changes)
+	}
+}
+
+func Test_CertificationCriteriaChanges(t *testing.T) {
+	changedAt := time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC)
+	previous := &CertificationCriteria{CertificationID: 44, CertificationNumber: "170.315 (f)(2)", Title: "Transmission to Public Health Agencies"}
+
+	current := *previous
+	current.Removed = true
+	changes := CertificationCriteriaChanges(previous, &current, changedAt)
+	if len(changes) != 1 || changes[0].Change != "removed" || changes[0].Previous != "false" || changes[0].Current != "true" {
+		t.Errorf("expected the criteria to be marked removed. Got %+v", changes)
+	}
+	if changes[0].Kind != CHPLCriteria || changes[0].CHPLID != "44" || changes[0].Name != "170.315 (f)(2)" {
+		t.Errorf("expected the change to describe the criteria. Got %+v", changes[0])
+	}
+
+	if changes := CertificationCriteriaChanges(nil, nil, changedAt); changes != nil {
+		t.Errorf("expected no changes. Got %+v", changes)
+	}
+}
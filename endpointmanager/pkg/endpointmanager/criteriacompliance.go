@@ -0,0 +1,25 @@
+package endpointmanager
+
+// StandardizedAPICriteria is the certification number of the criteria for standardized API access to patient and
+// population services. Products certified to it must publish a service base URL list for their FHIR endpoints.
+const StandardizedAPICriteria = "170.315 (g)(10)"
+
+// EndpointCriteriaCompliance describes whether the health IT product that a FHIR endpoint was matched to is
+// certified to a certification criteria. HTTPResponse is the response to the endpoint's most recent capability
+// query, or 0 if it has not been queried.
+type EndpointCriteriaCompliance struct {
+	URL                 string
+	HTTPResponse        int
+	HealthITProductID   int
+	ProductName         string
+	ProductVersion      string
+	CHPLID              string
+	VendorName          string
+	CertificationNumber string
+	Certified           bool
+}
+
+// Live returns true if the endpoint responded to its most recent capability query with a 200 status.
+func (c *EndpointCriteriaCompliance) Live() bool {
+	return c.HTTPResponse == 200
+}
@@ -0,0 +1,22 @@
+package endpointmanager
+
+import (
+	"testing"
+)
+
+func Test_EndpointCriteriaComplianceLive(t *testing.T) {
+	compliance := &EndpointCriteriaCompliance{HTTPResponse: 200}
+	if !compliance.Live() {
+		t.Errorf("Expected an endpoint with a 200 response to be live")
+	}
+
+	compliance.HTTPResponse = 404
+	if compliance.Live() {
+		t.Errorf("Expected an endpoint with a 404 response to not be live")
+	}
+
+	compliance.HTTPResponse = 0
+	if compliance.Live() {
+		t.Errorf("Expected an endpoint that has not been queried to not be live")
+	}
+}
@@ -0,0 +1,9 @@
+package endpointmanager
+
+// EndpointSnapshot is the state of an endpoint for one requested FHIR version at a point in time: the
+// FHIREndpointInfo that was in effect, along with the FHIREndpointMetadata it referenced, and its Vendor as the
+// Vendor was at that time.
+type EndpointSnapshot struct {
+	Info   *FHIREndpointInfo // Info.Metadata is nil if the metadata has since been removed by the history retention
+	Vendor *Vendor           // nil if the info did not have a vendor or the vendor did not exist at that time
+}
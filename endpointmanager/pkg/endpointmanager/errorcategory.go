@@ -0,0 +1,130 @@
+package endpointmanager
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+)
+
+// ErrorCategory is the cause of a failed request to a FHIR endpoint. It is stored alongside the raw
+// error message so that failures can be grouped by cause.
+type ErrorCategory string
+
+const (
+	// NoError indicates that the request succeeded
+	NoError ErrorCategory = ""
+	// DNSFailure indicates that the endpoint's host name could not be resolved
+	DNSFailure ErrorCategory = "dns_failure"
+	// ConnectionRefused indicates that the endpoint's host refused the connection
+	ConnectionRefused ErrorCategory = "connection_refused"
+	// Timeout indicates that the request did not complete in time
+	Timeout ErrorCategory = "timeout"
+	// TLSHandshake indicates that a TLS connection could not be negotiated with the endpoint
+	TLSHandshake ErrorCategory = "tls_handshake"
+	// CertificateInvalid indicates that the endpoint's certificate could not be verified
+	CertificateInvalid ErrorCategory = "certificate_invalid"
+	// HTTPClientError indicates that the endpoint responded with a 4xx status code
+	HTTPClientError ErrorCategory = "http_4xx"
+	// HTTPServerError indicates that the endpoint responded with a 5xx status code
+	HTTPServerError ErrorCategory = "http_5xx"
+	// NonJSONBody indicates that the endpoint's response was not JSON
+	NonJSONBody ErrorCategory = "non_json_body"
+	// InvalidFHIRJSON indicates that the endpoint's response was JSON but not a FHIR resource
+	InvalidFHIRJSON ErrorCategory = "invalid_fhir_json"
+	// WrongResourceType indicates that the endpoint's response was a FHIR resource of an unexpected type
+	WrongResourceType ErrorCategory = "wrong_resource_type"
+	// UnknownError indicates that the request failed for a reason that does not fit any other category
+	UnknownError ErrorCategory = "unknown"
+)
+
+// ErrorCategories is the list of all error categories that a failed request may be assigned
+var ErrorCategories = []ErrorCategory{
+	DNSFailure,
+	ConnectionRefused,
+	Timeout,
+	TLSHandshake,
+	CertificateInvalid,
+	HTTPClientError,
+	HTTPServerError,
+	NonJSONBody,
+	InvalidFHIRJSON,
+	WrongResourceType,
+	UnknownError,
+}
+
+// ClassifyError returns the category of an error returned while making or reading a request to a FHIR endpoint.
+// A nil error is classified as NoError.
+func ClassifyError(err error) ErrorCategory {
+	if err == nil {
+		return NoError
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return DNSFailure
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ConnectionRefused
+	}
+
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var certInvalidErr x509.CertificateInvalidError
+	if errors.As(err, &unknownAuthorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &certInvalidErr) {
+		return CertificateInvalid
+	}
+
+	var recordHeaderErr tls.RecordHeaderError
+	if errors.As(err, &recordHeaderErr) || strings.Contains(err.Error(), "tls: ") {
+		return TLSHandshake
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return Timeout
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return NonJSONBody
+	}
+
+	return UnknownError
+}
+
+// ClassifyHTTPStatus returns HTTPClientError for 4xx status codes, HTTPServerError for 5xx status codes,
+// and NoError otherwise.
+func ClassifyHTTPStatus(statusCode int) ErrorCategory {
+	if statusCode >= http.StatusBadRequest && statusCode < http.StatusInternalServerError {
+		return HTTPClientError
+	}
+	if statusCode >= http.StatusInternalServerError && statusCode < 600 {
+		return HTTPServerError
+	}
+	return NoError
+}
+
+// ClassifyFHIRResource returns the category of a parsed JSON response body that was expected to be a FHIR
+// resource with one of the given resource types. It returns NoError if the body is such a resource.
+func ClassifyFHIRResource(body interface{}, resourceTypes ...string) ErrorCategory {
+	resource, ok := body.(map[string]interface{})
+	if !ok {
+		return InvalidFHIRJSON
+	}
+	resourceType, ok := resource["resourceType"].(string)
+	if !ok {
+		return InvalidFHIRJSON
+	}
+	for _, expected := range resourceTypes {
+		if resourceType == expected {
+			return NoError
+		}
+	}
+	return WrongResourceType
+}
@@ -0,0 +1,85 @@
+package endpointmanager
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func Test_ClassifyError(t *testing.T) {
+	wrap := func(err error) error {
+		return &url.Error{Op: "Get", URL: "http://example.com/metadata", Err: err}
+	}
+
+	var syntaxErr error
+	var body interface{}
+	syntaxErr = json.Unmarshal([]byte("<html></html>"), &body)
+
+	cases := []struct {
+		err      error
+		expected ErrorCategory
+	}{
+		{nil, NoError},
+		{wrap(&net.DNSError{Err: "no such host", Name: "example.com", IsNotFound: true}), DNSFailure},
+		{wrap(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), ConnectionRefused},
+		{wrap(context.DeadlineExceeded), Timeout},
+		{wrap(x509.UnknownAuthorityError{}), CertificateInvalid},
+		{wrap(x509.HostnameError{Certificate: &x509.Certificate{}, Host: "example.com"}), CertificateInvalid},
+		{wrap(fmt.Errorf("remote error: tls: handshake failure")), TLSHandshake},
+		{syntaxErr, NonJSONBody},
+		{fmt.Errorf("something else went wrong"), UnknownError},
+	}
+
+	for _, c := range cases {
+		actual := ClassifyError(c.err)
+		if actual != c.expected {
+			t.Errorf("Expected error %v to be classified as %q, got %q", c.err, c.expected, actual)
+		}
+	}
+}
+
+func Test_ClassifyHTTPStatus(t *testing.T) {
+	cases := map[int]ErrorCategory{
+		200: NoError,
+		301: NoError,
+		404: HTTPClientError,
+		406: HTTPClientError,
+		500: HTTPServerError,
+		502: HTTPServerError,
+	}
+
+	for code, expected := range cases {
+		actual := ClassifyHTTPStatus(code)
+		if actual != expected {
+			t.Errorf("Expected status %d to be classified as %q, got %q", code, expected, actual)
+		}
+	}
+}
+
+func Test_ClassifyFHIRResource(t *testing.T) {
+	cases := []struct {
+		body     interface{}
+		expected ErrorCategory
+	}{
+		{map[string]interface{}{"resourceType": "CapabilityStatement"}, NoError},
+		{map[string]interface{}{"resourceType": "Conformance"}, NoError},
+		{map[string]interface{}{"resourceType": "OperationOutcome"}, WrongResourceType},
+		{map[string]interface{}{"status": "active"}, InvalidFHIRJSON},
+		{map[string]interface{}{"resourceType": 5}, InvalidFHIRJSON},
+		{[]interface{}{"CapabilityStatement"}, InvalidFHIRJSON},
+		{nil, InvalidFHIRJSON},
+	}
+
+	for _, c := range cases {
+		actual := ClassifyFHIRResource(c.body, "CapabilityStatement", "Conformance")
+		if actual != c.expected {
+			t.Errorf("Expected %v to be classified as %q, got %q", c.body, c.expected, actual)
+		}
+	}
+}
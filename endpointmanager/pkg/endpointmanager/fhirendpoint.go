@@ -12,12 +12,15 @@ import (
 // HealthITProduct and populated by a ProviderOrganization.
 // Information about the FHIR API endpoint is populated by the FHIR
 // capability statement found at that endpoint as well as information
-// discovered about the IP address of the endpoint.
+// discovered about the IP address of the endpoint, which is stored
+// separately as a FHIREndpointHosting. Locations holds the addresses of the
+// endpoint's organizations when the endpoint list provides them.
 type FHIREndpoint struct {
 	ID                int
 	URL               string
 	OrganizationNames []string
 	NPIIDs            []string
+	Locations         []*Location
 	ListSource        string
 	VersionsResponse  versionsoperatorparser.VersionsResponse
 	CreatedAt         time.Time
@@ -43,6 +46,14 @@ func (e *FHIREndpoint) Equal(e2 *FHIREndpoint) bool {
 	if !helpers.StringArraysEqual(e.NPIIDs, e2.NPIIDs) {
 		return false
 	}
+	if len(e.Locations) != len(e2.Locations) {
+		return false
+	}
+	for _, location := range e.Locations {
+		if !e2.hasLocation(location) {
+			return false
+		}
+	}
 	if !e.VersionsResponse.Equal(e2.VersionsResponse) {
 		return false
 	}
@@ -92,6 +103,22 @@ func (e *FHIREndpoint) AddNPIID(npiID string) {
 	}
 }
 
+// AddLocation adds the location to the endpoint's Locations list if it's not present already. If it is, it does nothing.
+func (e *FHIREndpoint) AddLocation(location *Location) {
+	if location != nil && !e.hasLocation(location) {
+		e.Locations = append(e.Locations, location)
+	}
+}
+
+func (e *FHIREndpoint) hasLocation(location *Location) bool {
+	for _, existing := range e.Locations {
+		if existing.Equal(location) {
+			return true
+		}
+	}
+	return false
+}
+
 // Prepends url with https:// and appends with .well-know/smart-configuration/ if needed
 func NormalizeWellKnownURL(url string) string {
 	normalized := NormalizeURL(url)
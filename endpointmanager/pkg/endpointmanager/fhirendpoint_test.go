@@ -153,6 +153,12 @@ func Test_FHIREndpointEqual(t *testing.T) {
 	}
 	endpoint2.NPIIDs = endpoint1.NPIIDs
 
+	endpoint2.Locations = []*Location{{City: "Boston", State: "MA", ZipCode: "02115"}}
+	if endpoint1.Equal(endpoint2) {
+		t.Error("Did not expect endpoint1 to equal endpoint 2. Locations should be different.")
+	}
+	endpoint2.Locations = endpoint1.Locations
+
 	endpoint2.ListSource = "other"
 	if endpoint1.Equal(endpoint2) {
 		t.Errorf("Did not expect endpoint1 to equal endpoint 2. ListSource should be different. %s vs %s", endpoint1.ListSource, endpoint2.ListSource)
@@ -235,3 +241,28 @@ func Test_AddNPIID(t *testing.T) {
 	endpoint.AddNPIID(npiID)
 	th.Assert(t, helpers.StringArraysEqual(endpoint.NPIIDs, expected), fmt.Sprintf("expected %v to equal %v", endpoint.NPIIDs, expected))
 }
+
+func Test_AddLocation(t *testing.T) {
+	var endpoint = &FHIREndpoint{
+		URL:        "example.com/FHIR/DSTU2",
+		ListSource: "https://open.epic.com/MyApps/EndpointsJson"}
+
+	location1 := &Location{City: "Boston", State: "MA", ZipCode: "02115"}
+	location2 := &Location{City: "Springfield", State: "IL", ZipCode: "62701"}
+
+	// test with empty locations list
+	endpoint.AddLocation(location1)
+	th.Assert(t, len(endpoint.Locations) == 1, fmt.Sprintf("expected 1 location, got %d", len(endpoint.Locations)))
+
+	// test with non-empty locations list
+	endpoint.AddLocation(location2)
+	th.Assert(t, len(endpoint.Locations) == 2, fmt.Sprintf("expected 2 locations, got %d", len(endpoint.Locations)))
+
+	// test with location that's already in list
+	endpoint.AddLocation(&Location{City: "Boston", State: "MA", ZipCode: "02115"})
+	th.Assert(t, len(endpoint.Locations) == 2, fmt.Sprintf("expected 2 locations, got %d", len(endpoint.Locations)))
+
+	// test with nil location
+	endpoint.AddLocation(nil)
+	th.Assert(t, len(endpoint.Locations) == 2, fmt.Sprintf("expected 2 locations, got %d", len(endpoint.Locations)))
+}
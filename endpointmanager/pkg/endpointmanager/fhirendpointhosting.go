@@ -0,0 +1,89 @@
+package endpointmanager
+
+import (
+	"time"
+)
+
+// FHIREndpointHosting represents the DNS and network information discovered about the host of a FHIR endpoint.
+// IPAddresses contains an entry for each A and AAAA record that the endpoint's host name resolved to. Errors
+// holds any error encountered while resolving the host name.
+type FHIREndpointHosting struct {
+	ID          int
+	URL         string
+	Hostname    string
+	IPAddresses []IPAddressInfo
+	Errors      string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// IPAddressInfo represents a single IP address that a FHIR endpoint's host name resolved to, the names found
+// through a reverse DNS lookup of that address, and the autonomous system (AS) that announces it.
+type IPAddressInfo struct {
+	IP             string   `json:"ip"`
+	Family         string   `json:"family"`
+	ReverseDNS     []string `json:"reverseDNS"`
+	ASN            int      `json:"asn"`
+	ASOrganization string   `json:"asOrganization"`
+	CountryCode    string   `json:"countryCode"`
+}
+
+// Equal checks each field of the two IPAddressInfos to see if they are equal.
+func (i IPAddressInfo) Equal(i2 IPAddressInfo) bool {
+	if i.IP != i2.IP {
+		return false
+	}
+	if i.Family != i2.Family {
+		return false
+	}
+	if len(i.ReverseDNS) != len(i2.ReverseDNS) {
+		return false
+	}
+	for idx := range i.ReverseDNS {
+		if i.ReverseDNS[idx] != i2.ReverseDNS[idx] {
+			return false
+		}
+	}
+	if i.ASN != i2.ASN {
+		return false
+	}
+	if i.ASOrganization != i2.ASOrganization {
+		return false
+	}
+	if i.CountryCode != i2.CountryCode {
+		return false
+	}
+
+	return true
+}
+
+// Equal checks each field of the two FHIREndpointHostings except for the database ID, CreatedAt and UpdatedAt fields to see if they are equal.
+func (h *FHIREndpointHosting) Equal(h2 *FHIREndpointHosting) bool {
+	if h == nil && h2 == nil {
+		return true
+	} else if h == nil {
+		return false
+	} else if h2 == nil {
+		return false
+	}
+
+	if h.URL != h2.URL {
+		return false
+	}
+	if h.Hostname != h2.Hostname {
+		return false
+	}
+	if len(h.IPAddresses) != len(h2.IPAddresses) {
+		return false
+	}
+	for idx := range h.IPAddresses {
+		if !h.IPAddresses[idx].Equal(h2.IPAddresses[idx]) {
+			return false
+		}
+	}
+	if h.Errors != h2.Errors {
+		return false
+	}
+
+	return true
+}
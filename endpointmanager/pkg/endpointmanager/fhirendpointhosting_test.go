@@ -0,0 +1,93 @@
+package endpointmanager
+
+import (
+	"testing"
+)
+
+func Test_FHIREndpointHostingEqual(t *testing.T) {
+	var hosting1 = &FHIREndpointHosting{
+		ID:       1,
+		URL:      "http://www.example.com/fhir",
+		Hostname: "www.example.com",
+		IPAddresses: []IPAddressInfo{
+			{
+				IP:             "93.184.216.34",
+				Family:         "IPv4",
+				ReverseDNS:     []string{"www.example.com."},
+				ASN:            15133,
+				ASOrganization: "EDGECAST",
+				CountryCode:    "US",
+			},
+		},
+	}
+	var hosting2 = &FHIREndpointHosting{
+		ID:       1,
+		URL:      "http://www.example.com/fhir",
+		Hostname: "www.example.com",
+		IPAddresses: []IPAddressInfo{
+			{
+				IP:             "93.184.216.34",
+				Family:         "IPv4",
+				ReverseDNS:     []string{"www.example.com."},
+				ASN:            15133,
+				ASOrganization: "EDGECAST",
+				CountryCode:    "US",
+			},
+		},
+	}
+
+	if !hosting1.Equal(hosting2) {
+		t.Errorf("Expected hosting1 to equal hosting2. They are not equal.")
+	}
+
+	hosting2.ID = 2
+	if !hosting1.Equal(hosting2) {
+		t.Errorf("Expect hosting1 to equal hosting2. ids should be ignored. %d vs %d", hosting1.ID, hosting2.ID)
+	}
+	hosting2.ID = hosting1.ID
+
+	hosting2.URL = "other"
+	if hosting1.Equal(hosting2) {
+		t.Errorf("Did not expect hosting1 to equal hosting2. URL should be different. %s vs %s", hosting1.URL, hosting2.URL)
+	}
+	hosting2.URL = hosting1.URL
+
+	hosting2.Hostname = "other"
+	if hosting1.Equal(hosting2) {
+		t.Errorf("Did not expect hosting1 to equal hosting2. Hostname should be different. %s vs %s", hosting1.Hostname, hosting2.Hostname)
+	}
+	hosting2.Hostname = hosting1.Hostname
+
+	hosting2.Errors = "other"
+	if hosting1.Equal(hosting2) {
+		t.Errorf("Did not expect hosting1 to equal hosting2. Errors should be different. %s vs %s", hosting1.Errors, hosting2.Errors)
+	}
+	hosting2.Errors = hosting1.Errors
+
+	hosting2.IPAddresses[0].ASN = 1
+	if hosting1.Equal(hosting2) {
+		t.Errorf("Did not expect hosting1 to equal hosting2. ASN should be different. %d vs %d", hosting1.IPAddresses[0].ASN, hosting2.IPAddresses[0].ASN)
+	}
+	hosting2.IPAddresses[0].ASN = hosting1.IPAddresses[0].ASN
+
+	hosting2.IPAddresses[0].ReverseDNS = []string{"other.example.com."}
+	if hosting1.Equal(hosting2) {
+		t.Errorf("Did not expect hosting1 to equal hosting2. Reverse DNS should be different. %v vs %v", hosting1.IPAddresses[0].ReverseDNS, hosting2.IPAddresses[0].ReverseDNS)
+	}
+	hosting2.IPAddresses[0].ReverseDNS = hosting1.IPAddresses[0].ReverseDNS
+
+	hosting2.IPAddresses = append(hosting2.IPAddresses, IPAddressInfo{IP: "2606:2800:220:1:248:1893:25c8:1946", Family: "IPv6"})
+	if hosting1.Equal(hosting2) {
+		t.Errorf("Did not expect hosting1 to equal hosting2. IP addresses should be different. %v vs %v", hosting1.IPAddresses, hosting2.IPAddresses)
+	}
+	hosting2.IPAddresses = hosting1.IPAddresses
+
+	hosting2 = nil
+	if hosting1.Equal(hosting2) {
+		t.Errorf("Did not expect hosting1 to equal nil hosting2.")
+	}
+	hosting1 = nil
+	if !hosting1.Equal(hosting2) {
+		t.Errorf("Expected nil hosting1 to equal nil hosting2.")
+	}
+}
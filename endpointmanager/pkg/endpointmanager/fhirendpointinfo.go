@@ -12,7 +12,10 @@ import (
 // FHIREndpointInfo represents a fielded FHIR API endpoint hosted by a
 // HealthITProduct and populated by a ProviderOrganization.
 // Information about the FHIR API endpoint is populated by the FHIR
-// capability statement found at that endpoint.
+// capability statement found at that endpoint. HTTPVersion and ALPNProtocol are the
+// HTTP protocol version and TLS application protocol negotiated with the endpoint, and
+// IPReachability records whether the endpoint could be reached over each IP family when
+// the querier checks IPv4 and IPv6 separately.
 type FHIREndpointInfo struct {
 	ID                    int
 	HealthITProductID     int
@@ -30,8 +33,18 @@ type FHIREndpointInfo struct {
 	Metadata              *FHIREndpointMetadata
 	RequestedFhirVersion  string
 	CapabilityFhirVersion string
+	HTTPVersion           string
+	ALPNProtocol          string
+	IPReachability        map[string]bool
 }
 
+const (
+	// IPv4Family is the key used for IPv4 in per IP family results
+	IPv4Family = "IPv4"
+	// IPv6Family is the key used for IPv6 in per IP family results
+	IPv6Family = "IPv6"
+)
+
 // EqualExcludeMetadata checks each field of the two FHIREndpointInfos except for metadata fields to see if they are equal.
 func (e *FHIREndpointInfo) EqualExcludeMetadata(e2 *FHIREndpointInfo) bool {
 	if e == nil && e2 == nil {
@@ -68,6 +81,23 @@ func (e *FHIREndpointInfo) EqualExcludeMetadata(e2 *FHIREndpointInfo) bool {
 	if e.CapabilityFhirVersion != e2.CapabilityFhirVersion {
 		return false
 	}
+
+	if e.HTTPVersion != e2.HTTPVersion {
+		return false
+	}
+
+	if e.ALPNProtocol != e2.ALPNProtocol {
+		return false
+	}
+
+	if len(e.IPReachability) != len(e2.IPReachability) {
+		return false
+	}
+	for family, reachable := range e.IPReachability {
+		if reachable2, ok := e2.IPReachability[family]; !ok || reachable != reachable2 {
+			return false
+		}
+	}
 	// because CapabilityStatement is an interface, we need to confirm it's not nil before using the Equal
 	// method.
 	if e.CapabilityStatement != nil && !e.CapabilityStatement.Equal(e2.CapabilityStatement) {
@@ -161,6 +191,7 @@ const (
 	UniqueResourcesRule  RuleOption = "uniqueResourcesRule"
 	SearchParamsRule     RuleOption = "searchParamsRule"
 	VersionsResponseRule RuleOption = "versionsResponseRule"
+	HTTPVersionRule      RuleOption = "httpVersionRule"
 )
 
 // compareOperations compares the operation resource fields for an endpoint
@@ -311,6 +311,18 @@ func Test_FHIREndpointInfoEqual(t *testing.T) {
 	}
 	endpointInfo2.TLSVersion = endpointInfo1.TLSVersion
 
+	endpointInfo2.HTTPVersion = "HTTP/1.0"
+	if endpointInfo1.Equal(endpointInfo2) {
+		t.Errorf("Did not expect endpointInfo1 to equal endpointInfo 2. HTTPVersion should be different. %s vs %s", endpointInfo1.HTTPVersion, endpointInfo2.HTTPVersion)
+	}
+	endpointInfo2.HTTPVersion = endpointInfo1.HTTPVersion
+
+	endpointInfo2.IPReachability = map[string]bool{IPv4Family: true, IPv6Family: false}
+	if endpointInfo1.Equal(endpointInfo2) {
+		t.Errorf("Did not expect endpointInfo1 to equal endpointInfo 2. IPReachability should be different. %v vs %v", endpointInfo1.IPReachability, endpointInfo2.IPReachability)
+	}
+	endpointInfo2.IPReachability = endpointInfo1.IPReachability
+
 	endpointInfo2.MIMETypes = []string{"other"}
 	if endpointInfo1.Equal(endpointInfo2) {
 		t.Errorf("Did not expect endpointInfo1 to equal endpointInfo 2. MIMETypes should be different. %s vs %s", endpointInfo1.MIMETypes, endpointInfo2.MIMETypes)
@@ -13,12 +13,19 @@ type FHIREndpointMetadata struct {
 	URL                  string
 	HTTPResponse         int
 	Errors               string
+	ErrorCategory        ErrorCategory
 	CreatedAt            time.Time
 	UpdatedAt            time.Time
 	SMARTHTTPResponse    int
 	ResponseTime         float64
 	Availability         float64
 	RequestedFhirVersion string
+	Redirects            []Redirect
+	SMARTRedirects       []Redirect
+	PermanentRedirect    bool
+	CanonicalURL         string
+	IPResponseTimes      map[string]float64
+	LatencyBreakdown     LatencyBreakdown
 }
 
 // Equal checks each field of the two FHIREndpointMetadatass except for the database ID, CreatedAt and UpdatedAt fields to see if they are equal.
@@ -43,6 +50,9 @@ func (e *FHIREndpointMetadata) Equal(e2 *FHIREndpointMetadata) bool {
 	if e.Errors != e2.Errors {
 		return false
 	}
+	if e.ErrorCategory != e2.ErrorCategory {
+		return false
+	}
 	if e.SMARTHTTPResponse != e2.SMARTHTTPResponse {
 		return false
 	}
@@ -52,6 +62,29 @@ func (e *FHIREndpointMetadata) Equal(e2 *FHIREndpointMetadata) bool {
 	if e.RequestedFhirVersion != e2.RequestedFhirVersion {
 		return false
 	}
+	if !RedirectsEqual(e.Redirects, e2.Redirects) {
+		return false
+	}
+	if !RedirectsEqual(e.SMARTRedirects, e2.SMARTRedirects) {
+		return false
+	}
+	if e.PermanentRedirect != e2.PermanentRedirect {
+		return false
+	}
+	if e.CanonicalURL != e2.CanonicalURL {
+		return false
+	}
+	if len(e.IPResponseTimes) != len(e2.IPResponseTimes) {
+		return false
+	}
+	for family, responseTime := range e.IPResponseTimes {
+		if responseTime2, ok := e2.IPResponseTimes[family]; !ok || !cmp.Equal(responseTime, responseTime2) {
+			return false
+		}
+	}
+	if !e.LatencyBreakdown.Equal(e2.LatencyBreakdown) {
+		return false
+	}
 
 	return true
 }
@@ -70,6 +70,12 @@ func Test_FHIREndpointMetadataEqual(t *testing.T) {
 	}
 	endpointMetadata2.Errors = endpointMetadata1.Errors
 
+	endpointMetadata2.ErrorCategory = Timeout
+	if endpointMetadata1.Equal(endpointMetadata2) {
+		t.Errorf("Did not expect endpointMetadata1 to equal endpointMetadata2. ErrorCategory should be different. %s vs %s", endpointMetadata1.ErrorCategory, endpointMetadata2.ErrorCategory)
+	}
+	endpointMetadata2.ErrorCategory = endpointMetadata1.ErrorCategory
+
 	endpointMetadata2.ResponseTime = 0.234567
 	if endpointMetadata1.Equal(endpointMetadata2) {
 		t.Errorf("Did not expect endpointMetadata1 to equal endpointMetadata2. ResponseTime should be different. %f vs %f", endpointMetadata1.ResponseTime, endpointMetadata2.ResponseTime)
@@ -82,6 +88,42 @@ func Test_FHIREndpointMetadataEqual(t *testing.T) {
 	}
 	endpointMetadata2.RequestedFhirVersion = endpointMetadata1.RequestedFhirVersion
 
+	endpointMetadata2.Redirects = []Redirect{{StatusCode: 301, Location: "https://www.example.com/metadata"}}
+	if endpointMetadata1.Equal(endpointMetadata2) {
+		t.Errorf("Did not expect endpointMetadata1 to equal endpointMetadata2. Redirects should be different. %v vs %v", endpointMetadata1.Redirects, endpointMetadata2.Redirects)
+	}
+	endpointMetadata2.Redirects = endpointMetadata1.Redirects
+
+	endpointMetadata2.SMARTRedirects = []Redirect{{StatusCode: 302, Location: "https://www.example.com/.well-known/smart-configuration"}}
+	if endpointMetadata1.Equal(endpointMetadata2) {
+		t.Errorf("Did not expect endpointMetadata1 to equal endpointMetadata2. SMARTRedirects should be different. %v vs %v", endpointMetadata1.SMARTRedirects, endpointMetadata2.SMARTRedirects)
+	}
+	endpointMetadata2.SMARTRedirects = endpointMetadata1.SMARTRedirects
+
+	endpointMetadata2.PermanentRedirect = true
+	if endpointMetadata1.Equal(endpointMetadata2) {
+		t.Errorf("Did not expect endpointMetadata1 to equal endpointMetadata2. PermanentRedirect should be different. %t vs %t", endpointMetadata1.PermanentRedirect, endpointMetadata2.PermanentRedirect)
+	}
+	endpointMetadata2.PermanentRedirect = endpointMetadata1.PermanentRedirect
+
+	endpointMetadata2.CanonicalURL = "https://www.example.com/"
+	if endpointMetadata1.Equal(endpointMetadata2) {
+		t.Errorf("Did not expect endpointMetadata1 to equal endpointMetadata2. CanonicalURL should be different. %s vs %s", endpointMetadata1.CanonicalURL, endpointMetadata2.CanonicalURL)
+	}
+	endpointMetadata2.CanonicalURL = endpointMetadata1.CanonicalURL
+
+	endpointMetadata2.IPResponseTimes = map[string]float64{IPv4Family: 0.25}
+	if endpointMetadata1.Equal(endpointMetadata2) {
+		t.Errorf("Did not expect endpointMetadata1 to equal endpointMetadata2. IPResponseTimes should be different. %v vs %v", endpointMetadata1.IPResponseTimes, endpointMetadata2.IPResponseTimes)
+	}
+	endpointMetadata2.IPResponseTimes = endpointMetadata1.IPResponseTimes
+
+	endpointMetadata2.LatencyBreakdown.TLSHandshake = 1.5
+	if endpointMetadata1.Equal(endpointMetadata2) {
+		t.Errorf("Did not expect endpointMetadata1 to equal endpointMetadata2. LatencyBreakdown should be different. %+v vs %+v", endpointMetadata1.LatencyBreakdown, endpointMetadata2.LatencyBreakdown)
+	}
+	endpointMetadata2.LatencyBreakdown = endpointMetadata1.LatencyBreakdown
+
 	endpointMetadata2 = nil
 	if endpointMetadata1.Equal(endpointMetadata2) {
 		t.Errorf("Did not expect endpointMetadata1 to equal nil endpointMetadata2.")
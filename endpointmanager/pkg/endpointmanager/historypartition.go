@@ -0,0 +1,35 @@
+package endpointmanager
+
+import "time"
+
+// The tiers of a history partition. A partition starts with every row of its month. Once summarized, the
+// fhir_endpoints_info_history partition only keeps the rows where an endpoint's information changed and the
+// fhir_endpoints_metadata partition is replaced by daily aggregates, except for the rows that are still referenced.
+// A dropped partition no longer exists.
+const (
+	TierFull    = "full"
+	TierSummary = "summary"
+	TierDropped = "dropped"
+)
+
+// The tables that are partitioned by month.
+const (
+	InfoHistoryTable = "fhir_endpoints_info_history"
+	MetadataTable    = "fhir_endpoints_metadata"
+)
+
+// HistoryPartition is one month of the partitioned fhir_endpoints_info_history or fhir_endpoints_metadata table.
+type HistoryPartition struct {
+	Name        string
+	ParentTable string
+	Month       time.Time
+	Tier        string
+}
+
+// MonthsOld returns the number of whole months between the partition's month and the month of 'now'. The partition
+// for the current month is 0 months old.
+func (p *HistoryPartition) MonthsOld(now time.Time) int {
+	now = now.UTC()
+	month := p.Month.UTC()
+	return (now.Year()-month.Year())*12 + int(now.Month()) - int(month.Month())
+}
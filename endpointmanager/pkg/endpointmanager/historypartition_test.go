@@ -0,0 +1,32 @@
+package endpointmanager
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_HistoryPartitionMonthsOld(t *testing.T) {
+	partition := &HistoryPartition{
+		Name:        "fhir_endpoints_metadata_y2020m11",
+		ParentTable: MetadataTable,
+		Month:       time.Date(2020, time.November, 1, 0, 0, 0, 0, time.UTC),
+		Tier:        TierFull,
+	}
+
+	cases := []struct {
+		now      time.Time
+		expected int
+	}{
+		{time.Date(2020, time.November, 30, 23, 0, 0, 0, time.UTC), 0},
+		{time.Date(2020, time.December, 1, 0, 0, 0, 0, time.UTC), 1},
+		{time.Date(2021, time.January, 15, 0, 0, 0, 0, time.UTC), 2},
+		{time.Date(2021, time.November, 1, 0, 0, 0, 0, time.UTC), 12},
+		{time.Date(2020, time.October, 31, 0, 0, 0, 0, time.UTC), -1},
+	}
+
+	for _, c := range cases {
+		if actual := partition.MonthsOld(c.now); actual != c.expected {
+			t.Errorf("expected the partition to be %d months old at %s, got %d", c.expected, c.now, actual)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package endpointmanager
+
+import (
+	"github.com/google/go-cmp/cmp"
+)
+
+// LatencyBreakdown splits the time taken to make a request into the phases of the request, in seconds, so
+// that slow endpoints can be diagnosed as slow due to the network, the TLS handshake, or the server.
+// DNSLookup, TCPConnect and TLSHandshake are 0 when an existing connection was reused. TimeToFirstByte is
+// the time from when the request was written to when the first byte of the response was received, and
+// BodyTransfer is the time from then until the response body was read. BodyTransfer is 0 when the
+// response body was not read.
+type LatencyBreakdown struct {
+	DNSLookup       float64 `json:"dnsLookup"`
+	TCPConnect      float64 `json:"tcpConnect"`
+	TLSHandshake    float64 `json:"tlsHandshake"`
+	TimeToFirstByte float64 `json:"timeToFirstByte"`
+	BodyTransfer    float64 `json:"bodyTransfer"`
+}
+
+// Equal checks each phase of the two LatencyBreakdowns to see if they are equal.
+func (l LatencyBreakdown) Equal(l2 LatencyBreakdown) bool {
+	return cmp.Equal(l.DNSLookup, l2.DNSLookup) &&
+		cmp.Equal(l.TCPConnect, l2.TCPConnect) &&
+		cmp.Equal(l.TLSHandshake, l2.TLSHandshake) &&
+		cmp.Equal(l.TimeToFirstByte, l2.TimeToFirstByte) &&
+		cmp.Equal(l.BodyTransfer, l2.BodyTransfer)
+}
@@ -0,0 +1,25 @@
+package endpointmanager
+
+import (
+	"testing"
+)
+
+func Test_LatencyBreakdownEqual(t *testing.T) {
+	l1 := LatencyBreakdown{DNSLookup: 0.01, TCPConnect: 0.02, TLSHandshake: 0.05, TimeToFirstByte: 0.3, BodyTransfer: 0.04}
+	l2 := l1
+
+	if !l1.Equal(l2) {
+		t.Errorf("Expected latency breakdowns to be equal")
+	}
+
+	l2.TimeToFirstByte = 1.2
+	if l1.Equal(l2) {
+		t.Errorf("Did not expect latency breakdowns with different times to first byte to be equal")
+	}
+	l2.TimeToFirstByte = l1.TimeToFirstByte
+
+	l2.DNSLookup = 0
+	if l1.Equal(l2) {
+		t.Errorf("Did not expect latency breakdowns with different DNS lookup times to be equal")
+	}
+}
@@ -0,0 +1,115 @@
+package endpointmanager
+
+import (
+	"time"
+)
+
+// The statuses of an EndpointOrganizationReview.
+const (
+	ReviewPending  = "pending"
+	ReviewAccepted = "accepted"
+	ReviewRejected = "rejected"
+)
+
+// EndpointOrganizationReview represents a link between a FHIR endpoint and an NPI organization found by the
+// endpoint linker with a confidence below the review threshold. Pending links are not added to the
+// endpoint_organization table until a reviewer accepts them. Accepted and rejected reviews are applied on
+// every later run of the endpoint linker. DecidedBy and DecidedAt are empty while the review is pending.
+type EndpointOrganizationReview struct {
+	URL               string
+	OrganizationNPIID string
+	Confidence        float64
+	ScoreBreakdown    LinkScoreBreakdown
+	Status            string
+	DecidedBy         string
+	DecidedAt         time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// LinkScoreBreakdown records how the endpoint linker scored a link between a FHIR endpoint and an NPI organization.
+// NameScore is the weighted Jaccard index of the best matching pair of organization names, and SharedTokens are the
+// tokens those names have in common. LocationScores holds the score of each geographic component that was compared,
+// keyed by "address", "city", "state" and "zipcode".
+type LinkScoreBreakdown struct {
+	IDMatch          bool               `json:"idMatch"`
+	NameScore        float64            `json:"nameScore"`
+	EndpointName     string             `json:"endpointName"`
+	OrganizationName string             `json:"organizationName"`
+	SharedTokens     []string           `json:"sharedTokens"`
+	LocationScores   map[string]float64 `json:"locationScores"`
+	CombinedScore    float64            `json:"combinedScore"`
+}
+
+// Equal checks each field of the two EndpointOrganizationReviews except for the CreatedAt and UpdatedAt fields to see if they are equal.
+func (r *EndpointOrganizationReview) Equal(r2 *EndpointOrganizationReview) bool {
+	if r == nil && r2 == nil {
+		return true
+	} else if r == nil {
+		return false
+	} else if r2 == nil {
+		return false
+	}
+
+	if r.URL != r2.URL {
+		return false
+	}
+	if r.OrganizationNPIID != r2.OrganizationNPIID {
+		return false
+	}
+	if r.Confidence != r2.Confidence {
+		return false
+	}
+	if !r.ScoreBreakdown.Equal(r2.ScoreBreakdown) {
+		return false
+	}
+	if r.Status != r2.Status {
+		return false
+	}
+	if r.DecidedBy != r2.DecidedBy {
+		return false
+	}
+	if !r.DecidedAt.Equal(r2.DecidedAt) {
+		return false
+	}
+
+	return true
+}
+
+// Equal checks each field of the two LinkScoreBreakdowns to see if they are equal.
+func (b LinkScoreBreakdown) Equal(b2 LinkScoreBreakdown) bool {
+	if b.IDMatch != b2.IDMatch {
+		return false
+	}
+	if b.NameScore != b2.NameScore {
+		return false
+	}
+	if b.EndpointName != b2.EndpointName {
+		return false
+	}
+	if b.OrganizationName != b2.OrganizationName {
+		return false
+	}
+	if len(b.SharedTokens) != len(b2.SharedTokens) {
+		return false
+	}
+	for i := range b.SharedTokens {
+		if b.SharedTokens[i] != b2.SharedTokens[i] {
+			return false
+		}
+	}
+	if len(b.LocationScores) != len(b2.LocationScores) {
+		return false
+	}
+	for component, score := range b.LocationScores {
+		score2, ok := b2.LocationScores[component]
+		if !ok || score != score2 {
+			return false
+		}
+	}
+	if b.CombinedScore != b2.CombinedScore {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,109 @@
+package endpointmanager
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_EndpointOrganizationReviewEqual(t *testing.T) {
+	var review1 = &EndpointOrganizationReview{
+		URL:               "http://www.example.com/fhir",
+		OrganizationNPIID: "1234567890",
+		Confidence:        .86,
+		ScoreBreakdown: LinkScoreBreakdown{
+			NameScore:        .8,
+			EndpointName:     "MEMORIAL HOSPITAL",
+			OrganizationName: "MEMORIAL HOSPITAL OF BOSTON",
+			SharedTokens:     []string{"MEMORIAL", "HOSPITAL"},
+			LocationScores:   map[string]float64{"state": 1},
+			CombinedScore:    .86,
+		},
+		Status: ReviewPending,
+	}
+	var review2 = &EndpointOrganizationReview{
+		URL:               "http://www.example.com/fhir",
+		OrganizationNPIID: "1234567890",
+		Confidence:        .86,
+		ScoreBreakdown: LinkScoreBreakdown{
+			NameScore:        .8,
+			EndpointName:     "MEMORIAL HOSPITAL",
+			OrganizationName: "MEMORIAL HOSPITAL OF BOSTON",
+			SharedTokens:     []string{"MEMORIAL", "HOSPITAL"},
+			LocationScores:   map[string]float64{"state": 1},
+			CombinedScore:    .86,
+		},
+		Status: ReviewPending,
+	}
+
+	if !review1.Equal(review2) {
+		t.Errorf("Expected review1 to equal review2. They are not equal.")
+	}
+
+	review2.CreatedAt = time.Now()
+	if !review1.Equal(review2) {
+		t.Errorf("Expect review1 to equal review2. CreatedAt should be ignored.")
+	}
+
+	review2.URL = "other"
+	if review1.Equal(review2) {
+		t.Errorf("Did not expect review1 to equal review2. URL should be different. %s vs %s", review1.URL, review2.URL)
+	}
+	review2.URL = review1.URL
+
+	review2.OrganizationNPIID = "other"
+	if review1.Equal(review2) {
+		t.Errorf("Did not expect review1 to equal review2. OrganizationNPIID should be different. %s vs %s", review1.OrganizationNPIID, review2.OrganizationNPIID)
+	}
+	review2.OrganizationNPIID = review1.OrganizationNPIID
+
+	review2.Confidence = .9
+	if review1.Equal(review2) {
+		t.Errorf("Did not expect review1 to equal review2. Confidence should be different. %f vs %f", review1.Confidence, review2.Confidence)
+	}
+	review2.Confidence = review1.Confidence
+
+	review2.ScoreBreakdown.SharedTokens = []string{"MEMORIAL"}
+	if review1.Equal(review2) {
+		t.Errorf("Did not expect review1 to equal review2. ScoreBreakdown.SharedTokens should be different. %v vs %v", review1.ScoreBreakdown.SharedTokens, review2.ScoreBreakdown.SharedTokens)
+	}
+	review2.ScoreBreakdown.SharedTokens = review1.ScoreBreakdown.SharedTokens
+
+	review2.ScoreBreakdown.LocationScores = map[string]float64{"state": 0}
+	if review1.Equal(review2) {
+		t.Errorf("Did not expect review1 to equal review2. ScoreBreakdown.LocationScores should be different. %v vs %v", review1.ScoreBreakdown.LocationScores, review2.ScoreBreakdown.LocationScores)
+	}
+	review2.ScoreBreakdown.LocationScores = review1.ScoreBreakdown.LocationScores
+
+	review2.ScoreBreakdown.IDMatch = true
+	if review1.Equal(review2) {
+		t.Errorf("Did not expect review1 to equal review2. ScoreBreakdown.IDMatch should be different.")
+	}
+	review2.ScoreBreakdown.IDMatch = review1.ScoreBreakdown.IDMatch
+
+	review2.Status = ReviewAccepted
+	if review1.Equal(review2) {
+		t.Errorf("Did not expect review1 to equal review2. Status should be different. %s vs %s", review1.Status, review2.Status)
+	}
+	review2.Status = review1.Status
+
+	review2.DecidedBy = "reviewer"
+	if review1.Equal(review2) {
+		t.Errorf("Did not expect review1 to equal review2. DecidedBy should be different. %s vs %s", review1.DecidedBy, review2.DecidedBy)
+	}
+	review2.DecidedBy = review1.DecidedBy
+
+	review2.DecidedAt = time.Now()
+	if review1.Equal(review2) {
+		t.Errorf("Did not expect review1 to equal review2. DecidedAt should be different. %s vs %s", review1.DecidedAt, review2.DecidedAt)
+	}
+	review2.DecidedAt = review1.DecidedAt
+
+	review2 = nil
+	if review1.Equal(review2) {
+		t.Errorf("Did not expect review1 to equal nil review2.")
+	}
+	review1 = nil
+	if !review1.Equal(review2) {
+		t.Errorf("Nil review1 should equal nil review2.")
+	}
+}
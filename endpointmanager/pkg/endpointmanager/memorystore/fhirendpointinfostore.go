@@ -0,0 +1,175 @@
+package memorystore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
+)
+
+// GetFHIREndpointInfo gets the FHIREndpointInfo with the given database id, along with its metadata.
+// If the FHIREndpointInfo or its metadata does not exist, sql.ErrNoRows will be returned.
+func (s *Store) GetFHIREndpointInfo(ctx context.Context, id int) (*endpointmanager.FHIREndpointInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, ok := s.infos[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return s.infoWithMetadata(info)
+}
+
+// GetFHIREndpointInfosUsingURL gets all of the FHIREndpointInfos with the given URL.
+func (s *Store) GetFHIREndpointInfosUsingURL(ctx context.Context, url string) ([]*endpointmanager.FHIREndpointInfo, error) {
+	return s.findInfos(func(info *endpointmanager.FHIREndpointInfo) bool {
+		return info.URL == url
+	})
+}
+
+// GetFHIREndpointInfoUsingURLAndRequestedVersion gets the FHIREndpointInfo with the given URL and requested version.
+// If the FHIREndpointInfo does not exist, sql.ErrNoRows will be returned.
+func (s *Store) GetFHIREndpointInfoUsingURLAndRequestedVersion(ctx context.Context, url string, requestedVersion string) (*endpointmanager.FHIREndpointInfo, error) {
+	infos, err := s.findInfos(func(info *endpointmanager.FHIREndpointInfo) bool {
+		return info.URL == url && info.RequestedFhirVersion == requestedVersion
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return infos[0], nil
+}
+
+// GetFHIREndpointInfosByURLWithDifferentRequestedVersion gets all of the FHIREndpointInfos for the given url whose
+// RequestedFhirVersion is not in the versions list
+func (s *Store) GetFHIREndpointInfosByURLWithDifferentRequestedVersion(ctx context.Context, url string, versions []string) ([]*endpointmanager.FHIREndpointInfo, error) {
+	return s.findInfos(func(info *endpointmanager.FHIREndpointInfo) bool {
+		return info.URL == url && !helpers.StringArrayContains(versions, info.RequestedFhirVersion)
+	})
+}
+
+// AddFHIREndpointInfo adds the FHIREndpointInfo to the store with the given metadata id and sets its database id.
+func (s *Store) AddFHIREndpointInfo(ctx context.Context, e *endpointmanager.FHIREndpointInfo, metadataID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e.ID = s.newID()
+	stored := copyFHIREndpointInfo(e)
+	stored.CreatedAt = s.now()
+	stored.UpdatedAt = stored.CreatedAt
+	s.infos[e.ID] = stored
+	s.infoMetadataIDs[e.ID] = metadataID
+	return nil
+}
+
+// UpdateFHIREndpointInfo updates the FHIREndpointInfo using the FHIREndpointInfo's database id as the key.
+func (s *Store) UpdateFHIREndpointInfo(ctx context.Context, e *endpointmanager.FHIREndpointInfo, metadataID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.infos[e.ID]
+	if !ok {
+		return nil
+	}
+	stored := copyFHIREndpointInfo(e)
+	stored.CreatedAt = existing.CreatedAt
+	stored.UpdatedAt = s.now()
+	s.infos[e.ID] = stored
+	s.infoMetadataIDs[e.ID] = metadataID
+	return nil
+}
+
+// UpdateMetadataIDInfo only updates the metadata id of the FHIREndpointInfo with the given database id.
+func (s *Store) UpdateMetadataIDInfo(ctx context.Context, metadataID int, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.infos[id]; ok {
+		s.infoMetadataIDs[id] = metadataID
+	}
+	return nil
+}
+
+// DeleteFHIREndpointInfo deletes the FHIREndpointInfo using the FHIREndpointInfo's database id as the key.
+func (s *Store) DeleteFHIREndpointInfo(ctx context.Context, e *endpointmanager.FHIREndpointInfo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.infos, e.ID)
+	delete(s.infoMetadataIDs, e.ID)
+	return nil
+}
+
+// GetFHIREndpointMetadata gets the FHIREndpointMetadata with the given database id.
+// If the FHIREndpointMetadata does not exist, sql.ErrNoRows will be returned.
+func (s *Store) GetFHIREndpointMetadata(ctx context.Context, metadataID int) (*endpointmanager.FHIREndpointMetadata, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	metadata, ok := s.metadata[metadataID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	cp := *metadata
+	return &cp, nil
+}
+
+// AddFHIREndpointMetadata adds the FHIREndpointMetadata to the store and returns its database id.
+func (s *Store) AddFHIREndpointMetadata(ctx context.Context, e *endpointmanager.FHIREndpointMetadata) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *e
+	stored.ID = s.newID()
+	stored.CreatedAt = s.now()
+	stored.UpdatedAt = stored.CreatedAt
+	s.metadata[stored.ID] = &stored
+	return stored.ID, nil
+}
+
+// findInfos returns the FHIREndpointInfos that match, in the order they were added, along with their metadata.
+func (s *Store) findInfos(match func(info *endpointmanager.FHIREndpointInfo) bool) ([]*endpointmanager.FHIREndpointInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int
+	for id := range s.infos {
+		ids = append(ids, id)
+	}
+
+	var infos []*endpointmanager.FHIREndpointInfo
+	for _, id := range sortedIDs(ids) {
+		if !match(s.infos[id]) {
+			continue
+		}
+		info, err := s.infoWithMetadata(s.infos[id])
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// infoWithMetadata returns a copy of the FHIREndpointInfo with its metadata attached. The caller must hold the
+// store's lock.
+func (s *Store) infoWithMetadata(info *endpointmanager.FHIREndpointInfo) (*endpointmanager.FHIREndpointInfo, error) {
+	metadata, ok := s.metadata[s.infoMetadataIDs[info.ID]]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	cp := copyFHIREndpointInfo(info)
+	metadataCopy := *metadata
+	cp.Metadata = &metadataCopy
+	return cp, nil
+}
+
+func copyFHIREndpointInfo(e *endpointmanager.FHIREndpointInfo) *endpointmanager.FHIREndpointInfo {
+	cp := *e
+	cp.MIMETypes = copyStrings(e.MIMETypes)
+	cp.Metadata = nil
+	return &cp
+}
@@ -0,0 +1,192 @@
+package memorystore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/pkg/errors"
+)
+
+// GetAllFHIREndpoints returns a list of all of the fhir endpoints
+func (s *Store) GetAllFHIREndpoints(ctx context.Context) ([]*endpointmanager.FHIREndpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var endpoints []*endpointmanager.FHIREndpoint
+	for _, id := range s.endpointIDs() {
+		endpoints = append(endpoints, copyFHIREndpoint(s.endpoints[id]))
+	}
+	return endpoints, nil
+}
+
+// GetFHIREndpointCount returns the number of fhir endpoints
+func (s *Store) GetFHIREndpointCount(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.endpoints), nil
+}
+
+// GetFHIREndpoint gets the FHIREndpoint with the given database id.
+// If the FHIREndpoint does not exist, sql.ErrNoRows will be returned.
+func (s *Store) GetFHIREndpoint(ctx context.Context, id int) (*endpointmanager.FHIREndpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	endpoint, ok := s.endpoints[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return copyFHIREndpoint(endpoint), nil
+}
+
+// GetFHIREndpointUsingURL returns all of the FHIREndpoints with the given url.
+func (s *Store) GetFHIREndpointUsingURL(ctx context.Context, url string) ([]*endpointmanager.FHIREndpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var endpoints []*endpointmanager.FHIREndpoint
+	for _, id := range s.endpointIDs() {
+		if s.endpoints[id].URL == url {
+			endpoints = append(endpoints, copyFHIREndpoint(s.endpoints[id]))
+		}
+	}
+	return endpoints, nil
+}
+
+// GetFHIREndpointUsingURLAndListSource returns the FHIREndpoint with the given url and list source.
+// If the FHIREndpoint does not exist, sql.ErrNoRows will be returned.
+func (s *Store) GetFHIREndpointUsingURLAndListSource(ctx context.Context, url string, listSource string) (*endpointmanager.FHIREndpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range s.endpointIDs() {
+		endpoint := s.endpoints[id]
+		if endpoint.URL == url && endpoint.ListSource == listSource {
+			return copyFHIREndpoint(endpoint), nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// GetFHIREndpointsUsingListSourceAndUpdateTime returns the FHIREndpoints from the given list source that were last
+// updated before the given time.
+func (s *Store) GetFHIREndpointsUsingListSourceAndUpdateTime(ctx context.Context, updateTime time.Time, listSource string) ([]*endpointmanager.FHIREndpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var endpoints []*endpointmanager.FHIREndpoint
+	for _, id := range s.endpointIDs() {
+		endpoint := s.endpoints[id]
+		if endpoint.ListSource == listSource && endpoint.UpdatedAt.Before(updateTime) {
+			endpoints = append(endpoints, copyFHIREndpoint(endpoint))
+		}
+	}
+	return endpoints, nil
+}
+
+// AddOrUpdateFHIREndpoint adds the endpoint if it doesn't already exist. If it does exist, it updates the endpoint.
+func (s *Store) AddOrUpdateFHIREndpoint(ctx context.Context, e *endpointmanager.FHIREndpoint) error {
+	existingEndpt, err := s.GetFHIREndpointUsingURLAndListSource(ctx, e.URL, e.ListSource)
+	if err == sql.ErrNoRows {
+		err = s.AddFHIREndpoint(ctx, e)
+		if err != nil {
+			return errors.Wrap(err, "adding fhir endpoint to store failed")
+		}
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "getting fhir endpoint from store failed")
+	}
+
+	// Merge new data with old data
+	for _, name := range e.OrganizationNames {
+		existingEndpt.AddOrganizationName(name)
+	}
+	for _, npiID := range e.NPIIDs {
+		existingEndpt.AddNPIID(npiID)
+	}
+	for _, location := range e.Locations {
+		existingEndpt.AddLocation(location)
+	}
+	existingEndpt.VersionsResponse = e.VersionsResponse
+	return s.UpdateFHIREndpoint(ctx, existingEndpt)
+}
+
+// AddOrUpdateFHIREndpoints adds or updates each of the endpoints in turn using AddOrUpdateFHIREndpoint.
+func (s *Store) AddOrUpdateFHIREndpoints(ctx context.Context, endpoints []*endpointmanager.FHIREndpoint) error {
+	for _, e := range endpoints {
+		err := s.AddOrUpdateFHIREndpoint(ctx, e)
+		if err != nil {
+			return err
+		}
+		if e.ID == 0 {
+			existingEndpt, err := s.GetFHIREndpointUsingURLAndListSource(ctx, e.URL, e.ListSource)
+			if err != nil {
+				return err
+			}
+			e.ID = existingEndpt.ID
+		}
+	}
+	return nil
+}
+
+// AddFHIREndpoint adds the FHIREndpoint to the store and sets its database id. As in the database, the versions
+// response is only stored when the endpoint is updated.
+func (s *Store) AddFHIREndpoint(ctx context.Context, e *endpointmanager.FHIREndpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e.ID = s.newID()
+	stored := copyFHIREndpoint(e)
+	stored.VersionsResponse.Response = nil
+	stored.CreatedAt = s.now()
+	stored.UpdatedAt = stored.CreatedAt
+	s.endpoints[e.ID] = stored
+	return nil
+}
+
+// UpdateFHIREndpoint updates the FHIREndpoint using the FHIREndpoint's database id as the key.
+func (s *Store) UpdateFHIREndpoint(ctx context.Context, e *endpointmanager.FHIREndpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.endpoints[e.ID]
+	if !ok {
+		return nil
+	}
+	stored := copyFHIREndpoint(e)
+	stored.CreatedAt = existing.CreatedAt
+	stored.UpdatedAt = s.now()
+	s.endpoints[e.ID] = stored
+	return nil
+}
+
+// DeleteFHIREndpoint deletes the FHIREndpoint using the FHIREndpoint's database id as the key.
+func (s *Store) DeleteFHIREndpoint(ctx context.Context, e *endpointmanager.FHIREndpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.endpoints, e.ID)
+	return nil
+}
+
+func (s *Store) endpointIDs() []int {
+	var ids []int
+	for id := range s.endpoints {
+		ids = append(ids, id)
+	}
+	return sortedIDs(ids)
+}
+
+func copyFHIREndpoint(e *endpointmanager.FHIREndpoint) *endpointmanager.FHIREndpoint {
+	cp := *e
+	cp.OrganizationNames = copyStrings(e.OrganizationNames)
+	cp.NPIIDs = copyStrings(e.NPIIDs)
+	cp.Locations = nil
+	for _, location := range e.Locations {
+		cp.Locations = append(cp.Locations, copyLocation(location))
+	}
+	return &cp
+}
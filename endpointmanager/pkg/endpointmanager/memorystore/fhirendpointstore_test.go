@@ -0,0 +1,177 @@
+package memorystore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+)
+
+func Test_FHIREndpointStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+
+	endpoint1 := &endpointmanager.FHIREndpoint{
+		URL:               "example.com/FHIR/DSTU2/",
+		OrganizationNames: []string{"Example Inc."},
+		NPIIDs:            []string{"1"},
+		ListSource:        "https://open.epic.com/MyApps/EndpointsJson",
+	}
+	endpoint2 := &endpointmanager.FHIREndpoint{
+		URL:               "other.example.com/FHIR/DSTU2/",
+		OrganizationNames: []string{"Other Example Inc."},
+		ListSource:        "https://open.epic.com/MyApps/EndpointsJson",
+	}
+	endpoint3 := &endpointmanager.FHIREndpoint{
+		URL:        "example.com/FHIR/DSTU2/",
+		ListSource: "Lantern",
+	}
+
+	// add endpoints
+
+	for _, endpoint := range []*endpointmanager.FHIREndpoint{endpoint1, endpoint2, endpoint3} {
+		err := store.AddFHIREndpoint(ctx, endpoint)
+		th.Assert(t, err == nil, err)
+		th.Assert(t, endpoint.ID != 0, "expected the endpoint to be given an ID")
+	}
+
+	count, err := store.GetFHIREndpointCount(ctx)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, count == 3, fmt.Sprintf("expected 3 endpoints, got %d", count))
+
+	// get endpoints
+
+	e1, err := store.GetFHIREndpoint(ctx, endpoint1.ID)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, e1.Equal(endpoint1), "retrieved endpoint is not equal to the saved endpoint")
+	th.Assert(t, !e1.UpdatedAt.IsZero(), "expected the endpoint's update time to be set")
+
+	_, err = store.GetFHIREndpoint(ctx, -1)
+	th.Assert(t, err == sql.ErrNoRows, fmt.Sprintf("expected sql.ErrNoRows for a missing endpoint, got %v", err))
+
+	endpoints, err := store.GetFHIREndpointUsingURL(ctx, endpoint1.URL)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(endpoints) == 2, fmt.Sprintf("expected 2 endpoints with the URL, got %d", len(endpoints)))
+
+	e3, err := store.GetFHIREndpointUsingURLAndListSource(ctx, endpoint3.URL, endpoint3.ListSource)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, e3.ID == endpoint3.ID, "expected the endpoint from the Lantern list source")
+
+	// returned endpoints are copies
+
+	e1.OrganizationNames[0] = "Changed"
+	e1, err = store.GetFHIREndpoint(ctx, endpoint1.ID)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, e1.OrganizationNames[0] == "Example Inc.", "changing a retrieved endpoint should not change the stored endpoint")
+
+	// add or update merges with the existing endpoint
+
+	updateTime := time.Now()
+	err = store.AddOrUpdateFHIREndpoint(ctx, &endpointmanager.FHIREndpoint{
+		URL:               endpoint1.URL,
+		OrganizationNames: []string{"Second Example Inc."},
+		NPIIDs:            []string{"2"},
+		ListSource:        endpoint1.ListSource,
+	})
+	th.Assert(t, err == nil, err)
+	e1, err = store.GetFHIREndpoint(ctx, endpoint1.ID)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(e1.OrganizationNames) == 2, fmt.Sprintf("expected the organization names to be merged, got %v", e1.OrganizationNames))
+	th.Assert(t, len(e1.NPIIDs) == 2, fmt.Sprintf("expected the NPI IDs to be merged, got %v", e1.NPIIDs))
+
+	// endpoints that were not updated since the update time
+
+	old, err := store.GetFHIREndpointsUsingListSourceAndUpdateTime(ctx, updateTime, endpoint1.ListSource)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(old) == 1 && old[0].ID == endpoint2.ID, fmt.Sprintf("expected only endpoint 2 to be older than the update time, got %v", old))
+
+	// delete
+
+	err = store.DeleteFHIREndpoint(ctx, endpoint2)
+	th.Assert(t, err == nil, err)
+	_, err = store.GetFHIREndpoint(ctx, endpoint2.ID)
+	th.Assert(t, err == sql.ErrNoRows, fmt.Sprintf("expected the deleted endpoint to be missing, got %v", err))
+
+	all, err := store.GetAllFHIREndpoints(ctx)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(all) == 2, fmt.Sprintf("expected 2 endpoints, got %d", len(all)))
+	th.Assert(t, all[0].ID == endpoint1.ID && all[1].ID == endpoint3.ID, "expected the endpoints in the order they were added")
+}
+
+func Test_FHIREndpointInfoStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+
+	metadataID, err := store.AddFHIREndpointMetadata(ctx, &endpointmanager.FHIREndpointMetadata{
+		URL:                  "example.com/FHIR/DSTU2/",
+		HTTPResponse:         200,
+		RequestedFhirVersion: "None",
+	})
+	th.Assert(t, err == nil, err)
+
+	info := &endpointmanager.FHIREndpointInfo{
+		URL:                  "example.com/FHIR/DSTU2/",
+		TLSVersion:           "TLS 1.2",
+		MIMETypes:            []string{"application/json+fhir"},
+		RequestedFhirVersion: "None",
+	}
+	err = store.AddFHIREndpointInfo(ctx, info, metadataID)
+	th.Assert(t, err == nil, err)
+
+	versionInfo := &endpointmanager.FHIREndpointInfo{
+		URL:                  "example.com/FHIR/DSTU2/",
+		RequestedFhirVersion: "4.0",
+	}
+	err = store.AddFHIREndpointInfo(ctx, versionInfo, metadataID)
+	th.Assert(t, err == nil, err)
+
+	// the metadata is attached to the info
+
+	i, err := store.GetFHIREndpointInfoUsingURLAndRequestedVersion(ctx, info.URL, "None")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, i.EqualExcludeMetadata(info), "retrieved info is not equal to the saved info")
+	th.Assert(t, i.Metadata != nil && i.Metadata.HTTPResponse == 200, "expected the info's metadata to be attached")
+
+	_, err = store.GetFHIREndpointInfoUsingURLAndRequestedVersion(ctx, info.URL, "1.0.2")
+	th.Assert(t, err == sql.ErrNoRows, fmt.Sprintf("expected sql.ErrNoRows for a missing requested version, got %v", err))
+
+	infos, err := store.GetFHIREndpointInfosUsingURL(ctx, info.URL)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(infos) == 2, fmt.Sprintf("expected 2 infos, got %d", len(infos)))
+
+	infos, err = store.GetFHIREndpointInfosByURLWithDifferentRequestedVersion(ctx, info.URL, []string{"None"})
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(infos) == 1 && infos[0].ID == versionInfo.ID, "expected only the info with another requested version")
+
+	// update the info with new metadata
+
+	newMetadataID, err := store.AddFHIREndpointMetadata(ctx, &endpointmanager.FHIREndpointMetadata{
+		URL:          info.URL,
+		HTTPResponse: 404,
+	})
+	th.Assert(t, err == nil, err)
+	info.TLSVersion = "TLS 1.3"
+	err = store.UpdateFHIREndpointInfo(ctx, info, newMetadataID)
+	th.Assert(t, err == nil, err)
+	i, err = store.GetFHIREndpointInfo(ctx, info.ID)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, i.TLSVersion == "TLS 1.3", fmt.Sprintf("expected the updated TLS version, got %s", i.TLSVersion))
+	th.Assert(t, i.Metadata.HTTPResponse == 404, "expected the new metadata to be attached")
+
+	err = store.UpdateMetadataIDInfo(ctx, metadataID, info.ID)
+	th.Assert(t, err == nil, err)
+	i, err = store.GetFHIREndpointInfo(ctx, info.ID)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, i.Metadata.HTTPResponse == 200, "expected the original metadata to be attached again")
+
+	// delete
+
+	err = store.DeleteFHIREndpointInfo(ctx, info)
+	th.Assert(t, err == nil, err)
+	_, err = store.GetFHIREndpointInfo(ctx, info.ID)
+	th.Assert(t, err == sql.ErrNoRows, fmt.Sprintf("expected the deleted info to be missing, got %v", err))
+}
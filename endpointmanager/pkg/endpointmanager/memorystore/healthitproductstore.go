@@ -0,0 +1,225 @@
+package memorystore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+)
+
+// GetHealthITProduct gets the HealthITProduct with the given database ID.
+// If the HealthITProduct does not exist, sql.ErrNoRows will be returned.
+func (s *Store) GetHealthITProduct(ctx context.Context, id int) (*endpointmanager.HealthITProduct, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hitp, ok := s.products[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return copyHealthITProduct(hitp), nil
+}
+
+// GetHealthITProductUsingNameAndVersion gets the HealthITProduct with the given name and version.
+// If the HealthITProduct does not exist, sql.ErrNoRows will be returned.
+func (s *Store) GetHealthITProductUsingNameAndVersion(ctx context.Context, name string, version string) (*endpointmanager.HealthITProduct, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range s.productIDs() {
+		hitp := s.products[id]
+		if hitp.Name == name && hitp.Version == version {
+			return copyHealthITProduct(hitp), nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// GetHealthITProductsUsingVendor returns the HealthITProducts of the vendor with the given database ID.
+func (s *Store) GetHealthITProductsUsingVendor(ctx context.Context, vendorID int) ([]*endpointmanager.HealthITProduct, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var hitps []*endpointmanager.HealthITProduct
+	for _, id := range s.productIDs() {
+		if s.products[id].VendorID == vendorID {
+			hitps = append(hitps, copyHealthITProduct(s.products[id]))
+		}
+	}
+	return hitps, nil
+}
+
+// GetHealthITProductIDByCHPLID gets the database ID of the HealthITProduct with the given CHPL ID.
+// If the HealthITProduct does not exist, sql.ErrNoRows will be returned.
+func (s *Store) GetHealthITProductIDByCHPLID(ctx context.Context, CHPLID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range s.productIDs() {
+		if s.products[id].CHPLID == CHPLID {
+			return id, nil
+		}
+	}
+	return 0, sql.ErrNoRows
+}
+
+// AddHealthITProduct adds the HealthITProduct to the store and sets its database ID.
+func (s *Store) AddHealthITProduct(ctx context.Context, hitp *endpointmanager.HealthITProduct) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hitp.ID = s.newID()
+	stored := copyHealthITProduct(hitp)
+	stored.CreatedAt = s.now()
+	stored.UpdatedAt = stored.CreatedAt
+	s.products[hitp.ID] = stored
+	return nil
+}
+
+// UpdateHealthITProduct updates the HealthITProduct using the HealthITProduct's database ID as the key.
+func (s *Store) UpdateHealthITProduct(ctx context.Context, hitp *endpointmanager.HealthITProduct) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.products[hitp.ID]
+	if !ok {
+		return nil
+	}
+	stored := copyHealthITProduct(hitp)
+	stored.CreatedAt = existing.CreatedAt
+	stored.UpdatedAt = s.now()
+	s.products[hitp.ID] = stored
+	return nil
+}
+
+// DeleteHealthITProduct deletes the HealthITProduct using the HealthITProduct's database ID as the key.
+func (s *Store) DeleteHealthITProduct(ctx context.Context, hitp *endpointmanager.HealthITProduct) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.products, hitp.ID)
+	return nil
+}
+
+// GetProductCriteriaLink retrieves the product database id, criteria id, and criteria number for the requested
+// product db id and criteria id. If the link doesn't exist, returns a SQL no rows error.
+func (s *Store) GetProductCriteriaLink(ctx context.Context, criteriaID int, productID int) (int, int, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	number, ok := s.productCriteria[productCriteriaKey{productID: productID, criteriaID: criteriaID}]
+	if !ok {
+		return 0, 0, "", sql.ErrNoRows
+	}
+	return productID, criteriaID, number, nil
+}
+
+// LinkProductToCriteria links a product database id to a certification criteria id
+func (s *Store) LinkProductToCriteria(ctx context.Context, criteriaID int, productID int, productNumber string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.productCriteria[productCriteriaKey{productID: productID, criteriaID: criteriaID}] = productNumber
+	return nil
+}
+
+// DeleteLinksByProduct deletes all of the product criteria links with the given health it product database id
+func (s *Store) DeleteLinksByProduct(ctx context.Context, productID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.productCriteria {
+		if key.productID == productID {
+			delete(s.productCriteria, key)
+		}
+	}
+	return nil
+}
+
+// GetCriteria gets the CertificationCriteria with the given database ID.
+// If the CertificationCriteria does not exist, sql.ErrNoRows will be returned.
+func (s *Store) GetCriteria(ctx context.Context, id int) (*endpointmanager.CertificationCriteria, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	criteria, ok := s.criteria[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	cp := *criteria
+	return &cp, nil
+}
+
+// GetCriteriaByCertificationID gets the CertificationCriteria with the given certification ID.
+// If the CertificationCriteria does not exist, sql.ErrNoRows will be returned.
+func (s *Store) GetCriteriaByCertificationID(ctx context.Context, certID int) (*endpointmanager.CertificationCriteria, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int
+	for id := range s.criteria {
+		ids = append(ids, id)
+	}
+	for _, id := range sortedIDs(ids) {
+		if s.criteria[id].CertificationID == certID {
+			cp := *s.criteria[id]
+			return &cp, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// AddCriteria adds the CertificationCriteria to the store and sets its database ID.
+func (s *Store) AddCriteria(ctx context.Context, criteria *endpointmanager.CertificationCriteria) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	criteria.ID = s.newID()
+	stored := *criteria
+	stored.CreatedAt = s.now()
+	stored.UpdatedAt = stored.CreatedAt
+	s.criteria[criteria.ID] = &stored
+	return nil
+}
+
+// UpdateCriteria updates the CertificationCriteria using the CertificationCriteria's database ID as the key.
+func (s *Store) UpdateCriteria(ctx context.Context, criteria *endpointmanager.CertificationCriteria) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.criteria[criteria.ID]
+	if !ok {
+		return nil
+	}
+	stored := *criteria
+	stored.CreatedAt = existing.CreatedAt
+	stored.UpdatedAt = s.now()
+	s.criteria[criteria.ID] = &stored
+	return nil
+}
+
+// DeleteCriteria deletes the CertificationCriteria using the CertificationCriteria's database ID as the key.
+func (s *Store) DeleteCriteria(ctx context.Context, criteria *endpointmanager.CertificationCriteria) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.criteria, criteria.ID)
+	return nil
+}
+
+func (s *Store) productIDs() []int {
+	var ids []int
+	for id := range s.products {
+		ids = append(ids, id)
+	}
+	return sortedIDs(ids)
+}
+
+func copyHealthITProduct(hitp *endpointmanager.HealthITProduct) *endpointmanager.HealthITProduct {
+	cp := *hitp
+	cp.Location = copyLocation(hitp.Location)
+	if hitp.CertificationCriteria != nil {
+		cp.CertificationCriteria = append([]int{}, hitp.CertificationCriteria...)
+	}
+	return &cp
+}
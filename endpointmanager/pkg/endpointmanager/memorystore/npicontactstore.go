@@ -0,0 +1,101 @@
+package memorystore
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+)
+
+// GetNPIContactByNPIID gets the NPIContact with the given NPI ID.
+// If the NPIContact does not exist, sql.ErrNoRows will be returned.
+func (s *Store) GetNPIContactByNPIID(ctx context.Context, npiID string) (*endpointmanager.NPIContact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	contact := s.npiContactByNPIID(npiID)
+	if contact == nil {
+		return nil, sql.ErrNoRows
+	}
+	return copyNPIContact(contact), nil
+}
+
+// GetAllNPIContacts gets all of the NPIContacts ordered by NPI id.
+func (s *Store) GetAllNPIContacts(ctx context.Context) ([]*endpointmanager.NPIContact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var contacts []*endpointmanager.NPIContact
+	for _, id := range s.npiContactIDs() {
+		contacts = append(contacts, copyNPIContact(s.npiContacts[id]))
+	}
+	sort.SliceStable(contacts, func(i, j int) bool {
+		return contacts[i].NPI_ID < contacts[j].NPI_ID
+	})
+	return contacts, nil
+}
+
+// AddNPIContact adds the NPIContact to the store and sets its database ID.
+func (s *Store) AddNPIContact(ctx context.Context, contact *endpointmanager.NPIContact) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	contact.ID = s.newID()
+	stored := copyNPIContact(contact)
+	stored.CreatedAt = s.now()
+	stored.UpdatedAt = stored.CreatedAt
+	s.npiContacts[contact.ID] = stored
+	return nil
+}
+
+// UpdateNPIContactByNPIID updates the NPIContact using the NPIContact's NPIID as the key.
+func (s *Store) UpdateNPIContactByNPIID(ctx context.Context, contact *endpointmanager.NPIContact) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing := s.npiContactByNPIID(contact.NPI_ID)
+	if existing == nil {
+		return nil
+	}
+	stored := copyNPIContact(contact)
+	stored.ID = existing.ID
+	stored.CreatedAt = existing.CreatedAt
+	stored.UpdatedAt = s.now()
+	s.npiContacts[existing.ID] = stored
+	return nil
+}
+
+// DeleteNPIContact deletes the NPIContact using the NPIContact's database ID as the key.
+func (s *Store) DeleteNPIContact(ctx context.Context, contact *endpointmanager.NPIContact) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.npiContacts, contact.ID)
+	return nil
+}
+
+// npiContactByNPIID returns the first stored contact with the given NPI ID, or nil if there is none. The caller
+// must hold the store's lock.
+func (s *Store) npiContactByNPIID(npiID string) *endpointmanager.NPIContact {
+	for _, id := range s.npiContactIDs() {
+		if s.npiContacts[id].NPI_ID == npiID {
+			return s.npiContacts[id]
+		}
+	}
+	return nil
+}
+
+func (s *Store) npiContactIDs() []int {
+	var ids []int
+	for id := range s.npiContacts {
+		ids = append(ids, id)
+	}
+	return sortedIDs(ids)
+}
+
+func copyNPIContact(contact *endpointmanager.NPIContact) *endpointmanager.NPIContact {
+	cp := *contact
+	cp.Location = copyLocation(contact.Location)
+	return &cp
+}
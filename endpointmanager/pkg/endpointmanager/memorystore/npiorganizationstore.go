@@ -0,0 +1,362 @@
+package memorystore
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/pkg/errors"
+)
+
+// GetNPIOrganization gets the NPIOrganization with the given database ID.
+// If the NPIOrganization does not exist, sql.ErrNoRows will be returned.
+func (s *Store) GetNPIOrganization(ctx context.Context, id int) (*endpointmanager.NPIOrganization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	org, ok := s.npiOrgs[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return s.npiOrganizationWithTaxonomies(org), nil
+}
+
+// GetNPIOrganizationByNPIID gets the NPIOrganization with the given NPI ID.
+// If the NPIOrganization does not exist, sql.ErrNoRows will be returned.
+func (s *Store) GetNPIOrganizationByNPIID(ctx context.Context, npiID string) (*endpointmanager.NPIOrganization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	org := s.npiOrganizationByNPIID(npiID)
+	if org == nil {
+		return nil, sql.ErrNoRows
+	}
+	return s.npiOrganizationWithTaxonomies(org), nil
+}
+
+// AddNPIOrganization adds the NPIOrganization to the store and sets its database ID.
+func (s *Store) AddNPIOrganization(ctx context.Context, org *endpointmanager.NPIOrganization) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	org.ID = s.newID()
+	stored := copyNPIOrganization(org)
+	stored.CreatedAt = s.now()
+	stored.UpdatedAt = stored.CreatedAt
+	s.npiOrgs[org.ID] = stored
+	return nil
+}
+
+// UpdateNPIOrganization updates the NPIOrganization using the NPIOrganization's database ID as the key.
+func (s *Store) UpdateNPIOrganization(ctx context.Context, org *endpointmanager.NPIOrganization) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.npiOrgs[org.ID]
+	if !ok {
+		return nil
+	}
+	s.updateNPIOrganization(existing, org)
+	return nil
+}
+
+// UpdateNPIOrganizationByNPIID updates the NPIOrganization using the NPIOrganization's NPIID as the key.
+func (s *Store) UpdateNPIOrganizationByNPIID(ctx context.Context, org *endpointmanager.NPIOrganization) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing := s.npiOrganizationByNPIID(org.NPI_ID)
+	if existing == nil {
+		return nil
+	}
+	s.updateNPIOrganization(existing, org)
+	return nil
+}
+
+// DeleteNPIOrganization deletes the NPIOrganization using the NPIOrganization's database ID as the key.
+func (s *Store) DeleteNPIOrganization(ctx context.Context, org *endpointmanager.NPIOrganization) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.npiOrgs, org.ID)
+	return nil
+}
+
+// GetAllNPIOrganizationNormalizedNames gets list of all primary and secondary names along with the location of each organization.
+// Organizations whose NPI has been deactivated are not included.
+func (s *Store) GetAllNPIOrganizationNormalizedNames(ctx context.Context) ([]*endpointmanager.NPIOrganization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var orgs []*endpointmanager.NPIOrganization
+	for _, id := range s.npiOrganizationIDs() {
+		org := s.npiOrgs[id]
+		if !org.DeactivationDate.IsZero() && org.ReactivationDate.Before(org.DeactivationDate) {
+			continue
+		}
+		orgs = append(orgs, &endpointmanager.NPIOrganization{
+			ID:                      org.ID,
+			NPI_ID:                  org.NPI_ID,
+			NormalizedName:          org.NormalizedName,
+			NormalizedSecondaryName: org.NormalizedSecondaryName,
+			Location:                copyLocation(org.Location),
+		})
+	}
+	return orgs, nil
+}
+
+// GetNPIOrganizationNPIIDsByProviderType returns the NPI IDs of the organizations with a taxonomy whose NUCC
+// grouping, classification, specialization or display name matches one of the provider types, ignoring case.
+func (s *Store) GetNPIOrganizationNPIIDsByProviderType(ctx context.Context, providerTypes []string) (map[string]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	types := make(map[string]bool)
+	for _, providerType := range providerTypes {
+		types[strings.ToLower(strings.TrimSpace(providerType))] = true
+	}
+
+	npiIDs := make(map[string]bool)
+	for _, org := range s.npiOrgs {
+		for _, taxonomy := range org.Taxonomies {
+			nucc, ok := s.nuccTaxonomies[taxonomy.Code]
+			if !ok {
+				continue
+			}
+			if types[strings.ToLower(nucc.Grouping)] || types[strings.ToLower(nucc.Classification)] ||
+				types[strings.ToLower(nucc.Specialization)] || types[strings.ToLower(nucc.DisplayName)] {
+				npiIDs[org.NPI_ID] = true
+			}
+		}
+	}
+	return npiIDs, nil
+}
+
+// GetNUCCTaxonomy gets the NUCC taxonomy with the given code.
+// If the taxonomy does not exist, sql.ErrNoRows will be returned.
+func (s *Store) GetNUCCTaxonomy(ctx context.Context, code string) (*endpointmanager.NUCCTaxonomy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	taxonomy, ok := s.nuccTaxonomies[code]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	cp := *taxonomy
+	return &cp, nil
+}
+
+// AddOrUpdateNUCCTaxonomy adds the NUCC taxonomy, or updates it if a taxonomy with the same code already exists.
+func (s *Store) AddOrUpdateNUCCTaxonomy(ctx context.Context, taxonomy *endpointmanager.NUCCTaxonomy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *taxonomy
+	s.nuccTaxonomies[taxonomy.Code] = &stored
+	return nil
+}
+
+// LinkNPIOrganizationToFHIREndpoint links an npi organization's NPI ID to a FHIR endpoint URL
+func (s *Store) LinkNPIOrganizationToFHIREndpoint(ctx context.Context, orgID string, endpointURL string, confidence float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := orgLinkKey{orgID: orgID, url: endpointURL}
+	if _, ok := s.orgLinks[key]; ok {
+		return errors.Errorf("the organization %s is already linked to the endpoint %s", orgID, endpointURL)
+	}
+	s.orgLinks[key] = confidence
+	return nil
+}
+
+// GetNPIOrganizationFHIREndpointLink retrieves the organization id, endpoint url, and confidence for the requested organization id and
+// endpoint url. If the link doesn't exist, returns a SQL no rows error.
+func (s *Store) GetNPIOrganizationFHIREndpointLink(ctx context.Context, orgID string, endpointURL string) (string, string, float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	confidence, ok := s.orgLinks[orgLinkKey{orgID: orgID, url: endpointURL}]
+	if !ok {
+		return "", "", 0, sql.ErrNoRows
+	}
+	return orgID, endpointURL, confidence, nil
+}
+
+// UpdateNPIOrganizationFHIREndpointLink updates the confidence value for the link between the organization id and the endpoint url.
+func (s *Store) UpdateNPIOrganizationFHIREndpointLink(ctx context.Context, orgID string, endpointURL string, confidence float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := orgLinkKey{orgID: orgID, url: endpointURL}
+	if _, ok := s.orgLinks[key]; ok {
+		s.orgLinks[key] = confidence
+	}
+	return nil
+}
+
+// DeleteNPIOrganizationFHIREndpointLink deletes the link between the organization id and the endpoint url.
+func (s *Store) DeleteNPIOrganizationFHIREndpointLink(ctx context.Context, orgID string, endpointURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.orgLinks, orgLinkKey{orgID: orgID, url: endpointURL})
+	return nil
+}
+
+// GetEndpointOrganizationReview gets the review of the link between the endpoint URL and the NPI organization.
+// If the review does not exist, sql.ErrNoRows will be returned.
+func (s *Store) GetEndpointOrganizationReview(ctx context.Context, url string, orgID string) (*endpointmanager.EndpointOrganizationReview, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	review, ok := s.reviews[orgLinkKey{orgID: orgID, url: url}]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	cp := *review
+	return &cp, nil
+}
+
+// GetEndpointOrganizationReviews gets the reviews with the given status. If status is empty, all reviews are returned.
+// The reviews are ordered by confidence, lowest first.
+func (s *Store) GetEndpointOrganizationReviews(ctx context.Context, status string) ([]*endpointmanager.EndpointOrganizationReview, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var reviews []*endpointmanager.EndpointOrganizationReview
+	for _, review := range s.reviews {
+		if status == "" || review.Status == status {
+			cp := *review
+			reviews = append(reviews, &cp)
+		}
+	}
+	sort.Slice(reviews, func(i, j int) bool {
+		if reviews[i].Confidence != reviews[j].Confidence {
+			return reviews[i].Confidence < reviews[j].Confidence
+		}
+		if reviews[i].URL != reviews[j].URL {
+			return reviews[i].URL < reviews[j].URL
+		}
+		return reviews[i].OrganizationNPIID < reviews[j].OrganizationNPIID
+	})
+	return reviews, nil
+}
+
+// SavePendingEndpointOrganizationReview adds the review as pending. If a pending review already exists for the same
+// endpoint URL and NPI organization, its confidence and score breakdown are updated. Reviews that have already been
+// accepted or rejected are left unchanged.
+func (s *Store) SavePendingEndpointOrganizationReview(ctx context.Context, r *endpointmanager.EndpointOrganizationReview) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := orgLinkKey{orgID: r.OrganizationNPIID, url: r.URL}
+	existing, ok := s.reviews[key]
+	if !ok {
+		s.reviews[key] = &endpointmanager.EndpointOrganizationReview{
+			URL:               r.URL,
+			OrganizationNPIID: r.OrganizationNPIID,
+			Confidence:        r.Confidence,
+			ScoreBreakdown:    r.ScoreBreakdown,
+			Status:            endpointmanager.ReviewPending,
+			CreatedAt:         s.now(),
+			UpdatedAt:         s.now(),
+		}
+	} else if existing.Status == endpointmanager.ReviewPending {
+		existing.Confidence = r.Confidence
+		existing.ScoreBreakdown = r.ScoreBreakdown
+		existing.UpdatedAt = s.now()
+	}
+	return nil
+}
+
+// DecideEndpointOrganizationReview records that the reviewer accepted or rejected the link between the endpoint URL and
+// the NPI organization. The review is created if the link was never proposed by the endpoint linker.
+func (s *Store) DecideEndpointOrganizationReview(ctx context.Context, url string, orgID string, status string, decidedBy string) error {
+	if status != endpointmanager.ReviewAccepted && status != endpointmanager.ReviewRejected {
+		return errors.Errorf("review status must be %s or %s, got %s", endpointmanager.ReviewAccepted, endpointmanager.ReviewRejected, status)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := orgLinkKey{orgID: orgID, url: url}
+	review, ok := s.reviews[key]
+	if !ok {
+		review = &endpointmanager.EndpointOrganizationReview{
+			URL:               url,
+			OrganizationNPIID: orgID,
+			CreatedAt:         s.now(),
+		}
+		s.reviews[key] = review
+	}
+	review.Status = status
+	review.DecidedBy = decidedBy
+	review.DecidedAt = s.now()
+	review.UpdatedAt = review.DecidedAt
+	return nil
+}
+
+// npiOrganizationByNPIID returns the stored organization with the given NPI ID, or nil if there is none. The caller
+// must hold the store's lock.
+func (s *Store) npiOrganizationByNPIID(npiID string) *endpointmanager.NPIOrganization {
+	for _, id := range s.npiOrganizationIDs() {
+		if s.npiOrgs[id].NPI_ID == npiID {
+			return s.npiOrgs[id]
+		}
+	}
+	return nil
+}
+
+// updateNPIOrganization replaces the stored organization with the updated one, keeping its database ID and creation
+// time. The caller must hold the store's lock.
+func (s *Store) updateNPIOrganization(existing *endpointmanager.NPIOrganization, org *endpointmanager.NPIOrganization) {
+	stored := copyNPIOrganization(org)
+	stored.ID = existing.ID
+	stored.CreatedAt = existing.CreatedAt
+	stored.UpdatedAt = s.now()
+	s.npiOrgs[existing.ID] = stored
+}
+
+// npiOrganizationWithTaxonomies returns a copy of the organization whose taxonomies are joined with the NUCC code
+// set. The caller must hold the store's lock.
+func (s *Store) npiOrganizationWithTaxonomies(org *endpointmanager.NPIOrganization) *endpointmanager.NPIOrganization {
+	cp := copyNPIOrganization(org)
+	for i, taxonomy := range cp.Taxonomies {
+		nucc, ok := s.nuccTaxonomies[taxonomy.Code]
+		if !ok {
+			nucc = &endpointmanager.NUCCTaxonomy{}
+		}
+		cp.Taxonomies[i].Grouping = nucc.Grouping
+		cp.Taxonomies[i].Classification = nucc.Classification
+		cp.Taxonomies[i].Specialization = nucc.Specialization
+		cp.Taxonomies[i].DisplayName = nucc.DisplayName
+	}
+	return cp
+}
+
+func (s *Store) npiOrganizationIDs() []int {
+	var ids []int
+	for id := range s.npiOrgs {
+		ids = append(ids, id)
+	}
+	return sortedIDs(ids)
+}
+
+func copyNPIOrganization(org *endpointmanager.NPIOrganization) *endpointmanager.NPIOrganization {
+	cp := *org
+	cp.Location = copyLocation(org.Location)
+	cp.MailingLocation = copyLocation(org.MailingLocation)
+	if org.AuthorizedOfficial != nil {
+		official := *org.AuthorizedOfficial
+		cp.AuthorizedOfficial = &official
+	}
+	if org.Taxonomies != nil {
+		cp.Taxonomies = append([]endpointmanager.NPITaxonomy{}, org.Taxonomies...)
+	}
+	if org.Identifiers != nil {
+		cp.Identifiers = append([]endpointmanager.NPIIdentifier{}, org.Identifiers...)
+	}
+	return &cp
+}
@@ -0,0 +1,122 @@
+package memorystore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+)
+
+func Test_NPIOrganizationStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+
+	err := store.AddOrUpdateNUCCTaxonomy(ctx, &endpointmanager.NUCCTaxonomy{
+		Code:           "282N00000X",
+		Grouping:       "Hospitals",
+		Classification: "General Acute Care Hospital",
+	})
+	th.Assert(t, err == nil, err)
+
+	hospital := &endpointmanager.NPIOrganization{
+		NPI_ID:         "1",
+		Name:           "Example Hospital",
+		NormalizedName: "EXAMPLE HOSPITAL",
+		Taxonomies:     []endpointmanager.NPITaxonomy{{Code: "282N00000X", Primary: true}},
+	}
+	deactivated := &endpointmanager.NPIOrganization{
+		NPI_ID:           "2",
+		Name:             "Closed Hospital",
+		NormalizedName:   "CLOSED HOSPITAL",
+		DeactivationDate: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for _, org := range []*endpointmanager.NPIOrganization{hospital, deactivated} {
+		err = store.AddNPIOrganization(ctx, org)
+		th.Assert(t, err == nil, err)
+	}
+
+	// the taxonomies are joined with the NUCC code set
+
+	org, err := store.GetNPIOrganizationByNPIID(ctx, "1")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, org.ID == hospital.ID, "expected the hospital")
+	th.Assert(t, org.Taxonomies[0].Grouping == "Hospitals", fmt.Sprintf("expected the taxonomy's NUCC grouping, got %s", org.Taxonomies[0].Grouping))
+
+	_, err = store.GetNPIOrganizationByNPIID(ctx, "3")
+	th.Assert(t, err == sql.ErrNoRows, fmt.Sprintf("expected sql.ErrNoRows for a missing organization, got %v", err))
+
+	npiIDs, err := store.GetNPIOrganizationNPIIDsByProviderType(ctx, []string{" hospitals "})
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(npiIDs) == 1 && npiIDs["1"], fmt.Sprintf("expected only the hospital to match the provider type, got %v", npiIDs))
+
+	// deactivated organizations are not included in the normalized names
+
+	orgs, err := store.GetAllNPIOrganizationNormalizedNames(ctx)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(orgs) == 1 && orgs[0].NPI_ID == "1", "expected only the active organization")
+
+	deactivated.ReactivationDate = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	err = store.UpdateNPIOrganizationByNPIID(ctx, deactivated)
+	th.Assert(t, err == nil, err)
+	orgs, err = store.GetAllNPIOrganizationNormalizedNames(ctx)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(orgs) == 2, fmt.Sprintf("expected the reactivated organization to be included, got %d organizations", len(orgs)))
+
+	// links
+
+	_, _, _, err = store.GetNPIOrganizationFHIREndpointLink(ctx, "1", "example.com/FHIR/DSTU2/")
+	th.Assert(t, err == sql.ErrNoRows, fmt.Sprintf("expected sql.ErrNoRows for a missing link, got %v", err))
+	err = store.LinkNPIOrganizationToFHIREndpoint(ctx, "1", "example.com/FHIR/DSTU2/", .5)
+	th.Assert(t, err == nil, err)
+	err = store.LinkNPIOrganizationToFHIREndpoint(ctx, "1", "example.com/FHIR/DSTU2/", .5)
+	th.Assert(t, err != nil, "expected an error linking the same organization and endpoint twice")
+	err = store.UpdateNPIOrganizationFHIREndpointLink(ctx, "1", "example.com/FHIR/DSTU2/", .75)
+	th.Assert(t, err == nil, err)
+	_, _, confidence, err := store.GetNPIOrganizationFHIREndpointLink(ctx, "1", "example.com/FHIR/DSTU2/")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, confidence == .75, fmt.Sprintf("expected the updated confidence, got %f", confidence))
+}
+
+func Test_EndpointOrganizationReviewStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+
+	review := &endpointmanager.EndpointOrganizationReview{
+		URL:               "example.com/FHIR/DSTU2/",
+		OrganizationNPIID: "1",
+		Confidence:        .8,
+	}
+	err := store.SavePendingEndpointOrganizationReview(ctx, review)
+	th.Assert(t, err == nil, err)
+	err = store.SavePendingEndpointOrganizationReview(ctx, &endpointmanager.EndpointOrganizationReview{
+		URL:               "other.example.com/FHIR/DSTU2/",
+		OrganizationNPIID: "1",
+		Confidence:        .75,
+	})
+	th.Assert(t, err == nil, err)
+
+	reviews, err := store.GetEndpointOrganizationReviews(ctx, endpointmanager.ReviewPending)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(reviews) == 2, fmt.Sprintf("expected 2 pending reviews, got %d", len(reviews)))
+	th.Assert(t, reviews[0].Confidence == .75, "expected the reviews to be ordered by confidence")
+
+	// decided reviews are not changed by later proposals
+
+	err = store.DecideEndpointOrganizationReview(ctx, review.URL, review.OrganizationNPIID, endpointmanager.ReviewRejected, "reviewer")
+	th.Assert(t, err == nil, err)
+	review.Confidence = .9
+	err = store.SavePendingEndpointOrganizationReview(ctx, review)
+	th.Assert(t, err == nil, err)
+	r, err := store.GetEndpointOrganizationReview(ctx, review.URL, review.OrganizationNPIID)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, r.Status == endpointmanager.ReviewRejected, fmt.Sprintf("expected the review to stay rejected, got %s", r.Status))
+	th.Assert(t, r.Confidence == .8, fmt.Sprintf("expected the rejected review's confidence to be unchanged, got %f", r.Confidence))
+	th.Assert(t, r.DecidedBy == "reviewer", fmt.Sprintf("expected the reviewer to be recorded, got %s", r.DecidedBy))
+
+	err = store.DecideEndpointOrganizationReview(ctx, review.URL, review.OrganizationNPIID, endpointmanager.ReviewPending, "reviewer")
+	th.Assert(t, err != nil, "expected an error deciding a review as pending")
+}
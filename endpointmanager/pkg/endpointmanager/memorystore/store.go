@@ -0,0 +1,149 @@
+package memorystore
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+)
+
+// Store is an in-memory implementation of the store interfaces in the endpointmanager package, for testing the
+// packages that use them without a database. It keeps the lookup semantics of postgresql.Store, including returning
+// sql.ErrNoRows when a lookup finds nothing, but does not enforce the database's constraints.
+// Usage:
+//
+// store := memorystore.NewStore()
+// err := store.AddFHIREndpoint(ctx, endpoint)
+// <etc.>
+//
+// The objects passed to and returned from the store are copied, so changing them does not change what is stored.
+type Store struct {
+	mu sync.Mutex
+
+	nextID int
+	now    func() time.Time
+
+	endpoints       map[int]*endpointmanager.FHIREndpoint
+	infos           map[int]*endpointmanager.FHIREndpointInfo
+	infoMetadataIDs map[int]int
+	metadata        map[int]*endpointmanager.FHIREndpointMetadata
+	vendors         map[int]*endpointmanager.Vendor
+	products        map[int]*endpointmanager.HealthITProduct
+	productCriteria map[productCriteriaKey]string
+	criteria        map[int]*endpointmanager.CertificationCriteria
+	npiOrgs         map[int]*endpointmanager.NPIOrganization
+	nuccTaxonomies  map[string]*endpointmanager.NUCCTaxonomy
+	orgLinks        map[orgLinkKey]float64
+	reviews         map[orgLinkKey]*endpointmanager.EndpointOrganizationReview
+	npiContacts     map[int]*endpointmanager.NPIContact
+	validations     map[int][]endpointmanager.Rule
+}
+
+var _ endpointmanager.EndpointStore = (*Store)(nil)
+var _ endpointmanager.EndpointInfoStore = (*Store)(nil)
+var _ endpointmanager.EndpointListStore = (*Store)(nil)
+var _ endpointmanager.VendorStore = (*Store)(nil)
+var _ endpointmanager.ProductStore = (*Store)(nil)
+var _ endpointmanager.NPIStore = (*Store)(nil)
+var _ endpointmanager.ValidationStore = (*Store)(nil)
+
+// productCriteriaKey identifies a link between a health IT product's database ID and a criteria's certification ID.
+type productCriteriaKey struct {
+	productID  int
+	criteriaID int
+}
+
+// orgLinkKey identifies a link, or a review of a link, between an NPI organization's NPI ID and an endpoint URL.
+type orgLinkKey struct {
+	orgID string
+	url   string
+}
+
+// NewStore creates an empty in-memory store.
+func NewStore() *Store {
+	return &Store{
+		nextID:          1,
+		now:             time.Now,
+		endpoints:       make(map[int]*endpointmanager.FHIREndpoint),
+		infos:           make(map[int]*endpointmanager.FHIREndpointInfo),
+		infoMetadataIDs: make(map[int]int),
+		metadata:        make(map[int]*endpointmanager.FHIREndpointMetadata),
+		vendors:         make(map[int]*endpointmanager.Vendor),
+		products:        make(map[int]*endpointmanager.HealthITProduct),
+		productCriteria: make(map[productCriteriaKey]string),
+		criteria:        make(map[int]*endpointmanager.CertificationCriteria),
+		npiOrgs:         make(map[int]*endpointmanager.NPIOrganization),
+		nuccTaxonomies:  make(map[string]*endpointmanager.NUCCTaxonomy),
+		orgLinks:        make(map[orgLinkKey]float64),
+		reviews:         make(map[orgLinkKey]*endpointmanager.EndpointOrganizationReview),
+		npiContacts:     make(map[int]*endpointmanager.NPIContact),
+		validations:     make(map[int][]endpointmanager.Rule),
+	}
+}
+
+// WithEndpointListTx runs 'fn' with the store. If 'fn' returns an error, the endpoints, infos and metadata are restored
+// to what they were before 'fn' was run. Unlike a database transaction, the calls are not isolated from calls made
+// to the store concurrently.
+func (s *Store) WithEndpointListTx(ctx context.Context, fn func(txStore endpointmanager.EndpointListStore) error) error {
+	s.mu.Lock()
+	endpoints := make(map[int]*endpointmanager.FHIREndpoint, len(s.endpoints))
+	for id, endpoint := range s.endpoints {
+		endpoints[id] = endpoint
+	}
+	infos := make(map[int]*endpointmanager.FHIREndpointInfo, len(s.infos))
+	for id, info := range s.infos {
+		infos[id] = info
+	}
+	infoMetadataIDs := make(map[int]int, len(s.infoMetadataIDs))
+	for id, metadataID := range s.infoMetadataIDs {
+		infoMetadataIDs[id] = metadataID
+	}
+	metadata := make(map[int]*endpointmanager.FHIREndpointMetadata, len(s.metadata))
+	for id, m := range s.metadata {
+		metadata[id] = m
+	}
+	s.mu.Unlock()
+
+	err := fn(s)
+	if err != nil {
+		s.mu.Lock()
+		s.endpoints = endpoints
+		s.infos = infos
+		s.infoMetadataIDs = infoMetadataIDs
+		s.metadata = metadata
+		s.mu.Unlock()
+	}
+	return err
+}
+
+// newID returns the next database ID. The IDs are shared by all of the stored objects, so they are unique across
+// them. The caller must hold the store's lock.
+func (s *Store) newID() int {
+	id := s.nextID
+	s.nextID++
+	return id
+}
+
+// sortedIDs returns the keys of a map keyed by database ID in ascending order, so that the objects are listed in the
+// order they were added.
+func sortedIDs(ids []int) []int {
+	sort.Ints(ids)
+	return ids
+}
+
+func copyStrings(strs []string) []string {
+	if strs == nil {
+		return nil
+	}
+	return append([]string{}, strs...)
+}
+
+func copyLocation(location *endpointmanager.Location) *endpointmanager.Location {
+	if location == nil {
+		return nil
+	}
+	cp := *location
+	return &cp
+}
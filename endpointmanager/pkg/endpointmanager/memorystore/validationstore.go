@@ -0,0 +1,36 @@
+package memorystore
+
+import (
+	"context"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+)
+
+// GetValidationByID gets the validation rules stored with the given validation result id
+func (s *Store) GetValidationByID(ctx context.Context, id int) (*[]endpointmanager.Rule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rules []endpointmanager.Rule
+	rules = append(rules, s.validations[id]...)
+	return &rules, nil
+}
+
+// AddValidationResult creates a new ID for the validation data and returns it
+func (s *Store) AddValidationResult(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.newID()
+	s.validations[id] = nil
+	return id, nil
+}
+
+// AddValidation adds the Validation data for the given validation result id
+func (s *Store) AddValidation(ctx context.Context, v *endpointmanager.Validation, valResID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.validations[valResID] = append(s.validations[valResID], v.Results...)
+	return nil
+}
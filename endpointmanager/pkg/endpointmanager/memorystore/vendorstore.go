@@ -0,0 +1,99 @@
+package memorystore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+)
+
+// GetVendor gets the Vendor with the given database ID.
+// If the Vendor does not exist, sql.ErrNoRows will be returned.
+func (s *Store) GetVendor(ctx context.Context, id int) (*endpointmanager.Vendor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vendor, ok := s.vendors[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return copyVendor(vendor), nil
+}
+
+// GetVendorUsingName gets the Vendor with the given name.
+// If the Vendor does not exist, sql.ErrNoRows will be returned.
+func (s *Store) GetVendorUsingName(ctx context.Context, name string) (*endpointmanager.Vendor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range s.vendorIDs() {
+		if s.vendors[id].Name == name {
+			return copyVendor(s.vendors[id]), nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// GetVendorNames returns a list of all of the vendor names
+func (s *Store) GetVendorNames(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var names []string
+	for _, id := range s.vendorIDs() {
+		names = append(names, s.vendors[id].Name)
+	}
+	return names, nil
+}
+
+// AddVendor adds the Vendor to the store and sets its database ID.
+func (s *Store) AddVendor(ctx context.Context, v *endpointmanager.Vendor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v.ID = s.newID()
+	stored := copyVendor(v)
+	stored.CreatedAt = s.now()
+	stored.UpdatedAt = stored.CreatedAt
+	s.vendors[v.ID] = stored
+	return nil
+}
+
+// UpdateVendor updates the Vendor using the Vendor's database ID as the key.
+func (s *Store) UpdateVendor(ctx context.Context, v *endpointmanager.Vendor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.vendors[v.ID]
+	if !ok {
+		return nil
+	}
+	stored := copyVendor(v)
+	stored.CreatedAt = existing.CreatedAt
+	stored.UpdatedAt = s.now()
+	s.vendors[v.ID] = stored
+	return nil
+}
+
+// DeleteVendor deletes the Vendor using the Vendor's database ID as the key.
+func (s *Store) DeleteVendor(ctx context.Context, v *endpointmanager.Vendor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.vendors, v.ID)
+	return nil
+}
+
+func (s *Store) vendorIDs() []int {
+	var ids []int
+	for id := range s.vendors {
+		ids = append(ids, id)
+	}
+	return sortedIDs(ids)
+}
+
+func copyVendor(v *endpointmanager.Vendor) *endpointmanager.Vendor {
+	cp := *v
+	cp.Location = copyLocation(v.Location)
+	return &cp
+}
@@ -11,10 +11,17 @@ type NPIOrganization struct {
 	NPI_ID                  string
 	Name                    string
 	SecondaryName           string
-	Location                *Location
-	Taxonomy                string // Taxonomy code mapping: http://www.wpc-edi.com/reference/codelists/healthcare/health-care-provider-taxonomy-code-set/
+	Location                *Location // the practice location
+	MailingLocation         *Location
+	Taxonomy                string // the primary taxonomy code. Taxonomy code mapping: http://www.wpc-edi.com/reference/codelists/healthcare/health-care-provider-taxonomy-code-set/
+	Taxonomies              []NPITaxonomy
+	Identifiers             []NPIIdentifier
+	AuthorizedOfficial      *NPIAuthorizedOfficial
 	NormalizedName          string
 	NormalizedSecondaryName string
+	LastUpdateDate          time.Time // the zero time when NPPES has not reported a date
+	DeactivationDate        time.Time
+	ReactivationDate        time.Time
 	CreatedAt               time.Time
 	UpdatedAt               time.Time
 }
@@ -40,9 +47,108 @@ func (org *NPIOrganization) Equal(org2 *NPIOrganization) bool {
 	if !org.Location.Equal(org2.Location) {
 		return false
 	}
+	if !org.MailingLocation.Equal(org2.MailingLocation) {
+		return false
+	}
 	if org.Taxonomy != org2.Taxonomy {
 		return false
 	}
+	if len(org.Taxonomies) != len(org2.Taxonomies) {
+		return false
+	}
+	for i, taxonomy := range org.Taxonomies {
+		if !taxonomy.Equal(org2.Taxonomies[i]) {
+			return false
+		}
+	}
+	if len(org.Identifiers) != len(org2.Identifiers) {
+		return false
+	}
+	for i, identifier := range org.Identifiers {
+		if identifier != org2.Identifiers[i] {
+			return false
+		}
+	}
+	if !org.AuthorizedOfficial.Equal(org2.AuthorizedOfficial) {
+		return false
+	}
 
 	return true
 }
+
+// Deactivated returns true if NPPES deactivated the organization's NPI and has not reactivated it since.
+func (org *NPIOrganization) Deactivated() bool {
+	return !org.DeactivationDate.IsZero() && org.ReactivationDate.Before(org.DeactivationDate)
+}
+
+// NPITaxonomy is one of the up to 15 taxonomies NPPES lists for an organization. Grouping, Classification,
+// Specialization and DisplayName are looked up from the NUCC code set and are empty if the code is not in the
+// code set.
+type NPITaxonomy struct {
+	Code           string
+	LicenseNumber  string
+	LicenseState   string
+	Primary        bool
+	Grouping       string
+	Classification string
+	Specialization string
+	DisplayName    string
+}
+
+// Equal checks the fields of the two NPITaxonomies that come from NPPES to see if they are equal. The fields
+// looked up from the NUCC code set are not checked.
+func (t NPITaxonomy) Equal(t2 NPITaxonomy) bool {
+	return t.Code == t2.Code &&
+		t.LicenseNumber == t2.LicenseNumber &&
+		t.LicenseState == t2.LicenseState &&
+		t.Primary == t2.Primary
+}
+
+// NPIIdentifier is another identifier for an organization, such as a Medicaid or Medicare ID.
+type NPIIdentifier struct {
+	Identifier string
+	TypeCode   string
+	State      string
+	Issuer     string // only set when TypeCode is "01" (Other)
+}
+
+// npiIdentifierTypes maps the NPPES other provider identifier type codes to their descriptions.
+var npiIdentifierTypes = map[string]string{
+	"01": "Other",
+	"02": "Medicare UPIN",
+	"04": "Medicare ID-Type I",
+	"05": "Medicaid",
+	"06": "Medicare OSCAR/Certification",
+	"07": "Medicare NSC",
+	"08": "Medicare PIN",
+}
+
+// TypeDescription returns the description of the identifier's type code, or the type code itself if it is not known.
+func (id NPIIdentifier) TypeDescription() string {
+	if description, ok := npiIdentifierTypes[id.TypeCode]; ok {
+		return description
+	}
+	return id.TypeCode
+}
+
+// NPIAuthorizedOfficial is the person NPPES lists as authorized to act for an organization.
+type NPIAuthorizedOfficial struct {
+	NamePrefix string `json:"namePrefix"`
+	FirstName  string `json:"firstName"`
+	MiddleName string `json:"middleName"`
+	LastName   string `json:"lastName"`
+	NameSuffix string `json:"nameSuffix"`
+	Credential string `json:"credential"`
+	Title      string `json:"title"`
+	Telephone  string `json:"telephone"`
+}
+
+// Equal checks each field of the two NPIAuthorizedOfficials to see if they are equal.
+func (ao *NPIAuthorizedOfficial) Equal(ao2 *NPIAuthorizedOfficial) bool {
+	if ao == nil && ao2 == nil {
+		return true
+	} else if ao == nil || ao2 == nil {
+		return false
+	}
+	return *ao == *ao2
+}
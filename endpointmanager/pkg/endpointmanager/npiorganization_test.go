@@ -18,7 +18,20 @@ func Test_NPIOrganizationEqual(t *testing.T) {
 			City:     "A City",
 			State:    "AK",
 			ZipCode:  "00000"},
-		Taxonomy: "208D00000X"}
+		MailingLocation: &Location{
+			Address1: "PO Box 1",
+			City:     "A City",
+			State:    "AK",
+			ZipCode:  "00000"},
+		Taxonomy: "208D00000X",
+		Taxonomies: []NPITaxonomy{
+			{Code: "208D00000X", LicenseNumber: "123", LicenseState: "AK", Primary: true},
+			{Code: "282N00000X"}},
+		Identifiers: []NPIIdentifier{{Identifier: "456", TypeCode: "05", State: "AK"}},
+		AuthorizedOfficial: &NPIAuthorizedOfficial{
+			FirstName: "Jane",
+			LastName:  "Doe",
+			Title:     "CEO"}}
 
 	var npio2 = &NPIOrganization{
 		ID:            1,
@@ -31,7 +44,20 @@ func Test_NPIOrganizationEqual(t *testing.T) {
 			City:     "A City",
 			State:    "AK",
 			ZipCode:  "00000"},
-		Taxonomy: "208D00000X"}
+		MailingLocation: &Location{
+			Address1: "PO Box 1",
+			City:     "A City",
+			State:    "AK",
+			ZipCode:  "00000"},
+		Taxonomy: "208D00000X",
+		Taxonomies: []NPITaxonomy{
+			{Code: "208D00000X", LicenseNumber: "123", LicenseState: "AK", Primary: true},
+			{Code: "282N00000X"}},
+		Identifiers: []NPIIdentifier{{Identifier: "456", TypeCode: "05", State: "AK"}},
+		AuthorizedOfficial: &NPIAuthorizedOfficial{
+			FirstName: "Jane",
+			LastName:  "Doe",
+			Title:     "CEO"}}
 
 	if !npio1.Equal(npio2) {
 		t.Errorf("Expected npi organization 1 to equal npi organization 2. They are not equal.")
@@ -73,6 +99,47 @@ func Test_NPIOrganizationEqual(t *testing.T) {
 	}
 	npio2.Taxonomy = npio1.Taxonomy
 
+	npio2.MailingLocation.Address1 = "other"
+	if npio1.Equal(npio2) {
+		t.Errorf("Did not expect npi organization 1 to equal npi organization 2. MailingLocation.Address1 should be different. %s vs %s", npio1.MailingLocation.Address1, npio2.MailingLocation.Address1)
+	}
+	npio2.MailingLocation.Address1 = npio1.MailingLocation.Address1
+
+	npio2.Taxonomies = npio2.Taxonomies[:1]
+	if npio1.Equal(npio2) {
+		t.Errorf("Did not expect npi organization 1 to equal npi organization 2. Taxonomies should be different. %v vs %v", npio1.Taxonomies, npio2.Taxonomies)
+	}
+	npio2.Taxonomies = []NPITaxonomy{
+		{Code: "208D00000X", LicenseNumber: "123", LicenseState: "AK", Primary: true, DisplayName: "General Practice"},
+		{Code: "282N00000X", Grouping: "Hospitals"}}
+	if !npio1.Equal(npio2) {
+		t.Errorf("Expected npi organization 1 to equal npi organization 2. Taxonomy fields from the code set should be ignored.")
+	}
+	npio2.Taxonomies[0].Primary = false
+	if npio1.Equal(npio2) {
+		t.Errorf("Did not expect npi organization 1 to equal npi organization 2. Taxonomy primary flag should be different.")
+	}
+	npio2.Taxonomies[0].Primary = true
+
+	npio2.Identifiers = []NPIIdentifier{{Identifier: "456", TypeCode: "06", State: "AK"}}
+	if npio1.Equal(npio2) {
+		t.Errorf("Did not expect npi organization 1 to equal npi organization 2. Identifiers should be different. %v vs %v", npio1.Identifiers, npio2.Identifiers)
+	}
+	npio2.Identifiers = []NPIIdentifier{{Identifier: "456", TypeCode: "05", State: "AK"}}
+
+	npio2.AuthorizedOfficial.Title = "other"
+	if npio1.Equal(npio2) {
+		t.Errorf("Did not expect npi organization 1 to equal npi organization 2. AuthorizedOfficial.Title should be different. %s vs %s", npio1.AuthorizedOfficial.Title, npio2.AuthorizedOfficial.Title)
+	}
+	npio2.AuthorizedOfficial = nil
+	if npio1.Equal(npio2) {
+		t.Errorf("Did not expect npi organization 1 to equal npi organization 2. AuthorizedOfficial should be nil.")
+	}
+	npio2.AuthorizedOfficial = &NPIAuthorizedOfficial{
+		FirstName: "Jane",
+		LastName:  "Doe",
+		Title:     "CEO"}
+
 	npio2 = nil
 	if npio1.Equal(npio2) {
 		t.Errorf("Did not expect npi organization 1 to equal nil npi organization 2.")
@@ -89,3 +156,14 @@ func Test_NPIOrganizationEqual(t *testing.T) {
 		t.Errorf("Nil npi organization 1 should equal nil npi organization 2.")
 	}
 }
+
+func Test_NPIIdentifierTypeDescription(t *testing.T) {
+	id := NPIIdentifier{Identifier: "456", TypeCode: "05"}
+	if id.TypeDescription() != "Medicaid" {
+		t.Errorf("Expected type description to be Medicaid, got %s", id.TypeDescription())
+	}
+	id.TypeCode = "99"
+	if id.TypeDescription() != "99" {
+		t.Errorf("Expected an unknown type code to be returned as is, got %s", id.TypeDescription())
+	}
+}
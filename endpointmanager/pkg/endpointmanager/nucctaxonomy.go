@@ -0,0 +1,11 @@
+package endpointmanager
+
+// NUCCTaxonomy is an entry in the NUCC health care provider taxonomy code set, which NPPES uses to describe the
+// type of a provider. See https://www.nucc.org/index.php/code-sets-mainmenu-41/provider-taxonomy-mainmenu-40
+type NUCCTaxonomy struct {
+	Code           string
+	Grouping       string // for example, "Hospitals" or "Suppliers"
+	Classification string // for example, "General Acute Care Hospital" or "Pharmacy"
+	Specialization string // for example, "Critical Access" or "Community/Retail Pharmacy"
+	DisplayName    string
+}
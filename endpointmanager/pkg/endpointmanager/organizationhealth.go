@@ -0,0 +1,108 @@
+package endpointmanager
+
+import (
+	"sort"
+)
+
+// OrganizationEndpoint is a FHIR endpoint linked to an NPI organization along with the results of the endpoint's
+// most recent capability query. HTTPResponse is 0 and Availability is 0 if the endpoint has not been queried.
+type OrganizationEndpoint struct {
+	URL          string
+	Confidence   float64 // the confidence of the link between the endpoint and the organization
+	VendorName   string
+	FHIRVersion  string
+	HTTPResponse int
+	Availability float64
+}
+
+// Working returns true if the endpoint responded to its most recent capability query with a 200 status.
+func (e *OrganizationEndpoint) Working() bool {
+	return e.HTTPResponse == 200
+}
+
+// OrganizationEndpointHealth summarizes the health of the FHIR endpoints linked to an NPI organization.
+// Vendors and FHIRVersions are the distinct values among the endpoints, Availability is the mean availability
+// of the endpoints and HasWorkingAPI is true if any of the endpoints is working.
+type OrganizationEndpointHealth struct {
+	NPIID         string
+	Name          string
+	State         string
+	Endpoints     []*OrganizationEndpoint
+	Vendors       []string
+	FHIRVersions  []string
+	Availability  float64
+	HasWorkingAPI bool
+}
+
+// Summarize sets the Vendors, FHIRVersions, Availability and HasWorkingAPI fields from the organization's
+// endpoints.
+func (o *OrganizationEndpointHealth) Summarize() {
+	o.Vendors = nil
+	o.FHIRVersions = nil
+	o.Availability = 0
+	o.HasWorkingAPI = false
+
+	vendors := make(map[string]bool)
+	versions := make(map[string]bool)
+	for _, endpoint := range o.Endpoints {
+		if endpoint.VendorName != "" && !vendors[endpoint.VendorName] {
+			vendors[endpoint.VendorName] = true
+			o.Vendors = append(o.Vendors, endpoint.VendorName)
+		}
+		if endpoint.FHIRVersion != "" && !versions[endpoint.FHIRVersion] {
+			versions[endpoint.FHIRVersion] = true
+			o.FHIRVersions = append(o.FHIRVersions, endpoint.FHIRVersion)
+		}
+		o.Availability += endpoint.Availability
+		if endpoint.Working() {
+			o.HasWorkingAPI = true
+		}
+	}
+	if len(o.Endpoints) > 0 {
+		o.Availability = o.Availability / float64(len(o.Endpoints))
+	}
+	sort.Strings(o.Vendors)
+	sort.Strings(o.FHIRVersions)
+}
+
+// StateCoverage counts the organizations in a state that have linked endpoints and how many of them have a
+// working API.
+type StateCoverage struct {
+	State                string
+	Organizations        int
+	OrganizationsWithAPI int
+	Endpoints            int
+	WorkingEndpoints     int
+}
+
+// OrganizationCoverageByState returns the coverage of each state among the organizations, ordered by state.
+// Organizations without a state are counted under the empty state.
+func OrganizationCoverageByState(orgs []*OrganizationEndpointHealth) []StateCoverage {
+	coverages := make(map[string]*StateCoverage)
+	var states []string
+	for _, org := range orgs {
+		coverage, ok := coverages[org.State]
+		if !ok {
+			coverage = &StateCoverage{State: org.State}
+			coverages[org.State] = coverage
+			states = append(states, org.State)
+		}
+		coverage.Organizations++
+		if org.HasWorkingAPI {
+			coverage.OrganizationsWithAPI++
+		}
+		for _, endpoint := range org.Endpoints {
+			coverage.Endpoints++
+			if endpoint.Working() {
+				coverage.WorkingEndpoints++
+			}
+		}
+	}
+
+	sort.Strings(states)
+	result := make([]StateCoverage, len(states))
+	for i, state := range states {
+		result[i] = *coverages[state]
+	}
+	return result
+}
@@ -0,0 +1,74 @@
+package endpointmanager
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_OrganizationEndpointHealthSummarize(t *testing.T) {
+	org := &OrganizationEndpointHealth{
+		NPIID: "1",
+		Endpoints: []*OrganizationEndpoint{
+			{URL: "http://a.com/", VendorName: "Epic Systems Corporation", FHIRVersion: "4.0.1", HTTPResponse: 404, Availability: 0.5},
+			{URL: "http://b.com/", VendorName: "Cerner Corporation", FHIRVersion: "4.0.1", HTTPResponse: 200, Availability: 1},
+			{URL: "http://c.com/", VendorName: "Epic Systems Corporation", FHIRVersion: "1.0.2", HTTPResponse: 0, Availability: 0},
+		},
+	}
+	org.Summarize()
+
+	expectedVendors := []string{"Cerner Corporation", "Epic Systems Corporation"}
+	if !cmp.Equal(org.Vendors, expectedVendors) {
+		t.Errorf("Expected vendors %v. Got %v", expectedVendors, org.Vendors)
+	}
+	expectedVersions := []string{"1.0.2", "4.0.1"}
+	if !cmp.Equal(org.FHIRVersions, expectedVersions) {
+		t.Errorf("Expected FHIR versions %v. Got %v", expectedVersions, org.FHIRVersions)
+	}
+	if org.Availability != 0.5 {
+		t.Errorf("Expected the mean availability 0.5. Got %f", org.Availability)
+	}
+	if !org.HasWorkingAPI {
+		t.Errorf("Expected the organization to have a working API")
+	}
+
+	// summarizing again after the working endpoint is removed
+
+	org.Endpoints = org.Endpoints[:1]
+	org.Summarize()
+	if org.HasWorkingAPI {
+		t.Errorf("Expected the organization to not have a working API")
+	}
+	if len(org.Vendors) != 1 || len(org.FHIRVersions) != 1 {
+		t.Errorf("Expected one vendor and one FHIR version. Got %v and %v", org.Vendors, org.FHIRVersions)
+	}
+
+	// no endpoints
+
+	org = &OrganizationEndpointHealth{NPIID: "2"}
+	org.Summarize()
+	if org.Availability != 0 || org.HasWorkingAPI {
+		t.Errorf("Expected an organization without endpoints to have no availability and no working API")
+	}
+}
+
+func Test_OrganizationCoverageByState(t *testing.T) {
+	working := &OrganizationEndpoint{URL: "http://a.com/", HTTPResponse: 200}
+	down := &OrganizationEndpoint{URL: "http://b.com/", HTTPResponse: 500}
+	orgs := []*OrganizationEndpointHealth{
+		{NPIID: "1", State: "MA", Endpoints: []*OrganizationEndpoint{working, down}, HasWorkingAPI: true},
+		{NPIID: "2", State: "AK", Endpoints: []*OrganizationEndpoint{down}},
+		{NPIID: "3", State: "MA", Endpoints: []*OrganizationEndpoint{down}},
+		{NPIID: "4", Endpoints: []*OrganizationEndpoint{working}, HasWorkingAPI: true},
+	}
+
+	expected := []StateCoverage{
+		{State: "", Organizations: 1, OrganizationsWithAPI: 1, Endpoints: 1, WorkingEndpoints: 1},
+		{State: "AK", Organizations: 1, OrganizationsWithAPI: 0, Endpoints: 1, WorkingEndpoints: 0},
+		{State: "MA", Organizations: 2, OrganizationsWithAPI: 1, Endpoints: 3, WorkingEndpoints: 1},
+	}
+	actual := OrganizationCoverageByState(orgs)
+	if !cmp.Equal(actual, expected) {
+		t.Errorf("Expected coverage %v. Got %v", expected, actual)
+	}
+}
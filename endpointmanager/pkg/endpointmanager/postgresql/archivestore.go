@@ -0,0 +1,143 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ArchiveEndpoint is an endpoint from an endpoint list along with a FHIR version that has been requested from it.
+// RequestedFhirVersion is "None" if the endpoint has no info history.
+type ArchiveEndpoint struct {
+	URL                  string
+	RequestedFhirVersion string
+	OrganizationNames    []string
+	CreatedAt            time.Time
+	ListSource           string
+}
+
+// ArchiveInfoHistoryEntry is the part of a fhir_endpoints_info_history entry summarized in the archive.
+// VendorName is "" if the entry does not have a vendor.
+type ArchiveInfoHistoryEntry struct {
+	UpdatedAt             time.Time
+	Operation             string
+	CapabilityFhirVersion string
+	TLSVersion            string
+	MIMETypes             []string
+	VendorName            string
+}
+
+// ArchiveMetadataEntry is the part of a fhir_endpoints_metadata entry summarized in the archive.
+type ArchiveMetadataEntry struct {
+	ResponseTimeSeconds float64
+	HTTPResponse        int
+	SMARTHTTPResponse   int
+	Errors              string
+	ErrorCategory       string
+}
+
+// GetArchiveEndpoints gets every endpoint in fhir_endpoints once for each FHIR version requested from its URL.
+func (s *Store) GetArchiveEndpoints(ctx context.Context) ([]*ArchiveEndpoint, error) {
+	sqlStatement := `
+	SELECT DISTINCT e.url, h.requested_fhir_version, e.organization_names, e.created_at, e.list_source
+	FROM fhir_endpoints e LEFT JOIN fhir_endpoints_info_history h ON e.url = h.url`
+	rows, err := s.querier().QueryContext(ctx, sqlStatement)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var endpoints []*ArchiveEndpoint
+	for rows.Next() {
+		var endpoint ArchiveEndpoint
+		var requestedFhirVersion sql.NullString
+		err = rows.Scan(
+			&endpoint.URL,
+			&requestedFhirVersion,
+			pq.Array(&endpoint.OrganizationNames),
+			&endpoint.CreatedAt,
+			&endpoint.ListSource)
+		if err != nil {
+			return nil, err
+		}
+		endpoint.RequestedFhirVersion = "None"
+		if requestedFhirVersion.Valid {
+			endpoint.RequestedFhirVersion = requestedFhirVersion.String
+		}
+		endpoints = append(endpoints, &endpoint)
+	}
+	return endpoints, rows.Err()
+}
+
+// GetArchiveInfoHistory gets the info history entries of the URL and requested version updated at or after start
+// and before end, along with the name of their vendor, ordered by update time. The range is also applied to
+// entered_at, the column the history is partitioned on, so that only the partitions of the range are scanned. An
+// entry is never entered before its update time, and only deletions, which keep the deleted row's update time, are
+// entered later, so this leaves out just the deletions made after the range.
+func (s *Store) GetArchiveInfoHistory(ctx context.Context, url string, requestedFhirVersion string, start time.Time, end time.Time) ([]*ArchiveInfoHistoryEntry, error) {
+	sqlStatement := `
+	SELECT h.updated_at, h.operation, h.capability_fhir_version, h.tls_version, h.mime_types, v.name
+	FROM fhir_endpoints_info_history h LEFT JOIN vendors v ON h.vendor_id = v.id
+	WHERE h.entered_at >= $1 AND h.entered_at < $2 AND h.updated_at >= $1 AND h.updated_at < $2
+		AND h.url=$3 AND h.requested_fhir_version=$4
+	ORDER BY h.updated_at`
+	rows, err := s.querier().QueryContext(ctx, sqlStatement, start, end, url, requestedFhirVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*ArchiveInfoHistoryEntry
+	for rows.Next() {
+		var entry ArchiveInfoHistoryEntry
+		var vendorName sql.NullString
+		err = rows.Scan(
+			&entry.UpdatedAt,
+			&entry.Operation,
+			&entry.CapabilityFhirVersion,
+			&entry.TLSVersion,
+			pq.Array(&entry.MIMETypes),
+			&vendorName)
+		if err != nil {
+			return nil, err
+		}
+		entry.VendorName = vendorName.String
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
+
+// GetArchiveMetadata gets the metadata of the URL and requested version updated at or after start and before end,
+// ordered by update time. Metadata rows are not updated once they are added, so the range is also applied to
+// created_at, the column the metadata is partitioned on, so that only the partitions of the range are scanned.
+func (s *Store) GetArchiveMetadata(ctx context.Context, url string, requestedFhirVersion string, start time.Time, end time.Time) ([]*ArchiveMetadataEntry, error) {
+	sqlStatement := `
+	SELECT response_time_seconds, http_response, smart_http_response, errors, error_category
+	FROM fhir_endpoints_metadata
+	WHERE created_at >= $1 AND created_at < $2 AND updated_at >= $1 AND updated_at < $2
+		AND url=$3 AND requested_fhir_version=$4
+	ORDER BY updated_at`
+	rows, err := s.querier().QueryContext(ctx, sqlStatement, start, end, url, requestedFhirVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*ArchiveMetadataEntry
+	for rows.Next() {
+		var entry ArchiveMetadataEntry
+		err = rows.Scan(
+			&entry.ResponseTimeSeconds,
+			&entry.HTTPResponse,
+			&entry.SMARTHTTPResponse,
+			&entry.Errors,
+			&entry.ErrorCategory)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
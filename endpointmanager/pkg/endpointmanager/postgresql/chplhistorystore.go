@@ -0,0 +1,265 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+)
+
+// chplRevisionsQuery returns each row of the history table %[1]s entered at or after $1 as JSON along with the
+// row that preceded it for the same id, so that the two versions can be compared. other_version is true for
+// products that were added while a product with the same name and a different version already existed.
+const chplRevisionsQuery = `
+	SELECT operation, entered_at, current_row, previous_row, other_version FROM (
+		SELECT operation, entered_at, to_jsonb(h) AS current_row,
+			LAG(to_jsonb(h)) OVER (PARTITION BY id ORDER BY entered_at) AS previous_row,
+			%[2]s AS other_version
+		FROM %[1]s AS h
+		WHERE id IN (SELECT id FROM %[1]s WHERE entered_at >= $1)) AS revisions
+	WHERE entered_at >= $1
+	ORDER BY entered_at`
+
+const chplOtherVersionColumn = `(h.operation = 'I' AND EXISTS (
+	SELECT 1 FROM healthit_products_history AS o
+	WHERE o.name = h.name AND o.version IS DISTINCT FROM h.version AND o.entered_at < h.entered_at))`
+
+type productRevision struct {
+	Name                  string `json:"name"`
+	Version               string `json:"version"`
+	APIURL                string `json:"api_url"`
+	CertificationCriteria []int  `json:"certification_criteria"`
+	CertificationStatus   string `json:"certification_status"`
+	CertificationDate     string `json:"certification_date"`
+	CertificationEdition  string `json:"certification_edition"`
+	CHPLID                string `json:"chpl_id"`
+}
+
+type vendorRevision struct {
+	Name          string `json:"name"`
+	DeveloperCode string `json:"developer_code"`
+	URL           string `json:"url"`
+	Status        string `json:"status"`
+	CHPLID        int    `json:"chpl_id"`
+}
+
+type criteriaRevision struct {
+	CertificationID     int    `json:"certification_id"`
+	CertificationNumber string `json:"cerification_number"`
+	Title               string `json:"title"`
+	Description         string `json:"description"`
+	Removed             bool   `json:"removed"`
+}
+
+// AddCHPLSync records the start of a CHPL sync in the given mode and returns it.
+func (s *Store) AddCHPLSync(ctx context.Context, mode string) (*endpointmanager.CHPLSync, error) {
+	sync := endpointmanager.CHPLSync{Mode: mode}
+	row := s.querier().QueryRowContext(ctx, `INSERT INTO chpl_syncs (mode) VALUES ($1) RETURNING id, started_at`, mode)
+	err := row.Scan(&sync.ID, &sync.StartedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &sync, nil
+}
+
+// CompleteCHPLSync records that the CHPL sync finished successfully.
+func (s *Store) CompleteCHPLSync(ctx context.Context, sync *endpointmanager.CHPLSync) error {
+	row := s.querier().QueryRowContext(ctx, `UPDATE chpl_syncs SET completed_at = NOW() WHERE id = $1 RETURNING completed_at`, sync.ID)
+	return row.Scan(&sync.CompletedAt)
+}
+
+// GetLastCompletedCHPLSync gets the most recent CHPL sync that finished successfully. If there is none,
+// sql.ErrNoRows will be returned.
+func (s *Store) GetLastCompletedCHPLSync(ctx context.Context) (*endpointmanager.CHPLSync, error) {
+	var sync endpointmanager.CHPLSync
+	row := s.querier().QueryRowContext(ctx, `
+		SELECT id, mode, started_at, completed_at FROM chpl_syncs
+		WHERE completed_at IS NOT NULL
+		ORDER BY started_at DESC LIMIT 1`)
+	err := row.Scan(&sync.ID, &sync.Mode, &sync.StartedAt, &sync.CompletedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &sync, nil
+}
+
+// GetCHPLChangesSince gets the changes to the stored products, vendors and certification criteria that were made
+// at or after 'since', ordered by when they were made. Updates that did not change any of the compared fields are
+// left out.
+func (s *Store) GetCHPLChangesSince(ctx context.Context, since time.Time) ([]endpointmanager.CHPLChange, error) {
+	var changes []endpointmanager.CHPLChange
+
+	err := s.forEachCHPLRevision(ctx, "certification_criteria_history", "FALSE", since,
+		func(operation string, enteredAt time.Time, previousJSON, currentJSON []byte, otherVersion bool) error {
+			previous, err := decodeCriteriaRevision(previousJSON)
+			if err != nil {
+				return err
+			}
+			current, err := decodeCriteriaRevision(currentJSON)
+			if err != nil {
+				return err
+			}
+			if operation == "I" {
+				previous = nil
+			} else if operation == "D" {
+				previous, current = current, nil
+			}
+			changes = append(changes, endpointmanager.CertificationCriteriaChanges(previous, current, enteredAt)...)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.forEachCHPLRevision(ctx, "vendors_history", "FALSE", since,
+		func(operation string, enteredAt time.Time, previousJSON, currentJSON []byte, otherVersion bool) error {
+			previous, err := decodeVendorRevision(previousJSON)
+			if err != nil {
+				return err
+			}
+			current, err := decodeVendorRevision(currentJSON)
+			if err != nil {
+				return err
+			}
+			if operation == "I" {
+				previous = nil
+			} else if operation == "D" {
+				previous, current = current, nil
+			}
+			changes = append(changes, endpointmanager.VendorChanges(previous, current, enteredAt)...)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.forEachCHPLRevision(ctx, "healthit_products_history", chplOtherVersionColumn, since,
+		func(operation string, enteredAt time.Time, previousJSON, currentJSON []byte, otherVersion bool) error {
+			previous, err := decodeProductRevision(previousJSON)
+			if err != nil {
+				return err
+			}
+			current, err := decodeProductRevision(currentJSON)
+			if err != nil {
+				return err
+			}
+			if operation == "I" {
+				previous = nil
+			} else if operation == "D" {
+				previous, current = current, nil
+			}
+			productChanges := endpointmanager.HealthITProductChanges(previous, current, enteredAt)
+			if otherVersion && len(productChanges) == 1 && productChanges[0].Change == endpointmanager.CHPLChangeAdded {
+				productChanges[0].Change = endpointmanager.CHPLChangeNewVersion
+			}
+			changes = append(changes, productChanges...)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].ChangedAt.Before(changes[j].ChangedAt)
+	})
+	return changes, nil
+}
+
+// the decode functions return nil for a history row that does not exist, such as the row before an insert
+
+func decodeCriteriaRevision(data []byte) (*endpointmanager.CertificationCriteria, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var rev criteriaRevision
+	err := json.Unmarshal(data, &rev)
+	if err != nil {
+		return nil, err
+	}
+	return &endpointmanager.CertificationCriteria{
+		CertificationID:     rev.CertificationID,
+		CertificationNumber: rev.CertificationNumber,
+		Title:               rev.Title,
+		Description:         rev.Description,
+		Removed:             rev.Removed,
+	}, nil
+}
+
+func decodeVendorRevision(data []byte) (*endpointmanager.Vendor, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var rev vendorRevision
+	err := json.Unmarshal(data, &rev)
+	if err != nil {
+		return nil, err
+	}
+	return &endpointmanager.Vendor{
+		Name:          rev.Name,
+		DeveloperCode: rev.DeveloperCode,
+		URL:           rev.URL,
+		Status:        rev.Status,
+		CHPLID:        rev.CHPLID,
+	}, nil
+}
+
+func decodeProductRevision(data []byte) (*endpointmanager.HealthITProduct, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var rev productRevision
+	err := json.Unmarshal(data, &rev)
+	if err != nil {
+		return nil, err
+	}
+	product := &endpointmanager.HealthITProduct{
+		Name:                  rev.Name,
+		Version:               rev.Version,
+		APIURL:                rev.APIURL,
+		CertificationCriteria: rev.CertificationCriteria,
+		CertificationStatus:   rev.CertificationStatus,
+		CertificationEdition:  rev.CertificationEdition,
+		CHPLID:                rev.CHPLID,
+	}
+	if rev.CertificationDate != "" {
+		product.CertificationDate, err = time.Parse("2006-01-02", rev.CertificationDate)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return product, nil
+}
+
+func (s *Store) forEachCHPLRevision(ctx context.Context,
+	table string,
+	otherVersionColumn string,
+	since time.Time,
+	fn func(operation string, enteredAt time.Time, previous, current []byte, otherVersion bool) error) error {
+
+	rows, err := s.querier().QueryContext(ctx, fmt.Sprintf(chplRevisionsQuery, table, otherVersionColumn), since)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var operation string
+		var enteredAt time.Time
+		var current []byte
+		var previous []byte
+		var otherVersion sql.NullBool
+		err = rows.Scan(&operation, &enteredAt, &current, &previous, &otherVersion)
+		if err != nil {
+			return err
+		}
+		err = fn(operation, enteredAt, previous, current, otherVersion.Bool)
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,124 @@
+// +build integration
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+)
+
+func Test_CHPLSyncs(t *testing.T) {
+	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
+	defer teardown(t, store.DB)
+
+	ctx := context.Background()
+
+	_, err := store.GetLastCompletedCHPLSync(ctx)
+	th.Assert(t, err == sql.ErrNoRows, "expected no completed CHPL sync")
+
+	full, err := store.AddCHPLSync(ctx, endpointmanager.CHPLSyncFull)
+	th.Assert(t, err == nil, err)
+	_, err = store.GetLastCompletedCHPLSync(ctx)
+	th.Assert(t, err == sql.ErrNoRows, "expected a running CHPL sync not to be completed")
+
+	err = store.CompleteCHPLSync(ctx, full)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, !full.CompletedAt.IsZero(), "expected the completion time to be set")
+
+	// a sync that did not complete is not returned
+	_, err = store.AddCHPLSync(ctx, endpointmanager.CHPLSyncModified)
+	th.Assert(t, err == nil, err)
+
+	last, err := store.GetLastCompletedCHPLSync(ctx)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, last.ID == full.ID && last.Mode == endpointmanager.CHPLSyncFull, "expected the completed full sync to be the last completed sync")
+}
+
+func Test_GetCHPLChangesSince(t *testing.T) {
+	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
+	defer teardown(t, store.DB)
+
+	ctx := context.Background()
+
+	vendor := &endpointmanager.Vendor{
+		Name:          "Epic Systems Corporation",
+		DeveloperCode: "1447",
+		CHPLID:        448,
+		URL:           "http://www.epic.com",
+		Location:      &endpointmanager.Location{},
+		Status:        "Active",
+	}
+	err := store.AddVendor(ctx, vendor)
+	th.Assert(t, err == nil, err)
+
+	product := &endpointmanager.HealthITProduct{
+		Name:                 "Carefluence Open API",
+		Version:              "1",
+		VendorID:             vendor.ID,
+		APIURL:               "http://carefluence.com/docs.html",
+		CertificationStatus:  "Active",
+		CertificationDate:    time.Date(2016, 7, 1, 0, 0, 0, 0, time.UTC),
+		CertificationEdition: "2014",
+		CHPLID:               "15.04.04.2657.Care.01.00.0.160701",
+		Location:             &endpointmanager.Location{},
+	}
+	err = store.AddHealthITProduct(ctx, product)
+	th.Assert(t, err == nil, err)
+
+	sync, err := store.AddCHPLSync(ctx, endpointmanager.CHPLSyncModified)
+	th.Assert(t, err == nil, err)
+
+	// an update that does not change anything is not recorded
+	err = store.UpdateVendor(ctx, vendor)
+	th.Assert(t, err == nil, err)
+
+	product.CertificationStatus = "Withdrawn by Developer"
+	product.APIURL = "http://carefluence.com/docs-v2.html"
+	err = store.UpdateHealthITProduct(ctx, product)
+	th.Assert(t, err == nil, err)
+
+	newVersion := *product
+	newVersion.ID = 0
+	newVersion.Version = "2"
+	newVersion.CertificationStatus = "Active"
+	newVersion.CHPLID = "15.04.04.2657.Care.02.00.0.200101"
+	err = store.AddHealthITProduct(ctx, &newVersion)
+	th.Assert(t, err == nil, err)
+
+	changes, err := store.GetCHPLChangesSince(ctx, sync.StartedAt)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(changes) == 3, fmt.Sprintf("expected 3 changes. Got %d: %+v", len(changes), changes))
+
+	kinds := make(map[string]endpointmanager.CHPLChange)
+	for _, change := range changes {
+		kinds[change.Change] = change
+	}
+	withdrawn, ok := kinds[endpointmanager.CHPLChangeWithdrawn]
+	th.Assert(t, ok, "expected the product to be reported as withdrawn")
+	th.Assert(t, withdrawn.Previous == "Active" && withdrawn.Current == "Withdrawn by Developer", "expected the withdrawn change to include the certification statuses")
+	apiURL, ok := kinds["api_url"]
+	th.Assert(t, ok, "expected the api_url change to be reported")
+	th.Assert(t, apiURL.Previous == "http://carefluence.com/docs.html" && apiURL.Current == "http://carefluence.com/docs-v2.html", "expected the api_url change to include the URLs")
+	added, ok := kinds[endpointmanager.CHPLChangeNewVersion]
+	th.Assert(t, ok, "expected the second version to be reported as a new version")
+	th.Assert(t, added.CHPLID == newVersion.CHPLID, "expected the new version to have its CHPL ID")
+
+	// removing a vendor is recorded
+	err = store.DeleteVendor(ctx, vendor)
+	th.Assert(t, err == nil, err)
+	changes, err = store.GetCHPLChangesSince(ctx, sync.StartedAt)
+	th.Assert(t, err == nil, err)
+	found := false
+	for _, change := range changes {
+		if change.Kind == endpointmanager.CHPLVendor && change.Change == endpointmanager.CHPLChangeRemoved {
+			found = true
+		}
+	}
+	th.Assert(t, found, "expected the vendor to be reported as removed")
+}
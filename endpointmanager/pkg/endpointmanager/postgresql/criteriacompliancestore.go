@@ -0,0 +1,106 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+)
+
+// endpointCriteriaComplianceQuery joins each queried endpoint to its health IT product and checks whether the
+// product is linked to the certification criteria $1 in product_criteria. Endpoints that were not matched to a
+// product are left out. Only the endpoints' default FHIR version responses are used.
+const endpointCriteriaComplianceQuery = `
+	SELECT info.url, COALESCE(metadata.http_response, 0), products.id, COALESCE(products.name, ''), COALESCE(products.version, ''),
+		COALESCE(products.chpl_id, ''), COALESCE(vendors.name, ''),
+		EXISTS (SELECT 1 FROM product_criteria AS crit
+			WHERE crit.healthit_product_id = products.id AND crit.certification_number = $1)
+	FROM fhir_endpoints_info AS info
+	JOIN healthit_products AS products ON info.healthit_product_id = products.id
+	LEFT JOIN vendors ON products.vendor_id = vendors.id
+	LEFT JOIN fhir_endpoints_metadata AS metadata ON info.metadata_id = metadata.id
+	WHERE info.requested_fhir_version = 'None'`
+
+// GetEndpointCriteriaCompliance gets whether the product of each endpoint that was matched to a health IT product
+// is certified to the criteria with the given certification number, ordered by URL.
+func (s *Store) GetEndpointCriteriaCompliance(ctx context.Context, certificationNumber string) ([]*endpointmanager.EndpointCriteriaCompliance, error) {
+	rows, err := s.querier().QueryContext(ctx, endpointCriteriaComplianceQuery+" ORDER BY info.url", certificationNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var compliances []*endpointmanager.EndpointCriteriaCompliance
+	for rows.Next() {
+		compliance, err := scanEndpointCriteriaCompliance(rows, certificationNumber)
+		if err != nil {
+			return nil, err
+		}
+		compliances = append(compliances, compliance)
+	}
+	return compliances, rows.Err()
+}
+
+// GetEndpointCriteriaComplianceUsingURL gets whether the product of the endpoint with the given URL is certified
+// to the criteria with the given certification number. If the endpoint has not been matched to a health IT
+// product, sql.ErrNoRows will be returned.
+func (s *Store) GetEndpointCriteriaComplianceUsingURL(ctx context.Context, url string, certificationNumber string) (*endpointmanager.EndpointCriteriaCompliance, error) {
+	row := s.querier().QueryRowContext(ctx, endpointCriteriaComplianceQuery+" AND info.url = $2", certificationNumber, url)
+	return scanEndpointCriteriaCompliance(row, certificationNumber)
+}
+
+// GetCertifiedProductsWithoutLiveEndpoints gets the health IT products certified to the criteria with the given
+// certification number that have no endpoints which responded to their most recent capability query with a 200
+// status, ordered by name and version.
+func (s *Store) GetCertifiedProductsWithoutLiveEndpoints(ctx context.Context, certificationNumber string) ([]*endpointmanager.HealthITProduct, error) {
+	sqlStatement := `
+	SELECT products.id, COALESCE(products.name, ''), COALESCE(products.version, ''), COALESCE(products.vendor_id, 0), COALESCE(products.api_url, ''),
+		COALESCE(products.certification_status, ''), COALESCE(products.certification_edition, ''), COALESCE(products.chpl_id, '')
+	FROM healthit_products AS products
+	WHERE EXISTS (SELECT 1 FROM product_criteria AS crit
+			WHERE crit.healthit_product_id = products.id AND crit.certification_number = $1)
+		AND NOT EXISTS (SELECT 1 FROM fhir_endpoints_info AS info
+			JOIN fhir_endpoints_metadata AS metadata ON info.metadata_id = metadata.id
+			WHERE info.healthit_product_id = products.id AND metadata.http_response = 200)
+	ORDER BY products.name, products.version`
+	rows, err := s.querier().QueryContext(ctx, sqlStatement, certificationNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hitps []*endpointmanager.HealthITProduct
+	for rows.Next() {
+		var hitp endpointmanager.HealthITProduct
+		err = rows.Scan(
+			&hitp.ID,
+			&hitp.Name,
+			&hitp.Version,
+			&hitp.VendorID,
+			&hitp.APIURL,
+			&hitp.CertificationStatus,
+			&hitp.CertificationEdition,
+			&hitp.CHPLID)
+		if err != nil {
+			return nil, err
+		}
+		hitps = append(hitps, &hitp)
+	}
+	return hitps, rows.Err()
+}
+
+func scanEndpointCriteriaCompliance(row rowScanner, certificationNumber string) (*endpointmanager.EndpointCriteriaCompliance, error) {
+	compliance := endpointmanager.EndpointCriteriaCompliance{CertificationNumber: certificationNumber}
+	err := row.Scan(
+		&compliance.URL,
+		&compliance.HTTPResponse,
+		&compliance.HealthITProductID,
+		&compliance.ProductName,
+		&compliance.ProductVersion,
+		&compliance.CHPLID,
+		&compliance.VendorName,
+		&compliance.Certified)
+	if err != nil {
+		return nil, err
+	}
+	return &compliance, nil
+}
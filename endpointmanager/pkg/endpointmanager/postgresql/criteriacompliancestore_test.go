@@ -0,0 +1,94 @@
+// +build integration
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+)
+
+func Test_CriteriaCompliance(t *testing.T) {
+	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
+	defer teardown(t, store.DB)
+
+	var err error
+	ctx := context.Background()
+
+	vendor := &endpointmanager.Vendor{Name: "Epic Systems Corporation", DeveloperCode: "A", CHPLID: 1}
+	err = store.AddVendor(ctx, vendor)
+	th.Assert(t, err == nil, err)
+
+	g10 := &endpointmanager.CertificationCriteria{CertificationID: 182, CertificationNumber: endpointmanager.StandardizedAPICriteria, Title: "Standardized API for patient and population services"}
+	f2 := &endpointmanager.CertificationCriteria{CertificationID: 44, CertificationNumber: "170.315 (f)(2)", Title: "Transmission to Public Health Agencies - Syndromic Surveillance"}
+	for _, crit := range []*endpointmanager.CertificationCriteria{g10, f2} {
+		err = store.AddCriteria(ctx, crit)
+		th.Assert(t, err == nil, err)
+	}
+
+	// certifiedLive is certified to (g)(10) and has a live endpoint, certifiedDown is certified to (g)(10) but its
+	// endpoint is not responding, certifiedUnused is certified to (g)(10) with no endpoints and uncertified is not
+	// certified to (g)(10)
+	certifiedLive := &endpointmanager.HealthITProduct{Name: "Certified Live", Version: "1", VendorID: vendor.ID, CHPLID: "live", CertificationCriteria: []int{182}}
+	certifiedDown := &endpointmanager.HealthITProduct{Name: "Certified Down", Version: "1", VendorID: vendor.ID, CHPLID: "down", CertificationCriteria: []int{182}}
+	certifiedUnused := &endpointmanager.HealthITProduct{Name: "Certified Unused", Version: "1", VendorID: vendor.ID, CHPLID: "unused", CertificationCriteria: []int{182, 44}}
+	uncertified := &endpointmanager.HealthITProduct{Name: "Uncertified", Version: "1", VendorID: vendor.ID, CHPLID: "uncertified", CertificationCriteria: []int{44}}
+	for _, hitp := range []*endpointmanager.HealthITProduct{certifiedLive, certifiedDown, certifiedUnused, uncertified} {
+		err = store.AddHealthITProduct(ctx, hitp)
+		th.Assert(t, err == nil, err)
+		for _, critID := range hitp.CertificationCriteria {
+			crit, err := store.GetCriteriaByCertificationID(ctx, critID)
+			th.Assert(t, err == nil, err)
+			err = store.LinkProductToCriteria(ctx, critID, hitp.ID, crit.CertificationNumber)
+			th.Assert(t, err == nil, err)
+		}
+	}
+
+	addInfo := func(url string, productID int, httpResponse int) {
+		metadataID, err := store.AddFHIREndpointMetadata(ctx, &endpointmanager.FHIREndpointMetadata{URL: url, HTTPResponse: httpResponse, RequestedFhirVersion: "None"})
+		th.Assert(t, err == nil, err)
+		valResID, err := store.AddValidationResult(ctx)
+		th.Assert(t, err == nil, err)
+		info := &endpointmanager.FHIREndpointInfo{URL: url, HealthITProductID: productID, VendorID: vendor.ID, RequestedFhirVersion: "None", ValidationID: valResID}
+		err = store.AddFHIREndpointInfo(ctx, info, metadataID)
+		th.Assert(t, err == nil, err)
+	}
+	addInfo("http://example.com/live/", certifiedLive.ID, 200)
+	addInfo("http://example.com/down/", certifiedDown.ID, 404)
+	addInfo("http://example.com/uncertified/", uncertified.ID, 200)
+	addInfo("http://example.com/unmatched/", 0, 200)
+
+	// endpoint compliance
+
+	compliances, err := store.GetEndpointCriteriaCompliance(ctx, endpointmanager.StandardizedAPICriteria)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(compliances) == 3, fmt.Sprintf("Expected 3 endpoints matched to products. Got %d", len(compliances)))
+	th.Assert(t, compliances[0].URL == "http://example.com/down/" && compliances[0].Certified && !compliances[0].Live(), "Expected the down endpoint to be certified and not live")
+	th.Assert(t, compliances[1].URL == "http://example.com/live/" && compliances[1].Certified && compliances[1].Live(), "Expected the live endpoint to be certified and live")
+	th.Assert(t, compliances[2].URL == "http://example.com/uncertified/" && !compliances[2].Certified, "Expected the uncertified endpoint to not be certified")
+	th.Assert(t, compliances[2].ProductName == "Uncertified" && compliances[2].CHPLID == "uncertified" && compliances[2].VendorName == vendor.Name, "Expected the uncertified endpoint's product and vendor")
+
+	compliance, err := store.GetEndpointCriteriaComplianceUsingURL(ctx, "http://example.com/live/", endpointmanager.StandardizedAPICriteria)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, compliance.Certified && compliance.HealthITProductID == certifiedLive.ID, "Expected the live endpoint's product to be certified")
+
+	compliance, err = store.GetEndpointCriteriaComplianceUsingURL(ctx, "http://example.com/live/", "170.315 (f)(2)")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, !compliance.Certified, "Expected the live endpoint's product to not be certified to (f)(2)")
+
+	_, err = store.GetEndpointCriteriaComplianceUsingURL(ctx, "http://example.com/unmatched/", endpointmanager.StandardizedAPICriteria)
+	th.Assert(t, err == sql.ErrNoRows, "Expected no rows for an endpoint without a product")
+
+	// certified products without live endpoints
+
+	hitps, err := store.GetCertifiedProductsWithoutLiveEndpoints(ctx, endpointmanager.StandardizedAPICriteria)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(hitps) == 2, fmt.Sprintf("Expected 2 certified products without live endpoints. Got %d", len(hitps)))
+	th.Assert(t, hitps[0].ID == certifiedDown.ID, "Expected the product whose endpoint is down")
+	th.Assert(t, hitps[1].ID == certifiedUnused.ID, "Expected the product without endpoints")
+	th.Assert(t, hitps[1].CHPLID == "unused", fmt.Sprintf("Expected the CHPL ID unused. Got %s", hitps[1].CHPLID))
+}
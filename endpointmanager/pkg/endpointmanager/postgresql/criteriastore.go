@@ -31,7 +31,7 @@ func (s *Store) GetCriteria(ctx context.Context, id int) (*endpointmanager.Certi
 		created_at,
 		updated_at
 	FROM certification_criteria WHERE id=$1`
-	row := s.DB.QueryRowContext(ctx, sqlStatement, id)
+	row := s.querier().QueryRowContext(ctx, sqlStatement, id)
 
 	err := row.Scan(
 		&criteria.ID,
@@ -70,7 +70,7 @@ func (s *Store) GetCriteriaByCertificationID(ctx context.Context, certID int) (*
 		created_at,
 		updated_at
 	FROM certification_criteria WHERE certification_id=$1`
-	row := s.DB.QueryRowContext(ctx, sqlStatement, certID)
+	row := s.querier().QueryRowContext(ctx, sqlStatement, certID)
 
 	err := row.Scan(
 		&criteria.ID,
@@ -92,7 +92,7 @@ func (s *Store) GetCriteriaByCertificationID(ctx context.Context, certID int) (*
 
 // AddCriteria adds the CertificationCriteria to the database.
 func (s *Store) AddCriteria(ctx context.Context, criteria *endpointmanager.CertificationCriteria) error {
-	row := addCriteriaStatement.QueryRowContext(ctx,
+	row := s.stmt(ctx, addCriteriaStatement).QueryRowContext(ctx,
 		criteria.CertificationID,
 		criteria.CertificationNumber,
 		criteria.Title,
@@ -109,7 +109,7 @@ func (s *Store) AddCriteria(ctx context.Context, criteria *endpointmanager.Certi
 // UpdateCriteria updates the CertificationCriteria in the database using the CertificationCriteria's database ID as the key.
 func (s *Store) UpdateCriteria(ctx context.Context, criteria *endpointmanager.CertificationCriteria) error {
 
-	_, err := updateCriteriaStatement.ExecContext(ctx,
+	_, err := s.stmt(ctx, updateCriteriaStatement).ExecContext(ctx,
 		criteria.CertificationID,
 		criteria.CertificationNumber,
 		criteria.Title,
@@ -124,7 +124,7 @@ func (s *Store) UpdateCriteria(ctx context.Context, criteria *endpointmanager.Ce
 
 // DeleteCriteria deletes the CertificationCriteria from the database using the CertificationCriteria's database ID as the key.
 func (s *Store) DeleteCriteria(ctx context.Context, criteria *endpointmanager.CertificationCriteria) error {
-	_, err := deleteCriteriaStatement.ExecContext(ctx, criteria.ID)
+	_, err := s.stmt(ctx, deleteCriteriaStatement).ExecContext(ctx, criteria.ID)
 
 	return err
 }
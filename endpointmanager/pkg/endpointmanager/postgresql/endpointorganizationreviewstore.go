@@ -0,0 +1,172 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/pkg/errors"
+)
+
+// prepared statements are left open to be used throughout the execution of the application
+var savePendingEndpointOrganizationReviewStatement *sql.Stmt
+var decideEndpointOrganizationReviewStatement *sql.Stmt
+
+// GetEndpointOrganizationReview gets the review of the link between the endpoint URL and the NPI organization.
+// If the review does not exist in the database, sql.ErrNoRows will be returned.
+func (s *Store) GetEndpointOrganizationReview(ctx context.Context, url string, orgID string) (*endpointmanager.EndpointOrganizationReview, error) {
+	sqlStatement := `
+	SELECT
+		url,
+		organization_npi_id,
+		confidence,
+		score_breakdown,
+		status,
+		decided_by,
+		decided_at,
+		created_at,
+		updated_at
+	FROM endpoint_organization_reviews WHERE url=$1 AND organization_npi_id=$2`
+	row := s.querier().QueryRowContext(ctx, sqlStatement, url, orgID)
+
+	return scanEndpointOrganizationReview(row)
+}
+
+// GetEndpointOrganizationReviews gets the reviews with the given status. If status is empty, all reviews are returned.
+// The reviews are ordered by confidence, lowest first, so that the least likely links are reviewed first.
+func (s *Store) GetEndpointOrganizationReviews(ctx context.Context, status string) ([]*endpointmanager.EndpointOrganizationReview, error) {
+	sqlStatement := `
+	SELECT
+		url,
+		organization_npi_id,
+		confidence,
+		score_breakdown,
+		status,
+		decided_by,
+		decided_at,
+		created_at,
+		updated_at
+	FROM endpoint_organization_reviews WHERE $1 = '' OR status=$1
+	ORDER BY confidence, url, organization_npi_id`
+	rows, err := s.querier().QueryContext(ctx, sqlStatement, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []*endpointmanager.EndpointOrganizationReview
+	for rows.Next() {
+		review, err := scanEndpointOrganizationReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+// SavePendingEndpointOrganizationReview adds the review to the database as pending. If a pending review already
+// exists for the same endpoint URL and NPI organization, its confidence and score breakdown are updated. Reviews
+// that have already been accepted or rejected are left unchanged.
+func (s *Store) SavePendingEndpointOrganizationReview(ctx context.Context, r *endpointmanager.EndpointOrganizationReview) error {
+	scoreBreakdownJSON, err := json.Marshal(r.ScoreBreakdown)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.stmt(ctx, savePendingEndpointOrganizationReviewStatement).ExecContext(ctx,
+		r.URL,
+		r.OrganizationNPIID,
+		r.Confidence,
+		scoreBreakdownJSON)
+	return err
+}
+
+// DecideEndpointOrganizationReview records that the reviewer accepted or rejected the link between the endpoint URL and
+// the NPI organization. The review is created if the link was never proposed by the endpoint linker.
+func (s *Store) DecideEndpointOrganizationReview(ctx context.Context, url string, orgID string, status string, decidedBy string) error {
+	if status != endpointmanager.ReviewAccepted && status != endpointmanager.ReviewRejected {
+		return errors.Errorf("review status must be %s or %s, got %s", endpointmanager.ReviewAccepted, endpointmanager.ReviewRejected, status)
+	}
+	_, err := s.stmt(ctx, decideEndpointOrganizationReviewStatement).ExecContext(ctx,
+		url,
+		orgID,
+		status,
+		decidedBy)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanEndpointOrganizationReview(row rowScanner) (*endpointmanager.EndpointOrganizationReview, error) {
+	var review endpointmanager.EndpointOrganizationReview
+	var confidence sql.NullFloat64
+	var scoreBreakdownJSON []byte
+	var decidedBy sql.NullString
+	var decidedAt sql.NullTime
+
+	err := row.Scan(
+		&review.URL,
+		&review.OrganizationNPIID,
+		&confidence,
+		&scoreBreakdownJSON,
+		&review.Status,
+		&decidedBy,
+		&decidedAt,
+		&review.CreatedAt,
+		&review.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	review.Confidence = confidence.Float64
+	review.DecidedBy = decidedBy.String
+	review.DecidedAt = decidedAt.Time
+
+	if scoreBreakdownJSON != nil {
+		err = json.Unmarshal(scoreBreakdownJSON, &review.ScoreBreakdown)
+		if err != nil {
+			return nil, errors.Wrap(err, "error unmarshalling JSON score breakdown")
+		}
+	}
+
+	return &review, nil
+}
+
+func prepareEndpointOrganizationReviewStatements(s *Store) error {
+	var err error
+	savePendingEndpointOrganizationReviewStatement, err = s.DB.Prepare(`
+		INSERT INTO endpoint_organization_reviews (
+			url,
+			organization_npi_id,
+			confidence,
+			score_breakdown,
+			status)
+		VALUES ($1, $2, $3, $4, 'pending')
+		ON CONFLICT (url, organization_npi_id) DO UPDATE
+		SET confidence = EXCLUDED.confidence,
+			score_breakdown = EXCLUDED.score_breakdown
+		WHERE endpoint_organization_reviews.status = 'pending'`)
+	if err != nil {
+		return err
+	}
+	decideEndpointOrganizationReviewStatement, err = s.DB.Prepare(`
+		INSERT INTO endpoint_organization_reviews (
+			url,
+			organization_npi_id,
+			status,
+			decided_by,
+			decided_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (url, organization_npi_id) DO UPDATE
+		SET status = EXCLUDED.status,
+			decided_by = EXCLUDED.decided_by,
+			decided_at = EXCLUDED.decided_at`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
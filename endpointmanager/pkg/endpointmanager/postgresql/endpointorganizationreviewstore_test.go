@@ -0,0 +1,112 @@
+// +build integration
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+)
+
+func Test_PersistEndpointOrganizationReview(t *testing.T) {
+	SetupStore()
+	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
+	defer teardown(t, store.DB)
+
+	var err error
+	ctx := context.Background()
+
+	var review1 = &endpointmanager.EndpointOrganizationReview{
+		URL:               "https://example.com/FHIR/DSTU2/",
+		OrganizationNPIID: "1",
+		Confidence:        .86,
+		ScoreBreakdown: endpointmanager.LinkScoreBreakdown{
+			NameScore:        .875,
+			EndpointName:     "MEMORIAL HOSPITAL",
+			OrganizationName: "MEMORIAL HOSPITAL INC",
+			SharedTokens:     []string{"MEMORIAL", "HOSPITAL"},
+			LocationScores:   map[string]float64{"state": 1},
+			CombinedScore:    .9,
+		},
+		Status: endpointmanager.ReviewPending,
+	}
+	var review2 = &endpointmanager.EndpointOrganizationReview{
+		URL:               "https://example.com/FHIR/DSTU2/",
+		OrganizationNPIID: "2",
+		Confidence:        .85,
+		Status:            endpointmanager.ReviewPending,
+	}
+
+	// save pending reviews
+
+	err = store.SavePendingEndpointOrganizationReview(ctx, review1)
+	th.Assert(t, err == nil, err)
+	err = store.SavePendingEndpointOrganizationReview(ctx, review2)
+	th.Assert(t, err == nil, err)
+
+	r1, err := store.GetEndpointOrganizationReview(ctx, review1.URL, review1.OrganizationNPIID)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, r1.Equal(review1), "retrieved review is not equal to saved review")
+
+	_, err = store.GetEndpointOrganizationReview(ctx, review1.URL, "3")
+	th.Assert(t, err == sql.ErrNoRows, "expected no rows for a review that does not exist")
+
+	// pending reviews are ordered by confidence
+
+	reviews, err := store.GetEndpointOrganizationReviews(ctx, endpointmanager.ReviewPending)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(reviews) == 2, "expected 2 pending reviews")
+	th.Assert(t, reviews[0].Equal(review2), "expected the lowest confidence review first")
+
+	// saving a pending review again updates its score
+
+	review1.Confidence = .87
+	err = store.SavePendingEndpointOrganizationReview(ctx, review1)
+	th.Assert(t, err == nil, err)
+	r1, err = store.GetEndpointOrganizationReview(ctx, review1.URL, review1.OrganizationNPIID)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, r1.Confidence == .87, "expected the pending review's confidence to be updated")
+
+	// decide a review
+
+	err = store.DecideEndpointOrganizationReview(ctx, review1.URL, review1.OrganizationNPIID, endpointmanager.ReviewAccepted, "reviewer")
+	th.Assert(t, err == nil, err)
+	r1, err = store.GetEndpointOrganizationReview(ctx, review1.URL, review1.OrganizationNPIID)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, r1.Status == endpointmanager.ReviewAccepted, "expected the review to be accepted")
+	th.Assert(t, r1.DecidedBy == "reviewer", "expected the reviewer to be saved")
+	th.Assert(t, !r1.DecidedAt.IsZero(), "expected the decision time to be saved")
+
+	// decided reviews are not changed by later linker runs
+
+	review1.Confidence = .5
+	err = store.SavePendingEndpointOrganizationReview(ctx, review1)
+	th.Assert(t, err == nil, err)
+	r1, err = store.GetEndpointOrganizationReview(ctx, review1.URL, review1.OrganizationNPIID)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, r1.Status == endpointmanager.ReviewAccepted, "expected the review to still be accepted")
+	th.Assert(t, r1.Confidence == .87, "expected the accepted review's confidence not to change")
+
+	reviews, err = store.GetEndpointOrganizationReviews(ctx, endpointmanager.ReviewPending)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(reviews) == 1, "expected 1 pending review")
+	reviews, err = store.GetEndpointOrganizationReviews(ctx, "")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(reviews) == 2, "expected 2 reviews")
+
+	// links that were never proposed can be decided
+
+	err = store.DecideEndpointOrganizationReview(ctx, "https://other.example.com/", "4", endpointmanager.ReviewRejected, "reviewer")
+	th.Assert(t, err == nil, err)
+	r, err := store.GetEndpointOrganizationReview(ctx, "https://other.example.com/", "4")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, r.Status == endpointmanager.ReviewRejected, "expected the review to be rejected")
+
+	// invalid decisions
+
+	err = store.DecideEndpointOrganizationReview(ctx, review2.URL, review2.OrganizationNPIID, endpointmanager.ReviewPending, "reviewer")
+	th.Assert(t, err != nil, "expected an error deciding a review as pending")
+}
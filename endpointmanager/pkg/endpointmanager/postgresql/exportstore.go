@@ -0,0 +1,49 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// GetEndpointExport gets the distinct url, endpoint names, info creation time, list source and vendor name rows of
+// the endpoint_export view.
+func (s *Store) GetEndpointExport(ctx context.Context) (*sql.Rows, error) {
+	sqlStatement := "SELECT DISTINCT url, endpoint_names, info_created, list_source, vendor_name FROM endpoint_export;"
+	return s.querier().QueryContext(ctx, sqlStatement)
+}
+
+// GetInfoHistoryWithMetadata gets the info history entries of the URL joined with the metadata recorded for each of
+// them. Each row has the url, http response, response time, errors, capability statement, TLS version, MIME types,
+// operation resource, SMART http response, SMART response, update time and capability statement FHIR version.
+func (s *Store) GetInfoHistoryWithMetadata(ctx context.Context, url string) (*sql.Rows, error) {
+	sqlStatement := `
+		SELECT history.url, fhir_endpoints_metadata.http_response, fhir_endpoints_metadata.response_time_seconds, fhir_endpoints_metadata.errors,
+		capability_statement, tls_version, mime_types, operation_resource,
+		fhir_endpoints_metadata.smart_http_response, smart_response, history.updated_at, capability_fhir_version
+		FROM fhir_endpoints_info_history_with_documents AS history, fhir_endpoints_metadata
+		WHERE history.metadata_id = fhir_endpoints_metadata.id AND history.url=$1;`
+	return s.querier().QueryContext(ctx, sqlStatement, url)
+}
+
+// GetInfoHistoryWithMetadataAsOf gets the same rows as GetInfoHistoryWithMetadata, limited to the info history
+// entries that were entered at or before the given time.
+func (s *Store) GetInfoHistoryWithMetadataAsOf(ctx context.Context, url string, asOf time.Time) (*sql.Rows, error) {
+	sqlStatement := `
+		SELECT history.url, fhir_endpoints_metadata.http_response, fhir_endpoints_metadata.response_time_seconds, fhir_endpoints_metadata.errors,
+		capability_statement, tls_version, mime_types, operation_resource,
+		fhir_endpoints_metadata.smart_http_response, smart_response, history.updated_at, capability_fhir_version
+		FROM fhir_endpoints_info_history_with_documents AS history, fhir_endpoints_metadata
+		WHERE history.metadata_id = fhir_endpoints_metadata.id AND history.url=$1 AND history.entered_at <= $2;`
+	return s.querier().QueryContext(ctx, sqlStatement, url, asOf)
+}
+
+// ExportEndpointsCSV copies the entire contents of the endpoint_export view into a CSV file with a header at the
+// given path. The file is written by the database server, so the path is on the database's host.
+func (s *Store) ExportEndpointsCSV(ctx context.Context, path string) error {
+	sqlStatement := "COPY (SELECT * FROM endpoint_export) TO " + pq.QuoteLiteral(path) + " DELIMITER ',' CSV HEADER;"
+	_, err := s.querier().ExecContext(ctx, sqlStatement)
+	return err
+}
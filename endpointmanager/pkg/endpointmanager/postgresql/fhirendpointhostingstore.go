@@ -0,0 +1,134 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+)
+
+// prepared statements are left open to be used throughout the execution of the application
+var addFHIREndpointHostingStatement *sql.Stmt
+var updateFHIREndpointHostingStatement *sql.Stmt
+var deleteFHIREndpointHostingStatement *sql.Stmt
+
+// GetFHIREndpointHostingUsingURL gets the FHIREndpointHosting from the database using the endpoint's URL as a key.
+// If the FHIREndpointHosting does not exist in the database, sql.ErrNoRows will be returned.
+func (s *Store) GetFHIREndpointHostingUsingURL(ctx context.Context, url string) (*endpointmanager.FHIREndpointHosting, error) {
+	var hosting endpointmanager.FHIREndpointHosting
+	var ipAddressesJSON []byte
+
+	sqlStatement := `
+	SELECT
+		id,
+		url,
+		hostname,
+		ip_addresses,
+		errors,
+		created_at,
+		updated_at
+	FROM fhir_endpoints_hosting WHERE url=$1`
+	row := s.querier().QueryRowContext(ctx, sqlStatement, url)
+
+	err := row.Scan(
+		&hosting.ID,
+		&hosting.URL,
+		&hosting.Hostname,
+		&ipAddressesJSON,
+		&hosting.Errors,
+		&hosting.CreatedAt,
+		&hosting.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if ipAddressesJSON != nil {
+		err = json.Unmarshal(ipAddressesJSON, &hosting.IPAddresses)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &hosting, err
+}
+
+// AddFHIREndpointHosting adds the FHIREndpointHosting to the database.
+func (s *Store) AddFHIREndpointHosting(ctx context.Context, h *endpointmanager.FHIREndpointHosting) error {
+	var err error
+
+	ipAddressesJSON, err := json.Marshal(h.IPAddresses)
+	if err != nil {
+		return err
+	}
+
+	row := s.stmt(ctx, addFHIREndpointHostingStatement).QueryRowContext(ctx,
+		h.URL,
+		h.Hostname,
+		ipAddressesJSON,
+		h.Errors)
+
+	err = row.Scan(&h.ID)
+
+	return err
+}
+
+// UpdateFHIREndpointHosting updates the FHIREndpointHosting in the database using the FHIREndpointHosting's database id as the key.
+// Each update is recorded in the fhir_endpoints_hosting_history table.
+func (s *Store) UpdateFHIREndpointHosting(ctx context.Context, h *endpointmanager.FHIREndpointHosting) error {
+	var err error
+
+	ipAddressesJSON, err := json.Marshal(h.IPAddresses)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.stmt(ctx, updateFHIREndpointHostingStatement).ExecContext(ctx,
+		h.URL,
+		h.Hostname,
+		ipAddressesJSON,
+		h.Errors,
+		h.ID)
+
+	return err
+}
+
+// DeleteFHIREndpointHosting deletes the FHIREndpointHosting from the database using the FHIREndpointHosting's database id as the key.
+func (s *Store) DeleteFHIREndpointHosting(ctx context.Context, h *endpointmanager.FHIREndpointHosting) error {
+	_, err := s.stmt(ctx, deleteFHIREndpointHostingStatement).ExecContext(ctx, h.ID)
+
+	return err
+}
+
+func prepareFHIREndpointHostingStatements(s *Store) error {
+	var err error
+	addFHIREndpointHostingStatement, err = s.DB.Prepare(`
+		INSERT INTO fhir_endpoints_hosting (
+			url,
+			hostname,
+			ip_addresses,
+			errors)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`)
+	if err != nil {
+		return err
+	}
+	updateFHIREndpointHostingStatement, err = s.DB.Prepare(`
+		UPDATE fhir_endpoints_hosting
+		SET
+			url = $1,
+			hostname = $2,
+			ip_addresses = $3,
+			errors = $4
+		WHERE id = $5`)
+	if err != nil {
+		return err
+	}
+	deleteFHIREndpointHostingStatement, err = s.DB.Prepare(`
+		DELETE FROM fhir_endpoints_hosting
+		WHERE id = $1`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// +build integration
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+)
+
+func Test_PersistFHIREndpointHosting(t *testing.T) {
+	SetupStore()
+	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
+	defer teardown(t, store.DB)
+
+	var err error
+	ctx := context.Background()
+
+	var hosting1 = &endpointmanager.FHIREndpointHosting{
+		URL:      "https://example.com/FHIR/DSTU2/",
+		Hostname: "example.com",
+		IPAddresses: []endpointmanager.IPAddressInfo{
+			{
+				IP:             "93.184.216.34",
+				Family:         "IPv4",
+				ReverseDNS:     []string{"example.com."},
+				ASN:            15133,
+				ASOrganization: "EDGECAST",
+				CountryCode:    "US",
+			},
+		},
+	}
+	var hosting2 = &endpointmanager.FHIREndpointHosting{
+		URL:      "https://unresolvable.example.com/FHIR/DSTU2/",
+		Hostname: "unresolvable.example.com",
+		Errors:   "lookup unresolvable.example.com: no such host",
+	}
+
+	// add hosting information
+
+	err = store.AddFHIREndpointHosting(ctx, hosting1)
+	if err != nil {
+		t.Errorf("Error adding fhir endpoint hosting: %s", err.Error())
+	}
+
+	err = store.AddFHIREndpointHosting(ctx, hosting2)
+	if err != nil {
+		t.Errorf("Error adding fhir endpoint hosting: %s", err.Error())
+	}
+
+	// retrieve hosting information
+
+	h1, err := store.GetFHIREndpointHostingUsingURL(ctx, hosting1.URL)
+	if err != nil {
+		t.Errorf("Error getting fhir endpoint hosting: %s", err.Error())
+	}
+	if !h1.Equal(hosting1) {
+		t.Errorf("retrieved hosting information is not equal to saved hosting information.")
+	}
+
+	h2, err := store.GetFHIREndpointHostingUsingURL(ctx, hosting2.URL)
+	if err != nil {
+		t.Errorf("Error getting fhir endpoint hosting: %s", err.Error())
+	}
+	if !h2.Equal(hosting2) {
+		t.Errorf("retrieved hosting information is not equal to saved hosting information.")
+	}
+
+	// update hosting information
+
+	h1.IPAddresses = append(h1.IPAddresses, endpointmanager.IPAddressInfo{
+		IP:             "2606:2800:220:1:248:1893:25c8:1946",
+		Family:         "IPv6",
+		ASN:            15133,
+		ASOrganization: "EDGECAST",
+		CountryCode:    "US",
+	})
+	err = store.UpdateFHIREndpointHosting(ctx, h1)
+	if err != nil {
+		t.Errorf("Error updating fhir endpoint hosting: %s", err.Error())
+	}
+
+	h1Updated, err := store.GetFHIREndpointHostingUsingURL(ctx, hosting1.URL)
+	if err != nil {
+		t.Errorf("Error getting fhir endpoint hosting: %s", err.Error())
+	}
+	if !h1Updated.Equal(h1) {
+		t.Errorf("retrieved updated hosting information is not equal to saved hosting information.")
+	}
+	if h1Updated.UpdatedAt.Equal(h1Updated.CreatedAt) {
+		t.Errorf("UpdatedAt is not being properly set on update.")
+	}
+
+	// check history
+
+	var historyCount int
+	err = store.DB.QueryRow("SELECT COUNT(*) FROM fhir_endpoints_hosting_history WHERE url=$1", hosting1.URL).Scan(&historyCount)
+	if err != nil {
+		t.Errorf("Error counting hosting history: %s", err.Error())
+	}
+	if historyCount != 2 {
+		t.Errorf("Expected 2 history entries for an insert and an update, got %d", historyCount)
+	}
+
+	// delete hosting information
+
+	err = store.DeleteFHIREndpointHosting(ctx, h1)
+	if err != nil {
+		t.Errorf("Error deleting fhir endpoint hosting: %s", err.Error())
+	}
+
+	_, err = store.GetFHIREndpointHostingUsingURL(ctx, hosting1.URL)
+	if err != sql.ErrNoRows {
+		t.Errorf("Expected deleted hosting information to not be found. Got error: %v", err)
+	}
+
+	_, err = store.GetFHIREndpointHostingUsingURL(ctx, hosting2.URL)
+	if err != nil {
+		t.Errorf("Error getting fhir endpoint hosting: %s", err.Error())
+	}
+}
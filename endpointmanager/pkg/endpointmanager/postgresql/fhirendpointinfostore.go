@@ -29,6 +29,7 @@ func (s *Store) GetFHIREndpointInfo(ctx context.Context, id int) (*endpointmanag
 	var healthitProductIDNullable sql.NullInt64
 	var vendorIDNullable sql.NullInt64
 	var smartResponseJSON []byte
+	var ipReachabilityJSON []byte
 	var operResourceJSON []byte
 	var metadataID int
 
@@ -49,9 +50,12 @@ func (s *Store) GetFHIREndpointInfo(ctx context.Context, id int) (*endpointmanag
 		validation_result_id,
 		metadata_id,
 		requested_fhir_version,
-		capability_fhir_version
-	FROM fhir_endpoints_info WHERE id=$1`
-	row := s.DB.QueryRowContext(ctx, sqlStatementInfo, id)
+		capability_fhir_version,
+		http_version,
+		alpn_protocol,
+		ip_reachability
+	FROM fhir_endpoints_info_with_documents WHERE id=$1`
+	row := s.querier().QueryRowContext(ctx, sqlStatementInfo, id)
 
 	err := row.Scan(
 		&endpointInfo.ID,
@@ -69,7 +73,10 @@ func (s *Store) GetFHIREndpointInfo(ctx context.Context, id int) (*endpointmanag
 		&endpointInfo.ValidationID,
 		&metadataID,
 		&endpointInfo.RequestedFhirVersion,
-		&endpointInfo.CapabilityFhirVersion)
+		&endpointInfo.CapabilityFhirVersion,
+		&endpointInfo.HTTPVersion,
+		&endpointInfo.ALPNProtocol,
+		&ipReachabilityJSON)
 	if err != nil {
 		return nil, err
 	}
@@ -105,6 +112,13 @@ func (s *Store) GetFHIREndpointInfo(ctx context.Context, id int) (*endpointmanag
 		}
 	}
 
+	if ipReachabilityJSON != nil {
+		err = json.Unmarshal(ipReachabilityJSON, &endpointInfo.IPReachability)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	endpointMetadata, err := s.GetFHIREndpointMetadata(ctx, metadataID)
 	if err != nil {
 		return nil, err
@@ -135,10 +149,13 @@ func (s *Store) GetFHIREndpointInfosUsingURL(ctx context.Context, url string) ([
 		operation_resource,
 		metadata_id,
 		requested_fhir_version,
-		capability_fhir_version
-	FROM fhir_endpoints_info WHERE fhir_endpoints_info.url = $1`
+		capability_fhir_version,
+		http_version,
+		alpn_protocol,
+		ip_reachability
+	FROM fhir_endpoints_info_with_documents WHERE url = $1`
 
-	rows, err := s.DB.QueryContext(ctx, sqlStatementInfo, url)
+	rows, err := s.querier().QueryContext(ctx, sqlStatementInfo, url)
 	if err != nil {
 		return nil, err
 	}
@@ -150,6 +167,7 @@ func (s *Store) GetFHIREndpointInfosUsingURL(ctx context.Context, url string) ([
 		var healthitProductIDNullable sql.NullInt64
 		var vendorIDNullable sql.NullInt64
 		var smartResponseJSON []byte
+		var ipReachabilityJSON []byte
 		var metadataID int
 
 		err := rows.Scan(
@@ -168,7 +186,10 @@ func (s *Store) GetFHIREndpointInfosUsingURL(ctx context.Context, url string) ([
 			&operResourceJSON,
 			&metadataID,
 			&endpointInfo.RequestedFhirVersion,
-			&endpointInfo.CapabilityFhirVersion)
+			&endpointInfo.CapabilityFhirVersion,
+			&endpointInfo.HTTPVersion,
+			&endpointInfo.ALPNProtocol,
+			&ipReachabilityJSON)
 		if err != nil {
 			return nil, err
 		}
@@ -198,6 +219,13 @@ func (s *Store) GetFHIREndpointInfosUsingURL(ctx context.Context, url string) ([
 			}
 		}
 
+		if ipReachabilityJSON != nil {
+			err = json.Unmarshal(ipReachabilityJSON, &endpointInfo.IPReachability)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		endpointMetadata, err := s.GetFHIREndpointMetadata(ctx, metadataID)
 		if err != nil {
 			return nil, err
@@ -219,6 +247,7 @@ func (s *Store) GetFHIREndpointInfoUsingURLAndRequestedVersion(ctx context.Conte
 	var healthitProductIDNullable sql.NullInt64
 	var vendorIDNullable sql.NullInt64
 	var smartResponseJSON []byte
+	var ipReachabilityJSON []byte
 	var operResourceJSON []byte
 	var metadataID int
 
@@ -239,10 +268,13 @@ func (s *Store) GetFHIREndpointInfoUsingURLAndRequestedVersion(ctx context.Conte
 		validation_result_id,
 		metadata_id,
 		requested_fhir_version,
-		capability_fhir_version
-	FROM fhir_endpoints_info WHERE fhir_endpoints_info.url = $1 AND fhir_endpoints_info.requested_fhir_version = $2`
+		capability_fhir_version,
+		http_version,
+		alpn_protocol,
+		ip_reachability
+	FROM fhir_endpoints_info_with_documents WHERE url = $1 AND requested_fhir_version = $2`
 
-	row := s.DB.QueryRowContext(ctx, sqlStatementInfo, url, requestedVersion)
+	row := s.querier().QueryRowContext(ctx, sqlStatementInfo, url, requestedVersion)
 
 	err := row.Scan(
 		&endpointInfo.ID,
@@ -260,7 +292,10 @@ func (s *Store) GetFHIREndpointInfoUsingURLAndRequestedVersion(ctx context.Conte
 		&endpointInfo.ValidationID,
 		&metadataID,
 		&endpointInfo.RequestedFhirVersion,
-		&endpointInfo.CapabilityFhirVersion)
+		&endpointInfo.CapabilityFhirVersion,
+		&endpointInfo.HTTPVersion,
+		&endpointInfo.ALPNProtocol,
+		&ipReachabilityJSON)
 	if err != nil {
 		return nil, err
 	}
@@ -297,6 +332,13 @@ func (s *Store) GetFHIREndpointInfoUsingURLAndRequestedVersion(ctx context.Conte
 		}
 	}
 
+	if ipReachabilityJSON != nil {
+		err = json.Unmarshal(ipReachabilityJSON, &endpointInfo.IPReachability)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	endpointMetadata, err := s.GetFHIREndpointMetadata(ctx, metadataID)
 	if err != nil {
 		return nil, err
@@ -352,9 +394,14 @@ func (s *Store) AddFHIREndpointInfo(ctx context.Context, e *endpointmanager.FHIR
 		smartResponseJSON = []byte("null")
 	}
 
+	ipReachabilityJSON, err := json.Marshal(e.IPReachability)
+	if err != nil {
+		return err
+	}
+
 	nullableInts := getNullableInts([]int{e.HealthITProductID, e.VendorID})
 
-	row := addFHIREndpointInfoStatement.QueryRowContext(ctx,
+	row := s.stmt(ctx, addFHIREndpointInfoStatement).QueryRowContext(ctx,
 		e.URL,
 		nullableInts[0],
 		nullableInts[1],
@@ -367,7 +414,10 @@ func (s *Store) AddFHIREndpointInfo(ctx context.Context, e *endpointmanager.FHIR
 		e.ValidationID,
 		metadataID,
 		e.RequestedFhirVersion,
-		e.CapabilityFhirVersion)
+		e.CapabilityFhirVersion,
+		e.HTTPVersion,
+		e.ALPNProtocol,
+		ipReachabilityJSON)
 
 	err = row.Scan(&e.ID)
 
@@ -408,9 +458,14 @@ func (s *Store) UpdateFHIREndpointInfo(ctx context.Context, e *endpointmanager.F
 		smartResponseJSON = []byte("null")
 	}
 
+	ipReachabilityJSON, err := json.Marshal(e.IPReachability)
+	if err != nil {
+		return err
+	}
+
 	nullableInts := getNullableInts([]int{e.HealthITProductID, e.VendorID})
 
-	_, err = updateFHIREndpointInfoStatement.ExecContext(ctx,
+	_, err = s.stmt(ctx, updateFHIREndpointInfoStatement).ExecContext(ctx,
 		e.URL,
 		nullableInts[0],
 		nullableInts[1],
@@ -424,6 +479,9 @@ func (s *Store) UpdateFHIREndpointInfo(ctx context.Context, e *endpointmanager.F
 		metadataID,
 		e.RequestedFhirVersion,
 		e.CapabilityFhirVersion,
+		e.HTTPVersion,
+		e.ALPNProtocol,
+		ipReachabilityJSON,
 		e.ID)
 
 	return err
@@ -431,15 +489,15 @@ func (s *Store) UpdateFHIREndpointInfo(ctx context.Context, e *endpointmanager.F
 
 // UpdateMetadataIDInfo only updates the metadata_id in the info table without affecting the info history table
 func (s *Store) UpdateMetadataIDInfo(ctx context.Context, metadataID int, id int) error {
-	_, err := s.DB.ExecContext(ctx, "SELECT set_config('metadata.setting', 'TRUE', 'FALSE');")
+	_, err := s.querier().ExecContext(ctx, "SELECT set_config('metadata.setting', 'TRUE', 'FALSE');")
 	if err != nil {
 		return err
 	}
-	_, err = updateFHIREndpointInfoMetadataStatement.ExecContext(ctx, metadataID, id)
+	_, err = s.stmt(ctx, updateFHIREndpointInfoMetadataStatement).ExecContext(ctx, metadataID, id)
 	if err != nil {
 		return err
 	}
-	_, err = s.DB.ExecContext(ctx, "SELECT set_config('metadata.setting', 'FALSE', 'FALSE');")
+	_, err = s.querier().ExecContext(ctx, "SELECT set_config('metadata.setting', 'FALSE', 'FALSE');")
 	if err != nil {
 		return err
 	}
@@ -449,7 +507,7 @@ func (s *Store) UpdateMetadataIDInfo(ctx context.Context, metadataID int, id int
 
 // DeleteFHIREndpointInfo deletes the FHIREndpointInfo from the database using the FHIREndpointInfo's database id  as the key.
 func (s *Store) DeleteFHIREndpointInfo(ctx context.Context, e *endpointmanager.FHIREndpointInfo) error {
-	_, err := deleteFHIREndpointInfoStatement.ExecContext(ctx, e.ID)
+	_, err := s.stmt(ctx, deleteFHIREndpointInfoStatement).ExecContext(ctx, e.ID)
 	return err
 }
 
@@ -461,7 +519,7 @@ func (s *Store) GetFHIREndpointInfosByURLWithDifferentRequestedVersion(ctx conte
 	// Convert array of strings to a string that postgres can convert back to an sql ARRAY
 	versionsString := strings.Join(versions, ",")
 
-	rows, err := getFHIREndpointsByURLAndDifferentRequestedVersion.QueryContext(ctx, url, versionsString)
+	rows, err := s.stmt(ctx, getFHIREndpointsByURLAndDifferentRequestedVersion).QueryContext(ctx, url, versionsString)
 	if err != nil {
 		return nil, err
 	}
@@ -473,6 +531,7 @@ func (s *Store) GetFHIREndpointInfosByURLWithDifferentRequestedVersion(ctx conte
 		var healthitProductIDNullable sql.NullInt64
 		var vendorIDNullable sql.NullInt64
 		var smartResponseJSON []byte
+		var ipReachabilityJSON []byte
 		var metadataID int
 
 		err := rows.Scan(
@@ -491,7 +550,10 @@ func (s *Store) GetFHIREndpointInfosByURLWithDifferentRequestedVersion(ctx conte
 			&operResourceJSON,
 			&metadataID,
 			&endpointInfo.RequestedFhirVersion,
-			&endpointInfo.CapabilityFhirVersion)
+			&endpointInfo.CapabilityFhirVersion,
+			&endpointInfo.HTTPVersion,
+			&endpointInfo.ALPNProtocol,
+			&ipReachabilityJSON)
 		if err != nil {
 			return nil, err
 		}
@@ -521,6 +583,13 @@ func (s *Store) GetFHIREndpointInfosByURLWithDifferentRequestedVersion(ctx conte
 			}
 		}
 
+		if ipReachabilityJSON != nil {
+			err = json.Unmarshal(ipReachabilityJSON, &endpointInfo.IPReachability)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		endpointMetadata, err := s.GetFHIREndpointMetadata(ctx, metadataID)
 		if err != nil {
 			return nil, err
@@ -534,6 +603,37 @@ func (s *Store) GetFHIREndpointInfosByURLWithDifferentRequestedVersion(ctx conte
 	return endpointInfos, err
 }
 
+// GetURLsWithSameCapabilityStatement gets the URLs of the other endpoints that serve the same capability statement
+// as the endpoint with the given URL when no FHIR version is requested. Capability statements are stored once per
+// canonical JSON hash, so endpoints serve the same statement when their info rows reference the same hash.
+func (s *Store) GetURLsWithSameCapabilityStatement(ctx context.Context, url string) ([]string, error) {
+	sqlStatement := `
+	SELECT DISTINCT other.url
+	FROM fhir_endpoints_info AS info
+	JOIN fhir_endpoints_info AS other ON info.capability_statement_hash = other.capability_statement_hash
+		AND other.requested_fhir_version = 'None'
+		AND other.url != info.url
+	WHERE info.url = $1 AND info.requested_fhir_version = 'None'
+	ORDER BY other.url`
+
+	rows, err := s.querier().QueryContext(ctx, sqlStatement, url)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []string
+	for rows.Next() {
+		var otherURL string
+		err = rows.Scan(&otherURL)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, otherURL)
+	}
+	return urls, rows.Err()
+}
+
 func prepareFHIREndpointInfoStatements(s *Store) error {
 	var err error
 	addFHIREndpointInfoStatement, err = s.DB.Prepare(`
@@ -543,15 +643,18 @@ func prepareFHIREndpointInfoStatements(s *Store) error {
 			vendor_id,
 			tls_version,
 			mime_types,
-			capability_statement,
-			smart_response,
+			capability_statement_hash,
+			smart_response_hash,
 			included_fields,
 			operation_resource,
 			validation_result_id,
 			metadata_id,
 			requested_fhir_version,
-			capability_fhir_version)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			capability_fhir_version,
+			http_version,
+			alpn_protocol,
+			ip_reachability)
+		VALUES ($1, $2, $3, $4, $5, add_fhir_endpoints_document($6), add_fhir_endpoints_document($7), $8, $9, $10, $11, $12, $13, $14, $15, $16)
 		RETURNING id`)
 	if err != nil {
 		return err
@@ -564,15 +667,18 @@ func prepareFHIREndpointInfoStatements(s *Store) error {
 			vendor_id = $3,
 			tls_version = $4,
 			mime_types = $5,
-			capability_statement = $6,
-			smart_response = $7,
+			capability_statement_hash = add_fhir_endpoints_document($6),
+			smart_response_hash = add_fhir_endpoints_document($7),
 			included_fields = $8,
 			operation_resource = $9,
 			validation_result_id = $10,
 			metadata_id = $11,
 			requested_fhir_version = $12,
-			capability_fhir_version = $13		
-		WHERE id = $14`)
+			capability_fhir_version = $13,
+			http_version = $14,
+			alpn_protocol = $15,
+			ip_reachability = $16
+		WHERE id = $17`)
 	if err != nil {
 		return err
 	}
@@ -607,8 +713,11 @@ func prepareFHIREndpointInfoStatements(s *Store) error {
 		operation_resource,
 		metadata_id,
 		requested_fhir_version,
-		capability_fhir_version
-		FROM fhir_endpoints_info WHERE fhir_endpoints_info.url = $1 AND NOT (fhir_endpoints_info.requested_fhir_version = ANY (string_to_array($2,',','')))`)
+		capability_fhir_version,
+		http_version,
+		alpn_protocol,
+		ip_reachability
+		FROM fhir_endpoints_info_with_documents WHERE url = $1 AND NOT (requested_fhir_version = ANY (string_to_array($2,',','')))`)
 	if err != nil {
 		return err
 	}
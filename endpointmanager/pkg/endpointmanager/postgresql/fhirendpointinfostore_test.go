@@ -414,7 +414,7 @@ func Test_PersistFHIREndpointInfo(t *testing.T) {
 	}
 
 	// check the value
-	rows = store.DB.QueryRow("SELECT http_response, capability_statement FROM fhir_endpoints_info_history, fhir_endpoints_metadata WHERE fhir_endpoints_info_history.metadata_id = fhir_endpoints_metadata.id AND fhir_endpoints_info_history.id= $1 AND operation='I';", endpointInfo1.ID)
+	rows = store.DB.QueryRow("SELECT http_response, capability_statement FROM fhir_endpoints_info_history_with_documents, fhir_endpoints_metadata WHERE fhir_endpoints_info_history_with_documents.metadata_id = fhir_endpoints_metadata.id AND fhir_endpoints_info_history_with_documents.id= $1 AND operation='I';", endpointInfo1.ID)
 	err = rows.Scan(&response, &capStatJson)
 	if err != nil {
 		t.Errorf("get values for insertion: %s", err.Error())
@@ -439,7 +439,7 @@ func Test_PersistFHIREndpointInfo(t *testing.T) {
 	}
 
 	// get the first update and check its value
-	rows = store.DB.QueryRow("SELECT http_response, capability_statement FROM fhir_endpoints_info_history, fhir_endpoints_metadata WHERE fhir_endpoints_info_history.metadata_id = fhir_endpoints_metadata.id AND operation='U' AND fhir_endpoints_info_history.id=$1 ORDER BY fhir_endpoints_info_history.entered_at ASC LIMIT 1;", endpointInfo1.ID)
+	rows = store.DB.QueryRow("SELECT http_response, capability_statement FROM fhir_endpoints_info_history_with_documents, fhir_endpoints_metadata WHERE fhir_endpoints_info_history_with_documents.metadata_id = fhir_endpoints_metadata.id AND operation='U' AND fhir_endpoints_info_history_with_documents.id=$1 ORDER BY fhir_endpoints_info_history_with_documents.entered_at ASC LIMIT 1;", endpointInfo1.ID)
 	err = rows.Scan(&response, &capStatJson)
 	if err != nil {
 		t.Errorf("history count for insertions: %s", err.Error())
@@ -452,7 +452,7 @@ func Test_PersistFHIREndpointInfo(t *testing.T) {
 	}
 
 	// get the second update and check its value
-	rows = store.DB.QueryRow("SELECT http_response, capability_statement FROM fhir_endpoints_info_history, fhir_endpoints_metadata WHERE fhir_endpoints_info_history.metadata_id = fhir_endpoints_metadata.id AND operation='U' AND fhir_endpoints_info_history.id=$1 ORDER BY fhir_endpoints_info_history.entered_at DESC LIMIT 1;", endpointInfo1.ID)
+	rows = store.DB.QueryRow("SELECT http_response, capability_statement FROM fhir_endpoints_info_history_with_documents, fhir_endpoints_metadata WHERE fhir_endpoints_info_history_with_documents.metadata_id = fhir_endpoints_metadata.id AND operation='U' AND fhir_endpoints_info_history_with_documents.id=$1 ORDER BY fhir_endpoints_info_history_with_documents.entered_at DESC LIMIT 1;", endpointInfo1.ID)
 	err = rows.Scan(&response, &capStatJson)
 	if err != nil {
 		t.Errorf("history count for insertions: %s", err.Error())
@@ -474,3 +474,58 @@ func Test_PersistFHIREndpointInfo(t *testing.T) {
 		t.Errorf("expected 1 deletion for endpointInfo1. Got %d.", count)
 	}
 }
+
+func Test_GetURLsWithSameCapabilityStatement(t *testing.T) {
+	SetupStore()
+	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
+	defer teardown(t, store.DB)
+
+	ctx := context.Background()
+
+	cernerJSON, err := ioutil.ReadFile(filepath.Join("../../testdata", "cerner_capability_dstu2.json"))
+	th.Assert(t, err == nil, err)
+	cernerCS, err := capabilityparser.NewCapabilityStatement(cernerJSON)
+	th.Assert(t, err == nil, err)
+	epicJSON, err := ioutil.ReadFile(filepath.Join("../../testdata", "epic_capability_dstu2.json"))
+	th.Assert(t, err == nil, err)
+	epicCS, err := capabilityparser.NewCapabilityStatement(epicJSON)
+	th.Assert(t, err == nil, err)
+
+	infos := []*endpointmanager.FHIREndpointInfo{
+		{URL: "https://first.cerner.example.com/", CapabilityStatement: cernerCS},
+		{URL: "https://second.cerner.example.com/", CapabilityStatement: cernerCS},
+		{URL: "https://third.cerner.example.com/", CapabilityStatement: cernerCS},
+		{URL: "https://epic.example.com/", CapabilityStatement: epicCS},
+		{URL: "https://unreachable.example.com/"},
+	}
+	for _, info := range infos {
+		info.RequestedFhirVersion = "None"
+		info.Metadata = &endpointmanager.FHIREndpointMetadata{URL: info.URL, HTTPResponse: 200, RequestedFhirVersion: "None"}
+		metadataID, err := store.AddFHIREndpointMetadata(ctx, info.Metadata)
+		th.Assert(t, err == nil, fmt.Sprintf("Error adding fhir endpointMetadata: %s", err))
+		info.ValidationID, err = store.AddValidationResult(ctx)
+		th.Assert(t, err == nil, fmt.Sprintf("Error adding validation result ID: %s", err))
+		err = store.AddFHIREndpointInfo(ctx, info, metadataID)
+		th.Assert(t, err == nil, fmt.Sprintf("Error adding fhir endpointInfo: %s", err))
+	}
+
+	// the same capability statement is only stored once, along with the epic statement and the null statement
+	var count int
+	err = store.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM fhir_endpoints_documents WHERE jsonb_typeof(document) = 'object';").Scan(&count)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, count == 2, fmt.Sprintf("expected 2 stored capability statements, got %d", count))
+
+	// the stored document is still returned with the endpoint info
+	info, err := store.GetFHIREndpointInfoUsingURLAndRequestedVersion(ctx, infos[1].URL, "None")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, info.CapabilityStatement.Equal(cernerCS), "expected the retrieved capability statement to equal the stored one")
+
+	urls, err := store.GetURLsWithSameCapabilityStatement(ctx, infos[0].URL)
+	th.Assert(t, err == nil, err)
+	expected := []string{infos[1].URL, infos[2].URL}
+	th.Assert(t, reflect.DeepEqual(urls, expected), fmt.Sprintf("expected %v, got %v", expected, urls))
+
+	urls, err = store.GetURLsWithSameCapabilityStatement(ctx, infos[3].URL)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(urls) == 0, fmt.Sprintf("expected no endpoints with the same statement as epic, got %v", urls))
+}
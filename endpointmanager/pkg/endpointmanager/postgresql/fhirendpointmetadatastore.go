@@ -3,6 +3,7 @@ package postgresql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
 )
@@ -14,6 +15,10 @@ var addFHIREndpointMetadataStatement *sql.Stmt
 // If the FHIREndpointMetadata does not exist in the database, sql.ErrNoRows will be returned.
 func (s *Store) GetFHIREndpointMetadata(ctx context.Context, metadataID int) (*endpointmanager.FHIREndpointMetadata, error) {
 	var endpointMetadata endpointmanager.FHIREndpointMetadata
+	var redirectsJSON []byte
+	var smartRedirectsJSON []byte
+	var ipResponseTimesJSON []byte
+	var latencyBreakdownJSON []byte
 	endpointMetadata.ID = metadataID
 
 	sqlStatementMetadata := `
@@ -22,29 +27,68 @@ func (s *Store) GetFHIREndpointMetadata(ctx context.Context, metadataID int) (*e
 		http_response,
 		availability,
 		errors,
+		error_category,
 		response_time_seconds,
 		smart_http_response,
 		requested_fhir_version,
+		redirects,
+		smart_redirects,
+		permanent_redirect,
+		canonical_url,
+		ip_response_times,
+		latency_breakdown,
 		updated_at,
 		created_at 
 	FROM fhir_endpoints_metadata WHERE id=$1;`
 
-	row := s.DB.QueryRowContext(ctx, sqlStatementMetadata, metadataID)
+	row := s.querier().QueryRowContext(ctx, sqlStatementMetadata, metadataID)
 
 	err := row.Scan(
 		&endpointMetadata.URL,
 		&endpointMetadata.HTTPResponse,
 		&endpointMetadata.Availability,
 		&endpointMetadata.Errors,
+		&endpointMetadata.ErrorCategory,
 		&endpointMetadata.ResponseTime,
 		&endpointMetadata.SMARTHTTPResponse,
 		&endpointMetadata.RequestedFhirVersion,
+		&redirectsJSON,
+		&smartRedirectsJSON,
+		&endpointMetadata.PermanentRedirect,
+		&endpointMetadata.CanonicalURL,
+		&ipResponseTimesJSON,
+		&latencyBreakdownJSON,
 		&endpointMetadata.UpdatedAt,
 		&endpointMetadata.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
 
+	if redirectsJSON != nil {
+		err = json.Unmarshal(redirectsJSON, &endpointMetadata.Redirects)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if smartRedirectsJSON != nil {
+		err = json.Unmarshal(smartRedirectsJSON, &endpointMetadata.SMARTRedirects)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if ipResponseTimesJSON != nil {
+		err = json.Unmarshal(ipResponseTimesJSON, &endpointMetadata.IPResponseTimes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if latencyBreakdownJSON != nil {
+		err = json.Unmarshal(latencyBreakdownJSON, &endpointMetadata.LatencyBreakdown)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &endpointMetadata, err
 }
 
@@ -53,20 +97,73 @@ func (s *Store) AddFHIREndpointMetadata(ctx context.Context, e *endpointmanager.
 	var err error
 	var metadataID int
 
-	row := addFHIREndpointMetadataStatement.QueryRowContext(ctx,
+	redirectsJSON, err := json.Marshal(e.Redirects)
+	if err != nil {
+		return 0, err
+	}
+	smartRedirectsJSON, err := json.Marshal(e.SMARTRedirects)
+	if err != nil {
+		return 0, err
+	}
+	ipResponseTimesJSON, err := json.Marshal(e.IPResponseTimes)
+	if err != nil {
+		return 0, err
+	}
+	latencyBreakdownJSON, err := json.Marshal(e.LatencyBreakdown)
+	if err != nil {
+		return 0, err
+	}
+
+	row := s.stmt(ctx, addFHIREndpointMetadataStatement).QueryRowContext(ctx,
 		e.URL,
 		e.HTTPResponse,
 		e.Availability,
 		e.Errors,
+		e.ErrorCategory,
 		e.ResponseTime,
 		e.SMARTHTTPResponse,
-		e.RequestedFhirVersion)
+		e.RequestedFhirVersion,
+		redirectsJSON,
+		smartRedirectsJSON,
+		e.PermanentRedirect,
+		e.CanonicalURL,
+		ipResponseTimesJSON,
+		latencyBreakdownJSON)
 
 	err = row.Scan(&metadataID)
 
 	return metadataID, err
 }
 
+// GetCanonicalURLProposals gets the canonical URLs proposed by the most recent metadata request for each endpoint
+// that was permanently redirected. The returned map is keyed by the URL that the endpoint is currently saved under.
+func (s *Store) GetCanonicalURLProposals(ctx context.Context) (map[string]string, error) {
+	proposals := make(map[string]string)
+
+	sqlStatement := `
+	SELECT DISTINCT info.url, metadata.canonical_url
+	FROM fhir_endpoints_info AS info, fhir_endpoints_metadata AS metadata
+	WHERE info.metadata_id = metadata.id AND metadata.canonical_url != '' AND info.requested_fhir_version = 'None';`
+
+	rows, err := s.querier().QueryContext(ctx, sqlStatement)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var url string
+		var canonicalURL string
+		err = rows.Scan(&url, &canonicalURL)
+		if err != nil {
+			return nil, err
+		}
+		proposals[url] = canonicalURL
+	}
+
+	return proposals, rows.Err()
+}
+
 func prepareFHIREndpointMetadataStatements(s *Store) error {
 	var err error
 	addFHIREndpointMetadataStatement, err = s.DB.Prepare(`
@@ -75,10 +172,17 @@ func prepareFHIREndpointMetadataStatements(s *Store) error {
 			http_response,
 			availability,
 			errors,
+			error_category,
 			response_time_seconds,
 			smart_http_response,
-			requested_fhir_version)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+			requested_fhir_version,
+			redirects,
+			smart_redirects,
+			permanent_redirect,
+			canonical_url,
+			ip_response_times,
+			latency_breakdown)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		RETURNING id`)
 	return err
 }
@@ -39,12 +39,17 @@ func Test_PersistFHIREndpointMetadata(t *testing.T) {
 		Errors:               "Example Error",
 		SMARTHTTPResponse:    0,
 		Availability:         1.0,
-		RequestedFhirVersion: "None"}
+		RequestedFhirVersion: "None",
+		Redirects:            []endpointmanager.Redirect{{StatusCode: 301, Location: "https://new.example.com/FHIR/DSTU2/metadata"}},
+		PermanentRedirect:    true,
+		CanonicalURL:         "https://new.example.com/FHIR/DSTU2/",
+		LatencyBreakdown:     endpointmanager.LatencyBreakdown{DNSLookup: 0.01, TCPConnect: 0.02, TLSHandshake: 0.05, TimeToFirstByte: 0.3, BodyTransfer: 0.04}}
 
 	var endpointMetadata2 = &endpointmanager.FHIREndpointMetadata{
 		URL:                  "other.example.com/FHIR/DSTU2/",
 		HTTPResponse:         404,
 		Errors:               "Example Error 2",
+		ErrorCategory:        endpointmanager.HTTPClientError,
 		SMARTHTTPResponse:    0,
 		Availability:         0,
 		RequestedFhirVersion: "None"}
@@ -158,6 +163,13 @@ func Test_PersistFHIREndpointMetadata(t *testing.T) {
 		t.Errorf("retrieved endpointMetadata is not equal to saved endpointMetadata.")
 	}
 
+	// retrieve canonical URL proposals
+
+	proposals, err := store.GetCanonicalURLProposals(ctx)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(proposals) == 1, fmt.Sprintf("expected one canonical URL proposal. Got %d.", len(proposals)))
+	th.Assert(t, proposals[endpointInfo1.URL] == endpointMetadata1.CanonicalURL, fmt.Sprintf("expected canonical URL %s for %s. Got %s.", endpointMetadata1.CanonicalURL, endpointInfo1.URL, proposals[endpointInfo1.URL]))
+
 	// update endpoint info metadata id
 
 	endpointInfo1.Metadata.HTTPResponse = 700
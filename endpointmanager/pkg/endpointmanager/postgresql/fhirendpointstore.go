@@ -4,10 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
 	"github.com/pkg/errors"
 )
 
@@ -20,6 +23,7 @@ var deleteFHIREndpointStatement *sql.Stmt
 // GetAllFHIREndpoints returns a list of all of the fhir endpoints
 func (s *Store) GetAllFHIREndpoints(ctx context.Context) ([]*endpointmanager.FHIREndpoint, error) {
 	var versionsResponseJSON []byte
+	var locationsJSON []byte
 
 	sqlStatement := `
 	SELECT
@@ -27,9 +31,10 @@ func (s *Store) GetAllFHIREndpoints(ctx context.Context) ([]*endpointmanager.FHI
 		url,
 		organization_names,
 		npi_ids,
+		locations,
 		versions_response
 	FROM fhir_endpoints`
-	rows, err := s.DB.QueryContext(ctx, sqlStatement)
+	rows, err := s.querier().QueryContext(ctx, sqlStatement)
 	if err != nil {
 		return nil, err
 	}
@@ -43,6 +48,7 @@ func (s *Store) GetAllFHIREndpoints(ctx context.Context) ([]*endpointmanager.FHI
 			&endpoint.URL,
 			pq.Array(&endpoint.OrganizationNames),
 			pq.Array(&endpoint.NPIIDs),
+			&locationsJSON,
 			&versionsResponseJSON)
 		if err != nil {
 			return nil, err
@@ -53,16 +59,30 @@ func (s *Store) GetAllFHIREndpoints(ctx context.Context) ([]*endpointmanager.FHI
 				return nil, errors.Wrap(err, "error unmarshalling JSON versions response")
 			}
 		}
+		if locationsJSON != nil {
+			err = json.Unmarshal(locationsJSON, &endpoint.Locations)
+			if err != nil {
+				return nil, errors.Wrap(err, "error unmarshalling JSON locations")
+			}
+		}
 		endpoints = append(endpoints, &endpoint)
 	}
 	return endpoints, nil
 }
 
+// GetFHIREndpointCount returns the number of fhir endpoints
+func (s *Store) GetFHIREndpointCount(ctx context.Context) (int, error) {
+	var count int
+	err := s.querier().QueryRowContext(ctx, "SELECT COUNT(*) FROM fhir_endpoints").Scan(&count)
+	return count, err
+}
+
 // GetFHIREndpoint gets a FHIREndpoint from the database using the database id as a key.
 // If the FHIREndpoint does not exist in the database, sql.ErrNoRows will be returned.
 func (s *Store) GetFHIREndpoint(ctx context.Context, id int) (*endpointmanager.FHIREndpoint, error) {
 	var endpoint endpointmanager.FHIREndpoint
 	var versionsResponseJSON []byte
+	var locationsJSON []byte
 
 	sqlStatement := `
 	SELECT
@@ -70,18 +90,20 @@ func (s *Store) GetFHIREndpoint(ctx context.Context, id int) (*endpointmanager.F
 		url,
 		organization_names,
 		npi_ids,
+		locations,
 		list_source,
 		versions_response,
 		created_at,
 		updated_at
 	FROM fhir_endpoints WHERE id=$1`
-	row := s.DB.QueryRowContext(ctx, sqlStatement, id)
+	row := s.querier().QueryRowContext(ctx, sqlStatement, id)
 
 	err := row.Scan(
 		&endpoint.ID,
 		&endpoint.URL,
 		pq.Array(&endpoint.OrganizationNames),
 		pq.Array(&endpoint.NPIIDs),
+		&locationsJSON,
 		&endpoint.ListSource,
 		&versionsResponseJSON,
 		&endpoint.CreatedAt,
@@ -96,6 +118,12 @@ func (s *Store) GetFHIREndpoint(ctx context.Context, id int) (*endpointmanager.F
 			return nil, errors.Wrap(err, "error unmarshalling JSON versions response")
 		}
 	}
+	if locationsJSON != nil {
+		err = json.Unmarshal(locationsJSON, &endpoint.Locations)
+		if err != nil {
+			return nil, errors.Wrap(err, "error unmarshalling JSON locations")
+		}
+	}
 
 	return &endpoint, err
 }
@@ -103,6 +131,7 @@ func (s *Store) GetFHIREndpoint(ctx context.Context, id int) (*endpointmanager.F
 // GetFHIREndpointUsingURL returns all FHIREndpoint from the database using the given url as a key.
 func (s *Store) GetFHIREndpointUsingURL(ctx context.Context, url string) ([]*endpointmanager.FHIREndpoint, error) {
 	var versionsResponseJSON []byte
+	var locationsJSON []byte
 
 	sqlStatement := `
 	SELECT
@@ -110,10 +139,11 @@ func (s *Store) GetFHIREndpointUsingURL(ctx context.Context, url string) ([]*end
 		url,
 		organization_names,
 		npi_ids,
+		locations,
 		list_source,
 		versions_response
 	FROM fhir_endpoints WHERE url=$1`
-	rows, err := s.DB.QueryContext(ctx, sqlStatement, url)
+	rows, err := s.querier().QueryContext(ctx, sqlStatement, url)
 	if err != nil {
 		return nil, err
 	}
@@ -127,6 +157,7 @@ func (s *Store) GetFHIREndpointUsingURL(ctx context.Context, url string) ([]*end
 			&endpoint.URL,
 			pq.Array(&endpoint.OrganizationNames),
 			pq.Array(&endpoint.NPIIDs),
+			&locationsJSON,
 			&endpoint.ListSource,
 			&versionsResponseJSON)
 		if err != nil {
@@ -138,6 +169,12 @@ func (s *Store) GetFHIREndpointUsingURL(ctx context.Context, url string) ([]*end
 				return nil, errors.Wrap(err, "error unmarshalling JSON versions response")
 			}
 		}
+		if locationsJSON != nil {
+			err = json.Unmarshal(locationsJSON, &endpoint.Locations)
+			if err != nil {
+				return nil, errors.Wrap(err, "error unmarshalling JSON locations")
+			}
+		}
 		endpoints = append(endpoints, &endpoint)
 	}
 	return endpoints, nil
@@ -148,6 +185,7 @@ func (s *Store) GetFHIREndpointUsingURL(ctx context.Context, url string) ([]*end
 func (s *Store) GetFHIREndpointUsingURLAndListSource(ctx context.Context, url string, listSource string) (*endpointmanager.FHIREndpoint, error) {
 	var endpoint endpointmanager.FHIREndpoint
 	var versionsResponseJSON []byte
+	var locationsJSON []byte
 
 	sqlStatement := `
 	SELECT
@@ -155,19 +193,21 @@ func (s *Store) GetFHIREndpointUsingURLAndListSource(ctx context.Context, url st
 		url,
 		organization_names,
 		npi_ids,
+		locations,
 		list_source,
 		versions_response,
 		created_at,
 		updated_at
 	FROM fhir_endpoints WHERE url=$1 AND list_source=$2`
 
-	row := s.DB.QueryRowContext(ctx, sqlStatement, url, listSource)
+	row := s.querier().QueryRowContext(ctx, sqlStatement, url, listSource)
 
 	err := row.Scan(
 		&endpoint.ID,
 		&endpoint.URL,
 		pq.Array(&endpoint.OrganizationNames),
 		pq.Array(&endpoint.NPIIDs),
+		&locationsJSON,
 		&endpoint.ListSource,
 		&versionsResponseJSON,
 		&endpoint.CreatedAt,
@@ -181,6 +221,12 @@ func (s *Store) GetFHIREndpointUsingURLAndListSource(ctx context.Context, url st
 			return nil, errors.Wrap(err, "error unmarshalling JSON versions response")
 		}
 	}
+	if locationsJSON != nil {
+		err = json.Unmarshal(locationsJSON, &endpoint.Locations)
+		if err != nil {
+			return nil, errors.Wrap(err, "error unmarshalling JSON locations")
+		}
+	}
 
 	return &endpoint, err
 }
@@ -189,6 +235,7 @@ func (s *Store) GetFHIREndpointUsingURLAndListSource(ctx context.Context, url st
 // listsource that update time is before the given update time.
 func (s *Store) GetFHIREndpointsUsingListSourceAndUpdateTime(ctx context.Context, updateTime time.Time, listSource string) ([]*endpointmanager.FHIREndpoint, error) {
 	var versionsResponseJSON []byte
+	var locationsJSON []byte
 
 	sqlStatement := `
 	SELECT
@@ -196,10 +243,11 @@ func (s *Store) GetFHIREndpointsUsingListSourceAndUpdateTime(ctx context.Context
 		url,
 		organization_names,
 		npi_ids,
+		locations,
 		versions_response
 	FROM fhir_endpoints WHERE list_source=$1 AND updated_at<$2`
 
-	rows, err := s.DB.QueryContext(ctx, sqlStatement, listSource, updateTime)
+	rows, err := s.querier().QueryContext(ctx, sqlStatement, listSource, updateTime)
 	if err != nil {
 		return nil, err
 	}
@@ -213,6 +261,7 @@ func (s *Store) GetFHIREndpointsUsingListSourceAndUpdateTime(ctx context.Context
 			&endpoint.URL,
 			pq.Array(&endpoint.OrganizationNames),
 			pq.Array(&endpoint.NPIIDs),
+			&locationsJSON,
 			&versionsResponseJSON)
 		if err != nil {
 			return nil, err
@@ -223,6 +272,12 @@ func (s *Store) GetFHIREndpointsUsingListSourceAndUpdateTime(ctx context.Context
 				return nil, errors.Wrap(err, "error unmarshalling JSON versions response")
 			}
 		}
+		if locationsJSON != nil {
+			err = json.Unmarshal(locationsJSON, &endpoint.Locations)
+			if err != nil {
+				return nil, errors.Wrap(err, "error unmarshalling JSON locations")
+			}
+		}
 		endpoints = append(endpoints, &endpoint)
 	}
 	return endpoints, nil
@@ -240,13 +295,16 @@ func (s *Store) AddOrUpdateFHIREndpoint(ctx context.Context, e *endpointmanager.
 		return errors.Wrap(err, "getting fhir endpoint from store failed")
 	} else {
 		// Merge new data with old data
-		// Org names NPI IDs and VersionsResponse only possible new data
+		// Org names NPI IDs Locations and VersionsResponse only possible new data
 		for _, name := range e.OrganizationNames {
 			existingEndpt.AddOrganizationName(name)
 		}
 		for _, npiID := range e.NPIIDs {
 			existingEndpt.AddNPIID(npiID)
 		}
+		for _, location := range e.Locations {
+			existingEndpt.AddLocation(location)
+		}
 		existingEndpt.VersionsResponse = e.VersionsResponse
 		err = s.UpdateFHIREndpoint(ctx, existingEndpt)
 		if err != nil {
@@ -256,15 +314,204 @@ func (s *Store) AddOrUpdateFHIREndpoint(ctx context.Context, e *endpointmanager.
 	return nil
 }
 
+// fhirEndpointBatchSize is the number of endpoints written by each statement of AddOrUpdateFHIREndpoints, which keeps
+// the statements under postgres's limit on the number of parameters.
+const fhirEndpointBatchSize = 1000
+
+// AddOrUpdateFHIREndpoints adds the endpoints that don't already exist and updates the ones that do, merging them
+// with the existing endpoints in the same way as AddOrUpdateFHIREndpoint. Endpoints in the list with the same URL and
+// list source are merged together. The endpoints' database ids are set.
+// The endpoints are written in batches, so use WithTx to store them atomically.
+func (s *Store) AddOrUpdateFHIREndpoints(ctx context.Context, endpoints []*endpointmanager.FHIREndpoint) error {
+	var listSources []string
+	for _, e := range endpoints {
+		if !helpers.StringArrayContains(listSources, e.ListSource) {
+			listSources = append(listSources, e.ListSource)
+		}
+	}
+	existingEndpts, err := s.getFHIREndpointsUsingListSources(ctx, listSources)
+	if err != nil {
+		return errors.Wrap(err, "getting fhir endpoints from store failed")
+	}
+
+	// Merge new data with old data, keeping the order the endpoints were given in
+	var merged []*endpointmanager.FHIREndpoint
+	mergedEndpts := make(map[fhirEndpointKey]*endpointmanager.FHIREndpoint)
+	for _, e := range endpoints {
+		key := fhirEndpointKey{url: e.URL, listSource: e.ListSource}
+		mergedEndpt, ok := mergedEndpts[key]
+		if !ok {
+			mergedEndpt, ok = existingEndpts[key]
+			if !ok {
+				mergedEndpt = &endpointmanager.FHIREndpoint{URL: e.URL, ListSource: e.ListSource}
+			}
+			mergedEndpts[key] = mergedEndpt
+			merged = append(merged, mergedEndpt)
+		}
+		for _, name := range e.OrganizationNames {
+			mergedEndpt.AddOrganizationName(name)
+		}
+		for _, npiID := range e.NPIIDs {
+			mergedEndpt.AddNPIID(npiID)
+		}
+		for _, location := range e.Locations {
+			mergedEndpt.AddLocation(location)
+		}
+		mergedEndpt.VersionsResponse = e.VersionsResponse
+	}
+
+	ids := make(map[fhirEndpointKey]int)
+	for start := 0; start < len(merged); start += fhirEndpointBatchSize {
+		end := start + fhirEndpointBatchSize
+		if end > len(merged) {
+			end = len(merged)
+		}
+		err = s.upsertFHIREndpoints(ctx, merged[start:end], ids)
+		if err != nil {
+			return errors.Wrap(err, "adding or updating fhir endpoints in store failed")
+		}
+	}
+
+	for _, e := range endpoints {
+		e.ID = ids[fhirEndpointKey{url: e.URL, listSource: e.ListSource}]
+	}
+	return nil
+}
+
+// fhirEndpointKey is the url and list source that uniquely identify an endpoint in fhir_endpoints.
+type fhirEndpointKey struct {
+	url        string
+	listSource string
+}
+
+// upsertFHIREndpoints writes the endpoints using a single multi-row insert and records their database ids in 'ids'.
+// The endpoints must not share a url and list source.
+func (s *Store) upsertFHIREndpoints(ctx context.Context, endpoints []*endpointmanager.FHIREndpoint, ids map[fhirEndpointKey]int) error {
+	var values []string
+	var args []interface{}
+	for _, e := range endpoints {
+		versionsResponseJSON := []byte("null")
+		if e.VersionsResponse.Response != nil {
+			var err error
+			versionsResponseJSON, err = e.VersionsResponse.GetJSON()
+			if err != nil {
+				return err
+			}
+		}
+		locationsJSON, err := json.Marshal(e.Locations)
+		if err != nil {
+			return err
+		}
+
+		n := len(args)
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6))
+		args = append(args,
+			e.URL,
+			pq.Array(e.OrganizationNames),
+			pq.Array(e.NPIIDs),
+			e.ListSource,
+			versionsResponseJSON,
+			locationsJSON)
+	}
+
+	sqlStatement := `
+		INSERT INTO fhir_endpoints (url,
+			organization_names,
+			npi_ids,
+			list_source,
+			versions_response,
+			locations)
+		VALUES ` + strings.Join(values, ", ") + `
+		ON CONFLICT (url, list_source) DO UPDATE
+		SET organization_names = EXCLUDED.organization_names,
+			npi_ids = EXCLUDED.npi_ids,
+			versions_response = EXCLUDED.versions_response,
+			locations = EXCLUDED.locations
+		RETURNING id, url, list_source`
+	rows, err := s.querier().QueryContext(ctx, sqlStatement, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var key fhirEndpointKey
+		err = rows.Scan(&id, &key.url, &key.listSource)
+		if err != nil {
+			return err
+		}
+		ids[key] = id
+	}
+	return rows.Err()
+}
+
+// getFHIREndpointsUsingListSources gets the endpoints from the given list sources keyed by their url and list source.
+func (s *Store) getFHIREndpointsUsingListSources(ctx context.Context, listSources []string) (map[fhirEndpointKey]*endpointmanager.FHIREndpoint, error) {
+	var versionsResponseJSON []byte
+	var locationsJSON []byte
+
+	sqlStatement := `
+	SELECT
+		id,
+		url,
+		organization_names,
+		npi_ids,
+		locations,
+		list_source,
+		versions_response
+	FROM fhir_endpoints WHERE list_source = ANY($1)`
+	rows, err := s.querier().QueryContext(ctx, sqlStatement, pq.Array(listSources))
+	if err != nil {
+		return nil, err
+	}
+
+	endpoints := make(map[fhirEndpointKey]*endpointmanager.FHIREndpoint)
+	defer rows.Close()
+	for rows.Next() {
+		var endpoint endpointmanager.FHIREndpoint
+		err = rows.Scan(
+			&endpoint.ID,
+			&endpoint.URL,
+			pq.Array(&endpoint.OrganizationNames),
+			pq.Array(&endpoint.NPIIDs),
+			&locationsJSON,
+			&endpoint.ListSource,
+			&versionsResponseJSON)
+		if err != nil {
+			return nil, err
+		}
+		if versionsResponseJSON != nil {
+			err = json.Unmarshal(versionsResponseJSON, &endpoint.VersionsResponse)
+			if err != nil {
+				return nil, errors.Wrap(err, "error unmarshalling JSON versions response")
+			}
+		}
+		if locationsJSON != nil {
+			err = json.Unmarshal(locationsJSON, &endpoint.Locations)
+			if err != nil {
+				return nil, errors.Wrap(err, "error unmarshalling JSON locations")
+			}
+		}
+		endpoints[fhirEndpointKey{url: endpoint.URL, listSource: endpoint.ListSource}] = &endpoint
+	}
+	return endpoints, rows.Err()
+}
+
 // AddFHIREndpoint adds the FHIREndpoint to the database.
 func (s *Store) AddFHIREndpoint(ctx context.Context, e *endpointmanager.FHIREndpoint) error {
 	var err error
 
-	row := addFHIREndpointStatement.QueryRowContext(ctx,
+	locationsJSON, err := json.Marshal(e.Locations)
+	if err != nil {
+		return err
+	}
+
+	row := s.stmt(ctx, addFHIREndpointStatement).QueryRowContext(ctx,
 		e.URL,
 		pq.Array(e.OrganizationNames),
 		pq.Array(e.NPIIDs),
-		e.ListSource)
+		e.ListSource,
+		locationsJSON)
 
 	err = row.Scan(&e.ID)
 
@@ -275,6 +522,7 @@ func (s *Store) AddFHIREndpoint(ctx context.Context, e *endpointmanager.FHIREndp
 func (s *Store) UpdateFHIREndpoint(ctx context.Context, e *endpointmanager.FHIREndpoint) error {
 	var err error
 	var versionsResponseJSON []byte
+	var locationsJSON []byte
 
 	if e.VersionsResponse.Response != nil {
 		versionsResponseJSON, err = e.VersionsResponse.GetJSON()
@@ -285,12 +533,18 @@ func (s *Store) UpdateFHIREndpoint(ctx context.Context, e *endpointmanager.FHIRE
 		versionsResponseJSON = []byte("null")
 	}
 
-	_, err = updateFHIREndpointStatement.ExecContext(ctx,
+	locationsJSON, err = json.Marshal(e.Locations)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.stmt(ctx, updateFHIREndpointStatement).ExecContext(ctx,
 		e.URL,
 		pq.Array(e.OrganizationNames),
 		pq.Array(e.NPIIDs),
 		e.ListSource,
 		versionsResponseJSON,
+		locationsJSON,
 		e.ID)
 
 	return err
@@ -298,7 +552,7 @@ func (s *Store) UpdateFHIREndpoint(ctx context.Context, e *endpointmanager.FHIRE
 
 // DeleteFHIREndpoint deletes the FHIREndpoint from the database using the FHIREndpoint's database id  as the key.
 func (s *Store) DeleteFHIREndpoint(ctx context.Context, e *endpointmanager.FHIREndpoint) error {
-	_, err := deleteFHIREndpointStatement.ExecContext(ctx, e.ID)
+	_, err := s.stmt(ctx, deleteFHIREndpointStatement).ExecContext(ctx, e.ID)
 
 	return err
 }
@@ -309,8 +563,9 @@ func prepareFHIREndpointStatements(s *Store) error {
 		INSERT INTO fhir_endpoints (url,
 			organization_names,
 			npi_ids,
-			list_source)
-		VALUES ($1, $2, $3, $4)
+			list_source,
+			locations)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id`)
 	if err != nil {
 		return err
@@ -321,8 +576,9 @@ func prepareFHIREndpointStatements(s *Store) error {
 			organization_names = $2,
 			npi_ids = $3,
 			list_source = $4,
-			versions_response = $5
-		WHERE id = $6`)
+			versions_response = $5,
+			locations = $6
+		WHERE id = $7`)
 	if err != nil {
 		return err
 	}
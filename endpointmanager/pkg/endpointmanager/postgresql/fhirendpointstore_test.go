@@ -4,6 +4,8 @@ package postgresql
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
@@ -26,7 +28,13 @@ func Test_PersistFHIREndpoint(t *testing.T) {
 		URL:               "example.com/FHIR/DSTU2/",
 		OrganizationNames: []string{"Example Inc."},
 		NPIIDs:            []string{"1"},
-		ListSource:        "https://github.com/cerner/ignite-endpoints"}
+		ListSource:        "https://github.com/cerner/ignite-endpoints",
+		Locations: []*endpointmanager.Location{
+			{
+				Address1: "123 Gov Way",
+				City:     "A City",
+				State:    "AK",
+				ZipCode:  "00000"}}}
 
 	var endpoint2 = &endpointmanager.FHIREndpoint{
 		URL:               "other.example.com/FHIR/DSTU2/",
@@ -103,6 +111,8 @@ func Test_PersistFHIREndpoint(t *testing.T) {
 
 	e1.OrganizationNames = []string{"Org 1", "Org 2"}
 	e1.NPIIDs = []string{"2", "3"}
+	newLocation := &endpointmanager.Location{City: "Another City", State: "MA"}
+	e1.Locations = []*endpointmanager.Location{newLocation}
 	vsr.Response["versions"] = []string{"4.0", "2.0"}
 	e1.VersionsResponse = vsr
 	err = store.AddOrUpdateFHIREndpoint(ctx, e1)
@@ -122,6 +132,9 @@ func Test_PersistFHIREndpoint(t *testing.T) {
 	if !e1.VersionsResponse.Equal(vsr) {
 		t.Errorf("Expected VersionsResponse %v to be updated with new value so that it equals %v", e1.VersionsResponse, vsr)
 	}
+	if len(e1.Locations) != 2 || !e1.Locations[0].Equal(endpoint1.Locations[0]) || !e1.Locations[1].Equal(newLocation) {
+		t.Errorf("Expected locations to be merged with new locations. Got %v", e1.Locations)
+	}
 
 	// retreive all endpoints
 
@@ -171,3 +184,55 @@ func Test_PersistFHIREndpoint(t *testing.T) {
 		t.Errorf("Error deleting fhir endpoint: %s", err.Error())
 	}
 }
+
+func Test_AddOrUpdateFHIREndpoints(t *testing.T) {
+	SetupStore()
+	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
+	defer teardown(t, store.DB)
+
+	ctx := context.Background()
+
+	existing := &endpointmanager.FHIREndpoint{
+		URL:               "example.com/FHIR/DSTU2/",
+		OrganizationNames: []string{"Example Inc."},
+		ListSource:        "Lantern",
+	}
+	err := store.AddFHIREndpoint(ctx, existing)
+	th.Assert(t, err == nil, err)
+
+	// the existing endpoint is merged with the new data and the duplicated new endpoint is merged with itself
+	endpoints := []*endpointmanager.FHIREndpoint{
+		{URL: existing.URL, OrganizationNames: []string{"Second Example Inc."}, NPIIDs: []string{"1"}, ListSource: "Lantern"},
+		{URL: "other.example.com/FHIR/DSTU2/", OrganizationNames: []string{"Other Example Inc."}, ListSource: "Lantern"},
+		{URL: "other.example.com/FHIR/DSTU2/", OrganizationNames: []string{"Other Example Inc. 2"}, ListSource: "Lantern"},
+	}
+	err = store.AddOrUpdateFHIREndpoints(ctx, endpoints)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, endpoints[0].ID == existing.ID, "expected the existing endpoint's id to be set")
+	th.Assert(t, endpoints[1].ID != 0 && endpoints[1].ID == endpoints[2].ID, "expected the duplicated endpoint to be stored once")
+
+	count, err := store.GetFHIREndpointCount(ctx)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, count == 2, fmt.Sprintf("expected 2 endpoints, got %d", count))
+
+	e, err := store.GetFHIREndpoint(ctx, existing.ID)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, helpers.StringArraysEqual(e.OrganizationNames, []string{"Example Inc.", "Second Example Inc."}), fmt.Sprintf("expected the organization names to be merged, got %v", e.OrganizationNames))
+	th.Assert(t, helpers.StringArraysEqual(e.NPIIDs, []string{"1"}), fmt.Sprintf("expected the NPI IDs to be merged, got %v", e.NPIIDs))
+
+	e, err = store.GetFHIREndpoint(ctx, endpoints[1].ID)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, helpers.StringArraysEqual(e.OrganizationNames, []string{"Other Example Inc.", "Other Example Inc. 2"}), fmt.Sprintf("expected the organization names to be merged, got %v", e.OrganizationNames))
+
+	// an error rolls back the whole list when run within a transaction
+	err = store.WithTx(ctx, func(txStore *Store) error {
+		return txStore.AddOrUpdateFHIREndpoints(ctx, []*endpointmanager.FHIREndpoint{
+			{URL: "new.example.com/FHIR/DSTU2/", ListSource: "Lantern"},
+			{URL: "bad.example.com/FHIR/DSTU2/", ListSource: strings.Repeat("a", 510)},
+		})
+	})
+	th.Assert(t, err != nil, "expected an error for a list source that is too long")
+	count, err = store.GetFHIREndpointCount(ctx)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, count == 2, fmt.Sprintf("expected no endpoints to be added, got %d endpoints", count))
+}
@@ -45,7 +45,7 @@ func (s *Store) GetHealthITProduct(ctx context.Context, id int) (*endpointmanage
 		created_at,
 		updated_at
 	FROM healthit_products WHERE id=$1`
-	row := s.DB.QueryRowContext(ctx, sqlStatement, id)
+	row := s.querier().QueryRowContext(ctx, sqlStatement, id)
 
 	err := row.Scan(
 		&hitp.ID,
@@ -89,7 +89,7 @@ func (s *Store) GetHealthITProductUsingNameAndVersion(ctx context.Context, name
 	var certificationCriteriaJSON []byte
 	var vendorIDNullable sql.NullInt64
 
-	row := getHealthITProductUsingNameAndVersion.QueryRowContext(ctx, name, version)
+	row := s.stmt(ctx, getHealthITProductUsingNameAndVersion).QueryRowContext(ctx, name, version)
 
 	err := row.Scan(
 		&hitp.ID,
@@ -152,7 +152,7 @@ func (s *Store) GetHealthITProductsUsingVendor(ctx context.Context, vendorID int
 		created_at,
 		updated_at
 	FROM healthit_products WHERE vendor_id=$1`
-	rows, err := s.DB.QueryContext(ctx, sqlStatement, vendorID)
+	rows, err := s.querier().QueryContext(ctx, sqlStatement, vendorID)
 	if err != nil {
 		return nil, err
 	}
@@ -203,7 +203,7 @@ func (s *Store) GetHealthITProductsUsingVendor(ctx context.Context, vendorID int
 func (s *Store) GetHealthITProductIDByCHPLID(ctx context.Context, CHPLID string) (int, error) {
 	var retProductID int
 
-	row := getHealthITProductIDByCHPLID.QueryRowContext(ctx, CHPLID)
+	row := s.stmt(ctx, getHealthITProductIDByCHPLID).QueryRowContext(ctx, CHPLID)
 
 	err := row.Scan(&retProductID)
 
@@ -224,7 +224,7 @@ func (s *Store) AddHealthITProduct(ctx context.Context, hitp *endpointmanager.He
 
 	nullableInts := getNullableInts([]int{hitp.VendorID})
 
-	row := addHealthITProductStatement.QueryRowContext(ctx,
+	row := s.stmt(ctx, addHealthITProductStatement).QueryRowContext(ctx,
 		hitp.Name,
 		hitp.Version,
 		nullableInts[0],
@@ -258,7 +258,7 @@ func (s *Store) UpdateHealthITProduct(ctx context.Context, hitp *endpointmanager
 
 	nullableInts := getNullableInts([]int{hitp.VendorID})
 
-	_, err = updateHealthITProductStatement.ExecContext(ctx,
+	_, err = s.stmt(ctx, updateHealthITProductStatement).ExecContext(ctx,
 		hitp.Name,
 		hitp.Version,
 		nullableInts[0],
@@ -279,7 +279,7 @@ func (s *Store) UpdateHealthITProduct(ctx context.Context, hitp *endpointmanager
 
 // DeleteHealthITProduct deletes the HealthITProduct from the database using the HealthITProduct's database ID as the key.
 func (s *Store) DeleteHealthITProduct(ctx context.Context, hitp *endpointmanager.HealthITProduct) error {
-	_, err := deleteHealthITProductStatement.ExecContext(ctx, hitp.ID)
+	_, err := s.stmt(ctx, deleteHealthITProductStatement).ExecContext(ctx, hitp.ID)
 
 	return err
 }
@@ -291,7 +291,7 @@ func (s *Store) GetProductCriteriaLink(ctx context.Context, criteriaID int, prod
 	var retCriteriaID int
 	var retCriteriaNumber string
 
-	row := getProductCriteriaLinkStatement.QueryRowContext(ctx,
+	row := s.stmt(ctx, getProductCriteriaLinkStatement).QueryRowContext(ctx,
 		productID,
 		criteriaID)
 
@@ -306,7 +306,7 @@ func (s *Store) GetProductCriteriaLink(ctx context.Context, criteriaID int, prod
 
 // LinkProductToCriteria links a product database id to a certification criteria id
 func (s *Store) LinkProductToCriteria(ctx context.Context, criteriaID int, productID int, productNumber string) error {
-	_, err := linkProductToCriteriaStatement.ExecContext(ctx,
+	_, err := s.stmt(ctx, linkProductToCriteriaStatement).ExecContext(ctx,
 		productID,
 		criteriaID,
 		productNumber)
@@ -316,7 +316,7 @@ func (s *Store) LinkProductToCriteria(ctx context.Context, criteriaID int, produ
 // DeleteLinksByProduct deletes all of the links in product_criteria with the given health it product database id
 func (s *Store) DeleteLinksByProduct(ctx context.Context, productID int) error {
 	sqlStatement := `DELETE FROM product_criteria WHERE healthit_product_id=$1`
-	_, err := s.DB.ExecContext(ctx, sqlStatement, productID)
+	_, err := s.querier().ExecContext(ctx, sqlStatement, productID)
 	return err
 }
 
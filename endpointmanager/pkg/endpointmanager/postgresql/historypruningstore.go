@@ -5,46 +5,148 @@ import (
 	"database/sql"
 	"strconv"
 
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 )
 
 var pruningStatementQueryInterval *sql.Stmt
 var pruningStatementNoQueryInterval *sql.Stmt
-var pruningDeleteStatement *sql.Stmt
-var pruningDeleteValStatement *sql.Stmt
-var pruningDeleteValResStatement *sql.Stmt
+var pruningURLsStatementQueryInterval *sql.Stmt
+var pruningURLsStatementNoQueryInterval *sql.Stmt
 
-// PruningGetInfoHistory gets info history entries for pruning
-func (s *Store) PruningGetInfoHistory(ctx context.Context, queryInterval bool) (*sql.Rows, error) {
+// PruningEntry identifies a fhir_endpoints_info_history entry removed by the history pruning.
+type PruningEntry struct {
+	URL                  string
+	RequestedFhirVersion string
+	EnteredAt            string
+	ValidationResultID   sql.NullInt64
+}
 
+// PruningGetInfoHistoryURLs gets up to 'limit' of the URLs that have info history entries to check for pruning,
+// ordered by URL and starting after 'afterURL'.
+func (s *Store) PruningGetInfoHistoryURLs(ctx context.Context, queryInterval bool, afterURL string, limit int) ([]string, error) {
 	var rows *sql.Rows
 	var err error
 
 	if queryInterval {
-		rows, err = pruningStatementQueryInterval.QueryContext(ctx)
+		rows, err = s.stmt(ctx, pruningURLsStatementQueryInterval).QueryContext(ctx, afterURL, limit)
 	} else {
-		rows, err = pruningStatementNoQueryInterval.QueryContext(ctx)
+		rows, err = s.stmt(ctx, pruningURLsStatementNoQueryInterval).QueryContext(ctx, afterURL, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []string
+	for rows.Next() {
+		var url string
+		err = rows.Scan(&url)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, url)
+	}
+	return urls, rows.Err()
+}
+
+// PruningGetInfoHistory gets the info history entries of the given URLs for pruning, ordered by URL and entry date.
+func (s *Store) PruningGetInfoHistory(ctx context.Context, queryInterval bool, urls []string) (*sql.Rows, error) {
+
+	var rows *sql.Rows
+	var err error
+
+	if queryInterval {
+		rows, err = s.stmt(ctx, pruningStatementQueryInterval).QueryContext(ctx, pq.Array(urls))
+	} else {
+		rows, err = s.stmt(ctx, pruningStatementNoQueryInterval).QueryContext(ctx, pq.Array(urls))
 	}
 
 	return rows, err
 }
 
-// PruningDeleteInfoHistory deletes info history entry due to pruning
-func (s *Store) PruningDeleteInfoHistory(ctx context.Context, url string, entryDate string, requested_fhir_version string) error {
-	_, err := pruningDeleteStatement.ExecContext(ctx, url, requested_fhir_version, entryDate)
-	return err
+// PruningDeleteInfoHistoryEntries deletes the given info history entries along with their validation results and the
+// documents that are no longer referenced, and records 'lastURL' as the pruning checkpoint for 'mode'. The entries are
+// deleted and the checkpoint is recorded in a single transaction, so a pruning run that stops part way through resumes
+// after the last batch it completed.
+func (s *Store) PruningDeleteInfoHistoryEntries(ctx context.Context, entries []PruningEntry, mode string, lastURL string) error {
+	urls := make([]string, len(entries))
+	versions := make([]string, len(entries))
+	enteredAts := make([]string, len(entries))
+	var valResIDs []int64
+	for i, entry := range entries {
+		urls[i] = entry.URL
+		versions[i] = entry.RequestedFhirVersion
+		enteredAts[i] = entry.EnteredAt
+		if entry.ValidationResultID.Valid {
+			valResIDs = append(valResIDs, entry.ValidationResultID.Int64)
+		}
+	}
+
+	return s.WithTx(ctx, func(txStore *Store) error {
+		tx := txStore.tx
+
+		var hashes []string
+		if len(entries) > 0 {
+			rows, err := tx.QueryContext(ctx, `
+				DELETE FROM fhir_endpoints_info_history AS history
+				USING unnest($1::VARCHAR[], $2::VARCHAR[], $3::TIMESTAMPTZ[]) AS pruned(url, requested_fhir_version, entered_at)
+				WHERE history.operation = 'U' AND history.url = pruned.url
+					AND history.requested_fhir_version = pruned.requested_fhir_version
+					AND history.entered_at = pruned.entered_at
+				RETURNING history.validation_result_id, history.capability_statement_hash, history.smart_response_hash`,
+				pq.Array(urls), pq.Array(versions), pq.Array(enteredAts))
+			if err != nil {
+				return errors.Wrap(err, "error deleting info history entries")
+			}
+			_, hashes, err = scanRemovedHistoryReferences(rows)
+			if err != nil {
+				return errors.Wrap(err, "error deleting info history entries")
+			}
+		}
+
+		if len(valResIDs) > 0 {
+			_, err := tx.ExecContext(ctx, `DELETE FROM validations WHERE validation_result_id = ANY($1)`, pq.Array(valResIDs))
+			if err != nil {
+				return errors.Wrap(err, "error deleting validations")
+			}
+			_, err = tx.ExecContext(ctx, `DELETE FROM validation_results WHERE id = ANY($1)`, pq.Array(valResIDs))
+			if err != nil {
+				return errors.Wrap(err, "error deleting validation results")
+			}
+		}
+
+		err := deleteUnreferencedDocuments(ctx, tx, hashes)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO history_pruning_checkpoints (mode, last_url) VALUES ($1, $2)
+			ON CONFLICT (mode) DO UPDATE SET last_url = EXCLUDED.last_url`, mode, lastURL)
+		if err != nil {
+			return errors.Wrap(err, "error recording the history pruning checkpoint")
+		}
+
+		return nil
+	})
 }
 
-// PruningDeleteValidationTable deletes validation table entries based on the given ID
-func (s *Store) PruningDeleteValidationTable(ctx context.Context, valResID int) error {
-	_, err := pruningDeleteValStatement.ExecContext(ctx, valResID)
-	return err
+// GetHistoryPruningCheckpoint gets the last URL pruned by the unfinished pruning run for 'mode'. If there is no
+// unfinished run, an empty string is returned.
+func (s *Store) GetHistoryPruningCheckpoint(ctx context.Context, mode string) (string, error) {
+	var lastURL string
+	err := s.querier().QueryRowContext(ctx, `SELECT last_url FROM history_pruning_checkpoints WHERE mode = $1`, mode).Scan(&lastURL)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return lastURL, err
 }
 
-// PruningDeleteValidationResultEntry deletes an entry from the validation_results table based
-// on the given ID
-func (s *Store) PruningDeleteValidationResultEntry(ctx context.Context, valResID int) error {
-	_, err := pruningDeleteValResStatement.ExecContext(ctx, valResID)
+// DeleteHistoryPruningCheckpoint removes the pruning checkpoint for 'mode' once a pruning run has finished.
+func (s *Store) DeleteHistoryPruningCheckpoint(ctx context.Context, mode string) error {
+	_, err := s.querier().ExecContext(ctx, `DELETE FROM history_pruning_checkpoints WHERE mode = $1`, mode)
 	return err
 }
 
@@ -57,35 +159,45 @@ func prepareHistoryPruningStatements(s *Store) error {
 	thresholdString := strconv.Itoa(pruningThreshold)
 	queryIntString := strconv.Itoa(pruningThreshold + (3 * queryInterval))
 
-	pruningStatementQueryInterval, err = s.DB.Prepare(`
-		SELECT operation, url, capability_statement, entered_at, tls_version, mime_types, smart_response, validation_result_id, requested_fhir_version FROM fhir_endpoints_info_history 
-		WHERE (operation='U' OR operation='I') 
+	queryIntervalWhere := `
+		WHERE (operation='U' OR operation='I')
 			AND (date_trunc('minute', entered_at) <= date_trunc('minute', current_date - INTERVAL '` + thresholdString + ` minute'))
-			AND (date_trunc('minute', entered_at) >= date_trunc('minute', current_date - INTERVAL '` + queryIntString + ` minute'))
+			AND (date_trunc('minute', entered_at) >= date_trunc('minute', current_date - INTERVAL '` + queryIntString + ` minute'))`
+	noQueryIntervalWhere := `
+		WHERE (operation='U' OR operation='I')
+			AND (date_trunc('minute', entered_at) <= date_trunc('minute', current_date - INTERVAL '` + thresholdString + ` minute'))`
+
+	pruningStatementQueryInterval, err = s.DB.Prepare(`
+		SELECT operation, url, capability_statement, entered_at, tls_version, mime_types, smart_response, validation_result_id, requested_fhir_version FROM fhir_endpoints_info_history_with_documents` +
+		queryIntervalWhere + `
+			AND url = ANY($1)
 		ORDER BY url, entered_at ASC;`)
 	if err != nil {
 		return err
 	}
 	pruningStatementNoQueryInterval, err = s.DB.Prepare(`
-		SELECT operation, url, capability_statement, entered_at, tls_version, mime_types, smart_response, validation_result_id, requested_fhir_version FROM fhir_endpoints_info_history 
-		WHERE (operation='U' OR operation='I') 
-			AND (date_trunc('minute', entered_at) <= date_trunc('minute', current_date - INTERVAL '` + thresholdString + ` minute')) 
+		SELECT operation, url, capability_statement, entered_at, tls_version, mime_types, smart_response, validation_result_id, requested_fhir_version FROM fhir_endpoints_info_history_with_documents` +
+		noQueryIntervalWhere + `
+			AND url = ANY($1)
 		ORDER BY url, entered_at ASC;`)
 	if err != nil {
 		return err
 	}
-	pruningDeleteStatement, err = s.DB.Prepare(`
-		DELETE FROM fhir_endpoints_info_history WHERE url=$1 AND operation='U' AND requested_fhir_version=$2 AND entered_at = $3;`)
-	if err != nil {
-		return err
-	}
-	pruningDeleteValStatement, err = s.DB.Prepare(`
-		DELETE FROM validations WHERE validation_result_id = $1;`)
+	pruningURLsStatementQueryInterval, err = s.DB.Prepare(`
+		SELECT DISTINCT url FROM fhir_endpoints_info_history` +
+		queryIntervalWhere + `
+			AND url > $1
+		ORDER BY url ASC
+		LIMIT $2;`)
 	if err != nil {
 		return err
 	}
-	pruningDeleteValResStatement, err = s.DB.Prepare(`
-		DELETE FROM validation_results WHERE id = $1;`)
+	pruningURLsStatementNoQueryInterval, err = s.DB.Prepare(`
+		SELECT DISTINCT url FROM fhir_endpoints_info_history` +
+		noQueryIntervalWhere + `
+			AND url > $1
+		ORDER BY url ASC
+		LIMIT $2;`)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,356 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/pkg/errors"
+)
+
+// ErrHistoryPartitionInUse is returned when a fhir_endpoints_metadata partition can not be dropped because the
+// current information of an endpoint still references it.
+var ErrHistoryPartitionInUse = errors.New("the history partition is referenced by fhir_endpoints_info")
+
+// a fhir_endpoints_info_history entry is a change point if it is the first entry for its endpoint and requested
+// version, or if any of these fields differ from the endpoint's previous entry. These are the fields that the
+// history pruning compares.
+const infoHistoryFingerprint = `md5(ROW(tls_version, mime_types, capability_statement_hash, smart_response_hash)::text)`
+
+// CreateHistoryPartitions creates the monthly fhir_endpoints_info_history and fhir_endpoints_metadata partitions
+// from the current month through 'monthsAhead' months from now. Partitions that were already created are skipped.
+func (s *Store) CreateHistoryPartitions(ctx context.Context, monthsAhead int) error {
+	_, err := s.querier().ExecContext(ctx,
+		`SELECT create_history_partitions(CURRENT_DATE, (CURRENT_DATE + $1 * INTERVAL '1 month')::DATE)`,
+		monthsAhead)
+	return err
+}
+
+// GetHistoryPartitions gets all of the history partitions, including the dropped ones, ordered by month. The
+// fhir_endpoints_info_history partition of a month comes before its fhir_endpoints_metadata partition.
+func (s *Store) GetHistoryPartitions(ctx context.Context) ([]*endpointmanager.HistoryPartition, error) {
+	rows, err := s.querier().QueryContext(ctx, `
+		SELECT name, parent_table, month, tier
+		FROM history_partitions
+		ORDER BY month, parent_table`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var partitions []*endpointmanager.HistoryPartition
+	for rows.Next() {
+		var partition endpointmanager.HistoryPartition
+		err = rows.Scan(&partition.Name, &partition.ParentTable, &partition.Month, &partition.Tier)
+		if err != nil {
+			return nil, err
+		}
+		partitions = append(partitions, &partition)
+	}
+	return partitions, rows.Err()
+}
+
+// SummarizeHistoryPartition moves a partition from the full tier to the summary tier. The fhir_endpoints_info_history
+// entries that are not change points are removed along with their validations and the documents that are no longer
+// referenced. The fhir_endpoints_metadata rows are
+// aggregated by day into fhir_endpoints_metadata_daily, and the rows that are not referenced by fhir_endpoints_info or
+// fhir_endpoints_info_history are removed. The partition is summarized in a single transaction.
+func (s *Store) SummarizeHistoryPartition(ctx context.Context, partition *endpointmanager.HistoryPartition) error {
+	return s.WithTx(ctx, func(txStore *Store) error {
+		tx := txStore.tx
+
+		err := lockHistoryPartition(ctx, tx, partition, endpointmanager.TierFull)
+		if err != nil {
+			return err
+		}
+
+		switch partition.ParentTable {
+		case endpointmanager.InfoHistoryTable:
+			err = summarizeInfoHistoryPartition(ctx, tx, partition)
+		case endpointmanager.MetadataTable:
+			err = summarizeMetadataPartition(ctx, tx, partition)
+		default:
+			err = errors.Errorf("%s is not a partition of a history table", partition.Name)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "error summarizing history partition %s", partition.Name)
+		}
+
+		return setHistoryPartitionTier(ctx, tx, partition, endpointmanager.TierSummary)
+	})
+}
+
+// DropHistoryPartition detaches and drops a partition. The validations of the dropped fhir_endpoints_info_history
+// entries and the documents that are no longer referenced are removed, as are the daily aggregates of a dropped
+// fhir_endpoints_metadata partition. A
+// fhir_endpoints_metadata partition that fhir_endpoints_info still references is not dropped and
+// ErrHistoryPartitionInUse is returned.
+func (s *Store) DropHistoryPartition(ctx context.Context, partition *endpointmanager.HistoryPartition) error {
+	return s.WithTx(ctx, func(txStore *Store) error {
+		tx := txStore.tx
+
+		err := lockHistoryPartition(ctx, tx, partition, endpointmanager.TierFull, endpointmanager.TierSummary)
+		if err != nil {
+			return err
+		}
+
+		switch partition.ParentTable {
+		case endpointmanager.InfoHistoryTable:
+			err = dropInfoHistoryPartition(ctx, tx, partition)
+		case endpointmanager.MetadataTable:
+			err = dropMetadataPartition(ctx, tx, partition)
+		default:
+			err = errors.Errorf("%s is not a partition of a history table", partition.Name)
+		}
+		if err == ErrHistoryPartitionInUse {
+			return err
+		} else if err != nil {
+			return errors.Wrapf(err, "error dropping history partition %s", partition.Name)
+		}
+
+		return setHistoryPartitionTier(ctx, tx, partition, endpointmanager.TierDropped)
+	})
+}
+
+// lockHistoryPartition locks the partition's history_partitions row for the rest of the transaction and checks that
+// the partition is in one of the expected tiers, so that concurrent runs can not apply the same change twice.
+func lockHistoryPartition(ctx context.Context, tx *sql.Tx, partition *endpointmanager.HistoryPartition, tiers ...string) error {
+	var tier string
+	err := tx.QueryRowContext(ctx, `SELECT tier FROM history_partitions WHERE name = $1 FOR UPDATE`, partition.Name).Scan(&tier)
+	if err != nil {
+		return errors.Wrapf(err, "error locking history partition %s", partition.Name)
+	}
+	for _, expected := range tiers {
+		if tier == expected {
+			return nil
+		}
+	}
+	return errors.Errorf("history partition %s is in the %s tier", partition.Name, tier)
+}
+
+func setHistoryPartitionTier(ctx context.Context, tx *sql.Tx, partition *endpointmanager.HistoryPartition, tier string) error {
+	_, err := tx.ExecContext(ctx, `UPDATE history_partitions SET tier = $1 WHERE name = $2`, tier, partition.Name)
+	if err != nil {
+		return errors.Wrapf(err, "error setting the tier of history partition %s", partition.Name)
+	}
+	partition.Tier = tier
+	return nil
+}
+
+func summarizeInfoHistoryPartition(ctx context.Context, tx *sql.Tx, partition *endpointmanager.HistoryPartition) error {
+	// the previous entry of an endpoint may be in an earlier partition, so the entries are compared across the whole
+	// table up to the end of the partition's month
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+		WITH entries AS (
+			SELECT tableoid, ctid, operation,
+				%[2]s AS fingerprint,
+				LAG(%[2]s) OVER (PARTITION BY url, requested_fhir_version ORDER BY entered_at) AS previous_fingerprint
+			FROM fhir_endpoints_info_history
+			WHERE entered_at < $1::DATE + INTERVAL '1 month'
+				AND url IN (SELECT url FROM %[1]s)
+		)
+		DELETE FROM %[1]s AS history
+		USING entries
+		WHERE entries.tableoid = $2::regclass AND history.ctid = entries.ctid
+			AND entries.operation = 'U' AND entries.fingerprint = entries.previous_fingerprint
+		RETURNING history.validation_result_id, history.capability_statement_hash, history.smart_response_hash`,
+		pq.QuoteIdentifier(partition.Name), infoHistoryFingerprint),
+		partition.Month, partition.Name)
+	if err != nil {
+		return err
+	}
+	valResIDs, hashes, err := scanRemovedHistoryReferences(rows)
+	if err != nil {
+		return err
+	}
+
+	err = deleteUnreferencedValidationResults(ctx, tx, valResIDs)
+	if err != nil {
+		return err
+	}
+	return deleteUnreferencedDocuments(ctx, tx, hashes)
+}
+
+func summarizeMetadataPartition(ctx context.Context, tx *sql.Tx, partition *endpointmanager.HistoryPartition) error {
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO fhir_endpoints_metadata_daily (url, requested_fhir_version, day, http_200_count, http_all_count,
+			avg_response_time_seconds, max_response_time_seconds)
+		SELECT url, COALESCE(requested_fhir_version, 'None'), created_at::DATE,
+			COUNT(*) FILTER (WHERE http_response = 200), COUNT(*),
+			AVG(response_time_seconds), MAX(response_time_seconds)
+		FROM %s
+		WHERE url IS NOT NULL
+		GROUP BY url, COALESCE(requested_fhir_version, 'None'), created_at::DATE
+		ON CONFLICT (url, requested_fhir_version, day) DO UPDATE SET
+			http_200_count = EXCLUDED.http_200_count,
+			http_all_count = EXCLUDED.http_all_count,
+			avg_response_time_seconds = EXCLUDED.avg_response_time_seconds,
+			max_response_time_seconds = EXCLUDED.max_response_time_seconds`, pq.QuoteIdentifier(partition.Name)))
+	if err != nil {
+		return errors.Wrap(err, "error aggregating metadata by day")
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+		DELETE FROM %s AS metadata
+		WHERE NOT EXISTS (SELECT 1 FROM fhir_endpoints_info AS info WHERE info.metadata_id = metadata.id)
+			AND NOT EXISTS (SELECT 1 FROM fhir_endpoints_info_history AS history WHERE history.metadata_id = metadata.id)`,
+		pq.QuoteIdentifier(partition.Name)))
+	if err != nil {
+		return errors.Wrap(err, "error removing unreferenced metadata")
+	}
+	return nil
+}
+
+func dropInfoHistoryPartition(ctx context.Context, tx *sql.Tx, partition *endpointmanager.HistoryPartition) error {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+		SELECT DISTINCT validation_result_id, capability_statement_hash, smart_response_hash FROM %s`,
+		pq.QuoteIdentifier(partition.Name)))
+	if err != nil {
+		return err
+	}
+	valResIDs, hashes, err := scanRemovedHistoryReferences(rows)
+	if err != nil {
+		return err
+	}
+
+	// detaching the partition locks fhir_endpoints_info_history, which the capability receiver writes to while it
+	// holds its lock on fhir_endpoints_documents. The documents are locked first so that the two can not deadlock.
+	if len(hashes) > 0 {
+		err = lockDocuments(ctx, tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = detachAndDropPartition(ctx, tx, partition)
+	if err != nil {
+		return err
+	}
+
+	err = deleteUnreferencedValidationResults(ctx, tx, valResIDs)
+	if err != nil {
+		return err
+	}
+	return deleteUnreferencedDocuments(ctx, tx, hashes)
+}
+
+func dropMetadataPartition(ctx context.Context, tx *sql.Tx, partition *endpointmanager.HistoryPartition) error {
+	var inUse bool
+	err := tx.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT EXISTS (SELECT 1 FROM %s AS metadata JOIN fhir_endpoints_info AS info ON info.metadata_id = metadata.id)`,
+		pq.QuoteIdentifier(partition.Name))).Scan(&inUse)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrHistoryPartitionInUse
+	}
+
+	err = detachAndDropPartition(ctx, tx, partition)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM fhir_endpoints_metadata_daily
+		WHERE day >= $1::DATE AND day < $1::DATE + INTERVAL '1 month'`, partition.Month)
+	return err
+}
+
+func detachAndDropPartition(ctx context.Context, tx *sql.Tx, partition *endpointmanager.HistoryPartition) error {
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s DETACH PARTITION %s`,
+		pq.QuoteIdentifier(partition.ParentTable), pq.QuoteIdentifier(partition.Name)))
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`DROP TABLE %s`, pq.QuoteIdentifier(partition.Name)))
+	return err
+}
+
+// deleteUnreferencedValidationResults removes the validation results with the given IDs, and their validations,
+// that are no longer referenced by fhir_endpoints_info or fhir_endpoints_info_history.
+func deleteUnreferencedValidationResults(ctx context.Context, tx *sql.Tx, valResIDs []int) error {
+	if len(valResIDs) == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `
+		WITH unreferenced AS (
+			SELECT id FROM unnest($1::INT[]) AS id
+			EXCEPT SELECT validation_result_id FROM fhir_endpoints_info WHERE validation_result_id = ANY($1::INT[])
+			EXCEPT SELECT validation_result_id FROM fhir_endpoints_info_history WHERE validation_result_id = ANY($1::INT[])
+		), removed_validations AS (
+			DELETE FROM validations WHERE validation_result_id IN (SELECT id FROM unreferenced)
+		)
+		DELETE FROM validation_results WHERE id IN (SELECT id FROM unreferenced)`, pq.Array(valResIDs))
+	if err != nil {
+		return errors.Wrap(err, "error removing validation results")
+	}
+	return nil
+}
+
+// deleteUnreferencedDocuments removes the fhir_endpoints_documents with the given hashes that are no longer
+// referenced by fhir_endpoints_info or fhir_endpoints_info_history.
+func deleteUnreferencedDocuments(ctx context.Context, tx *sql.Tx, hashes []string) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+	err := lockDocuments(ctx, tx)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM fhir_endpoints_documents AS documents
+		WHERE documents.hash = ANY($1::CHAR(64)[])
+			AND NOT EXISTS (SELECT 1 FROM fhir_endpoints_info WHERE capability_statement_hash = documents.hash)
+			AND NOT EXISTS (SELECT 1 FROM fhir_endpoints_info WHERE smart_response_hash = documents.hash)
+			AND NOT EXISTS (SELECT 1 FROM fhir_endpoints_info_history WHERE capability_statement_hash = documents.hash)
+			AND NOT EXISTS (SELECT 1 FROM fhir_endpoints_info_history WHERE smart_response_hash = documents.hash)`,
+		pq.Array(hashes))
+	if err != nil {
+		return errors.Wrap(err, "error removing unreferenced documents")
+	}
+	return nil
+}
+
+// lockDocuments keeps documents from being added to fhir_endpoints_documents until the transaction ends and waits for
+// the transactions that are adding documents to finish. A document that is already stored is referenced again
+// without being added, so without the lock a document could be removed while a concurrent write references it.
+func lockDocuments(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `LOCK TABLE fhir_endpoints_documents IN SHARE ROW EXCLUSIVE MODE`)
+	if err != nil {
+		return errors.Wrap(err, "error locking fhir_endpoints_documents")
+	}
+	return nil
+}
+
+// scanRemovedHistoryReferences scans rows of validation_result_id, capability_statement_hash and smart_response_hash
+// of removed fhir_endpoints_info_history entries and returns the distinct validation result IDs and document hashes.
+func scanRemovedHistoryReferences(rows *sql.Rows) ([]int, []string, error) {
+	defer rows.Close()
+
+	var valResIDs []int
+	var hashes []string
+	seenValResIDs := make(map[int]bool)
+	seenHashes := make(map[string]bool)
+	for rows.Next() {
+		var valResID sql.NullInt64
+		var capStatHash, smartRespHash sql.NullString
+		err := rows.Scan(&valResID, &capStatHash, &smartRespHash)
+		if err != nil {
+			return nil, nil, err
+		}
+		if valResID.Valid && !seenValResIDs[int(valResID.Int64)] {
+			seenValResIDs[int(valResID.Int64)] = true
+			valResIDs = append(valResIDs, int(valResID.Int64))
+		}
+		for _, hash := range []sql.NullString{capStatHash, smartRespHash} {
+			if hash.Valid && !seenHashes[hash.String] {
+				seenHashes[hash.String] = true
+				hashes = append(hashes, hash.String)
+			}
+		}
+	}
+	return valResIDs, hashes, rows.Err()
+}
@@ -42,7 +42,7 @@ func (s *Store) GetNPIContactByNPIID(ctx context.Context, npiID string) (*endpoi
 	created_at,
 	updated_at
 	FROM npi_contacts WHERE npi_id=$1`
-	row := s.DB.QueryRowContext(ctx, sqlStatement, npiID)
+	row := s.querier().QueryRowContext(ctx, sqlStatement, npiID)
 
 	err := row.Scan(
 		&contact.ID,
@@ -78,10 +78,78 @@ func (s *Store) GetNPIContactByNPIID(ctx context.Context, npiID string) (*endpoi
 	return &contact, err
 }
 
+// GetAllNPIContacts gets all of the NPIContacts from the database ordered by NPI id.
+func (s *Store) GetAllNPIContacts(ctx context.Context) ([]*endpointmanager.NPIContact, error) {
+	sqlStatement := `
+	SELECT
+	id,
+	npi_id,
+	endpoint_type,
+	endpoint_type_description,
+	endpoint,
+	valid_url,
+	affiliation,
+	endpoint_description,
+	affiliation_legal_business_name,
+	use_code,
+	use_description,
+	other_use_description,
+	content_type,
+	content_description,
+	other_content_description,
+	location,
+	created_at,
+	updated_at
+	FROM npi_contacts ORDER BY npi_id, id`
+	rows, err := s.querier().QueryContext(ctx, sqlStatement)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contacts []*endpointmanager.NPIContact
+	for rows.Next() {
+		var contact endpointmanager.NPIContact
+		var locationJSON []byte
+
+		err = rows.Scan(
+			&contact.ID,
+			&contact.NPI_ID,
+			&contact.EndpointType,
+			&contact.EndpointTypeDescription,
+			&contact.Endpoint,
+			&contact.ValidURL,
+			&contact.Affiliation,
+			&contact.EndpointDescription,
+			&contact.AffiliationLegalBusinessName,
+			&contact.UseCode,
+			&contact.UseDescription,
+			&contact.OtherUseDescription,
+			&contact.ContentType,
+			&contact.ContentDescription,
+			&contact.OtherContentDescription,
+			&locationJSON,
+			&contact.CreatedAt,
+			&contact.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal(locationJSON, &contact.Location)
+		if err != nil {
+			return nil, err
+		}
+
+		contacts = append(contacts, &contact)
+	}
+	return contacts, rows.Err()
+}
+
 // DeleteAllNPIContacts will remove all rows from the npi_Contacts table
 func (s *Store) DeleteAllNPIContacts(ctx context.Context) error {
 	sqlStatement := `DELETE FROM npi_contacts`
-	_, err := s.DB.ExecContext(ctx, sqlStatement)
+	_, err := s.querier().ExecContext(ctx, sqlStatement)
 	return err
 }
 
@@ -91,7 +159,7 @@ func (s *Store) AddNPIContact(ctx context.Context, contact *endpointmanager.NPIC
 	if err != nil {
 		return err
 	}
-	row := addNPIContactStatement.QueryRowContext(ctx,
+	row := s.stmt(ctx, addNPIContactStatement).QueryRowContext(ctx,
 		contact.NPI_ID,
 		contact.EndpointType,
 		contact.EndpointTypeDescription,
@@ -120,7 +188,7 @@ func (s *Store) UpdateNPIContactByNPIID(ctx context.Context, contact *endpointma
 		return err
 	}
 
-	_, err = updateNPIContactByNPIIDStatement.ExecContext(ctx,
+	_, err = s.stmt(ctx, updateNPIContactByNPIIDStatement).ExecContext(ctx,
 		contact.NPI_ID,
 		contact.EndpointType,
 		contact.EndpointTypeDescription,
@@ -142,7 +210,7 @@ func (s *Store) UpdateNPIContactByNPIID(ctx context.Context, contact *endpointma
 
 // DeleteNPIContact deletes the NPIContact from the database using the NPIContact's database ID as the key.
 func (s *Store) DeleteNPIContact(ctx context.Context, org *endpointmanager.NPIContact) error {
-	_, err := deleteNPIContactStatement.ExecContext(ctx, org.ID)
+	_, err := s.stmt(ctx, deleteNPIContactStatement).ExecContext(ctx, org.ID)
 
 	return err
 }
@@ -205,6 +205,18 @@ func Test_PersistNPIContact(t *testing.T) {
 		t.Errorf("retrieved contact is not equal to saved contact.")
 	}
 
+	// retrieve all contacts
+
+	contacts, err := store.GetAllNPIContacts(ctx)
+	if err != nil {
+		t.Errorf("Error getting all npi contacts: %s", err.Error())
+	}
+	if len(contacts) != 2 {
+		t.Errorf("Expected 2 npi contacts. Got %d", len(contacts))
+	} else if contacts[0].NPI_ID != contact1.NPI_ID || contacts[1].Endpoint != contact2.Endpoint {
+		t.Errorf("retrieved contacts are not equal to saved contacts.")
+	}
+
 	// update contact using UpdateNPIContactByNPIID
 
 	temp_affiliation := contact1.Affiliation
@@ -0,0 +1,501 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/pkg/errors"
+)
+
+// NPIOrganizationLoad copies NPI organizations and deactivations into a staging table using the Postgres COPY
+// command, and merges the staging table into the npi_organizations table when it is committed. The whole load is
+// run in a single transaction, so nothing is changed if the load is rolled back or fails. A load begun by a store
+// that is running within a transaction joins that transaction and runs within a savepoint, so rolling back the load
+// only undoes the load.
+type NPIOrganizationLoad struct {
+	tx     *sql.Tx
+	nested bool
+	copy   *sql.Stmt
+	line   int
+}
+
+// NPIOrganizationLoadSummary reports how the rows of an NPIOrganizationLoad changed the npi_organizations table.
+// Reactivated organizations are also counted as updated.
+type NPIOrganizationLoadSummary struct {
+	Added       int
+	Updated     int
+	Deactivated int
+	Reactivated int
+}
+
+// an organization is active if it was never deactivated or if it was reactivated after it was deactivated. The
+// comparison is coalesced so that the expression is never NULL and can be negated.
+const npiOrganizationActive = `(%[1]s.deactivation_date IS NULL OR COALESCE(%[1]s.reactivation_date >= %[1]s.deactivation_date, FALSE))`
+
+// BeginNPIOrganizationLoad starts a bulk load of NPI organizations.
+func (s *Store) BeginNPIOrganizationLoad(ctx context.Context) (*NPIOrganizationLoad, error) {
+	load := &NPIOrganizationLoad{tx: s.tx, nested: s.tx != nil}
+	if load.nested {
+		_, err := load.tx.ExecContext(ctx, `SAVEPOINT npi_organization_load`)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating NPI organization load savepoint")
+		}
+	} else {
+		tx, err := s.DB.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		load.tx = tx
+	}
+	tx := load.tx
+
+	_, err := tx.ExecContext(ctx, `
+		CREATE TEMP TABLE npi_organizations_staging (
+			line                        INTEGER,
+			npi_id                      VARCHAR(500),
+			name                        VARCHAR(500),
+			secondary_name              VARCHAR(500),
+			location                    JSONB,
+			mailing_location            JSONB,
+			taxonomy                    VARCHAR(500),
+			authorized_official         JSONB,
+			taxonomies                  JSONB,
+			identifiers                 JSONB,
+			normalized_name             VARCHAR(500),
+			normalized_secondary_name   VARCHAR(500),
+			last_update_date            DATE,
+			deactivation_date           DATE,
+			reactivation_date           DATE,
+			deactivation_only           BOOLEAN,
+			merge                       VARCHAR(10)
+		) ON COMMIT DROP`)
+	if err != nil {
+		_ = load.rollback()
+		return nil, errors.Wrap(err, "error creating NPI organization staging table")
+	}
+
+	copyStmt, err := tx.PrepareContext(ctx, pq.CopyIn("npi_organizations_staging",
+		"line",
+		"npi_id",
+		"name",
+		"secondary_name",
+		"location",
+		"mailing_location",
+		"taxonomy",
+		"authorized_official",
+		"taxonomies",
+		"identifiers",
+		"normalized_name",
+		"normalized_secondary_name",
+		"last_update_date",
+		"deactivation_date",
+		"reactivation_date",
+		"deactivation_only"))
+	if err != nil {
+		_ = load.rollback()
+		return nil, errors.Wrap(err, "error starting copy into NPI organization staging table")
+	}
+	load.copy = copyStmt
+
+	return load, nil
+}
+
+// AddOrganization copies the organization into the staging table along with the dates the organization was last
+// updated, deactivated and reactivated in NPPES. Dates that are not known should be the zero time.
+func (l *NPIOrganizationLoad) AddOrganization(ctx context.Context, org *endpointmanager.NPIOrganization, lastUpdate time.Time, deactivation time.Time, reactivation time.Time) error {
+	locationJSON, err := json.Marshal(org.Location)
+	if err != nil {
+		return err
+	}
+	mailingLocationJSON, authorizedOfficialJSON, err := marshalNPIOrganizationDetails(org)
+	if err != nil {
+		return err
+	}
+	taxonomiesJSON, identifiersJSON, err := marshalStagedNPIOrganizationChildren(org)
+	if err != nil {
+		return err
+	}
+	l.line++
+	_, err = l.copy.ExecContext(ctx,
+		l.line,
+		org.NPI_ID,
+		org.Name,
+		org.SecondaryName,
+		string(locationJSON),
+		string(mailingLocationJSON),
+		org.Taxonomy,
+		string(authorizedOfficialJSON),
+		taxonomiesJSON,
+		identifiersJSON,
+		org.NormalizedName,
+		org.NormalizedSecondaryName,
+		nullDate(lastUpdate),
+		nullDate(deactivation),
+		nullDate(reactivation),
+		false)
+	return err
+}
+
+// AddDeactivation copies the deactivation of the NPI into the staging table. Only NPIs that are already in the
+// npi_organizations table are deactivated.
+func (l *NPIOrganizationLoad) AddDeactivation(ctx context.Context, npiID string, deactivation time.Time) error {
+	l.line++
+	_, err := l.copy.ExecContext(ctx,
+		l.line,
+		npiID,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nullDate(deactivation),
+		nil,
+		true)
+	return err
+}
+
+// Commit merges the staging table into the npi_organizations table and commits the load. New organizations are
+// added, organizations whose information changed are updated, and organizations that were deactivated are marked
+// as deactivated. If the same NPI was added to the load more than once, only the last row is used.
+func (l *NPIOrganizationLoad) Commit(ctx context.Context) (NPIOrganizationLoadSummary, error) {
+	var summary NPIOrganizationLoadSummary
+
+	// flush the copy
+	_, err := l.copy.ExecContext(ctx)
+	if err != nil {
+		_ = l.Rollback()
+		return summary, errors.Wrap(err, "error copying into NPI organization staging table")
+	}
+	err = l.copy.Close()
+	if err != nil {
+		_ = l.Rollback()
+		return summary, errors.Wrap(err, "error copying into NPI organization staging table")
+	}
+
+	_, err = l.tx.ExecContext(ctx, `
+		DELETE FROM npi_organizations_staging s
+		USING npi_organizations_staging s2
+		WHERE s.npi_id = s2.npi_id AND s.line < s2.line`)
+	if err != nil {
+		_ = l.rollback()
+		return summary, errors.Wrap(err, "error removing duplicate NPIs from NPI organization staging table")
+	}
+
+	err = l.tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM npi_organizations o
+		JOIN npi_organizations_staging s ON o.npi_id = s.npi_id
+		WHERE NOT s.deactivation_only
+		AND NOT `+sprintfActive("o")+`
+		AND `+sprintfActive("s")).Scan(&summary.Reactivated)
+	if err != nil {
+		_ = l.rollback()
+		return summary, errors.Wrap(err, "error counting reactivated NPI organizations")
+	}
+
+	// mark the organizations that are new or that changed. The stored taxonomies and identifiers are aggregated
+	// into the same JSON form as the staged taxonomies and identifiers to compare them.
+	_, err = l.tx.ExecContext(ctx, `
+		UPDATE npi_organizations_staging s
+		SET merge = 'update'
+		FROM npi_organizations o
+		WHERE o.npi_id = s.npi_id
+		AND NOT s.deactivation_only
+		AND `+sprintfActive("s")+`
+		AND (o.name IS DISTINCT FROM s.name
+			OR o.secondary_name IS DISTINCT FROM s.secondary_name
+			OR o.location IS DISTINCT FROM s.location
+			OR o.mailing_location IS DISTINCT FROM s.mailing_location
+			OR o.taxonomy IS DISTINCT FROM s.taxonomy
+			OR o.authorized_official IS DISTINCT FROM s.authorized_official
+			OR o.normalized_name IS DISTINCT FROM s.normalized_name
+			OR o.normalized_secondary_name IS DISTINCT FROM s.normalized_secondary_name
+			OR o.deactivation_date IS DISTINCT FROM s.deactivation_date
+			OR o.reactivation_date IS DISTINCT FROM s.reactivation_date
+			OR s.taxonomies IS DISTINCT FROM (
+				SELECT jsonb_agg(jsonb_build_object(
+					'position', t.position,
+					'code', t.code,
+					'license_number', t.license_number,
+					'license_state', t.license_state,
+					'is_primary', t.is_primary) ORDER BY t.position)
+				FROM npi_organization_taxonomies t WHERE t.npi_id = o.npi_id)
+			OR s.identifiers IS DISTINCT FROM (
+				SELECT jsonb_agg(jsonb_build_object(
+					'position', i.position,
+					'identifier', i.identifier,
+					'type_code', i.type_code,
+					'state', i.state,
+					'issuer', i.issuer) ORDER BY i.position)
+				FROM npi_organization_identifiers i WHERE i.npi_id = o.npi_id))`)
+	if err != nil {
+		_ = l.rollback()
+		return summary, errors.Wrap(err, "error finding updated NPI organizations")
+	}
+	_, err = l.tx.ExecContext(ctx, `
+		UPDATE npi_organizations_staging s
+		SET merge = 'insert'
+		WHERE NOT s.deactivation_only
+		AND NOT EXISTS (SELECT 1 FROM npi_organizations o WHERE o.npi_id = s.npi_id)`)
+	if err != nil {
+		_ = l.rollback()
+		return summary, errors.Wrap(err, "error finding new NPI organizations")
+	}
+
+	res, err := l.tx.ExecContext(ctx, `
+		UPDATE npi_organizations o
+		SET name = s.name,
+			secondary_name = s.secondary_name,
+			location = s.location,
+			mailing_location = s.mailing_location,
+			taxonomy = s.taxonomy,
+			authorized_official = s.authorized_official,
+			normalized_name = s.normalized_name,
+			normalized_secondary_name = s.normalized_secondary_name,
+			last_update_date = s.last_update_date,
+			deactivation_date = s.deactivation_date,
+			reactivation_date = s.reactivation_date
+		FROM npi_organizations_staging s
+		WHERE o.npi_id = s.npi_id
+		AND s.merge = 'update'`)
+	if err != nil {
+		_ = l.rollback()
+		return summary, errors.Wrap(err, "error updating NPI organizations")
+	}
+	summary.Updated, err = rowsAffected(res)
+	if err != nil {
+		_ = l.rollback()
+		return summary, err
+	}
+
+	res, err = l.tx.ExecContext(ctx, `
+		UPDATE npi_organizations o
+		SET deactivation_date = s.deactivation_date,
+			reactivation_date = s.reactivation_date
+		FROM npi_organizations_staging s
+		WHERE o.npi_id = s.npi_id
+		AND NOT `+sprintfActive("s")+`
+		AND `+sprintfActive("o"))
+	if err != nil {
+		_ = l.rollback()
+		return summary, errors.Wrap(err, "error deactivating NPI organizations")
+	}
+	summary.Deactivated, err = rowsAffected(res)
+	if err != nil {
+		_ = l.rollback()
+		return summary, err
+	}
+
+	res, err = l.tx.ExecContext(ctx, `
+		INSERT INTO npi_organizations (
+			npi_id,
+			name,
+			secondary_name,
+			location,
+			mailing_location,
+			taxonomy,
+			authorized_official,
+			normalized_name,
+			normalized_secondary_name,
+			last_update_date,
+			deactivation_date,
+			reactivation_date)
+		SELECT
+			s.npi_id,
+			s.name,
+			s.secondary_name,
+			s.location,
+			s.mailing_location,
+			s.taxonomy,
+			s.authorized_official,
+			s.normalized_name,
+			s.normalized_secondary_name,
+			s.last_update_date,
+			s.deactivation_date,
+			s.reactivation_date
+		FROM npi_organizations_staging s
+		WHERE s.merge = 'insert'`)
+	if err != nil {
+		_ = l.rollback()
+		return summary, errors.Wrap(err, "error adding NPI organizations")
+	}
+	summary.Added, err = rowsAffected(res)
+	if err != nil {
+		_ = l.rollback()
+		return summary, err
+	}
+
+	err = l.mergeChildren(ctx)
+	if err != nil {
+		_ = l.rollback()
+		return summary, err
+	}
+
+	err = l.commit(ctx)
+	if err != nil {
+		return NPIOrganizationLoadSummary{}, errors.Wrap(err, "error committing NPI organization load")
+	}
+	return summary, nil
+}
+
+// commit commits the load's transaction, or releases the load's savepoint if it joined an existing transaction. The
+// staging table is only dropped by committing, so it is dropped here when the load joined a transaction.
+func (l *NPIOrganizationLoad) commit(ctx context.Context) error {
+	if !l.nested {
+		return l.tx.Commit()
+	}
+	_, err := l.tx.ExecContext(ctx, `DROP TABLE npi_organizations_staging`)
+	if err != nil {
+		_ = l.rollback()
+		return err
+	}
+	_, err = l.tx.ExecContext(ctx, `RELEASE SAVEPOINT npi_organization_load`)
+	return err
+}
+
+// rollback rolls back the load's transaction, or rolls back to the load's savepoint if it joined an existing
+// transaction.
+func (l *NPIOrganizationLoad) rollback() error {
+	if !l.nested {
+		return l.tx.Rollback()
+	}
+	_, err := l.tx.Exec(`ROLLBACK TO SAVEPOINT npi_organization_load`)
+	if err != nil {
+		return err
+	}
+	_, err = l.tx.Exec(`RELEASE SAVEPOINT npi_organization_load`)
+	return err
+}
+
+// mergeChildren replaces the taxonomies and identifiers of the new and updated organizations with the staged
+// taxonomies and identifiers.
+func (l *NPIOrganizationLoad) mergeChildren(ctx context.Context) error {
+	_, err := l.tx.ExecContext(ctx, `
+		DELETE FROM npi_organization_taxonomies t
+		USING npi_organizations_staging s
+		WHERE t.npi_id = s.npi_id AND s.merge IS NOT NULL`)
+	if err != nil {
+		return errors.Wrap(err, "error removing NPI organization taxonomies")
+	}
+	_, err = l.tx.ExecContext(ctx, `
+		INSERT INTO npi_organization_taxonomies (npi_id, position, code, license_number, license_state, is_primary)
+		SELECT s.npi_id, t.position, t.code, t.license_number, t.license_state, t.is_primary
+		FROM npi_organizations_staging s,
+			jsonb_to_recordset(s.taxonomies) AS t(position INTEGER, code VARCHAR, license_number VARCHAR, license_state VARCHAR, is_primary BOOLEAN)
+		WHERE s.merge IS NOT NULL`)
+	if err != nil {
+		return errors.Wrap(err, "error adding NPI organization taxonomies")
+	}
+
+	_, err = l.tx.ExecContext(ctx, `
+		DELETE FROM npi_organization_identifiers i
+		USING npi_organizations_staging s
+		WHERE i.npi_id = s.npi_id AND s.merge IS NOT NULL`)
+	if err != nil {
+		return errors.Wrap(err, "error removing NPI organization identifiers")
+	}
+	_, err = l.tx.ExecContext(ctx, `
+		INSERT INTO npi_organization_identifiers (npi_id, position, identifier, type_code, state, issuer)
+		SELECT s.npi_id, i.position, i.identifier, i.type_code, i.state, i.issuer
+		FROM npi_organizations_staging s,
+			jsonb_to_recordset(s.identifiers) AS i(position INTEGER, identifier VARCHAR, type_code VARCHAR, state VARCHAR, issuer VARCHAR)
+		WHERE s.merge IS NOT NULL`)
+	if err != nil {
+		return errors.Wrap(err, "error adding NPI organization identifiers")
+	}
+	return nil
+}
+
+// Rollback abandons the load without changing the npi_organizations table.
+func (l *NPIOrganizationLoad) Rollback() error {
+	_ = l.copy.Close()
+	return l.rollback()
+}
+
+// stagedNPITaxonomy and stagedNPIIdentifier have the same JSON form as the rows of the npi_organization_taxonomies
+// and npi_organization_identifiers tables.
+type stagedNPITaxonomy struct {
+	Position      int    `json:"position"`
+	Code          string `json:"code"`
+	LicenseNumber string `json:"license_number"`
+	LicenseState  string `json:"license_state"`
+	IsPrimary     bool   `json:"is_primary"`
+}
+
+type stagedNPIIdentifier struct {
+	Position   int    `json:"position"`
+	Identifier string `json:"identifier"`
+	TypeCode   string `json:"type_code"`
+	State      string `json:"state"`
+	Issuer     string `json:"issuer"`
+}
+
+// marshalStagedNPIOrganizationChildren returns nil instead of an empty array when the organization has no
+// taxonomies or identifiers, which is what aggregating no rows returns.
+func marshalStagedNPIOrganizationChildren(org *endpointmanager.NPIOrganization) (interface{}, interface{}, error) {
+	var taxonomiesJSON, identifiersJSON interface{}
+	if len(org.Taxonomies) > 0 {
+		var taxonomies []stagedNPITaxonomy
+		for i, taxonomy := range org.Taxonomies {
+			taxonomies = append(taxonomies, stagedNPITaxonomy{
+				Position:      i + 1,
+				Code:          taxonomy.Code,
+				LicenseNumber: taxonomy.LicenseNumber,
+				LicenseState:  taxonomy.LicenseState,
+				IsPrimary:     taxonomy.Primary,
+			})
+		}
+		b, err := json.Marshal(taxonomies)
+		if err != nil {
+			return nil, nil, err
+		}
+		taxonomiesJSON = string(b)
+	}
+	if len(org.Identifiers) > 0 {
+		var identifiers []stagedNPIIdentifier
+		for i, identifier := range org.Identifiers {
+			identifiers = append(identifiers, stagedNPIIdentifier{
+				Position:   i + 1,
+				Identifier: identifier.Identifier,
+				TypeCode:   identifier.TypeCode,
+				State:      identifier.State,
+				Issuer:     identifier.Issuer,
+			})
+		}
+		b, err := json.Marshal(identifiers)
+		if err != nil {
+			return nil, nil, err
+		}
+		identifiersJSON = string(b)
+	}
+	return taxonomiesJSON, identifiersJSON, nil
+}
+
+func sprintfActive(table string) string {
+	return fmt.Sprintf(npiOrganizationActive, table)
+}
+
+func rowsAffected(res sql.Result) (int, error) {
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+func nullDate(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Format("2006-01-02")
+}
@@ -3,9 +3,11 @@ package postgresql
 import (
 	"context"
 	"encoding/json"
+	"strings"
 
 	"database/sql"
 
+	"github.com/lib/pq"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
 )
 
@@ -19,12 +21,19 @@ var linkNPIOrganizationToFHIREndpointStatement *sql.Stmt
 var getNPIOrganizationFHIREndpointLinkStatement *sql.Stmt
 var updateNPIOrganizationFHIREndpointLinkStatement *sql.Stmt
 var deleteNPIOrganizationFHIREndpointLinkStatement *sql.Stmt
+var deleteNPIOrganizationTaxonomiesStatement *sql.Stmt
+var addNPIOrganizationTaxonomyStatement *sql.Stmt
+var deleteNPIOrganizationIdentifiersStatement *sql.Stmt
+var addNPIOrganizationIdentifierStatement *sql.Stmt
 
 // GetNPIOrganizationByNPIID gets a NPIOrganization from the database using the NPI id as a key.
 // If the NPIOrganization does not exist in the database, sql.ErrNoRows will be returned.
 func (s *Store) GetNPIOrganizationByNPIID(ctx context.Context, npiID string) (*endpointmanager.NPIOrganization, error) {
 	var org endpointmanager.NPIOrganization
 	var locationJSON []byte
+	var mailingLocationJSON []byte
+	var authorizedOfficialJSON []byte
+	var lastUpdate, deactivation, reactivation sql.NullTime
 
 	sqlStatement := `
 	SELECT
@@ -33,13 +42,18 @@ func (s *Store) GetNPIOrganizationByNPIID(ctx context.Context, npiID string) (*e
 		name,
 		secondary_name,
 		location,
+		mailing_location,
 		taxonomy,
+		authorized_official,
 		normalized_name,
 		normalized_secondary_name,
+		last_update_date,
+		deactivation_date,
+		reactivation_date,
 		created_at,
 		updated_at
 	FROM npi_organizations WHERE npi_id=$1`
-	row := s.DB.QueryRowContext(ctx, sqlStatement, npiID)
+	row := s.querier().QueryRowContext(ctx, sqlStatement, npiID)
 
 	err := row.Scan(
 		&org.ID,
@@ -47,9 +61,14 @@ func (s *Store) GetNPIOrganizationByNPIID(ctx context.Context, npiID string) (*e
 		&org.Name,
 		&org.SecondaryName,
 		&locationJSON,
+		&mailingLocationJSON,
 		&org.Taxonomy,
+		&authorizedOfficialJSON,
 		&org.NormalizedName,
 		&org.NormalizedSecondaryName,
+		&lastUpdate,
+		&deactivation,
+		&reactivation,
 		&org.CreatedAt,
 		&org.UpdatedAt)
 
@@ -57,19 +76,29 @@ func (s *Store) GetNPIOrganizationByNPIID(ctx context.Context, npiID string) (*e
 		return nil, err
 	}
 
+	org.LastUpdateDate = lastUpdate.Time
+	org.DeactivationDate = deactivation.Time
+	org.ReactivationDate = reactivation.Time
+
 	err = json.Unmarshal(locationJSON, &org.Location)
 
 	if err != nil {
 		return nil, err
 	}
 
+	err = s.getNPIOrganizationDetails(ctx, &org, mailingLocationJSON, authorizedOfficialJSON)
+
+	if err != nil {
+		return nil, err
+	}
+
 	return &org, err
 }
 
 // DeleteAllNPIOrganizations will remove all rows from the npi_organizations table
 func (s *Store) DeleteAllNPIOrganizations(ctx context.Context) error {
 	sqlStatement := `DELETE FROM npi_organizations`
-	_, err := s.DB.ExecContext(ctx, sqlStatement)
+	_, err := s.querier().ExecContext(ctx, sqlStatement)
 	return err
 }
 
@@ -78,6 +107,9 @@ func (s *Store) DeleteAllNPIOrganizations(ctx context.Context) error {
 func (s *Store) GetNPIOrganization(ctx context.Context, id int) (*endpointmanager.NPIOrganization, error) {
 	var org endpointmanager.NPIOrganization
 	var locationJSON []byte
+	var mailingLocationJSON []byte
+	var authorizedOfficialJSON []byte
+	var lastUpdate, deactivation, reactivation sql.NullTime
 
 	sqlStatement := `
 	SELECT
@@ -86,13 +118,18 @@ func (s *Store) GetNPIOrganization(ctx context.Context, id int) (*endpointmanage
 		name,
 		secondary_name,
 		location,
+		mailing_location,
 		taxonomy,
+		authorized_official,
 		normalized_name,
 		normalized_secondary_name,
+		last_update_date,
+		deactivation_date,
+		reactivation_date,
 		created_at,
 		updated_at
 	FROM npi_organizations WHERE id=$1`
-	row := s.DB.QueryRowContext(ctx, sqlStatement, id)
+	row := s.querier().QueryRowContext(ctx, sqlStatement, id)
 
 	err := row.Scan(
 		&org.ID,
@@ -100,9 +137,14 @@ func (s *Store) GetNPIOrganization(ctx context.Context, id int) (*endpointmanage
 		&org.Name,
 		&org.SecondaryName,
 		&locationJSON,
+		&mailingLocationJSON,
 		&org.Taxonomy,
+		&authorizedOfficialJSON,
 		&org.NormalizedName,
 		&org.NormalizedSecondaryName,
+		&lastUpdate,
+		&deactivation,
+		&reactivation,
 		&org.CreatedAt,
 		&org.UpdatedAt)
 
@@ -110,12 +152,22 @@ func (s *Store) GetNPIOrganization(ctx context.Context, id int) (*endpointmanage
 		return nil, err
 	}
 
+	org.LastUpdateDate = lastUpdate.Time
+	org.DeactivationDate = deactivation.Time
+	org.ReactivationDate = reactivation.Time
+
 	err = json.Unmarshal(locationJSON, &org.Location)
 
 	if err != nil {
 		return nil, err
 	}
 
+	err = s.getNPIOrganizationDetails(ctx, &org, mailingLocationJSON, authorizedOfficialJSON)
+
+	if err != nil {
+		return nil, err
+	}
+
 	return &org, err
 }
 
@@ -126,19 +178,29 @@ func (s *Store) AddNPIOrganization(ctx context.Context, org *endpointmanager.NPI
 		return err
 	}
 
-	row := addNPIOrganizationStatement.QueryRowContext(ctx,
+	mailingLocationJSON, authorizedOfficialJSON, err := marshalNPIOrganizationDetails(org)
+	if err != nil {
+		return err
+	}
+
+	row := s.stmt(ctx, addNPIOrganizationStatement).QueryRowContext(ctx,
 		//sqlStatement,
 		org.NPI_ID,
 		org.Name,
 		org.SecondaryName,
 		locationJSON,
+		mailingLocationJSON,
 		org.Taxonomy,
+		authorizedOfficialJSON,
 		org.NormalizedName,
 		org.NormalizedSecondaryName)
 
 	err = row.Scan(&org.ID)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return s.saveNPIOrganizationChildren(ctx, org)
 }
 
 // UpdateNPIOrganization updates the NPIOrganization in the database using the NPIOrganization's database ID as the key.
@@ -148,17 +210,27 @@ func (s *Store) UpdateNPIOrganization(ctx context.Context, org *endpointmanager.
 		return err
 	}
 
-	_, err = updateNPIOrganizationStatement.ExecContext(ctx,
+	mailingLocationJSON, authorizedOfficialJSON, err := marshalNPIOrganizationDetails(org)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.stmt(ctx, updateNPIOrganizationStatement).ExecContext(ctx,
 		org.ID,
 		org.NPI_ID,
 		org.Name,
 		org.SecondaryName,
 		locationJSON,
+		mailingLocationJSON,
 		org.Taxonomy,
+		authorizedOfficialJSON,
 		org.NormalizedName,
 		org.NormalizedSecondaryName)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return s.saveNPIOrganizationChildren(ctx, org)
 }
 
 // UpdateNPIOrganizationByNPIID updates the NPIOrganization in the database using the NPIOrganization's NPIID as the key.
@@ -168,30 +240,42 @@ func (s *Store) UpdateNPIOrganizationByNPIID(ctx context.Context, org *endpointm
 		return err
 	}
 
-	_, err = updateNPIOrganizationByNPIIDStatement.ExecContext(ctx,
+	mailingLocationJSON, authorizedOfficialJSON, err := marshalNPIOrganizationDetails(org)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.stmt(ctx, updateNPIOrganizationByNPIIDStatement).ExecContext(ctx,
 		org.NPI_ID,
 		org.Name,
 		org.SecondaryName,
 		locationJSON,
+		mailingLocationJSON,
 		org.Taxonomy,
+		authorizedOfficialJSON,
 		org.NormalizedName,
 		org.NormalizedSecondaryName)
+	if err != nil {
+		return err
+	}
 
-	return err
+	return s.saveNPIOrganizationChildren(ctx, org)
 }
 
 // DeleteNPIOrganization deletes the NPIOrganization from the database using the NPIOrganization's database ID as the key.
 func (s *Store) DeleteNPIOrganization(ctx context.Context, org *endpointmanager.NPIOrganization) error {
-	_, err := deleteNPIOrganizationStatement.ExecContext(ctx, org.ID)
+	_, err := s.stmt(ctx, deleteNPIOrganizationStatement).ExecContext(ctx, org.ID)
 
 	return err
 }
 
-// GetAllNPIOrganizationNormalizedNames gets list of all primary and secondary names
+// GetAllNPIOrganizationNormalizedNames gets list of all primary and secondary names along with the location of each organization.
+// Organizations whose NPI has been deactivated are not included.
 func (s *Store) GetAllNPIOrganizationNormalizedNames(ctx context.Context) ([]*endpointmanager.NPIOrganization, error) {
 	sqlStatement := `
-	SELECT id, normalized_name, normalized_secondary_name, npi_id FROM npi_organizations`
-	rows, err := s.DB.QueryContext(ctx, sqlStatement)
+	SELECT id, normalized_name, normalized_secondary_name, npi_id, location FROM npi_organizations
+	WHERE deactivation_date IS NULL OR reactivation_date >= deactivation_date`
+	rows, err := s.querier().QueryContext(ctx, sqlStatement)
 	if err != nil {
 		return nil, err
 	}
@@ -199,18 +283,60 @@ func (s *Store) GetAllNPIOrganizationNormalizedNames(ctx context.Context) ([]*en
 	defer rows.Close()
 	for rows.Next() {
 		var org endpointmanager.NPIOrganization
-		err = rows.Scan(&org.ID, &org.NormalizedName, &org.NormalizedSecondaryName, &org.NPI_ID)
+		var locationJSON []byte
+		err = rows.Scan(&org.ID, &org.NormalizedName, &org.NormalizedSecondaryName, &org.NPI_ID, &locationJSON)
 		if err != nil {
 			return nil, err
 		}
+		if locationJSON != nil {
+			err = json.Unmarshal(locationJSON, &org.Location)
+			if err != nil {
+				return nil, err
+			}
+		}
 		orgs = append(orgs, &org)
 	}
 	return orgs, nil
 }
 
+// GetNPIOrganizationNPIIDsByProviderType returns the NPI IDs of the organizations with a taxonomy whose NUCC
+// grouping, classification, specialization or display name matches one of the provider types, ignoring case. For
+// example, "Hospitals" matches all hospitals and "Pharmacy" matches all pharmacies.
+func (s *Store) GetNPIOrganizationNPIIDsByProviderType(ctx context.Context, providerTypes []string) (map[string]bool, error) {
+	var types []string
+	for _, providerType := range providerTypes {
+		types = append(types, strings.ToLower(strings.TrimSpace(providerType)))
+	}
+
+	sqlStatement := `
+	SELECT DISTINCT taxonomies.npi_id
+	FROM npi_organization_taxonomies AS taxonomies
+	JOIN nucc_taxonomies AS nucc ON taxonomies.code = nucc.code
+	WHERE LOWER(nucc.grouping_name) = ANY($1)
+		OR LOWER(nucc.classification) = ANY($1)
+		OR LOWER(nucc.specialization) = ANY($1)
+		OR LOWER(nucc.display_name) = ANY($1)`
+	rows, err := s.querier().QueryContext(ctx, sqlStatement, pq.Array(types))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	npiIDs := make(map[string]bool)
+	for rows.Next() {
+		var npiID string
+		err = rows.Scan(&npiID)
+		if err != nil {
+			return nil, err
+		}
+		npiIDs[npiID] = true
+	}
+	return npiIDs, rows.Err()
+}
+
 // LinkNPIOrganizationToFHIREndpoint links an npi organization database id to a FHIR endpoint database id
 func (s *Store) LinkNPIOrganizationToFHIREndpoint(ctx context.Context, orgID string, endpointURL string, confidence float64) error {
-	_, err := linkNPIOrganizationToFHIREndpointStatement.ExecContext(ctx,
+	_, err := s.stmt(ctx, linkNPIOrganizationToFHIREndpointStatement).ExecContext(ctx,
 		orgID,
 		endpointURL,
 		confidence)
@@ -224,7 +350,7 @@ func (s *Store) GetNPIOrganizationFHIREndpointLink(ctx context.Context, orgID st
 	var retEndpointURL string
 	var retConfidence float64
 
-	row := getNPIOrganizationFHIREndpointLinkStatement.QueryRowContext(ctx,
+	row := s.stmt(ctx, getNPIOrganizationFHIREndpointLinkStatement).QueryRowContext(ctx,
 		orgID,
 		endpointURL)
 
@@ -239,7 +365,7 @@ func (s *Store) GetNPIOrganizationFHIREndpointLink(ctx context.Context, orgID st
 
 // UpdateNPIOrganizationFHIREndpointLink updates the confidence value for the link between the organization id and the endpoint url.
 func (s *Store) UpdateNPIOrganizationFHIREndpointLink(ctx context.Context, orgID string, endpointURL string, confidence float64) error {
-	_, err := updateNPIOrganizationFHIREndpointLinkStatement.ExecContext(ctx,
+	_, err := s.stmt(ctx, updateNPIOrganizationFHIREndpointLinkStatement).ExecContext(ctx,
 		orgID,
 		endpointURL,
 		confidence)
@@ -248,7 +374,7 @@ func (s *Store) UpdateNPIOrganizationFHIREndpointLink(ctx context.Context, orgID
 
 // DeleteNPIOrganizationFHIREndpointLink deletes the link between the organization id and the endpoint url.
 func (s *Store) DeleteNPIOrganizationFHIREndpointLink(ctx context.Context, orgID string, endpointURL string) error {
-	_, err := deleteNPIOrganizationFHIREndpointLinkStatement.ExecContext(ctx,
+	_, err := s.stmt(ctx, deleteNPIOrganizationFHIREndpointLinkStatement).ExecContext(ctx,
 		orgID,
 		endpointURL)
 	return err
@@ -262,10 +388,12 @@ func prepareNPIOrganizationStatements(s *Store) error {
 			name,
 			secondary_name,
 			location,
+			mailing_location,
 			taxonomy,
+			authorized_official,
 			normalized_name,
 			normalized_secondary_name)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id`)
 	if err != nil {
 		return err
@@ -276,9 +404,11 @@ func prepareNPIOrganizationStatements(s *Store) error {
 		        name = $3,
 		        secondary_name = $4,
 		        location = $5,
-		        taxonomy = $6,
-		        normalized_name = $7,
-		        normalized_secondary_name = $8
+		        mailing_location = $6,
+		        taxonomy = $7,
+		        authorized_official = $8,
+		        normalized_name = $9,
+		        normalized_secondary_name = $10
 		WHERE id=$1`)
 	if err != nil {
 		return err
@@ -288,9 +418,11 @@ func prepareNPIOrganizationStatements(s *Store) error {
 		SET name = $2,
 			secondary_name = $3,
 			location = $4,
-			taxonomy = $5,
-			normalized_name = $6,
-			normalized_secondary_name = $7
+			mailing_location = $5,
+			taxonomy = $6,
+			authorized_official = $7,
+			normalized_name = $8,
+			normalized_secondary_name = $9
 		WHERE npi_id=$1`)
 	if err != nil {
 		return err
@@ -334,5 +466,176 @@ func prepareNPIOrganizationStatements(s *Store) error {
 	if err != nil {
 		return err
 	}
+	deleteNPIOrganizationTaxonomiesStatement, err = s.DB.Prepare(`
+		DELETE FROM npi_organization_taxonomies
+		WHERE npi_id = $1`)
+	if err != nil {
+		return err
+	}
+	addNPIOrganizationTaxonomyStatement, err = s.DB.Prepare(`
+		INSERT INTO npi_organization_taxonomies (
+			npi_id,
+			position,
+			code,
+			license_number,
+			license_state,
+			is_primary)
+		VALUES ($1, $2, $3, $4, $5, $6)`)
+	if err != nil {
+		return err
+	}
+	deleteNPIOrganizationIdentifiersStatement, err = s.DB.Prepare(`
+		DELETE FROM npi_organization_identifiers
+		WHERE npi_id = $1`)
+	if err != nil {
+		return err
+	}
+	addNPIOrganizationIdentifierStatement, err = s.DB.Prepare(`
+		INSERT INTO npi_organization_identifiers (
+			npi_id,
+			position,
+			identifier,
+			type_code,
+			state,
+			issuer)
+		VALUES ($1, $2, $3, $4, $5, $6)`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func marshalNPIOrganizationDetails(org *endpointmanager.NPIOrganization) ([]byte, []byte, error) {
+	mailingLocationJSON, err := json.Marshal(org.MailingLocation)
+	if err != nil {
+		return nil, nil, err
+	}
+	authorizedOfficialJSON, err := json.Marshal(org.AuthorizedOfficial)
+	if err != nil {
+		return nil, nil, err
+	}
+	return mailingLocationJSON, authorizedOfficialJSON, nil
+}
+
+// getNPIOrganizationDetails sets the mailing location, authorized official, taxonomies and identifiers of the
+// organization. The taxonomies are joined with the NUCC code set.
+func (s *Store) getNPIOrganizationDetails(ctx context.Context, org *endpointmanager.NPIOrganization, mailingLocationJSON []byte, authorizedOfficialJSON []byte) error {
+	if mailingLocationJSON != nil {
+		err := json.Unmarshal(mailingLocationJSON, &org.MailingLocation)
+		if err != nil {
+			return err
+		}
+	}
+	if authorizedOfficialJSON != nil {
+		err := json.Unmarshal(authorizedOfficialJSON, &org.AuthorizedOfficial)
+		if err != nil {
+			return err
+		}
+	}
+
+	sqlStatement := `
+	SELECT
+		taxonomies.code,
+		taxonomies.license_number,
+		taxonomies.license_state,
+		taxonomies.is_primary,
+		COALESCE(nucc.grouping_name, ''),
+		COALESCE(nucc.classification, ''),
+		COALESCE(nucc.specialization, ''),
+		COALESCE(nucc.display_name, '')
+	FROM npi_organization_taxonomies AS taxonomies
+	LEFT JOIN nucc_taxonomies AS nucc ON taxonomies.code = nucc.code
+	WHERE taxonomies.npi_id = $1
+	ORDER BY taxonomies.position`
+	rows, err := s.querier().QueryContext(ctx, sqlStatement, org.NPI_ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var taxonomy endpointmanager.NPITaxonomy
+		err = rows.Scan(
+			&taxonomy.Code,
+			&taxonomy.LicenseNumber,
+			&taxonomy.LicenseState,
+			&taxonomy.Primary,
+			&taxonomy.Grouping,
+			&taxonomy.Classification,
+			&taxonomy.Specialization,
+			&taxonomy.DisplayName)
+		if err != nil {
+			return err
+		}
+		org.Taxonomies = append(org.Taxonomies, taxonomy)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	sqlStatement = `
+	SELECT
+		identifier,
+		type_code,
+		state,
+		issuer
+	FROM npi_organization_identifiers
+	WHERE npi_id = $1
+	ORDER BY position`
+	idRows, err := s.querier().QueryContext(ctx, sqlStatement, org.NPI_ID)
+	if err != nil {
+		return err
+	}
+	defer idRows.Close()
+	for idRows.Next() {
+		var identifier endpointmanager.NPIIdentifier
+		err = idRows.Scan(
+			&identifier.Identifier,
+			&identifier.TypeCode,
+			&identifier.State,
+			&identifier.Issuer)
+		if err != nil {
+			return err
+		}
+		org.Identifiers = append(org.Identifiers, identifier)
+	}
+	return idRows.Err()
+}
+
+// saveNPIOrganizationChildren replaces the stored taxonomies and identifiers of the organization with the
+// organization's taxonomies and identifiers.
+func (s *Store) saveNPIOrganizationChildren(ctx context.Context, org *endpointmanager.NPIOrganization) error {
+	_, err := s.stmt(ctx, deleteNPIOrganizationTaxonomiesStatement).ExecContext(ctx, org.NPI_ID)
+	if err != nil {
+		return err
+	}
+	for i, taxonomy := range org.Taxonomies {
+		_, err = s.stmt(ctx, addNPIOrganizationTaxonomyStatement).ExecContext(ctx,
+			org.NPI_ID,
+			i+1,
+			taxonomy.Code,
+			taxonomy.LicenseNumber,
+			taxonomy.LicenseState,
+			taxonomy.Primary)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.stmt(ctx, deleteNPIOrganizationIdentifiersStatement).ExecContext(ctx, org.NPI_ID)
+	if err != nil {
+		return err
+	}
+	for i, identifier := range org.Identifiers {
+		_, err = s.stmt(ctx, addNPIOrganizationIdentifierStatement).ExecContext(ctx,
+			org.NPI_ID,
+			i+1,
+			identifier.Identifier,
+			identifier.TypeCode,
+			identifier.State,
+			identifier.Issuer)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -4,8 +4,11 @@ package postgresql
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
 	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
@@ -204,6 +207,9 @@ func Test_PersistNPIOrganization(t *testing.T) {
 			if org.NormalizedSecondaryName != eSec {
 				t.Errorf("Expected normalized secondary name to be %s. Got %s.", eSec, org.NormalizedSecondaryName)
 			}
+			if !org.Location.Equal(npio1.Location) {
+				t.Errorf("Expected location to be %v. Got %v.", npio1.Location, org.Location)
+			}
 		}
 		if org.ID == npio2.ID {
 			ePrim := "A PRIMARY NAME"
@@ -288,6 +294,95 @@ func Test_PersistNPIOrganization(t *testing.T) {
 	}
 }
 
+func Test_PersistNPIOrganizationDetails(t *testing.T) {
+	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
+	defer teardown(t, store.DB)
+
+	var err error
+	ctx := context.Background()
+
+	var hospital = &endpointmanager.NPIOrganization{
+		NPI_ID: "1",
+		Name:   "Hospital #1 of America",
+		Location: &endpointmanager.Location{
+			Address1: "123 Gov Way",
+			City:     "A City",
+			State:    "AK",
+			ZipCode:  "00000"},
+		MailingLocation: &endpointmanager.Location{
+			Address1: "PO Box 1",
+			City:     "A City",
+			State:    "AK",
+			ZipCode:  "00000"},
+		Taxonomy: "282N00000X",
+		Taxonomies: []endpointmanager.NPITaxonomy{
+			{Code: "282N00000X", LicenseNumber: "123", LicenseState: "AK", Primary: true},
+			{Code: "261QP2300X"}},
+		Identifiers: []endpointmanager.NPIIdentifier{
+			{Identifier: "456", TypeCode: "05", State: "AK"},
+			{Identifier: "789", TypeCode: "01", State: "AK", Issuer: "BLUE CROSS"}},
+		AuthorizedOfficial: &endpointmanager.NPIAuthorizedOfficial{
+			FirstName: "Jane",
+			LastName:  "Doe",
+			Title:     "CEO"},
+		NormalizedName: "HOSPITAL  OF AMERICA"}
+
+	var pharmacy = &endpointmanager.NPIOrganization{
+		NPI_ID:         "2",
+		Name:           "Pharmacy of America",
+		Taxonomy:       "3336C0003X",
+		Taxonomies:     []endpointmanager.NPITaxonomy{{Code: "3336C0003X", Primary: true}},
+		NormalizedName: "PHARMACY OF AMERICA"}
+
+	nuccTaxonomies := []*endpointmanager.NUCCTaxonomy{
+		{Code: "282N00000X", Grouping: "Hospitals", Classification: "General Acute Care Hospital", DisplayName: "General Acute Care Hospital"},
+		{Code: "3336C0003X", Grouping: "Suppliers", Classification: "Pharmacy", Specialization: "Community/Retail Pharmacy", DisplayName: "Community/Retail Pharmacy"},
+	}
+	for _, taxonomy := range nuccTaxonomies {
+		err = store.AddOrUpdateNUCCTaxonomy(ctx, taxonomy)
+		th.Assert(t, err == nil, err)
+	}
+	nucc, err := store.GetNUCCTaxonomy(ctx, "282N00000X")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, *nucc == *nuccTaxonomies[0], fmt.Sprintf("Expected NUCC taxonomy %+v, got %+v", *nuccTaxonomies[0], *nucc))
+
+	err = store.AddNPIOrganization(ctx, hospital)
+	th.Assert(t, err == nil, err)
+	err = store.AddNPIOrganization(ctx, pharmacy)
+	th.Assert(t, err == nil, err)
+
+	hospitalGet, err := store.GetNPIOrganizationByNPIID(ctx, hospital.NPI_ID)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, hospitalGet.Equal(hospital), fmt.Sprintf("retrieved organization %+v is not equal to saved organization %+v", hospitalGet, hospital))
+	// the taxonomies are joined with the NUCC code set
+	th.Assert(t, hospitalGet.Taxonomies[0].Grouping == "Hospitals", fmt.Sprintf("Expected the grouping of the taxonomy to be Hospitals, got %s", hospitalGet.Taxonomies[0].Grouping))
+	th.Assert(t, hospitalGet.Taxonomies[1].DisplayName == "", "Expected a taxonomy that is not in the code set to have no display name")
+
+	// updating the organization replaces its taxonomies and identifiers
+	hospital.Taxonomies = hospital.Taxonomies[:1]
+	hospital.Identifiers = nil
+	err = store.UpdateNPIOrganizationByNPIID(ctx, hospital)
+	th.Assert(t, err == nil, err)
+	hospitalGet, err = store.GetNPIOrganization(ctx, hospital.ID)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, hospitalGet.Equal(hospital), fmt.Sprintf("retrieved organization %+v is not equal to updated organization %+v", hospitalGet, hospital))
+
+	npiIDs, err := store.GetNPIOrganizationNPIIDsByProviderType(ctx, []string{"hospitals"})
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(npiIDs) == 1 && npiIDs["1"], fmt.Sprintf("Expected only the hospital to be a hospital, got %v", npiIDs))
+	npiIDs, err = store.GetNPIOrganizationNPIIDsByProviderType(ctx, []string{"Pharmacy", "Community/Retail Pharmacy"})
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(npiIDs) == 1 && npiIDs["2"], fmt.Sprintf("Expected only the pharmacy to be a pharmacy, got %v", npiIDs))
+
+	// the taxonomies and identifiers are deleted with the organization
+	err = store.DeleteAllNPIOrganizations(ctx)
+	th.Assert(t, err == nil, err)
+	var count int
+	err = store.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM npi_organization_taxonomies").Scan(&count)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, count == 0, fmt.Sprintf("Expected the taxonomies to be deleted, got %d", count))
+}
+
 func Test_LinkNPIOrganizationToFHIREndpoint(t *testing.T) {
 	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
 	defer teardown(t, store.DB)
@@ -386,3 +481,47 @@ func Test_LinkNPIOrganizationToFHIREndpoint(t *testing.T) {
 	th.Assert(t, sEpURL == endpoint1.URL, fmt.Sprintf("expected stored url '%s' to be the same as the url that was stored '%s'.", sEpURL, endpoint1.URL))
 	th.Assert(t, sConfidence == .5, fmt.Sprintf("expected stored confidence '%f' to be the same as the confidence that was stored '%f'.", sConfidence, .5))
 }
+
+func Test_NPIOrganizationLoadWithinTx(t *testing.T) {
+	SetupStore()
+	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
+	defer teardown(t, store.DB)
+
+	ctx := context.Background()
+	org := &endpointmanager.NPIOrganization{
+		NPI_ID:         "1",
+		Name:           "Hospital #1 of America",
+		Location:       &endpointmanager.Location{City: "A City", State: "AK", ZipCode: "00000"},
+		NormalizedName: "HOSPITAL  OF AMERICA"}
+	var zero time.Time
+
+	err := store.WithTx(ctx, func(txStore *Store) error {
+		// a load that is rolled back only undoes the load
+		load, err := txStore.BeginNPIOrganizationLoad(ctx)
+		th.Assert(t, err == nil, err)
+		err = load.AddOrganization(ctx, org, zero, zero, zero)
+		th.Assert(t, err == nil, err)
+		err = load.Rollback()
+		th.Assert(t, err == nil, err)
+		_, err = txStore.GetNPIOrganizationByNPIID(ctx, org.NPI_ID)
+		th.Assert(t, err == sql.ErrNoRows, fmt.Sprintf("expected the rolled back organization to not be stored, got %v", err))
+
+		// a second load can be run in the same transaction and is visible to it
+		load, err = txStore.BeginNPIOrganizationLoad(ctx)
+		th.Assert(t, err == nil, err)
+		err = load.AddOrganization(ctx, org, zero, zero, zero)
+		th.Assert(t, err == nil, err)
+		summary, err := load.Commit(ctx)
+		th.Assert(t, err == nil, err)
+		th.Assert(t, summary.Added == 1, fmt.Sprintf("expected 1 added organization, got %d", summary.Added))
+		_, err = txStore.GetNPIOrganizationByNPIID(ctx, org.NPI_ID)
+		th.Assert(t, err == nil, err)
+
+		return errors.New("rolling back the transaction")
+	})
+	th.Assert(t, err != nil, "expected the transaction's error")
+
+	// the committed load is rolled back along with the transaction it joined
+	_, err = store.GetNPIOrganizationByNPIID(ctx, org.NPI_ID)
+	th.Assert(t, err == sql.ErrNoRows, fmt.Sprintf("expected the organization to be rolled back with the transaction, got %v", err))
+}
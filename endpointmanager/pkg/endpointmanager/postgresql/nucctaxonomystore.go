@@ -0,0 +1,70 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+)
+
+var addOrUpdateNUCCTaxonomyStatement *sql.Stmt
+
+// GetNUCCTaxonomy gets the NUCCTaxonomy with the given code from the database. If the code is not in the
+// database, sql.ErrNoRows will be returned.
+func (s *Store) GetNUCCTaxonomy(ctx context.Context, code string) (*endpointmanager.NUCCTaxonomy, error) {
+	var taxonomy endpointmanager.NUCCTaxonomy
+
+	sqlStatement := `
+	SELECT
+		code,
+		grouping_name,
+		classification,
+		specialization,
+		display_name
+	FROM nucc_taxonomies WHERE code=$1`
+	row := s.querier().QueryRowContext(ctx, sqlStatement, code)
+
+	err := row.Scan(
+		&taxonomy.Code,
+		&taxonomy.Grouping,
+		&taxonomy.Classification,
+		&taxonomy.Specialization,
+		&taxonomy.DisplayName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &taxonomy, nil
+}
+
+// AddOrUpdateNUCCTaxonomy adds the NUCCTaxonomy to the database or updates the entry with the same code.
+func (s *Store) AddOrUpdateNUCCTaxonomy(ctx context.Context, taxonomy *endpointmanager.NUCCTaxonomy) error {
+	_, err := s.stmt(ctx, addOrUpdateNUCCTaxonomyStatement).ExecContext(ctx,
+		taxonomy.Code,
+		taxonomy.Grouping,
+		taxonomy.Classification,
+		taxonomy.Specialization,
+		taxonomy.DisplayName)
+	return err
+}
+
+func prepareNUCCTaxonomyStatements(s *Store) error {
+	var err error
+	addOrUpdateNUCCTaxonomyStatement, err = s.DB.Prepare(`
+		INSERT INTO nucc_taxonomies (
+			code,
+			grouping_name,
+			classification,
+			specialization,
+			display_name)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (code) DO UPDATE
+		SET grouping_name = EXCLUDED.grouping_name,
+			classification = EXCLUDED.classification,
+			specialization = EXCLUDED.specialization,
+			display_name = EXCLUDED.display_name`)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+)
+
+// organizationEndpointsQuery returns each link between an NPI organization and an endpoint along with the results
+// of the endpoint's most recent capability query for its default FHIR version, ordered by organization.
+const organizationEndpointsQuery = `
+	SELECT orgs.npi_id, COALESCE(orgs.name, ''), COALESCE(orgs.location->>'state', ''),
+		links.url, COALESCE(links.confidence, 0), COALESCE(vendors.name, ''), COALESCE(info.capability_fhir_version, ''),
+		COALESCE(metadata.http_response, 0), COALESCE(metadata.availability, 0)
+	FROM endpoint_organization AS links
+	JOIN npi_organizations AS orgs ON links.organization_npi_id = orgs.npi_id
+	LEFT JOIN fhir_endpoints_info AS info ON links.url = info.url AND info.requested_fhir_version = 'None'
+	LEFT JOIN fhir_endpoints_metadata AS metadata ON info.metadata_id = metadata.id
+	LEFT JOIN vendors ON info.vendor_id = vendors.id`
+
+const organizationEndpointsOrder = ` ORDER BY orgs.npi_id, links.url`
+
+// GetOrganizationEndpointHealth gets the health of the endpoints linked to each NPI organization that has linked
+// endpoints, ordered by NPI ID.
+func (s *Store) GetOrganizationEndpointHealth(ctx context.Context) ([]*endpointmanager.OrganizationEndpointHealth, error) {
+	return s.getOrganizationEndpointHealth(ctx, organizationEndpointsQuery+organizationEndpointsOrder)
+}
+
+// GetOrganizationEndpointHealthUsingState gets the health of the endpoints linked to each NPI organization in the
+// given two-letter state, ordered by NPI ID.
+func (s *Store) GetOrganizationEndpointHealthUsingState(ctx context.Context, state string) ([]*endpointmanager.OrganizationEndpointHealth, error) {
+	return s.getOrganizationEndpointHealth(ctx, organizationEndpointsQuery+` WHERE orgs.location->>'state' = $1`+organizationEndpointsOrder, state)
+}
+
+// GetOrganizationEndpointHealthUsingNPIID gets the health of the endpoints linked to the NPI organization with the
+// given NPI ID. If the organization has no linked endpoints, sql.ErrNoRows will be returned.
+func (s *Store) GetOrganizationEndpointHealthUsingNPIID(ctx context.Context, npiID string) (*endpointmanager.OrganizationEndpointHealth, error) {
+	orgs, err := s.getOrganizationEndpointHealth(ctx, organizationEndpointsQuery+` WHERE orgs.npi_id = $1`+organizationEndpointsOrder, npiID)
+	if err != nil {
+		return nil, err
+	}
+	if len(orgs) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return orgs[0], nil
+}
+
+func (s *Store) getOrganizationEndpointHealth(ctx context.Context, query string, args ...interface{}) ([]*endpointmanager.OrganizationEndpointHealth, error) {
+	rows, err := s.querier().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orgs []*endpointmanager.OrganizationEndpointHealth
+	var org *endpointmanager.OrganizationEndpointHealth
+	for rows.Next() {
+		var npiID, name, state string
+		var endpoint endpointmanager.OrganizationEndpoint
+		err = rows.Scan(
+			&npiID,
+			&name,
+			&state,
+			&endpoint.URL,
+			&endpoint.Confidence,
+			&endpoint.VendorName,
+			&endpoint.FHIRVersion,
+			&endpoint.HTTPResponse,
+			&endpoint.Availability)
+		if err != nil {
+			return nil, err
+		}
+		// the rows are ordered by organization, so a new NPI ID starts the next organization
+		if org == nil || org.NPIID != npiID {
+			org = &endpointmanager.OrganizationEndpointHealth{NPIID: npiID, Name: name, State: state}
+			orgs = append(orgs, org)
+		}
+		org.Endpoints = append(org.Endpoints, &endpoint)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, org := range orgs {
+		org.Summarize()
+	}
+	return orgs, nil
+}
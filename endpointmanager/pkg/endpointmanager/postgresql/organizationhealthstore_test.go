@@ -0,0 +1,96 @@
+// +build integration
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+)
+
+func Test_GetOrganizationEndpointHealth(t *testing.T) {
+	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
+	defer teardown(t, store.DB)
+
+	var err error
+	ctx := context.Background()
+
+	vendor := &endpointmanager.Vendor{Name: "Epic Systems Corporation", DeveloperCode: "A", CHPLID: 1}
+	err = store.AddVendor(ctx, vendor)
+	th.Assert(t, err == nil, err)
+
+	// org1 in MA has a working and a failing endpoint, org2 in AK only has a failing endpoint and org3 in MA has
+	// an endpoint that has not been queried
+	org1 := &endpointmanager.NPIOrganization{NPI_ID: "1", Name: "Org 1", Location: &endpointmanager.Location{State: "MA"}}
+	org2 := &endpointmanager.NPIOrganization{NPI_ID: "2", Name: "Org 2", Location: &endpointmanager.Location{State: "AK"}}
+	org3 := &endpointmanager.NPIOrganization{NPI_ID: "3", Name: "Org 3", Location: &endpointmanager.Location{State: "MA"}}
+	for _, org := range []*endpointmanager.NPIOrganization{org1, org2, org3} {
+		err = store.AddNPIOrganization(ctx, org)
+		th.Assert(t, err == nil, err)
+	}
+
+	addInfo := func(url string, fhirVersion string, httpResponse int) {
+		metadataID, err := store.AddFHIREndpointMetadata(ctx, &endpointmanager.FHIREndpointMetadata{URL: url, HTTPResponse: httpResponse, RequestedFhirVersion: "None"})
+		th.Assert(t, err == nil, err)
+		valResID, err := store.AddValidationResult(ctx)
+		th.Assert(t, err == nil, err)
+		info := &endpointmanager.FHIREndpointInfo{URL: url, VendorID: vendor.ID, RequestedFhirVersion: "None", CapabilityFhirVersion: fhirVersion, ValidationID: valResID}
+		err = store.AddFHIREndpointInfo(ctx, info, metadataID)
+		th.Assert(t, err == nil, err)
+	}
+	addInfo("http://example.com/working/", "4.0.1", 200)
+	addInfo("http://example.com/failing/", "1.0.2", 500)
+
+	links := []struct {
+		npiID      string
+		url        string
+		confidence float64
+	}{
+		{"1", "http://example.com/working/", 1},
+		{"1", "http://example.com/failing/", 0.9},
+		{"2", "http://example.com/failing/", 0.8},
+		{"3", "http://example.com/unqueried/", 1},
+	}
+	for _, link := range links {
+		err = store.LinkNPIOrganizationToFHIREndpoint(ctx, link.npiID, link.url, link.confidence)
+		th.Assert(t, err == nil, err)
+	}
+
+	// all organizations
+
+	orgs, err := store.GetOrganizationEndpointHealth(ctx)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(orgs) == 3, fmt.Sprintf("Expected 3 organizations. Got %d", len(orgs)))
+
+	th.Assert(t, orgs[0].NPIID == "1" && orgs[0].Name == "Org 1" && orgs[0].State == "MA", "Expected org 1 first")
+	th.Assert(t, len(orgs[0].Endpoints) == 2, fmt.Sprintf("Expected org 1 to have 2 endpoints. Got %d", len(orgs[0].Endpoints)))
+	th.Assert(t, orgs[0].HasWorkingAPI, "Expected org 1 to have a working API")
+	th.Assert(t, orgs[0].Availability == 0.5, fmt.Sprintf("Expected org 1 to have an availability of 0.5. Got %f", orgs[0].Availability))
+	th.Assert(t, len(orgs[0].FHIRVersions) == 2, fmt.Sprintf("Expected org 1 to have 2 FHIR versions. Got %v", orgs[0].FHIRVersions))
+	th.Assert(t, len(orgs[0].Vendors) == 1 && orgs[0].Vendors[0] == vendor.Name, fmt.Sprintf("Expected org 1 to have the vendor %s. Got %v", vendor.Name, orgs[0].Vendors))
+
+	th.Assert(t, !orgs[1].HasWorkingAPI, "Expected org 2 to not have a working API")
+	th.Assert(t, orgs[1].Endpoints[0].Confidence == 0.8, fmt.Sprintf("Expected org 2's link confidence to be 0.8. Got %f", orgs[1].Endpoints[0].Confidence))
+
+	th.Assert(t, !orgs[2].HasWorkingAPI, "Expected org 3 to not have a working API")
+	th.Assert(t, orgs[2].Endpoints[0].HTTPResponse == 0, "Expected org 3's endpoint to not have been queried")
+
+	// by state
+
+	orgs, err = store.GetOrganizationEndpointHealthUsingState(ctx, "MA")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(orgs) == 2 && orgs[0].NPIID == "1" && orgs[1].NPIID == "3", "Expected org 1 and org 3 in MA")
+
+	// by NPI ID
+
+	org, err := store.GetOrganizationEndpointHealthUsingNPIID(ctx, "2")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, org.Name == "Org 2" && len(org.Endpoints) == 1, "Expected org 2 with 1 endpoint")
+
+	_, err = store.GetOrganizationEndpointHealthUsingNPIID(ctx, "4")
+	th.Assert(t, err == sql.ErrNoRows, "Expected no rows for an organization without linked endpoints")
+}
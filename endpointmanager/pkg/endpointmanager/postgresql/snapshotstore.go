@@ -0,0 +1,254 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/capabilityparser"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/smartparser"
+)
+
+// endpointSnapshotQuery selects the latest fhir_endpoints_info_history entry entered at or before $1 for each url
+// and requested FHIR version, leaving out the infos whose latest entry is a deletion. The metadata and the vendor
+// are selected as JSON so that a missing one is a single NULL column. The vendor is its latest vendors_history row
+// entered at or before $1, or its current row if it was created by then and has no history from before $1.
+const endpointSnapshotQuery = `
+	WITH infos AS (
+		SELECT DISTINCT ON (url, requested_fhir_version) *
+		FROM fhir_endpoints_info_history
+		WHERE entered_at <= $1
+		ORDER BY url, requested_fhir_version, entered_at DESC
+	)
+	SELECT
+		infos.id,
+		infos.url,
+		infos.healthit_product_id,
+		infos.vendor_id,
+		COALESCE(infos.tls_version, ''),
+		infos.mime_types,
+		capstat.document,
+		infos.created_at,
+		infos.updated_at,
+		smart.document,
+		infos.included_fields,
+		infos.operation_resource,
+		infos.validation_result_id,
+		COALESCE(infos.requested_fhir_version, ''),
+		COALESCE(infos.capability_fhir_version, ''),
+		COALESCE(infos.http_version, ''),
+		COALESCE(infos.alpn_protocol, ''),
+		infos.ip_reachability,
+		to_jsonb(metadata),
+		CASE
+			WHEN vendor_history.operation IS NULL THEN to_jsonb(vendors)
+			WHEN vendor_history.operation = 'D' THEN NULL
+			ELSE vendor_history.vendor
+		END
+	FROM infos
+	LEFT JOIN fhir_endpoints_documents AS capstat ON infos.capability_statement_hash = capstat.hash
+	LEFT JOIN fhir_endpoints_documents AS smart ON infos.smart_response_hash = smart.hash
+	LEFT JOIN fhir_endpoints_metadata AS metadata ON infos.metadata_id = metadata.id
+	LEFT JOIN LATERAL (
+		SELECT h.operation, to_jsonb(h) AS vendor FROM vendors_history AS h
+		WHERE h.id = infos.vendor_id AND h.entered_at <= $1
+		ORDER BY h.entered_at DESC LIMIT 1
+	) AS vendor_history ON TRUE
+	LEFT JOIN vendors ON infos.vendor_id = vendors.id AND vendors.created_at <= $1
+	WHERE infos.operation != 'D'
+	ORDER BY infos.url, infos.requested_fhir_version`
+
+type metadataSnapshot struct {
+	ID                   int                              `json:"id"`
+	URL                  string                           `json:"url"`
+	HTTPResponse         int                              `json:"http_response"`
+	Availability         float64                          `json:"availability"`
+	Errors               string                           `json:"errors"`
+	ErrorCategory        endpointmanager.ErrorCategory    `json:"error_category"`
+	ResponseTime         float64                          `json:"response_time_seconds"`
+	SMARTHTTPResponse    int                              `json:"smart_http_response"`
+	RequestedFhirVersion string                           `json:"requested_fhir_version"`
+	Redirects            []endpointmanager.Redirect       `json:"redirects"`
+	SMARTRedirects       []endpointmanager.Redirect       `json:"smart_redirects"`
+	PermanentRedirect    bool                             `json:"permanent_redirect"`
+	CanonicalURL         string                           `json:"canonical_url"`
+	IPResponseTimes      map[string]float64               `json:"ip_response_times"`
+	LatencyBreakdown     endpointmanager.LatencyBreakdown `json:"latency_breakdown"`
+	CreatedAt            time.Time                        `json:"created_at"`
+	UpdatedAt            time.Time                        `json:"updated_at"`
+}
+
+type vendorSnapshot struct {
+	ID                 int                       `json:"id"`
+	Name               string                    `json:"name"`
+	DeveloperCode      string                    `json:"developer_code"`
+	URL                string                    `json:"url"`
+	Location           *endpointmanager.Location `json:"location"`
+	Status             string                    `json:"status"`
+	LastModifiedInCHPL time.Time                 `json:"last_modified_in_chpl"`
+	CHPLID             int                       `json:"chpl_id"`
+	CreatedAt          time.Time                 `json:"created_at"`
+	UpdatedAt          time.Time                 `json:"updated_at"`
+}
+
+// GetEndpointSnapshots gets the state of every url and requested FHIR version at the given time: the
+// FHIREndpointInfo that was in effect, the FHIREndpointMetadata it referenced and the Vendor as it was then. Infos
+// that had been deleted by then, or that were first added after it, are left out. The snapshots are ordered by url
+// and requested FHIR version.
+func (s *Store) GetEndpointSnapshots(ctx context.Context, asOf time.Time) ([]*endpointmanager.EndpointSnapshot, error) {
+	var snapshots []*endpointmanager.EndpointSnapshot
+
+	rows, err := s.querier().QueryContext(ctx, endpointSnapshotQuery, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var endpointInfo endpointmanager.FHIREndpointInfo
+		var capabilityStatementJSON []byte
+		var includedFieldsJSON []byte
+		var healthitProductIDNullable sql.NullInt64
+		var vendorIDNullable sql.NullInt64
+		var validationIDNullable sql.NullInt64
+		var smartResponseJSON []byte
+		var ipReachabilityJSON []byte
+		var operResourceJSON []byte
+		var metadataJSON []byte
+		var vendorJSON []byte
+
+		err = rows.Scan(
+			&endpointInfo.ID,
+			&endpointInfo.URL,
+			&healthitProductIDNullable,
+			&vendorIDNullable,
+			&endpointInfo.TLSVersion,
+			pq.Array(&endpointInfo.MIMETypes),
+			&capabilityStatementJSON,
+			&endpointInfo.CreatedAt,
+			&endpointInfo.UpdatedAt,
+			&smartResponseJSON,
+			&includedFieldsJSON,
+			&operResourceJSON,
+			&validationIDNullable,
+			&endpointInfo.RequestedFhirVersion,
+			&endpointInfo.CapabilityFhirVersion,
+			&endpointInfo.HTTPVersion,
+			&endpointInfo.ALPNProtocol,
+			&ipReachabilityJSON,
+			&metadataJSON,
+			&vendorJSON)
+		if err != nil {
+			return nil, err
+		}
+
+		if capabilityStatementJSON != nil {
+			endpointInfo.CapabilityStatement, err = capabilityparser.NewCapabilityStatement(capabilityStatementJSON)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		ints := getRegularInts([]sql.NullInt64{healthitProductIDNullable, vendorIDNullable, validationIDNullable})
+		endpointInfo.HealthITProductID = ints[0]
+		endpointInfo.VendorID = ints[1]
+		endpointInfo.ValidationID = ints[2]
+
+		if includedFieldsJSON != nil {
+			err = json.Unmarshal(includedFieldsJSON, &endpointInfo.IncludedFields)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if operResourceJSON != nil {
+			err = json.Unmarshal(operResourceJSON, &endpointInfo.OperationResource)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if smartResponseJSON != nil {
+			endpointInfo.SMARTResponse, err = smartparser.NewSMARTResp(smartResponseJSON)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if ipReachabilityJSON != nil {
+			err = json.Unmarshal(ipReachabilityJSON, &endpointInfo.IPReachability)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		endpointInfo.Metadata, err = decodeMetadataSnapshot(metadataJSON)
+		if err != nil {
+			return nil, err
+		}
+		vendor, err := decodeVendorSnapshot(vendorJSON)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, &endpointmanager.EndpointSnapshot{
+			Info:   &endpointInfo,
+			Vendor: vendor,
+		})
+	}
+	return snapshots, rows.Err()
+}
+
+// the decode functions return nil for a metadata or vendor row that does not exist
+
+func decodeMetadataSnapshot(data []byte) (*endpointmanager.FHIREndpointMetadata, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var snap metadataSnapshot
+	err := json.Unmarshal(data, &snap)
+	if err != nil {
+		return nil, err
+	}
+	return &endpointmanager.FHIREndpointMetadata{
+		ID:                   snap.ID,
+		URL:                  snap.URL,
+		HTTPResponse:         snap.HTTPResponse,
+		Errors:               snap.Errors,
+		ErrorCategory:        snap.ErrorCategory,
+		CreatedAt:            snap.CreatedAt,
+		UpdatedAt:            snap.UpdatedAt,
+		SMARTHTTPResponse:    snap.SMARTHTTPResponse,
+		ResponseTime:         snap.ResponseTime,
+		Availability:         snap.Availability,
+		RequestedFhirVersion: snap.RequestedFhirVersion,
+		Redirects:            snap.Redirects,
+		SMARTRedirects:       snap.SMARTRedirects,
+		PermanentRedirect:    snap.PermanentRedirect,
+		CanonicalURL:         snap.CanonicalURL,
+		IPResponseTimes:      snap.IPResponseTimes,
+		LatencyBreakdown:     snap.LatencyBreakdown,
+	}, nil
+}
+
+func decodeVendorSnapshot(data []byte) (*endpointmanager.Vendor, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var snap vendorSnapshot
+	err := json.Unmarshal(data, &snap)
+	if err != nil {
+		return nil, err
+	}
+	return &endpointmanager.Vendor{
+		ID:                 snap.ID,
+		Name:               snap.Name,
+		DeveloperCode:      snap.DeveloperCode,
+		URL:                snap.URL,
+		Location:           snap.Location,
+		Status:             snap.Status,
+		LastModifiedInCHPL: snap.LastModifiedInCHPL,
+		CHPLID:             snap.CHPLID,
+		CreatedAt:          snap.CreatedAt,
+		UpdatedAt:          snap.UpdatedAt,
+	}, nil
+}
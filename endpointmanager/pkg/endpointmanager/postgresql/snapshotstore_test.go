@@ -0,0 +1,117 @@
+// +build integration
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+)
+
+func Test_GetEndpointSnapshots(t *testing.T) {
+	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
+	defer teardown(t, store.DB)
+
+	ctx := context.Background()
+
+	// the snapshot times are taken from the database so that they are on the same clock as the history entries
+	dbNow := func() time.Time {
+		var now time.Time
+		err := store.DB.QueryRowContext(ctx, "SELECT clock_timestamp()").Scan(&now)
+		th.Assert(t, err == nil, err)
+		return now
+	}
+
+	beforeAdding := dbNow()
+
+	vendor := &endpointmanager.Vendor{
+		Name:          "Cerner",
+		DeveloperCode: "1221",
+		CHPLID:        222,
+		Location:      &endpointmanager.Location{State: "MO"},
+	}
+	err := store.AddVendor(ctx, vendor)
+	th.Assert(t, err == nil, err)
+
+	metadata := &endpointmanager.FHIREndpointMetadata{
+		URL:                  "example.com/FHIR/DSTU2/",
+		HTTPResponse:         200,
+		ResponseTime:         0.25,
+		RequestedFhirVersion: "None",
+	}
+	metadataID, err := store.AddFHIREndpointMetadata(ctx, metadata)
+	th.Assert(t, err == nil, err)
+	valResID, err := store.AddValidationResult(ctx)
+	th.Assert(t, err == nil, err)
+
+	info := &endpointmanager.FHIREndpointInfo{
+		URL:                  "example.com/FHIR/DSTU2/",
+		VendorID:             vendor.ID,
+		TLSVersion:           "TLS 1.2",
+		MIMETypes:            []string{"application/json+fhir"},
+		ValidationID:         valResID,
+		RequestedFhirVersion: "None",
+		Metadata:             metadata,
+	}
+	err = store.AddFHIREndpointInfo(ctx, info, metadataID)
+	th.Assert(t, err == nil, err)
+
+	afterAdding := dbNow()
+
+	// change the vendor and the info
+
+	vendor.Name = "Cerner Corporation"
+	err = store.UpdateVendor(ctx, vendor)
+	th.Assert(t, err == nil, err)
+
+	updatedMetadata := *metadata
+	updatedMetadata.HTTPResponse = 500
+	metadataID, err = store.AddFHIREndpointMetadata(ctx, &updatedMetadata)
+	th.Assert(t, err == nil, err)
+	info.TLSVersion = "TLS 1.3"
+	info.Metadata = &updatedMetadata
+	err = store.UpdateFHIREndpointInfo(ctx, info, metadataID)
+	th.Assert(t, err == nil, err)
+
+	afterUpdating := dbNow()
+
+	err = store.DeleteFHIREndpointInfo(ctx, info)
+	th.Assert(t, err == nil, err)
+
+	// nothing existed before the info was added
+
+	snapshots, err := store.GetEndpointSnapshots(ctx, beforeAdding)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(snapshots) == 0, fmt.Sprintf("expected no snapshots before the info was added, got %d", len(snapshots)))
+
+	// the info and vendor as they were added
+
+	snapshots, err = store.GetEndpointSnapshots(ctx, afterAdding)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(snapshots) == 1, fmt.Sprintf("expected 1 snapshot after the info was added, got %d", len(snapshots)))
+	th.Assert(t, snapshots[0].Info.ID == info.ID, "expected the snapshot to be of the added info")
+	th.Assert(t, snapshots[0].Info.TLSVersion == "TLS 1.2", fmt.Sprintf("expected the TLS version as it was added, got %s", snapshots[0].Info.TLSVersion))
+	th.Assert(t, snapshots[0].Info.Metadata != nil && snapshots[0].Info.Metadata.HTTPResponse == 200, "expected the metadata the added info referenced")
+	th.Assert(t, snapshots[0].Info.Metadata.ResponseTime == 0.25, fmt.Sprintf("expected a response time of 0.25, got %f", snapshots[0].Info.Metadata.ResponseTime))
+	th.Assert(t, snapshots[0].Vendor != nil && snapshots[0].Vendor.Name == "Cerner", "expected the vendor as it was added")
+	th.Assert(t, snapshots[0].Vendor.Location != nil && snapshots[0].Vendor.Location.State == "MO", "expected the vendor's location")
+
+	// the info and vendor as they were updated
+
+	snapshots, err = store.GetEndpointSnapshots(ctx, afterUpdating)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(snapshots) == 1, fmt.Sprintf("expected 1 snapshot after the info was updated, got %d", len(snapshots)))
+	th.Assert(t, snapshots[0].Info.TLSVersion == "TLS 1.3", fmt.Sprintf("expected the updated TLS version, got %s", snapshots[0].Info.TLSVersion))
+	th.Assert(t, snapshots[0].Info.Metadata != nil && snapshots[0].Info.Metadata.HTTPResponse == 500, "expected the metadata the updated info referenced")
+	th.Assert(t, snapshots[0].Vendor != nil && snapshots[0].Vendor.Name == "Cerner Corporation", "expected the updated vendor")
+
+	// the info has been deleted
+
+	snapshots, err = store.GetEndpointSnapshots(ctx, dbNow())
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(snapshots) == 0, fmt.Sprintf("expected no snapshots after the info was deleted, got %d", len(snapshots)))
+}
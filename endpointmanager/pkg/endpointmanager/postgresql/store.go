@@ -1,10 +1,12 @@
 package postgresql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
 	_ "github.com/lib/pq" // specified to do this for accessing postgres db
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
 )
 
 // Store is the structure for working with the postgres database.
@@ -14,8 +16,28 @@ import (
 // defer store.Close()
 // po := store.GetProviderOrganization(poID)
 // <etc.>
+//
+// To make several calls atomically, run them with the store passed to WithTx:
+//
+// err := store.WithTx(ctx, func(txStore *postgresql.Store) error { ... })
 type Store struct {
 	DB *sql.DB
+	tx *sql.Tx
+}
+
+var _ endpointmanager.EndpointStore = (*Store)(nil)
+var _ endpointmanager.EndpointInfoStore = (*Store)(nil)
+var _ endpointmanager.EndpointListStore = (*Store)(nil)
+var _ endpointmanager.VendorStore = (*Store)(nil)
+var _ endpointmanager.ProductStore = (*Store)(nil)
+var _ endpointmanager.NPIStore = (*Store)(nil)
+var _ endpointmanager.ValidationStore = (*Store)(nil)
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 }
 
 // NewStore creates a connection to the postgresql database and adds a reference to the database
@@ -82,10 +104,67 @@ func NewStore(host string, port int, user string, password string, dbname string
 	if err != nil {
 		return nil, err
 	}
+	err = prepareFHIREndpointHostingStatements(&store)
+	if err != nil {
+		return nil, err
+	}
+	err = prepareEndpointOrganizationReviewStatements(&store)
+	if err != nil {
+		return nil, err
+	}
+	err = prepareNUCCTaxonomyStatements(&store)
+	if err != nil {
+		return nil, err
+	}
 
 	return &store, nil
 }
 
+// WithTx runs 'fn' with a store whose methods all run within a single transaction. The transaction is committed if
+// 'fn' returns nil and rolled back otherwise, so the calls that 'fn' makes are either all stored or none of them are.
+// If the store is already running within a transaction, 'fn' is run within that transaction.
+func (s *Store) WithTx(ctx context.Context, fn func(txStore *Store) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = fn(&Store{DB: s.DB, tx: tx})
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// WithEndpointListTx runs 'fn' within a single transaction in the same way as WithTx.
+func (s *Store) WithEndpointListTx(ctx context.Context, fn func(txStore endpointmanager.EndpointListStore) error) error {
+	return s.WithTx(ctx, func(txStore *Store) error {
+		return fn(txStore)
+	})
+}
+
+// querier returns the store's transaction if it is running within one, and its database connection otherwise.
+func (s *Store) querier() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.DB
+}
+
+// stmt returns the prepared statement to use for the store, which is specific to the store's transaction if it is
+// running within one.
+func (s *Store) stmt(ctx context.Context, stmt *sql.Stmt) *sql.Stmt {
+	if s.tx != nil {
+		return s.tx.StmtContext(ctx, stmt)
+	}
+	return stmt
+}
+
 // Close closes the postgresql database connection.
 func (s *Store) Close() {
 	s.DB.Close()
@@ -3,10 +3,14 @@
 package postgresql
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"testing"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/config"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
 	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
 	"github.com/spf13/viper"
 )
@@ -45,3 +49,65 @@ func SetupStore() error {
 func teardown() {
 	store.Close()
 }
+
+func Test_WithTx(t *testing.T) {
+	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
+	defer teardown(t, store.DB)
+
+	ctx := context.Background()
+	testValidation := endpointmanager.Validation{
+		Results: []endpointmanager.Rule{
+			{
+				RuleName: endpointmanager.CapStatExistRule,
+				Valid:    true,
+				Expected: "true",
+				Actual:   "true",
+				Comment:  "The Capability Statement exists.",
+			},
+		},
+	}
+	countRows := func(table string) int {
+		var count int
+		err := store.DB.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count)
+		th.Assert(t, err == nil, err)
+		return count
+	}
+
+	// nothing is stored when the function returns an error, including the rows added before the error
+	expectedErr := errors.New("failed after adding the validation")
+	err := store.WithTx(ctx, func(txStore *Store) error {
+		valResID, err := txStore.AddValidationResult(ctx)
+		th.Assert(t, err == nil, err)
+		err = txStore.AddValidation(ctx, &testValidation, valResID)
+		th.Assert(t, err == nil, err)
+
+		// the transaction sees its own rows
+		validation, err := txStore.GetValidationByID(ctx, valResID)
+		th.Assert(t, err == nil, err)
+		th.Assert(t, len(*validation) == 1, fmt.Sprintf("expected 1 validation within the transaction, got %d", len(*validation)))
+
+		return expectedErr
+	})
+	th.Assert(t, err == expectedErr, fmt.Sprintf("expected the function's error, got %v", err))
+	th.Assert(t, countRows("validation_results") == 0, "expected the validation result to be rolled back")
+	th.Assert(t, countRows("validations") == 0, "expected the validation to be rolled back")
+
+	// everything is stored when the function succeeds
+	var valResID int
+	err = store.WithTx(ctx, func(txStore *Store) error {
+		var err error
+		valResID, err = txStore.AddValidationResult(ctx)
+		if err != nil {
+			return err
+		}
+		// nested calls run within the same transaction
+		return txStore.WithTx(ctx, func(nestedStore *Store) error {
+			return nestedStore.AddValidation(ctx, &testValidation, valResID)
+		})
+	})
+	th.Assert(t, err == nil, err)
+	th.Assert(t, countRows("validation_results") == 1, "expected the validation result to be committed")
+	validation, err := store.GetValidationByID(ctx, valResID)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(*validation) == 1, fmt.Sprintf("expected 1 committed validation, got %d", len(*validation)))
+}
@@ -26,7 +26,7 @@ func (s *Store) GetValidationByID(ctx context.Context, id int) (*[]endpointmanag
 		implementation_guide
 	FROM validations WHERE validation_result_id=$1`
 
-	rows, err := s.DB.QueryContext(ctx, sqlStatementInfo, id)
+	rows, err := s.querier().QueryContext(ctx, sqlStatementInfo, id)
 	if err != nil {
 		return nil, err
 	}
@@ -54,7 +54,7 @@ func (s *Store) GetValidationByID(ctx context.Context, id int) (*[]endpointmanag
 func (s *Store) AddValidationResult(ctx context.Context) (int, error) {
 	var err error
 
-	valResRow := addValidationResultStatement.QueryRowContext(ctx)
+	valResRow := s.stmt(ctx, addValidationResultStatement).QueryRowContext(ctx)
 	valResID := 0
 	err = valResRow.Scan(&valResID)
 
@@ -66,7 +66,7 @@ func (s *Store) AddValidation(ctx context.Context, v *endpointmanager.Validation
 	var err error
 
 	for _, ruleInfo := range v.Results {
-		_, err = addValidationStatement.ExecContext(ctx,
+		_, err = s.stmt(ctx, addValidationStatement).ExecContext(ctx,
 			ruleInfo.RuleName,
 			ruleInfo.Valid,
 			ruleInfo.Expected,
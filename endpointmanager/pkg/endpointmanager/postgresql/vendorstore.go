@@ -33,7 +33,7 @@ func (s *Store) GetVendor(ctx context.Context, id int) (*endpointmanager.Vendor,
 		created_at,
 		updated_at
 	FROM vendors WHERE id=$1`
-	row := s.DB.QueryRowContext(ctx, sqlStatement, id)
+	row := s.querier().QueryRowContext(ctx, sqlStatement, id)
 
 	err := row.Scan(
 		&vendor.ID,
@@ -78,7 +78,7 @@ func (s *Store) GetVendorUsingName(ctx context.Context, name string) (*endpointm
 		updated_at
 	FROM vendors WHERE name=$1`
 
-	row := s.DB.QueryRowContext(ctx, sqlStatement, name)
+	row := s.querier().QueryRowContext(ctx, sqlStatement, name)
 
 	err := row.Scan(
 		&vendor.ID,
@@ -108,7 +108,7 @@ func (s *Store) GetVendorNames(ctx context.Context) ([]string, error) {
 	var developers []string
 	var developer string
 	sqlStatement := "SELECT name FROM vendors"
-	rows, err := s.DB.QueryContext(ctx, sqlStatement)
+	rows, err := s.querier().QueryContext(ctx, sqlStatement)
 	if err != nil {
 		return nil, err
 	}
@@ -133,7 +133,7 @@ func (s *Store) AddVendor(ctx context.Context, v *endpointmanager.Vendor) error
 		return err
 	}
 
-	row := addVendorStatement.QueryRowContext(ctx,
+	row := s.stmt(ctx, addVendorStatement).QueryRowContext(ctx,
 		v.Name,
 		v.DeveloperCode,
 		v.URL,
@@ -156,7 +156,7 @@ func (s *Store) UpdateVendor(ctx context.Context, v *endpointmanager.Vendor) err
 		return err
 	}
 
-	_, err = updateVendorStatement.ExecContext(ctx,
+	_, err = s.stmt(ctx, updateVendorStatement).ExecContext(ctx,
 		v.Name,
 		v.DeveloperCode,
 		v.URL,
@@ -171,7 +171,7 @@ func (s *Store) UpdateVendor(ctx context.Context, v *endpointmanager.Vendor) err
 
 // DeleteVendor deletes the Vendor from the database using the Vendor's database id  as the key.
 func (s *Store) DeleteVendor(ctx context.Context, v *endpointmanager.Vendor) error {
-	_, err := deleteVendorStatement.ExecContext(ctx, v.ID)
+	_, err := s.stmt(ctx, deleteVendorStatement).ExecContext(ctx, v.ID)
 
 	return err
 }
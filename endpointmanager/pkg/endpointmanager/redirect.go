@@ -0,0 +1,65 @@
+package endpointmanager
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Redirect represents a single hop in the chain of HTTP redirects that was followed when
+// requesting a URL. StatusCode is the 3xx status code received and Location is the absolute
+// URL that the response redirected to.
+type Redirect struct {
+	StatusCode int    `json:"statusCode"`
+	Location   string `json:"location"`
+}
+
+// Permanent returns true if the redirect indicates that the resource has permanently moved.
+func (r Redirect) Permanent() bool {
+	return r.StatusCode == http.StatusMovedPermanently || r.StatusCode == http.StatusPermanentRedirect
+}
+
+// RedirectsEqual checks that the two redirect chains contain the same hops in the same order.
+func RedirectsEqual(r1 []Redirect, r2 []Redirect) bool {
+	if len(r1) != len(r2) {
+		return false
+	}
+	for i := range r1 {
+		if r1[i] != r2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// HasPermanentRedirect returns true if any hop in the redirect chain is a permanent redirect.
+func HasPermanentRedirect(redirects []Redirect) bool {
+	for _, redirect := range redirects {
+		if redirect.Permanent() {
+			return true
+		}
+	}
+	return false
+}
+
+// CanonicalURL proposes a new service base URL from the redirect chain followed when requesting
+// the endpoint's metadata. A URL is only proposed if every hop in the chain is a permanent redirect,
+// otherwise an empty string is returned. The "metadata" path segment is removed from the final location
+// so that the result can be used as a FHIREndpoint URL.
+func CanonicalURL(redirects []Redirect) string {
+	if len(redirects) == 0 {
+		return ""
+	}
+	for _, redirect := range redirects {
+		if !redirect.Permanent() {
+			return ""
+		}
+	}
+
+	canonical := redirects[len(redirects)-1].Location
+	// drop any query string the server may have added to the final location
+	canonical = strings.SplitN(canonical, "?", 2)[0]
+	canonical = strings.TrimSuffix(canonical, "/")
+	canonical = strings.TrimSuffix(canonical, "metadata")
+
+	return canonical
+}
@@ -0,0 +1,83 @@
+package endpointmanager
+
+import (
+	"testing"
+)
+
+func Test_RedirectPermanent(t *testing.T) {
+	if !(Redirect{StatusCode: 301}).Permanent() {
+		t.Errorf("Expected a 301 redirect to be permanent")
+	}
+	if !(Redirect{StatusCode: 308}).Permanent() {
+		t.Errorf("Expected a 308 redirect to be permanent")
+	}
+	if (Redirect{StatusCode: 302}).Permanent() {
+		t.Errorf("Did not expect a 302 redirect to be permanent")
+	}
+	if (Redirect{StatusCode: 307}).Permanent() {
+		t.Errorf("Did not expect a 307 redirect to be permanent")
+	}
+}
+
+func Test_RedirectsEqual(t *testing.T) {
+	r1 := []Redirect{{StatusCode: 301, Location: "https://example.com/metadata"}}
+	r2 := []Redirect{{StatusCode: 301, Location: "https://example.com/metadata"}}
+
+	if !RedirectsEqual(r1, r2) {
+		t.Errorf("Expected redirect chains to be equal")
+	}
+	if !RedirectsEqual(nil, []Redirect{}) {
+		t.Errorf("Expected nil and empty redirect chains to be equal")
+	}
+
+	r2[0].StatusCode = 302
+	if RedirectsEqual(r1, r2) {
+		t.Errorf("Did not expect redirect chains with different status codes to be equal")
+	}
+
+	r2 = append(r1, Redirect{StatusCode: 301, Location: "https://other.example.com/metadata"})
+	if RedirectsEqual(r1, r2) {
+		t.Errorf("Did not expect redirect chains of different lengths to be equal")
+	}
+}
+
+func Test_HasPermanentRedirect(t *testing.T) {
+	if HasPermanentRedirect(nil) {
+		t.Errorf("Did not expect an empty redirect chain to have a permanent redirect")
+	}
+	chain := []Redirect{
+		{StatusCode: 302, Location: "https://example.com/metadata"},
+		{StatusCode: 308, Location: "https://other.example.com/metadata"},
+	}
+	if !HasPermanentRedirect(chain) {
+		t.Errorf("Expected redirect chain to have a permanent redirect")
+	}
+	chain[1].StatusCode = 307
+	if HasPermanentRedirect(chain) {
+		t.Errorf("Did not expect redirect chain to have a permanent redirect")
+	}
+}
+
+func Test_CanonicalURL(t *testing.T) {
+	if CanonicalURL(nil) != "" {
+		t.Errorf("Expected no canonical URL when there are no redirects")
+	}
+
+	chain := []Redirect{
+		{StatusCode: 301, Location: "https://example.com/dstu2/metadata"},
+		{StatusCode: 308, Location: "https://new.example.com/dstu2/metadata?_format=json"},
+	}
+	if CanonicalURL(chain) != "https://new.example.com/dstu2/" {
+		t.Errorf("Expected canonical URL to be https://new.example.com/dstu2/, got %s", CanonicalURL(chain))
+	}
+
+	chain[1].Location = "https://new.example.com/dstu2/metadata/"
+	if CanonicalURL(chain) != "https://new.example.com/dstu2/" {
+		t.Errorf("Expected canonical URL to be https://new.example.com/dstu2/, got %s", CanonicalURL(chain))
+	}
+
+	chain[0].StatusCode = 302
+	if CanonicalURL(chain) != "" {
+		t.Errorf("Did not expect a canonical URL when the redirect chain includes a temporary redirect")
+	}
+}
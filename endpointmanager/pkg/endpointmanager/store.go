@@ -0,0 +1,145 @@
+package endpointmanager
+
+import (
+	"context"
+	"time"
+)
+
+// The store interfaces below are the narrow views of the database that the packages using it depend on. They are
+// implemented by postgresql.Store, and all but the CHPL history, hosting and history partition stores are also
+// implemented by the in-memory memorystore.Store, which can be used in tests that should not need a live database.
+// Lookups that find nothing return sql.ErrNoRows in both implementations.
+//
+// Some packages still take a postgresql.Store because what they use has no meaning outside of Postgres:
+// capabilityhandler saves each capability statement within a postgresql.Store transaction, the nppesquerier NPI
+// organization loads COPY into a Postgres staging table, jsonexport and historypruning read *sql.Rows from
+// Postgres queries.
+
+// EndpointStore stores the FHIREndpoints populated from the endpoint lists.
+type EndpointStore interface {
+	GetAllFHIREndpoints(ctx context.Context) ([]*FHIREndpoint, error)
+	GetFHIREndpointCount(ctx context.Context) (int, error)
+	GetFHIREndpoint(ctx context.Context, id int) (*FHIREndpoint, error)
+	GetFHIREndpointUsingURL(ctx context.Context, url string) ([]*FHIREndpoint, error)
+	GetFHIREndpointUsingURLAndListSource(ctx context.Context, url string, listSource string) (*FHIREndpoint, error)
+	GetFHIREndpointsUsingListSourceAndUpdateTime(ctx context.Context, updateTime time.Time, listSource string) ([]*FHIREndpoint, error)
+	AddOrUpdateFHIREndpoint(ctx context.Context, e *FHIREndpoint) error
+	AddOrUpdateFHIREndpoints(ctx context.Context, endpoints []*FHIREndpoint) error
+	AddFHIREndpoint(ctx context.Context, e *FHIREndpoint) error
+	UpdateFHIREndpoint(ctx context.Context, e *FHIREndpoint) error
+	DeleteFHIREndpoint(ctx context.Context, e *FHIREndpoint) error
+}
+
+// EndpointInfoStore stores the FHIREndpointInfos and FHIREndpointMetadata gathered by querying the endpoints.
+type EndpointInfoStore interface {
+	GetFHIREndpointInfo(ctx context.Context, id int) (*FHIREndpointInfo, error)
+	GetFHIREndpointInfosUsingURL(ctx context.Context, url string) ([]*FHIREndpointInfo, error)
+	GetFHIREndpointInfoUsingURLAndRequestedVersion(ctx context.Context, url string, requestedVersion string) (*FHIREndpointInfo, error)
+	GetFHIREndpointInfosByURLWithDifferentRequestedVersion(ctx context.Context, url string, versions []string) ([]*FHIREndpointInfo, error)
+	AddFHIREndpointInfo(ctx context.Context, e *FHIREndpointInfo, metadataID int) error
+	UpdateFHIREndpointInfo(ctx context.Context, e *FHIREndpointInfo, metadataID int) error
+	UpdateMetadataIDInfo(ctx context.Context, metadataID int, id int) error
+	DeleteFHIREndpointInfo(ctx context.Context, e *FHIREndpointInfo) error
+	GetFHIREndpointMetadata(ctx context.Context, metadataID int) (*FHIREndpointMetadata, error)
+	AddFHIREndpointMetadata(ctx context.Context, e *FHIREndpointMetadata) (int, error)
+}
+
+// EndpointListStore stores the endpoint lists, along with the infos of the endpoints that are removed from them. The
+// calls made using the store passed to the function given to WithEndpointListTx are either all stored or none of
+// them are.
+type EndpointListStore interface {
+	EndpointStore
+	EndpointInfoStore
+	WithEndpointListTx(ctx context.Context, fn func(txStore EndpointListStore) error) error
+}
+
+// VendorStore stores the Vendors gathered from CHPL.
+type VendorStore interface {
+	GetVendor(ctx context.Context, id int) (*Vendor, error)
+	GetVendorUsingName(ctx context.Context, name string) (*Vendor, error)
+	GetVendorNames(ctx context.Context) ([]string, error)
+	AddVendor(ctx context.Context, v *Vendor) error
+	UpdateVendor(ctx context.Context, v *Vendor) error
+	DeleteVendor(ctx context.Context, v *Vendor) error
+}
+
+// ProductStore stores the HealthITProducts and CertificationCriteria gathered from CHPL, and the links between them.
+type ProductStore interface {
+	GetHealthITProduct(ctx context.Context, id int) (*HealthITProduct, error)
+	GetHealthITProductUsingNameAndVersion(ctx context.Context, name string, version string) (*HealthITProduct, error)
+	GetHealthITProductsUsingVendor(ctx context.Context, vendorID int) ([]*HealthITProduct, error)
+	GetHealthITProductIDByCHPLID(ctx context.Context, CHPLID string) (int, error)
+	AddHealthITProduct(ctx context.Context, hitp *HealthITProduct) error
+	UpdateHealthITProduct(ctx context.Context, hitp *HealthITProduct) error
+	DeleteHealthITProduct(ctx context.Context, hitp *HealthITProduct) error
+	GetProductCriteriaLink(ctx context.Context, criteriaID int, productID int) (int, int, string, error)
+	LinkProductToCriteria(ctx context.Context, criteriaID int, productID int, productNumber string) error
+	DeleteLinksByProduct(ctx context.Context, productID int) error
+	GetCriteria(ctx context.Context, id int) (*CertificationCriteria, error)
+	GetCriteriaByCertificationID(ctx context.Context, certID int) (*CertificationCriteria, error)
+	AddCriteria(ctx context.Context, criteria *CertificationCriteria) error
+	UpdateCriteria(ctx context.Context, criteria *CertificationCriteria) error
+	DeleteCriteria(ctx context.Context, criteria *CertificationCriteria) error
+}
+
+// NPIStore stores the NPI organizations and contacts gathered from NPPES, the NUCC taxonomies used to classify the
+// organizations, and the links between the organizations and the endpoints along with their reviews.
+type NPIStore interface {
+	GetNPIOrganization(ctx context.Context, id int) (*NPIOrganization, error)
+	GetNPIOrganizationByNPIID(ctx context.Context, npiID string) (*NPIOrganization, error)
+	AddNPIOrganization(ctx context.Context, org *NPIOrganization) error
+	UpdateNPIOrganization(ctx context.Context, org *NPIOrganization) error
+	UpdateNPIOrganizationByNPIID(ctx context.Context, org *NPIOrganization) error
+	DeleteNPIOrganization(ctx context.Context, org *NPIOrganization) error
+	GetAllNPIOrganizationNormalizedNames(ctx context.Context) ([]*NPIOrganization, error)
+	GetNPIOrganizationNPIIDsByProviderType(ctx context.Context, providerTypes []string) (map[string]bool, error)
+
+	GetNUCCTaxonomy(ctx context.Context, code string) (*NUCCTaxonomy, error)
+	AddOrUpdateNUCCTaxonomy(ctx context.Context, taxonomy *NUCCTaxonomy) error
+
+	LinkNPIOrganizationToFHIREndpoint(ctx context.Context, orgID string, endpointURL string, confidence float64) error
+	GetNPIOrganizationFHIREndpointLink(ctx context.Context, orgID string, endpointURL string) (string, string, float64, error)
+	UpdateNPIOrganizationFHIREndpointLink(ctx context.Context, orgID string, endpointURL string, confidence float64) error
+	DeleteNPIOrganizationFHIREndpointLink(ctx context.Context, orgID string, endpointURL string) error
+
+	GetEndpointOrganizationReview(ctx context.Context, url string, orgID string) (*EndpointOrganizationReview, error)
+	GetEndpointOrganizationReviews(ctx context.Context, status string) ([]*EndpointOrganizationReview, error)
+	SavePendingEndpointOrganizationReview(ctx context.Context, r *EndpointOrganizationReview) error
+	DecideEndpointOrganizationReview(ctx context.Context, url string, orgID string, status string, decidedBy string) error
+
+	GetNPIContactByNPIID(ctx context.Context, npiID string) (*NPIContact, error)
+	GetAllNPIContacts(ctx context.Context) ([]*NPIContact, error)
+	AddNPIContact(ctx context.Context, contact *NPIContact) error
+	UpdateNPIContactByNPIID(ctx context.Context, contact *NPIContact) error
+	DeleteNPIContact(ctx context.Context, contact *NPIContact) error
+}
+
+// ValidationStore stores the results of validating the endpoints' capability statements.
+type ValidationStore interface {
+	AddValidationResult(ctx context.Context) (int, error)
+	AddValidation(ctx context.Context, v *Validation, valResID int) error
+	GetValidationByID(ctx context.Context, id int) (*[]Rule, error)
+}
+
+// CHPLHistoryStore records the runs of the CHPL querier and reports the changes they made.
+type CHPLHistoryStore interface {
+	AddCHPLSync(ctx context.Context, mode string) (*CHPLSync, error)
+	CompleteCHPLSync(ctx context.Context, sync *CHPLSync) error
+	GetLastCompletedCHPLSync(ctx context.Context) (*CHPLSync, error)
+	GetCHPLChangesSince(ctx context.Context, since time.Time) ([]CHPLChange, error)
+}
+
+// HostingStore stores where each endpoint is hosted.
+type HostingStore interface {
+	GetFHIREndpointHostingUsingURL(ctx context.Context, url string) (*FHIREndpointHosting, error)
+	AddFHIREndpointHosting(ctx context.Context, h *FHIREndpointHosting) error
+	UpdateFHIREndpointHosting(ctx context.Context, h *FHIREndpointHosting) error
+}
+
+// HistoryPartitionStore manages the monthly partitions of the history tables.
+type HistoryPartitionStore interface {
+	CreateHistoryPartitions(ctx context.Context, monthsAhead int) error
+	GetHistoryPartitions(ctx context.Context) ([]*HistoryPartition, error)
+	SummarizeHistoryPartition(ctx context.Context, partition *HistoryPartition) error
+	DropHistoryPartition(ctx context.Context, partition *HistoryPartition) error
+}
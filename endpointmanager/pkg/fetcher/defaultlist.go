@@ -1,5 +1,9 @@
 package fetcher
 
+import (
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+)
+
 // GetEndpoints takes the a list of endpoints and formats it into a ListOfEndpoints
 func getDefaultEndpoints(defaultList []map[string]interface{}, source string, listURL string) ListOfEndpoints {
 	var finalList ListOfEndpoints
@@ -19,6 +23,10 @@ func getDefaultEndpoints(defaultList []map[string]interface{}, source string, li
 		if uriOk {
 			fhirEntry.FHIRPatientFacingURI = uri
 		}
+		location := getListEntryLocation(defaultList[entry])
+		if location != nil {
+			fhirEntry.Locations = []*endpointmanager.Location{location}
+		}
 		innerList = append(innerList, fhirEntry)
 	}
 
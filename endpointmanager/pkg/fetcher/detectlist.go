@@ -0,0 +1,125 @@
+package fetcher
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+)
+
+// csvURLColumns and csvOrgColumns are the lowercased CSV headers that hold the endpoint URL and the
+// organization name, in order of preference.
+var csvURLColumns = []string{"fhirpatientfacinguri", "service base url", "fhir base url", "fhir url", "base url", "baseurl", "url", "endpoint"}
+var csvOrgColumns = []string{"organizationname", "organization name", "organization", "name"}
+
+// GetListOfEndpointsDetectFormat parses a list of endpoints whose format is not known ahead of time, such
+// as a service base URL list published by a vendor. JSON lists are parsed as a FHIR Bundle or as one of
+// the Cerner, Lantern or default formats depending on their keys, and anything else is parsed as CSV with
+// a header row. The ListSource of every entry is set to listSource.
+func GetListOfEndpointsDetectFormat(rawendpts []byte, listSource string) (ListOfEndpoints, error) {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(rawendpts, []byte("\xef\xbb\xbf")))
+	if len(trimmed) == 0 {
+		return ListOfEndpoints{}, nil
+	}
+	if trimmed[0] != '{' {
+		return getCSVEndpoints(trimmed, listSource)
+	}
+
+	var initialList map[string]interface{}
+	err := json.Unmarshal(trimmed, &initialList)
+	if err != nil {
+		return ListOfEndpoints{}, err
+	}
+
+	if initialList["resourceType"] == "Bundle" || initialList["entry"] != nil {
+		return GetListOfEndpointsKnownSource(trimmed, "FHIR", listSource)
+	} else if initialList["endpoints"] != nil {
+		return GetListOfEndpointsKnownSource(trimmed, "Cerner", listSource)
+	} else if initialList["Endpoints"] != nil {
+		return GetListOfEndpointsKnownSource(trimmed, "Lantern", listSource)
+	} else if initialList["Entries"] != nil {
+		return GetListOfEndpoints(trimmed, listSource, listSource)
+	}
+	return ListOfEndpoints{}, fmt.Errorf("the endpoint list is not in a known JSON format")
+}
+
+// getCSVEndpoints parses a CSV list of endpoints. The first row must be a header that names a URL column,
+// see csvURLColumns. The organization name, NPI ID and location columns are optional.
+func getCSVEndpoints(rawendpts []byte, listSource string) (ListOfEndpoints, error) {
+	var result ListOfEndpoints
+
+	reader := csv.NewReader(bytes.NewReader(rawendpts))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return result, fmt.Errorf("endpoint list is neither JSON nor CSV: %s", err)
+	}
+	if len(records) == 0 {
+		return result, nil
+	}
+
+	columns := make(map[string]int)
+	for i, header := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(header))] = i
+	}
+	urlColumn := csvColumn(columns, csvURLColumns...)
+	if urlColumn < 0 {
+		return result, fmt.Errorf("the CSV endpoint list does not have a URL column")
+	}
+	orgColumn := csvColumn(columns, csvOrgColumns...)
+	npiColumn := csvColumn(columns, "npi", "npiid", "npi id")
+	addressColumn := csvColumn(columns, "address")
+	cityColumn := csvColumn(columns, "city")
+	stateColumn := csvColumn(columns, "state")
+	zipColumn := csvColumn(columns, "zipcode", "zip code", "zip", "postal code")
+
+	for _, record := range records[1:] {
+		uri := csvValue(record, urlColumn)
+		if uri == "" {
+			continue
+		}
+		entry := EndpointEntry{
+			FHIRPatientFacingURI: uri,
+			ListSource:           listSource,
+		}
+		if orgName := csvValue(record, orgColumn); orgName != "" {
+			entry.OrganizationNames = []string{orgName}
+		}
+		if npiID := csvValue(record, npiColumn); npiID != "" {
+			entry.NPIIDs = []string{npiID}
+		}
+		location := &endpointmanager.Location{
+			Address1: csvValue(record, addressColumn),
+			City:     csvValue(record, cityColumn),
+			State:    csvValue(record, stateColumn),
+			ZipCode:  csvValue(record, zipColumn),
+		}
+		if !isEmptyLocation(location) {
+			entry.Locations = []*endpointmanager.Location{location}
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+
+	return result, nil
+}
+
+// csvColumn returns the index of the first of the headers present in columns, or -1 if there are none.
+func csvColumn(columns map[string]int, headers ...string) int {
+	for _, header := range headers {
+		if i, ok := columns[header]; ok {
+			return i
+		}
+	}
+	return -1
+}
+
+func csvValue(record []string, column int) string {
+	if column < 0 || column >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[column])
+}
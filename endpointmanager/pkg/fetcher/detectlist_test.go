@@ -0,0 +1,70 @@
+package fetcher
+
+import (
+	"fmt"
+	"testing"
+
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+)
+
+func Test_GetListOfEndpointsDetectFormat(t *testing.T) {
+	listSource := "15.04.04.2657.Care.01.00.0.200101"
+
+	// test FHIR bundle
+
+	result, err := GetListOfEndpointsDetectFormat(testFHIR, listSource)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(result.Entries) == 1, fmt.Sprintf("Expected 1 entry. Got %d", len(result.Entries)))
+	th.Assert(t, result.Entries[0].FHIRPatientFacingURI == "http://example2.com/DTSU2", "Expected the FHIR bundle to be parsed")
+	th.Assert(t, result.Entries[0].ListSource == listSource, fmt.Sprintf("Expected the list source %s. Got %s", listSource, result.Entries[0].ListSource))
+
+	// test vendor JSON
+
+	result, err = GetListOfEndpointsDetectFormat(testCerner, listSource)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(result.Entries) == 1, fmt.Sprintf("Expected 1 entry. Got %d", len(result.Entries)))
+	th.Assert(t, result.Entries[0].OrganizationNames[0] == "A Woman's Place, LLC", "Expected the Cerner list to be parsed")
+	th.Assert(t, result.Entries[0].ListSource == listSource, fmt.Sprintf("Expected the list source %s. Got %s", listSource, result.Entries[0].ListSource))
+
+	result, err = GetListOfEndpointsDetectFormat(testLantern, listSource)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(result.Entries) == 1 && result.Entries[0].NPIIDs[0] == "1", "Expected the Lantern list to be parsed")
+
+	result, err = GetListOfEndpointsDetectFormat(testDefault, listSource)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(result.Entries) == 1, fmt.Sprintf("Expected 1 entry. Got %d", len(result.Entries)))
+	th.Assert(t, result.Entries[0].FHIRPatientFacingURI == "https://example.com", "Expected the default list to be parsed")
+	th.Assert(t, result.Entries[0].ListSource == listSource, fmt.Sprintf("Expected the list source %s. Got %s", listSource, result.Entries[0].ListSource))
+
+	// test CSV
+
+	csvList := []byte("\xef\xbb\xbfOrganization Name,FHIR Base URL,City,State\n" +
+		"Example Hospital,https://example.com/fhir/r4,Boston,MA\n" +
+		"No URL Clinic,,,\n" +
+		"\"Example Clinic, LLC\",https://example.org/fhir/r4\n")
+	result, err = GetListOfEndpointsDetectFormat(csvList, listSource)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(result.Entries) == 2, fmt.Sprintf("Expected 2 entries. Got %d", len(result.Entries)))
+	th.Assert(t, result.Entries[0].FHIRPatientFacingURI == "https://example.com/fhir/r4", fmt.Sprintf("Unexpected URL %s", result.Entries[0].FHIRPatientFacingURI))
+	th.Assert(t, result.Entries[0].OrganizationNames[0] == "Example Hospital", "Expected the organization name to be parsed")
+	th.Assert(t, len(result.Entries[0].Locations) == 1 && result.Entries[0].Locations[0].City == "Boston", "Expected the location to be parsed")
+	th.Assert(t, result.Entries[0].ListSource == listSource, fmt.Sprintf("Expected the list source %s. Got %s", listSource, result.Entries[0].ListSource))
+	th.Assert(t, result.Entries[1].OrganizationNames[0] == "Example Clinic, LLC", "Expected the quoted organization name to be parsed")
+	th.Assert(t, result.Entries[1].Locations == nil, "Expected no location")
+
+	// test CSV without a URL column
+
+	_, err = GetListOfEndpointsDetectFormat([]byte("Organization Name,City\nExample Hospital,Boston\n"), listSource)
+	th.Assert(t, err != nil, "Expected an error due to the missing URL column")
+
+	// test unknown JSON
+
+	_, err = GetListOfEndpointsDetectFormat([]byte(`{"unknown": []}`), listSource)
+	th.Assert(t, err != nil, "Expected an error due to the unknown JSON format")
+
+	// test empty list
+
+	result, err = GetListOfEndpointsDetectFormat([]byte("  \n"), listSource)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(result.Entries) == 0, "Expected no entries")
+}
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
 	"github.com/pkg/errors"
 )
@@ -22,6 +23,7 @@ type EndpointEntry struct {
 	NPIIDs               []string
 	FHIRPatientFacingURI string
 	ListSource           string
+	Locations            []*endpointmanager.Location
 }
 
 // ListOfEndpoints is a structure for the whole EndpointSources file
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
 	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
 	logtest "github.com/sirupsen/logrus/hooks/test"
 )
@@ -227,3 +228,101 @@ func Test_convertInterfaceToList(t *testing.T) {
 	_, err = convertInterfaceToList(initialList3, "entry")
 	th.Assert(t, err != nil, fmt.Sprintf("Should have thrown endpoint list is not map[string]interface{} error, instead threw %s", err))
 }
+
+func Test_GetEndpointsLocations(t *testing.T) {
+	expectedLocation := &endpointmanager.Location{
+		Address1: "123 Main St",
+		City:     "Boston",
+		State:    "MA",
+		ZipCode:  "02114",
+	}
+
+	// test default list with address fields
+
+	testDefaultAddress := []byte(`{"Entries":[
+	{
+		"OrganizationName":"Test Default",
+		"FHIRPatientFacingURI":"https://example.com",
+		"Address": " 123 Main St ",
+		"City": "Boston",
+		"State": "MA",
+		"ZipCode": "02114"
+	},
+	{
+		"OrganizationName":"Test Default 2",
+		"FHIRPatientFacingURI":"https://example2.com"
+	}]}`)
+	defaultResult, err := GetListOfEndpoints(testDefaultAddress, "Test", "")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(defaultResult.Entries[0].Locations) == 1, fmt.Sprintf("expected 1 location, got %d", len(defaultResult.Entries[0].Locations)))
+	th.Assert(t, defaultResult.Entries[0].Locations[0].Equal(expectedLocation), fmt.Sprintf("expected %+v, got %+v", expectedLocation, defaultResult.Entries[0].Locations[0]))
+	th.Assert(t, defaultResult.Entries[1].Locations == nil, "expected no locations for an entry with no address fields")
+
+	// test lantern list with address fields
+
+	testLanternAddress := []byte(`{"Endpoints": [
+	{
+		"URL": "http://example.com/DTSU2/",
+		"OrganizationName": "fakeOrganization",
+		"NPIID": "1",
+		"Address": "123 Main St",
+		"City": "Boston",
+		"State": "MA",
+		"ZipCode": "02114"
+	}]}`)
+	lanternResult, err := GetListOfEndpointsKnownSource(testLanternAddress, "Lantern", "")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(lanternResult.Entries[0].Locations) == 1, fmt.Sprintf("expected 1 location, got %d", len(lanternResult.Entries[0].Locations)))
+	th.Assert(t, lanternResult.Entries[0].Locations[0].Equal(expectedLocation), fmt.Sprintf("expected %+v, got %+v", expectedLocation, lanternResult.Entries[0].Locations[0]))
+
+	// test fhir list with organization resources
+
+	testFHIROrganizations := []byte(`{"resourceType": "Bundle",
+		"entry": [
+			{
+				"fullUrl": "http://example.com/fhir/Endpoint/1",
+				"resource": {
+					"resourceType": "Endpoint",
+					"id": "1",
+					"managingOrganization": { "reference": "Organization/10" },
+					"address": "http://example.com/fhir/1"
+				}
+			},
+			{
+				"fullUrl": "http://example.com/fhir/Endpoint/2",
+				"resource": {
+					"resourceType": "Endpoint",
+					"id": "2",
+					"name": "Endpoint 2",
+					"address": "http://example.com/fhir/2"
+				}
+			},
+			{
+				"fullUrl": "http://example.com/fhir/Organization/10",
+				"resource": {
+					"resourceType": "Organization",
+					"id": "10",
+					"name": "Boston Clinic",
+					"address": [{ "line": ["123 Main St"], "city": "Boston", "state": "MA", "postalCode": "02114" }],
+					"endpoint": [{ "reference": "Endpoint/1" }, { "reference": "Endpoint/2" }]
+				}
+			}
+		]}`)
+	fhirResult, err := GetListOfEndpointsKnownSource(testFHIROrganizations, "FHIR", "")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(fhirResult.Entries) == 2, fmt.Sprintf("expected organization resources not to be endpoints, got %d entries", len(fhirResult.Entries)))
+	// referenced through managingOrganization and the organization's endpoint list
+	th.Assert(t, len(fhirResult.Entries[0].Locations) == 1, fmt.Sprintf("expected 1 location, got %d", len(fhirResult.Entries[0].Locations)))
+	th.Assert(t, fhirResult.Entries[0].Locations[0].Equal(expectedLocation), fmt.Sprintf("expected %+v, got %+v", expectedLocation, fhirResult.Entries[0].Locations[0]))
+	th.Assert(t, len(fhirResult.Entries[0].OrganizationNames) == 1 && fhirResult.Entries[0].OrganizationNames[0] == "Boston Clinic", fmt.Sprintf("expected the referenced organization's name, got %v", fhirResult.Entries[0].OrganizationNames))
+	// referenced only through the organization's endpoint list
+	th.Assert(t, len(fhirResult.Entries[1].Locations) == 1, fmt.Sprintf("expected 1 location, got %d", len(fhirResult.Entries[1].Locations)))
+	th.Assert(t, fhirResult.Entries[1].Locations[0].Equal(expectedLocation), fmt.Sprintf("expected %+v, got %+v", expectedLocation, fhirResult.Entries[1].Locations[0]))
+
+	// unresolvable managingOrganization references are still used as organization names
+
+	fhirResult, err = GetListOfEndpointsKnownSource(testFHIR, "FHIR", "")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, fhirResult.Entries[0].Locations == nil, "expected no locations for an endpoint with no organization resource")
+	th.Assert(t, fhirResult.Entries[0].OrganizationNames[0] == "Telstra Health", fmt.Sprintf("expected Telstra Health, got %v", fhirResult.Entries[0].OrganizationNames))
+}
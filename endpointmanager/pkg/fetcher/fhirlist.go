@@ -1,6 +1,9 @@
 package fetcher
 
 import (
+	"strings"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -20,11 +23,16 @@ type FHIRList struct{}
 		}
 	  }, ...
 ] }
+Organization resources in the same bundle are not endpoints. Their names and addresses are used for
+the endpoints that reference them, either through the endpoint's managingOrganization
+reference or through the organization's endpoint references.
 */
 func (fl FHIRList) GetEndpoints(fhirList []map[string]interface{}, listURL string) ListOfEndpoints {
 	var finalList ListOfEndpoints
 	var innerList []EndpointEntry
 
+	orgs, endptLocations := getFHIROrganizations(fhirList)
+
 	for entry := range fhirList {
 		fhirEntry := EndpointEntry{}
 		if listURL != "" {
@@ -34,6 +42,9 @@ func (fl FHIRList) GetEndpoints(fhirList []map[string]interface{}, listURL strin
 		}
 
 		resource, ok := fhirList[entry]["resource"].(map[string]interface{})
+		if ok && resource["resourceType"] == "Organization" {
+			continue
+		}
 		if ok {
 			uri, uriOk := resource["address"].(string)
 			if uriOk {
@@ -49,9 +60,19 @@ func (fl FHIRList) GetEndpoints(fhirList []map[string]interface{}, listURL strin
 					}
 					alternateName, orgOk := managingOrg["reference"].(string)
 					if orgOk {
-						fhirEntry.OrganizationNames = append(fhirEntry.OrganizationNames, alternateName)
+						if org, refOk := orgs[alternateName]; refOk {
+							if org.name != "" {
+								fhirEntry.OrganizationNames = append(fhirEntry.OrganizationNames, org.name)
+							}
+							fhirEntry.Locations = appendLocations(fhirEntry.Locations, org.locations...)
+						} else {
+							fhirEntry.OrganizationNames = append(fhirEntry.OrganizationNames, alternateName)
+						}
 					}
 				}
+				for _, ref := range fhirResourceReferences(fhirList[entry], resource, "Endpoint") {
+					fhirEntry.Locations = appendLocations(fhirEntry.Locations, endptLocations[ref]...)
+				}
 				nameEndpt, nameOk := resource["name"].(string)
 				if nameOk {
 					fhirEntry.OrganizationNames = append(fhirEntry.OrganizationNames, nameEndpt)
@@ -72,3 +93,60 @@ func (fl FHIRList) GetEndpoints(fhirList []map[string]interface{}, listURL strin
 	finalList.Entries = innerList
 	return finalList
 }
+
+type fhirOrganization struct {
+	name      string
+	locations []*endpointmanager.Location
+}
+
+// getFHIROrganizations collects the names and addresses of the Organization resources in the list. The
+// first map is keyed by each reference that may be used to refer to the organization, and the second
+// map holds the organizations' addresses keyed by each endpoint reference listed by the organizations.
+func getFHIROrganizations(fhirList []map[string]interface{}) (map[string]fhirOrganization, map[string][]*endpointmanager.Location) {
+	orgs := make(map[string]fhirOrganization)
+	endptLocations := make(map[string][]*endpointmanager.Location)
+
+	for _, entry := range fhirList {
+		resource, ok := entry["resource"].(map[string]interface{})
+		if !ok || resource["resourceType"] != "Organization" {
+			continue
+		}
+		locations := getFHIRAddressLocations(resource)
+		name, _ := resource["name"].(string)
+		for _, ref := range fhirResourceReferences(entry, resource, "Organization") {
+			orgs[ref] = fhirOrganization{name: name, locations: locations}
+		}
+		endpoints, _ := resource["endpoint"].([]interface{})
+		for _, endpointInt := range endpoints {
+			endpoint, ok := endpointInt.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			ref, ok := endpoint["reference"].(string)
+			if ok {
+				endptLocations[ref] = appendLocations(endptLocations[ref], locations...)
+			}
+		}
+	}
+
+	return orgs, endptLocations
+}
+
+// fhirResourceReferences returns the references that may be used to refer to the resource within the
+// list: its relative reference, "<resourceType>/<id>", and its fullUrl.
+func fhirResourceReferences(entry map[string]interface{}, resource map[string]interface{}, resourceType string) []string {
+	var refs []string
+	id, ok := resource["id"].(string)
+	if ok && id != "" {
+		refs = append(refs, resourceType+"/"+id)
+	}
+	fullURL, ok := entry["fullUrl"].(string)
+	if ok && fullURL != "" {
+		refs = append(refs, fullURL)
+		// the relative reference may also be given as the end of the fullUrl
+		if idx := strings.Index(fullURL, resourceType+"/"); idx > 0 {
+			refs = append(refs, fullURL[idx:])
+		}
+	}
+	return refs
+}
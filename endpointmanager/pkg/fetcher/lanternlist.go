@@ -1,5 +1,9 @@
 package fetcher
 
+import (
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+)
+
 // LanternList implements the Endpoints interface for lantern endpoint lists
 type LanternList struct{}
 
@@ -27,6 +31,10 @@ func (ll LanternList) GetEndpoints(lanternList []map[string]interface{}, listURL
 		if npiIDOk {
 			fhirEntry.NPIIDs = []string{npiID}
 		}
+		location := getListEntryLocation(lanternList[entry])
+		if location != nil {
+			fhirEntry.Locations = []*endpointmanager.Location{location}
+		}
 		innerList = append(innerList, fhirEntry)
 	}
 
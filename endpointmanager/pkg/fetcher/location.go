@@ -0,0 +1,100 @@
+package fetcher
+
+import (
+	"strings"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+)
+
+// getListEntryLocation returns the location described by the optional "Address", "City", "State" and
+// "ZipCode" fields of an endpoint list entry. nil is returned if none of the fields are present.
+func getListEntryLocation(entry map[string]interface{}) *endpointmanager.Location {
+	address, _ := entry["Address"].(string)
+	city, _ := entry["City"].(string)
+	state, _ := entry["State"].(string)
+	zipCode, _ := entry["ZipCode"].(string)
+
+	location := &endpointmanager.Location{
+		Address1: strings.TrimSpace(address),
+		City:     strings.TrimSpace(city),
+		State:    strings.TrimSpace(state),
+		ZipCode:  strings.TrimSpace(zipCode),
+	}
+	if isEmptyLocation(location) {
+		return nil
+	}
+	return location
+}
+
+// getFHIRAddressLocations returns the locations described by the addresses of a FHIR resource.
+// Based on: https://www.hl7.org/fhir/datatypes.html#Address
+func getFHIRAddressLocations(resource map[string]interface{}) []*endpointmanager.Location {
+	var locations []*endpointmanager.Location
+
+	addresses, ok := resource["address"].([]interface{})
+	if !ok {
+		return locations
+	}
+
+	for _, addressInt := range addresses {
+		address, ok := addressInt.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		location := &endpointmanager.Location{}
+		lines, _ := address["line"].([]interface{})
+		for i, lineInt := range lines {
+			line, ok := lineInt.(string)
+			if !ok {
+				continue
+			}
+			line = strings.TrimSpace(line)
+			switch i {
+			case 0:
+				location.Address1 = line
+			case 1:
+				location.Address2 = line
+			case 2:
+				location.Address3 = line
+			}
+		}
+		city, _ := address["city"].(string)
+		location.City = strings.TrimSpace(city)
+		state, _ := address["state"].(string)
+		location.State = strings.TrimSpace(state)
+		zipCode, _ := address["postalCode"].(string)
+		location.ZipCode = strings.TrimSpace(zipCode)
+
+		if !isEmptyLocation(location) {
+			locations = append(locations, location)
+		}
+	}
+
+	return locations
+}
+
+// appendLocations appends the given locations to the list, skipping any that are already in the list.
+func appendLocations(list []*endpointmanager.Location, locations ...*endpointmanager.Location) []*endpointmanager.Location {
+	for _, location := range locations {
+		found := false
+		for _, existing := range list {
+			if existing.Equal(location) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, location)
+		}
+	}
+	return list
+}
+
+func isEmptyLocation(location *endpointmanager.Location) bool {
+	return location.Address1 == "" &&
+		location.Address2 == "" &&
+		location.Address3 == "" &&
+		location.City == "" &&
+		location.State == "" &&
+		location.ZipCode == ""
+}
@@ -2,61 +2,63 @@ package populatefhirendpoints
 
 import (
 	"context"
-	"database/sql"
 	"time"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
-	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/fetcher"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
-// AddEndpointData iterates through the list of endpoints and adds each one to the database
-func AddEndpointData(ctx context.Context, store *postgresql.Store, endpoints *fetcher.ListOfEndpoints) error {
-	var firstUpdate time.Time
+// AddEndpointData adds or updates all of the endpoints in the list in bulk and removes the endpoints that are no
+// longer in the list's list source. The list is saved within a single transaction, so either all of it is saved or,
+// if there is an error, none of it is.
+func AddEndpointData(ctx context.Context, store endpointmanager.EndpointListStore, endpoints *fetcher.ListOfEndpoints) error {
+	if len(endpoints.Entries) == 0 {
+		return nil
+	}
 	var listsource = endpoints.Entries[0].ListSource
-	for i, endpoint := range endpoints.Entries {
-		select {
-		case <-ctx.Done():
-			return errors.Wrapf(ctx.Err(), "saved %d out of %d endpoints before context ended", i, len(endpoints.Entries))
-		default:
-			// ok
-		}
 
-		err := saveEndpointData(ctx, store, &endpoint)
+	select {
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "saved 0 out of %d endpoints before context ended", len(endpoints.Entries))
+	default:
+		// ok
+	}
+
+	var fhirEndpoints []*endpointmanager.FHIREndpoint
+	for _, endpoint := range endpoints.Entries {
+		fhirEndpoint, err := formatToFHIREndpt(&endpoint)
 		if err != nil {
 			log.Warn(err)
 			continue
 		}
-		if firstUpdate.IsZero() {
-			// get time of update for first endpoint
-			fhirURL := endpoint.FHIRPatientFacingURI
-			if fhirURL[len(fhirURL)-1:] != "/" {
-				fhirURL = fhirURL + "/"
-			}
-			existingEndpt, err := store.GetFHIREndpointUsingURLAndListSource(ctx, fhirURL, endpoint.ListSource)
-			if err != nil {
-				log.Warn(err)
-				continue
-			} else {
-				firstUpdate = existingEndpt.UpdatedAt
-			}
-		}
+		fhirEndpoints = append(fhirEndpoints, fhirEndpoint)
 	}
-
-	err := RemoveOldEndpoints(ctx, store, firstUpdate, listsource)
-	if err != nil {
-		log.Warn(err)
+	if len(fhirEndpoints) == 0 {
+		return nil
 	}
 
-	return nil
+	return store.WithEndpointListTx(ctx, func(txStore endpointmanager.EndpointListStore) error {
+		err := txStore.AddOrUpdateFHIREndpoints(ctx, fhirEndpoints)
+		if err != nil {
+			return errors.Wrapf(err, "saving the endpoints from list source %s failed", listsource)
+		}
+
+		// get time of update for first endpoint
+		firstEndpt, err := txStore.GetFHIREndpoint(ctx, fhirEndpoints[0].ID)
+		if err != nil {
+			return errors.Wrapf(err, "getting the update time of the endpoints from list source %s failed", listsource)
+		}
+
+		return RemoveOldEndpoints(ctx, txStore, firstEndpt.UpdatedAt, listsource)
+	})
 }
 
 // saveEndpointData formats the endpoint as a FHIREndpoint and then checks to see if it's in the database.
 // If it is, ignore it, if it isn't, add it to the database.
-func saveEndpointData(ctx context.Context, store *postgresql.Store, endpoint *fetcher.EndpointEntry) error {
+func saveEndpointData(ctx context.Context, store endpointmanager.EndpointStore, endpoint *fetcher.EndpointEntry) error {
 	fhirEndpoint, err := formatToFHIREndpt(endpoint)
 	if err != nil {
 		return err
@@ -81,51 +83,55 @@ func formatToFHIREndpt(endpoint *fetcher.EndpointEntry) (*endpointmanager.FHIREn
 		OrganizationNames: endpoint.OrganizationNames,
 		ListSource:        endpoint.ListSource,
 		NPIIDs:            endpoint.NPIIDs,
+		Locations:         endpoint.Locations,
 	}
 
-	// @TODO Get Location
-
 	return &dbEntry, nil
 }
 
 // RemoveOldEndpoints removes fhir endpoints from fhir_endpoints and fhir_endpoints_info
-// that are no longer in the given listsource
-func RemoveOldEndpoints(ctx context.Context, store *postgresql.Store, updateTime time.Time, listSource string) error {
-	// get endpoints that are from this listsource and have an update time before this time
-	fhirEndpoints, err := store.GetFHIREndpointsUsingListSourceAndUpdateTime(ctx, updateTime, listSource)
-	if err != nil {
-		return err
-	}
-
-	for _, endpoint := range fhirEndpoints {
-		err = store.DeleteFHIREndpoint(ctx, endpoint)
+// that are no longer in the given listsource. The endpoints are removed within a single transaction.
+func RemoveOldEndpoints(ctx context.Context, store endpointmanager.EndpointListStore, updateTime time.Time, listSource string) error {
+	var removed int
+	err := store.WithEndpointListTx(ctx, func(txStore endpointmanager.EndpointListStore) error {
+		// get endpoints that are from this listsource and have an update time before this time
+		fhirEndpoints, err := txStore.GetFHIREndpointsUsingListSourceAndUpdateTime(ctx, updateTime, listSource)
 		if err != nil {
-			log.Warn(err)
-			continue
+			return err
 		}
-		existingEndpointList, err := store.GetFHIREndpointInfosUsingURL(ctx, endpoint.URL)
-		if err == sql.ErrNoRows {
-			log.Warn(err)
-			continue
-		} else {
-			endpointList, err := store.GetFHIREndpointUsingURL(ctx, endpoint.URL)
+
+		for _, endpoint := range fhirEndpoints {
+			err = txStore.DeleteFHIREndpoint(ctx, endpoint)
+			if err != nil {
+				return errors.Wrapf(err, "deleting endpoint %s failed", endpoint.URL)
+			}
+			// the infos are only removed once the URL is no longer in any list source
+			endpointList, err := txStore.GetFHIREndpointUsingURL(ctx, endpoint.URL)
 			if err != nil {
-				log.Warn(err)
+				return err
+			}
+			if len(endpointList) != 0 {
 				continue
 			}
-			if len(endpointList) == 0 {
-				for _, existingEndpoint := range existingEndpointList {
-					err = store.DeleteFHIREndpointInfo(ctx, existingEndpoint)
-					if err != nil {
-						log.Warn(err)
-						continue
-					}
+			existingEndpointList, err := txStore.GetFHIREndpointInfosUsingURL(ctx, endpoint.URL)
+			if err != nil {
+				return err
+			}
+			for _, existingEndpoint := range existingEndpointList {
+				err = txStore.DeleteFHIREndpointInfo(ctx, existingEndpoint)
+				if err != nil {
+					return errors.Wrapf(err, "deleting the info for endpoint %s failed", endpoint.URL)
 				}
 			}
 		}
+		removed = len(fhirEndpoints)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	log.Infof("Removed %d endpoints from list source %s", len(fhirEndpoints), listSource)
+	log.Infof("Removed %d endpoints from list source %s", removed, listSource)
 
 	return nil
 }
@@ -1,11 +1,15 @@
 package populatefhirendpoints
 
 import (
+	"context"
+	"fmt"
 	"testing"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/memorystore"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/fetcher"
 	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+	"github.com/pkg/errors"
 )
 
 var testEndpointEntry fetcher.EndpointEntry = fetcher.EndpointEntry{
@@ -35,4 +39,94 @@ func Test_formatToFHIREndpt(t *testing.T) {
 	fhirEndpt, err = formatToFHIREndpt(&endpt)
 	th.Assert(t, err == nil, err)
 	th.Assert(t, fhirEndpt.Equal(&expectedFHIREndpt), "EndpointEntry did not get parsed into a FHIREndpoint as expected")
+
+	// test that the entry's locations are kept
+	location := &endpointmanager.Location{Address1: "123 Main St", City: "Boston", State: "MA", ZipCode: "02114"}
+	endpt.Locations = []*endpointmanager.Location{location}
+	expectedFHIREndpt.Locations = []*endpointmanager.Location{location}
+	fhirEndpt, err = formatToFHIREndpt(&endpt)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, fhirEndpt.Equal(&expectedFHIREndpt), "EndpointEntry locations did not get parsed into the FHIREndpoint as expected")
+}
+
+func Test_AddEndpointDataWithMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := memorystore.NewStore()
+
+	// an endpoint that is no longer in the list, along with its info
+	oldEndpoint := &endpointmanager.FHIREndpoint{
+		URL:        "https://old.example.com/FHIR/DSTU2/",
+		ListSource: "epicList",
+	}
+	err := store.AddFHIREndpoint(ctx, oldEndpoint)
+	th.Assert(t, err == nil, err)
+	metadataID, err := store.AddFHIREndpointMetadata(ctx, &endpointmanager.FHIREndpointMetadata{URL: oldEndpoint.URL})
+	th.Assert(t, err == nil, err)
+	err = store.AddFHIREndpointInfo(ctx, &endpointmanager.FHIREndpointInfo{URL: oldEndpoint.URL, RequestedFhirVersion: "None"}, metadataID)
+	th.Assert(t, err == nil, err)
+
+	// an endpoint from another list source
+	otherEndpoint := &endpointmanager.FHIREndpoint{
+		URL:        "https://other.example.com/FHIR/DSTU2/",
+		ListSource: "otherList",
+	}
+	err = store.AddFHIREndpoint(ctx, otherEndpoint)
+	th.Assert(t, err == nil, err)
+
+	entry := testEndpointEntry
+	err = AddEndpointData(ctx, store, &fetcher.ListOfEndpoints{Entries: []fetcher.EndpointEntry{entry}})
+	th.Assert(t, err == nil, err)
+
+	endpoints, err := store.GetAllFHIREndpoints(ctx)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(endpoints) == 2, fmt.Sprintf("expected the old endpoint to be removed, got %d endpoints", len(endpoints)))
+	th.Assert(t, endpoints[0].URL == otherEndpoint.URL, "expected the endpoint from the other list source to be kept")
+	th.Assert(t, endpoints[1].URL == testFHIREndpoint.URL, "expected the endpoint from the list to be added")
+
+	infos, err := store.GetFHIREndpointInfosUsingURL(ctx, oldEndpoint.URL)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(infos) == 0, "expected the old endpoint's info to be removed")
+}
+
+// failingInfoStore fails to delete endpoint infos, to check that list refreshes are rolled back.
+type failingInfoStore struct {
+	*memorystore.Store
+}
+
+func (s *failingInfoStore) DeleteFHIREndpointInfo(ctx context.Context, e *endpointmanager.FHIREndpointInfo) error {
+	return errors.New("deleting the info failed")
+}
+
+func (s *failingInfoStore) WithEndpointListTx(ctx context.Context, fn func(txStore endpointmanager.EndpointListStore) error) error {
+	return s.Store.WithEndpointListTx(ctx, func(txStore endpointmanager.EndpointListStore) error {
+		return fn(&failingInfoStore{txStore.(*memorystore.Store)})
+	})
+}
+
+func Test_AddEndpointDataRollback(t *testing.T) {
+	ctx := context.Background()
+	store := &failingInfoStore{memorystore.NewStore()}
+
+	oldEndpoint := &endpointmanager.FHIREndpoint{
+		URL:        "https://old.example.com/FHIR/DSTU2/",
+		ListSource: "epicList",
+	}
+	err := store.AddFHIREndpoint(ctx, oldEndpoint)
+	th.Assert(t, err == nil, err)
+	metadataID, err := store.AddFHIREndpointMetadata(ctx, &endpointmanager.FHIREndpointMetadata{URL: oldEndpoint.URL})
+	th.Assert(t, err == nil, err)
+	err = store.AddFHIREndpointInfo(ctx, &endpointmanager.FHIREndpointInfo{URL: oldEndpoint.URL, RequestedFhirVersion: "None"}, metadataID)
+	th.Assert(t, err == nil, err)
+
+	// removing the old endpoint's info fails, so none of the list is saved
+	err = AddEndpointData(ctx, store, &fetcher.ListOfEndpoints{Entries: []fetcher.EndpointEntry{testEndpointEntry}})
+	th.Assert(t, err != nil, "expected an error removing the old endpoint's info")
+
+	endpoints, err := store.GetAllFHIREndpoints(ctx)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(endpoints) == 1 && endpoints[0].ID == oldEndpoint.ID, fmt.Sprintf("expected only the old endpoint to be stored, got %d endpoints", len(endpoints)))
+
+	infos, err := store.GetFHIREndpointInfosUsingURL(ctx, oldEndpoint.URL)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(infos) == 1, "expected the old endpoint's info to be kept")
 }
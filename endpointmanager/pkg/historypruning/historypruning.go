@@ -10,25 +10,126 @@ import (
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/smartparser"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 )
 
-// PruneInfoHistory checks info table and prunes any repetitive entries
-func PruneInfoHistory(ctx context.Context, store *postgresql.Store, queryInterval bool) {
-	var rows *sql.Rows
-	var err error
+// The pruning modes, which have separate checkpoints. The query interval mode only checks the entries entered
+// within three query intervals past the pruning threshold, and the threshold mode checks every entry past it.
+const (
+	ModeQueryInterval = "query_interval"
+	ModeThreshold     = "threshold"
+)
+
+// Result describes what a pruning run removed, or would have removed in a dry run.
+type Result struct {
+	URLs                     int
+	RemovedEntries           int
+	RemovedValidationResults int
+}
+
+// PruneInfoHistory checks info table and prunes any repetitive entries. The URLs are pruned in batches of
+// pruning_batch_size, each in its own transaction, and a checkpoint is recorded after each batch so that a run
+// that stops part way through resumes after the last completed batch. If dryRun is true, nothing is removed and
+// the result reports what would have been removed.
+func PruneInfoHistory(ctx context.Context, store *postgresql.Store, queryInterval bool, dryRun bool) (*Result, error) {
+	mode := ModeThreshold
+	if queryInterval {
+		mode = ModeQueryInterval
+	}
+	batchSize := viper.GetInt("pruning_batch_size")
+	if batchSize < 1 {
+		return nil, errors.Errorf("the pruning batch size must be at least 1, got %d", batchSize)
+	}
+
+	lastURL, err := store.GetHistoryPruningCheckpoint(ctx, mode)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting the history pruning checkpoint")
+	}
+	if lastURL != "" {
+		log.Infof("resuming history pruning after %s", lastURL)
+	}
+
+	var result Result
+	for {
+		select {
+		case <-ctx.Done():
+			return &result, errors.Wrapf(ctx.Err(), "pruned the history of %d URLs before context ended", result.URLs)
+		default:
+			// ok
+		}
+
+		urls, err := store.PruningGetInfoHistoryURLs(ctx, queryInterval, lastURL, batchSize)
+		if err != nil {
+			return &result, errors.Wrap(err, "error getting the URLs to prune")
+		}
+		if len(urls) == 0 {
+			break
+		}
+		lastURL = urls[len(urls)-1]
+
+		entries, err := getPrunableEntries(ctx, store, queryInterval, urls)
+		if err != nil {
+			return &result, errors.Wrapf(err, "error checking the history of the URLs up to %s", lastURL)
+		}
+
+		if !dryRun {
+			err = store.PruningDeleteInfoHistoryEntries(ctx, entries, mode, lastURL)
+			if err != nil {
+				return &result, errors.Wrapf(err, "error pruning the history of the URLs up to %s", lastURL)
+			}
+		}
+		result.add(urls, entries)
+	}
+
+	if !dryRun {
+		err = store.DeleteHistoryPruningCheckpoint(ctx, mode)
+		if err != nil {
+			return &result, errors.Wrap(err, "error removing the history pruning checkpoint")
+		}
+	}
+	return &result, nil
+}
+
+func (r *Result) add(urls []string, entries []postgresql.PruningEntry) {
+	r.URLs += len(urls)
+	r.RemovedEntries += len(entries)
+	valResIDs := make(map[int64]bool)
+	for _, entry := range entries {
+		if entry.ValidationResultID.Valid {
+			valResIDs[entry.ValidationResultID.Int64] = true
+		}
+	}
+	r.RemovedValidationResults += len(valResIDs)
+}
+
+// getPrunableEntries streams the info history entries of the given URLs and returns the ones that repeat the entry
+// before them.
+func getPrunableEntries(ctx context.Context, store *postgresql.Store, queryInterval bool, urls []string) ([]postgresql.PruningEntry, error) {
+	rows, err := store.PruningGetInfoHistory(ctx, queryInterval, urls)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	rows, err = store.PruningGetInfoHistory(ctx, queryInterval)
-	helpers.FailOnError("", err)
+	var entries []postgresql.PruningEntry
 
 	if !rows.Next() {
-		return
+		return entries, rows.Err()
 	}
 
-	_, fhirURL1, _, capStat1, tlsVersion1, mimeTypes1, smartResponse1, _, requestedFhirVersion1 := getRowInfo(rows)
+	_, fhirURL1, _, capStat1, tlsVersion1, mimeTypes1, smartResponse1, _, requestedFhirVersion1, err := getRowInfo(rows)
+	if err != nil {
+		return nil, err
+	}
 
 	for rows.Next() {
 
-		operation2, fhirURL2, entryDate2, capStat2, tlsVersion2, mimeTypes2, smartResponse2, valResID2, requestedFhirVersion2 := getRowInfo(rows)
+		operation2, fhirURL2, entryDate2, capStat2, tlsVersion2, mimeTypes2, smartResponse2, valResID2, requestedFhirVersion2, err := getRowInfo(rows)
+		if err != nil {
+			return nil, err
+		}
 
 		equalFhirEntries := fhirURL1 == fhirURL2
 
@@ -64,13 +165,12 @@ func PruneInfoHistory(ctx context.Context, store *postgresql.Store, queryInterva
 		}
 
 		if equalFhirEntries && operation2 == "U" {
-			err := store.PruningDeleteInfoHistory(ctx, fhirURL1, entryDate2, requestedFhirVersion1)
-			helpers.FailOnError("", err)
-			// Delete the validation table entries for the history table row
-			err = store.PruningDeleteValidationTable(ctx, valResID2)
-			helpers.FailOnError("", err)
-			err = store.PruningDeleteValidationResultEntry(ctx, valResID2)
-			helpers.FailOnError("", err)
+			entries = append(entries, postgresql.PruningEntry{
+				URL:                  fhirURL1,
+				RequestedFhirVersion: requestedFhirVersion1,
+				EnteredAt:            entryDate2,
+				ValidationResultID:   valResID2,
+			})
 		} else {
 			fhirURL1 = fhirURL2
 			capStat1 = capStat2
@@ -81,9 +181,10 @@ func PruneInfoHistory(ctx context.Context, store *postgresql.Store, queryInterva
 			continue
 		}
 	}
+	return entries, rows.Err()
 }
 
-func getRowInfo(rows *sql.Rows) (string, string, string, capabilityparser.CapabilityStatement, string, []string, smartparser.SMARTResponse, int, string) {
+func getRowInfo(rows *sql.Rows) (string, string, string, capabilityparser.CapabilityStatement, string, []string, smartparser.SMARTResponse, sql.NullInt64, string, error) {
 	var capInt map[string]interface{}
 	var fhirURL string
 	var operation string
@@ -93,20 +194,28 @@ func getRowInfo(rows *sql.Rows) (string, string, string, capabilityparser.Capabi
 	var mimeTypes []string
 	var smartResponseJSON []byte
 	var smartResponseInt map[string]interface{}
-	var valResID int
+	var valResID sql.NullInt64
 	var requestedFhirVersion string
 
 	err := rows.Scan(&operation, &fhirURL, &capStatJSON, &entryDate, &tlsVersion, pq.Array(&mimeTypes), &smartResponseJSON, &valResID, &requestedFhirVersion)
-	helpers.FailOnError("", err)
+	if err != nil {
+		return "", "", "", nil, "", nil, nil, valResID, "", err
+	}
 
 	err = json.Unmarshal(capStatJSON, &capInt)
-	helpers.FailOnError("", err)
+	if err != nil {
+		return "", "", "", nil, "", nil, nil, valResID, "", err
+	}
 	capStat, err := capabilityparser.NewCapabilityStatementFromInterface(capInt)
-	helpers.FailOnError("", err)
+	if err != nil {
+		return "", "", "", nil, "", nil, nil, valResID, "", err
+	}
 
 	err = json.Unmarshal(smartResponseJSON, &smartResponseInt)
-	helpers.FailOnError("", err)
+	if err != nil {
+		return "", "", "", nil, "", nil, nil, valResID, "", err
+	}
 	smartResponse := smartparser.NewSMARTRespFromInterface(smartResponseInt)
 
-	return operation, fhirURL, entryDate, capStat, tlsVersion, mimeTypes, smartResponse, valResID, requestedFhirVersion
+	return operation, fhirURL, entryDate, capStat, tlsVersion, mimeTypes, smartResponse, valResID, requestedFhirVersion, nil
 }
@@ -119,11 +119,11 @@ func Test_PruneInfoHistory(t *testing.T) {
 		url,
 		tls_version,
 		mime_types,
-		smart_response, 
-		capability_statement,
+		smart_response_hash, 
+		capability_statement_hash,
 		validation_result_id,
 		requested_fhir_version)			
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);`)
+	VALUES ($1, $2, $3, $4, $5, $6, add_fhir_endpoints_document($7), add_fhir_endpoints_document($8), $9, $10);`)
 	th.Assert(t, err == nil, err)
 	defer addFHIREndpointInfoHistoryStatement.Close()
 
@@ -178,7 +178,8 @@ func Test_PruneInfoHistory(t *testing.T) {
 	th.Assert(t, count == 2, "Should have got 2, got "+strconv.Itoa(count))
 
 	// Call PruneInfoHistory function which will call the history pruning function
-	PruneInfoHistory(ctx, store, false)
+	_, err = PruneInfoHistory(ctx, store, false, false)
+	th.Assert(t, err == nil, err)
 
 	// Info history table should have 2 entries as history pruning will not remove entries less than month old
 	err = ctStatement.QueryRow(testEndpointURL).Scan(&count)
@@ -247,7 +248,8 @@ func Test_PruneInfoHistory(t *testing.T) {
 	th.Assert(t, count == 3, "Should have got 3, got "+strconv.Itoa(count))
 
 	// PruneInfoHistory ignores current entry and prunes old repetitive info entries, keeping the oldest entry
-	PruneInfoHistory(ctx, store, false)
+	_, err = PruneInfoHistory(ctx, store, false, false)
+	th.Assert(t, err == nil, err)
 
 	// Should be 2 entries as history pruning will not remove the I operation entries but will remove each of their duplicates
 	err = ctStatement.QueryRow(testEndpointURL).Scan(&count)
@@ -314,7 +316,8 @@ func Test_PruneInfoHistory(t *testing.T) {
 	th.Assert(t, count == 3, "Should have got 3, got "+strconv.Itoa(count))
 
 	// PruneInfoHistory ignores current entry and prunes old repetitive info entries, keeping the oldest entry
-	PruneInfoHistory(ctx, store, false)
+	_, err = PruneInfoHistory(ctx, store, false, false)
+	th.Assert(t, err == nil, err)
 
 	// Should be 1 entry as history pruning will remove the two newest repetitive entries and keep oldest repetitive entry
 	err = ctStatement.QueryRow(testEndpointURL).Scan(&count)
@@ -394,7 +397,8 @@ func Test_PruneInfoHistory(t *testing.T) {
 	th.Assert(t, count == 4, "Should have got 4, got "+strconv.Itoa(count))
 
 	// Call PruneInfoHistory function
-	PruneInfoHistory(ctx, store, false)
+	_, err = PruneInfoHistory(ctx, store, false, false)
+	th.Assert(t, err == nil, err)
 
 	// Info history table should have only 1 entry as history pruning will remove all old entries if their capability statements only differ by date field and keep only oldest entry
 	err = ctStatement.QueryRow(testEndpointURL).Scan(&count)
@@ -418,6 +422,18 @@ func Test_PruneInfoHistory(t *testing.T) {
 	err = checkValidationResultCount(ctx, store, 1)
 	th.Assert(t, err == nil, err)
 
+	// the capability statement with the modified date field is no longer referenced and is removed, while the
+	// statement of the remaining entry is kept
+	var docCount int
+	err = store.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM fhir_endpoints_documents WHERE document->>'date' = '2010-01-03 15:04:05'`).Scan(&docCount)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, docCount == 0, fmt.Sprintf("Expected the pruned capability statement to be removed, found %d", docCount))
+	err = store.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM fhir_endpoints_documents
+		WHERE hash IN (SELECT capability_statement_hash FROM fhir_endpoints_info_history WHERE url = $1)`, testEndpointURL).Scan(&docCount)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, docCount == 1, fmt.Sprintf("Expected the remaining capability statement to be kept, found %d", docCount))
+
 	// Clear history table in database
 	_, err = clearStatement.ExecContext(ctx, testEndpointURL)
 	th.Assert(t, err == nil, err)
@@ -477,7 +493,8 @@ func Test_PruneInfoHistory(t *testing.T) {
 	th.Assert(t, count == 4, "Should have got 4, got "+strconv.Itoa(count))
 
 	// Call PruneInfoHistory function
-	PruneInfoHistory(ctx, store, false)
+	_, err = PruneInfoHistory(ctx, store, false, false)
+	th.Assert(t, err == nil, err)
 
 	// Info history table should have 2 entries as history pruning will remove 1 entry with modified description and 1 entry without modified description, keeping the oldest of each
 	err = ctStatement.QueryRow(testEndpointURL).Scan(&count)
@@ -564,7 +581,8 @@ func Test_PruneInfoHistory(t *testing.T) {
 	th.Assert(t, count == 5, "Should have got 5, got "+strconv.Itoa(count))
 
 	// Call PruneInfoHistory function
-	PruneInfoHistory(ctx, store, false)
+	_, err = PruneInfoHistory(ctx, store, false, false)
+	th.Assert(t, err == nil, err)
 
 	// Info history table should have 3 entries as history pruning will remove 1 of the first two equal entries, will not remove the modified description entry in middle, and will remove 1 of the oldest non modified capability statements
 	err = ctStatement.QueryRow(testEndpointURL).Scan(&count)
@@ -611,7 +629,8 @@ func Test_PruneInfoHistory(t *testing.T) {
 	th.Assert(t, count == 2, "Should have got 2, got "+strconv.Itoa(count))
 
 	// Call PruneInfoHistory function
-	PruneInfoHistory(ctx, store, false)
+	_, err = PruneInfoHistory(ctx, store, false, false)
+	th.Assert(t, err == nil, err)
 
 	// Info history table should have 1 entries as history pruning will remove the newer null capability statement entry
 	err = ctStatement.QueryRow(testEndpointURL).Scan(&count)
@@ -674,7 +693,8 @@ func Test_PruneInfoHistory(t *testing.T) {
 	th.Assert(t, count == 4, "Should have got 4, got "+strconv.Itoa(count))
 
 	// Call PruneInfoHistory function
-	PruneInfoHistory(ctx, store, false)
+	_, err = PruneInfoHistory(ctx, store, false, false)
+	th.Assert(t, err == nil, err)
 
 	// Info history table should have 2 entry as history pruning will remove 1 of the non null capability statment entries and 1 of the null capability statement entries
 	err = ctStatement.QueryRow(testEndpointURL).Scan(&count)
@@ -760,7 +780,8 @@ func Test_PruneInfoHistory(t *testing.T) {
 	th.Assert(t, count == 5, "Should have got 5, got "+strconv.Itoa(count))
 
 	// Call PruneInfoHistory function
-	PruneInfoHistory(ctx, store, false)
+	_, err = PruneInfoHistory(ctx, store, false, false)
+	th.Assert(t, err == nil, err)
 
 	// Info history table should have 3 entries as history pruning will remove 1 of the first two old null entries, it will not remove the non-null entry in middle, and it will remove 1 of the older null entries more entries
 	err = ctStatement.QueryRow(testEndpointURL).Scan(&count)
@@ -851,7 +872,8 @@ func Test_PruneInfoHistory(t *testing.T) {
 	th.Assert(t, count == 6, "Should have got 6, got "+strconv.Itoa(count))
 
 	// Call PruneInfoHistory function
-	PruneInfoHistory(ctx, store, false)
+	_, err = PruneInfoHistory(ctx, store, false, false)
+	th.Assert(t, err == nil, err)
 
 	// Info history table should have 3 entries as history pruning will keep one entry for each differing mime type
 	err = ctStatement.QueryRow(testEndpointURL).Scan(&count)
@@ -921,7 +943,8 @@ func Test_PruneInfoHistory(t *testing.T) {
 	th.Assert(t, count == 4, "Should have got 4, got "+strconv.Itoa(count))
 
 	// Call PruneInfoHistory function
-	PruneInfoHistory(ctx, store, false)
+	_, err = PruneInfoHistory(ctx, store, false, false)
+	th.Assert(t, err == nil, err)
 
 	// Info history table should have 2 entries one for each differing tls version
 	err = ctStatement.QueryRow(testEndpointURL).Scan(&count)
@@ -1024,7 +1047,8 @@ func Test_PruneInfoHistory(t *testing.T) {
 	th.Assert(t, count == 6, "Should have got 6, got "+strconv.Itoa(count))
 
 	// Call PruneInfoHistory function
-	PruneInfoHistory(ctx, store, false)
+	_, err = PruneInfoHistory(ctx, store, false, false)
+	th.Assert(t, err == nil, err)
 
 	// Info history table should have 3 entries one for each differing smart response
 	err = ctStatement.QueryRow(testEndpointURL).Scan(&count)
@@ -1076,7 +1100,8 @@ func Test_PruneInfoHistory(t *testing.T) {
 	th.Assert(t, count == 2, "Should have got 2, got "+strconv.Itoa(count))
 
 	// Call PruneInfoHistory function
-	PruneInfoHistory(ctx, store, false)
+	_, err = PruneInfoHistory(ctx, store, false, false)
+	th.Assert(t, err == nil, err)
 
 	// Info history table should have 2 entries as history pruning will keep both entries for an endpoint if their requested version differs
 	err = ctStatement.QueryRow(testEndpointURL).Scan(&count)
@@ -1229,3 +1254,100 @@ func setup() error {
 func teardown() {
 	store.Close()
 }
+
+func Test_PruneInfoHistoryDryRunAndCheckpoint(t *testing.T) {
+	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
+	defer teardown(t, store.DB)
+
+	setupCapabilityStatement(t, filepath.Join("../testdata", "cerner_capability_dstu2.json"))
+
+	ctx := context.Background()
+
+	var err error
+
+	addFHIREndpointInfoHistoryStatement, err = store.DB.Prepare(`
+	INSERT INTO fhir_endpoints_info_history (
+		operation, 
+		entered_at, 
+		id, 
+		url,
+		tls_version,
+		mime_types,
+		smart_response_hash, 
+		capability_statement_hash,
+		validation_result_id,
+		requested_fhir_version)			
+	VALUES ($1, $2, $3, $4, $5, $6, add_fhir_endpoints_document($7), add_fhir_endpoints_document($8), $9, $10);`)
+	th.Assert(t, err == nil, err)
+	defer addFHIREndpointInfoHistoryStatement.Close()
+
+	var count int
+	ctStatement, err := store.DB.Prepare(`SELECT count(*) FROM fhir_endpoints_info_history WHERE url = $1;`)
+	th.Assert(t, err == nil, err)
+	defer ctStatement.Close()
+
+	// Add three identical entries older than the pruning threshold for each of two endpoints
+	threshold := viper.GetInt("pruning_threshold")
+	pastDate := threshold + 3*(1440)
+	otherFhirEndpointInfo := testFhirEndpointInfo2
+	otherFhirEndpointInfo.CapabilityStatement = testFhirEndpointInfo.CapabilityStatement
+	for _, info := range []endpointmanager.FHIREndpointInfo{testFhirEndpointInfo, otherFhirEndpointInfo} {
+		for i := 0; i < 3; i++ {
+			valRes, err := store.AddValidationResult(ctx)
+			th.Assert(t, err == nil, fmt.Sprintf("Error when adding to the validation_result table %s", err))
+			err = store.AddValidation(ctx, &testValidation, valRes)
+			th.Assert(t, err == nil, fmt.Sprintf("Error when adding to the validation table %s", err))
+
+			enteredAt := time.Now().Add(time.Duration((-1)*(pastDate-i)) * time.Minute).Format("2006-01-02 15:04:05.000000000")
+			err = AddFHIREndpointInfoHistory(ctx, store, info, enteredAt, idCount, "U", valRes)
+			th.Assert(t, err == nil, err)
+		}
+	}
+
+	// A dry run reports the duplicate entries without removing them
+	result, err := PruneInfoHistory(ctx, store, false, true)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, result.URLs == 2, fmt.Sprintf("Should have checked 2 URLs, checked %d", result.URLs))
+	th.Assert(t, result.RemovedEntries == 4, fmt.Sprintf("Should have reported 4 entries, reported %d", result.RemovedEntries))
+	th.Assert(t, result.RemovedValidationResults == 4, fmt.Sprintf("Should have reported 4 validation results, reported %d", result.RemovedValidationResults))
+	err = checkValidationResultCount(ctx, store, 6)
+	th.Assert(t, err == nil, err)
+	for _, url := range []string{testFhirEndpointInfo.URL, otherFhirEndpointInfo.URL} {
+		err = ctStatement.QueryRow(url).Scan(&count)
+		th.Assert(t, err == nil, err)
+		th.Assert(t, count == 3, "Should have got 3, got "+strconv.Itoa(count))
+	}
+
+	// Record a checkpoint after the first URL, as if an earlier run stopped after pruning it
+	_, err = store.DB.ExecContext(ctx, `INSERT INTO history_pruning_checkpoints (mode, last_url) VALUES ($1, $2)`, ModeThreshold, testFhirEndpointInfo.URL)
+	th.Assert(t, err == nil, err)
+
+	// The run resumes after the checkpoint, so only the second URL is pruned
+	result, err = PruneInfoHistory(ctx, store, false, false)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, result.URLs == 1, fmt.Sprintf("Should have checked 1 URL, checked %d", result.URLs))
+	th.Assert(t, result.RemovedEntries == 2, fmt.Sprintf("Should have removed 2 entries, removed %d", result.RemovedEntries))
+	err = ctStatement.QueryRow(testFhirEndpointInfo.URL).Scan(&count)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, count == 3, "Should have got 3, got "+strconv.Itoa(count))
+	err = ctStatement.QueryRow(otherFhirEndpointInfo.URL).Scan(&count)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, count == 1, "Should have got 1, got "+strconv.Itoa(count))
+	err = checkValidationResultCount(ctx, store, 4)
+	th.Assert(t, err == nil, err)
+
+	// The finished run removed its checkpoint, so the next run checks every URL
+	lastURL, err := store.GetHistoryPruningCheckpoint(ctx, ModeThreshold)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, lastURL == "", "Expected the checkpoint to be removed, got "+lastURL)
+
+	result, err = PruneInfoHistory(ctx, store, false, false)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, result.URLs == 2, fmt.Sprintf("Should have checked 2 URLs, checked %d", result.URLs))
+	th.Assert(t, result.RemovedEntries == 2, fmt.Sprintf("Should have removed 2 entries, removed %d", result.RemovedEntries))
+	err = ctStatement.QueryRow(testFhirEndpointInfo.URL).Scan(&count)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, count == 1, "Should have got 1, got "+strconv.Itoa(count))
+	err = checkValidationResultCount(ctx, store, 2)
+	th.Assert(t, err == nil, err)
+}
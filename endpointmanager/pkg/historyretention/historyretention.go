@@ -0,0 +1,144 @@
+package historyretention
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// monthsAhead is the number of months past the current month that partitions are created for, so that the history
+// is not written to the default partitions if retention is not applied for a while.
+const monthsAhead = 3
+
+// Policy is how long the history partitions are kept in each tier. A partition keeps all of its rows for FullMonths
+// months, is summarized for another SummaryMonths months and is then dropped.
+type Policy struct {
+	FullMonths    int
+	SummaryMonths int
+}
+
+// Action is a change of a history partition to a later tier.
+type Action struct {
+	Partition *endpointmanager.HistoryPartition
+	Tier      string
+}
+
+// PolicyFromConfig returns the policy set by the retention_full_months and retention_summary_months configuration.
+func PolicyFromConfig() (Policy, error) {
+	policy := Policy{
+		FullMonths:    viper.GetInt("retention_full_months"),
+		SummaryMonths: viper.GetInt("retention_summary_months"),
+	}
+	return policy, policy.Validate()
+}
+
+// Validate returns an error if the policy would drop or summarize the history of the current month.
+func (p Policy) Validate() error {
+	if p.FullMonths < 1 {
+		return fmt.Errorf("the history must be kept in full for at least 1 month, got %d", p.FullMonths)
+	}
+	if p.SummaryMonths < 0 {
+		return fmt.Errorf("the number of months to keep summarized history can not be negative, got %d", p.SummaryMonths)
+	}
+	return nil
+}
+
+// TargetTier returns the tier that a partition should be in at 'now' under the policy.
+func (p Policy) TargetTier(partition *endpointmanager.HistoryPartition, now time.Time) string {
+	monthsOld := partition.MonthsOld(now)
+	if monthsOld < p.FullMonths {
+		return endpointmanager.TierFull
+	}
+	if monthsOld < p.FullMonths+p.SummaryMonths {
+		return endpointmanager.TierSummary
+	}
+	return endpointmanager.TierDropped
+}
+
+// PlanRetention returns the actions that move each partition to the tier that the policy sets for it. Partitions
+// only move to later tiers, so a partition is never restored by a policy that keeps more history.
+func PlanRetention(partitions []*endpointmanager.HistoryPartition, policy Policy, now time.Time) []Action {
+	var actions []Action
+	for _, partition := range partitions {
+		target := policy.TargetTier(partition, now)
+		if tierOrder(target) > tierOrder(partition.Tier) {
+			actions = append(actions, Action{Partition: partition, Tier: target})
+		}
+	}
+	return actions
+}
+
+// ApplyRetention creates the upcoming history partitions and applies the policy to the existing ones. Each partition
+// is changed in its own transaction. A partition that can not be changed is logged and left in its tier to be
+// retried the next time the policy is applied. If dryRun is true, the planned actions are only logged.
+func ApplyRetention(ctx context.Context, store endpointmanager.HistoryPartitionStore, policy Policy, now time.Time, dryRun bool) error {
+	err := policy.Validate()
+	if err != nil {
+		return err
+	}
+
+	if !dryRun {
+		err = store.CreateHistoryPartitions(ctx, monthsAhead)
+		if err != nil {
+			return fmt.Errorf("creating the history partitions failed: %s", err)
+		}
+	}
+
+	partitions, err := store.GetHistoryPartitions(ctx)
+	if err != nil {
+		return fmt.Errorf("getting the history partitions failed: %s", err)
+	}
+
+	actions := PlanRetention(partitions, policy, now)
+	applied := 0
+	for _, action := range actions {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("applied %d out of %d history retention actions before context ended: %s", applied, len(actions), ctx.Err())
+		default:
+			// ok
+		}
+
+		if dryRun {
+			log.Infof("would move history partition %s from the %s tier to the %s tier", action.Partition.Name, action.Partition.Tier, action.Tier)
+			continue
+		}
+
+		log.Infof("moving history partition %s from the %s tier to the %s tier", action.Partition.Name, action.Partition.Tier, action.Tier)
+		if action.Tier == endpointmanager.TierDropped {
+			err = store.DropHistoryPartition(ctx, action.Partition)
+		} else {
+			err = store.SummarizeHistoryPartition(ctx, action.Partition)
+		}
+		if err == postgresql.ErrHistoryPartitionInUse {
+			log.Infof("keeping history partition %s, which is still referenced by the current endpoint information", action.Partition.Name)
+			continue
+		} else if err != nil {
+			log.Warnf("moving history partition %s to the %s tier failed: %s", action.Partition.Name, action.Tier, err)
+			continue
+		}
+		applied++
+	}
+
+	if !dryRun {
+		log.Infof("applied %d out of %d history retention actions", applied, len(actions))
+	}
+	return nil
+}
+
+func tierOrder(tier string) int {
+	switch tier {
+	case endpointmanager.TierFull:
+		return 0
+	case endpointmanager.TierSummary:
+		return 1
+	case endpointmanager.TierDropped:
+		return 2
+	}
+	return -1
+}
@@ -0,0 +1,89 @@
+package historyretention
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+)
+
+func Test_PolicyValidate(t *testing.T) {
+	err := Policy{FullMonths: 6, SummaryMonths: 18}.Validate()
+	th.Assert(t, err == nil, err)
+
+	err = Policy{FullMonths: 1, SummaryMonths: 0}.Validate()
+	th.Assert(t, err == nil, err)
+
+	err = Policy{FullMonths: 0, SummaryMonths: 18}.Validate()
+	th.Assert(t, err != nil, "expected an error for a policy that does not keep the current month in full")
+
+	err = Policy{FullMonths: 6, SummaryMonths: -1}.Validate()
+	th.Assert(t, err != nil, "expected an error for a negative number of summary months")
+}
+
+func Test_TargetTier(t *testing.T) {
+	policy := Policy{FullMonths: 2, SummaryMonths: 3}
+	now := time.Date(2021, time.June, 15, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		month    time.Month
+		expected string
+	}{
+		{time.July, endpointmanager.TierFull},
+		{time.June, endpointmanager.TierFull},
+		{time.May, endpointmanager.TierFull},
+		{time.April, endpointmanager.TierSummary},
+		{time.February, endpointmanager.TierSummary},
+		{time.January, endpointmanager.TierDropped},
+	}
+
+	for _, c := range cases {
+		partition := testPartition(c.month, endpointmanager.TierFull)
+		actual := policy.TargetTier(partition, now)
+		th.Assert(t, actual == c.expected, fmt.Sprintf("expected the %s partition to be in the %s tier, got %s", c.month, c.expected, actual))
+	}
+
+	// without summary months, partitions are dropped once they are no longer kept in full
+	policy = Policy{FullMonths: 2, SummaryMonths: 0}
+	actual := policy.TargetTier(testPartition(time.April, endpointmanager.TierFull), now)
+	th.Assert(t, actual == endpointmanager.TierDropped, fmt.Sprintf("expected the partition to be dropped, got %s", actual))
+}
+
+func Test_PlanRetention(t *testing.T) {
+	policy := Policy{FullMonths: 2, SummaryMonths: 3}
+	now := time.Date(2021, time.June, 15, 0, 0, 0, 0, time.UTC)
+
+	partitions := []*endpointmanager.HistoryPartition{
+		testPartition(time.December, endpointmanager.TierDropped),
+		testPartition(time.January, endpointmanager.TierSummary),
+		testPartition(time.February, endpointmanager.TierFull),
+		testPartition(time.March, endpointmanager.TierSummary),
+		testPartition(time.May, endpointmanager.TierFull),
+		testPartition(time.June, endpointmanager.TierFull),
+	}
+	// the December partition is from the year before
+	partitions[0].Month = time.Date(2020, time.December, 1, 0, 0, 0, 0, time.UTC)
+
+	actions := PlanRetention(partitions, policy, now)
+	th.Assert(t, len(actions) == 2, fmt.Sprintf("expected 2 actions, got %d", len(actions)))
+	th.Assert(t, actions[0].Partition == partitions[1], "expected the January partition to be changed first")
+	th.Assert(t, actions[0].Tier == endpointmanager.TierDropped, fmt.Sprintf("expected the January partition to be dropped, got %s", actions[0].Tier))
+	th.Assert(t, actions[1].Partition == partitions[2], "expected the February partition to be changed second")
+	th.Assert(t, actions[1].Tier == endpointmanager.TierSummary, fmt.Sprintf("expected the February partition to be summarized, got %s", actions[1].Tier))
+
+	// a policy that keeps more history does not move partitions to earlier tiers
+	policy = Policy{FullMonths: 12, SummaryMonths: 12}
+	actions = PlanRetention(partitions, policy, now)
+	th.Assert(t, len(actions) == 0, fmt.Sprintf("expected no actions, got %d", len(actions)))
+}
+
+func testPartition(month time.Month, tier string) *endpointmanager.HistoryPartition {
+	return &endpointmanager.HistoryPartition{
+		Name:        "fhir_endpoints_info_history_y2021m" + month.String(),
+		ParentTable: endpointmanager.InfoHistoryTable,
+		Month:       time.Date(2021, month, 1, 0, 0, 0, 0, time.UTC),
+		Tier:        tier,
+	}
+}
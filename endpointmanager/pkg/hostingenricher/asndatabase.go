@@ -0,0 +1,123 @@
+package hostingenricher
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// ASNRecord is the autonomous system that announces a range of IP addresses.
+type ASNRecord struct {
+	ASN          int
+	CountryCode  string
+	Organization string
+}
+
+type asnRange struct {
+	start  net.IP
+	end    net.IP
+	record ASNRecord
+}
+
+// ASNDatabase maps IP addresses to the autonomous system that announces them. It is loaded from a local
+// file so that no external service is queried when enriching endpoints.
+type ASNDatabase struct {
+	ranges []asnRange
+}
+
+// LoadASNDatabaseFromConfig reads the IP to ASN database from the file set by the hosting_asndb configuration. If
+// no file is configured, nil is returned and IP addresses are not mapped to autonomous systems.
+func LoadASNDatabaseFromConfig() (*ASNDatabase, error) {
+	path := viper.GetString("hosting_asndb")
+	if path == "" {
+		log.Warn("LANTERN_HOSTING_ASNDB is not set. IP addresses will not be mapped to autonomous systems.")
+		return nil, nil
+	}
+	return LoadASNDatabase(path)
+}
+
+// LoadASNDatabase reads an IP to ASN database from the given file. The file is expected to be in the
+// tab separated format of the ip2asn-combined.tsv file published at https://iptoasn.com, where each line
+// contains the first IP address of a range, the last IP address of the range, the AS number, the AS
+// country code, and the AS description. Ranges with an AS number of 0 are not routed and are skipped.
+func LoadASNDatabase(path string) (*ASNDatabase, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open ASN database %s: %s", path, err)
+	}
+	defer file.Close()
+
+	var db ASNDatabase
+	lineNum := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("line %d of ASN database %s has %d fields, expected 5", lineNum, path, len(fields))
+		}
+		start := net.ParseIP(fields[0])
+		end := net.ParseIP(fields[1])
+		if start == nil || end == nil {
+			return nil, fmt.Errorf("line %d of ASN database %s has an invalid IP range %s - %s", lineNum, path, fields[0], fields[1])
+		}
+		asn, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d of ASN database %s has an invalid AS number %s", lineNum, path, fields[2])
+		}
+		if asn == 0 {
+			continue
+		}
+
+		db.ranges = append(db.ranges, asnRange{
+			start: start.To16(),
+			end:   end.To16(),
+			record: ASNRecord{
+				ASN:          asn,
+				CountryCode:  fields[3],
+				Organization: fields[4],
+			},
+		})
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read ASN database %s: %s", path, err)
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return bytes.Compare(db.ranges[i].start, db.ranges[j].start) < 0
+	})
+
+	return &db, nil
+}
+
+// Lookup returns the autonomous system that announces the given IP address. The second return value is
+// false if the IP address is not in any range in the database.
+func (db *ASNDatabase) Lookup(ip net.IP) (ASNRecord, bool) {
+	if db == nil || ip == nil {
+		return ASNRecord{}, false
+	}
+	ip = ip.To16()
+
+	// find the last range that starts at or before the IP address
+	idx := sort.Search(len(db.ranges), func(i int) bool {
+		return bytes.Compare(db.ranges[i].start, ip) > 0
+	}) - 1
+	if idx < 0 || bytes.Compare(ip, db.ranges[idx].end) > 0 {
+		return ASNRecord{}, false
+	}
+
+	return db.ranges[idx].record, true
+}
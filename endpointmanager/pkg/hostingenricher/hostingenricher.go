@@ -0,0 +1,180 @@
+package hostingenricher
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	log "github.com/sirupsen/logrus"
+)
+
+// Resolver looks up the IP addresses of a host name and the host names of an IP address. *net.Resolver
+// satisfies this interface.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+}
+
+// lookupTimeout is how long a single DNS lookup may take, so that an unresponsive name server does not hold up the
+// rest of the endpoints.
+var lookupTimeout = 10 * time.Second
+
+// Store stores the hosting information of the FHIR endpoints.
+type Store interface {
+	GetAllFHIREndpoints(ctx context.Context) ([]*endpointmanager.FHIREndpoint, error)
+	endpointmanager.HostingStore
+}
+
+// EnrichEndpoints resolves the host of every FHIR endpoint in the database, maps each of the resulting IP
+// addresses to the autonomous system that announces it using asnDB, and saves the results. A host's
+// information is only updated when it has changed so that the fhir_endpoints_hosting_history table
+// reflects changes in where the endpoint is hosted.
+func EnrichEndpoints(ctx context.Context, store Store, resolver Resolver, asnDB *ASNDatabase) error {
+	endpoints, err := store.GetAllFHIREndpoints(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to get FHIR endpoints: %s", err)
+	}
+
+	// several endpoints are often served by the same host, so only resolve each host once
+	hostCache := make(map[string]*endpointmanager.FHIREndpointHosting)
+	seenURLs := make(map[string]bool)
+
+	for _, endpoint := range endpoints {
+		if seenURLs[endpoint.URL] {
+			continue
+		}
+		seenURLs[endpoint.URL] = true
+
+		hosting, err := getHostingInfo(ctx, endpoint.URL, resolver, asnDB, hostCache)
+		if err != nil {
+			log.Warnf("unable to get hosting information for %s: %s", endpoint.URL, err)
+			continue
+		}
+
+		err = saveHostingInfo(ctx, store, hosting)
+		if err != nil {
+			return fmt.Errorf("unable to save hosting information for %s: %s", endpoint.URL, err)
+		}
+	}
+
+	return nil
+}
+
+// EnrichEndpointsPeriodically runs EnrichEndpoints every 'interval' minutes until the context ends. Errors are
+// sent to 'errs' and do not stop the next run.
+func EnrichEndpointsPeriodically(ctx context.Context, wg *sync.WaitGroup, store Store, resolver Resolver, asnDB *ASNDatabase, interval int, errs chan<- error) {
+	defer wg.Done()
+
+	for {
+		err := EnrichEndpoints(ctx, store, resolver, asnDB)
+		if err != nil {
+			errs <- err
+		}
+
+		log.Infof("Waiting %d minutes to enrich the endpoints' hosting information", interval)
+		select {
+		case <-time.After(time.Duration(interval) * time.Minute):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// GetHostingInfo resolves the host of the given FHIR endpoint URL to its IP addresses and looks up the
+// reverse DNS names and autonomous system of each address. Failing to resolve the host is not returned
+// as an error, but is recorded in the Errors field of the returned FHIREndpointHosting.
+func GetHostingInfo(ctx context.Context, fhirURL string, resolver Resolver, asnDB *ASNDatabase) (*endpointmanager.FHIREndpointHosting, error) {
+	return getHostingInfo(ctx, fhirURL, resolver, asnDB, nil)
+}
+
+func getHostingInfo(ctx context.Context, fhirURL string, resolver Resolver, asnDB *ASNDatabase, hostCache map[string]*endpointmanager.FHIREndpointHosting) (*endpointmanager.FHIREndpointHosting, error) {
+	parsedURL, err := url.Parse(fhirURL)
+	if err != nil {
+		return nil, fmt.Errorf("endpoint URL parsing error: %s", err)
+	}
+	hostname := parsedURL.Hostname()
+	if hostname == "" {
+		return nil, fmt.Errorf("endpoint URL %s has no host", fhirURL)
+	}
+
+	if cached, ok := hostCache[hostname]; ok {
+		hosting := *cached
+		hosting.URL = fhirURL
+		return &hosting, nil
+	}
+
+	hosting := &endpointmanager.FHIREndpointHosting{
+		URL:      fhirURL,
+		Hostname: hostname,
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	ipAddrs, err := resolver.LookupIPAddr(lookupCtx, hostname)
+	cancel()
+	if err != nil {
+		hosting.Errors = err.Error()
+	}
+
+	for _, ipAddr := range ipAddrs {
+		ipInfo := endpointmanager.IPAddressInfo{
+			IP:     ipAddr.IP.String(),
+			Family: ipFamily(ipAddr.IP),
+		}
+
+		// a missing PTR record is common and is not treated as an error
+		lookupCtx, cancel := context.WithTimeout(ctx, lookupTimeout)
+		names, err := resolver.LookupAddr(lookupCtx, ipInfo.IP)
+		cancel()
+		if err == nil {
+			sort.Strings(names)
+			ipInfo.ReverseDNS = names
+		}
+
+		if record, ok := asnDB.Lookup(ipAddr.IP); ok {
+			ipInfo.ASN = record.ASN
+			ipInfo.ASOrganization = record.Organization
+			ipInfo.CountryCode = record.CountryCode
+		}
+
+		hosting.IPAddresses = append(hosting.IPAddresses, ipInfo)
+	}
+
+	// DNS servers may return records in any order, so sort them to avoid recording spurious changes
+	sort.Slice(hosting.IPAddresses, func(i, j int) bool {
+		return hosting.IPAddresses[i].IP < hosting.IPAddresses[j].IP
+	})
+
+	if hostCache != nil {
+		hostCache[hostname] = hosting
+	}
+
+	return hosting, nil
+}
+
+func saveHostingInfo(ctx context.Context, store endpointmanager.HostingStore, hosting *endpointmanager.FHIREndpointHosting) error {
+	existing, err := store.GetFHIREndpointHostingUsingURL(ctx, hosting.URL)
+	if err == sql.ErrNoRows {
+		return store.AddFHIREndpointHosting(ctx, hosting)
+	} else if err != nil {
+		return err
+	}
+
+	if existing.Equal(hosting) {
+		return nil
+	}
+	hosting.ID = existing.ID
+	return store.UpdateFHIREndpointHosting(ctx, hosting)
+}
+
+func ipFamily(ip net.IP) string {
+	if ip.To4() != nil {
+		return endpointmanager.IPv4Family
+	}
+	return endpointmanager.IPv6Family
+}
@@ -0,0 +1,98 @@
+// +build integration
+
+package hostingenricher
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/config"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+	"github.com/spf13/viper"
+)
+
+var store *postgresql.Store
+
+func TestMain(m *testing.M) {
+	var err error
+
+	err = config.SetupConfigForTests()
+	if err != nil {
+		panic(err)
+	}
+
+	hap := th.HostAndPort{Host: viper.GetString("dbhost"), Port: viper.GetString("dbport")}
+	err = th.CheckResources(hap)
+	if err != nil {
+		panic(err)
+	}
+
+	store, err = postgresql.NewStore(viper.GetString("dbhost"), viper.GetInt("dbport"), viper.GetString("dbuser"), viper.GetString("dbpassword"), viper.GetString("dbname"), viper.GetString("dbsslmode"))
+	if err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+
+	store.Close()
+	os.Exit(code)
+}
+
+func Test_EnrichEndpoints(t *testing.T) {
+	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
+	defer teardown(t, store.DB)
+
+	ctx := context.Background()
+	db, err := LoadASNDatabase(filepath.Join("testdata", "ip2asn.tsv"))
+	th.Assert(t, err == nil, err)
+
+	endpoints := []*endpointmanager.FHIREndpoint{
+		{URL: "https://fhir.example.com/dstu2/", ListSource: "https://example.com/list1"},
+		{URL: "https://fhir.example.com/dstu2/", ListSource: "https://example.com/list2"},
+		{URL: "https://unknown.example.com/dstu2/", ListSource: "https://example.com/list1"},
+	}
+	for _, endpoint := range endpoints {
+		err = store.AddFHIREndpoint(ctx, endpoint)
+		th.Assert(t, err == nil, err)
+	}
+
+	resolver := testResolver()
+	err = EnrichEndpoints(ctx, store, resolver, db)
+	th.Assert(t, err == nil, err)
+
+	hosting, err := store.GetFHIREndpointHostingUsingURL(ctx, "https://fhir.example.com/dstu2/")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(hosting.IPAddresses) == 2, fmt.Sprintf("expected 2 IP addresses, got %d", len(hosting.IPAddresses)))
+
+	hosting, err = store.GetFHIREndpointHostingUsingURL(ctx, "https://unknown.example.com/dstu2/")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, hosting.Errors != "", "expected the DNS error to be saved")
+
+	// running again without any changes does not add history
+	err = EnrichEndpoints(ctx, store, resolver, db)
+	th.Assert(t, err == nil, err)
+
+	var historyCount int
+	err = store.DB.QueryRow("SELECT COUNT(*) FROM fhir_endpoints_hosting_history WHERE url=$1", "https://fhir.example.com/dstu2/").Scan(&historyCount)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, historyCount == 1, fmt.Sprintf("expected 1 history entry, got %d", historyCount))
+
+	// a change in the host's addresses is recorded in the history
+	resolver.hosts["fhir.example.com"] = []string{"1.0.0.1"}
+	err = EnrichEndpoints(ctx, store, resolver, db)
+	th.Assert(t, err == nil, err)
+
+	hosting, err = store.GetFHIREndpointHostingUsingURL(ctx, "https://fhir.example.com/dstu2/")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(hosting.IPAddresses) == 1, fmt.Sprintf("expected 1 IP address, got %d", len(hosting.IPAddresses)))
+	th.Assert(t, hosting.IPAddresses[0].ASN == 13335, fmt.Sprintf("expected ASN 13335, got %d", hosting.IPAddresses[0].ASN))
+
+	err = store.DB.QueryRow("SELECT COUNT(*) FROM fhir_endpoints_hosting_history WHERE url=$1", "https://fhir.example.com/dstu2/").Scan(&historyCount)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, historyCount == 2, fmt.Sprintf("expected 2 history entries, got %d", historyCount))
+}
@@ -0,0 +1,150 @@
+package hostingenricher
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+)
+
+type mockResolver struct {
+	hosts   map[string][]string
+	ptrs    map[string][]string
+	lookups int
+}
+
+func (r *mockResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	r.lookups++
+	ips, ok := r.hosts[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	var ipAddrs []net.IPAddr
+	for _, ip := range ips {
+		ipAddrs = append(ipAddrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return ipAddrs, nil
+}
+
+func (r *mockResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	names, ok := r.ptrs[addr]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: addr, IsNotFound: true}
+	}
+	return names, nil
+}
+
+// hangingResolver never answers and returns once the lookup's context ends
+type hangingResolver struct{}
+
+func (r hangingResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (r hangingResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func testResolver() *mockResolver {
+	return &mockResolver{
+		hosts: map[string][]string{
+			"fhir.example.com": {"2606:2800:220:1:248:1893:25c8:1946", "93.184.216.34"},
+		},
+		ptrs: map[string][]string{
+			"93.184.216.34": {"b.example.com.", "a.example.com."},
+		},
+	}
+}
+
+func Test_LoadASNDatabase(t *testing.T) {
+	db, err := LoadASNDatabase(filepath.Join("testdata", "ip2asn.tsv"))
+	th.Assert(t, err == nil, err)
+
+	record, ok := db.Lookup(net.ParseIP("93.184.216.34"))
+	th.Assert(t, ok, "expected 93.184.216.34 to be found in the ASN database")
+	th.Assert(t, record.ASN == 15133, fmt.Sprintf("expected ASN 15133, got %d", record.ASN))
+	th.Assert(t, record.Organization == "EDGECAST", fmt.Sprintf("expected organization EDGECAST, got %s", record.Organization))
+	th.Assert(t, record.CountryCode == "US", fmt.Sprintf("expected country code US, got %s", record.CountryCode))
+
+	record, ok = db.Lookup(net.ParseIP("1.0.0.0"))
+	th.Assert(t, ok, "expected the first address of a range to be found in the ASN database")
+	th.Assert(t, record.ASN == 13335, fmt.Sprintf("expected ASN 13335, got %d", record.ASN))
+
+	record, ok = db.Lookup(net.ParseIP("2606:2800:220:1:248:1893:25c8:1946"))
+	th.Assert(t, ok, "expected IPv6 address to be found in the ASN database")
+	th.Assert(t, record.ASN == 15133, fmt.Sprintf("expected ASN 15133, got %d", record.ASN))
+
+	// not routed ranges are skipped
+	_, ok = db.Lookup(net.ParseIP("1.0.2.1"))
+	th.Assert(t, !ok, "did not expect a not routed address to be found in the ASN database")
+
+	_, ok = db.Lookup(net.ParseIP("10.0.0.1"))
+	th.Assert(t, !ok, "did not expect 10.0.0.1 to be found in the ASN database")
+
+	_, ok = db.Lookup(net.ParseIP("0.0.0.1"))
+	th.Assert(t, !ok, "did not expect an address before the first range to be found in the ASN database")
+
+	_, err = LoadASNDatabase(filepath.Join("testdata", "nonexistent.tsv"))
+	th.Assert(t, err != nil, "expected an error loading a nonexistent ASN database")
+}
+
+func Test_GetHostingInfo(t *testing.T) {
+	ctx := context.Background()
+	db, err := LoadASNDatabase(filepath.Join("testdata", "ip2asn.tsv"))
+	th.Assert(t, err == nil, err)
+
+	hosting, err := GetHostingInfo(ctx, "https://fhir.example.com:8443/dstu2/", testResolver(), db)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, hosting.Hostname == "fhir.example.com", fmt.Sprintf("expected hostname fhir.example.com, got %s", hosting.Hostname))
+	th.Assert(t, hosting.Errors == "", fmt.Sprintf("expected no errors, got %s", hosting.Errors))
+	th.Assert(t, len(hosting.IPAddresses) == 2, fmt.Sprintf("expected 2 IP addresses, got %d", len(hosting.IPAddresses)))
+
+	// addresses are sorted so that the order the DNS server returns them in does not matter
+	ipv6 := hosting.IPAddresses[0]
+	ipv4 := hosting.IPAddresses[1]
+	th.Assert(t, ipv4.IP == "93.184.216.34", fmt.Sprintf("expected IPv4 address 93.184.216.34, got %s", ipv4.IP))
+	th.Assert(t, ipv4.Family == "IPv4", fmt.Sprintf("expected family IPv4, got %s", ipv4.Family))
+	th.Assert(t, len(ipv4.ReverseDNS) == 2 && ipv4.ReverseDNS[0] == "a.example.com.", fmt.Sprintf("expected sorted reverse DNS names, got %v", ipv4.ReverseDNS))
+	th.Assert(t, ipv4.ASN == 15133, fmt.Sprintf("expected ASN 15133, got %d", ipv4.ASN))
+	th.Assert(t, ipv6.Family == "IPv6", fmt.Sprintf("expected family IPv6, got %s", ipv6.Family))
+	th.Assert(t, len(ipv6.ReverseDNS) == 0, fmt.Sprintf("expected no reverse DNS names, got %v", ipv6.ReverseDNS))
+	th.Assert(t, ipv6.ASOrganization == "EDGECAST", fmt.Sprintf("expected organization EDGECAST, got %s", ipv6.ASOrganization))
+
+	// unresolvable hosts record the error
+	hosting, err = GetHostingInfo(ctx, "https://unknown.example.com/dstu2/", testResolver(), db)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(hosting.IPAddresses) == 0, fmt.Sprintf("expected no IP addresses, got %d", len(hosting.IPAddresses)))
+	th.Assert(t, hosting.Errors != "", "expected the DNS error to be recorded")
+
+	// hosts are resolved once per run
+	resolver := testResolver()
+	cache := make(map[string]*endpointmanager.FHIREndpointHosting)
+	_, err = getHostingInfo(ctx, "https://fhir.example.com/dstu2/", resolver, db, cache)
+	th.Assert(t, err == nil, err)
+	hosting, err = getHostingInfo(ctx, "https://fhir.example.com/r4/", resolver, db, cache)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, resolver.lookups == 1, fmt.Sprintf("expected the host to be resolved once, was resolved %d times", resolver.lookups))
+	th.Assert(t, hosting.URL == "https://fhir.example.com/r4/", fmt.Sprintf("expected cached hosting information to use the requested URL, got %s", hosting.URL))
+
+	_, err = GetHostingInfo(ctx, "/dstu2/", testResolver(), db)
+	th.Assert(t, err != nil, "expected an error for a URL with no host")
+}
+
+func Test_GetHostingInfoLookupTimeout(t *testing.T) {
+	defaultTimeout := lookupTimeout
+	lookupTimeout = 10 * time.Millisecond
+	defer func() { lookupTimeout = defaultTimeout }()
+
+	// a lookup that does not answer is abandoned and its error is recorded
+	hosting, err := GetHostingInfo(context.Background(), "https://fhir.example.com/dstu2/", hangingResolver{}, nil)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(hosting.IPAddresses) == 0, fmt.Sprintf("expected no IP addresses, got %d", len(hosting.IPAddresses)))
+	th.Assert(t, hosting.Errors == context.DeadlineExceeded.Error(), fmt.Sprintf("expected the lookup to time out, got %s", hosting.Errors))
+}
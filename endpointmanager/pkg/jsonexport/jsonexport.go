@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/lib/pq"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/workers"
 	log "github.com/sirupsen/logrus"
@@ -47,6 +48,7 @@ type Result struct {
 
 type historyArgs struct {
 	fhirURL string
+	asOf    time.Time // if set, only the history entered at or before it is included
 	store   *postgresql.Store
 	result  chan Result
 }
@@ -63,10 +65,20 @@ func CreateJSONExport(ctx context.Context, store *postgresql.Store, fileToWriteT
 	return err
 }
 
+// CreateJSONExportAsOf creates a JSON export file in the same format as CreateJSONExport of the endpoints as they
+// were at the given time. Each endpoint's history is limited to the entries made by then.
+func CreateJSONExportAsOf(ctx context.Context, store *postgresql.Store, asOf time.Time, fileToWriteTo string) error {
+	finalFormatJSON, err := createJSONAsOf(ctx, store, asOf)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(fileToWriteTo, finalFormatJSON, 0644)
+	return err
+}
+
 func createJSON(ctx context.Context, store *postgresql.Store) ([]byte, error) {
 	// Get everything from the fhir_endpoints_info table
-	sqlQuery := "SELECT DISTINCT url, endpoint_names, info_created, list_source, vendor_name FROM endpoint_export;"
-	rows, err := store.DB.QueryContext(ctx, sqlQuery)
+	rows, err := store.GetEndpointExport(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("Make sure that the database is not empty. Error: %s", err)
 	}
@@ -88,9 +100,7 @@ func createJSON(ctx context.Context, store *postgresql.Store) ([]byte, error) {
 		if err != nil {
 			return nil, fmt.Errorf("Error scanning the row. Error: %s", err)
 		}
-		if !vendorNameNullable.Valid {
-			entry.VendorName = ""
-		}
+		entry.VendorName = vendorNameNullable.String
 		// If the URL already exists, include the new list source and organization names
 		if val, ok := entryCheck[entry.URL]; ok {
 			val.ListSource = append(val.ListSource, listSource)
@@ -104,8 +114,76 @@ func createJSON(ctx context.Context, store *postgresql.Store) ([]byte, error) {
 	}
 
 	var entries []jsonEntry
-	for _, e := range entryCheck {
-		entries = append(entries, e)
+	for _, url := range urls {
+		entries = append(entries, entryCheck[url])
+	}
+
+	return addHistory(ctx, store, entries, time.Time{})
+}
+
+func createJSONAsOf(ctx context.Context, store *postgresql.Store, asOf time.Time) ([]byte, error) {
+	snapshots, err := store.GetEndpointSnapshots(ctx, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting the endpoints as of %s. Error: %s", asOf.Format(time.RFC3339), err)
+	}
+	endpoints, err := store.GetAllFHIREndpoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting the endpoints from the lists. Error: %s", err)
+	}
+
+	entries := snapshotEntries(snapshots, endpoints, asOf)
+	return addHistory(ctx, store, entries, asOf)
+}
+
+// snapshotEntries creates an entry for each url of the snapshots, in the order of the snapshots. The endpoint lists
+// are not kept in the history, so the list sources and organization names are those of the endpoints that are in
+// the lists now and were added to them by the given time.
+func snapshotEntries(snapshots []*endpointmanager.EndpointSnapshot, endpoints []*endpointmanager.FHIREndpoint, asOf time.Time) []jsonEntry {
+	listEndpoints := make(map[string][]*endpointmanager.FHIREndpoint)
+	for _, endpoint := range endpoints {
+		if !endpoint.CreatedAt.After(asOf) {
+			listEndpoints[endpoint.URL] = append(listEndpoints[endpoint.URL], endpoint)
+		}
+	}
+
+	var entries []jsonEntry
+	entryIndex := make(map[string]int)
+	for _, snapshot := range snapshots {
+		info := snapshot.Info
+		i, ok := entryIndex[info.URL]
+		if !ok {
+			entry := jsonEntry{
+				URL:               info.URL,
+				OrganizationNames: []string{},
+				CreatedAt:         info.CreatedAt,
+				ListSource:        []string{},
+			}
+			for _, endpoint := range listEndpoints[info.URL] {
+				entry.ListSource = append(entry.ListSource, endpoint.ListSource)
+				entry.OrganizationNames = append(entry.OrganizationNames, endpoint.OrganizationNames...)
+			}
+			i = len(entries)
+			entryIndex[info.URL] = i
+			entries = append(entries, entry)
+		}
+
+		// an endpoint that was queried for several FHIR versions is listed once, created when it was first queried
+		if info.CreatedAt.Before(entries[i].CreatedAt) {
+			entries[i].CreatedAt = info.CreatedAt
+		}
+		if entries[i].VendorName == "" && snapshot.Vendor != nil {
+			entries[i].VendorName = snapshot.Vendor.Name
+		}
+	}
+	return entries
+}
+
+// addHistory gets the history of each entry from the database, limited to the entries made by asOf if it is set,
+// and formats the entries as JSON
+func addHistory(ctx context.Context, store *postgresql.Store, entries []jsonEntry, asOf time.Time) ([]byte, error) {
+	var urls []string
+	for _, entry := range entries {
+		urls = append(urls, entry.URL)
 	}
 
 	errs := make(chan error)
@@ -117,25 +195,21 @@ func createJSON(ctx context.Context, store *postgresql.Store) ([]byte, error) {
 	allWorkers := workers.NewWorkers()
 
 	// Start workers
-	err = allWorkers.Start(ctx, numWorkers, errs)
+	err := allWorkers.Start(ctx, numWorkers, errs)
 	if err != nil {
 		return nil, fmt.Errorf("Error from starting workers. Error: %s", err)
 	}
 
 	resultCh := make(chan Result)
-	go createJobs(ctx, resultCh, urls, store, allWorkers)
+	go createJobs(ctx, resultCh, urls, asOf, store, allWorkers)
 
 	// Add the results from createJobs to mapURLHistory
-	count := 0
 	mapURLHistory := make(map[string][]Operation)
-	for res := range resultCh {
+	for count := 0; count < len(urls); count++ {
+		res := <-resultCh
 		if res.URL != "unknown" {
 			mapURLHistory[res.URL] = res.Rows
 		}
-		if count == len(urls)-1 {
-			close(resultCh)
-		}
-		count++
 	}
 
 	// Add each array of rows to the Operation field in the entries
@@ -194,12 +268,14 @@ func getSupportedResources(opRes []byte) []string {
 func createJobs(ctx context.Context,
 	ch chan Result,
 	urls []string,
+	asOf time.Time,
 	store *postgresql.Store,
 	allWorkers *workers.Workers) {
 	for index := range urls {
 		jobArgs := make(map[string]interface{})
 		jobArgs["historyArgs"] = historyArgs{
 			fhirURL: urls[index],
+			asOf:    asOf,
 			store:   store,
 			result:  ch,
 		}
@@ -233,13 +309,13 @@ func getHistory(ctx context.Context, args *map[string]interface{}) error {
 	}
 
 	// Get everything from the fhir_endpoints_info_history table for the given URL
-	selectHistory := `
-		SELECT fhir_endpoints_info_history.url, fhir_endpoints_metadata.http_response, fhir_endpoints_metadata.response_time_seconds, fhir_endpoints_metadata.errors,
-		capability_statement, tls_version, mime_types, operation_resource,
-		fhir_endpoints_metadata.smart_http_response, smart_response, fhir_endpoints_info_history.updated_at, capability_fhir_version
-		FROM fhir_endpoints_info_history, fhir_endpoints_metadata
-		WHERE fhir_endpoints_info_history.metadata_id = fhir_endpoints_metadata.id AND fhir_endpoints_info_history.url=$1;`
-	historyRows, err := ha.store.DB.QueryContext(ctx, selectHistory, ha.fhirURL)
+	var historyRows *sql.Rows
+	var err error
+	if ha.asOf.IsZero() {
+		historyRows, err = ha.store.GetInfoHistoryWithMetadata(ctx, ha.fhirURL)
+	} else {
+		historyRows, err = ha.store.GetInfoHistoryWithMetadataAsOf(ctx, ha.fhirURL, ha.asOf)
+	}
 	if err != nil {
 		log.Warnf("Failed getting the history rows for URL %s. Error: %s", ha.fhirURL, err)
 		result := Result{
@@ -3,7 +3,9 @@ package jsonexport
 import (
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/helpers"
 	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
 )
@@ -52,3 +54,69 @@ func Test_getSupportedResources(t *testing.T) {
 	supRes = getSupportedResources(testSupportedResources)
 	th.Assert(t, len(supRes) == 0, fmt.Sprintf("There should be 0 supported resources, is instead %d", len(supRes)))
 }
+
+func Test_formatOrganizationExport(t *testing.T) {
+	org := &endpointmanager.OrganizationEndpointHealth{
+		NPIID: "1",
+		Name:  "Test Org",
+		State: "MA",
+		Endpoints: []*endpointmanager.OrganizationEndpoint{
+			{URL: "http://a.com/", Confidence: 1, VendorName: "Epic Systems Corporation", FHIRVersion: "4.0.1", HTTPResponse: 200, Availability: 1},
+		},
+	}
+	org.Summarize()
+
+	export := formatOrganizationExport([]*endpointmanager.OrganizationEndpointHealth{org})
+	th.Assert(t, len(export.Organizations) == 1, fmt.Sprintf("There should be 1 organization, is instead %d", len(export.Organizations)))
+	th.Assert(t, export.Organizations[0].HasWorkingAPI, "The organization should have a working API")
+	th.Assert(t, len(export.Organizations[0].Endpoints) == 1, "The organization should have 1 endpoint")
+	th.Assert(t, export.Organizations[0].Endpoints[0].VendorName == "Epic Systems Corporation", "The endpoint should have the vendor name")
+	th.Assert(t, len(export.States) == 1, fmt.Sprintf("There should be 1 state, is instead %d", len(export.States)))
+	th.Assert(t, export.States[0].State == "MA" && export.States[0].OrganizationsWithAPI == 1, "MA should have 1 organization with a working API")
+
+	// no organizations are exported as empty lists rather than null
+
+	export = formatOrganizationExport(nil)
+	th.Assert(t, export.Organizations != nil && export.States != nil, "The organizations and states should be empty lists")
+}
+
+func Test_snapshotEntries(t *testing.T) {
+	asOf := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	snapshots := []*endpointmanager.EndpointSnapshot{
+		{
+			Info:   &endpointmanager.FHIREndpointInfo{URL: "http://a.com/", RequestedFhirVersion: "4.0.1", CreatedAt: created.Add(time.Hour)},
+			Vendor: nil,
+		},
+		{
+			Info:   &endpointmanager.FHIREndpointInfo{URL: "http://a.com/", RequestedFhirVersion: "None", CreatedAt: created},
+			Vendor: &endpointmanager.Vendor{Name: "Epic Systems Corporation"},
+		},
+		{
+			Info: &endpointmanager.FHIREndpointInfo{URL: "http://b.com/", RequestedFhirVersion: "None", CreatedAt: created},
+		},
+	}
+	endpoints := []*endpointmanager.FHIREndpoint{
+		{URL: "http://a.com/", ListSource: "Epic", OrganizationNames: []string{"Org A"}, CreatedAt: created},
+		{URL: "http://a.com/", ListSource: "Cerner", OrganizationNames: []string{"Org A2"}, CreatedAt: asOf.Add(time.Hour)},
+		{URL: "http://c.com/", ListSource: "Epic", OrganizationNames: []string{"Org C"}, CreatedAt: created},
+	}
+
+	entries := snapshotEntries(snapshots, endpoints, asOf)
+	th.Assert(t, len(entries) == 2, fmt.Sprintf("There should be 2 entries, is instead %d", len(entries)))
+
+	// each url is listed once, with the earliest creation time and the vendor of any of its versions
+	th.Assert(t, entries[0].URL == "http://a.com/", fmt.Sprintf("The first entry should be http://a.com/, is instead %s", entries[0].URL))
+	th.Assert(t, entries[0].CreatedAt.Equal(created), fmt.Sprintf("The entry should have been created when it was first queried, is instead %s", entries[0].CreatedAt))
+	th.Assert(t, entries[0].VendorName == "Epic Systems Corporation", fmt.Sprintf("The entry should have the vendor name, is instead %s", entries[0].VendorName))
+
+	// endpoints added to the lists after the given time are left out
+	th.Assert(t, len(entries[0].ListSource) == 1 && entries[0].ListSource[0] == "Epic", fmt.Sprintf("The entry should only have the Epic list source, is instead %v", entries[0].ListSource))
+	th.Assert(t, len(entries[0].OrganizationNames) == 1 && entries[0].OrganizationNames[0] == "Org A", fmt.Sprintf("The entry should only have the Org A name, is instead %v", entries[0].OrganizationNames))
+
+	// a url that is no longer in the lists has no list sources
+	th.Assert(t, entries[1].URL == "http://b.com/", fmt.Sprintf("The second entry should be http://b.com/, is instead %s", entries[1].URL))
+	th.Assert(t, entries[1].ListSource != nil && len(entries[1].ListSource) == 0, "The entry should have an empty list of list sources")
+	th.Assert(t, entries[1].VendorName == "", fmt.Sprintf("The entry should not have a vendor name, is instead %s", entries[1].VendorName))
+}
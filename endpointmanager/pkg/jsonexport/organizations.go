@@ -0,0 +1,102 @@
+package jsonexport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
+)
+
+type organizationExport struct {
+	Organizations []jsonOrganization  `json:"organizations"`
+	States        []jsonStateCoverage `json:"states"`
+}
+
+type jsonOrganization struct {
+	NPIID         string                     `json:"npi_id"`
+	Name          string                     `json:"name"`
+	State         string                     `json:"state"`
+	Endpoints     []jsonOrganizationEndpoint `json:"endpoints"`
+	VendorNames   []string                   `json:"certified_api_developer_names"`
+	FHIRVersions  []string                   `json:"fhir_versions"`
+	Availability  float64                    `json:"availability"`
+	HasWorkingAPI bool                       `json:"has_working_patient_access_api"`
+}
+
+type jsonOrganizationEndpoint struct {
+	URL          string  `json:"url"`
+	Confidence   float64 `json:"match_score"`
+	VendorName   string  `json:"certified_api_developer_name"`
+	FHIRVersion  string  `json:"fhir_version"`
+	HTTPResponse int     `json:"http_response"`
+	Availability float64 `json:"availability"`
+}
+
+type jsonStateCoverage struct {
+	State                string `json:"state"`
+	Organizations        int    `json:"organizations"`
+	OrganizationsWithAPI int    `json:"organizations_with_working_api"`
+	Endpoints            int    `json:"endpoints"`
+	WorkingEndpoints     int    `json:"working_endpoints"`
+}
+
+// CreateOrganizationJSONExport writes the health of the endpoints linked to each NPI organization, along with
+// the coverage of working patient access APIs by state, to the given file
+func CreateOrganizationJSONExport(ctx context.Context, store *postgresql.Store, fileToWriteTo string) error {
+	orgs, err := store.GetOrganizationEndpointHealth(ctx)
+	if err != nil {
+		return fmt.Errorf("Error getting the organizations' endpoints. Error: %s", err)
+	}
+
+	// Convert the object to JSON using proper tab formatting
+	finalFormatJSON, err := json.MarshalIndent(formatOrganizationExport(orgs), "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileToWriteTo, finalFormatJSON, 0644)
+}
+
+func formatOrganizationExport(orgs []*endpointmanager.OrganizationEndpointHealth) organizationExport {
+	export := organizationExport{
+		Organizations: []jsonOrganization{},
+		States:        []jsonStateCoverage{},
+	}
+
+	for _, org := range orgs {
+		jsonOrg := jsonOrganization{
+			NPIID:         org.NPIID,
+			Name:          org.Name,
+			State:         org.State,
+			Endpoints:     []jsonOrganizationEndpoint{},
+			VendorNames:   org.Vendors,
+			FHIRVersions:  org.FHIRVersions,
+			Availability:  org.Availability,
+			HasWorkingAPI: org.HasWorkingAPI,
+		}
+		for _, endpoint := range org.Endpoints {
+			jsonOrg.Endpoints = append(jsonOrg.Endpoints, jsonOrganizationEndpoint{
+				URL:          endpoint.URL,
+				Confidence:   endpoint.Confidence,
+				VendorName:   endpoint.VendorName,
+				FHIRVersion:  endpoint.FHIRVersion,
+				HTTPResponse: endpoint.HTTPResponse,
+				Availability: endpoint.Availability,
+			})
+		}
+		export.Organizations = append(export.Organizations, jsonOrg)
+	}
+
+	for _, coverage := range endpointmanager.OrganizationCoverageByState(orgs) {
+		export.States = append(export.States, jsonStateCoverage{
+			State:                coverage.State,
+			Organizations:        coverage.Organizations,
+			OrganizationsWithAPI: coverage.OrganizationsWithAPI,
+			Endpoints:            coverage.Endpoints,
+			WorkingEndpoints:     coverage.WorkingEndpoints,
+		})
+	}
+	return export
+}
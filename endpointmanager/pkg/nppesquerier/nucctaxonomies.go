@@ -0,0 +1,122 @@
+package nppesquerier
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+)
+
+// columns of the NUCC taxonomy code set csv file that are stored. Older versions of the file do not have the
+// "Display Name" column.
+var nuccColumns = []string{"Code", "Grouping", "Classification", "Specialization", "Display Name"}
+
+// ParseAndStoreNUCCTaxonomyFile parses the NUCC health care provider taxonomy code set out of fname, writes it to
+// store and returns the number of taxonomies stored. Rows without a code are rejected and logged, and the load stops
+// at the first taxonomy that can not be stored. The code set .csv can be downloaded from
+// https://www.nucc.org/index.php/code-sets-mainmenu-41/provider-taxonomy-mainmenu-40/csv-mainmenu-57
+func ParseAndStoreNUCCTaxonomyFile(ctx context.Context, fname string, store endpointmanager.NPIStore) (int, error) {
+	reader, f, err := csvReader(ctx, fname)
+	if err != nil {
+		return -1, err
+	}
+	defer f.Close()
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return -1, err
+	}
+	columnIndexes, err := nuccColumnIndexes(header)
+	if err != nil {
+		return -1, errors.Wrapf(err, "%s is not a NUCC taxonomy code set file", fname)
+	}
+
+	added := 0
+	rejected := 0
+	// the header is line 1
+	i := 1
+	for {
+		line, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return added, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return added, errors.Wrapf(ctx.Err(), "stored %d NUCC taxonomies before the context ended", added)
+		default:
+			// ok
+		}
+
+		i++
+
+		taxonomy := parseNUCCTaxonomyLine(line, columnIndexes)
+		if taxonomy.Code == "" {
+			log.Warnf("rejected line %d of %s: missing code", i, fname)
+			rejected++
+			continue
+		}
+		err = store.AddOrUpdateNUCCTaxonomy(ctx, taxonomy)
+		if err != nil {
+			return added, errors.Wrapf(err, "loading line %d of %s failed", i, fname)
+		}
+		added++
+	}
+	log.Infof("Stored %d NUCC taxonomies. Rejected %d.", added, rejected)
+	return added, nil
+}
+
+// nuccColumnIndexes returns the index of each of the nuccColumns in the header, or -1 if the optional
+// "Display Name" column is missing.
+func nuccColumnIndexes(header []string) (map[string]int, error) {
+	indexes := make(map[string]int)
+	for _, column := range nuccColumns {
+		indexes[column] = -1
+	}
+	for i, column := range header {
+		// the first column of the file may start with a byte order mark
+		column = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+		if _, ok := indexes[column]; ok {
+			indexes[column] = i
+		}
+	}
+	for _, column := range nuccColumns[:4] {
+		if indexes[column] == -1 {
+			return nil, errors.Errorf("missing %s column", column)
+		}
+	}
+	return indexes, nil
+}
+
+func parseNUCCTaxonomyLine(line []string, columnIndexes map[string]int) *endpointmanager.NUCCTaxonomy {
+	column := func(name string) string {
+		i := columnIndexes[name]
+		if i < 0 || i >= len(line) {
+			return ""
+		}
+		return strings.TrimSpace(line[i])
+	}
+	taxonomy := &endpointmanager.NUCCTaxonomy{
+		Code:           column("Code"),
+		Grouping:       column("Grouping"),
+		Classification: column("Classification"),
+		Specialization: column("Specialization"),
+		DisplayName:    column("Display Name"),
+	}
+	// the display name is the most specific name of the taxonomy
+	if taxonomy.DisplayName == "" {
+		taxonomy.DisplayName = taxonomy.Classification
+		if taxonomy.Specialization != "" {
+			taxonomy.DisplayName = taxonomy.Specialization
+		}
+	}
+	return taxonomy
+}
@@ -0,0 +1,69 @@
+package nppesquerier
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/memorystore"
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+	"github.com/pkg/errors"
+)
+
+func Test_parseNUCCTaxonomyLine(t *testing.T) {
+	reader, f, err := csvReader(context.Background(), "testdata/nucc_taxonomy_fixture.csv")
+	th.Assert(t, err == nil, err)
+	defer f.Close()
+
+	header, err := reader.Read()
+	th.Assert(t, err == nil, err)
+	columnIndexes, err := nuccColumnIndexes(header)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, columnIndexes["Code"] == 0, fmt.Sprintf("Expected the byte order mark to be ignored, got column indexes %v", columnIndexes))
+
+	expected := []endpointmanager.NUCCTaxonomy{
+		{Code: "251E00000X", Grouping: "Agencies", Classification: "Home Health", DisplayName: "Home Health Agency"},
+		{Code: "251G00000X", Grouping: "Agencies", Classification: "Hospice Care, Community Based", DisplayName: "Hospice Care, Community Based"},
+		{Code: "282NC0060X", Grouping: "Hospitals", Classification: "General Acute Care Hospital", Specialization: "Critical Access", DisplayName: "Critical Access"},
+	}
+	for _, e := range expected {
+		line, err := reader.Read()
+		th.Assert(t, err == nil, err)
+		taxonomy := parseNUCCTaxonomyLine(line, columnIndexes)
+		th.Assert(t, *taxonomy == e, fmt.Sprintf("Expected %+v, got %+v", e, *taxonomy))
+	}
+
+	// the display name column is optional
+	columnIndexes, err = nuccColumnIndexes([]string{"Code", "Grouping", "Classification", "Specialization"})
+	th.Assert(t, err == nil, err)
+	taxonomy := parseNUCCTaxonomyLine([]string{"333600000X", "Suppliers", "Pharmacy", ""}, columnIndexes)
+	th.Assert(t, taxonomy.DisplayName == "Pharmacy", fmt.Sprintf("Expected the display name to be the classification, got %s", taxonomy.DisplayName))
+
+	_, err = nuccColumnIndexes([]string{"NPI", "Endpoint"})
+	th.Assert(t, err != nil, "Expected an error for a file without the NUCC columns")
+}
+
+// failingTaxonomyStore fails to store any NUCC taxonomy
+type failingTaxonomyStore struct {
+	*memorystore.Store
+}
+
+var errTaxonomyStore = errors.New("database unavailable")
+
+func (s *failingTaxonomyStore) AddOrUpdateNUCCTaxonomy(ctx context.Context, taxonomy *endpointmanager.NUCCTaxonomy) error {
+	return errTaxonomyStore
+}
+
+func Test_ParseAndStoreNUCCTaxonomyFileStoreError(t *testing.T) {
+	ctx := context.Background()
+
+	added, err := ParseAndStoreNUCCTaxonomyFile(ctx, "testdata/nucc_taxonomy_fixture.csv", memorystore.NewStore())
+	th.Assert(t, err == nil, err)
+	th.Assert(t, added == 4, fmt.Sprintf("Expected 4 taxonomies to be stored, got %d", added))
+
+	// a taxonomy that can not be stored stops the load instead of being skipped
+	added, err = ParseAndStoreNUCCTaxonomyFile(ctx, "testdata/nucc_taxonomy_fixture.csv", &failingTaxonomyStore{memorystore.NewStore()})
+	th.Assert(t, errors.Cause(err) == errTaxonomyStore, fmt.Sprintf("Expected the store error, got %v", err))
+	th.Assert(t, added == 0, fmt.Sprintf("Expected no taxonomies to be stored, got %d", added))
+}
@@ -4,6 +4,7 @@ package nppesquerier_test
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"os"
 	"testing"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/config"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/nppesquerier"
 	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
@@ -97,6 +99,107 @@ func Test_ParseAndStoreNPIFileContext(t *testing.T) {
 	th.Assert(t, added >= 0, "expected items added to be zero or more after context deadline met")
 }
 
+func Test_LoadNPIIncrementalFiles(t *testing.T) {
+	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
+	defer teardown(t, store.DB)
+
+	ctx := context.Background()
+
+	report, err := nppesquerier.LoadNPIFile(ctx, "testdata/npidata_pfile_fixture.csv", store)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, report.Added == 3, fmt.Sprintf("Expected 3 organizations to be added, got %d", report.Added))
+	th.Assert(t, report.Rejected == 0, fmt.Sprintf("Expected no rows to be rejected, got %d", report.Rejected))
+
+	// loading the same file again changes nothing
+	report, err = nppesquerier.LoadNPIFile(ctx, "testdata/npidata_pfile_fixture.csv", store)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, report.Added == 0 && report.Updated == 0 && report.Deactivated == 0, fmt.Sprintf("Expected no changes from reloading the file, got %+v", report))
+
+	// the weekly file renames one organization, deactivates one, adds one and has one invalid row
+	report, err = nppesquerier.LoadNPIFile(ctx, "testdata/npidata_pfile_weekly_fixture.csv", store)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, report.Added == 1, fmt.Sprintf("Expected 1 organization to be added, got %d", report.Added))
+	th.Assert(t, report.Updated == 1, fmt.Sprintf("Expected 1 organization to be updated, got %d", report.Updated))
+	th.Assert(t, report.Deactivated == 1, fmt.Sprintf("Expected 1 organization to be deactivated, got %d", report.Deactivated))
+	th.Assert(t, report.Reactivated == 0, fmt.Sprintf("Expected no organizations to be reactivated, got %d", report.Reactivated))
+	th.Assert(t, report.Rejected == 1, fmt.Sprintf("Expected 1 row to be rejected, got %d", report.Rejected))
+
+	renamed, err := store.GetNPIOrganizationByNPIID(ctx, "1497758544")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, renamed.Name == "CUMBERLAND COUNTY HOSPITAL SYSTEM", fmt.Sprintf("Expected the organization to be renamed, got %s", renamed.Name))
+	th.Assert(t, renamed.LastUpdateDate.Equal(time.Date(2020, time.March, 2, 0, 0, 0, 0, time.UTC)), fmt.Sprintf("Expected the last update date to be updated, got %s", renamed.LastUpdateDate))
+
+	deactivated, err := store.GetNPIOrganizationByNPIID(ctx, "1023011178")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, deactivated.Deactivated(), "Expected the organization to be deactivated")
+	th.Assert(t, deactivated.Name == "COLLABRIA CARE", "Expected the information of the deactivated organization to be kept")
+
+	added, err := store.GetNPIOrganizationByNPIID(ctx, "1114920000")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, added.Name == "SANDHILLS REGIONAL MEDICAL CENTER", fmt.Sprintf("Expected the new organization to be added, got %s", added.Name))
+
+	_, err = store.GetNPIOrganizationByNPIID(ctx, "1114920001")
+	th.Assert(t, err == sql.ErrNoRows, "Expected the rejected organization not to be added")
+
+	// deactivated organizations are not linked to endpoints
+	orgs, err := store.GetAllNPIOrganizationNormalizedNames(ctx)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(orgs) == 3, fmt.Sprintf("Expected 3 active organizations, got %d", len(orgs)))
+	for _, org := range orgs {
+		th.Assert(t, org.NPI_ID != "1023011178", "Expected the deactivated organization not to be returned")
+	}
+
+	report, err = nppesquerier.LoadNPIFile(ctx, "testdata/npidata_pfile_reactivation_fixture.csv", store)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, report.Updated == 1, fmt.Sprintf("Expected 1 organization to be updated, got %d", report.Updated))
+	th.Assert(t, report.Reactivated == 1, fmt.Sprintf("Expected 1 organization to be reactivated, got %d", report.Reactivated))
+
+	reactivated, err := store.GetNPIOrganizationByNPIID(ctx, "1023011178")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, !reactivated.Deactivated(), "Expected the organization to be reactivated")
+
+	// the deactivation file deactivates a stored organization, ignores an unknown NPI and has one invalid row
+	report, err = nppesquerier.LoadNPIDeactivationFile(ctx, "testdata/npi_deactivation_fixture.csv", store)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, report.Deactivated == 1, fmt.Sprintf("Expected 1 organization to be deactivated, got %d", report.Deactivated))
+	th.Assert(t, report.Added == 0, fmt.Sprintf("Expected no organizations to be added, got %d", report.Added))
+	th.Assert(t, report.Rejected == 1, fmt.Sprintf("Expected 1 row to be rejected, got %d", report.Rejected))
+
+	deactivated, err = store.GetNPIOrganizationByNPIID(ctx, "1023011079")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, deactivated.Deactivated(), "Expected the organization to be deactivated")
+	th.Assert(t, deactivated.DeactivationDate.Equal(time.Date(2020, time.March, 5, 0, 0, 0, 0, time.UTC)), fmt.Sprintf("Expected the deactivation date to be stored, got %s", deactivated.DeactivationDate))
+
+	_, err = store.GetNPIOrganizationByNPIID(ctx, "1999999999")
+	th.Assert(t, err == sql.ErrNoRows, "Expected an unknown deactivated NPI not to be added")
+}
+
+func Test_ParseAndStoreNUCCTaxonomyFile(t *testing.T) {
+	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
+	defer teardown(t, store.DB)
+
+	ctx := context.Background()
+
+	added, err := nppesquerier.ParseAndStoreNUCCTaxonomyFile(ctx, "testdata/nucc_taxonomy_fixture.csv", store)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, added == 4, fmt.Sprintf("Expected 4 taxonomies to be stored, got %d", added))
+
+	_, err = nppesquerier.ParseAndStoreNPIFile(ctx, "testdata/npidata_pfile_fixture.csv", store)
+	th.Assert(t, err == nil, err)
+
+	org, err := store.GetNPIOrganizationByNPIID(ctx, "1023011079")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(org.Taxonomies) == 2, fmt.Sprintf("Expected 2 taxonomies, got %d", len(org.Taxonomies)))
+	th.Assert(t, org.Taxonomies[1].Primary, "Expected the second taxonomy to be primary")
+	th.Assert(t, org.Taxonomies[0].DisplayName == "Home Health Agency", fmt.Sprintf("Expected the display name from the code set, got %s", org.Taxonomies[0].DisplayName))
+	th.Assert(t, org.MailingLocation.Address2 == "SUITE F", fmt.Sprintf("Expected the mailing location to be stored, got %+v", org.MailingLocation))
+	th.Assert(t, org.AuthorizedOfficial.LastName == "SLEETER", fmt.Sprintf("Expected the authorized official to be stored, got %+v", org.AuthorizedOfficial))
+
+	npiIDs, err := store.GetNPIOrganizationNPIIDsByProviderType(ctx, []string{"Agencies"})
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(npiIDs) == 3, fmt.Sprintf("Expected the 3 agencies, got %v", npiIDs))
+}
+
 func Test_ParseAndStoreNPIContactFile(t *testing.T) {
 	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
 	defer teardown(t, store.DB)
@@ -142,6 +245,49 @@ func Test_ParseAndStoreNPIContactFileContext(t *testing.T) {
 	th.Assert(t, added >= 0, "expected items added to be zero or more after context deadline met")
 }
 
+func Test_ReconcileNPIContactsWithStore(t *testing.T) {
+	teardown, _ := th.IntegrationDBTestSetup(t, store.DB)
+	defer teardown(t, store.DB)
+
+	ctx := context.Background()
+
+	contacts := []*endpointmanager.NPIContact{
+		{NPI_ID: "1", EndpointType: "FHIR", Endpoint: "https://nppes.example.com/fhir", ValidURL: true, AffiliationLegalBusinessName: "NPPES Org", Location: &endpointmanager.Location{}},
+		{NPI_ID: "2", EndpointType: "FHIR", Endpoint: "https://listed.example.com/fhir", ValidURL: true, AffiliationLegalBusinessName: "Listed Org", Location: &endpointmanager.Location{}},
+	}
+	for _, contact := range contacts {
+		err := store.AddNPIContact(ctx, contact)
+		th.Assert(t, err == nil, err)
+	}
+	err := store.AddFHIREndpoint(ctx, &endpointmanager.FHIREndpoint{URL: "https://listed.example.com/fhir/metadata", OrganizationNames: []string{"Listed Org"}, ListSource: "Epic"})
+	th.Assert(t, err == nil, err)
+	err = store.AddFHIREndpoint(ctx, &endpointmanager.FHIREndpoint{URL: "https://unreported.example.com/fhir", ListSource: "Epic"})
+	th.Assert(t, err == nil, err)
+
+	// report only
+	reconciliation, imported, err := nppesquerier.ReconcileNPIContactsWithStore(ctx, store, false)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, imported == 0, "expected no endpoints to be imported")
+	th.Assert(t, len(reconciliation.MissingFromLists) == 1, fmt.Sprintf("expected 1 NPPES endpoint missing from the lists. Got %d", len(reconciliation.MissingFromLists)))
+	th.Assert(t, len(reconciliation.WithoutNPPESContact) == 1, fmt.Sprintf("expected 1 list endpoint without an NPPES contact. Got %d", len(reconciliation.WithoutNPPESContact)))
+	th.Assert(t, len(reconciliation.OrganizationMismatches) == 0, fmt.Sprintf("expected no organization mismatches. Got %d", len(reconciliation.OrganizationMismatches)))
+	_, err = store.GetFHIREndpointUsingURLAndListSource(ctx, "https://nppes.example.com/fhir/", nppesquerier.NPPESListSource)
+	th.Assert(t, err == sql.ErrNoRows, "expected the missing endpoint not to be imported")
+
+	// import the missing endpoints
+	_, imported, err = nppesquerier.ReconcileNPIContactsWithStore(ctx, store, true)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, imported == 1, fmt.Sprintf("expected 1 endpoint to be imported. Got %d", imported))
+	endpoint, err := store.GetFHIREndpointUsingURLAndListSource(ctx, "https://nppes.example.com/fhir/", nppesquerier.NPPESListSource)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(endpoint.NPIIDs) == 1 && endpoint.NPIIDs[0] == "1", "expected the imported endpoint to have the contact's NPI ID")
+
+	// the imported endpoint is not part of the lists so it is still reported as missing
+	reconciliation, _, err = nppesquerier.ReconcileNPIContactsWithStore(ctx, store, false)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, len(reconciliation.MissingFromLists) == 1, "expected the imported endpoint to still be missing from the lists")
+}
+
 
 func setup() error {
 	var err error
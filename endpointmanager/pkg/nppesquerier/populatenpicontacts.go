@@ -13,9 +13,11 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
-	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
 )
 
+// NPPESListSource is the list source of the FHIR endpoints that come from the NPPES contact file
+const NPPESListSource = "NPPES"
+
 // "endpoint_pfile" .csv downloaded from http://download.cms.gov/nppes/NPI_Files.html
 type NPIContactCsvLine struct {
 	NPI                             string
@@ -92,6 +94,23 @@ func buildNPIContactFromNPICsvLine(data NPIContactCsvLine) *endpointmanager.NPIC
 	return npiContact
 }
 
+// buildFHIREndpointFromNPIContact returns the FHIR endpoint for the contact's URL with the NPPES list source
+func buildFHIREndpointFromNPIContact(npiContact *endpointmanager.NPIContact) *endpointmanager.FHIREndpoint {
+	var fhirEndpoint = &endpointmanager.FHIREndpoint{
+		URL:        npiContact.Endpoint,
+		ListSource: NPPESListSource}
+	if npiContact.AffiliationLegalBusinessName != "" {
+		fhirEndpoint.OrganizationNames = []string{npiContact.AffiliationLegalBusinessName}
+	}
+	if npiContact.NPI_ID != "" {
+		fhirEndpoint.NPIIDs = []string{npiContact.NPI_ID}
+	}
+	if npiContact.Location != nil && !npiContact.Location.Equal(&endpointmanager.Location{}) {
+		fhirEndpoint.AddLocation(npiContact.Location)
+	}
+	return fhirEndpoint
+}
+
 func isValidURL(url string) bool {
 	urlregex := regexp.MustCompile(`^(?:http(s)?:\/\/)?[\w.-]+(?:\.[\w\.-]+)+[\w\-\._~:/?#[\]@!\$&'\(\)\*\+,;=.]+$`)
 	urlmatched := urlregex.MatchString(strings.ToLower(url))
@@ -192,8 +211,14 @@ func readContactCsv(ctx context.Context, filename string) ([][]string, error) {
 	return lines[1:], nil
 }
 
+// ContactStore is the part of the database that the NPI contacts and the endpoints added from them are saved to.
+type ContactStore interface {
+	endpointmanager.EndpointListStore
+	endpointmanager.NPIStore
+}
+
 // ParseAndStoreNPIContactsFile parses NPI Org data out of fname, writes it to store and returns the number of Contacts processed
-func ParseAndStoreNPIContactsFile(ctx context.Context, fname string, store *postgresql.Store) (int, error) {
+func ParseAndStoreNPIContactsFile(ctx context.Context, fname string, store ContactStore) (int, error) {
 	// Provider Contact .csv downloaded from http://download.cms.gov/nppes/NPI_Files.html
 	lines, err := readContactCsv(ctx, fname)
 	if err != nil {
@@ -222,16 +247,7 @@ func ParseAndStoreNPIContactsFile(ctx context.Context, fname string, store *post
 			}
 			// If contact has a valid URL, add to our fhir endpoints table, source list is NPPES
 			if npiContact.ValidURL {
-				var fhirEndpoint = &endpointmanager.FHIREndpoint{
-					URL:        npiContact.Endpoint,
-					ListSource: "NPPES"}
-				if npiContact.AffiliationLegalBusinessName != "" {
-					fhirEndpoint.OrganizationNames = []string{npiContact.AffiliationLegalBusinessName}
-				}
-				if npiContact.NPI_ID != "" {
-					fhirEndpoint.NPIIDs = []string{npiContact.NPI_ID}
-				}
-				err = store.AddOrUpdateFHIREndpoint(ctx, fhirEndpoint)
+				err = store.AddOrUpdateFHIREndpoint(ctx, buildFHIREndpointFromNPIContact(npiContact))
 				if err != nil {
 					log.Error(err)
 				}
@@ -5,6 +5,8 @@ import (
 	"encoding/csv"
 	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -354,16 +356,129 @@ func parseNPIdataLine(line []string) NPICsvLine {
 		Entity_Type_Code: line[1],
 		Provider_Organization_Name_Legal_Business_Name:          line[4],
 		Provider_Other_Organization_Name:                        line[11],
+		Provider_First_Line_Business_Mailing_Address:            line[20],
+		Provider_Second_Line_Business_Mailing_Address:           line[21],
+		Provider_Business_Mailing_Address_City_Name:             line[22],
+		Provider_Business_Mailing_Address_State_Name:            line[23],
+		Provider_Business_Mailing_Address_Postal_Code:           line[24],
 		Provider_First_Line_Business_Practice_Location_Address:  line[28],
 		Provider_Second_Line_Business_Practice_Location_Address: line[29],
 		Provider_Business_Practice_Location_Address_City_Name:   line[30],
 		Provider_Business_Practice_Location_Address_State_Name:  line[31],
 		Provider_Business_Practice_Location_Address_Postal_Code: line[32],
-		Healthcare_Provider_Taxonomy_Code_1:                     line[47],
+		Last_Update_Date:                      line[37],
+		NPI_Deactivation_Reason_Code:          line[38],
+		NPI_Deactivation_Date:                 line[39],
+		NPI_Reactivation_Date:                 line[40],
+		Authorized_Official_Last_Name:         line[42],
+		Authorized_Official_First_Name:        line[43],
+		Authorized_Official_Middle_Name:       line[44],
+		Authorized_Official_Title_or_Position: line[45],
+		Authorized_Official_Telephone_Number:  line[46],
+	}
+	// the taxonomy and other identifier columns repeat in groups of four
+	for i, columns := range npiTaxonomyColumns(&data) {
+		for j, column := range columns {
+			*column = line[npiTaxonomyStartIndex+4*i+j]
+		}
+	}
+	if len(line) > npiAuthorizedOfficialCredentialIndex {
+		for i, columns := range npiIdentifierColumns(&data) {
+			for j, column := range columns {
+				*column = line[npiIdentifierStartIndex+4*i+j]
+			}
+		}
+		data.Authorized_Official_Name_Prefix_Text = line[npiAuthorizedOfficialCredentialIndex-2]
+		data.Authorized_Official_Name_Suffix_Text = line[npiAuthorizedOfficialCredentialIndex-1]
+		data.Authorized_Official_Credential_Text = line[npiAuthorizedOfficialCredentialIndex]
 	}
 	return data
 }
 
+// indexes of the repeated taxonomy and other identifier columns and of the last authorized official column
+const npiTaxonomyStartIndex = 47
+const npiIdentifierStartIndex = 107
+const npiAuthorizedOfficialCredentialIndex = 313
+
+// npiTaxonomyColumns returns the code, license number, license state and primary switch columns of each of the
+// 15 taxonomies in an NPPES row
+func npiTaxonomyColumns(data *NPICsvLine) [][4]*string {
+	return [][4]*string{
+		{&data.Healthcare_Provider_Taxonomy_Code_1, &data.Provider_License_Number_1, &data.Provider_License_Number_State_Code_1, &data.Healthcare_Provider_Primary_Taxonomy_Switch_1},
+		{&data.Healthcare_Provider_Taxonomy_Code_2, &data.Provider_License_Number_2, &data.Provider_License_Number_State_Code_2, &data.Healthcare_Provider_Primary_Taxonomy_Switch_2},
+		{&data.Healthcare_Provider_Taxonomy_Code_3, &data.Provider_License_Number_3, &data.Provider_License_Number_State_Code_3, &data.Healthcare_Provider_Primary_Taxonomy_Switch_3},
+		{&data.Healthcare_Provider_Taxonomy_Code_4, &data.Provider_License_Number_4, &data.Provider_License_Number_State_Code_4, &data.Healthcare_Provider_Primary_Taxonomy_Switch_4},
+		{&data.Healthcare_Provider_Taxonomy_Code_5, &data.Provider_License_Number_5, &data.Provider_License_Number_State_Code_5, &data.Healthcare_Provider_Primary_Taxonomy_Switch_5},
+		{&data.Healthcare_Provider_Taxonomy_Code_6, &data.Provider_License_Number_6, &data.Provider_License_Number_State_Code_6, &data.Healthcare_Provider_Primary_Taxonomy_Switch_6},
+		{&data.Healthcare_Provider_Taxonomy_Code_7, &data.Provider_License_Number_7, &data.Provider_License_Number_State_Code_7, &data.Healthcare_Provider_Primary_Taxonomy_Switch_7},
+		{&data.Healthcare_Provider_Taxonomy_Code_8, &data.Provider_License_Number_8, &data.Provider_License_Number_State_Code_8, &data.Healthcare_Provider_Primary_Taxonomy_Switch_8},
+		{&data.Healthcare_Provider_Taxonomy_Code_9, &data.Provider_License_Number_9, &data.Provider_License_Number_State_Code_9, &data.Healthcare_Provider_Primary_Taxonomy_Switch_9},
+		{&data.Healthcare_Provider_Taxonomy_Code_10, &data.Provider_License_Number_10, &data.Provider_License_Number_State_Code_10, &data.Healthcare_Provider_Primary_Taxonomy_Switch_10},
+		{&data.Healthcare_Provider_Taxonomy_Code_11, &data.Provider_License_Number_11, &data.Provider_License_Number_State_Code_11, &data.Healthcare_Provider_Primary_Taxonomy_Switch_11},
+		{&data.Healthcare_Provider_Taxonomy_Code_12, &data.Provider_License_Number_12, &data.Provider_License_Number_State_Code_12, &data.Healthcare_Provider_Primary_Taxonomy_Switch_12},
+		{&data.Healthcare_Provider_Taxonomy_Code_13, &data.Provider_License_Number_13, &data.Provider_License_Number_State_Code_13, &data.Healthcare_Provider_Primary_Taxonomy_Switch_13},
+		{&data.Healthcare_Provider_Taxonomy_Code_14, &data.Provider_License_Number_14, &data.Provider_License_Number_State_Code_14, &data.Healthcare_Provider_Primary_Taxonomy_Switch_14},
+		{&data.Healthcare_Provider_Taxonomy_Code_15, &data.Provider_License_Number_15, &data.Provider_License_Number_State_Code_15, &data.Healthcare_Provider_Primary_Taxonomy_Switch_15},
+	}
+}
+
+// npiIdentifierColumns returns the identifier, type code, state and issuer columns of each of the 50 other
+// identifiers in an NPPES row
+func npiIdentifierColumns(data *NPICsvLine) [][4]*string {
+	return [][4]*string{
+		{&data.Other_Provider_Identifier_1, &data.Other_Provider_Identifier_Type_Code_1, &data.Other_Provider_Identifier_State_1, &data.Other_Provider_Identifier_Issuer_1},
+		{&data.Other_Provider_Identifier_2, &data.Other_Provider_Identifier_Type_Code_2, &data.Other_Provider_Identifier_State_2, &data.Other_Provider_Identifier_Issuer_2},
+		{&data.Other_Provider_Identifier_3, &data.Other_Provider_Identifier_Type_Code_3, &data.Other_Provider_Identifier_State_3, &data.Other_Provider_Identifier_Issuer_3},
+		{&data.Other_Provider_Identifier_4, &data.Other_Provider_Identifier_Type_Code_4, &data.Other_Provider_Identifier_State_4, &data.Other_Provider_Identifier_Issuer_4},
+		{&data.Other_Provider_Identifier_5, &data.Other_Provider_Identifier_Type_Code_5, &data.Other_Provider_Identifier_State_5, &data.Other_Provider_Identifier_Issuer_5},
+		{&data.Other_Provider_Identifier_6, &data.Other_Provider_Identifier_Type_Code_6, &data.Other_Provider_Identifier_State_6, &data.Other_Provider_Identifier_Issuer_6},
+		{&data.Other_Provider_Identifier_7, &data.Other_Provider_Identifier_Type_Code_7, &data.Other_Provider_Identifier_State_7, &data.Other_Provider_Identifier_Issuer_7},
+		{&data.Other_Provider_Identifier_8, &data.Other_Provider_Identifier_Type_Code_8, &data.Other_Provider_Identifier_State_8, &data.Other_Provider_Identifier_Issuer_8},
+		{&data.Other_Provider_Identifier_9, &data.Other_Provider_Identifier_Type_Code_9, &data.Other_Provider_Identifier_State_9, &data.Other_Provider_Identifier_Issuer_9},
+		{&data.Other_Provider_Identifier_10, &data.Other_Provider_Identifier_Type_Code_10, &data.Other_Provider_Identifier_State_10, &data.Other_Provider_Identifier_Issuer_10},
+		{&data.Other_Provider_Identifier_11, &data.Other_Provider_Identifier_Type_Code_11, &data.Other_Provider_Identifier_State_11, &data.Other_Provider_Identifier_Issuer_11},
+		{&data.Other_Provider_Identifier_12, &data.Other_Provider_Identifier_Type_Code_12, &data.Other_Provider_Identifier_State_12, &data.Other_Provider_Identifier_Issuer_12},
+		{&data.Other_Provider_Identifier_13, &data.Other_Provider_Identifier_Type_Code_13, &data.Other_Provider_Identifier_State_13, &data.Other_Provider_Identifier_Issuer_13},
+		{&data.Other_Provider_Identifier_14, &data.Other_Provider_Identifier_Type_Code_14, &data.Other_Provider_Identifier_State_14, &data.Other_Provider_Identifier_Issuer_14},
+		{&data.Other_Provider_Identifier_15, &data.Other_Provider_Identifier_Type_Code_15, &data.Other_Provider_Identifier_State_15, &data.Other_Provider_Identifier_Issuer_15},
+		{&data.Other_Provider_Identifier_16, &data.Other_Provider_Identifier_Type_Code_16, &data.Other_Provider_Identifier_State_16, &data.Other_Provider_Identifier_Issuer_16},
+		{&data.Other_Provider_Identifier_17, &data.Other_Provider_Identifier_Type_Code_17, &data.Other_Provider_Identifier_State_17, &data.Other_Provider_Identifier_Issuer_17},
+		{&data.Other_Provider_Identifier_18, &data.Other_Provider_Identifier_Type_Code_18, &data.Other_Provider_Identifier_State_18, &data.Other_Provider_Identifier_Issuer_18},
+		{&data.Other_Provider_Identifier_19, &data.Other_Provider_Identifier_Type_Code_19, &data.Other_Provider_Identifier_State_19, &data.Other_Provider_Identifier_Issuer_19},
+		{&data.Other_Provider_Identifier_20, &data.Other_Provider_Identifier_Type_Code_20, &data.Other_Provider_Identifier_State_20, &data.Other_Provider_Identifier_Issuer_20},
+		{&data.Other_Provider_Identifier_21, &data.Other_Provider_Identifier_Type_Code_21, &data.Other_Provider_Identifier_State_21, &data.Other_Provider_Identifier_Issuer_21},
+		{&data.Other_Provider_Identifier_22, &data.Other_Provider_Identifier_Type_Code_22, &data.Other_Provider_Identifier_State_22, &data.Other_Provider_Identifier_Issuer_22},
+		{&data.Other_Provider_Identifier_23, &data.Other_Provider_Identifier_Type_Code_23, &data.Other_Provider_Identifier_State_23, &data.Other_Provider_Identifier_Issuer_23},
+		{&data.Other_Provider_Identifier_24, &data.Other_Provider_Identifier_Type_Code_24, &data.Other_Provider_Identifier_State_24, &data.Other_Provider_Identifier_Issuer_24},
+		{&data.Other_Provider_Identifier_25, &data.Other_Provider_Identifier_Type_Code_25, &data.Other_Provider_Identifier_State_25, &data.Other_Provider_Identifier_Issuer_25},
+		{&data.Other_Provider_Identifier_26, &data.Other_Provider_Identifier_Type_Code_26, &data.Other_Provider_Identifier_State_26, &data.Other_Provider_Identifier_Issuer_26},
+		{&data.Other_Provider_Identifier_27, &data.Other_Provider_Identifier_Type_Code_27, &data.Other_Provider_Identifier_State_27, &data.Other_Provider_Identifier_Issuer_27},
+		{&data.Other_Provider_Identifier_28, &data.Other_Provider_Identifier_Type_Code_28, &data.Other_Provider_Identifier_State_28, &data.Other_Provider_Identifier_Issuer_28},
+		{&data.Other_Provider_Identifier_29, &data.Other_Provider_Identifier_Type_Code_29, &data.Other_Provider_Identifier_State_29, &data.Other_Provider_Identifier_Issuer_29},
+		{&data.Other_Provider_Identifier_30, &data.Other_Provider_Identifier_Type_Code_30, &data.Other_Provider_Identifier_State_30, &data.Other_Provider_Identifier_Issuer_30},
+		{&data.Other_Provider_Identifier_31, &data.Other_Provider_Identifier_Type_Code_31, &data.Other_Provider_Identifier_State_31, &data.Other_Provider_Identifier_Issuer_31},
+		{&data.Other_Provider_Identifier_32, &data.Other_Provider_Identifier_Type_Code_32, &data.Other_Provider_Identifier_State_32, &data.Other_Provider_Identifier_Issuer_32},
+		{&data.Other_Provider_Identifier_33, &data.Other_Provider_Identifier_Type_Code_33, &data.Other_Provider_Identifier_State_33, &data.Other_Provider_Identifier_Issuer_33},
+		{&data.Other_Provider_Identifier_34, &data.Other_Provider_Identifier_Type_Code_34, &data.Other_Provider_Identifier_State_34, &data.Other_Provider_Identifier_Issuer_34},
+		{&data.Other_Provider_Identifier_35, &data.Other_Provider_Identifier_Type_Code_35, &data.Other_Provider_Identifier_State_35, &data.Other_Provider_Identifier_Issuer_35},
+		{&data.Other_Provider_Identifier_36, &data.Other_Provider_Identifier_Type_Code_36, &data.Other_Provider_Identifier_State_36, &data.Other_Provider_Identifier_Issuer_36},
+		{&data.Other_Provider_Identifier_37, &data.Other_Provider_Identifier_Type_Code_37, &data.Other_Provider_Identifier_State_37, &data.Other_Provider_Identifier_Issuer_37},
+		{&data.Other_Provider_Identifier_38, &data.Other_Provider_Identifier_Type_Code_38, &data.Other_Provider_Identifier_State_38, &data.Other_Provider_Identifier_Issuer_38},
+		{&data.Other_Provider_Identifier_39, &data.Other_Provider_Identifier_Type_Code_39, &data.Other_Provider_Identifier_State_39, &data.Other_Provider_Identifier_Issuer_39},
+		{&data.Other_Provider_Identifier_40, &data.Other_Provider_Identifier_Type_Code_40, &data.Other_Provider_Identifier_State_40, &data.Other_Provider_Identifier_Issuer_40},
+		{&data.Other_Provider_Identifier_41, &data.Other_Provider_Identifier_Type_Code_41, &data.Other_Provider_Identifier_State_41, &data.Other_Provider_Identifier_Issuer_41},
+		{&data.Other_Provider_Identifier_42, &data.Other_Provider_Identifier_Type_Code_42, &data.Other_Provider_Identifier_State_42, &data.Other_Provider_Identifier_Issuer_42},
+		{&data.Other_Provider_Identifier_43, &data.Other_Provider_Identifier_Type_Code_43, &data.Other_Provider_Identifier_State_43, &data.Other_Provider_Identifier_Issuer_43},
+		{&data.Other_Provider_Identifier_44, &data.Other_Provider_Identifier_Type_Code_44, &data.Other_Provider_Identifier_State_44, &data.Other_Provider_Identifier_Issuer_44},
+		{&data.Other_Provider_Identifier_45, &data.Other_Provider_Identifier_Type_Code_45, &data.Other_Provider_Identifier_State_45, &data.Other_Provider_Identifier_Issuer_45},
+		{&data.Other_Provider_Identifier_46, &data.Other_Provider_Identifier_Type_Code_46, &data.Other_Provider_Identifier_State_46, &data.Other_Provider_Identifier_Issuer_46},
+		{&data.Other_Provider_Identifier_47, &data.Other_Provider_Identifier_Type_Code_47, &data.Other_Provider_Identifier_State_47, &data.Other_Provider_Identifier_Issuer_47},
+		{&data.Other_Provider_Identifier_48, &data.Other_Provider_Identifier_Type_Code_48, &data.Other_Provider_Identifier_State_48, &data.Other_Provider_Identifier_Issuer_48},
+		{&data.Other_Provider_Identifier_49, &data.Other_Provider_Identifier_Type_Code_49, &data.Other_Provider_Identifier_State_49, &data.Other_Provider_Identifier_Issuer_49},
+		{&data.Other_Provider_Identifier_50, &data.Other_Provider_Identifier_Type_Code_50, &data.Other_Provider_Identifier_State_50, &data.Other_Provider_Identifier_Issuer_50},
+	}
+}
+
 func buildNPIOrgFromNPICsvLine(data NPICsvLine) (*endpointmanager.NPIOrganization, error) {
 	normalizedName, err := endpointlinker.NormalizeOrgName(data.Provider_Organization_Name_Legal_Business_Name)
 	if err != nil {
@@ -383,9 +498,52 @@ func buildNPIOrgFromNPICsvLine(data NPICsvLine) (*endpointmanager.NPIOrganizatio
 			City:     data.Provider_Business_Practice_Location_Address_City_Name,
 			State:    data.Provider_Business_Practice_Location_Address_State_Name,
 			ZipCode:  data.Provider_Business_Practice_Location_Address_Postal_Code},
-		Taxonomy:                data.Healthcare_Provider_Taxonomy_Code_1,
+		MailingLocation: &endpointmanager.Location{
+			Address1: data.Provider_First_Line_Business_Mailing_Address,
+			Address2: data.Provider_Second_Line_Business_Mailing_Address,
+			City:     data.Provider_Business_Mailing_Address_City_Name,
+			State:    data.Provider_Business_Mailing_Address_State_Name,
+			ZipCode:  data.Provider_Business_Mailing_Address_Postal_Code},
+		Taxonomy: data.Healthcare_Provider_Taxonomy_Code_1,
+		AuthorizedOfficial: &endpointmanager.NPIAuthorizedOfficial{
+			NamePrefix: data.Authorized_Official_Name_Prefix_Text,
+			FirstName:  data.Authorized_Official_First_Name,
+			MiddleName: data.Authorized_Official_Middle_Name,
+			LastName:   data.Authorized_Official_Last_Name,
+			NameSuffix: data.Authorized_Official_Name_Suffix_Text,
+			Credential: data.Authorized_Official_Credential_Text,
+			Title:      data.Authorized_Official_Title_or_Position,
+			Telephone:  data.Authorized_Official_Telephone_Number},
 		NormalizedName:          normalizedName,
 		NormalizedSecondaryName: normalizedSecondary}
+
+	for _, columns := range npiTaxonomyColumns(&data) {
+		if *columns[0] == "" {
+			continue
+		}
+		taxonomy := endpointmanager.NPITaxonomy{
+			Code:          *columns[0],
+			LicenseNumber: *columns[1],
+			LicenseState:  *columns[2],
+			Primary:       *columns[3] == "Y",
+		}
+		// the organization's taxonomy is the primary taxonomy, or the first taxonomy if none are primary
+		if taxonomy.Primary {
+			npiOrg.Taxonomy = taxonomy.Code
+		}
+		npiOrg.Taxonomies = append(npiOrg.Taxonomies, taxonomy)
+	}
+	for _, columns := range npiIdentifierColumns(&data) {
+		if *columns[0] == "" {
+			continue
+		}
+		npiOrg.Identifiers = append(npiOrg.Identifiers, endpointmanager.NPIIdentifier{
+			Identifier: *columns[0],
+			TypeCode:   *columns[1],
+			State:      *columns[2],
+			Issuer:     *columns[3],
+		})
+	}
 	return npiOrg, nil
 }
 
@@ -409,62 +567,269 @@ func csvReader(ctx context.Context, filename string) (*csv.Reader, *os.File, err
 	return reader, f, nil
 }
 
-// ParseAndStoreNPIFile parses NPI Org data out of fname, writes it to store and returns the number of organizations processed
+// NPILoadReport summarizes how an NPPES file changed the stored NPI organizations. Rejected is the number of rows
+// in the file that could not be parsed.
+type NPILoadReport struct {
+	postgresql.NPIOrganizationLoadSummary
+	Rejected int
+}
+
+// dates in the NPPES files are formatted as MM/DD/YYYY
+const nppesDateLayout = "01/02/2006"
+
+// minimum number of columns parseNPIdataLine needs. The other identifier and later columns are optional.
+const npiDataLineLength = npiTaxonomyStartIndex + 4*15
+
+// ParseAndStoreNPIFile parses NPI Org data out of fname, writes it to store and returns the number of organizations
+// added or updated
 func ParseAndStoreNPIFile(ctx context.Context, fname string, store *postgresql.Store) (int, error) {
+	report, err := LoadNPIFile(ctx, fname, store)
+	if err != nil {
+		return -1, err
+	}
+	return report.Added + report.Updated, nil
+}
+
+// LoadNPIFile loads the NPI organizations in fname into store using a single bulk load. fname may be the full
+// monthly NPPES file or one of the weekly incremental files, which have the same format. Organizations that are
+// new are added, organizations that changed are updated, and NPIs that NPPES reports as deactivated or reactivated
+// are marked as such. Rows that cannot be parsed are rejected and logged. Nothing is changed if an error is returned.
+func LoadNPIFile(ctx context.Context, fname string, store *postgresql.Store) (NPILoadReport, error) {
+	var report NPILoadReport
+
 	// Provider organization .csv downloaded from http://download.cms.gov/nppes/NPI_Files.html
 	reader, f, err := csvReader(ctx, fname)
 	if err != nil {
-		return -1, err
+		return report, err
 	}
 	defer f.Close()
 
 	//Remove header
 	_, err = reader.Read()
 	if err != nil {
-		return -1, err
+		return report, err
+	}
+
+	load, err := store.BeginNPIOrganizationLoad(ctx)
+	if err != nil {
+		return report, err
 	}
 
-	added := 0
 	i := 0
 	// Loop through lines & turn into object
 	for {
-
 		line, err := reader.Read()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return -1, err
+			_ = load.Rollback()
+			return report, err
 		}
 
 		// break out of loop and return error if context has ended
 		select {
 		case <-ctx.Done():
-			return added, errors.Wrapf(ctx.Err(), "read %d lines of the csv file before the context ended", i)
+			_ = load.Rollback()
+			return report, errors.Wrapf(ctx.Err(), "read %d lines of the csv file before the context ended", i)
 		default:
 			// ok
 		}
 
 		if i%10000 == 0 {
-			log.Infof("Processed %d NPI entities. Added %d organizations.\n", i, added)
+			log.Infof("Processed %d NPI entities. Rejected %d.\n", i, report.Rejected)
 		}
+		i++
 
-		data := parseNPIdataLine(line)
-		// We will only parse out organizations (entiy_type_code == 2), not individual providers
-		if data.Entity_Type_Code == "2" {
-			npiOrg, err := buildNPIOrgFromNPICsvLine(data)
-			if err != nil {
-				log.Debug(err)
-				continue
-			}
-			err = store.AddNPIOrganization(ctx, npiOrg)
-			if err != nil {
-				log.Debug(err)
-				continue
-			}
-			added += 1
+		npiLine, err := parseNPILoadLine(line)
+		if err != nil {
+			log.Warnf("rejected line %d of %s: %s", i, fname, err)
+			report.Rejected++
+			continue
+		}
+		err = npiLine.addTo(ctx, load)
+		if err != nil {
+			_ = load.Rollback()
+			return report, errors.Wrapf(err, "loading line %d of %s failed", i, fname)
+		}
+	}
+
+	summary, err := commitLoad(ctx, load)
+	if err != nil {
+		return report, err
+	}
+	report.NPIOrganizationLoadSummary = summary
+	return report, nil
+}
+
+// LoadNPIDeactivationFile marks the NPIs in the NPPES deactivation file fname as deactivated. The deactivation
+// report is published by NPPES as an Excel file and must be saved as a csv file with the NPI in the first column
+// and the deactivation date in the second column. Rows that cannot be parsed are rejected and logged. Nothing is
+// changed if an error is returned.
+func LoadNPIDeactivationFile(ctx context.Context, fname string, store *postgresql.Store) (NPILoadReport, error) {
+	var report NPILoadReport
+
+	reader, f, err := csvReader(ctx, fname)
+	if err != nil {
+		return report, err
+	}
+	defer f.Close()
+	reader.FieldsPerRecord = -1
+
+	//Remove header
+	_, err = reader.Read()
+	if err != nil {
+		return report, err
+	}
+
+	load, err := store.BeginNPIOrganizationLoad(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	i := 0
+	for {
+		line, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			_ = load.Rollback()
+			return report, err
+		}
+
+		select {
+		case <-ctx.Done():
+			_ = load.Rollback()
+			return report, errors.Wrapf(ctx.Err(), "read %d lines of the csv file before the context ended", i)
+		default:
+			// ok
 		}
 		i++
+
+		npiLine, err := parseNPIDeactivationLine(line)
+		if err != nil {
+			log.Warnf("rejected line %d of %s: %s", i, fname, err)
+			report.Rejected++
+			continue
+		}
+		err = npiLine.addTo(ctx, load)
+		if err != nil {
+			_ = load.Rollback()
+			return report, errors.Wrapf(err, "loading line %d of %s failed", i, fname)
+		}
+	}
+
+	summary, err := commitLoad(ctx, load)
+	if err != nil {
+		return report, err
+	}
+	report.NPIOrganizationLoadSummary = summary
+	return report, nil
+}
+
+// npiLoadLine is a line of an NPPES file that has been parsed and can be added to a load
+type npiLoadLine struct {
+	npiID        string
+	org          *endpointmanager.NPIOrganization // nil if the line only deactivates the NPI
+	lastUpdate   time.Time
+	deactivation time.Time
+	reactivation time.Time
+}
+
+// addTo adds the organization or deactivation to the load. A nil line, such as an individual provider's, adds
+// nothing.
+func (l *npiLoadLine) addTo(ctx context.Context, load *postgresql.NPIOrganizationLoad) error {
+	if l == nil {
+		return nil
+	}
+	if l.org == nil {
+		return load.AddDeactivation(ctx, l.npiID, l.deactivation)
+	}
+	return load.AddOrganization(ctx, l.org, l.lastUpdate, l.deactivation, l.reactivation)
+}
+
+// parseNPILoadLine parses the organization in line. Individual providers (entity_type_code == 1) are skipped and
+// returned as nil. NPPES removes all information except for the NPI and the deactivation date from the rows of
+// deactivated NPIs, so rows without an entity type and with a deactivation date are parsed as deactivations.
+func parseNPILoadLine(line []string) (*npiLoadLine, error) {
+	if len(line) < npiDataLineLength {
+		return nil, errors.Errorf("expected at least %d columns, got %d", npiDataLineLength, len(line))
+	}
+	data := parseNPIdataLine(line)
+	if data.NPI == "" {
+		return nil, errors.New("missing NPI")
+	}
+
+	deactivation, err := parseNPPESDate(data.NPI_Deactivation_Date)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid deactivation date for NPI %s", data.NPI)
+	}
+
+	switch data.Entity_Type_Code {
+	case "2":
+		lastUpdate, err := parseNPPESDate(data.Last_Update_Date)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid last update date for NPI %s", data.NPI)
+		}
+		reactivation, err := parseNPPESDate(data.NPI_Reactivation_Date)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid reactivation date for NPI %s", data.NPI)
+		}
+		npiOrg, err := buildNPIOrgFromNPICsvLine(data)
+		if err != nil {
+			return nil, err
+		}
+		return &npiLoadLine{
+			npiID:        data.NPI,
+			org:          npiOrg,
+			lastUpdate:   lastUpdate,
+			deactivation: deactivation,
+			reactivation: reactivation,
+		}, nil
+	case "":
+		if deactivation.IsZero() {
+			return nil, errors.Errorf("missing entity type for NPI %s", data.NPI)
+		}
+		return &npiLoadLine{npiID: data.NPI, deactivation: deactivation}, nil
+	}
+	// individual providers are not loaded
+	return nil, nil
+}
+
+func parseNPIDeactivationLine(line []string) (*npiLoadLine, error) {
+	if len(line) < 2 {
+		return nil, errors.Errorf("expected at least 2 columns, got %d", len(line))
+	}
+	npiID := strings.TrimSpace(line[0])
+	if npiID == "" {
+		return nil, errors.New("missing NPI")
+	}
+	deactivation, err := parseNPPESDate(strings.TrimSpace(line[1]))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid deactivation date for NPI %s", npiID)
+	}
+	if deactivation.IsZero() {
+		return nil, errors.Errorf("missing deactivation date for NPI %s", npiID)
+	}
+	return &npiLoadLine{npiID: npiID, deactivation: deactivation}, nil
+}
+
+func commitLoad(ctx context.Context, load *postgresql.NPIOrganizationLoad) (postgresql.NPIOrganizationLoadSummary, error) {
+	select {
+	case <-ctx.Done():
+		_ = load.Rollback()
+		return postgresql.NPIOrganizationLoadSummary{}, errors.Wrap(ctx.Err(), "did not store NPI organizations; context ended")
+	default:
+		// ok
+	}
+	return load.Commit(ctx)
+}
+
+// parseNPPESDate returns the zero time for an empty date
+func parseNPPESDate(date string) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
 	}
-	return added, nil
+	return time.Parse(nppesDateLayout, date)
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
 	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
 	"github.com/pkg/errors"
 )
@@ -73,6 +75,14 @@ func Test_ParseNPIdataLine(t *testing.T) {
 	if data.Healthcare_Provider_Taxonomy_Code_1 != "251G00000X" {
 		t.Errorf("Expected Name to be %s, got %s", "251G00000X", data.Healthcare_Provider_Taxonomy_Code_1)
 	}
+	// Last_Update_Date
+	if data.Last_Update_Date != "09/26/2011" {
+		t.Errorf("Expected Last Update Date to be %s, got %s", "09/26/2011", data.Last_Update_Date)
+	}
+	// NPI_Deactivation_Date
+	if data.NPI_Deactivation_Date != "" {
+		t.Errorf("Expected Deactivation Date to be %s, got %s", "", data.NPI_Deactivation_Date)
+	}
 }
 
 func Test_csvReaderContextCancel(t *testing.T) {
@@ -149,4 +159,113 @@ func Test_BuildNPIOrgFromNPICsvLine(t *testing.T) {
 	if npi_org.Taxonomy != "251G00000X" {
 		t.Errorf("Expected Name to be %s, got %s", "251G00000X", npi_org.Taxonomy)
 	}
+	// MailingLocation
+	expectedMailing := &endpointmanager.Location{Address1: "3418 VILLAGE DR", City: "FAYETTEVILLE", State: "NC", ZipCode: "283044552"}
+	if !npi_org.MailingLocation.Equal(expectedMailing) {
+		t.Errorf("Expected MailingLocation to be %+v, got %+v", expectedMailing, npi_org.MailingLocation)
+	}
+	// Taxonomies
+	expectedTaxonomies := []endpointmanager.NPITaxonomy{{Code: "251G00000X", LicenseNumber: "HC0283", LicenseState: "NC", Primary: true}}
+	th.Assert(t, len(npi_org.Taxonomies) == 1, fmt.Sprintf("Expected 1 taxonomy, got %d", len(npi_org.Taxonomies)))
+	th.Assert(t, npi_org.Taxonomies[0].Equal(expectedTaxonomies[0]), fmt.Sprintf("Expected taxonomy %+v, got %+v", expectedTaxonomies[0], npi_org.Taxonomies[0]))
+	// Identifiers
+	expectedIdentifier := endpointmanager.NPIIdentifier{Identifier: "3401562", TypeCode: "05", State: "NC"}
+	th.Assert(t, len(npi_org.Identifiers) == 1, fmt.Sprintf("Expected 1 identifier, got %d", len(npi_org.Identifiers)))
+	th.Assert(t, npi_org.Identifiers[0] == expectedIdentifier, fmt.Sprintf("Expected identifier %+v, got %+v", expectedIdentifier, npi_org.Identifiers[0]))
+	// AuthorizedOfficial
+	expectedOfficial := &endpointmanager.NPIAuthorizedOfficial{
+		NamePrefix: "MR.",
+		FirstName:  "MICHAEL",
+		LastName:   "NAGOWSKI",
+		Title:      "CEO",
+		Telephone:  "9106096700"}
+	th.Assert(t, npi_org.AuthorizedOfficial.Equal(expectedOfficial), fmt.Sprintf("Expected authorized official %+v, got %+v", expectedOfficial, npi_org.AuthorizedOfficial))
+}
+
+func Test_BuildNPIOrgFromNPICsvLinePrimaryTaxonomy(t *testing.T) {
+	line := make([]string, npiAuthorizedOfficialCredentialIndex+1)
+	line[0] = "1023011079"
+	line[1] = "2"
+	line[4] = "ADVANTAGE HOME HEALTH CARE, INC."
+	// the second taxonomy is the primary taxonomy
+	copy(line[npiTaxonomyStartIndex:], []string{"251E00000X", "1008614", "IL", "N", "282N00000X", "1011673", "IL", "Y"})
+
+	npi_org, err := buildNPIOrgFromNPICsvLine(parseNPIdataLine(line))
+	th.Assert(t, err == nil, err)
+	th.Assert(t, npi_org.Taxonomy == "282N00000X", fmt.Sprintf("Expected the primary taxonomy 282N00000X, got %s", npi_org.Taxonomy))
+	th.Assert(t, len(npi_org.Taxonomies) == 2, fmt.Sprintf("Expected 2 taxonomies, got %d", len(npi_org.Taxonomies)))
+	th.Assert(t, !npi_org.Taxonomies[0].Primary && npi_org.Taxonomies[1].Primary, "Expected only the second taxonomy to be primary")
+	th.Assert(t, len(npi_org.Identifiers) == 0, fmt.Sprintf("Expected no identifiers, got %d", len(npi_org.Identifiers)))
+}
+
+func Test_parseNPPESDate(t *testing.T) {
+	date, err := parseNPPESDate("09/26/2011")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, date.Equal(time.Date(2011, time.September, 26, 0, 0, 0, 0, time.UTC)), fmt.Sprintf("Expected 2011-09-26, got %s", date))
+
+	date, err = parseNPPESDate("")
+	th.Assert(t, err == nil, err)
+	th.Assert(t, date.IsZero(), "Expected an empty date to be the zero time")
+
+	_, err = parseNPPESDate("2011-09-26")
+	th.Assert(t, err != nil, "Expected an error for a date that is not formatted as MM/DD/YYYY")
+}
+
+func Test_parseNPILoadLine(t *testing.T) {
+	line := make([]string, npiDataLineLength)
+	line[0] = "1497758544"
+
+	// lines that are rejected before they are added to the load
+	_, err := parseNPILoadLine(line[:10])
+	th.Assert(t, err != nil, "Expected a line that is too short to be rejected")
+
+	noNPI := make([]string, npiDataLineLength)
+	noNPI[1] = "2"
+	_, err = parseNPILoadLine(noNPI)
+	th.Assert(t, err != nil, "Expected a line without an NPI to be rejected")
+
+	line[1] = ""
+	_, err = parseNPILoadLine(line)
+	th.Assert(t, err != nil, "Expected a line without an entity type or deactivation date to be rejected")
+
+	line[1] = "2"
+	line[37] = "2011-09-26"
+	_, err = parseNPILoadLine(line)
+	th.Assert(t, err != nil, "Expected a line with an invalid last update date to be rejected")
+
+	// organizations and deactivations are parsed
+	line[37] = "09/26/2011"
+	npiLine, err := parseNPILoadLine(line)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, npiLine != nil && npiLine.org != nil, "Expected the organization to be parsed")
+	th.Assert(t, npiLine.lastUpdate.Equal(time.Date(2011, time.September, 26, 0, 0, 0, 0, time.UTC)), fmt.Sprintf("Expected the last update date to be parsed, got %s", npiLine.lastUpdate))
+
+	deactivated := make([]string, npiDataLineLength)
+	deactivated[0] = "1023011178"
+	deactivated[39] = "03/05/2020"
+	npiLine, err = parseNPILoadLine(deactivated)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, npiLine != nil && npiLine.org == nil, "Expected a line without an entity type to be parsed as a deactivation")
+	th.Assert(t, npiLine.deactivation.Equal(time.Date(2020, time.March, 5, 0, 0, 0, 0, time.UTC)), fmt.Sprintf("Expected the deactivation date to be parsed, got %s", npiLine.deactivation))
+
+	// individual providers are skipped
+	line[1] = "1"
+	npiLine, err = parseNPILoadLine(line)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, npiLine == nil, "Expected an individual provider to be skipped")
+	err = npiLine.addTo(context.Background(), nil)
+	th.Assert(t, err == nil, err)
+}
+
+func Test_parseNPIDeactivationLine(t *testing.T) {
+	_, err := parseNPIDeactivationLine([]string{"1497758544"})
+	th.Assert(t, err != nil, "Expected a deactivation line without a date to be rejected")
+	_, err = parseNPIDeactivationLine([]string{"1497758544", ""})
+	th.Assert(t, err != nil, "Expected a deactivation line with an empty date to be rejected")
+	_, err = parseNPIDeactivationLine([]string{"1497758544", "not a date"})
+	th.Assert(t, err != nil, "Expected a deactivation line with an invalid date to be rejected")
+
+	npiLine, err := parseNPIDeactivationLine([]string{" 1497758544 ", "03/05/2020"})
+	th.Assert(t, err == nil, err)
+	th.Assert(t, npiLine.npiID == "1497758544" && npiLine.org == nil, fmt.Sprintf("Expected a deactivation of 1497758544, got %+v", npiLine))
 }
@@ -0,0 +1,217 @@
+package nppesquerier
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/fetcher"
+	endptQuerier "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/fhirendpointquerier"
+)
+
+// ContactReconciliation compares the FHIR endpoint URLs that organizations report to NPPES with the endpoints
+// from the endpoint lists. Endpoints that were added from the NPPES contact file are not considered part of the
+// endpoint lists.
+type ContactReconciliation struct {
+	// MissingFromLists are the NPPES contacts whose URL is not in any endpoint list
+	MissingFromLists []*endpointmanager.NPIContact
+	// WithoutNPPESContact are the list endpoints whose URL no NPPES contact reports
+	WithoutNPPESContact []*endpointmanager.FHIREndpoint
+	// OrganizationMismatches are the URLs in both where the list endpoints do not name the NPPES contact's organization
+	OrganizationMismatches []ContactOrganizationMismatch
+}
+
+// ContactOrganizationMismatch is an NPPES contact whose organization is neither among the NPI IDs nor the
+// organization names of the list endpoints with the same URL.
+type ContactOrganizationMismatch struct {
+	Contact   *endpointmanager.NPIContact
+	Endpoints []*endpointmanager.FHIREndpoint
+}
+
+// NormalizeContactURL returns the form of a FHIR endpoint URL used to compare NPPES contacts with list
+// endpoints. The scheme and host are lowercased, https:// is added when there is no scheme, and the
+// /metadata suffix and trailing slashes are removed.
+func NormalizeContactURL(rawURL string) string {
+	normalized := strings.TrimSpace(rawURL)
+	for _, scheme := range []string{"https://", "http://"} {
+		if strings.HasPrefix(strings.ToLower(normalized), scheme) {
+			normalized = scheme + normalized[len(scheme):]
+		}
+	}
+	normalized = endpointmanager.NormalizeURL(normalized)
+	parsed, err := url.Parse(normalized)
+	if err == nil {
+		parsed.Host = strings.ToLower(parsed.Host)
+		normalized = parsed.String()
+	}
+	normalized = strings.TrimRight(normalized, "/")
+	normalized = strings.TrimSuffix(normalized, "/metadata")
+	return strings.TrimRight(normalized, "/")
+}
+
+// ReconcileNPIContacts compares the contacts with valid URLs against the endpoints that do not have the NPPES
+// list source. The results are ordered by URL.
+func ReconcileNPIContacts(contacts []*endpointmanager.NPIContact, endpoints []*endpointmanager.FHIREndpoint) *ContactReconciliation {
+	listEndpoints := make(map[string][]*endpointmanager.FHIREndpoint)
+	for _, endpoint := range endpoints {
+		if endpoint.ListSource == NPPESListSource {
+			continue
+		}
+		normalized := NormalizeContactURL(endpoint.URL)
+		listEndpoints[normalized] = append(listEndpoints[normalized], endpoint)
+	}
+
+	reconciliation := &ContactReconciliation{}
+	contactURLs := make(map[string]bool)
+	for _, contact := range contacts {
+		if !contact.ValidURL {
+			continue
+		}
+		normalized := NormalizeContactURL(contact.Endpoint)
+		contactURLs[normalized] = true
+		matches, ok := listEndpoints[normalized]
+		if !ok {
+			reconciliation.MissingFromLists = append(reconciliation.MissingFromLists, contact)
+		} else if !contactOrganizationListed(contact, matches) {
+			reconciliation.OrganizationMismatches = append(reconciliation.OrganizationMismatches,
+				ContactOrganizationMismatch{Contact: contact, Endpoints: matches})
+		}
+	}
+
+	for normalized, matches := range listEndpoints {
+		if !contactURLs[normalized] {
+			reconciliation.WithoutNPPESContact = append(reconciliation.WithoutNPPESContact, matches...)
+		}
+	}
+
+	sort.SliceStable(reconciliation.MissingFromLists, func(i, j int) bool {
+		return NormalizeContactURL(reconciliation.MissingFromLists[i].Endpoint) < NormalizeContactURL(reconciliation.MissingFromLists[j].Endpoint)
+	})
+	sort.SliceStable(reconciliation.WithoutNPPESContact, func(i, j int) bool {
+		a, b := reconciliation.WithoutNPPESContact[i], reconciliation.WithoutNPPESContact[j]
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		return a.ListSource < b.ListSource
+	})
+	sort.SliceStable(reconciliation.OrganizationMismatches, func(i, j int) bool {
+		return NormalizeContactURL(reconciliation.OrganizationMismatches[i].Contact.Endpoint) < NormalizeContactURL(reconciliation.OrganizationMismatches[j].Contact.Endpoint)
+	})
+
+	return reconciliation
+}
+
+// contactOrganizationListed returns true if any of the endpoints has the contact's NPI ID or legal business
+// name. Contacts without either can not be compared and are considered listed.
+func contactOrganizationListed(contact *endpointmanager.NPIContact, endpoints []*endpointmanager.FHIREndpoint) bool {
+	name := strings.TrimSpace(contact.AffiliationLegalBusinessName)
+	if contact.NPI_ID == "" && name == "" {
+		return true
+	}
+	for _, endpoint := range endpoints {
+		for _, npiID := range endpoint.NPIIDs {
+			if contact.NPI_ID != "" && npiID == contact.NPI_ID {
+				return true
+			}
+		}
+		for _, orgName := range endpoint.OrganizationNames {
+			if name != "" && strings.EqualFold(strings.TrimSpace(orgName), name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReconcileNPIContactsWithStore reconciles the stored NPI contacts with the stored FHIR endpoints. When
+// importMissing is true, the contacts that are missing from the endpoint lists are saved as the endpoint list of
+// the NPPES list source, and the number of contacts imported is returned. Like any other endpoint list, the NPPES
+// endpoints that are no longer missing from the lists, or that NPPES no longer reports, are removed.
+func ReconcileNPIContactsWithStore(ctx context.Context, store ContactStore, importMissing bool) (*ContactReconciliation, int, error) {
+	contacts, err := store.GetAllNPIContacts(ctx)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "getting npi contacts from store failed")
+	}
+	endpoints, err := store.GetAllFHIREndpoints(ctx)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "getting fhir endpoints from store failed")
+	}
+
+	reconciliation := ReconcileNPIContacts(contacts, endpoints)
+	log.Infof("%d NPPES endpoints are missing from the endpoint lists, %d list endpoints have no NPPES contact and %d have a different organization",
+		len(reconciliation.MissingFromLists), len(reconciliation.WithoutNPPESContact), len(reconciliation.OrganizationMismatches))
+	if !importMissing {
+		return reconciliation, 0, nil
+	}
+
+	var nppesList fetcher.ListOfEndpoints
+	for _, contact := range reconciliation.MissingFromLists {
+		nppesList.Entries = append(nppesList.Entries, endpointEntryFromNPIContact(contact))
+	}
+	if len(nppesList.Entries) == 0 {
+		// AddEndpointData ignores an empty list, so the NPPES endpoints are all removed here
+		err = endptQuerier.RemoveOldEndpoints(ctx, store, time.Now(), NPPESListSource)
+	} else {
+		err = endptQuerier.AddEndpointData(ctx, store, &nppesList)
+	}
+	if err != nil {
+		return reconciliation, 0, errors.Wrap(err, "importing the NPPES endpoints failed")
+	}
+	return reconciliation, len(nppesList.Entries), nil
+}
+
+// endpointEntryFromNPIContact returns the endpoint list entry for the contact's URL with the NPPES list source
+func endpointEntryFromNPIContact(contact *endpointmanager.NPIContact) fetcher.EndpointEntry {
+	endpoint := buildFHIREndpointFromNPIContact(contact)
+	return fetcher.EndpointEntry{
+		OrganizationNames:    endpoint.OrganizationNames,
+		NPIIDs:               endpoint.NPIIDs,
+		FHIRPatientFacingURI: endpoint.URL,
+		ListSource:           endpoint.ListSource,
+		Locations:            endpoint.Locations,
+	}
+}
+
+// WriteCSV writes one row for each contact or endpoint in the reconciliation with the columns category, url,
+// npi_id, nppes_organization, list_organizations and list_source.
+func (r *ContactReconciliation) WriteCSV(out io.Writer) error {
+	w := csv.NewWriter(out)
+	err := w.Write([]string{"category", "url", "npi_id", "nppes_organization", "list_organizations", "list_source"})
+	if err != nil {
+		return err
+	}
+	for _, contact := range r.MissingFromLists {
+		err = w.Write([]string{"missing_from_lists", contact.Endpoint, contact.NPI_ID, contact.AffiliationLegalBusinessName, "", ""})
+		if err != nil {
+			return err
+		}
+	}
+	for _, endpoint := range r.WithoutNPPESContact {
+		err = w.Write([]string{"no_nppes_contact", endpoint.URL, strings.Join(endpoint.NPIIDs, ";"), "", strings.Join(endpoint.OrganizationNames, ";"), endpoint.ListSource})
+		if err != nil {
+			return err
+		}
+	}
+	for _, mismatch := range r.OrganizationMismatches {
+		var names, sources []string
+		for _, endpoint := range mismatch.Endpoints {
+			names = append(names, endpoint.OrganizationNames...)
+			sources = append(sources, endpoint.ListSource)
+		}
+		err = w.Write([]string{"organization_mismatch", mismatch.Contact.Endpoint, mismatch.Contact.NPI_ID, mismatch.Contact.AffiliationLegalBusinessName, strings.Join(names, ";"), strings.Join(sources, ";")})
+		if err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
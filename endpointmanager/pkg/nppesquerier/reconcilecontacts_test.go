@@ -0,0 +1,118 @@
+package nppesquerier
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/memorystore"
+	th "github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/testhelper"
+)
+
+func Test_NormalizeContactURL(t *testing.T) {
+	expected := "https://fhir.example.com/api/R4"
+	urls := []string{
+		"https://fhir.example.com/api/R4",
+		"https://FHIR.Example.com/api/R4/",
+		"HTTPS://fhir.example.com/api/R4/metadata",
+		"fhir.example.com/api/R4/metadata/",
+		" https://fhir.example.com/api/R4 ",
+	}
+	for _, url := range urls {
+		normalized := NormalizeContactURL(url)
+		th.Assert(t, normalized == expected, fmt.Sprintf("expected %s to normalize to %s. Got %s", url, expected, normalized))
+	}
+
+	// the path is case sensitive
+	th.Assert(t, NormalizeContactURL("https://fhir.example.com/API/R4") != expected, "expected the path to keep its case")
+}
+
+func Test_ReconcileNPIContacts(t *testing.T) {
+	contacts := []*endpointmanager.NPIContact{
+		{NPI_ID: "1", Endpoint: "https://only.nppes.com/fhir", ValidURL: true, AffiliationLegalBusinessName: "Only NPPES"},
+		{NPI_ID: "2", Endpoint: "https://Both.com/fhir/", ValidURL: true, AffiliationLegalBusinessName: "both org"},
+		{NPI_ID: "3", Endpoint: "https://mismatch.com/fhir", ValidURL: true, AffiliationLegalBusinessName: "Other Org"},
+		{NPI_ID: "4", Endpoint: "https://npi.com/fhir", ValidURL: true, AffiliationLegalBusinessName: "Other Org"},
+		{NPI_ID: "5", Endpoint: "not a url", ValidURL: false},
+	}
+	endpoints := []*endpointmanager.FHIREndpoint{
+		{URL: "https://both.com/fhir/metadata", OrganizationNames: []string{"Both Org"}, ListSource: "Cerner"},
+		{URL: "https://mismatch.com/fhir", OrganizationNames: []string{"Listed Org"}, ListSource: "Epic"},
+		{URL: "https://npi.com/fhir", NPIIDs: []string{"4"}, ListSource: "Epic"},
+		{URL: "https://only.list.com/fhir", OrganizationNames: []string{"Only List"}, ListSource: "Epic"},
+		// endpoints from NPPES are not part of the lists
+		{URL: "https://only.nppes.com/fhir", OrganizationNames: []string{"Only NPPES"}, ListSource: NPPESListSource},
+	}
+
+	reconciliation := ReconcileNPIContacts(contacts, endpoints)
+
+	th.Assert(t, len(reconciliation.MissingFromLists) == 1, fmt.Sprintf("expected 1 contact missing from the lists. Got %d", len(reconciliation.MissingFromLists)))
+	th.Assert(t, reconciliation.MissingFromLists[0].NPI_ID == "1", "expected the NPPES only contact to be missing from the lists")
+
+	th.Assert(t, len(reconciliation.WithoutNPPESContact) == 1, fmt.Sprintf("expected 1 endpoint without an NPPES contact. Got %d", len(reconciliation.WithoutNPPESContact)))
+	th.Assert(t, reconciliation.WithoutNPPESContact[0].URL == "https://only.list.com/fhir", "expected the list only endpoint to have no NPPES contact")
+
+	th.Assert(t, len(reconciliation.OrganizationMismatches) == 1, fmt.Sprintf("expected 1 organization mismatch. Got %d", len(reconciliation.OrganizationMismatches)))
+	mismatch := reconciliation.OrganizationMismatches[0]
+	th.Assert(t, mismatch.Contact.NPI_ID == "3", "expected the contact with a different organization name to be a mismatch")
+	th.Assert(t, len(mismatch.Endpoints) == 1 && mismatch.Endpoints[0].ListSource == "Epic", "expected the mismatch to include the list endpoint")
+
+	var out bytes.Buffer
+	err := reconciliation.WriteCSV(&out)
+	th.Assert(t, err == nil, err)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	th.Assert(t, len(lines) == 4, fmt.Sprintf("expected a header and 3 rows. Got %d lines", len(lines)))
+	th.Assert(t, lines[1] == "missing_from_lists,https://only.nppes.com/fhir,1,Only NPPES,,", fmt.Sprintf("unexpected row %s", lines[1]))
+	th.Assert(t, lines[2] == "no_nppes_contact,https://only.list.com/fhir,,,Only List,Epic", fmt.Sprintf("unexpected row %s", lines[2]))
+	th.Assert(t, lines[3] == "organization_mismatch,https://mismatch.com/fhir,3,Other Org,Listed Org,Epic", fmt.Sprintf("unexpected row %s", lines[3]))
+}
+
+func Test_ReconcileNPIContactsWithStoreRemovesStaleEndpoints(t *testing.T) {
+	ctx := context.Background()
+	store := memorystore.NewStore()
+
+	dropped := &endpointmanager.NPIContact{NPI_ID: "1", EndpointType: "FHIR", Endpoint: "https://dropped.example.com/fhir", ValidURL: true, AffiliationLegalBusinessName: "Dropped Org"}
+	listed := &endpointmanager.NPIContact{NPI_ID: "2", EndpointType: "FHIR", Endpoint: "https://listed.example.com/fhir", ValidURL: true, AffiliationLegalBusinessName: "Listed Org"}
+	kept := &endpointmanager.NPIContact{NPI_ID: "3", EndpointType: "FHIR", Endpoint: "https://kept.example.com/fhir", ValidURL: true, AffiliationLegalBusinessName: "Kept Org"}
+	for _, contact := range []*endpointmanager.NPIContact{dropped, listed, kept} {
+		err := store.AddNPIContact(ctx, contact)
+		th.Assert(t, err == nil, err)
+	}
+
+	_, imported, err := ReconcileNPIContactsWithStore(ctx, store, true)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, imported == 3, fmt.Sprintf("expected 3 endpoints to be imported. Got %d", imported))
+
+	// NPPES no longer reports the first contact and the second now shows up in an endpoint list
+	err = store.DeleteNPIContact(ctx, dropped)
+	th.Assert(t, err == nil, err)
+	err = store.AddFHIREndpoint(ctx, &endpointmanager.FHIREndpoint{URL: "https://listed.example.com/fhir/", OrganizationNames: []string{"Listed Org"}, ListSource: "Epic"})
+	th.Assert(t, err == nil, err)
+
+	_, imported, err = ReconcileNPIContactsWithStore(ctx, store, true)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, imported == 1, fmt.Sprintf("expected 1 endpoint to be imported. Got %d", imported))
+
+	_, err = store.GetFHIREndpointUsingURLAndListSource(ctx, "https://dropped.example.com/fhir/", NPPESListSource)
+	th.Assert(t, err == sql.ErrNoRows, "expected the endpoint that NPPES no longer reports to be removed")
+	_, err = store.GetFHIREndpointUsingURLAndListSource(ctx, "https://listed.example.com/fhir/", NPPESListSource)
+	th.Assert(t, err == sql.ErrNoRows, "expected the endpoint that is now in an endpoint list to be removed")
+	_, err = store.GetFHIREndpointUsingURLAndListSource(ctx, "https://listed.example.com/fhir/", "Epic")
+	th.Assert(t, err == nil, "expected the list endpoint to be kept")
+	_, err = store.GetFHIREndpointUsingURLAndListSource(ctx, "https://kept.example.com/fhir/", NPPESListSource)
+	th.Assert(t, err == nil, "expected the endpoint that is still missing from the lists to be kept")
+
+	// once every contact is in an endpoint list, no NPPES endpoints are left
+	err = store.AddFHIREndpoint(ctx, &endpointmanager.FHIREndpoint{URL: "https://kept.example.com/fhir/", OrganizationNames: []string{"Kept Org"}, ListSource: "Epic"})
+	th.Assert(t, err == nil, err)
+
+	_, imported, err = ReconcileNPIContactsWithStore(ctx, store, true)
+	th.Assert(t, err == nil, err)
+	th.Assert(t, imported == 0, fmt.Sprintf("expected no endpoints to be imported. Got %d", imported))
+	_, err = store.GetFHIREndpointUsingURLAndListSource(ctx, "https://kept.example.com/fhir/", NPPESListSource)
+	th.Assert(t, err == sql.ErrNoRows, "expected the last NPPES endpoint to be removed")
+}
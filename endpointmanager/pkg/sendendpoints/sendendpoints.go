@@ -6,7 +6,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager/postgresql"
+	"github.com/onc-healthit/lantern-back-end/endpointmanager/pkg/endpointmanager"
 	"github.com/onc-healthit/lantern-back-end/lanternmq"
 	"github.com/onc-healthit/lantern-back-end/lanternmq/pkg/accessqueue"
 	log "github.com/sirupsen/logrus"
@@ -19,7 +19,7 @@ func GetEnptsAndSend(
 	wg *sync.WaitGroup,
 	qName string,
 	qInterval int,
-	store *postgresql.Store,
+	store endpointmanager.EndpointStore,
 	mq *lanternmq.MessageQueue,
 	channelID *lanternmq.ChannelID,
 	errs chan<- error) {
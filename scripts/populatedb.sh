@@ -23,12 +23,22 @@ cd chplquerier
 go run main.go
 cd ..
 
+# get endpoints from the CHPL products' service base URL lists into db
+cd chplendpointharvester
+go run main.go
+cd ..
+
 # get NPPES contact (endpoint) pfile into db
 cd nppescontactpopulator
 go run main.go /etc/lantern/resources/endpoint_pfile.csv
 cd ..
 
 
+# get NUCC taxonomy code set into db
+cd nucctaxonomypopulator
+go run main.go /etc/lantern/resources/nucc_taxonomy.csv
+cd ..
+
 # get NPPES org pfile data into db
 cd nppesorgpopulator
 go run main.go /etc/lantern/resources/npidata_pfile.csv
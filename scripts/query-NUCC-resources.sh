@@ -0,0 +1,30 @@
+#!/bin/sh
+
+#update the NUCC health care provider taxonomy code set
+cd ../resources/prod_resources
+YEAR=$(date +%y)
+MONTH=$(date +%m | sed 's/^0//')
+
+# NUCC publishes a new version of the code set for January (version YY.0) and July (version YY.1)
+if [ "${MONTH}" -ge 7 ]
+then
+  VERSION="${YEAR}1"
+  PASTVERSION="${YEAR}0"
+else
+  PASTYEAR=$(date -v-1y +%y 2> /dev/null) || PASTYEAR=$(date -d '1 years ago' +%y)
+  VERSION="${YEAR}0"
+  PASTVERSION="${PASTYEAR}1"
+fi
+
+NUCCFILE="https://www.nucc.org/images/stories/CSV/nucc_taxonomy_${VERSION}.csv"
+PASTNUCCFILE="https://www.nucc.org/images/stories/CSV/nucc_taxonomy_${PASTVERSION}.csv"
+
+echo "Downloading NUCC taxonomy code set version ${VERSION}..."
+if curl -s -f -o temp_nucc_taxonomy.csv ${NUCCFILE} || (echo "NUCC taxonomy code set version ${VERSION} not available, downloading version ${PASTVERSION}..." && curl -s -f -o temp_nucc_taxonomy.csv ${PASTNUCCFILE})
+then
+  mv temp_nucc_taxonomy.csv nucc_taxonomy.csv
+  echo "done"
+else
+  rm -f temp_nucc_taxonomy.csv
+  echo "NUCC taxonomy code set not available, keeping the existing nucc_taxonomy.csv"
+fi